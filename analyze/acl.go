@@ -63,6 +63,12 @@ type aceSummary struct {
 	Trustee string   // SID of the account/group this ACE applies to
 	Mask    uint32   // Access mask containing the rights
 	Rights  []string // Human-readable names for the rights in this ACE
+	Flags   byte     // ACE header flags (inheritance)
+
+	// Object ACEs only: the property, property set or extended right the ACE is
+	// limited to, and the object class it is inherited by (empty if not present)
+	ObjectType          string
+	InheritedObjectType string
 }
 
 // sdSummary represents a simplified summary of a Security Descriptor.
@@ -74,7 +80,7 @@ type sdSummary struct {
 	HighRisk []aceSummary // List of high-risk ACEs (those with dangerous rights)
 }
 
-// wellKnownSIDName returns the friendly name for well-known Windows SIDs.
+// WellKnownSIDName returns the friendly name for well-known Windows SIDs.
 // It converts well-known security identifier strings to their human-readable names.
 //
 // Parameters:
@@ -95,7 +101,7 @@ type sdSummary struct {
 //   - S-1-5-32-551: Backup Operators
 //
 // Reference: https://learn.microsoft.com/en-us/windows-server/identity/ad-ds/manage/understand-security-identifiers
-func wellKnownSIDName(sid string) string {
+func WellKnownSIDName(sid string) string {
 	switch sid {
 	case "S-1-1-0":
 		return "Everyone"
@@ -130,7 +136,7 @@ func formatTrustee(sid string) string {
 	if sid == "" {
 		return ""
 	}
-	if name := wellKnownSIDName(sid); name != "" {
+	if name := WellKnownSIDName(sid); name != "" {
 		return name + " (" + sid + ")"
 	}
 	return sid
@@ -265,6 +271,7 @@ func parseACL(b []byte) (parsedACL, error) {
 			break
 		}
 		aceType := b[off]
+		aceFlags := b[off+1]
 		aceSize := int(binary.LittleEndian.Uint16(b[off+2 : off+4]))
 		if aceSize < 4 || off+aceSize > aclSize {
 			break
@@ -284,6 +291,7 @@ func parseACL(b []byte) (parsedACL, error) {
 				Trustee: trustee,
 				Mask:    mask,
 				Rights:  decodeRiskyRights(mask),
+				Flags:   aceFlags,
 			})
 		} else if aceType == aceTypeAccessAllowedObject || aceType == aceTypeAccessDeniedObject {
 			if aceSize < 16 {
@@ -293,10 +301,17 @@ func parseACL(b []byte) (parsedACL, error) {
 			mask := binary.LittleEndian.Uint32(aceBytes[4:8])
 			flags := binary.LittleEndian.Uint32(aceBytes[8:12])
 			cursor := 12
-			if flags&0x1 != 0 {
+			var objectType, inheritedObjectType string
+			if flags&aceObjectTypePresent != 0 {
+				if cursor+16 <= aceSize {
+					objectType, _ = ParseObjectGUID(aceBytes[cursor : cursor+16])
+				}
 				cursor += 16
 			}
-			if flags&0x2 != 0 {
+			if flags&aceInheritedObjectTypePresent != 0 {
+				if cursor+16 <= aceSize {
+					inheritedObjectType, _ = ParseObjectGUID(aceBytes[cursor : cursor+16])
+				}
 				cursor += 16
 			}
 			if cursor >= aceSize {
@@ -305,10 +320,13 @@ func parseACL(b []byte) (parsedACL, error) {
 			}
 			trustee, _ := ParseObjectSID(aceBytes[cursor:])
 			out.Aces = append(out.Aces, aceSummary{
-				Allow:   aceType == aceTypeAccessAllowedObject,
-				Trustee: trustee,
-				Mask:    mask,
-				Rights:  decodeRiskyRights(mask),
+				Allow:               aceType == aceTypeAccessAllowedObject,
+				Trustee:             trustee,
+				Mask:                mask,
+				Rights:              decodeRiskyRights(mask),
+				Flags:               aceFlags,
+				ObjectType:          objectType,
+				InheritedObjectType: inheritedObjectType,
 			})
 		}
 		off += aceSize
@@ -13,19 +13,27 @@ const (
 	AttrCN                                      = "cn"
 	AttrName                                    = "name"
 	AttrObjectCategory                          = "objectCategory"
+	AttrDescription                             = "description"
 
 	// Account Attributes
 	AttrSAMAccountName                          = "sAMAccountName"
 	AttrUserPrincipalName                       = "userPrincipalName"
 	AttrUserAccountControl                      = "userAccountControl"
 	AttrAccountExpires                          = "accountExpires"
+	AttrMSDSUserAccountControlComputed          = "msDS-User-Account-Control-Computed"
 	AttrPwdLastSet                              = "pwdLastSet"
 	AttrAdminCount                              = "adminCount"
+	AttrPrimaryGroupID                          = "primaryGroupID"
 
 	// Security and Identity Attributes
 	AttrMSDSCreatorSID                          = "mS-DS-CreatorSID"
+	AttrMSDSMachineAccountQuota                 = "ms-DS-MachineAccountQuota"
 	AttrSIDHistory                              = "sIDHistory"
 	AttrNTSecurityDescriptor                    = "nTSecurityDescriptor"
+	AttrTokenGroups                             = "tokenGroups"
+	AttrAllowedAttributesEffective              = "allowedAttributesEffective"
+	AttrAllowedChildClassesEffective            = "allowedChildClassesEffective"
+	AttrSDRightsEffective                       = "sDRightsEffective"
 
 	// Time Attributes
 	AttrWhenCreated                             = "whenCreated"
@@ -41,11 +49,14 @@ const (
 	AttrMSDSSupportedEncryptionTypes            = "msDS-SupportedEncryptionTypes"
 	AttrServicePrincipalName                    = "servicePrincipalName"
 	AttrLogonHours                              = "logonHours"
+	AttrUserWorkstations                        = "userWorkstations"
 	AttrMSDSGenerationId                        = "msDS-GenerationId"
 
 	// Computer Attributes
 	AttrOperatingSystem                         = "operatingSystem"
+	AttrOperatingSystemVersion                  = "operatingSystemVersion"
 	AttrDNSHostName                             = "dNSHostName"
+	AttrMSDSAdditionalDNSHostName               = "msDS-AdditionalDnsHostName"
 
 	// Group Attributes
 	AttrMember                                  = "member"
@@ -58,6 +69,8 @@ const (
 	AttrTrustType                               = "trustType"
 	AttrTrustAttributes                         = "trustAttributes"
 	AttrFlatName                                = "flatName"
+	AttrTrustPartner                            = "trustPartner"
+	AttrSecurityIdentifier                      = "securityIdentifier"
 
 	// Display Attributes
 	AttrDisplayName                             = "displayName"
@@ -67,4 +80,35 @@ const (
 	AttrGPCMachineExtensionNames                = "gPCMachineExtensionNames"
 	AttrGPCUserExtensionNames                   = "gPCUserExtensionNames"
 	AttrVersionNumber                           = "versionNumber"
+	AttrGPLink                                  = "gPLink"
+	AttrGPOptions                               = "gPOptions"
+
+	// Schema and Partition Attributes
+	AttrMSDSBehaviorVersion                     = "msDS-Behavior-Version"
+	AttrObjectVersion                           = "objectVersion"
+	AttrLDAPDisplayName                         = "lDAPDisplayName"
+	AttrRangeUpper                              = "rangeUpper"
+	AttrMSExchSchemaVersionPt                   = "ms-Exch-Schema-Version-Pt"
+	AttrConfigurationNamingContext              = "configurationNamingContext"
+	AttrSchemaNamingContext                     = "schemaNamingContext"
+
+	// Exchange Attributes
+	AttrMSExchCurrentServerRoles                = "msExchCurrentServerRoles"
+	AttrSerialNumber                            = "serialNumber"
+	AttrNetworkAddress                          = "networkAddress"
+
+	// KDS Root Key Attributes
+	AttrMSKdsCreateTime                         = "msKds-CreateTime"
+	AttrMSKdsUseStartTime                       = "msKds-UseStartTime"
+	AttrMSKdsDomainID                           = "msKds-DomainID"
+	AttrMSKdsVersion                            = "msKds-Version"
+	AttrMSKdsKDFAlgorithmID                     = "msKds-KDFAlgorithmID"
+	AttrMSKdsRootKeyData                        = "msKds-RootKeyData"
+
+	// Managed Service Account Attributes
+	AttrMSDSGroupMSAMembership                  = "msDS-GroupMSAMembership"
+	AttrMSDSManagedAccountPrecededByLink        = "msDS-ManagedAccountPrecededByLink"
+	AttrMSDSDelegatedMSAState                   = "msDS-DelegatedMSAState"
+	AttrMSDSSupersededManagedAccountLink        = "msDS-SupersededManagedAccountLink"
+	AttrMSDSSupersededServiceAccountState       = "msDS-SupersededServiceAccountState"
 )
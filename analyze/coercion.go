@@ -0,0 +1,119 @@
+package analyze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// printQueue attributes naming the server that publishes a printer
+const (
+	AttrServerName      = "serverName"
+	AttrShortServerName = "shortServerName"
+	AttrPrinterName     = "printerName"
+	AttrUNCName         = "uNCName"
+)
+
+// Coercion hints: why a computer looks coercible, or worth coercing to
+const (
+	CoercionHintSpooler       = "Print Spooler (published printers)"
+	CoercionHintWebDAV        = "WebDAV/HTTP (HTTP SPN)"
+	CoercionHintUnconstrained = "Unconstrained delegation (HOST SPN)"
+)
+
+// CoercionAttributes are the computer attributes CoercionCandidates reads
+var CoercionAttributes = []string{
+	AttrSAMAccountName,
+	AttrUserAccountControl,
+	AttrDNSHostName,
+	AttrServicePrincipalName,
+}
+
+// CoercionCandidate is a computer whose SPNs or published printers
+// suggest authentication coercion is viable. Print Spooler (PrinterBug)
+// and WebClient (WebDAV over HTTP, relayable to LDAP) make a computer
+// authenticate to an attacker host; CoerceHere marks hosts with
+// unconstrained delegation, where coerced DC authentication leaves a TGT.
+type CoercionCandidate struct {
+	Name        string   `json:"name"`
+	DN          string   `json:"dn"`
+	DNSHostName string   `json:"dnsHostName,omitempty"`
+	DC          bool     `json:"dc"`
+	Hints       []string `json:"hints"`      // Coercion* hints
+	CoerceHere  bool     `json:"coerceHere"` // Coerce DCs to authenticate to this host
+}
+
+// CoercionCandidates returns the computers with at least one coercion
+// hint. printQueues are the printQueue objects of the domain, matched to
+// computers by serverName and shortServerName. Hosts to coerce to come
+// first, then domain controllers, then computers with the most hints.
+func CoercionCandidates(computers, printQueues []*ldap.Entry) []CoercionCandidate {
+	printServers := make(map[string]bool)
+	for _, q := range printQueues {
+		for _, attr := range []string{AttrServerName, AttrShortServerName} {
+			if v := q.GetEqualFoldAttributeValue(attr); v != "" {
+				printServers[strings.ToLower(v)] = true
+			}
+		}
+	}
+
+	var candidates []CoercionCandidate
+	for _, e := range computers {
+		uac, _ := strconv.ParseUint(e.GetEqualFoldAttributeValue(AttrUserAccountControl), 10, 32)
+		if uac&UF_ACCOUNTDISABLE != 0 {
+			continue
+		}
+		c := CoercionCandidate{
+			Name:        e.GetEqualFoldAttributeValue(AttrSAMAccountName),
+			DN:          e.DN,
+			DNSHostName: e.GetEqualFoldAttributeValue(AttrDNSHostName),
+			DC:          uac&UF_SERVER_TRUST_ACCOUNT != 0,
+		}
+		if c.Name == "" {
+			c.Name = e.DN
+		}
+		short := strings.TrimSuffix(strings.ToLower(c.Name), "$")
+		if printServers[strings.ToLower(c.DNSHostName)] || printServers[short] {
+			c.Hints = append(c.Hints, CoercionHintSpooler)
+		}
+		spns := e.GetEqualFoldAttributeValues(AttrServicePrincipalName)
+		if hasSPNClass(spns, "HTTP") {
+			c.Hints = append(c.Hints, CoercionHintWebDAV)
+		}
+		if uac&UF_TRUSTED_FOR_DELEGATION != 0 && !c.DC && hasSPNClass(spns, "Host") {
+			c.Hints = append(c.Hints, CoercionHintUnconstrained)
+			c.CoerceHere = true
+		}
+		if len(c.Hints) > 0 {
+			candidates = append(candidates, c)
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		a, b := candidates[i], candidates[j]
+		if a.CoerceHere != b.CoerceHere {
+			return a.CoerceHere
+		}
+		if a.DC != b.DC {
+			return a.DC
+		}
+		if len(a.Hints) != len(b.Hints) {
+			return len(a.Hints) > len(b.Hints)
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return candidates
+}
+
+// hasSPNClass reports whether one of spns identifies the service class
+// (as returned by SPNClass)
+func hasSPNClass(spns []string, class string) bool {
+	for _, spn := range spns {
+		if s, ok := ParseSPN(spn); ok && s.Class == class {
+			return true
+		}
+	}
+	return false
+}
@@ -4,21 +4,66 @@ package analyze
 // These constants define the configuration key paths used by the Viper configuration management system.
 // They follow a hierarchical naming convention (e.g., "ldap.server", "ldap.port").
 const (
-	ConfigLDAPServer    = "ldap.server"
-	ConfigLDAPPort      = "ldap.port"
-	ConfigLDAPBaseDN    = "ldap.baseDN"
-	ConfigLDAPUsername  = "ldap.username"
-	ConfigLDAPPassword  = "ldap.password"
-	ConfigLDAPLoginName = "ldap.loginName"
-	ConfigLDAPSecurity  = "ldap.security"
-	ConfigOutput        = "output"
+	ConfigLDAPServer        = "ldap.server"
+	ConfigLDAPPort          = "ldap.port"
+	ConfigLDAPBaseDN        = "ldap.baseDN"
+	ConfigLDAPUsername      = "ldap.username"
+	ConfigLDAPPassword      = "ldap.password"
+	ConfigLDAPLoginName     = "ldap.loginName"
+	ConfigLDAPSecurity      = "ldap.security"
+	ConfigLDAPTimeout       = "ldap.timeout"
+	ConfigLDAPSizeLimit     = "ldap.sizeLimit"
+	ConfigOutput            = "output"
+	ConfigNotifyURL         = "notify.url"
+	ConfigNotifyMinScore    = "notify.minScore"
+	ConfigTimeZone          = "time.zone"
+	ConfigTimeFormat        = "time.format"
+	ConfigCSVDelimiter      = "csv.delimiter"
+	ConfigCSVQuoteAll       = "csv.quoteAll"
+	ConfigCSVCRLF           = "csv.crlf"
+	ConfigCSVBOM            = "csv.bom"
+	ConfigCSVWide           = "csv.wide"
+	ConfigQueriesDir        = "queries.dir"
+	ConfigQueriesCustom     = "queries.custom"
+	ConfigPluginsEnabled    = "plugins.enabled"
+	ConfigPluginsDir        = "plugins.dir"
+	ConfigCacheEnabled      = "cache.enabled"
+	ConfigCacheTTL          = "cache.ttl"
+	ConfigCacheDir          = "cache.dir"
+	ConfigLogLevel          = "log.level"
+	ConfigLogFormat         = "log.format"
+	ConfigLogFile           = "log.file"
+	ConfigLogMaxSize        = "log.maxSize"
+	ConfigLogMaxBackups     = "log.maxBackups"
+	ConfigRetryMaxAttempts  = "retry.maxAttempts"
+	ConfigRetryInitialDelay = "retry.initialDelay"
+	ConfigRetryMaxDelay     = "retry.maxDelay"
+	ConfigRetryMultiplier   = "retry.multiplier"
+	ConfigPoolMaxConns      = "pool.maxConns"
+	ConfigPoolIdleTimeout   = "pool.idleTimeout"
+	ConfigPoolMaxLifetime   = "pool.maxLifetime"
 )
 
 // Output Formats
 const (
-	OutputFormatText = "text"
-	OutputFormatJSON = "json"
-	OutputFormatCSV  = "csv"
+	OutputFormatText     = "text"
+	OutputFormatTable    = "table"
+	OutputFormatJSON     = "json"
+	OutputFormatJSONL    = "jsonl"
+	OutputFormatGrep     = "grep"
+	OutputFormatCSV      = "csv"
+	OutputFormatXLSX     = "xlsx"
+	OutputFormatHTML     = "html"
+	OutputFormatTemplate = "template"
+	OutputFormatStats    = "stats"
+	OutputFormatDOT      = "dot"
+	OutputFormatMermaid  = "mermaid"
+	OutputFormatSplunk   = "splunk"
+	OutputFormatElastic  = "elastic"
+	OutputFormatRaw      = "raw"
+	OutputFormatLDIF     = "ldif"
+	OutputFormatHashcat  = "hashcat"
+	OutputFormatJohn     = "john"
 )
 
 // Port Ranges
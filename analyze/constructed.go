@@ -0,0 +1,80 @@
+package analyze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ConstructedAttributes are computed by the DC for one object at a time and
+// only returned by base-scope searches. Subtree searches omit them, so they
+// are read from each entry found separately.
+var ConstructedAttributes = []string{
+	AttrMSDSUserAccountControlComputed, // Lockout and password expiry, which userAccountControl no longer shows
+	AttrAllowedAttributesEffective,     // Attributes the bound user may write on the object
+	AttrAllowedChildClassesEffective,   // Classes the bound user may create under the object
+	AttrSDRightsEffective,              // Security descriptor parts the bound user may write
+	AttrTokenGroups,                    // Security groups in the token of the account
+}
+
+// IsConstructedAttribute reports whether attribute is one of ConstructedAttributes
+func IsConstructedAttribute(attribute string) bool {
+	return containsFold(ConstructedAttributes, attribute)
+}
+
+// SplitConstructedAttributes separates the ConstructedAttributes of a
+// request from the attributes a subtree search returns. If only
+// constructed attributes are requested, the regular ones are just the
+// distinguishedName, since an empty list would return every attribute.
+func SplitConstructedAttributes(attributes []string) (regular, constructed []string) {
+	for _, attr := range attributes {
+		if IsConstructedAttribute(attr) {
+			constructed = append(constructed, attr)
+		} else {
+			regular = append(regular, attr)
+		}
+	}
+	if len(constructed) > 0 && len(regular) == 0 {
+		regular = []string{AttrDistinguishedName}
+	}
+	return regular, constructed
+}
+
+// FormatUACFlags lists the flags of a userAccountControl-style value
+// followed by the raw value, e.g. "LOCKOUT, PASSWORD_EXPIRED (8388624)"
+func FormatUACFlags(entry *ldap.Entry, attribute string) (string, error) {
+	v := entry.GetEqualFoldAttributeValue(attribute)
+	if v == "" {
+		return "", nil
+	}
+	uac, err := strconv.ParseUint(v, 10, 32)
+	if err != nil {
+		return v, nil
+	}
+	names := UACFlagNames(uint32(uac))
+	if len(names) == 0 {
+		return v, nil
+	}
+	return fmt.Sprintf("%s (%d)", strings.Join(names, ", "), uac), nil
+}
+
+// FormatMultiValue joins the values of a multi-valued attribute with commas
+func FormatMultiValue(entry *ldap.Entry, attribute string) (string, error) {
+	return strings.Join(entry.GetEqualFoldAttributeValues(attribute), ", "), nil
+}
+
+// FormatSIDList converts every binary SID value of an attribute, such as
+// tokenGroups, to string form and joins them with commas
+func FormatSIDList(entry *ldap.Entry, attribute string) (string, error) {
+	var sids []string
+	for _, raw := range entry.GetEqualFoldRawAttributeValues(attribute) {
+		sid, err := ParseObjectSID(raw)
+		if err != nil {
+			return "", err
+		}
+		sids = append(sids, sid)
+	}
+	return strings.Join(sids, ", "), nil
+}
@@ -17,9 +17,26 @@ const (
 	DefaultRetryMaxDelay = 5             // Maximum retry delay in seconds
 	DefaultRetryMultiplier = 2.0         // Exponential backoff multiplier
 
+	// Pool Defaults
+	DefaultPoolMaxConns    = 5  // Maximum connections opened by collect-all and batch
+	DefaultPoolIdleTimeout = 5  // Idle pooled connections are replaced after this many minutes
+	DefaultPoolMaxLifetime = 30 // Pooled connections are replaced after this many minutes
+
 	// Output Defaults
 	DefaultOutputFormat = OutputFormatText // Text output by default
 
 	// Pagination Defaults
 	DefaultPagingSize = 1000 // LDAP pagination size
+
+	// Collection Defaults
+	DefaultCollectConcurrency = 4 // Queries run at once by collect-all
+
+	// Cache Defaults
+	DefaultCacheTTL = "10m" // How long cached search results are served
+
+	// Log Defaults
+	DefaultLogLevel      = "debug"   // Show every message
+	DefaultLogFormat     = "console" // Colored text on stderr
+	DefaultLogMaxSize    = 10        // Log file size in megabytes before it is rotated
+	DefaultLogMaxBackups = 3         // Rotated log files kept
 )
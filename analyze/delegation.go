@@ -0,0 +1,191 @@
+package analyze
+
+import (
+	"encoding/binary"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Delegation kinds
+const (
+	DelegationUnconstrained      = "Unconstrained"
+	DelegationConstrained        = "Constrained"
+	DelegationProtocolTransition = "Constrained (protocol transition)"
+	DelegationResourceBased      = "Resource-based"
+)
+
+// DelegationAttributes are the attributes Delegations reads
+var DelegationAttributes = []string{
+	AttrSAMAccountName,
+	AttrObjectSID,
+	AttrUserAccountControl,
+	AttrMSDSAllowedToDelegateTo,
+	AttrMSDSAllowedToActOnBehalfOfOtherIdentity,
+	AttrServicePrincipalName,
+}
+
+// Delegation is one delegation relationship: Source can impersonate users
+// to Target (or to any service, for unconstrained delegation).
+type Delegation struct {
+	Kind       string `json:"kind"`                 // One of the Delegation* kinds
+	Source     string `json:"source"`               // Account that can delegate; a SID for unresolved RBCD trustees
+	SourceSID  string `json:"sourceSid,omitempty"`  // SID of Source, if known
+	Target     string `json:"target,omitempty"`     // Account delegated to; empty for unconstrained delegation
+	Service    string `json:"service,omitempty"`    // SPN delegated to by constrained delegation
+	DC         bool   `json:"dc,omitempty"`         // Source is a domain controller, where unconstrained delegation is expected
+	CoerceHere bool   `json:"coerceHere,omitempty"` // Unconstrained source that coerced DC authentication can reach, leaving DC TGTs
+}
+
+// Delegations returns the delegation relationships configured on an entry:
+// unconstrained and constrained delegation from the entry, and
+// resource-based constrained delegation to it. Target SPNs and RBCD
+// trustee SIDs are left for the caller to resolve.
+func Delegations(entry *ldap.Entry) []Delegation {
+	name := entry.GetEqualFoldAttributeValue(AttrSAMAccountName)
+	if name == "" {
+		name = entry.DN
+	}
+	var sid string
+	if raw := entry.GetEqualFoldRawAttributeValues(AttrObjectSID); len(raw) > 0 {
+		sid, _ = ParseObjectSID(raw[0])
+	}
+	uac, _ := strconv.ParseUint(entry.GetEqualFoldAttributeValue(AttrUserAccountControl), 10, 32)
+
+	var result []Delegation
+	if uac&UF_TRUSTED_FOR_DELEGATION != 0 {
+		d := Delegation{
+			Kind:      DelegationUnconstrained,
+			Source:    name,
+			SourceSID: sid,
+			DC:        uac&UF_SERVER_TRUST_ACCOUNT != 0,
+		}
+		// Computers receive coerced authentication through their HOST
+		// SPN; users need any SPN plus a DNS record for it
+		spns := entry.GetEqualFoldAttributeValues(AttrServicePrincipalName)
+		if uac&UF_WORKSTATION_TRUST_ACCOUNT != 0 {
+			d.CoerceHere = hasSPNClass(spns, "Host")
+		} else {
+			d.CoerceHere = len(spns) > 0
+		}
+		d.CoerceHere = d.CoerceHere && !d.DC && uac&UF_ACCOUNTDISABLE == 0
+		result = append(result, d)
+	}
+
+	kind := DelegationConstrained
+	if uac&UF_TRUSTED_TO_AUTH_FOR_DELEGATION != 0 {
+		kind = DelegationProtocolTransition
+	}
+	for _, spn := range entry.GetEqualFoldAttributeValues(AttrMSDSAllowedToDelegateTo) {
+		result = append(result, Delegation{Kind: kind, Source: name, SourceSID: sid, Service: spn})
+	}
+
+	for _, raw := range entry.GetEqualFoldRawAttributeValues(AttrMSDSAllowedToActOnBehalfOfOtherIdentity) {
+		for _, trustee := range rbcdTrustees(raw) {
+			result = append(result, Delegation{Kind: DelegationResourceBased, Source: trustee, SourceSID: trustee, Target: name})
+		}
+	}
+
+	return result
+}
+
+// DelegationTarget is a service, or for RBCD an account, and the accounts
+// that can delegate to it
+type DelegationTarget struct {
+	Target             string   `json:"target"`                       // Account the service belongs to; empty if unresolved
+	Service            string   `json:"service,omitempty"`            // SPN delegated to; empty for RBCD
+	Kind               string   `json:"kind"`                         // DelegationConstrained or DelegationResourceBased
+	Sources            []string `json:"sources"`                      // Accounts that can delegate to the target
+	ProtocolTransition bool     `json:"protocolTransition,omitempty"` // A source can impersonate any user without their ticket
+}
+
+// DelegationTargets inverts constrained and resource-based delegations into
+// one entry per target service (per target account for RBCD), so exposed
+// hosts such as domain controllers stand out. SPNs are grouped case
+// insensitively. Targets are sorted by account, then service.
+func DelegationTargets(delegations []Delegation) []DelegationTarget {
+	index := make(map[string]int)
+	var targets []DelegationTarget
+	for _, d := range delegations {
+		kind := d.Kind
+		if kind == DelegationProtocolTransition {
+			kind = DelegationConstrained
+		}
+		if kind != DelegationConstrained && kind != DelegationResourceBased {
+			continue
+		}
+		key := kind + "|" + strings.ToLower(d.Target) + "|" + strings.ToLower(d.Service)
+		i, ok := index[key]
+		if !ok {
+			i = len(targets)
+			index[key] = i
+			targets = append(targets, DelegationTarget{Target: d.Target, Service: d.Service, Kind: kind})
+		}
+		if !containsFold(targets[i].Sources, d.Source) {
+			targets[i].Sources = append(targets[i].Sources, d.Source)
+		}
+		if d.Kind == DelegationProtocolTransition {
+			targets[i].ProtocolTransition = true
+		}
+	}
+
+	sort.SliceStable(targets, func(i, j int) bool {
+		a, b := strings.ToLower(targets[i].Target), strings.ToLower(targets[j].Target)
+		if a != b {
+			return a < b
+		}
+		return strings.ToLower(targets[i].Service) < strings.ToLower(targets[j].Service)
+	})
+	for i := range targets {
+		sort.Strings(targets[i].Sources)
+	}
+	return targets
+}
+
+// rbcdTrustees returns the SIDs granted access by the DACL of an
+// msDS-AllowedToActOnBehalfOfOtherIdentity security descriptor. Unlike
+// ParseRBCDBinary it skips the owner and group SIDs, falling back to it
+// only if the descriptor cannot be parsed.
+func rbcdTrustees(sd []byte) []string {
+	const (
+		sdHeaderLen        = 20
+		aclHeaderLen       = 8
+		aceHeaderLen       = 8 // type, flags, size, access mask
+		aceTypeAccessAllow = 0x00
+	)
+
+	fallback := func() []string {
+		sids, _ := ParseRBCDBinary(sd)
+		return sids
+	}
+	if len(sd) < sdHeaderLen {
+		return fallback()
+	}
+	daclOffset := int(binary.LittleEndian.Uint32(sd[16:20]))
+	if daclOffset == 0 || daclOffset+aclHeaderLen > len(sd) {
+		return fallback()
+	}
+
+	aceCount := int(binary.LittleEndian.Uint16(sd[daclOffset+4:]))
+	var sids []string
+	pos := daclOffset + aclHeaderLen
+	for range aceCount {
+		if pos+aceHeaderLen > len(sd) {
+			return fallback()
+		}
+		aceType := sd[pos]
+		aceSize := int(binary.LittleEndian.Uint16(sd[pos+2:]))
+		if aceSize < aceHeaderLen || pos+aceSize > len(sd) {
+			return fallback()
+		}
+		if aceType == aceTypeAccessAllow {
+			if sid, err := ParseObjectSID(sd[pos+aceHeaderLen : pos+aceSize]); err == nil {
+				sids = append(sids, sid)
+			}
+		}
+		pos += aceSize
+	}
+	return sids
+}
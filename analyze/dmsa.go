@@ -0,0 +1,37 @@
+package analyze
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// dmsaStates are the values of msDS-DelegatedMSAState and
+// msDS-SupersededServiceAccountState
+var dmsaStates = map[int]string{
+	0: "Unknown",
+	1: "Migration in progress",
+	2: "Migration completed",
+	3: "Standalone",
+}
+
+// FormatDMSAState names a dMSA migration state followed by the raw value,
+// e.g. "Migration completed (2)". A dMSA in state 2 whose
+// msDS-ManagedAccountPrecededByLink names a privileged account inherits
+// its privileges (BadSuccessor).
+func FormatDMSAState(entry *ldap.Entry, attribute string) (string, error) {
+	v := entry.GetEqualFoldAttributeValue(attribute)
+	if v == "" {
+		return "", nil
+	}
+	state, err := strconv.Atoi(v)
+	if err != nil {
+		return v, nil
+	}
+	name, ok := dmsaStates[state]
+	if !ok {
+		return v, nil
+	}
+	return fmt.Sprintf("%s (%d)", name, state), nil
+}
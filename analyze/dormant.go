@@ -0,0 +1,84 @@
+package analyze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// DefaultDormantDays is how long a privileged account may go without a
+// logon before it counts as dormant. lastLogonTimestamp replicates lazily
+// and can lag the real last logon by up to 14 days.
+const DefaultDormantDays = 90
+
+// DormantAccount is a privileged account that never logged on or has not
+// logged on for longer than the dormancy threshold
+type DormantAccount struct {
+	Name          string    `json:"name"`
+	DN            string    `json:"dn"`
+	Enabled       bool      `json:"enabled"`
+	AdminCount    bool      `json:"adminCount"`
+	NeverLoggedOn bool      `json:"neverLoggedOn"`
+	LastLogon     time.Time `json:"lastLogon"`        // lastLogonTimestamp; zero if never
+	DaysInactive  int       `json:"daysInactive"`     // Days since the last logon, or since creation if never
+	PwdLastSet    time.Time `json:"pwdLastSet"`       // Zero if never set
+	Groups        []string  `json:"groups,omitempty"` // Direct memberships, by RDN
+}
+
+// DormantPrivileged returns the accounts among entries, the results of the
+// privilegedUsers query, whose lastLogonTimestamp is absent or more than
+// days old at now. Enabled accounts come first, since they can still be
+// logged on to, then never-used accounts, then the longest inactive.
+func DormantPrivileged(entries []*ldap.Entry, days int, now time.Time) []DormantAccount {
+	threshold := time.Duration(days) * 24 * time.Hour
+	var accounts []DormantAccount
+	for _, e := range entries {
+		last, loggedOn := FileTime(e.GetEqualFoldAttributeValue(AttrLastLogonTimestamp))
+		if loggedOn && now.Sub(last) <= threshold {
+			continue
+		}
+
+		uac, _ := strconv.ParseUint(e.GetEqualFoldAttributeValue(AttrUserAccountControl), 10, 32)
+		a := DormantAccount{
+			Name:          e.GetEqualFoldAttributeValue(AttrSAMAccountName),
+			DN:            e.DN,
+			Enabled:       uac&UF_ACCOUNTDISABLE == 0,
+			AdminCount:    e.GetEqualFoldAttributeValue(AttrAdminCount) == "1",
+			NeverLoggedOn: !loggedOn,
+			LastLogon:     last,
+		}
+		if a.Name == "" {
+			a.Name = e.DN
+		}
+		a.PwdLastSet, _ = FileTime(e.GetEqualFoldAttributeValue(AttrPwdLastSet))
+		since := last
+		if !loggedOn {
+			since, _ = time.Parse("20060102150405.0Z", e.GetEqualFoldAttributeValue(AttrWhenCreated))
+		}
+		if !since.IsZero() {
+			a.DaysInactive = int(now.Sub(since).Hours() / 24)
+		}
+		for _, g := range e.GetEqualFoldAttributeValues(AttrMemberOf) {
+			a.Groups = append(a.Groups, rdnValue(g))
+		}
+		accounts = append(accounts, a)
+	}
+
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := accounts[i], accounts[j]
+		if a.Enabled != b.Enabled {
+			return a.Enabled
+		}
+		if a.NeverLoggedOn != b.NeverLoggedOn {
+			return a.NeverLoggedOn
+		}
+		if a.DaysInactive != b.DaysInactive {
+			return a.DaysInactive > b.DaysInactive
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return accounts
+}
@@ -0,0 +1,213 @@
+package analyze
+
+import (
+	"encoding/binary"
+	"fmt"
+	"slices"
+)
+
+// ACE and security descriptor flags used for edge extraction
+// Reference: https://learn.microsoft.com/en-us/windows/win32/api/winnt/ns-winnt-ace_header
+const (
+	aceFlagInheritOnly = 0x08 // INHERIT_ONLY_ACE - ACE does not apply to the object itself
+	aceFlagInherited   = 0x10 // INHERITED_ACE - ACE was inherited from a parent
+
+	aceObjectTypePresent          = 0x1 // ACE_OBJECT_TYPE_PRESENT
+	aceInheritedObjectTypePresent = 0x2 // ACE_INHERITED_OBJECT_TYPE_PRESENT
+
+	sdControlDACLProtected = 0x1000 // SE_DACL_PROTECTED - DACL does not inherit ACEs
+
+	// Mapped form of GENERIC_ALL as stored in directory DACLs
+	accessMaskFullControl = 0x000F01FF
+)
+
+// BloodHound edge names (RightName values)
+const (
+	EdgeOwns                    = "Owns"
+	EdgeGenericAll              = "GenericAll"
+	EdgeGenericWrite            = "GenericWrite"
+	EdgeWriteDacl               = "WriteDacl"
+	EdgeWriteOwner              = "WriteOwner"
+	EdgeAllExtendedRights       = "AllExtendedRights"
+	EdgeForceChangePassword     = "ForceChangePassword"
+	EdgeAddMember               = "AddMember"
+	EdgeAddSelf                 = "AddSelf"
+	EdgeGetChanges              = "GetChanges"
+	EdgeGetChangesAll           = "GetChangesAll"
+	EdgeGetChangesInFilteredSet = "GetChangesInFilteredSet"
+	EdgeWriteSPN                = "WriteSPN"
+	EdgeAddKeyCredentialLink    = "AddKeyCredentialLink"
+	EdgeAddAllowedToAct         = "AddAllowedToAct"
+)
+
+// Schema attribute and extended right GUIDs referenced by object ACEs
+// Reference: https://learn.microsoft.com/en-us/windows/win32/adschema/extended-rights
+const (
+	guidUserForceChangePassword            = "{00299570-246d-11d0-a768-00aa006e0529}"
+	guidReplicationGetChanges              = "{1131f6aa-9c07-11d1-f79f-00c04fc2dcd2}"
+	guidReplicationGetChangesAll           = "{1131f6ad-9c07-11d1-f79f-00c04fc2dcd2}"
+	guidReplicationGetChangesInFilteredSet = "{89e95b76-444d-4c62-991a-0facbeda640c}"
+	guidAttrMember                         = "{bf9679c0-0de6-11d0-a285-00aa003049e2}"
+	guidAttrServicePrincipalName           = "{f3a64788-5306-11d1-a9c5-0000f80367c1}"
+	guidAttrKeyCredentialLink              = "{5b47d60f-6090-40b2-9f37-2a4de88f3063}"
+	guidAttrAllowedToActOnBehalf           = "{3f78c3e5-f79a-46bd-a0b8-9d18116ddc79}"
+)
+
+// objectClassGUIDs maps BloodHound object types to the schema class GUIDs an
+// inheritable ACE may target. Computers derive from user, so ACEs scoped to
+// user objects also apply to them.
+var objectClassGUIDs = map[string][]string{
+	"User":      {"{bf967aba-0de6-11d0-a285-00aa003049e2}"},
+	"Computer":  {"{bf967a86-0de6-11d0-a285-00aa003049e2}", "{bf967aba-0de6-11d0-a285-00aa003049e2}"},
+	"Group":     {"{bf967a9c-0de6-11d0-a285-00aa003049e2}"},
+	"Domain":    {"{19195a5a-6da0-11d0-afd3-00c04fd930c9}"},
+	"GPO":       {"{f30e3bc2-9ff0-11d1-b603-0000f80367c1}"},
+	"OU":        {"{bf967aa5-0de6-11d0-a285-00aa003049e2}"},
+	"Container": {"{bf967a8b-0de6-11d0-a285-00aa003049e2}"},
+}
+
+// ignoredEdgePrincipals are principals whose rights never form useful edges
+var ignoredEdgePrincipals = map[string]bool{
+	"S-1-3-0":  true, // CREATOR OWNER
+	"S-1-5-10": true, // SELF
+	"S-1-5-18": true, // Local System
+}
+
+// Edge is an abusable right a principal holds over an object
+type Edge struct {
+	PrincipalSID string // SID of the principal holding the right
+	RightName    string // BloodHound edge name (e.g., GenericAll)
+	IsInherited  bool   // true if the right comes from an inherited ACE
+}
+
+// ACLEdges holds the edges extracted from a security descriptor
+type ACLEdges struct {
+	Protected bool   // true if the DACL is protected from inheritance
+	Edges     []Edge // Edges in ACE order, without duplicates
+}
+
+// ExtractEdges parses a self-relative nTSecurityDescriptor and maps its owner
+// and allow ACEs to BloodHound edges. The objectType is the BloodHound type of
+// the object the descriptor belongs to (User, Computer, Group, Domain, GPO, OU
+// or Container) and decides which rights are meaningful.
+//
+// Deny ACEs are not subtracted; the result lists rights that are granted.
+func ExtractEdges(raw []byte, objectType string) (ACLEdges, error) {
+	var out ACLEdges
+	if len(raw) < 20 {
+		return out, fmt.Errorf("security descriptor too short")
+	}
+
+	control := binary.LittleEndian.Uint16(raw[2:4])
+	out.Protected = control&sdControlDACLProtected != 0
+
+	seen := make(map[Edge]bool)
+	add := func(e Edge) {
+		if !seen[e] {
+			seen[e] = true
+			out.Edges = append(out.Edges, e)
+		}
+	}
+
+	ownerOff := binary.LittleEndian.Uint32(raw[4:8])
+	if ownerOff != 0 && int(ownerOff) < len(raw) {
+		if sid, err := ParseObjectSID(raw[ownerOff:]); err == nil && !ignoredEdgePrincipals[sid] {
+			add(Edge{PrincipalSID: sid, RightName: EdgeOwns})
+		}
+	}
+
+	daclOff := binary.LittleEndian.Uint32(raw[16:20])
+	if daclOff == 0 || int(daclOff) >= len(raw) {
+		return out, nil
+	}
+	acl, err := parseACL(raw[daclOff:])
+	if err != nil {
+		return out, err
+	}
+
+	for _, ace := range acl.Aces {
+		if !ace.Allow || ace.Trustee == "" || ignoredEdgePrincipals[ace.Trustee] {
+			continue
+		}
+		if ace.Flags&aceFlagInheritOnly != 0 {
+			continue
+		}
+		if ace.InheritedObjectType != "" && !slices.Contains(objectClassGUIDs[objectType], ace.InheritedObjectType) {
+			continue
+		}
+
+		for _, right := range aceEdgeRights(ace, objectType) {
+			add(Edge{
+				PrincipalSID: ace.Trustee,
+				RightName:    right,
+				IsInherited:  ace.Flags&aceFlagInherited != 0,
+			})
+		}
+	}
+
+	return out, nil
+}
+
+// aceEdgeRights maps a single allow ACE to the BloodHound edges it grants on
+// an object of the given type.
+func aceEdgeRights(ace aceSummary, objectType string) []string {
+	mask := ace.Mask
+
+	if mask&accessMaskGenericAll != 0 || mask&accessMaskFullControl == accessMaskFullControl {
+		// Full control implies every other right
+		return []string{EdgeGenericAll}
+	}
+
+	var rights []string
+	if mask&accessMaskWriteDACL != 0 {
+		rights = append(rights, EdgeWriteDacl)
+	}
+	if mask&accessMaskWriteOwner != 0 {
+		rights = append(rights, EdgeWriteOwner)
+	}
+
+	switch {
+	case mask&accessMaskGenericWrite != 0, mask&accessMaskDSWriteProp != 0 && ace.ObjectType == "":
+		rights = append(rights, EdgeGenericWrite)
+	case mask&accessMaskDSWriteProp != 0:
+		switch {
+		case ace.ObjectType == guidAttrMember && objectType == "Group":
+			rights = append(rights, EdgeAddMember)
+		case ace.ObjectType == guidAttrServicePrincipalName && (objectType == "User" || objectType == "Computer"):
+			rights = append(rights, EdgeWriteSPN)
+		case ace.ObjectType == guidAttrKeyCredentialLink && (objectType == "User" || objectType == "Computer"):
+			rights = append(rights, EdgeAddKeyCredentialLink)
+		case ace.ObjectType == guidAttrAllowedToActOnBehalf && objectType == "Computer":
+			rights = append(rights, EdgeAddAllowedToAct)
+		}
+	case mask&accessMaskDSSelf != 0 && ace.ObjectType == guidAttrMember && objectType == "Group":
+		rights = append(rights, EdgeAddSelf)
+	}
+
+	if mask&accessMaskDSControlAccess != 0 {
+		switch ace.ObjectType {
+		case "":
+			if objectType == "User" || objectType == "Computer" || objectType == "Domain" {
+				rights = append(rights, EdgeAllExtendedRights)
+			}
+		case guidUserForceChangePassword:
+			if objectType == "User" {
+				rights = append(rights, EdgeForceChangePassword)
+			}
+		case guidReplicationGetChanges:
+			if objectType == "Domain" {
+				rights = append(rights, EdgeGetChanges)
+			}
+		case guidReplicationGetChangesAll:
+			if objectType == "Domain" {
+				rights = append(rights, EdgeGetChangesAll)
+			}
+		case guidReplicationGetChangesInFilteredSet:
+			if objectType == "Domain" {
+				rights = append(rights, EdgeGetChangesInFilteredSet)
+			}
+		}
+	}
+
+	return rights
+}
@@ -0,0 +1,98 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// exchangeServerRoles are the role bits of msExchCurrentServerRoles
+var exchangeServerRoles = []struct {
+	bit  uint64
+	name string
+}{
+	{2, "Mailbox"},
+	{4, "ClientAccess"},
+	{16, "UnifiedMessaging"},
+	{32, "HubTransport"},
+	{64, "EdgeTransport"},
+	{16384, "FrontendTransport"},
+}
+
+// exchangeSchemaVersions maps rangeUpper of ms-Exch-Schema-Version-Pt to
+// the Exchange releases that set it. Cumulative updates that do not
+// change the schema share the value of the last one that did.
+var exchangeSchemaVersions = map[int]string{
+	4397:  "Exchange 2007 RTM",
+	11116: "Exchange 2007 SP1",
+	14622: "Exchange 2007 SP2 or Exchange 2010 RTM",
+	14625: "Exchange 2007 SP3",
+	14726: "Exchange 2010 SP1",
+	14732: "Exchange 2010 SP2",
+	14734: "Exchange 2010 SP3",
+	15137: "Exchange 2013 RTM",
+	15254: "Exchange 2013 CU1",
+	15281: "Exchange 2013 CU2",
+	15283: "Exchange 2013 CU3",
+	15292: "Exchange 2013 SP1",
+	15300: "Exchange 2013 CU5",
+	15303: "Exchange 2013 CU6",
+	15312: "Exchange 2013 CU7-CU23",
+	15317: "Exchange 2016 RTM",
+	15323: "Exchange 2016 CU1",
+	15325: "Exchange 2016 CU2",
+	15326: "Exchange 2016 CU3-CU5",
+	15330: "Exchange 2016 CU6",
+	15332: "Exchange 2016 CU7-CU18",
+	15333: "Exchange 2016 CU19-CU20",
+	15334: "Exchange 2016 CU21-CU23",
+	17000: "Exchange 2019 RTM-CU1",
+	17001: "Exchange 2019 CU2-CU7",
+	17002: "Exchange 2019 CU8-CU9",
+	17003: "Exchange 2019 CU10 or later",
+}
+
+// ExchangeVersion returns the Exchange releases of a schema version, the
+// rangeUpper of ms-Exch-Schema-Version-Pt. Unknown versions are reported
+// relative to the closest earlier known one.
+func ExchangeVersion(rangeUpper int) string {
+	if release, ok := exchangeSchemaVersions[rangeUpper]; ok {
+		return release
+	}
+	known := make([]int, 0, len(exchangeSchemaVersions))
+	for v := range exchangeSchemaVersions {
+		known = append(known, v)
+	}
+	sort.Ints(known)
+	i := sort.SearchInts(known, rangeUpper)
+	if i == 0 {
+		return "unknown Exchange release"
+	}
+	return "unknown release after " + exchangeSchemaVersions[known[i-1]]
+}
+
+// FormatExchangeServerRoles lists the roles of an msExchCurrentServerRoles
+// value followed by the raw value, e.g. "Mailbox, ClientAccess (6)"
+func FormatExchangeServerRoles(entry *ldap.Entry, attribute string) (string, error) {
+	v := entry.GetEqualFoldAttributeValue(attribute)
+	if v == "" {
+		return "", nil
+	}
+	roles, err := strconv.ParseUint(v, 10, 64)
+	if err != nil {
+		return v, nil
+	}
+	var names []string
+	for _, r := range exchangeServerRoles {
+		if roles&r.bit != 0 {
+			names = append(names, r.name)
+		}
+	}
+	if len(names) == 0 {
+		return v, nil
+	}
+	return fmt.Sprintf("%s (%s)", strings.Join(names, ", "), v), nil
+}
@@ -0,0 +1,53 @@
+package analyze
+
+import (
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestExchangeVersion(t *testing.T) {
+	tests := []struct {
+		rangeUpper int
+		want       string
+	}{
+		{4397, "Exchange 2007 RTM"},
+		{15312, "Exchange 2013 CU7-CU23"},
+		{15334, "Exchange 2016 CU21-CU23"},
+		{17003, "Exchange 2019 CU10 or later"},
+		{15320, "unknown release after Exchange 2016 RTM"},
+		{17004, "unknown release after Exchange 2019 CU10 or later"},
+		{4000, "unknown Exchange release"},
+		{0, "unknown Exchange release"},
+	}
+	for _, tt := range tests {
+		if got := ExchangeVersion(tt.rangeUpper); got != tt.want {
+			t.Errorf("ExchangeVersion(%d) = %q, want %q", tt.rangeUpper, got, tt.want)
+		}
+	}
+}
+
+func TestFormatExchangeServerRoles(t *testing.T) {
+	tests := []struct {
+		value string
+		want  string
+	}{
+		{"", ""},
+		{"6", "Mailbox, ClientAccess (6)"},
+		{"16422", "Mailbox, ClientAccess, HubTransport, FrontendTransport (16422)"},
+		{"64", "EdgeTransport (64)"},
+		{"1", "1"},
+		{"mailbox", "mailbox"},
+	}
+	for _, tt := range tests {
+		attrs := map[string][]string{}
+		if tt.value != "" {
+			attrs[AttrMSExchCurrentServerRoles] = []string{tt.value}
+		}
+		entry := ldap.NewEntry("CN=EX01,CN=Servers,DC=example,DC=com", attrs)
+		got, err := FormatExchangeServerRoles(entry, AttrMSExchCurrentServerRoles)
+		if err != nil || got != tt.want {
+			t.Errorf("FormatExchangeServerRoles(%q) = %q, %v; want %q", tt.value, got, err, tt.want)
+		}
+	}
+}
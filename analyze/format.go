@@ -3,10 +3,33 @@ package analyze
 import (
 	"errors"
 	"strings"
+	"sync"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
+// BinaryAttributes hold binary values that FormatAttributeValue decodes
+// into text, losing the original bytes
+var BinaryAttributes = []string{
+	AttrObjectGUID,
+	AttrMSFVERecoveryGuid,
+	AttrMSFVEVolumeGuid,
+	AttrObjectSID,
+	AttrMSDSCreatorSID,
+	AttrSIDHistory,
+	AttrTokenGroups,
+	AttrNTSecurityDescriptor,
+	AttrMSDSAllowedToActOnBehalfOfOtherIdentity,
+	AttrMSDSGroupMSAMembership,
+	AttrMSDSGenerationId,
+	AttrLogonHours,
+}
+
+// IsBinaryAttribute reports whether attribute is one of BinaryAttributes
+func IsBinaryAttribute(attribute string) bool {
+	return containsFold(BinaryAttributes, attribute)
+}
+
 // FormatAttributeValue retrieves and formats an LDAP attribute value based on the attribute name.
 // It delegates to specialized formatters for known attribute types to provide human-readable output.
 //
@@ -20,22 +43,30 @@ import (
 //
 // Supported specialized formatters:
 //   - ObjectClass: Multi-valued attribute, joined with commas
-//   - ObjectGUID: Binary GUID converted to string format
+//   - ObjectGUID/msFVE-RecoveryGuid/msFVE-VolumeGuid: Binary GUID converted to string format
 //   - ObjectSID/mS-DS-CreatorSID: Binary SID converted to string format
 //   - Time attributes (whenCreated, whenChanged, etc.): GeneralizedTime conversion
-//   - FileTime attributes (lastLogon, pwdLastSet, etc.): Windows FileTime conversion
+//   - FileTime attributes (lastLogon, pwdLastSet, msKds-CreateTime, etc.): Windows FileTime conversion
 //   - msDS-SupportedEncryptionTypes: Encryption types list
 //   - nTSecurityDescriptor: SDDL or summary format
 //   - userAccountControl: UAC flag parsing
 //   - accountExpires: Account expiration handling
+//   - servicePrincipalName: Every SPN with the service it identifies
+//   - msExchCurrentServerRoles: Exchange server role names
+//   - msDS-DelegatedMSAState/msDS-SupersededServiceAccountState: dMSA migration state
+//   - msDS-User-Account-Control-Computed: UAC flag names (LOCKOUT, PASSWORD_EXPIRED)
+//   - allowedAttributesEffective/allowedChildClassesEffective: Multi-valued, joined with commas
+//   - sDRightsEffective: Writable security descriptor parts
+//   - tokenGroups: Every binary SID converted to string format
 //
-// For unknown attributes, returns the raw string value or hex representation if binary-like.
+// Other attributes use a formatter added with RegisterFormatter, or else
+// the raw string value or hex representation if binary-like.
 func FormatAttributeValue(entry *ldap.Entry, attribute string) (string, error) {
 	switch attribute {
 	case AttrObjectClass:
 		return FormatObjectClass(entry, attribute)
 
-	case AttrObjectGUID:
+	case AttrObjectGUID, AttrMSFVERecoveryGuid, AttrMSFVEVolumeGuid:
 		binaryGUID := entry.GetRawAttributeValue(attribute)
 		return ParseObjectGUID(binaryGUID)
 
@@ -49,10 +80,11 @@ func FormatAttributeValue(entry *ldap.Entry, attribute string) (string, error) {
 	case AttrMSDSSupportedEncryptionTypes:
 		return MSDSSupportedEncryptionTypes(entry, attribute)
 
-	case AttrLastLogon, AttrPwdLastSet, AttrLastLogonTimestamp, AttrBadPasswordTime:
+	case AttrLastLogon, AttrPwdLastSet, AttrLastLogonTimestamp, AttrBadPasswordTime,
+		AttrMSKdsCreateTime, AttrMSKdsUseStartTime:
 		return FileTimeToTime(entry, attribute)
 
-	case AttrMSDSGenerationId, AttrLogonHours, AttrMSDSAllowedToActOnBehalfOfOtherIdentity:
+	case AttrMSDSGenerationId, AttrLogonHours, AttrMSDSAllowedToActOnBehalfOfOtherIdentity, AttrMSDSGroupMSAMembership:
 		return AttributeHex(entry, attribute)
 
 	case AttrNTSecurityDescriptor:
@@ -78,7 +110,31 @@ func FormatAttributeValue(entry *ldap.Entry, attribute string) (string, error) {
 	case AttrAccountExpires:
 		return AccountExpires(entry, attribute)
 
+	case AttrServicePrincipalName:
+		return FormatSPNs(entry, attribute)
+
+	case AttrMSExchCurrentServerRoles:
+		return FormatExchangeServerRoles(entry, attribute)
+
+	case AttrMSDSDelegatedMSAState, AttrMSDSSupersededServiceAccountState:
+		return FormatDMSAState(entry, attribute)
+
+	case AttrMSDSUserAccountControlComputed:
+		return FormatUACFlags(entry, attribute)
+
+	case AttrAllowedAttributesEffective, AttrAllowedChildClassesEffective:
+		return FormatMultiValue(entry, attribute)
+
+	case AttrSDRightsEffective:
+		return FormatSDRights(entry, attribute)
+
+	case AttrTokenGroups:
+		return FormatSIDList(entry, attribute)
+
 	default:
+		if f := registeredFormatter(attribute); f != nil {
+			return f(entry, attribute)
+		}
 		v := entry.GetAttributeValue(attribute)
 		if v == "" {
 			return "", nil
@@ -94,6 +150,31 @@ func FormatAttributeValue(entry *ldap.Entry, attribute string) (string, error) {
 	}
 }
 
+// AttributeFormatter formats the values of an attribute of an entry
+type AttributeFormatter func(entry *ldap.Entry, attribute string) (string, error)
+
+// formatters holds the formatters added with RegisterFormatter, by
+// lowercase attribute name
+var (
+	formattersMu sync.RWMutex
+	formatters   = map[string]AttributeFormatter{}
+)
+
+// RegisterFormatter sets the formatter of an attribute that has no built-in
+// formatter, e.g. for plugins. Attribute names match case-insensitively.
+func RegisterFormatter(attribute string, f AttributeFormatter) {
+	formattersMu.Lock()
+	defer formattersMu.Unlock()
+	formatters[strings.ToLower(attribute)] = f
+}
+
+// registeredFormatter returns the formatter added for attribute, or nil
+func registeredFormatter(attribute string) AttributeFormatter {
+	formattersMu.RLock()
+	defer formattersMu.RUnlock()
+	return formatters[strings.ToLower(attribute)]
+}
+
 // FormatObjectClass retrieves and joins objectClass values.
 // The objectClass attribute is multi-valued; this function joins all values with commas.
 // Typically, the last value in the list is the most specific object class.
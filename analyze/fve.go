@@ -0,0 +1,137 @@
+package analyze
+
+import (
+	"encoding/binary"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// BitLocker recovery attributes
+const (
+	AttrMSFVERecoveryPassword = "msFVE-RecoveryPassword"
+	AttrMSFVERecoveryGuid     = "msFVE-RecoveryGuid"
+	AttrMSFVEVolumeGuid       = "msFVE-VolumeGuid"
+)
+
+// Schema GUIDs of the msFVE-RecoveryInformation class and of its
+// confidential msFVE-RecoveryPassword attribute
+const (
+	guidClassFVERecoveryInformation = "{ea715d30-8f53-40d0-bd1e-6109186d782c}"
+	guidAttrFVERecoveryPassword     = "{43061ac1-c8ad-4ccc-b785-2bfac20fc60a}"
+)
+
+// FVERecoveryAttributes are the msFVE-RecoveryInformation attributes
+// FVEComputers reads
+var FVERecoveryAttributes = []string{AttrWhenCreated, AttrMSFVERecoveryPassword, AttrNTSecurityDescriptor}
+
+// FVEComputer is a computer with BitLocker recovery information escrowed
+// in AD. Recovery objects are children of their computer.
+type FVEComputer struct {
+	Computer     string    `json:"computer"`     // Name of the computer object
+	DN           string    `json:"dn"`           // DN of the computer object
+	Keys         int       `json:"keys"`         // Number of recovery objects
+	LastEscrowed time.Time `json:"lastEscrowed"` // whenCreated of the newest recovery object
+	Readable     bool      `json:"readable"`     // The bind account read a recovery password
+	ReaderSIDs   []string  `json:"readerSids"`   // Principals able to read the recovery passwords
+	Readers      []string  `json:"readers"`      // ReaderSIDs resolved to names
+}
+
+// FVEComputers groups msFVE-RecoveryInformation entries by their parent
+// computer, sorted by computer name
+func FVEComputers(entries []*ldap.Entry) []FVEComputer {
+	byDN := make(map[string]*FVEComputer)
+	for _, e := range entries {
+		dn := ParentDN(e.DN)
+		key := strings.ToLower(dn)
+		c, ok := byDN[key]
+		if !ok {
+			c = &FVEComputer{Computer: rdnValue(dn), DN: dn}
+			byDN[key] = c
+		}
+		c.Keys++
+		if t, err := time.Parse("20060102150405.0Z", e.GetEqualFoldAttributeValue(AttrWhenCreated)); err == nil && t.After(c.LastEscrowed) {
+			c.LastEscrowed = t
+		}
+		if e.GetEqualFoldAttributeValue(AttrMSFVERecoveryPassword) != "" {
+			c.Readable = true
+		}
+		if raw := e.GetEqualFoldRawAttributeValue(AttrNTSecurityDescriptor); len(raw) > 0 {
+			readers, _ := FVEReaders(raw)
+			for _, sid := range readers {
+				if !containsFold(c.ReaderSIDs, sid) {
+					c.ReaderSIDs = append(c.ReaderSIDs, sid)
+				}
+			}
+		}
+	}
+
+	result := make([]FVEComputer, 0, len(byDN))
+	for _, c := range byDN {
+		sort.Strings(c.ReaderSIDs)
+		result = append(result, *c)
+	}
+	sort.Slice(result, func(i, j int) bool { return strings.ToLower(result[i].Computer) < strings.ToLower(result[j].Computer) })
+	return result
+}
+
+// FVEReaders returns the SIDs of the principals a msFVE-RecoveryInformation
+// security descriptor lets read msFVE-RecoveryPassword. The attribute is
+// confidential, so reading it takes full control or control access on the
+// object or the attribute; read property access is assumed alongside.
+// Deny ACEs are not subtracted.
+func FVEReaders(raw []byte) ([]string, error) {
+	if len(raw) < 20 {
+		return nil, fmt.Errorf("security descriptor too short")
+	}
+	daclOff := binary.LittleEndian.Uint32(raw[16:20])
+	if daclOff == 0 || int(daclOff) >= len(raw) {
+		return nil, nil
+	}
+	acl, err := parseACL(raw[daclOff:])
+	if err != nil {
+		return nil, err
+	}
+
+	var readers []string
+	for _, ace := range acl.Aces {
+		if !ace.Allow || ace.Trustee == "" || ignoredEdgePrincipals[ace.Trustee] ||
+			ace.Flags&aceFlagInheritOnly != 0 ||
+			(ace.InheritedObjectType != "" && ace.InheritedObjectType != guidClassFVERecoveryInformation) {
+			continue
+		}
+		fullControl := ace.Mask&accessMaskGenericAll != 0 || ace.Mask&accessMaskFullControl == accessMaskFullControl
+		controlAccess := ace.Mask&accessMaskDSControlAccess != 0 &&
+			(ace.ObjectType == "" || ace.ObjectType == guidAttrFVERecoveryPassword)
+		if (fullControl || controlAccess) && !containsFold(readers, ace.Trustee) {
+			readers = append(readers, ace.Trustee)
+		}
+	}
+	return readers, nil
+}
+
+// ParentDN returns the DN of an entry's parent, honouring escaped commas
+func ParentDN(dn string) string {
+	for i := 0; i < len(dn); i++ {
+		switch dn[i] {
+		case '\\':
+			i++
+		case ',':
+			return dn[i+1:]
+		}
+	}
+	return ""
+}
+
+// rdnValue returns the value of the first RDN of a DN, or the DN if it
+// does not parse
+func rdnValue(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil || len(parsed.RDNs) == 0 || len(parsed.RDNs[0].Attributes) == 0 {
+		return dn
+	}
+	return parsed.RDNs[0].Attributes[0].Value
+}
@@ -0,0 +1,174 @@
+package analyze
+
+import (
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// gPLink link options and gPOptions flags
+const (
+	gpLinkDisabled            = 1
+	gpLinkEnforced            = 2
+	gpOptionsBlockInheritance = 1
+)
+
+// GPO link scope types
+const (
+	GPOScopeDomain = "Domain"
+	GPOScopeOU     = "OU"
+	GPOScopeSite   = "Site"
+)
+
+// GPOLinkAttributes are the container attributes GPOScopes reads
+var GPOLinkAttributes = []string{AttrName, AttrObjectClass, AttrGPLink, AttrGPOptions}
+
+// GPOLink is a GPO linked to a domain, OU or site
+type GPOLink struct {
+	Scope     string `json:"scope"`     // DN of the linked container
+	ScopeType string `json:"scopeType"` // One of the GPOScope* types
+	DN        string `json:"gpoDN"`     // DN of the GPO
+	GUID      string `json:"guid"`      // GPO GUID, e.g. "{31B2F340-016D-11D2-945F-00C04FB984F9}"
+	GPO       string `json:"gpo"`       // GPO displayName, or GUID if not resolved
+	Order     int    `json:"order"`     // Link order, 1 has the highest precedence
+	Enabled   bool   `json:"enabled"`
+	Enforced  bool   `json:"enforced"`
+}
+
+// GPOScope is a container with GPO links or blocking inheritance
+type GPOScope struct {
+	DN               string    `json:"dn"`
+	Type             string    `json:"type"` // One of the GPOScope* types
+	BlockInheritance bool      `json:"blockInheritance"`
+	Links            []GPOLink `json:"links"`
+}
+
+// gpLinkPattern matches one [LDAP://<dn>;<options>] element of a gPLink value
+var gpLinkPattern = regexp.MustCompile(`(?i)\[LDAP://([^;\]]+);(\d+)\]`)
+
+// ParseGPLink parses a gPLink value into links, in link order. The value
+// lists the lowest precedence link first.
+func ParseGPLink(value string) []GPOLink {
+	matches := gpLinkPattern.FindAllStringSubmatch(value, -1)
+	links := make([]GPOLink, 0, len(matches))
+	for i := len(matches) - 1; i >= 0; i-- {
+		dn := matches[i][1]
+		opts, _ := strconv.Atoi(matches[i][2])
+		rdn, _, _ := strings.Cut(dn, ",")
+		_, guid, _ := strings.Cut(rdn, "=")
+		links = append(links, GPOLink{
+			DN:       dn,
+			GUID:     strings.ToUpper(guid),
+			Order:    len(links) + 1,
+			Enabled:  opts&gpLinkDisabled == 0,
+			Enforced: opts&gpLinkEnforced != 0,
+		})
+	}
+	return links
+}
+
+// GPOScopes returns the containers that link GPOs or block inheritance,
+// with GPO GUIDs resolved through names, a map of uppercase GUIDs to
+// displayNames. Sites come first, then the domain and OUs from the top
+// of the tree down.
+func GPOScopes(containers []*ldap.Entry, names map[string]string) []GPOScope {
+	var scopes []GPOScope
+	for _, e := range containers {
+		options, _ := strconv.Atoi(e.GetEqualFoldAttributeValue(AttrGPOptions))
+		s := GPOScope{
+			DN:               e.DN,
+			Type:             gpoScopeType(e),
+			BlockInheritance: options&gpOptionsBlockInheritance != 0,
+			Links:            ParseGPLink(e.GetEqualFoldAttributeValue(AttrGPLink)),
+		}
+		if len(s.Links) == 0 && !s.BlockInheritance {
+			continue
+		}
+		for i := range s.Links {
+			s.Links[i].Scope, s.Links[i].ScopeType = s.DN, s.Type
+			if s.Links[i].GPO = names[s.Links[i].GUID]; s.Links[i].GPO == "" {
+				s.Links[i].GPO = s.Links[i].GUID
+			}
+		}
+		scopes = append(scopes, s)
+	}
+	sort.SliceStable(scopes, func(i, j int) bool {
+		a, b := scopes[i], scopes[j]
+		if (a.Type == GPOScopeSite) != (b.Type == GPOScopeSite) {
+			return a.Type == GPOScopeSite
+		}
+		if da, db := dnDepth(a.DN), dnDepth(b.DN); da != db {
+			return da < db
+		}
+		return strings.ToLower(a.DN) < strings.ToLower(b.DN)
+	})
+	return scopes
+}
+
+// AppliedGPOs returns the enabled links that apply to objects in a domain
+// or OU scope, highest precedence first: enforced links from the top of
+// the tree down, then the other links from scope up to the first
+// container blocking inheritance. Site links depend on where the computer
+// is and are not included.
+func AppliedGPOs(scopes []GPOScope, scope GPOScope) []GPOLink {
+	// Containers on the path from the domain to scope, top first
+	var chain []GPOScope
+	for _, s := range scopes {
+		if s.Type != GPOScopeSite && isDNSuffix(scope.DN, s.DN) {
+			chain = append(chain, s)
+		}
+	}
+	sort.SliceStable(chain, func(i, j int) bool { return dnDepth(chain[i].DN) < dnDepth(chain[j].DN) })
+
+	var applied []GPOLink
+	for _, s := range chain {
+		for _, l := range s.Links {
+			if l.Enabled && l.Enforced {
+				applied = append(applied, l)
+			}
+		}
+	}
+	for i := len(chain) - 1; i >= 0; i-- {
+		for _, l := range chain[i].Links {
+			if l.Enabled && !l.Enforced {
+				applied = append(applied, l)
+			}
+		}
+		if chain[i].BlockInheritance {
+			break
+		}
+	}
+	return applied
+}
+
+// gpoScopeType returns the scope type of a container from its objectClass
+func gpoScopeType(e *ldap.Entry) string {
+	classes := e.GetEqualFoldAttributeValues(AttrObjectClass)
+	switch {
+	case containsFold(classes, "site"):
+		return GPOScopeSite
+	case containsFold(classes, "domainDNS"), containsFold(classes, "domain"):
+		return GPOScopeDomain
+	case containsFold(classes, "organizationalUnit"):
+		return GPOScopeOU
+	}
+	return ""
+}
+
+// isDNSuffix reports whether dn is parent or one of its descendants
+func isDNSuffix(dn, parent string) bool {
+	dn, parent = strings.ToLower(dn), strings.ToLower(parent)
+	return dn == parent || strings.HasSuffix(dn, ","+parent)
+}
+
+// dnDepth returns the number of RDNs of a DN
+func dnDepth(dn string) int {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return strings.Count(dn, ",") + 1
+	}
+	return len(parsed.RDNs)
+}
@@ -0,0 +1,58 @@
+package analyze
+
+import "strings"
+
+// privilegedDomainRIDs describes what membership of each privileged domain
+// group confers, keyed by RID
+// https://learn.microsoft.com/en-us/windows-server/identity/ad-ds/manage/understand-security-identifiers
+var privilegedDomainRIDs = map[string]string{
+	"512": "Full control of the domain",
+	"516": "Domain controller; DCSync",
+	"517": "Publish certificates to the directory",
+	"518": "Modify the forest schema",
+	"519": "Full control of the forest",
+	"520": "Create and link GPOs",
+	"526": "Write msDS-KeyCredentialLink (shadow credentials)",
+	"527": "Write msDS-KeyCredentialLink forest-wide (shadow credentials)",
+}
+
+// privilegedBuiltinSIDs describes what membership of each privileged builtin group confers
+var privilegedBuiltinSIDs = map[string]string{
+	"S-1-5-32-544": "Full control of domain controllers",
+	"S-1-5-32-548": "Create and modify most users and groups",
+	"S-1-5-32-549": "Log on to and manage domain controllers",
+	"S-1-5-32-550": "Load drivers on domain controllers",
+	"S-1-5-32-551": "Back up domain controllers (NTDS.dit)",
+	"S-1-5-32-578": "Manage Hyper-V hosting domain controllers",
+}
+
+// privilegedGroupNames covers privileged groups without a fixed RID
+var privilegedGroupNames = map[string]string{
+	"dnsadmins": "Load DLLs into the DNS service on domain controllers",
+}
+
+// GroupPrivilege returns what membership of a group confers, or "" if the
+// group is not a known privileged group. The group is identified by its
+// SID, or by its sAMAccountName for groups without a fixed RID.
+func GroupPrivilege(sid, name string) string {
+	if p, ok := privilegedBuiltinSIDs[sid]; ok {
+		return p
+	}
+	if strings.HasPrefix(sid, "S-1-5-21-") {
+		if i := strings.LastIndex(sid, "-"); i >= 0 {
+			if p, ok := privilegedDomainRIDs[sid[i+1:]]; ok {
+				return p
+			}
+		}
+	}
+	return privilegedGroupNames[strings.ToLower(name)]
+}
+
+// DomainSID returns the domain part of an account SID (the SID without its RID)
+func DomainSID(sid string) string {
+	if !strings.HasPrefix(sid, "S-1-5-21-") {
+		return ""
+	}
+	i := strings.LastIndex(sid, "-")
+	return sid[:i]
+}
@@ -2,7 +2,11 @@ package analyze
 
 import (
 	"encoding/binary"
+	"encoding/hex"
 	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
 )
 
 // ParseObjectGUID parses binary ObjectGUID to string format
@@ -25,6 +29,74 @@ func ParseObjectGUID(binaryGUID []byte) (string, error) {
 	return guid, nil
 }
 
+// EncodeObjectGUID converts a GUID string, with or without braces, to the
+// binary objectGUID layout (the reverse of ParseObjectGUID)
+func EncodeObjectGUID(guid string) ([]byte, error) {
+	s := strings.TrimSuffix(strings.TrimPrefix(strings.TrimSpace(guid), "{"), "}")
+	parts := strings.Split(s, "-")
+	if len(parts) != 5 || len(parts[0]) != 8 || len(parts[1]) != 4 || len(parts[2]) != 4 || len(parts[3]) != 4 || len(parts[4]) != 12 {
+		return nil, fmt.Errorf("invalid GUID format: %s", guid)
+	}
+	raw, err := hex.DecodeString(strings.Join(parts, ""))
+	if err != nil {
+		return nil, fmt.Errorf("invalid GUID format: %s", guid)
+	}
+
+	// The first three segments are stored little endian
+	b := make([]byte, 16)
+	binary.LittleEndian.PutUint32(b[0:4], binary.BigEndian.Uint32(raw[0:4]))
+	binary.LittleEndian.PutUint16(b[4:6], binary.BigEndian.Uint16(raw[4:6]))
+	binary.LittleEndian.PutUint16(b[6:8], binary.BigEndian.Uint16(raw[6:8]))
+	copy(b[8:], raw[8:])
+	return b, nil
+}
+
+// IsSIDString reports whether s looks like a string SID (S-1-...)
+func IsSIDString(s string) bool {
+	return strings.HasPrefix(strings.ToUpper(s), "S-1-")
+}
+
+// IsGUIDString reports whether s is a GUID, with or without braces
+func IsGUIDString(s string) bool {
+	_, err := EncodeObjectGUID(s)
+	return err == nil
+}
+
+// IdentifierFilter builds a search filter for a single object identified by
+// a SID, GUID, distinguished name, userPrincipalName or sAMAccountName.
+// Account names also match the computer account of the same name (name$).
+func IdentifierFilter(id string) (string, error) {
+	id = strings.TrimSpace(id)
+	switch {
+	case id == "":
+		return "", fmt.Errorf("empty identifier")
+	case IsSIDString(id):
+		return fmt.Sprintf("(%s=%s)", AttrObjectSID, ldap.EscapeFilter(strings.ToUpper(id))), nil
+	case IsGUIDString(id):
+		b, _ := EncodeObjectGUID(id)
+		var filter strings.Builder
+		filter.WriteString("(" + AttrObjectGUID + "=")
+		for _, c := range b {
+			fmt.Fprintf(&filter, "\\%02x", c)
+		}
+		filter.WriteString(")")
+		return filter.String(), nil
+	case strings.Contains(id, "=") && strings.Contains(strings.ToUpper(id), "DC="):
+		if _, err := ldap.ParseDN(id); err != nil {
+			return "", fmt.Errorf("invalid DN %q: %w", id, err)
+		}
+		return fmt.Sprintf("(%s=%s)", AttrDistinguishedName, ldap.EscapeFilter(id)), nil
+	case strings.Contains(id, "@"):
+		return fmt.Sprintf("(%s=%s)", AttrUserPrincipalName, ldap.EscapeFilter(id)), nil
+	default:
+		name := ldap.EscapeFilter(id)
+		if strings.HasSuffix(id, "$") {
+			return fmt.Sprintf("(%s=%s)", AttrSAMAccountName, name), nil
+		}
+		return fmt.Sprintf("(|(%s=%s)(%s=%s$))", AttrSAMAccountName, name, AttrSAMAccountName, name), nil
+	}
+}
+
 // ParseObjectSID parses binary ObjectSID to string format
 // binarySID: Binary representation of SID
 // Returns: Formatted SID string (e.g., "S-1-5-21-3623811015-3361044348-30300820-1013")
@@ -68,3 +140,26 @@ func ParseObjectSID(binarySID []byte) (string, error) {
 
 	return sid, nil
 }
+
+// ParseExtendedDN splits a DN returned with the extended DN control
+// (e.g., "<GUID=...>;<SID=S-1-5-21-...>;CN=User,DC=example,DC=com")
+// into the plain DN and its GUID and SID components.
+// Values without extended components are returned unchanged as the DN.
+func ParseExtendedDN(value string) (dn, guid, sid string) {
+	rest := value
+	for strings.HasPrefix(rest, "<") {
+		end := strings.Index(rest, ">")
+		if end < 0 {
+			break
+		}
+		part := rest[1:end]
+		switch {
+		case strings.HasPrefix(part, "GUID="):
+			guid = part[len("GUID="):]
+		case strings.HasPrefix(part, "SID="):
+			sid = part[len("SID="):]
+		}
+		rest = strings.TrimPrefix(rest[end+1:], ";")
+	}
+	return rest, guid, sid
+}
@@ -0,0 +1,102 @@
+package analyze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// LAPS password expiration attributes. Unlike the passwords, they are
+// readable by authenticated users, and LAPS sets them on every computer
+// it manages.
+const (
+	AttrMSMcsAdmPwdExpirationTime    = "ms-Mcs-AdmPwdExpirationTime"   // Legacy LAPS
+	AttrMSLAPSPasswordExpirationTime = "msLAPS-PasswordExpirationTime" // Windows LAPS
+)
+
+// LAPSAttributes are the computer attributes LAPSCoverage reads
+var LAPSAttributes = []string{
+	AttrSAMAccountName,
+	AttrUserAccountControl,
+	AttrOperatingSystem,
+	AttrMSMcsAdmPwdExpirationTime,
+	AttrMSLAPSPasswordExpirationTime,
+}
+
+// LAPSComputer is a computer without a LAPS-managed local administrator
+// password
+type LAPSComputer struct {
+	Name            string `json:"name"`
+	DN              string `json:"dn"`
+	OperatingSystem string `json:"operatingSystem,omitempty"`
+}
+
+// LAPSGap is an OU with computers not managed by LAPS
+type LAPSGap struct {
+	OU        string         `json:"ou"`
+	Computers int            `json:"computers"` // Enabled member computers directly in the OU
+	Unmanaged []LAPSComputer `json:"unmanaged"`
+}
+
+// HasLAPS reports whether a computer has a legacy or Windows LAPS
+// password expiration time set
+func HasLAPS(e *ldap.Entry) bool {
+	return e.GetEqualFoldAttributeValue(AttrMSMcsAdmPwdExpirationTime) != "" ||
+		e.GetEqualFoldAttributeValue(AttrMSLAPSPasswordExpirationTime) != ""
+}
+
+// LAPSCoverage groups the enabled member computers among entries by parent
+// OU and returns the OUs with computers lacking both LAPS expiration
+// attributes, most unmanaged computers first, and the number of computers
+// considered. Domain controllers have no local accounts and are skipped.
+func LAPSCoverage(entries []*ldap.Entry) ([]LAPSGap, int) {
+	gaps := make(map[string]*LAPSGap)
+	var order []string
+	total := 0
+	for _, e := range entries {
+		uac, _ := strconv.ParseUint(e.GetEqualFoldAttributeValue(AttrUserAccountControl), 10, 32)
+		if uac&(UF_ACCOUNTDISABLE|UF_SERVER_TRUST_ACCOUNT) != 0 {
+			continue
+		}
+		total++
+		ou := ParentDN(e.DN)
+		g, ok := gaps[strings.ToLower(ou)]
+		if !ok {
+			g = &LAPSGap{OU: ou}
+			gaps[strings.ToLower(ou)] = g
+			order = append(order, strings.ToLower(ou))
+		}
+		g.Computers++
+		if HasLAPS(e) {
+			continue
+		}
+		c := LAPSComputer{
+			Name:            e.GetEqualFoldAttributeValue(AttrSAMAccountName),
+			DN:              e.DN,
+			OperatingSystem: e.GetEqualFoldAttributeValue(AttrOperatingSystem),
+		}
+		if c.Name == "" {
+			c.Name = e.DN
+		}
+		g.Unmanaged = append(g.Unmanaged, c)
+	}
+
+	var result []LAPSGap
+	for _, key := range order {
+		if g := gaps[key]; len(g.Unmanaged) > 0 {
+			sort.Slice(g.Unmanaged, func(i, j int) bool {
+				return strings.ToLower(g.Unmanaged[i].Name) < strings.ToLower(g.Unmanaged[j].Name)
+			})
+			result = append(result, *g)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if len(result[i].Unmanaged) != len(result[j].Unmanaged) {
+			return len(result[i].Unmanaged) > len(result[j].Unmanaged)
+		}
+		return strings.ToLower(result[i].OU) < strings.ToLower(result[j].OU)
+	})
+	return result, total
+}
@@ -0,0 +1,79 @@
+package analyze
+
+import (
+	"sort"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// DefaultMachineAccountQuota is the ms-DS-MachineAccountQuota of a new domain
+const DefaultMachineAccountQuota = 10
+
+// MachineCreatorAttributes are the computer attributes MachineCreators reads
+var MachineCreatorAttributes = []string{
+	AttrSAMAccountName,
+	AttrMSDSCreatorSID,
+	AttrWhenCreated,
+}
+
+// MachineCreator is a principal that created computer accounts through the
+// machine account quota. Only those computers carry mS-DS-CreatorSID;
+// administrators and delegated OU owners create computers without it.
+type MachineCreator struct {
+	Creator     string    `json:"creator"`     // Account name; the SID if unresolved
+	CreatorSID  string    `json:"creatorSid"`  // mS-DS-CreatorSID of the computers
+	Computers   []string  `json:"computers"`   // sAMAccountNames of the computers created, oldest first
+	Used        int       `json:"used"`        // Quota consumed
+	Remaining   int       `json:"remaining"`   // Computers the principal can still create; 0 once the quota is exhausted
+	LastCreated time.Time `json:"lastCreated"` // whenCreated of the newest computer
+}
+
+// MachineCreators groups computers by mS-DS-CreatorSID, ordered by the
+// quota consumed. Creator names are left for the caller to resolve.
+func MachineCreators(computers []*ldap.Entry, quota int) []MachineCreator {
+	type created struct {
+		name string
+		when time.Time
+	}
+	bySID := make(map[string][]created)
+	for _, e := range computers {
+		raw := e.GetEqualFoldRawAttributeValues(AttrMSDSCreatorSID)
+		if len(raw) == 0 {
+			continue
+		}
+		sid, err := ParseObjectSID(raw[0])
+		if err != nil {
+			continue
+		}
+		name := e.GetEqualFoldAttributeValue(AttrSAMAccountName)
+		if name == "" {
+			name = e.DN
+		}
+		when, _ := time.Parse("20060102150405.0Z", e.GetEqualFoldAttributeValue(AttrWhenCreated))
+		bySID[sid] = append(bySID[sid], created{name, when})
+	}
+
+	creators := make([]MachineCreator, 0, len(bySID))
+	for sid, list := range bySID {
+		sort.SliceStable(list, func(i, j int) bool { return list[i].when.Before(list[j].when) })
+		c := MachineCreator{
+			Creator:     sid,
+			CreatorSID:  sid,
+			Used:        len(list),
+			Remaining:   max(quota-len(list), 0),
+			LastCreated: list[len(list)-1].when,
+		}
+		for _, comp := range list {
+			c.Computers = append(c.Computers, comp.name)
+		}
+		creators = append(creators, c)
+	}
+	sort.Slice(creators, func(i, j int) bool {
+		if creators[i].Used != creators[j].Used {
+			return creators[i].Used > creators[j].Used
+		}
+		return creators[i].CreatorSID < creators[j].CreatorSID
+	})
+	return creators
+}
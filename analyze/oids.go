@@ -12,5 +12,6 @@ const (
 
 	// LDAP Control OIDs
 	// These OIDs define LDAP extended operations and controls
-	OIDControlTypePaging = "1.2.840.113556.1.4.319" // LDAP_PAGED_RESULT
+	OIDControlTypePaging     = "1.2.840.113556.1.4.319" // LDAP_PAGED_RESULT
+	OIDControlTypeExtendedDN = "1.2.840.113556.1.4.529" // LDAP_SERVER_EXTENDED_DN_OID
 )
@@ -0,0 +1,127 @@
+package analyze
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// OSInventoryAttributes are the computer attributes OSInventory reads
+var OSInventoryAttributes = []string{
+	AttrSAMAccountName,
+	AttrOperatingSystem,
+	AttrOperatingSystemVersion,
+	AttrUserAccountControl,
+}
+
+// osLifecycle is the end of extended support of a Windows release, matched
+// as a substring of operatingSystem in order, so more specific names come
+// first. A zero end means the support period depends on more than the
+// name (LTSC channels), and the release is never flagged.
+var osLifecycle = []struct {
+	name string
+	end  time.Time
+}{
+	{"Windows 2000", utcDate(2010, 7, 13)},
+	{"Windows XP", utcDate(2014, 4, 8)},
+	{"Windows Server 2003", utcDate(2015, 7, 14)},
+	{"Windows Vista", utcDate(2017, 4, 11)},
+	{"Windows Server 2008", utcDate(2020, 1, 14)},
+	{"Windows 7", utcDate(2020, 1, 14)},
+	{"Windows 8.1", utcDate(2023, 1, 10)},
+	{"Windows 8", utcDate(2016, 1, 12)},
+	{"Windows Server 2012", utcDate(2023, 10, 10)},
+	{"Windows 10 Enterprise LTS", time.Time{}},
+	{"Windows 10 IoT Enterprise LTS", time.Time{}},
+	{"Windows 10", utcDate(2025, 10, 14)},
+	{"Windows Server 2016", utcDate(2027, 1, 12)},
+	{"Windows Server 2019", utcDate(2029, 1, 9)},
+	{"Windows Server 2022", utcDate(2031, 10, 14)},
+}
+
+// utcDate returns midnight UTC of a day
+func utcDate(year int, month time.Month, day int) time.Time {
+	return time.Date(year, month, day, 0, 0, 0, 0, time.UTC)
+}
+
+// osNameReplacer drops the trademark signs of names such as
+// "Windows Server® 2008 Enterprise"
+var osNameReplacer = strings.NewReplacer("®", "", "(R)", "", "™", "", "(TM)", "")
+
+// OSEndOfSupport returns the end of extended support of an operatingSystem
+// value, and false if it is not known
+func OSEndOfSupport(operatingSystem string) (time.Time, bool) {
+	name := strings.Join(strings.Fields(osNameReplacer.Replace(operatingSystem)), " ")
+	for _, l := range osLifecycle {
+		if strings.Contains(name, l.name) {
+			return l.end, !l.end.IsZero()
+		}
+	}
+	return time.Time{}, false
+}
+
+// OSVersion is a group of computers running the same operatingSystem and
+// operatingSystemVersion
+type OSVersion struct {
+	OperatingSystem string     `json:"operatingSystem"`        // Empty if the computers never reported one
+	Version         string     `json:"version"`                // operatingSystemVersion, e.g. "6.1 (7601)"
+	Computers       []string   `json:"computers"`              // sAMAccountNames of the computers, sorted
+	DCs             []string   `json:"dcs,omitempty"`          // Domain controllers among Computers
+	EndOfSupport    *time.Time `json:"endOfSupport,omitempty"` // End of extended support, if known
+	EOL             bool       `json:"eol"`                    // Support has ended
+}
+
+// OSInventory groups computers by operating system and version. Groups
+// past their end of support at now come first, then the largest groups.
+func OSInventory(computers []*ldap.Entry, now time.Time) []OSVersion {
+	type osKey struct{ os, version string }
+	groups := make(map[osKey]*OSVersion)
+	for _, e := range computers {
+		k := osKey{
+			strings.TrimSpace(e.GetEqualFoldAttributeValue(AttrOperatingSystem)),
+			strings.TrimSpace(e.GetEqualFoldAttributeValue(AttrOperatingSystemVersion)),
+		}
+		g, ok := groups[k]
+		if !ok {
+			g = &OSVersion{OperatingSystem: k.os, Version: k.version}
+			if end, known := OSEndOfSupport(k.os); known {
+				g.EndOfSupport = &end
+				g.EOL = !now.Before(end)
+			}
+			groups[k] = g
+		}
+
+		name := e.GetEqualFoldAttributeValue(AttrSAMAccountName)
+		if name == "" {
+			name = e.DN
+		}
+		g.Computers = append(g.Computers, name)
+		uac, _ := strconv.ParseUint(e.GetEqualFoldAttributeValue(AttrUserAccountControl), 10, 32)
+		if uac&UF_SERVER_TRUST_ACCOUNT != 0 {
+			g.DCs = append(g.DCs, name)
+		}
+	}
+
+	result := make([]OSVersion, 0, len(groups))
+	for _, g := range groups {
+		sort.Strings(g.Computers)
+		sort.Strings(g.DCs)
+		result = append(result, *g)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i], result[j]
+		switch {
+		case a.EOL != b.EOL:
+			return a.EOL
+		case len(a.Computers) != len(b.Computers):
+			return len(a.Computers) > len(b.Computers)
+		case a.OperatingSystem != b.OperatingSystem:
+			return a.OperatingSystem < b.OperatingSystem
+		}
+		return a.Version < b.Version
+	})
+	return result
+}
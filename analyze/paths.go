@@ -0,0 +1,453 @@
+package analyze
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Path edge kinds not produced by ExtractEdges
+const (
+	PathMemberOf          = "MemberOf"
+	PathAllowedToAct      = "AllowedToAct"
+	PathAllowedToDelegate = "AllowedToDelegate"
+	PathUnconstrained     = "UnconstrainedDelegation"
+	PathDCSync            = "DCSync"
+	PathGPLink            = "GPLink"
+	PathContains          = "Contains"
+)
+
+// Confidence labels of path steps and chains, from most to least reliable
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
+// Well-known groups every authenticated principal is a member of
+const (
+	sidEveryone           = "S-1-1-0"
+	sidAuthenticatedUsers = "S-1-5-11"
+)
+
+// pathStepFormats describes each step kind; the arguments are the source
+// and target names
+var pathStepFormats = map[string]string{
+	PathMemberOf:             "%s is a member of %s",
+	PathAllowedToAct:         "%s is trusted for RBCD to %s and can impersonate its admins",
+	PathAllowedToDelegate:    "%s can delegate to services of %s and impersonate its admins",
+	PathUnconstrained:        "%s has unconstrained delegation: coerce a DC of %s and capture its TGT",
+	PathDCSync:               "%s can DCSync %s",
+	PathGPLink:               "GPO %s applies to %s",
+	PathContains:             "%s contains %s",
+	EdgeOwns:                 "%s owns %s",
+	EdgeGenericAll:           "%s has full control of %s",
+	EdgeGenericWrite:         "%s can write the attributes of %s",
+	EdgeWriteDacl:            "%s can modify the DACL of %s",
+	EdgeWriteOwner:           "%s can take ownership of %s",
+	EdgeAllExtendedRights:    "%s holds all extended rights on %s",
+	EdgeForceChangePassword:  "%s can reset the password of %s",
+	EdgeAddMember:            "%s can add members to %s",
+	EdgeAddSelf:              "%s can add itself to %s",
+	EdgeWriteSPN:             "%s can set an SPN on %s and kerberoast it",
+	EdgeAddKeyCredentialLink: "%s can add shadow credentials to %s",
+	EdgeAddAllowedToAct:      "%s can write RBCD on %s",
+}
+
+// PathNode is an object of the path graph
+type PathNode struct {
+	ID   string `json:"id"`   // SID, or lowercase DN for objects without one
+	Name string `json:"name"` // sAMAccountName, GPO displayName or name
+	Type string `json:"type"` // BloodHound object type (User, Computer, Group, ...)
+}
+
+// pathEdge is a directed step from one node to another
+type pathEdge struct {
+	to         string
+	kind       string
+	confidence string
+}
+
+// PathGraph links principals to the objects they control. Build it with
+// NewPathGraph from the entries of the collect queries, read with the
+// extended DN control.
+type PathGraph struct {
+	nodes map[string]*PathNode
+	edges map[string][]pathEdge
+	dnIDs map[string]string // Lowercase DN to node ID
+}
+
+// PathStep is one step of an attack path
+type PathStep struct {
+	From        PathNode `json:"from"`
+	To          PathNode `json:"to"`
+	Kind        string   `json:"kind"` // Edge name or one of the Path* kinds
+	Confidence  string   `json:"confidence"`
+	Description string   `json:"description"`
+}
+
+// AttackPath is a chain of steps from the start principal to a target
+type AttackPath struct {
+	Target     PathNode   `json:"target"`
+	Confidence string     `json:"confidence"` // Lowest confidence of the steps
+	Steps      []PathStep `json:"steps"`
+}
+
+// NewPathGraph builds the path graph of entries: group memberships,
+// primary groups, abusable ACL edges, delegation, GPO links and
+// containment. quota is the machine account quota, which decides whether
+// RBCD writes can be abused without controlling a computer account.
+func NewPathGraph(entries []*ldap.Entry, quota int) *PathGraph {
+	g := &PathGraph{
+		nodes: make(map[string]*PathNode),
+		edges: make(map[string][]pathEdge),
+		dnIDs: make(map[string]string),
+	}
+
+	types := make([]string, len(entries))
+	ids := make([]string, len(entries))
+	hosts := make(map[string]string) // Lowercase host name to computer ID
+	for i, e := range entries {
+		types[i] = pathNodeType(e)
+		if types[i] == "" {
+			continue
+		}
+		id := pathEntrySID(e)
+		if id == "" || types[i] == "OU" || types[i] == "GPO" || types[i] == "Container" {
+			id = strings.ToLower(e.DN)
+		}
+		ids[i] = id
+		g.nodes[id] = &PathNode{ID: id, Name: pathEntryName(e), Type: types[i]}
+		g.dnIDs[strings.ToLower(e.DN)] = id
+		if types[i] == "Computer" {
+			if host := e.GetEqualFoldAttributeValue(AttrDNSHostName); host != "" {
+				hosts[strings.ToLower(host)] = id
+				short, _, _ := strings.Cut(host, ".")
+				hosts[strings.ToLower(short)] = id
+			}
+		}
+	}
+
+	for i, e := range entries {
+		id := ids[i]
+		if id == "" {
+			continue
+		}
+
+		for _, v := range e.GetEqualFoldAttributeValues(AttrMember) {
+			dn, _, sid := ParseExtendedDN(v)
+			if member := g.nodeID(dn, sid); member != "" {
+				g.addEdge(member, id, PathMemberOf, ConfidenceHigh)
+			}
+		}
+		if rid := e.GetEqualFoldAttributeValue(AttrPrimaryGroupID); rid != "" && DomainSID(id) != "" {
+			if group := DomainSID(id) + "-" + rid; g.nodes[group] != nil {
+				g.addEdge(id, group, PathMemberOf, ConfidenceHigh)
+			}
+		}
+
+		if raw := e.GetEqualFoldRawAttributeValues(AttrNTSecurityDescriptor); len(raw) > 0 {
+			g.addACLEdges(raw[0], id, types[i], quota)
+		}
+
+		for _, d := range Delegations(e) {
+			switch d.Kind {
+			case DelegationResourceBased:
+				if trustee := g.nodeID("", d.SourceSID); trustee != "" {
+					g.addEdge(trustee, id, PathAllowedToAct, ConfidenceHigh)
+				}
+			case DelegationConstrained, DelegationProtocolTransition:
+				host, _ := SPNHost(d.Service)
+				target := hosts[strings.ToLower(host)]
+				if target == "" || target == id {
+					continue
+				}
+				confidence := ConfidenceMedium
+				if d.Kind == DelegationProtocolTransition {
+					confidence = ConfidenceHigh
+				}
+				g.addEdge(id, target, PathAllowedToDelegate, confidence)
+			case DelegationUnconstrained:
+				if !d.DC {
+					if domain := g.domainOf(e.DN); domain != "" {
+						g.addEdge(id, domain, PathUnconstrained, ConfidenceLow)
+					}
+				}
+			}
+		}
+
+		for _, link := range ParseGPLink(e.GetEqualFoldAttributeValue(AttrGPLink)) {
+			if gpo := g.dnIDs[strings.ToLower(link.DN)]; gpo != "" && link.Enabled {
+				g.addEdge(gpo, id, PathGPLink, ConfidenceMedium)
+			}
+		}
+
+		if parent := g.dnIDs[strings.ToLower(ParentDN(e.DN))]; parent != "" {
+			switch g.nodes[parent].Type {
+			case "Domain", "OU", "Container":
+				g.addEdge(parent, id, PathContains, ConfidenceMedium)
+			}
+		}
+	}
+	return g
+}
+
+// addACLEdges adds the abusable rights of the security descriptor of the
+// object id. Replication rights only count once a principal holds both.
+func (g *PathGraph) addACLEdges(sd []byte, id, objectType string, quota int) {
+	acl, err := ExtractEdges(sd, objectType)
+	if err != nil {
+		return
+	}
+	replication := make(map[string]int)
+	for _, edge := range acl.Edges {
+		principal := g.nodeID("", edge.PrincipalSID)
+		if principal == "" || principal == id {
+			continue
+		}
+		switch edge.RightName {
+		case EdgeGetChanges, EdgeGetChangesAll:
+			if replication[principal]++; replication[principal] == 2 {
+				g.addEdge(principal, id, PathDCSync, ConfidenceHigh)
+			}
+			continue
+		}
+		if confidence := pathRightConfidence(edge.RightName, objectType, quota); confidence != "" {
+			g.addEdge(principal, id, edge.RightName, confidence)
+		}
+	}
+}
+
+// pathRightConfidence rates how reliably a right over an object of
+// objectType leads to controlling it, or returns "" if it does not
+func pathRightConfidence(right, objectType string, quota int) string {
+	switch right {
+	case EdgeGenericAll, EdgeWriteDacl, EdgeWriteOwner, EdgeOwns:
+		if objectType == "Computer" && quota == 0 {
+			return ConfidenceMedium
+		}
+		if objectType == "GPO" || objectType == "OU" || objectType == "Container" {
+			return ConfidenceMedium
+		}
+		return ConfidenceHigh
+	case EdgeGenericWrite:
+		switch objectType {
+		case "Group":
+			return ConfidenceHigh
+		case "Computer":
+			if quota > 0 {
+				return ConfidenceHigh
+			}
+		case "Domain":
+			return ""
+		}
+		return ConfidenceMedium
+	case EdgeAddAllowedToAct:
+		if quota > 0 {
+			return ConfidenceHigh
+		}
+		return ConfidenceMedium
+	case EdgeForceChangePassword, EdgeAddMember, EdgeAddSelf:
+		return ConfidenceHigh
+	case EdgeAllExtendedRights:
+		switch objectType {
+		case "User", "Domain":
+			return ConfidenceHigh
+		case "Computer":
+			return ConfidenceMedium // Only through a readable LAPS password
+		}
+	case EdgeAddKeyCredentialLink:
+		return ConfidenceMedium
+	case EdgeWriteSPN:
+		return ConfidenceLow
+	}
+	return ""
+}
+
+// nodeID returns the node of a member or principal, by SID when known and
+// by DN otherwise. SIDs outside the graph, such as foreign or well-known
+// principals, get a node named after the SID.
+func (g *PathGraph) nodeID(dn, sid string) string {
+	if sid != "" {
+		if g.nodes[sid] == nil {
+			if id := g.dnIDs[strings.ToLower(dn)]; id != "" {
+				return id
+			}
+			name := WellKnownSIDName(sid)
+			if name == "" {
+				name = sid
+			}
+			g.nodes[sid] = &PathNode{ID: sid, Name: name, Type: "Group"}
+		}
+		return sid
+	}
+	return g.dnIDs[strings.ToLower(dn)]
+}
+
+// domainOf returns the domain node whose DN is the deepest suffix of dn
+func (g *PathGraph) domainOf(dn string) string {
+	lower := strings.ToLower(dn)
+	best := ""
+	for dnKey, id := range g.dnIDs {
+		if g.nodes[id].Type != "Domain" || !strings.HasSuffix(lower, dnKey) {
+			continue
+		}
+		if best == "" || len(dnKey) > len(best) {
+			best = dnKey
+		}
+	}
+	return g.dnIDs[best]
+}
+
+// addEdge adds an edge, keeping the most reliable one per kind
+func (g *PathGraph) addEdge(from, to, kind, confidence string) {
+	for i, e := range g.edges[from] {
+		if e.to == to && e.kind == kind {
+			if confidenceRank(confidence) > confidenceRank(e.confidence) {
+				g.edges[from][i].confidence = confidence
+			}
+			return
+		}
+	}
+	g.edges[from] = append(g.edges[from], pathEdge{to: to, kind: kind, confidence: confidence})
+}
+
+// Node returns the node of a SID or DN, if it is part of the graph
+func (g *PathGraph) Node(id string) (PathNode, bool) {
+	if n := g.nodes[id]; n != nil {
+		return *n, true
+	}
+	if n := g.nodes[g.dnIDs[strings.ToLower(id)]]; n != nil {
+		return *n, true
+	}
+	return PathNode{}, false
+}
+
+// isPathTarget reports whether a node is Domain Admins, Enterprise Admins,
+// Administrators or a domain object
+func isPathTarget(n *PathNode) bool {
+	return n.Type == "Domain" || n.ID == "S-1-5-32-544" ||
+		(DomainSID(n.ID) != "" && (strings.HasSuffix(n.ID, "-512") || strings.HasSuffix(n.ID, "-519")))
+}
+
+// FindAttackPaths searches the graph breadth-first from the principal with
+// SID start, and Everyone and Authenticated Users, toward Domain Admins,
+// Enterprise Admins, Administrators and the domain objects. It returns
+// the shortest chain to each reached target, shortest first, at most max
+// paths if max > 0.
+func FindAttackPaths(g *PathGraph, start string, max int) []AttackPath {
+	prev := make(map[string]pathEdge) // Node to the edge reaching it, with to set to the source
+	visited := make(map[string]bool)
+	var queue []string
+	for _, id := range []string{start, sidEveryone, sidAuthenticatedUsers} {
+		if g.nodes[id] != nil && !visited[id] {
+			visited[id] = true
+			queue = append(queue, id)
+		}
+	}
+
+	var paths []AttackPath
+	for len(queue) > 0 && (max <= 0 || len(paths) < max) {
+		id := queue[0]
+		queue = queue[1:]
+		if _, reached := prev[id]; reached && isPathTarget(g.nodes[id]) {
+			paths = append(paths, g.buildPath(id, prev))
+			continue
+		}
+		for _, e := range g.edges[id] {
+			if visited[e.to] {
+				continue
+			}
+			visited[e.to] = true
+			prev[e.to] = pathEdge{to: id, kind: e.kind, confidence: e.confidence}
+			queue = append(queue, e.to)
+		}
+	}
+	return paths
+}
+
+// buildPath walks prev back from target to a start node
+func (g *PathGraph) buildPath(target string, prev map[string]pathEdge) AttackPath {
+	path := AttackPath{Target: *g.nodes[target], Confidence: ConfidenceHigh}
+	for id := target; ; {
+		e, ok := prev[id]
+		if !ok {
+			break
+		}
+		from, to := *g.nodes[e.to], *g.nodes[id]
+		path.Steps = append(path.Steps, PathStep{
+			From:        from,
+			To:          to,
+			Kind:        e.kind,
+			Confidence:  e.confidence,
+			Description: fmt.Sprintf(pathStepFormats[e.kind], from.Name, to.Name),
+		})
+		if confidenceRank(e.confidence) < confidenceRank(path.Confidence) {
+			path.Confidence = e.confidence
+		}
+		id = e.to
+	}
+	slices.Reverse(path.Steps)
+	return path
+}
+
+// confidenceRank orders confidence labels, higher is more reliable
+func confidenceRank(confidence string) int {
+	switch confidence {
+	case ConfidenceHigh:
+		return 3
+	case ConfidenceMedium:
+		return 2
+	case ConfidenceLow:
+		return 1
+	}
+	return 0
+}
+
+// pathNodeType returns the BloodHound type of an entry, or "" for objects
+// the path graph ignores
+func pathNodeType(e *ldap.Entry) string {
+	classes := e.GetEqualFoldAttributeValues(AttrObjectClass)
+	has := func(class string) bool {
+		return slices.ContainsFunc(classes, func(c string) bool { return strings.EqualFold(c, class) })
+	}
+	switch {
+	case has("computer"):
+		return "Computer"
+	case has("user"):
+		return "User"
+	case has("group"):
+		return "Group"
+	case has("domainDNS"), has("domain"):
+		return "Domain"
+	case has("groupPolicyContainer"):
+		return "GPO"
+	case has("organizationalUnit"):
+		return "OU"
+	case has("container"):
+		return "Container"
+	}
+	return ""
+}
+
+// pathEntrySID returns the objectSid of an entry, or ""
+func pathEntrySID(e *ldap.Entry) string {
+	raw := e.GetEqualFoldRawAttributeValues(AttrObjectSID)
+	if len(raw) == 0 {
+		return ""
+	}
+	sid, _ := ParseObjectSID(raw[0])
+	return sid
+}
+
+// pathEntryName returns the display name of a path node
+func pathEntryName(e *ldap.Entry) string {
+	for _, attr := range []string{AttrSAMAccountName, AttrDisplayName, AttrName} {
+		if v := e.GetEqualFoldAttributeValue(attr); v != "" {
+			return v
+		}
+	}
+	return e.DN
+}
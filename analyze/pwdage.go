@@ -0,0 +1,81 @@
+package analyze
+
+import (
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Password age buckets, youngest first
+const (
+	PasswordAgeUnder90Days = "<90d"
+	PasswordAge90To365Days = "90-365d"
+	PasswordAge1To3Years   = "1-3y"
+	PasswordAgeOver3Years  = ">3y"
+	PasswordAgeNeverSet    = "never set"
+)
+
+// PasswordAgeBuckets lists the buckets in display order
+var PasswordAgeBuckets = []string{
+	PasswordAgeUnder90Days,
+	PasswordAge90To365Days,
+	PasswordAge1To3Years,
+	PasswordAgeOver3Years,
+	PasswordAgeNeverSet,
+}
+
+// Account kinds password ages are broken down by
+const (
+	AccountKindUser           = "user"
+	AccountKindServiceAccount = "service account" // User with an SPN
+	AccountKindComputer       = "computer"
+	AccountKindManaged        = "managed service account"
+	AccountKindOther          = "other"
+)
+
+// PasswordAgeAttributes are the attributes PasswordAgeBucket and
+// AccountKind read
+var PasswordAgeAttributes = []string{AttrPwdLastSet, AttrObjectClass, AttrServicePrincipalName}
+
+// PasswordAgeBucket returns the age bucket of a pwdLastSet value at now.
+// A missing or zero pwdLastSet (must change at next logon) is "never set".
+func PasswordAgeBucket(pwdLastSet string, now time.Time) string {
+	set, ok := FileTime(pwdLastSet)
+	if !ok {
+		return PasswordAgeNeverSet
+	}
+	age := now.Sub(set)
+	switch {
+	case age < 90*24*time.Hour:
+		return PasswordAgeUnder90Days
+	case age < 365*24*time.Hour:
+		return PasswordAge90To365Days
+	case age < 3*365*24*time.Hour:
+		return PasswordAge1To3Years
+	}
+	return PasswordAgeOver3Years
+}
+
+// AccountKind classifies an account by objectClass and SPNs. Managed
+// service accounts rotate their passwords; users with an SPN are service
+// accounts, whose passwords are often set once and never changed.
+func AccountKind(e *ldap.Entry) string {
+	classes := e.GetEqualFoldAttributeValues(AttrObjectClass)
+	has := func(class string) bool {
+		return slices.ContainsFunc(classes, func(c string) bool { return strings.EqualFold(c, class) })
+	}
+	switch {
+	case has("msDS-GroupManagedServiceAccount"), has("msDS-ManagedServiceAccount"), has("msDS-DelegatedManagedServiceAccount"):
+		return AccountKindManaged
+	case has("computer"):
+		return AccountKindComputer
+	case has("user"):
+		if len(e.GetEqualFoldAttributeValues(AttrServicePrincipalName)) > 0 {
+			return AccountKindServiceAccount
+		}
+		return AccountKindUser
+	}
+	return AccountKindOther
+}
@@ -0,0 +1,46 @@
+package analyze
+
+import (
+	"sort"
+	"strings"
+)
+
+// Relay target statuses
+const (
+	RelayViable    = "viable"
+	RelayProtected = "protected"
+	RelayUnknown   = "unknown"
+)
+
+// Relay target services
+const (
+	RelayServiceLDAP          = "LDAP"
+	RelayServiceLDAPS         = "LDAPS"
+	RelayServiceWebEnrollment = "AD CS web enrollment"
+	RelayServiceUnconstrained = "Unconstrained delegation"
+)
+
+// RelayTarget is a service NTLM authentication could be relayed to, or
+// for unconstrained delegation a host to coerce DC authentication to
+type RelayTarget struct {
+	Service string `json:"service"` // One of the RelayService* services
+	Host    string `json:"host"`
+	Status  string `json:"status"` // One of the Relay* statuses
+	Detail  string `json:"detail,omitempty"`
+}
+
+// SortRelayTargets orders targets by status, viable first, then by
+// service and host
+func SortRelayTargets(targets []RelayTarget) {
+	rank := map[string]int{RelayViable: 0, RelayUnknown: 1, RelayProtected: 2}
+	sort.SliceStable(targets, func(i, j int) bool {
+		a, b := targets[i], targets[j]
+		if rank[a.Status] != rank[b.Status] {
+			return rank[a.Status] < rank[b.Status]
+		}
+		if a.Service != b.Service {
+			return a.Service < b.Service
+		}
+		return strings.ToLower(a.Host) < strings.ToLower(b.Host)
+	})
+}
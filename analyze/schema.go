@@ -0,0 +1,166 @@
+package analyze
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// functionalLevels maps msDS-Behavior-Version to Windows Server versions
+var functionalLevels = map[int]string{
+	0:  "2000 Mixed/Native",
+	1:  "2003 Interim",
+	2:  "2003",
+	3:  "2008",
+	4:  "2008 R2",
+	5:  "2012",
+	6:  "2012 R2",
+	7:  "2016",
+	10: "2025",
+}
+
+// FunctionalLevel returns the Windows Server version of a domain or forest
+// msDS-Behavior-Version value, or "" if it is not known
+func FunctionalLevel(value string) string {
+	v, err := strconv.Atoi(value)
+	if err != nil {
+		return ""
+	}
+	return functionalLevels[v]
+}
+
+// schemaVersions maps the objectVersion of the schema to the Windows
+// Server version that introduced it
+var schemaVersions = map[int]string{
+	13: "2000",
+	30: "2003",
+	31: "2003 R2",
+	44: "2008",
+	47: "2008 R2",
+	56: "2012",
+	69: "2012 R2",
+	87: "2016",
+	88: "2019/2022",
+	91: "2025",
+}
+
+// SchemaVersion returns the Windows Server version of a schema
+// objectVersion, or "" if it is not known
+func SchemaVersion(objectVersion int) string {
+	return schemaVersions[objectVersion]
+}
+
+// SchemaExtension is a product that extends the schema, detected by one of
+// its attributes (cn or lDAPDisplayName)
+type SchemaExtension struct {
+	Name       string
+	Attributes []string
+}
+
+// SchemaExtensions are the extensions the schema command reports
+var SchemaExtensions = []SchemaExtension{
+	{Name: "Legacy LAPS", Attributes: []string{"ms-Mcs-AdmPwd"}},
+	{Name: "Windows LAPS", Attributes: []string{"msLAPS-Password", "ms-LAPS-Password"}},
+	{Name: "Exchange", Attributes: []string{AttrMSExchSchemaVersionPt}},
+	{Name: "SCCM", Attributes: []string{"mS-SMS-Site-Code", "mSSMSSiteCode"}},
+}
+
+// SchemaExtensionAttributes are the attributeSchema attributes
+// SchemaExtensionsPresent reads
+var SchemaExtensionAttributes = []string{AttrCN, AttrLDAPDisplayName, AttrRangeUpper}
+
+// SchemaExtensionFilter matches the attributeSchema objects of all
+// SchemaExtensions
+func SchemaExtensionFilter() string {
+	var filter strings.Builder
+	filter.WriteString("(|")
+	for _, ext := range SchemaExtensions {
+		for _, attr := range ext.Attributes {
+			fmt.Fprintf(&filter, "(%s=%s)(%s=%s)", AttrCN, attr, AttrLDAPDisplayName, attr)
+		}
+	}
+	filter.WriteString(")")
+	return filter.String()
+}
+
+// SchemaExtensionsPresent reports which SchemaExtensions the
+// attributeSchema entries found by SchemaExtensionFilter belong to. The
+// Exchange release is read from the rangeUpper of its schema version
+// attribute.
+func SchemaExtensionsPresent(entries []*ldap.Entry) []ExtensionPresent {
+	result := make([]ExtensionPresent, len(SchemaExtensions))
+	for i, ext := range SchemaExtensions {
+		result[i].Name = ext.Name
+		for _, e := range entries {
+			if !containsFold(ext.Attributes, e.GetEqualFoldAttributeValue(AttrCN)) &&
+				!containsFold(ext.Attributes, e.GetEqualFoldAttributeValue(AttrLDAPDisplayName)) {
+				continue
+			}
+			result[i].Present = true
+			if v, err := strconv.Atoi(e.GetEqualFoldAttributeValue(AttrRangeUpper)); err == nil && ext.Name == "Exchange" {
+				result[i].Version = fmt.Sprintf("%s (%d)", ExchangeVersion(v), v)
+			}
+		}
+	}
+	return result
+}
+
+// SchemaReport is the schema version, functional levels and extensions of
+// a forest and domain
+type SchemaReport struct {
+	SchemaVersion int                `json:"schemaVersion"` // objectVersion of the schema, -1 if not read
+	Schema        string             `json:"schema"`        // Windows Server version of the schema
+	DomainLevel   int                `json:"domainLevel"`   // msDS-Behavior-Version of the domain
+	Domain        string             `json:"domain"`        // Windows Server version of the domain level
+	ForestLevel   int                `json:"forestLevel"`   // msDS-Behavior-Version of the forest, -1 if not read
+	Forest        string             `json:"forest"`        // Windows Server version of the forest level
+	Extensions    []ExtensionPresent `json:"extensions"`
+	Notes         []string           `json:"notes"` // What the versions mean for attacks and defenses
+}
+
+// ExtensionPresent reports whether a schema extension is present
+type ExtensionPresent struct {
+	Name    string `json:"name"`
+	Present bool   `json:"present"`
+	Version string `json:"version,omitempty"` // Release, for Exchange
+}
+
+// Extension returns whether the report lists the named extension as present
+func (r SchemaReport) Extension(name string) bool {
+	for _, e := range r.Extensions {
+		if e.Name == name {
+			return e.Present
+		}
+	}
+	return false
+}
+
+// SchemaNotes returns what the versions and extensions of a report imply
+// for attacks and defenses
+func SchemaNotes(r SchemaReport) []string {
+	var notes []string
+	if r.SchemaVersion >= 91 {
+		notes = append(notes, "Schema 2025: delegated managed service accounts (dMSA) exist; check who can create them (BadSuccessor)")
+	}
+	if r.SchemaVersion >= 56 {
+		notes = append(notes, "Schema 2012 or later: group managed service accounts need a KDS root key; check who can read gMSA passwords")
+	}
+	if r.DomainLevel < 3 {
+		notes = append(notes, "Domain level below 2008: accounts have no AES Kerberos keys and tickets use RC4")
+	}
+	if r.DomainLevel < 6 {
+		notes = append(notes, "Domain level below 2012 R2: Protected Users and authentication policy silos are not fully enforced by DCs")
+	}
+	if len(r.Extensions) > 0 && !r.Extension("Legacy LAPS") && !r.Extension("Windows LAPS") {
+		notes = append(notes, "No LAPS schema: local administrator passwords are not managed by LAPS and are likely shared")
+	}
+	if r.Extension("Exchange") {
+		notes = append(notes, "Exchange schema: Exchange groups often hold WriteDACL on the domain object")
+	}
+	if r.Extension("SCCM") {
+		notes = append(notes, "SCCM schema: look for management points, network access account credentials and client push accounts")
+	}
+	return notes
+}
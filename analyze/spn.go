@@ -0,0 +1,255 @@
+package analyze
+
+import (
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// SPN issue kinds
+const (
+	SPNDuplicate   = "Duplicate"
+	SPNMalformed   = "Malformed"
+	SPNUnknownHost = "Unknown host"
+)
+
+// SPNAttributes are the account attributes SPN checks read
+var SPNAttributes = []string{AttrSAMAccountName, AttrServicePrincipalName}
+
+// SPNHostAttributes are the computer attributes KnownHosts reads
+var SPNHostAttributes = []string{AttrSAMAccountName, AttrDNSHostName, AttrMSDSAdditionalDNSHostName}
+
+// SPNIssue is a servicePrincipalName that is registered more than once,
+// does not parse, or names a host that is not a computer of the domain
+type SPNIssue struct {
+	Kind     string   `json:"kind"`           // One of the SPN* kinds
+	SPN      string   `json:"spn"`            // The SPN as registered on the first account
+	Host     string   `json:"host,omitempty"` // Host part, for unknown hosts
+	Accounts []string `json:"accounts"`       // Accounts the SPN is registered on
+}
+
+// spnClasses maps lowercase SPN service classes to the service they
+// identify; other service classes are shown as registered
+var spnClasses = map[string]string{
+	"mssqlsvc":                          "MSSQL",
+	"http":                              "HTTP",
+	"www":                               "HTTP",
+	"cifs":                              "CIFS",
+	"wsman":                             "WinRM",
+	"termsrv":                           "RDP",
+	"exchangemdb":                       "Exchange",
+	"exchangerfr":                       "Exchange",
+	"exchangeab":                        "Exchange",
+	"smtp":                              "SMTP",
+	"smtpsvc":                           "SMTP",
+	"imap":                              "IMAP",
+	"pop":                               "POP3",
+	"ldap":                              "LDAP",
+	"gc":                                "Global Catalog",
+	"dns":                               "DNS",
+	"host":                              "Host",
+	"restrictedkrbhost":                 "Host",
+	"kadmin":                            "Kerberos",
+	"ftp":                               "FTP",
+	"nfs":                               "NFS",
+	"msolapsvc.3":                       "SSAS",
+	"msserverclustermgmtapi":            "Failover Cluster",
+	"msservercluster":                   "Failover Cluster",
+	"microsoft virtual console service": "Hyper-V",
+	"microsoft virtual system migration service": "Hyper-V",
+	"hyper-v replica service":                    "Hyper-V",
+	"oracle":                                     "Oracle",
+	"postgres":                                   "PostgreSQL",
+	"mysql":                                      "MySQL",
+	"wsbsvc":                                     "Windows Backup",
+	"dfsr-12f9a27c-bf97-4787-9364-d31b6c55eb04": "DFS Replication",
+	"e3514235-4b06-11d1-ab04-00c04fc2dcd2":      "AD Replication",
+}
+
+// SPN is a parsed servicePrincipalName of the form
+// service/host[:port][/name]
+type SPN struct {
+	Service string // Service class as registered, e.g. "MSSQLSvc"
+	Class   string // Service identified, e.g. "MSSQL"; Service if unknown
+	Host    string // Host name, without the port
+	Port    int    // Port, or 0 if none is given
+	Name    string // Service name, e.g. the domain of ldap/dc01/corp.local
+}
+
+// ParseSPN parses an SPN, returning false if it is malformed
+func ParseSPN(spn string) (SPN, bool) {
+	// Service classes may contain spaces ("Microsoft Virtual Console
+	// Service"), host names may not
+	parts := strings.Split(spn, "/")
+	if len(parts) < 2 || len(parts) > 3 || parts[0] == "" || parts[1] == "" ||
+		strings.ContainsAny(spn, "\t\r\n") || strings.ContainsAny(strings.Join(parts[1:], "/"), " ") {
+		return SPN{}, false
+	}
+	s := SPN{Service: parts[0], Class: SPNClass(parts[0]), Host: parts[1]}
+	if h, port, ok := strings.Cut(s.Host, ":"); ok {
+		n, err := strconv.Atoi(port)
+		if err != nil || n < 1 || n > 65535 || h == "" {
+			return SPN{}, false
+		}
+		s.Host, s.Port = h, n
+	}
+	if len(parts) == 3 {
+		s.Name = parts[2]
+	}
+	return s, true
+}
+
+// SPNClass returns the service an SPN service class identifies
+func SPNClass(service string) string {
+	if class, ok := spnClasses[strings.ToLower(service)]; ok {
+		return class
+	}
+	return service
+}
+
+// Endpoint returns the host and, if given, the port of the SPN
+func (s SPN) Endpoint() string {
+	if s.Port == 0 {
+		return s.Host
+	}
+	return s.Host + ":" + strconv.Itoa(s.Port)
+}
+
+// SPNHost returns the host of an SPN of the form service/host[:port][/name],
+// and false if the SPN does not have that form
+func SPNHost(spn string) (string, bool) {
+	s, ok := ParseSPN(spn)
+	return s.Host, ok
+}
+
+// FormatSPNs lists the SPNs of an entry, each followed by the service it
+// identifies, e.g. "MSSQLSvc/sql01:1433 [MSSQL]"
+func FormatSPNs(entry *ldap.Entry, attribute string) (string, error) {
+	values := entry.GetEqualFoldAttributeValues(attribute)
+	formatted := make([]string, len(values))
+	for i, v := range values {
+		if s, ok := ParseSPN(v); ok {
+			formatted[i] = v + " [" + s.Class + "]"
+		} else {
+			formatted[i] = v + " [malformed]"
+		}
+	}
+	return strings.Join(formatted, ", "), nil
+}
+
+// DuplicateSPNs returns the SPNs registered on more than one account,
+// compared case-insensitively as the KDC does. A duplicate SPN makes the
+// KDC fail or pick either account when issuing tickets for the service.
+func DuplicateSPNs(entries []*ldap.Entry) []SPNIssue {
+	bySPN := make(map[string]*SPNIssue)
+	var order []string
+	for _, e := range entries {
+		name := spnAccountName(e)
+		for _, spn := range e.GetEqualFoldAttributeValues(AttrServicePrincipalName) {
+			key := strings.ToLower(spn)
+			issue, ok := bySPN[key]
+			if !ok {
+				issue = &SPNIssue{Kind: SPNDuplicate, SPN: spn}
+				bySPN[key] = issue
+				order = append(order, key)
+			}
+			if !containsFold(issue.Accounts, name) {
+				issue.Accounts = append(issue.Accounts, name)
+			}
+		}
+	}
+
+	var result []SPNIssue
+	for _, key := range order {
+		if issue := bySPN[key]; len(issue.Accounts) > 1 {
+			sort.Strings(issue.Accounts)
+			result = append(result, *issue)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool { return strings.ToLower(result[i].SPN) < strings.ToLower(result[j].SPN) })
+	return result
+}
+
+// KnownHosts returns the lowercased DNS and NetBIOS names of computers,
+// and of the given domain names, which SPNs such as ldap/corp.local use
+func KnownHosts(computers []*ldap.Entry, domains ...string) map[string]bool {
+	known := make(map[string]bool)
+	for _, domain := range domains {
+		if domain != "" {
+			known[strings.ToLower(domain)] = true
+		}
+	}
+	for _, e := range computers {
+		if name := strings.TrimSuffix(e.GetEqualFoldAttributeValue(AttrSAMAccountName), "$"); name != "" {
+			known[strings.ToLower(name)] = true
+		}
+		for _, attr := range []string{AttrDNSHostName, AttrMSDSAdditionalDNSHostName} {
+			for _, host := range e.GetEqualFoldAttributeValues(attr) {
+				known[strings.ToLower(host)] = true
+			}
+		}
+	}
+	return known
+}
+
+// SPNHostIssues returns the malformed SPNs of entries and those naming a
+// host that is not in known. SPNs whose host cannot be a computer name
+// (GUID-based replication SPNs, _msdcs aliases, IP addresses and
+// kadmin/changepw) are not checked against known.
+func SPNHostIssues(entries []*ldap.Entry, known map[string]bool) []SPNIssue {
+	var result []SPNIssue
+	for _, e := range entries {
+		name := spnAccountName(e)
+		for _, spn := range e.GetEqualFoldAttributeValues(AttrServicePrincipalName) {
+			host, ok := SPNHost(spn)
+			switch {
+			case !ok:
+				result = append(result, SPNIssue{Kind: SPNMalformed, SPN: spn, Accounts: []string{name}})
+			case !checkSPNHost(spn, host):
+			case !known[strings.ToLower(host)]:
+				result = append(result, SPNIssue{Kind: SPNUnknownHost, SPN: spn, Host: host, Accounts: []string{name}})
+			}
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool {
+		if result[i].Kind != result[j].Kind {
+			return result[i].Kind < result[j].Kind
+		}
+		return strings.ToLower(result[i].SPN) < strings.ToLower(result[j].SPN)
+	})
+	return result
+}
+
+// checkSPNHost reports whether the host of an SPN should name a computer
+func checkSPNHost(spn, host string) bool {
+	service, _, _ := strings.Cut(spn, "/")
+	switch {
+	case strings.EqualFold(service, "kadmin"),
+		IsGUIDString(service), IsGUIDString(host),
+		strings.Contains(strings.ToLower(host), "._msdcs."),
+		net.ParseIP(host) != nil:
+		return false
+	}
+	return true
+}
+
+// spnAccountName returns the sAMAccountName of an entry, or its DN
+func spnAccountName(e *ldap.Entry) string {
+	if name := e.GetEqualFoldAttributeValue(AttrSAMAccountName); name != "" {
+		return name
+	}
+	return e.DN
+}
+
+// containsFold reports whether list contains s, ignoring case
+func containsFold(list []string, s string) bool {
+	for _, v := range list {
+		if strings.EqualFold(v, s) {
+			return true
+		}
+	}
+	return false
+}
@@ -3,6 +3,7 @@ package analyze
 import (
 	"fmt"
 	"strconv"
+	"strings"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
@@ -21,6 +22,57 @@ const (
 	NanoSecondsPerHundredNanoSeconds = 100
 )
 
+// Time output settings, changed with SetTimeFormat.
+// Defaults render every timestamp in UTC as "2006-01-02 15:04:05".
+var (
+	timeLocation = time.UTC
+	timeLayout   = time.DateTime
+)
+
+// timeLayoutAliases maps friendly layout names to Go time layouts
+var timeLayoutAliases = map[string]string{
+	"datetime": time.DateTime,
+	"rfc3339":  time.RFC3339,
+	"iso8601":  "2006-01-02T15:04:05Z07:00",
+	"date":     time.DateOnly,
+	"rfc1123":  time.RFC1123,
+}
+
+// SetTimeFormat sets the time zone and layout used by all time formatters.
+// zone is "UTC" (default), "Local" or an IANA name such as "Europe/Berlin";
+// layout is a Go time layout or one of datetime, rfc3339, iso8601, date, rfc1123.
+// Empty values keep the defaults.
+func SetTimeFormat(zone, layout string) error {
+	loc := time.UTC
+	if zone != "" {
+		l, err := time.LoadLocation(zone)
+		if err != nil {
+			return fmt.Errorf("invalid time zone %q: %w", zone, err)
+		}
+		loc = l
+	}
+
+	if layout == "" {
+		layout = time.DateTime
+	} else if alias, ok := timeLayoutAliases[strings.ToLower(layout)]; ok {
+		layout = alias
+	}
+
+	timeLocation = loc
+	timeLayout = layout
+	return nil
+}
+
+// FormatTime renders t in the configured time zone and layout
+func FormatTime(t time.Time) string {
+	return t.In(timeLocation).Format(timeLayout)
+}
+
+// TimeLocation returns the configured time zone
+func TimeLocation() *time.Location {
+	return timeLocation
+}
+
 // GeneralizedTime converts LDAP generalized time attribute to datetime string
 func GeneralizedTime(entry *ldap.Entry, attribute string) (string, error) {
 	generalizedTime := entry.GetAttributeValue(attribute)
@@ -29,7 +81,7 @@ func GeneralizedTime(entry *ldap.Entry, attribute string) (string, error) {
 
 // GeneralizedTimeToDateTime converts LDAP generalized time to date-time format
 // generalizedTime: LDAP generalized time string (e.g., "20230101120000.0Z")
-// Returns: Formatted time string in "2006-01-02 15:04:05" format
+// Returns: Formatted time string in the configured zone and layout (see SetTimeFormat)
 func GeneralizedTimeToDateTime(generalizedTime string) (string, error) {
 	if generalizedTime == "" {
 		return "", fmt.Errorf("empty generalized time string")
@@ -40,12 +92,12 @@ func GeneralizedTimeToDateTime(generalizedTime string) (string, error) {
 		return "", err
 	}
 
-	return t.Local().Format(time.DateTime), nil
+	return FormatTime(t), nil
 }
 
 // FileTimeToTime converts Windows FileTime attribute to formatted datetime string
 // Supported attributes: lastLogon, pwdLastSet, lastLogonTimestamp, badPasswordTime
-// Returns: Formatted time string "2006-01-02 15:04:05" (UTC)
+// Returns: Formatted time string in the configured zone and layout (see SetTimeFormat)
 func FileTimeToTime(entry *ldap.Entry, attribute string) (string, error) {
 	// Parameter validation
 	if entry == nil {
@@ -66,7 +118,7 @@ func FileTimeToTime(entry *ldap.Entry, attribute string) (string, error) {
 
 // ParseFileTimeToTime converts Windows FileTime to human-readable time format
 // fileTimeStr: Windows FileTime as a string (18-digit number)
-// Returns: Formatted time string in "2006-01-02 15:04:05" format
+// Returns: Formatted time string in the configured zone and layout (see SetTimeFormat)
 func ParseFileTimeToTime(fileTimeStr string) (string, error) {
 	if fileTimeStr == "" {
 		return "", fmt.Errorf("empty fileTime string")
@@ -94,14 +146,14 @@ func ParseFileTimeToTime(fileTimeStr string) (string, error) {
 	}
 
 	// Construct time.Time object and format output
-	timestamp := time.Unix(0, unixNano).UTC()
-	return timestamp.Format(time.DateTime), nil
+	timestamp := time.Unix(0, unixNano)
+	return FormatTime(timestamp), nil
 }
 
 // AccountExpires parses accountExpires attribute value to readable date format
 // Supports:
 // - "0" and "9223372036854775807" meaning "never"
-// - Normal FILETIME timestamps (100ns since 1601-01-01) converted to UTC
+// - Normal FILETIME timestamps (100ns since 1601-01-01) in the configured zone and layout
 func AccountExpires(entry *ldap.Entry, attribute string) (string, error) {
 	b := entry.GetAttributeValue(attribute)
 
@@ -131,11 +183,34 @@ func AccountExpires(entry *ldap.Entry, attribute string) (string, error) {
 		return "", fmt.Errorf("accountExpires value out of range: %d", ft)
 	}
 
-	// 6. Convert to UTC time and format as string
-	t := time.Unix(unixTime, 0).UTC()
+	// 6. Convert to time and format as string
+	t := time.Unix(unixTime, 0)
 
-	// 7. Return original timestamp and formatted UTC time string
-	ae := fmt.Sprintf("%v,%v", b, t.Format(time.DateTime))
+	// 7. Return original timestamp and formatted time string
+	ae := fmt.Sprintf("%v,%v", b, FormatTime(t))
 
 	return ae, nil
 }
+
+// FileTime parses a FILETIME attribute value (100ns intervals since
+// 1601-01-01 UTC), such as lastLogonTimestamp or pwdLastSet. It returns
+// false for empty, zero, "never" (max int64) and invalid values.
+func FileTime(value string) (time.Time, bool) {
+	ft, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ft <= 0 || ft == 9223372036854775807 {
+		return time.Time{}, false
+	}
+	if ft < fileTimeToUnixEpoch {
+		return time.Time{}, false
+	}
+	return time.Unix(0, (ft-fileTimeToUnixEpoch)*100).UTC(), true
+}
+
+// ToFileTime returns t as a FILETIME value, for comparisons with
+// attributes such as accountExpires in filters
+func ToFileTime(t time.Time) int64 {
+	return t.UnixNano()/100 + fileTimeToUnixEpoch
+}
+
+// fileTimeToUnixEpoch is 1601-01-01 to 1970-01-01 in 100ns units
+const fileTimeToUnixEpoch = 116444736000000000
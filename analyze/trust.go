@@ -0,0 +1,51 @@
+package analyze
+
+// Trust Attribute Flags
+// https://learn.microsoft.com/en-us/openspecs/windows_protocols/ms-adts/e9a2d23c-c31e-4a6f-88a0-6646fdb51a3c
+const (
+	TRUST_ATTRIBUTE_NON_TRANSITIVE      = 0x0001 // The trust is not transitive
+	TRUST_ATTRIBUTE_UPLEVEL_ONLY        = 0x0002 // Only Windows 2000 and later clients may use the trust
+	TRUST_ATTRIBUTE_QUARANTINED_DOMAIN  = 0x0004 // SID filtering is enabled on an external trust
+	TRUST_ATTRIBUTE_FOREST_TRANSITIVE   = 0x0008 // The trust is a forest trust
+	TRUST_ATTRIBUTE_CROSS_ORGANIZATION  = 0x0010 // Selective authentication is enabled
+	TRUST_ATTRIBUTE_WITHIN_FOREST       = 0x0020 // The trusted domain is within the same forest
+	TRUST_ATTRIBUTE_TREAT_AS_EXTERNAL   = 0x0040 // A forest trust is treated as external for SID filtering
+	TRUST_ATTRIBUTE_USES_RC4_ENCRYPTION = 0x0080 // The trust uses RC4 keys
+)
+
+// Trust Direction Values
+const (
+	TRUST_DIRECTION_DISABLED      = 0 // The trust relationship exists but is disabled
+	TRUST_DIRECTION_INBOUND       = 1 // The trusted domain trusts the local domain
+	TRUST_DIRECTION_OUTBOUND      = 2 // The local domain trusts the trusted domain
+	TRUST_DIRECTION_BIDIRECTIONAL = 3 // Both domains trust each other
+)
+
+// trustDirectionNames maps trust direction values to their names
+var trustDirectionNames = map[int]string{
+	TRUST_DIRECTION_DISABLED:      "Disabled",
+	TRUST_DIRECTION_INBOUND:       "Inbound",
+	TRUST_DIRECTION_OUTBOUND:      "Outbound",
+	TRUST_DIRECTION_BIDIRECTIONAL: "Bidirectional",
+}
+
+// TrustDirectionName returns the name of a trustDirection value
+func TrustDirectionName(direction int) string {
+	if name, ok := trustDirectionNames[direction]; ok {
+		return name
+	}
+	return "Unknown"
+}
+
+// TrustTypeName classifies a trust from its trustAttributes flags
+// using the categories BloodHound understands.
+func TrustTypeName(attributes int) string {
+	switch {
+	case attributes&TRUST_ATTRIBUTE_WITHIN_FOREST != 0:
+		return "ParentChild"
+	case attributes&TRUST_ATTRIBUTE_FOREST_TRANSITIVE != 0:
+		return "Forest"
+	default:
+		return "External"
+	}
+}
@@ -9,6 +9,8 @@ import (
 // https://learn.microsoft.com/en-us/windows/win32/adschema/a-useraccountcontrol
 const (
 	UF_ACCOUNTDISABLE                  = 0x0002    // The user account is disabled
+	UF_LOCKOUT                         = 0x0010    // The account is locked out (msDS-User-Account-Control-Computed)
+	UF_PASSWD_NOTREQD                  = 0x0020    // No password is required
 	UF_ENCRYPTED_TEXT_PASSWORD_ALLOWED = 0x0080    // The user password is stored under reversible encryption
 	UF_NORMAL_ACCOUNT                  = 0x0200    // The account is a typical user account
 	UF_INTERDOMAIN_TRUST_ACCOUNT       = 0x0800    // This is an account for a trusted domain that permits authentication to this domain
@@ -69,3 +71,39 @@ func ParseUserAccountControl(uacStr string) (string, error) {
 		return fmt.Sprintf("%d, Unknown", uac), nil
 	}
 }
+
+// uacFlagNames lists the UAC flags with their names, in bit order
+var uacFlagNames = []struct {
+	Flag uint32
+	Name string
+}{
+	{UF_ACCOUNTDISABLE, "ACCOUNTDISABLE"},
+	{UF_LOCKOUT, "LOCKOUT"},
+	{UF_PASSWD_NOTREQD, "PASSWD_NOTREQD"},
+	{UF_ENCRYPTED_TEXT_PASSWORD_ALLOWED, "ENCRYPTED_TEXT_PASSWORD_ALLOWED"},
+	{UF_NORMAL_ACCOUNT, "NORMAL_ACCOUNT"},
+	{UF_INTERDOMAIN_TRUST_ACCOUNT, "INTERDOMAIN_TRUST_ACCOUNT"},
+	{UF_WORKSTATION_TRUST_ACCOUNT, "WORKSTATION_TRUST_ACCOUNT"},
+	{UF_SERVER_TRUST_ACCOUNT, "SERVER_TRUST_ACCOUNT"},
+	{UF_DONT_EXPIRE_PASSWORD, "DONT_EXPIRE_PASSWORD"},
+	{UF_MNS_LOGON_ACCOUNT, "MNS_LOGON_ACCOUNT"},
+	{UF_SMARTCARD_REQUIRED, "SMARTCARD_REQUIRED"},
+	{UF_TRUSTED_FOR_DELEGATION, "TRUSTED_FOR_DELEGATION"},
+	{UF_NOT_DELEGATED, "NOT_DELEGATED"},
+	{UF_USE_DES_KEY_ONLY, "USE_DES_KEY_ONLY"},
+	{UF_DONT_REQUIRE_PREAUTH, "DONT_REQUIRE_PREAUTH"},
+	{UF_PASSWORD_EXPIRED, "PASSWORD_EXPIRED"},
+	{UF_TRUSTED_TO_AUTH_FOR_DELEGATION, "TRUSTED_TO_AUTH_FOR_DELEGATION"},
+	{UF_PARTIAL_SECRETS_ACCOUNT, "PARTIAL_SECRETS_ACCOUNT"},
+}
+
+// UACFlagNames returns the names of the known flags set in a UserAccountControl value
+func UACFlagNames(uac uint32) []string {
+	var names []string
+	for _, f := range uacFlagNames {
+		if uac&f.Flag != 0 {
+			names = append(names, f.Name)
+		}
+	}
+	return names
+}
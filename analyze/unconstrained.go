@@ -0,0 +1,147 @@
+package analyze
+
+import (
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Coercion likelihood of an unconstrained delegation host: how readily an
+// attacker who controls it can have a DC authenticate to it and capture the
+// DC's TGT
+const (
+	CoercionHigh   = "high"
+	CoercionMedium = "medium"
+	CoercionLow    = "low"
+)
+
+// unconstrainedActiveDays is how recent a logon must be for a host to count
+// as live
+const unconstrainedActiveDays = 30
+
+// UnconstrainedAttributes are the attributes UnconstrainedExposure reads
+var UnconstrainedAttributes = []string{
+	AttrSAMAccountName,
+	AttrObjectClass,
+	AttrUserAccountControl,
+	AttrLastLogonTimestamp,
+	AttrOperatingSystem,
+	AttrDNSHostName,
+	AttrServicePrincipalName,
+}
+
+// UnconstrainedAccount is an account trusted for unconstrained delegation
+type UnconstrainedAccount struct {
+	Name            string    `json:"name"`
+	DN              string    `json:"dn"`
+	Type            string    `json:"type"` // "computer" or "user"
+	DC              bool      `json:"dc"`   // Domain controllers are expected to have unconstrained delegation
+	Enabled         bool      `json:"enabled"`
+	OperatingSystem string    `json:"operatingSystem,omitempty"`
+	DNSHostName     string    `json:"dnsHostName,omitempty"`
+	LastLogon       time.Time `json:"lastLogon"`            // lastLogonTimestamp; zero if never
+	Likelihood      string    `json:"likelihood,omitempty"` // One of the Coercion* values; empty for DCs
+	Reasons         []string  `json:"reasons,omitempty"`    // Why the likelihood was chosen
+}
+
+// UnconstrainedExposure turns accounts with unconstrained delegation into
+// a report: domain controllers first, then the other accounts ranked by how
+// likely coerced authentication to them succeeds at now. Enabled, recently
+// active computers with a DNS name rank highest; users need an SPN and a
+// DNS record pointing at an attacker host, and disabled accounts cannot
+// authenticate at all.
+func UnconstrainedExposure(entries []*ldap.Entry, now time.Time) []UnconstrainedAccount {
+	accounts := make([]UnconstrainedAccount, 0, len(entries))
+	for _, e := range entries {
+		uac, _ := strconv.ParseUint(e.GetEqualFoldAttributeValue(AttrUserAccountControl), 10, 32)
+		if uac&UF_TRUSTED_FOR_DELEGATION == 0 {
+			continue
+		}
+		a := UnconstrainedAccount{
+			Name:            e.GetEqualFoldAttributeValue(AttrSAMAccountName),
+			DN:              e.DN,
+			Type:            "user",
+			DC:              uac&UF_SERVER_TRUST_ACCOUNT != 0,
+			Enabled:         uac&UF_ACCOUNTDISABLE == 0,
+			OperatingSystem: e.GetEqualFoldAttributeValue(AttrOperatingSystem),
+			DNSHostName:     e.GetEqualFoldAttributeValue(AttrDNSHostName),
+		}
+		if a.Name == "" {
+			a.Name = e.DN
+		}
+		if slices.ContainsFunc(e.GetEqualFoldAttributeValues(AttrObjectClass), func(c string) bool {
+			return strings.EqualFold(c, "computer")
+		}) {
+			a.Type = "computer"
+		}
+		a.LastLogon, _ = FileTime(e.GetEqualFoldAttributeValue(AttrLastLogonTimestamp))
+		if !a.DC {
+			a.Likelihood, a.Reasons = coercionLikelihood(a, len(e.GetEqualFoldAttributeValues(AttrServicePrincipalName)) > 0, now)
+		}
+		accounts = append(accounts, a)
+	}
+
+	sort.SliceStable(accounts, func(i, j int) bool {
+		a, b := accounts[i], accounts[j]
+		if a.DC != b.DC {
+			return a.DC
+		}
+		if ra, rb := coercionRank(a.Likelihood), coercionRank(b.Likelihood); ra != rb {
+			return ra > rb
+		}
+		if !a.LastLogon.Equal(b.LastLogon) {
+			return a.LastLogon.After(b.LastLogon)
+		}
+		return strings.ToLower(a.Name) < strings.ToLower(b.Name)
+	})
+	return accounts
+}
+
+// coercionLikelihood rates a non-DC account and explains the rating
+func coercionLikelihood(a UnconstrainedAccount, hasSPN bool, now time.Time) (string, []string) {
+	if !a.Enabled {
+		return CoercionLow, []string{"account disabled"}
+	}
+
+	var reasons []string
+	active := !a.LastLogon.IsZero() && now.Sub(a.LastLogon) <= unconstrainedActiveDays*24*time.Hour
+	switch {
+	case a.LastLogon.IsZero():
+		reasons = append(reasons, "never logged on")
+	case active:
+		reasons = append(reasons, "logged on within "+strconv.Itoa(unconstrainedActiveDays)+" days")
+	default:
+		reasons = append(reasons, "no logon for "+strconv.Itoa(int(now.Sub(a.LastLogon).Hours()/24))+" days")
+	}
+
+	if a.Type == "user" {
+		if !hasSPN {
+			return CoercionLow, append(reasons, "user without SPN: an SPN and a DNS record must be added")
+		}
+		return CoercionMedium, append(reasons, "user with SPN: a DNS record must point to an attacker host")
+	}
+	if a.DNSHostName == "" {
+		return CoercionMedium, append(reasons, "no dNSHostName")
+	}
+	if !active {
+		return CoercionMedium, reasons
+	}
+	return CoercionHigh, append(reasons, "resolvable as "+a.DNSHostName)
+}
+
+// coercionRank orders coercion likelihoods, higher is more likely
+func coercionRank(likelihood string) int {
+	switch likelihood {
+	case CoercionHigh:
+		return 3
+	case CoercionMedium:
+		return 2
+	case CoercionLow:
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,134 @@
+package analyze
+
+import (
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Security information bits of sDRightsEffective
+const (
+	sdRightsOwner = 0x1 // OWNER_SECURITY_INFORMATION
+	sdRightsGroup = 0x2 // GROUP_SECURITY_INFORMATION
+	sdRightsDACL  = 0x4 // DACL_SECURITY_INFORMATION
+	sdRightsSACL  = 0x8 // SACL_SECURITY_INFORMATION
+)
+
+// sdRightNames names the sDRightsEffective bits, in bit order
+var sdRightNames = []struct {
+	bit  int
+	name string
+}{
+	{sdRightsOwner, EdgeWriteOwner},
+	{sdRightsGroup, "WriteGroup"},
+	{sdRightsDACL, EdgeWriteDacl},
+	{sdRightsSACL, "WriteSacl"},
+}
+
+// abusableAttributes maps attributes whose write access leads to an attack
+// to the edge or abuse it enables (lowercase)
+var abusableAttributes = map[string]string{
+	"member":                 EdgeAddMember,
+	"serviceprincipalname":   EdgeWriteSPN,
+	"msds-keycredentiallink": EdgeAddKeyCredentialLink,
+	"msds-allowedtoactonbehalfofotheridentity": EdgeAddAllowedToAct,
+	"msds-allowedtodelegateto":                 "WriteAllowedToDelegateTo",
+	"useraccountcontrol":                       "WriteUserAccountControl",
+	"scriptpath":                               "WriteLogonScript",
+	"mstsinitialprogram":                       "WriteLogonScript",
+	"gpcfilesyspath":                           "WriteGPCFileSysPath",
+	"gplink":                                   "WriteGPLink",
+	"dnshostname":                              "WriteDNSHostName",
+	"altsecurityidentities":                    "WriteAltSecurityIdentities",
+	"userprincipalname":                        "WriteUPN",
+	"msds-managedaccountprecededbylink":        "WriteDMSALink",
+	"sidhistory":                               "WriteSIDHistory",
+}
+
+// WritableCandidateFilter matches the objects the writable command checks
+// by default: accounts, groups, OUs, GPOs and the domain
+var WritableCandidateFilter = "(|(objectCategory=person)(objectClass=computer)(objectClass=group)" +
+	"(objectClass=organizationalUnit)(objectClass=groupPolicyContainer)(objectClass=domain))"
+
+// WritableCandidateAttributes are the candidate attributes WritableObjects reads
+var WritableCandidateAttributes = []string{AttrSAMAccountName, AttrName, AttrDisplayName, AttrObjectClass}
+
+// WritableAttributes are the constructed attributes read for each candidate
+var WritableAttributes = []string{AttrAllowedAttributesEffective, AttrAllowedChildClassesEffective, AttrSDRightsEffective}
+
+// WritableObject is an object the bound account can modify
+type WritableObject struct {
+	Name         string   `json:"name"`
+	DN           string   `json:"dn"`
+	Class        string   `json:"class"`                  // Most specific objectClass
+	SDRights     []string `json:"sdRights,omitempty"`     // Writable security descriptor parts
+	Abusable     []string `json:"abusable,omitempty"`     // Abuses the writable attributes enable
+	Attributes   []string `json:"attributes,omitempty"`   // Writable attributes
+	ChildClasses []string `json:"childClasses,omitempty"` // Classes that can be created under the object
+}
+
+// Abuse reports whether writing the object leads to an attack: a writable
+// owner or DACL, or an abusable attribute
+func (w WritableObject) Abuse() bool {
+	return len(w.Abusable) > 0 || containsFold(w.SDRights, EdgeWriteOwner) || containsFold(w.SDRights, EdgeWriteDacl)
+}
+
+// NewWritableObject builds the WritableObject of a candidate entry from the
+// constructed attributes read for it. ok is false if nothing is writable.
+func NewWritableObject(candidate, constructed *ldap.Entry) (w WritableObject, ok bool) {
+	w = WritableObject{Name: writableName(candidate), DN: candidate.DN}
+	if classes := candidate.GetEqualFoldAttributeValues(AttrObjectClass); len(classes) > 0 {
+		w.Class = classes[len(classes)-1]
+	}
+
+	w.Attributes = constructed.GetEqualFoldAttributeValues(AttrAllowedAttributesEffective)
+	sort.Slice(w.Attributes, func(i, j int) bool { return strings.ToLower(w.Attributes[i]) < strings.ToLower(w.Attributes[j]) })
+	for _, attr := range w.Attributes {
+		if abuse, ok := abusableAttributes[strings.ToLower(attr)]; ok && !containsFold(w.Abusable, abuse) {
+			w.Abusable = append(w.Abusable, abuse)
+		}
+	}
+	sort.Strings(w.Abusable)
+	w.ChildClasses = constructed.GetEqualFoldAttributeValues(AttrAllowedChildClassesEffective)
+	sort.Strings(w.ChildClasses)
+
+	if rights, err := strconv.Atoi(constructed.GetEqualFoldAttributeValue(AttrSDRightsEffective)); err == nil {
+		w.SDRights = sdRights(rights)
+	}
+	return w, len(w.Attributes) > 0 || len(w.ChildClasses) > 0 || len(w.SDRights) > 0
+}
+
+// writableName returns the sAMAccountName, display name or name of an entry
+func writableName(e *ldap.Entry) string {
+	for _, attr := range []string{AttrSAMAccountName, AttrDisplayName, AttrName} {
+		if v := e.GetEqualFoldAttributeValue(attr); v != "" {
+			return v
+		}
+	}
+	return e.DN
+}
+
+// sdRights returns the names of the bits set in a sDRightsEffective value
+func sdRights(value int) []string {
+	var names []string
+	for _, r := range sdRightNames {
+		if value&r.bit != 0 {
+			names = append(names, r.name)
+		}
+	}
+	return names
+}
+
+// FormatSDRights lists the security descriptor parts of a sDRightsEffective
+// value followed by the raw value, e.g. "WriteOwner, WriteDacl (5)"
+func FormatSDRights(entry *ldap.Entry, attribute string) (string, error) {
+	v := entry.GetEqualFoldAttributeValue(attribute)
+	rights, err := strconv.Atoi(v)
+	if err != nil || rights == 0 {
+		return v, nil
+	}
+	return fmt.Sprintf("%s (%d)", strings.Join(sdRights(rights), ", "), rights), nil
+}
@@ -0,0 +1,186 @@
+// Package audit runs a curated set of security checks against Active
+// Directory and reports graded findings with affected objects and
+// remediation notes.
+package audit
+
+import (
+	"adgo/connect"
+	"adgo/queries"
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Severity grades a finding
+type Severity int
+
+// Severity levels, lowest first
+const (
+	SeverityInfo Severity = iota
+	SeverityLow
+	SeverityMedium
+	SeverityHigh
+	SeverityCritical
+)
+
+// severityNames maps severities to their report labels
+var severityNames = map[Severity]string{
+	SeverityInfo:     "Info",
+	SeverityLow:      "Low",
+	SeverityMedium:   "Medium",
+	SeverityHigh:     "High",
+	SeverityCritical: "Critical",
+}
+
+// String returns the severity label
+func (s Severity) String() string {
+	return severityNames[s]
+}
+
+// MarshalJSON encodes the severity as its label
+func (s Severity) MarshalJSON() ([]byte, error) {
+	return json.Marshal(s.String())
+}
+
+// Searcher runs a subtree search below the configured base DN, or below
+// the DN given to connect.WithSearchBase; connect.Client satisfies it.
+type Searcher interface {
+	Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error)
+}
+
+// Finding is a failed check
+type Finding struct {
+	ID          string   `json:"id"`               // Check identifier, e.g. kerberoast
+	Title       string   `json:"title"`            // Short description of the issue
+	Severity    Severity `json:"severity"`         // How serious the issue is
+	Description string   `json:"description"`      // Why the issue matters
+	Detail      string   `json:"detail,omitempty"` // Measured values, e.g. coverage or age
+	Remediation string   `json:"remediation"`      // How to fix it
+	Objects     []string `json:"objects"`          // Affected objects
+}
+
+// CheckError records a check that could not be run
+type CheckError struct {
+	ID    string `json:"id"`
+	Error string `json:"error"`
+}
+
+// Report is the result of an audit run
+type Report struct {
+	Generated time.Time    `json:"generated"`        // Time the audit finished
+	Grade     string       `json:"grade"`            // A (no findings) to F (critical findings)
+	Checks    int          `json:"checks"`           // Number of checks run
+	Passed    []string     `json:"passed"`           // IDs of checks without findings
+	Findings  []Finding    `json:"findings"`         // Failed checks, most severe first
+	Errors    []CheckError `json:"errors,omitempty"` // Checks that could not be run
+}
+
+// Run executes every check and returns the report. A check that fails to
+// run (e.g. for lack of read access, or because the partition it searches
+// cannot be found) is recorded in Errors and does not stop the audit.
+func Run(ctx context.Context, s Searcher) (*Report, error) {
+	r := &Report{Passed: []string{}, Findings: []Finding{}}
+	partitions := map[string]string{} // DNs of the partitions found so far
+
+	for _, c := range checks {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		r.Checks++
+
+		searchCtx := ctx
+		if c.partition != "" {
+			dn, ok := partitions[c.partition]
+			if !ok {
+				var err error
+				if dn, err = connect.ReadNamingContext(ctx, s, queries.NamingContext(c.partition)); err != nil {
+					r.Errors = append(r.Errors, CheckError{ID: c.id, Error: fmt.Sprintf("finding the %s partition: %v", c.partition, err)})
+					continue
+				}
+				partitions[c.partition] = dn
+			}
+			searchCtx = connect.WithSearchBase(ctx, dn)
+		}
+
+		entries, err := s.Search(searchCtx, c.filter, c.attributes)
+		if err != nil {
+			r.Errors = append(r.Errors, CheckError{ID: c.id, Error: err.Error()})
+			continue
+		}
+
+		objects, detail := c.evaluate(entries)
+		if len(objects) == 0 {
+			r.Passed = append(r.Passed, c.id)
+			continue
+		}
+		sort.Strings(objects)
+		r.Findings = append(r.Findings, Finding{
+			ID:          c.id,
+			Title:       c.title,
+			Severity:    c.severity,
+			Description: c.description,
+			Detail:      detail,
+			Remediation: c.remediation,
+			Objects:     objects,
+		})
+	}
+
+	sort.SliceStable(r.Findings, func(i, j int) bool {
+		return r.Findings[i].Severity > r.Findings[j].Severity
+	})
+	r.Grade = grade(r.Findings)
+	r.Generated = time.Now().UTC()
+	return r, nil
+}
+
+// grade rates the findings by the most severe one
+func grade(findings []Finding) string {
+	worst := Severity(-1)
+	for _, f := range findings {
+		worst = max(worst, f.Severity)
+	}
+	switch worst {
+	case SeverityCritical:
+		return "F"
+	case SeverityHigh:
+		return "D"
+	case SeverityMedium:
+		return "C"
+	case SeverityLow, SeverityInfo:
+		return "B"
+	default:
+		return "A"
+	}
+}
+
+// objectName returns the account name of an entry, falling back to its CN or DN
+func objectName(e *ldap.Entry) string {
+	for _, attr := range []string{"sAMAccountName", "cn"} {
+		if v := e.GetEqualFoldAttributeValue(attr); v != "" {
+			return v
+		}
+	}
+	return e.DN
+}
+
+// names returns the object names of all entries
+func names(entries []*ldap.Entry) []string {
+	result := make([]string, 0, len(entries))
+	for _, e := range entries {
+		result = append(result, objectName(e))
+	}
+	return result
+}
+
+// percent formats part of total as "part of total (n%)"
+func percent(part, total int, noun string) string {
+	if total == 0 {
+		return ""
+	}
+	return fmt.Sprintf("%d of %d %s (%d%%)", part, total, strings.TrimSpace(noun), part*100/total)
+}
@@ -0,0 +1,358 @@
+package audit
+
+import (
+	"adgo/analyze"
+	"adgo/queries"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Thresholds used by the checks
+const (
+	krbtgtMaxAge         = 180 * 24 * time.Hour // Rotate the krbtgt key at least twice a year
+	minPasswordLength    = 14                   // CIS benchmark minimum
+	fileTimeEpochOffset  = 116444736000000000   // 100ns intervals between 1601 and 1970
+	fileTimeNeverExpires = 0x7FFFFFFFFFFFFFFF
+)
+
+// check is one audit rule: a search and an evaluation of its results
+type check struct {
+	id          string
+	title       string
+	severity    Severity
+	description string
+	remediation string
+	filter      string
+	attributes  []string
+	partition   string // Partition searched instead of the base DN, as in queries.Query
+	// evaluate returns the affected objects and an optional detail line;
+	// no objects means the check passed
+	evaluate func(entries []*ldap.Entry) (objects []string, detail string)
+}
+
+// uacSet returns a filter matching accounts with a userAccountControl flag set
+func uacSet(flag int) string {
+	return fmt.Sprintf("(%s:%s:=%d)", analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, flag)
+}
+
+// uacClear returns a filter matching accounts with a userAccountControl flag clear
+func uacClear(flag int) string {
+	return "(!" + uacSet(flag) + ")"
+}
+
+// queryFilter returns the filter of a predefined query
+func queryFilter(name string) string {
+	q, _ := queries.Get(name)
+	return q.Filter
+}
+
+// allEntries reports every entry as affected
+func allEntries(entries []*ldap.Entry) ([]string, string) {
+	return names(entries), ""
+}
+
+// checks is the curated audit rule set
+var checks = []check{
+	{
+		id:          "kerberoast",
+		title:       "Kerberoastable user accounts",
+		severity:    SeverityHigh,
+		description: "Enabled user accounts with a service principal name. Any domain user can request a service ticket encrypted with the account's password hash and crack it offline.",
+		remediation: "Remove unneeded SPNs, move services to group managed service accounts, or set long random passwords and AES-only encryption on the remaining accounts.",
+		filter:      queryFilter("kerberoasting"),
+		attributes:  []string{analyze.AttrSAMAccountName},
+		evaluate:    allEntries,
+	},
+	{
+		id:          "asrep",
+		title:       "AS-REP roastable accounts",
+		severity:    SeverityHigh,
+		description: "Enabled accounts that do not require Kerberos pre-authentication. Anyone can request an AS-REP for them and crack the password hash offline.",
+		remediation: "Clear \"Do not require Kerberos preauthentication\" on these accounts.",
+		filter:      queryFilter("asreproast"),
+		attributes:  []string{analyze.AttrSAMAccountName},
+		evaluate:    allEntries,
+	},
+	{
+		id:          "unconstrained-delegation",
+		title:       "Unconstrained delegation outside domain controllers",
+		severity:    SeverityCritical,
+		description: "Accounts trusted for unconstrained delegation receive the TGT of every user that authenticates to them. Combined with coercion this leads to domain compromise.",
+		remediation: "Replace unconstrained delegation with constrained or resource-based constrained delegation, and mark privileged accounts as sensitive.",
+		filter: "(&" + uacSet(analyze.UF_TRUSTED_FOR_DELEGATION) + uacClear(analyze.UF_SERVER_TRUST_ACCOUNT) +
+			uacClear(analyze.UF_ACCOUNTDISABLE) + ")",
+		attributes: []string{analyze.AttrSAMAccountName},
+		evaluate:   allEntries,
+	},
+	{
+		id:          "protocol-transition",
+		title:       "Constrained delegation with protocol transition",
+		severity:    SeverityHigh,
+		description: "Accounts trusted to authenticate for delegation (S4U2Self) can impersonate any user, including administrators, to the services listed in msDS-AllowedToDelegateTo.",
+		remediation: "Use Kerberos-only constrained delegation where possible and review the delegation targets.",
+		filter:      "(&" + uacSet(analyze.UF_TRUSTED_TO_AUTH_FOR_DELEGATION) + uacClear(analyze.UF_ACCOUNTDISABLE) + ")",
+		attributes:  []string{analyze.AttrSAMAccountName},
+		evaluate:    allEntries,
+	},
+	{
+		id:          "laps-coverage",
+		title:       "Computers without LAPS",
+		severity:    SeverityMedium,
+		description: "Enabled member computers without a legacy or Windows LAPS password. Their local administrator passwords are likely shared, enabling lateral movement.",
+		remediation: "Deploy Windows LAPS to all member servers and workstations.",
+		filter: fmt.Sprintf("(&(%s=computer)%s%s)", analyze.AttrObjectCategory,
+			uacClear(analyze.UF_ACCOUNTDISABLE), uacClear(analyze.UF_SERVER_TRUST_ACCOUNT)),
+		attributes: []string{analyze.AttrSAMAccountName, analyze.AttrMSMcsAdmPwdExpirationTime, analyze.AttrMSLAPSPasswordExpirationTime},
+		evaluate:   evaluateLAPS,
+	},
+	{
+		id:          "krbtgt-age",
+		title:       "krbtgt password not rotated",
+		severity:    SeverityHigh,
+		description: "The krbtgt key signs every Kerberos ticket. An old key keeps forged (golden) tickets from past compromises valid.",
+		remediation: "Reset the krbtgt password twice, allowing replication between resets, and rotate it regularly.",
+		filter:      fmt.Sprintf("(%s=krbtgt)", analyze.AttrSAMAccountName),
+		attributes:  []string{analyze.AttrSAMAccountName, analyze.AttrPwdLastSet},
+		evaluate:    evaluateKrbtgtAge,
+	},
+	{
+		id:          "password-length",
+		title:       "Weak minimum password length",
+		severity:    SeverityMedium,
+		description: fmt.Sprintf("The default domain password policy requires fewer than %d characters.", minPasswordLength),
+		remediation: fmt.Sprintf("Set the minimum password length to at least %d, or enforce longer passwords with fine-grained password policies.", minPasswordLength),
+		filter:      "(objectClass=domainDNS)",
+		attributes:  []string{"minPwdLength"},
+		evaluate:    evaluatePasswordLength,
+	},
+	{
+		id:          "account-lockout",
+		title:       "No account lockout",
+		severity:    SeverityMedium,
+		description: "The default domain password policy never locks accounts, so passwords can be sprayed or brute-forced online.",
+		remediation: "Set an account lockout threshold (e.g. 10 attempts) with a lockout duration.",
+		filter:      "(objectClass=domainDNS)",
+		attributes:  []string{"lockoutThreshold"},
+		evaluate:    evaluateLockout,
+	},
+	{
+		id:          "dangerous-acl",
+		title:       "Dangerous rights for broad groups on privileged objects",
+		severity:    SeverityCritical,
+		description: "Everyone, Authenticated Users, Domain Users or Domain Computers hold control rights over the domain, AdminSDHolder or protected accounts and groups.",
+		remediation: "Remove the listed ACEs; privileged objects should only be writable by tier 0 administrators.",
+		filter:      fmt.Sprintf("(|(objectClass=domainDNS)(%s=1)(cn=AdminSDHolder))", analyze.AttrAdminCount),
+		attributes:  []string{analyze.AttrSAMAccountName, analyze.AttrCN, analyze.AttrObjectClass, analyze.AttrNTSecurityDescriptor},
+		evaluate:    evaluateDangerousACLs,
+	},
+	{
+		id:          "esc1",
+		title:       "ESC1 vulnerable certificate templates",
+		severity:    SeverityCritical,
+		description: "Templates that allow client authentication, let the enrollee supply the subject, and need no manager approval. Enrollees can request certificates as any user.",
+		remediation: "Clear \"Supply in the request\", require manager approval, or restrict enrollment rights to trusted principals.",
+		filter:      queryFilter("esc1"),
+		attributes:  []string{analyze.AttrCN},
+		partition:   queries.PartitionConfiguration,
+		evaluate:    allEntries,
+	},
+	{
+		id:          "esc2",
+		title:       "ESC2 vulnerable certificate templates",
+		severity:    SeverityHigh,
+		description: "Templates with the Any Purpose EKU or no EKU and no manager approval. Certificates can be used for client authentication or as enrollment agents.",
+		remediation: "Restrict the EKUs to what the template needs and limit enrollment rights.",
+		filter:      queryFilter("esc2"),
+		attributes:  []string{analyze.AttrCN},
+		partition:   queries.PartitionConfiguration,
+		evaluate:    allEntries,
+	},
+	{
+		id:          "machine-account-quota",
+		title:       "Users can join computers to the domain",
+		severity:    SeverityMedium,
+		description: "ms-DS-MachineAccountQuota lets any authenticated user create computer accounts, a building block for RBCD and relay attacks.",
+		remediation: "Set ms-DS-MachineAccountQuota to 0 and delegate domain join to a dedicated group.",
+		filter:      "(objectClass=domainDNS)",
+		attributes:  []string{"ms-DS-MachineAccountQuota"},
+		evaluate:    evaluateMachineAccountQuota,
+	},
+	{
+		id:          "duplicate-spn",
+		title:       "Duplicate service principal names",
+		severity:    SeverityMedium,
+		description: "SPNs registered on more than one account. The KDC cannot tell which account's key to encrypt service tickets with, breaking Kerberos to the service or handing its tickets to the wrong account.",
+		remediation: "Remove the SPN from every account but the one running the service (setspn -X lists duplicates).",
+		filter:      queryFilter("spn"),
+		attributes:  analyze.SPNAttributes,
+		evaluate:    evaluateDuplicateSPNs,
+	},
+}
+
+// evaluateDuplicateSPNs reports each duplicate SPN with its accounts
+func evaluateDuplicateSPNs(entries []*ldap.Entry) ([]string, string) {
+	var objects []string
+	for _, d := range analyze.DuplicateSPNs(entries) {
+		objects = append(objects, fmt.Sprintf("%s (%s)", d.SPN, strings.Join(d.Accounts, ", ")))
+	}
+	return objects, ""
+}
+
+// evaluateLAPS reports computers with neither LAPS expiration attribute
+func evaluateLAPS(entries []*ldap.Entry) ([]string, string) {
+	var missing []string
+	for _, e := range entries {
+		if !analyze.HasLAPS(e) {
+			missing = append(missing, objectName(e))
+		}
+	}
+	return missing, percent(len(missing), len(entries), "computers")
+}
+
+// evaluateKrbtgtAge reports krbtgt if its password is older than krbtgtMaxAge
+func evaluateKrbtgtAge(entries []*ldap.Entry) ([]string, string) {
+	for _, e := range entries {
+		set, ok := fileTime(e.GetEqualFoldAttributeValue(analyze.AttrPwdLastSet))
+		if !ok {
+			continue
+		}
+		if age := time.Since(set); age > krbtgtMaxAge {
+			return []string{objectName(e)}, fmt.Sprintf("password last set %s (%d days ago)",
+				analyze.FormatTime(set), int(age.Hours()/24))
+		}
+	}
+	return nil, ""
+}
+
+// evaluatePasswordLength reports the domain if minPwdLength is below minPasswordLength
+func evaluatePasswordLength(entries []*ldap.Entry) ([]string, string) {
+	for _, e := range entries {
+		n, err := strconv.Atoi(e.GetEqualFoldAttributeValue("minPwdLength"))
+		if err == nil && n < minPasswordLength {
+			return []string{e.DN}, fmt.Sprintf("minimum password length is %d", n)
+		}
+	}
+	return nil, ""
+}
+
+// evaluateLockout reports the domain if lockoutThreshold is 0
+func evaluateLockout(entries []*ldap.Entry) ([]string, string) {
+	for _, e := range entries {
+		if e.GetEqualFoldAttributeValue("lockoutThreshold") == "0" {
+			return []string{e.DN}, "lockout threshold is 0"
+		}
+	}
+	return nil, ""
+}
+
+// evaluateMachineAccountQuota reports the domain if the quota is above 0
+func evaluateMachineAccountQuota(entries []*ldap.Entry) ([]string, string) {
+	for _, e := range entries {
+		n, err := strconv.Atoi(e.GetEqualFoldAttributeValue("ms-DS-MachineAccountQuota"))
+		if err == nil && n > 0 {
+			return []string{e.DN}, fmt.Sprintf("machine account quota is %d", n)
+		}
+	}
+	return nil, ""
+}
+
+// broadPrincipals are well-known SIDs (or domain RIDs, prefixed with "-")
+// that include ordinary users
+var broadPrincipals = map[string]string{
+	"S-1-1-0":      "Everyone",
+	"S-1-5-7":      "Anonymous Logon",
+	"S-1-5-11":     "Authenticated Users",
+	"S-1-5-32-545": "Users",
+	"-513":         "Domain Users",
+	"-515":         "Domain Computers",
+}
+
+// dangerousRights are the edges that give control over an object
+var dangerousRights = map[string]bool{
+	analyze.EdgeOwns:                 true,
+	analyze.EdgeGenericAll:           true,
+	analyze.EdgeGenericWrite:         true,
+	analyze.EdgeWriteDacl:            true,
+	analyze.EdgeWriteOwner:           true,
+	analyze.EdgeAllExtendedRights:    true,
+	analyze.EdgeForceChangePassword:  true,
+	analyze.EdgeAddMember:            true,
+	analyze.EdgeWriteSPN:             true,
+	analyze.EdgeAddKeyCredentialLink: true,
+	analyze.EdgeAddAllowedToAct:      true,
+	analyze.EdgeGetChangesAll:        true,
+}
+
+// evaluateDangerousACLs reports rights broad groups hold over privileged objects
+func evaluateDangerousACLs(entries []*ldap.Entry) ([]string, string) {
+	var found []string
+	for _, e := range entries {
+		raw := e.GetEqualFoldRawAttributeValues(analyze.AttrNTSecurityDescriptor)
+		if len(raw) == 0 {
+			continue
+		}
+		acl, err := analyze.ExtractEdges(raw[0], aclObjectType(e))
+		if err != nil {
+			continue
+		}
+		for _, edge := range acl.Edges {
+			if !dangerousRights[edge.RightName] {
+				continue
+			}
+			if principal := broadPrincipal(edge.PrincipalSID); principal != "" {
+				found = append(found, fmt.Sprintf("%s: %s for %s", objectName(e), edge.RightName, principal))
+			}
+		}
+	}
+	return found, ""
+}
+
+// broadPrincipal returns the name of a broad group SID, or "" for other principals
+func broadPrincipal(sid string) string {
+	if name, ok := broadPrincipals[sid]; ok {
+		return name
+	}
+	if i := strings.LastIndex(sid, "-"); i > 0 && strings.HasPrefix(sid, "S-1-5-21-") {
+		return broadPrincipals[sid[i:]]
+	}
+	return ""
+}
+
+// aclObjectType returns the edge extraction object type of an entry
+func aclObjectType(e *ldap.Entry) string {
+	classes := e.GetEqualFoldAttributeValues(analyze.AttrObjectClass)
+	has := func(class string) bool {
+		for _, c := range classes {
+			if strings.EqualFold(c, class) {
+				return true
+			}
+		}
+		return false
+	}
+	switch {
+	case has("domainDNS"):
+		return "Domain"
+	case has("computer"):
+		return "Computer"
+	case has("user"):
+		return "User"
+	case has("group"):
+		return "Group"
+	default:
+		return "Container"
+	}
+}
+
+// fileTime parses a Windows FILETIME attribute value.
+// Returns false for 0, "never" and unparsable values.
+func fileTime(v string) (time.Time, bool) {
+	ft, err := strconv.ParseInt(v, 10, 64)
+	if err != nil || ft <= 0 || ft == fileTimeNeverExpires {
+		return time.Time{}, false
+	}
+	return time.Unix(0, (ft-fileTimeEpochOffset)*100), true
+}
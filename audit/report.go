@@ -0,0 +1,103 @@
+package audit
+
+import (
+	"adgo/analyze"
+	"encoding/json"
+	"fmt"
+	"html/template"
+	"io"
+	"strings"
+)
+
+// maxTextObjects caps the affected objects listed per finding in text output
+const maxTextObjects = 20
+
+// WriteText writes the report as plain text
+func WriteText(w io.Writer, r *Report) error {
+	fmt.Fprintf(w, "AD Audit - grade %s, %d findings, %d of %d checks passed\n",
+		r.Grade, len(r.Findings), len(r.Passed), r.Checks)
+	fmt.Fprintf(w, "Generated %s\n", analyze.FormatTime(r.Generated))
+
+	for _, f := range r.Findings {
+		fmt.Fprintf(w, "\n[%s] %s (%s, %d affected)\n", strings.ToUpper(f.Severity.String()), f.Title, f.ID, len(f.Objects))
+		fmt.Fprintf(w, "  %s\n", f.Description)
+		if f.Detail != "" {
+			fmt.Fprintf(w, "  Detail: %s\n", f.Detail)
+		}
+		fmt.Fprintf(w, "  Remediation: %s\n", f.Remediation)
+		for i, o := range f.Objects {
+			if i == maxTextObjects {
+				fmt.Fprintf(w, "    ... and %d more\n", len(f.Objects)-maxTextObjects)
+				break
+			}
+			fmt.Fprintf(w, "    - %s\n", o)
+		}
+	}
+
+	if len(r.Passed) > 0 {
+		fmt.Fprintf(w, "\nPassed: %s\n", strings.Join(r.Passed, ", "))
+	}
+	for _, e := range r.Errors {
+		fmt.Fprintf(w, "Not run: %s (%s)\n", e.ID, e.Error)
+	}
+	return nil
+}
+
+// WriteJSON writes the report as indented JSON
+func WriteJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// WriteHTML writes the report as a self-contained HTML page
+func WriteHTML(w io.Writer, r *Report) error {
+	return reportTemplate.Execute(w, struct {
+		*Report
+		GeneratedText string
+	}{r, analyze.FormatTime(r.Generated)})
+}
+
+var reportTemplate = template.Must(template.New("audit").Funcs(template.FuncMap{
+	"lower": strings.ToLower,
+}).Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>AD Audit Report</title>
+<style>
+body { font-family: -apple-system, "Segoe UI", Roboto, sans-serif; margin: 2em; color: #1f2937; }
+h1 { margin-bottom: 0; }
+.meta { color: #6b7280; margin-bottom: 2em; }
+.grade { font-size: 2em; font-weight: bold; }
+.finding { border-left: 6px solid #9ca3af; padding: 0.5em 1em; margin: 1em 0; background: #f9fafb; }
+.finding.critical { border-color: #7f1d1d; }
+.finding.high { border-color: #dc2626; }
+.finding.medium { border-color: #f59e0b; }
+.finding.low { border-color: #3b82f6; }
+.severity { font-weight: bold; text-transform: uppercase; }
+details ul { max-height: 20em; overflow-y: auto; }
+</style>
+</head>
+<body>
+<h1>AD Audit Report</h1>
+<div class="meta">Generated {{.GeneratedText}} &middot; {{len .Findings}} findings &middot; {{len .Passed}} of {{.Checks}} checks passed</div>
+<div class="grade">Grade {{.Grade}}</div>
+{{range .Findings}}
+<div class="finding {{lower .Severity.String}}">
+<h2><span class="severity">{{.Severity}}</span> {{.Title}}</h2>
+<p>{{.Description}}</p>
+{{if .Detail}}<p><strong>Detail:</strong> {{.Detail}}</p>{{end}}
+<p><strong>Remediation:</strong> {{.Remediation}}</p>
+<details><summary>{{len .Objects}} affected objects</summary>
+<ul>{{range .Objects}}<li>{{.}}</li>{{end}}</ul>
+</details>
+</div>
+{{end}}
+{{if .Passed}}<h2>Passed</h2>
+<ul>{{range .Passed}}<li>{{.}}</li>{{end}}</ul>{{end}}
+{{if .Errors}}<h2>Not Run</h2>
+<ul>{{range .Errors}}<li>{{.ID}}: {{.Error}}</li>{{end}}</ul>{{end}}
+</body>
+</html>
+`))
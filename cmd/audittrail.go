@@ -0,0 +1,83 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/log"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// auditTrail records every bind and search when --audit-trail is given
+var auditTrail *connect.AuditLog
+
+// setupAuditTrail opens the --audit-trail file and starts recording binds
+func setupAuditTrail(cmd *cobra.Command) error {
+	path, _ := cmd.Flags().GetString("audit-trail")
+	if path == "" || cmd.Annotations[annotationOffline] != "" {
+		return nil
+	}
+
+	a, err := connect.OpenAuditLog(path)
+	if err != nil {
+		return err
+	}
+	auditTrail = a
+	connect.SetBindHook(a.Bind)
+	log.Debugf("Appending LDAP operations to audit trail %s", path)
+	return nil
+}
+
+// closeAuditTrail closes the --audit-trail file, if any
+func closeAuditTrail() {
+	if auditTrail == nil {
+		return
+	}
+	connect.SetBindHook(nil)
+	head, err := auditTrail.Close()
+	if err != nil {
+		log.Warnf("Audit trail is incomplete: %v", err)
+	}
+	if head != "" {
+		// The chain alone cannot show records cut from the end
+		log.Infof("Audit trail head: %s (keep it outside the trail and check it with audit-trail verify --head)", head)
+	}
+	auditTrail = nil
+}
+
+// auditTrailCmd represents the audit-trail command group
+var auditTrailCmd = &cobra.Command{
+	Use:   "audit-trail",
+	Short: "Work with --audit-trail files",
+	Long: "--audit-trail FILE appends every bind and search (filter, base, attributes, entry " +
+		"count and duration) to FILE as JSON lines. Each record holds the SHA-256 hash of the " +
+		"record before it, so edited, removed or reordered lines are detected by verify. The chain " +
+		"is not keyed, so records cut from the end or a rewritten trail are only detected against " +
+		"the head hash logged when each command finishes; keep it outside the trail and pass the " +
+		"latest one to verify --head.",
+}
+
+// auditTrailVerifyCmd represents the audit-trail verify command
+var auditTrailVerifyCmd = &cobra.Command{
+	Use:         "verify FILE",
+	Short:       "Check that an audit trail has not been modified",
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		head, _ := cmd.Flags().GetString("head")
+		n, err := connect.VerifyAuditLog(args[0], head)
+		if err != nil {
+			return fmt.Errorf("audit trail %s is not intact after %d records: %w", args[0], n, err)
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "Audit trail %s is intact (%d records)\n", args[0], n)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(auditTrailCmd)
+	auditTrailCmd.AddCommand(auditTrailVerifyCmd)
+
+	auditTrailVerifyCmd.Flags().String("head", "", "Hash the last record must have, as logged when the trail was last written")
+	rootCmd.PersistentFlags().String("audit-trail", "", "Append every bind and search to this tamper-evident JSONL file")
+}
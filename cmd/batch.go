@@ -0,0 +1,284 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/output"
+	"adgo/queries"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"go.yaml.in/yaml/v3"
+)
+
+// batchManifestFile is written into the output directory of a batch run
+const batchManifestFile = "manifest.json"
+
+// batchEntry is one query of a batch file. Either Query names a registered
+// query or Filter gives a raw LDAP filter.
+type batchEntry struct {
+	Name       string            `yaml:"name"`
+	Query      string            `yaml:"query"`
+	Filter     string            `yaml:"filter"`
+	Attributes []string          `yaml:"attributes"`
+	Params     map[string]string `yaml:"params"`
+}
+
+// batchManifest describes the files written by a batch run
+type batchManifest struct {
+	Generated time.Time           `json:"generated"`
+	BaseDN    string              `json:"baseDN"`
+	Format    string              `json:"format"`
+	Queries   []batchManifestItem `json:"queries"`
+}
+
+// batchManifestItem is one query of a batch manifest
+type batchManifestItem struct {
+	Name       string   `json:"name"`
+	Query      string   `json:"query,omitempty"`
+	Filter     string   `json:"filter"`
+	Attributes []string `json:"attributes"`
+	File       string   `json:"file"`
+	Entries    int      `json:"entries"`
+	DurationMs int64    `json:"durationMs"`
+	Error      string   `json:"error,omitempty"`
+}
+
+// batchCmd represents the batch command
+var batchCmd = &cobra.Command{
+	Use:         "batch <file>",
+	Annotations: map[string]string{annotationDryRun: "true"},
+	Short:       "Run the queries listed in a file",
+	Long: "Batch reads query names and raw LDAP filters from a file, one per line (blank lines and " +
+		"# comments are skipped), or a YAML list of {name, query, filter, attributes, params} " +
+		"entries, and runs them sequentially or with --concurrency in parallel. Each result is " +
+		"written to its own file in a timestamped directory alongside " + batchManifestFile + ".",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runBatch(cmd, args[0])
+	},
+}
+
+// runBatch runs the queries of a batch file and writes the manifest
+func runBatch(cmd *cobra.Command, path string) error {
+	cfg := GetConfig()
+
+	entries, err := readBatchFile(path)
+	if err != nil {
+		return err
+	}
+	if len(entries) == 0 {
+		return fmt.Errorf("no queries in %s", path)
+	}
+
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	if err := ValidateOutputFormat(format); err != nil {
+		return err
+	}
+	redact, _ := cmd.Flags().GetBool("redact")
+	jsonRaw, _ := cmd.Flags().GetBool("json-raw")
+	compressFlag, _ := cmd.Flags().GetString("compress")
+	compress, err := output.ParseCompression(compressFlag)
+	if err != nil {
+		return err
+	}
+	steps, err := postProcessSteps(cmd)
+	if err != nil {
+		return err
+	}
+
+	profile, err := attrProfile(cmd)
+	if err != nil {
+		return err
+	}
+	jobs, err := batchJobs(entries, cfg.LDAP.BaseDN, profile)
+	if err != nil {
+		return err
+	}
+	for i := range jobs {
+		jobs[i].Printer = output.PrinterConfig{
+			Format:      format,
+			Compress:    compress,
+			Query:       jobs[i].Name,
+			Redact:      redact,
+			JSONRaw:     jsonRaw,
+			PostProcess: steps,
+		}
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return planCollection(cmd, jobs, format)
+	}
+
+	dir, _ := cmd.Flags().GetString("dir")
+	outDir := filepath.Join(dir, "batch-"+strings.TrimSuffix(connect.GenerateFilename(cfg.LDAP.BaseDN, ""), "."))
+	results, err := runCollection(cmd, jobs, outDir, concurrency)
+	if err != nil {
+		return err
+	}
+	if err := writeBatchManifest(outDir, cfg.LDAP.BaseDN, format, entries, jobs, results); err != nil {
+		return err
+	}
+	return printCollectSummary(cmd, outDir, results)
+}
+
+// readBatchFile parses a batch file. Files ending in .yaml or .yml hold a
+// list of entries; any other file holds one query name or filter per line.
+func readBatchFile(path string) ([]batchEntry, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening batch file: %w", err)
+	}
+	defer f.Close()
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		var entries []batchEntry
+		if err := yaml.NewDecoder(f).Decode(&entries); err != nil {
+			return nil, fmt.Errorf("parsing %s: %w", path, err)
+		}
+		return entries, nil
+	}
+
+	var entries []batchEntry
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		switch {
+		case line == "" || strings.HasPrefix(line, "#"):
+		case strings.HasPrefix(line, "("):
+			entries = append(entries, batchEntry{Filter: line})
+		default:
+			entries = append(entries, batchEntry{Query: line})
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading batch file: %w", err)
+	}
+	return entries, nil
+}
+
+// batchJobs validates the entries and builds their queries, with the
+// attributes of the attribute profile unless an entry lists its own. Entries
+// without a name are labelled after their query, or filter-N for raw
+// filters; repeated labels get a numeric suffix so every result has its own
+// file.
+func batchJobs(entries []batchEntry, baseDN, profile string) ([]collectJob, error) {
+	jobs := make([]collectJob, len(entries))
+	seen := make(map[string]int, len(entries))
+	for i, e := range entries {
+		var q queries.Query
+		switch {
+		case e.Query != "" && e.Filter != "":
+			return nil, fmt.Errorf("entry %d: query and filter are mutually exclusive", i+1)
+		case e.Query != "":
+			registered, ok := queries.Get(e.Query)
+			if !ok {
+				return nil, fmt.Errorf("entry %d: unknown query %q", i+1, e.Query)
+			}
+			if registered.Partition != "" {
+				return nil, fmt.Errorf("entry %d: query %q searches the %s partition; run it with adgo quick", i+1, e.Query, registered.Partition)
+			}
+			q = registered
+		case e.Filter != "":
+			if err := analyze.ValidateFilter(e.Filter); err != nil {
+				return nil, fmt.Errorf("entry %d: %w", i+1, err)
+			}
+			q = queries.Query{Filter: e.Filter}
+		default:
+			return nil, fmt.Errorf("entry %d: query or filter is required", i+1)
+		}
+
+		b := queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, baseDN).WithBaseDN(baseDN).WithAttrProfile(profile)
+		for k, v := range e.Params {
+			b.WithParam(k, v)
+		}
+		if len(e.Attributes) > 0 {
+			b.WithAttributes(e.Attributes...)
+		}
+		if missing := b.MissingParams(); len(missing) > 0 {
+			return nil, fmt.Errorf("entry %d: missing params: %s", i+1, strings.Join(missing, ", "))
+		}
+
+		name := e.Name
+		switch {
+		case strings.ContainsAny(name, `/\`):
+			return nil, fmt.Errorf("entry %d: name %q must not contain path separators", i+1, name)
+		case name != "":
+		case e.Query != "":
+			name = e.Query
+		default:
+			name = "filter-" + strconv.Itoa(i+1)
+		}
+		if n := seen[name]; n > 0 {
+			seen[name]++
+			name += "-" + strconv.Itoa(n+1)
+		} else {
+			seen[name] = 1
+		}
+		jobs[i] = collectJob{Name: name, Query: b.Build()}
+	}
+	return jobs, nil
+}
+
+// writeBatchManifest writes manifest.json listing each query and its file
+func writeBatchManifest(outDir, baseDN, format string, entries []batchEntry, jobs []collectJob, results []collectResult) error {
+	manifest := batchManifest{
+		Generated: time.Now().UTC(),
+		BaseDN:    baseDN,
+		Format:    format,
+		Queries:   make([]batchManifestItem, len(jobs)),
+	}
+	for i, job := range jobs {
+		item := batchManifestItem{
+			Name:       job.Name,
+			Query:      entries[i].Query,
+			Filter:     job.Query.Filter,
+			Attributes: job.Query.Attributes,
+			File:       filepath.Base(results[i].Path),
+			Entries:    results[i].Entries,
+			DurationMs: results[i].Duration.Milliseconds(),
+		}
+		if results[i].Err != nil {
+			item.Error = results[i].Err.Error()
+		}
+		manifest.Queries[i] = item
+	}
+
+	path := filepath.Join(outDir, batchManifestFile)
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating manifest: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetIndent("", "  ")
+	enc.SetEscapeHTML(false) // Keep filters copyable
+	if err := enc.Encode(manifest); err != nil {
+		f.Close()
+		return fmt.Errorf("writing manifest: %w", err)
+	}
+	log.Infof("Manifest written to %s", path)
+	return f.Close()
+}
+
+func init() {
+	rootCmd.AddCommand(batchCmd)
+
+	batchCmd.Flags().StringP("dir", "d", ".", "Directory to create the timestamped output directory in")
+	batchCmd.Flags().Int("concurrency", 1, "Number of queries to run at once; 1 runs them sequentially")
+}
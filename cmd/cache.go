@@ -0,0 +1,98 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"fmt"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// resultCache serves repeated searches when the cache is enabled
+var resultCache *connect.Cache
+
+// setupCache enables the result cache from config, --cache and --no-cache,
+// with the TTL of --cache-ttl or config
+func setupCache(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	enabled := cfg.Cache.Enabled
+	if on, _ := cmd.Flags().GetBool("cache"); on {
+		enabled = true
+	}
+	if off, _ := cmd.Flags().GetBool("no-cache"); off {
+		enabled = false
+	}
+	if !enabled {
+		resultCache = nil
+		return nil
+	}
+
+	c, err := cacheFromConfig(cmd, cfg.Cache)
+	if err != nil {
+		return err
+	}
+	resultCache = c
+	log.Debugf("Serving searches from %s for %s", c.Dir, c.TTL)
+	return nil
+}
+
+// cacheFromConfig returns the cache directory and TTL from config,
+// overridden by --cache-ttl
+func cacheFromConfig(cmd *cobra.Command, c CacheConfig) (*connect.Cache, error) {
+	ttl := c.TTL
+	if cmd.Flags().Changed("cache-ttl") {
+		ttl, _ = cmd.Flags().GetDuration("cache-ttl")
+	}
+	if ttl == 0 {
+		ttl, _ = time.ParseDuration(analyze.DefaultCacheTTL)
+	}
+	if ttl < 0 {
+		return nil, fmt.Errorf("--cache-ttl must be positive")
+	}
+
+	dir := c.Dir
+	if dir == "" {
+		dir = connect.DefaultCacheDir()
+	}
+	return connect.NewCache(dir, ttl), nil
+}
+
+// cacheCmd represents the cache command
+var cacheCmd = &cobra.Command{
+	Use:   "cache",
+	Short: "Manage the local result cache",
+	Long: "With --cache, or cache.enabled in the config, identical searches (same server, " +
+		"bind user, filter, attributes and base DN) are answered from ~/.adgo/cache until cache.ttl expires, " +
+		"so iterating on output formatting does not query the domain controller again.",
+}
+
+// cacheClearCmd represents the cache clear command
+var cacheClearCmd = &cobra.Command{
+	Use:         "clear",
+	Short:       "Remove all cached search results",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		c, err := cacheFromConfig(cmd, GetConfig().Cache)
+		if err != nil {
+			return err
+		}
+		removed, err := c.Clear()
+		if err != nil {
+			return err
+		}
+		log.Infof("Removed %d cached results from %s", removed, c.Dir)
+		return nil
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(cacheCmd)
+	cacheCmd.AddCommand(cacheClearCmd)
+
+	rootCmd.PersistentFlags().Bool("cache", false, "Serve identical searches from the local result cache (default from config)")
+	rootCmd.PersistentFlags().Bool("no-cache", false, "Bypass the result cache even if enabled in config")
+	rootCmd.PersistentFlags().Duration("cache-ttl", 0, "How long cached results are served (default from config, 10m)")
+}
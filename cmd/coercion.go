@@ -0,0 +1,158 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// coercionCmd represents the coercion command
+var coercionCmd = &cobra.Command{
+	Use:   "coercion",
+	Short: "List computers where authentication coercion looks viable",
+	Long: "Coercion reads every computer and the published printers (printQueues query) " +
+		"and flags hints that coercion is viable: a published printer means the Print " +
+		"Spooler runs on its server (PrinterBug), an HTTP SPN suggests the WebClient service " +
+		"(WebDAV coercion over HTTP, relayable to LDAP). Computers with unconstrained " +
+		"delegation and a HOST SPN, domain controllers excepted, are marked \"coerce here\": " +
+		"coercing a DC to authenticate to them leaves its TGT. Hints come from the directory " +
+		"only and do not prove a service runs. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCoercion(cmd)
+	},
+}
+
+// runCoercion collects and prints the coercion candidates
+func runCoercion(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.CoercionCandidate) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeCoercionTable
+	case analyze.OutputFormatJSON:
+		write = writeCoercionJSON
+	case analyze.OutputFormatCSV:
+		write = writeCoercionCSV
+	default:
+		return fmt.Errorf("coercion output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	candidates, err := collectCoercion(cmd.Context(), ldapClient)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, candidates) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Coercion report generated: %s (%d computers)", path, len(candidates))
+	return nil
+}
+
+// collectCoercion searches the computers and published printers and
+// returns the coercion candidates
+func collectCoercion(ctx context.Context, client connect.Client) ([]analyze.CoercionCandidate, error) {
+	q, _ := queries.Get("computers")
+	computers, err := client.Search(ctx, q.Filter, analyze.CoercionAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching computers: %w", err)
+	}
+	q, _ = queries.Get("printQueues")
+	printQueues, err := client.Search(ctx, q.Filter, q.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching published printers: %w", err)
+	}
+	return analyze.CoercionCandidates(computers, printQueues), nil
+}
+
+// coercionName formats the computer column, marking domain controllers
+// and hosts to coerce to
+func coercionName(c analyze.CoercionCandidate) string {
+	switch {
+	case c.DC:
+		return c.Name + " (DC)"
+	case c.CoerceHere:
+		return c.Name + " (coerce here)"
+	}
+	return c.Name
+}
+
+// writeCoercionTable writes the candidates as an aligned table
+func writeCoercionTable(w io.Writer, candidates []analyze.CoercionCandidate) error {
+	if len(candidates) == 0 {
+		_, err := fmt.Fprintln(w, "No coercion candidates found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPUTER\tDNS HOST NAME\tHINTS")
+	coerceHere, dcs := 0, 0
+	for _, c := range candidates {
+		host := c.DNSHostName
+		if host == "" {
+			host = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", coercionName(c), host, strings.Join(c.Hints, ", "))
+		if c.CoerceHere {
+			coerceHere++
+		}
+		if c.DC {
+			dcs++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d computers with coercion hints, %d domain controllers, %d hosts to coerce to\n", len(candidates), dcs, coerceHere)
+	return err
+}
+
+// writeCoercionJSON writes the candidates as an indented JSON array
+func writeCoercionJSON(w io.Writer, candidates []analyze.CoercionCandidate) error {
+	if candidates == nil {
+		candidates = []analyze.CoercionCandidate{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(candidates)
+}
+
+// writeCoercionCSV writes one row per candidate, with a header row
+func writeCoercionCSV(w io.Writer, candidates []analyze.CoercionCandidate) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "dn", "dnsHostName", "dc", "coerceHere", "hints"})
+	for _, c := range candidates {
+		cw.Write([]string{c.Name, c.DN, c.DNSHostName, strconv.FormatBool(c.DC), strconv.FormatBool(c.CoerceHere), strings.Join(c.Hints, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(coercionCmd)
+}
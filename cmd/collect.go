@@ -0,0 +1,77 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/log"
+	"adgo/output"
+	"adgo/queries"
+	"fmt"
+	"path/filepath"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// collectCmd represents the collect command
+var collectCmd = &cobra.Command{
+	Use:   "collect",
+	Short: "Collect all object types into a BloodHound zip",
+	Long: "Collect runs the users, computers, groups, domains, GPOs, OUs, containers and trusts " +
+		"collections over a single LDAP session and writes a timestamped zip with one " +
+		"BloodHound CE JSON file per object type.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, err := cmd.Flags().GetString("dir")
+		if err != nil {
+			return err
+		}
+		return runCollect(cmd, dir)
+	},
+}
+
+// runCollect runs every collection and writes the archive into dir
+func runCollect(cmd *cobra.Command, dir string) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	// Member DNs carry SIDs in extended form so groups link by SID
+	ctx := connect.WithExtendedDN(cmd.Context())
+
+	var entries []*ldap.Entry
+	for _, c := range queries.Collections {
+		searchCtx, stopProgress := ctx, func() {}
+		if progressEnabled(cmd, false) {
+			searchCtx, stopProgress = startProgress(ctx, cfg.LDAP.SizeLimit)
+		}
+		results, err := ldapClient.Search(searchCtx, c.Query.Filter, c.Query.Attributes)
+		stopProgress()
+		if err != nil {
+			return fmt.Errorf("collecting %s: %w", c.Name, err)
+		}
+		log.Infof("Collected %d %s", len(results), c.Name)
+		entries = append(entries, results...)
+	}
+
+	prefix := time.Now().Format("20060102150405")
+	path := filepath.Join(dir, prefix+"_BloodHound.zip")
+	if err := output.WriteBloodHoundCEArchive(path, prefix, entries); err != nil {
+		return fmt.Errorf("writing archive: %w", err)
+	}
+
+	log.Infof("BloodHound archive generated: %s", path)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(collectCmd)
+
+	collectCmd.Flags().StringP("dir", "d", ".", "Directory to write the zip archive to")
+}
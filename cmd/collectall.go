@@ -0,0 +1,334 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/output"
+	"adgo/queries"
+	"adgo/tracing"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"text/tabwriter"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// querySetAll selects every registered query
+const querySetAll = "all"
+
+// querySets maps --set names to the quick command categories they run
+var querySets = map[string]string{
+	"basic":       queries.CategoryBasic,
+	"admin":       queries.CategoryAdmin,
+	"kerberos":    queries.CategoryKerberos,
+	"delegation":  queries.CategoryDelegation,
+	"adcs":        queries.CategoryADCS,
+	"permissions": queries.CategoryPermissions,
+}
+
+// collectJob is one query run by collect-all or batch
+type collectJob struct {
+	Name    string               // Label used for the output file and summary
+	Query   queries.Query        // Query with parameters substituted
+	Printer output.PrinterConfig // Output of the query
+}
+
+// collectResult is the outcome of one query run by collect-all or batch
+type collectResult struct {
+	Query    string
+	Path     string
+	Entries  int
+	Duration time.Duration
+	Err      error
+}
+
+// collectAllCmd represents the collect-all command
+var collectAllCmd = &cobra.Command{
+	Use:         "collect-all",
+	Annotations: map[string]string{annotationDryRun: "true"},
+	Short:       "Run every predefined query and save each result to a file",
+	Long: "Collect-all runs every registered query, or those of a --set, concurrently over a " +
+		"connection pool and writes one file per query in the --output format into a " +
+		"timestamped directory, followed by a summary of entry counts and failures.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runCollectAll(cmd)
+	},
+}
+
+// runCollectAll runs the selected queries and prints the summary
+func runCollectAll(cmd *cobra.Command) error {
+	cfg := GetConfig()
+
+	set, _ := cmd.Flags().GetString("set")
+	names, err := querySetQueries(set)
+	if err != nil {
+		return err
+	}
+
+	concurrency, _ := cmd.Flags().GetInt("concurrency")
+	if concurrency < 1 {
+		return fmt.Errorf("--concurrency must be at least 1")
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	if err := ValidateOutputFormat(format); err != nil {
+		return err
+	}
+	redact, _ := cmd.Flags().GetBool("redact")
+	jsonRaw, _ := cmd.Flags().GetBool("json-raw")
+	compressFlag, _ := cmd.Flags().GetString("compress")
+	compress, err := output.ParseCompression(compressFlag)
+	if err != nil {
+		return err
+	}
+	steps, err := postProcessSteps(cmd)
+	if err != nil {
+		return err
+	}
+	profile, err := attrProfile(cmd)
+	if err != nil {
+		return err
+	}
+
+	jobs := make([]collectJob, len(names))
+	for i, name := range names {
+		q, _ := queries.Get(name)
+		jobs[i] = collectJob{
+			Name:  name,
+			Query: queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, cfg.LDAP.BaseDN).WithBaseDN(cfg.LDAP.BaseDN).WithAttrProfile(profile).Build(),
+			Printer: output.PrinterConfig{
+				Format:      format,
+				Compress:    compress,
+				Query:       name,
+				Redact:      redact,
+				JSONRaw:     jsonRaw,
+				PostProcess: steps,
+			},
+		}
+	}
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		return planCollection(cmd, jobs, format)
+	}
+
+	dir, _ := cmd.Flags().GetString("dir")
+	outDir := filepath.Join(dir, strings.TrimSuffix(connect.GenerateFilename(cfg.LDAP.BaseDN, ""), "."))
+	results, err := runCollection(cmd, jobs, outDir, concurrency)
+	if err != nil {
+		return err
+	}
+	return printCollectSummary(cmd, outDir, results)
+}
+
+// runCollection runs jobs concurrently over a connection pool, writing
+// each result to <outDir>/<name>.<ext>
+func runCollection(cmd *cobra.Command, jobs []collectJob, outDir string, concurrency int) ([]collectResult, error) {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return nil, err
+	}
+	if err := os.MkdirAll(outDir, 0755); err != nil {
+		return nil, fmt.Errorf("creating output directory: %w", err)
+	}
+
+	// pool.maxConns caps the connections; extra workers wait for one
+	poolCfg := cfg.Pool
+	if poolCfg.MaxConns < 1 || concurrency < poolCfg.MaxConns {
+		poolCfg.MaxConns = concurrency
+	}
+	ldapClient, err := newPoolingClient(&cfg.LDAP, poolCfg)
+	if err != nil {
+		return nil, fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	log.Infof("Running %d queries with %d connections into %s", len(jobs), poolCfg.MaxConns, outDir)
+
+	results := make([]collectResult, len(jobs))
+	work := make(chan int)
+	var wg sync.WaitGroup
+	for range concurrency {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range work {
+				job := jobs[i]
+				job.Printer.Path = filepath.Join(outDir, job.Name+"."+output.FileExtension(job.Printer.Format)+output.CompressionExtension(job.Printer.Compress))
+				results[i] = collectQuery(cmd.Context(), ldapClient, job)
+			}
+		}()
+	}
+	for i := range jobs {
+		work <- i
+	}
+	close(work)
+	wg.Wait()
+
+	return results, nil
+}
+
+// planCollection prints the search request of every job, as a JSON object
+// keyed by job name with -o json
+func planCollection(cmd *cobra.Command, jobs []collectJob, format string) error {
+	cfg := GetConfig()
+	ctx := cmd.Context()
+	if output.IsBloodHoundFormat(format) {
+		ctx = connect.WithExtendedDN(ctx)
+	}
+
+	plans := make(map[string]connect.SearchPlan, len(jobs))
+	for _, job := range jobs {
+		plans[job.Name] = connect.PlanSearch(ctx, &cfg.LDAP, job.Query.Filter, withAttributes(job.Query.Attributes, output.RequiredAttributes(format)...))
+	}
+	if format == analyze.OutputFormatJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Keep filters copyable
+		return enc.Encode(plans)
+	}
+
+	for i, job := range jobs {
+		if i > 0 {
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "# %s\n", job.Name)
+		if err := printSearchPlan(cmd, plans[job.Name]); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// collectQuery runs one job and writes its result
+func collectQuery(ctx context.Context, ldapClient connect.Client, job collectJob) collectResult {
+	start := time.Now()
+	pc := job.Printer
+	result := collectResult{Query: job.Name, Path: pc.Path}
+
+	ctx, span := tracing.Start(ctx, "query", tracing.String("adgo.query", job.Name), tracing.String("ldap.filter", job.Query.Filter))
+	defer span.End()
+
+	if output.IsBloodHoundFormat(pc.Format) {
+		// Member DNs carry SIDs in extended form so groups link by SID
+		ctx = connect.WithExtendedDN(ctx)
+	}
+	q := job.Query
+	attributes := withAttributes(q.Attributes, output.RequiredAttributes(pc.Format)...)
+
+	var entries []*ldap.Entry
+	entries, result.Err = ldapClient.Search(ctx, q.Filter, attributes)
+	if result.Err == nil {
+		result.Entries = len(entries)
+		var printer output.Printer
+		if printer, result.Err = output.NewPrinter(pc); result.Err == nil {
+			result.Err = withTracing(ctx, printer, pc.Format).Print(entries)
+		}
+	}
+	span.SetAttributes(tracing.Int("adgo.entries", result.Entries))
+	span.SetError(result.Err)
+
+	result.Duration = time.Since(start)
+	if result.Err != nil {
+		log.Warnf("Query %s failed: %v", job.Name, result.Err)
+	}
+	return result
+}
+
+// querySetQueries returns the query names of a --set, sorted. Queries
+// that need user-supplied parameters or search another partition than the
+// domain are left out.
+func querySetQueries(set string) ([]string, error) {
+	if set == "" || set == querySetAll {
+		return slices.DeleteFunc(queries.GetNames(), skipCollect), nil
+	}
+
+	category, ok := querySets[strings.ToLower(set)]
+	if !ok {
+		names := make([]string, 0, len(querySets)+1)
+		for name := range querySets {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return nil, fmt.Errorf("unknown query set %q (must be %s or %s)", set, strings.Join(names, ", "), querySetAll)
+	}
+
+	var names []string
+	for _, name := range queries.GetNames() {
+		if getCommandCategory(name) == category && !skipCollect(name) {
+			names = append(names, name)
+		}
+	}
+	return names, nil
+}
+
+// skipCollect reports whether a query is left out of query sets: it needs
+// parameters, or searches another partition than the domain collected
+func skipCollect(name string) bool {
+	q, _ := queries.Get(name)
+	return needsParams(name) || q.Partition != ""
+}
+
+// needsParams reports whether a query has required parameters without defaults
+func needsParams(name string) bool {
+	q, _ := queries.Get(name)
+	return len(queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, "").WithBaseDN("").MissingParams()) > 0
+}
+
+// printCollectSummary prints one line per query and the totals, unless
+// --quiet is given.
+// Returns an error if any query failed: with exit code ExitPartial if others
+// succeeded, otherwise wrapping the first failure.
+func printCollectSummary(cmd *cobra.Command, outDir string, results []collectResult) error {
+	out := cmd.OutOrStdout()
+	if quietMode(cmd) {
+		out = io.Discard // Failures are still returned
+	}
+	w := tabwriter.NewWriter(out, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "QUERY\tENTRIES\tTIME\tSTATUS")
+
+	total, failed := 0, 0
+	var firstErr error
+	for _, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed: " + r.Err.Error()
+			failed++
+			if firstErr == nil {
+				firstErr = r.Err
+			}
+		}
+		total += r.Entries
+		fmt.Fprintf(w, "%s\t%d\t%s\t%s\n", r.Query, r.Entries, r.Duration.Round(time.Millisecond), status)
+	}
+	w.Flush()
+
+	fmt.Fprintf(out, "\n%d queries, %d entries, %d failed -> %s\n", len(results), total, failed, outDir)
+	if failed == len(results) && failed > 0 {
+		return fmt.Errorf("all %d queries failed: %w", failed, firstErr)
+	}
+	if failed > 0 {
+		return withExitCode(ExitPartial, fmt.Errorf("%d of %d queries failed", failed, len(results)))
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(collectAllCmd)
+
+	collectAllCmd.Flags().StringP("dir", "d", ".", "Directory to create the timestamped output directory in")
+	collectAllCmd.Flags().String("set", querySetAll, "Queries to run: all, basic, admin, kerberos, delegation, adcs or permissions")
+	collectAllCmd.Flags().Int("concurrency", analyze.DefaultCollectConcurrency, "Number of queries to run at once (pool connections)")
+}
@@ -4,15 +4,22 @@ import (
 	"adgo/analyze"
 	"adgo/connect"
 	"adgo/log"
+	"adgo/output"
+	"adgo/queries"
 	"bytes"
 	"errors"
 	"fmt"
 	"os"
-	"text/template"
+	"reflect"
+	"slices"
+	"strings"
 	"sync"
+	"time"
 
 	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
 	"github.com/spf13/viper"
+	"go.yaml.in/yaml/v3"
 )
 
 var (
@@ -22,15 +29,83 @@ var (
 
 // AppConfig application configuration structure
 type AppConfig struct {
-	LDAP   connect.Config `mapstructure:"ldap"`
-	Output string         `mapstructure:"output"`
+	LDAP connect.Config `mapstructure:"ldap" yaml:"ldap"`
+
+	// Profiles are alternative ldap sections selected with --profile or
+	// ADGO_PROFILE. Names are case-insensitive.
+	Profiles map[string]connect.Config `mapstructure:"profiles" yaml:"profiles,omitempty"`
+
+	Output  string         `mapstructure:"output" yaml:"output"`
+	Time    TimeConfig     `mapstructure:"time" yaml:"time"`
+	CSV     CSVConfig      `mapstructure:"csv" yaml:"csv"`
+	Queries QueriesConfig  `mapstructure:"queries" yaml:"queries"`
+	Plugins PluginsConfig  `mapstructure:"plugins" yaml:"plugins"`
+	Targets []TargetConfig `mapstructure:"targets" yaml:"targets,omitempty"`
+	Cache   CacheConfig    `mapstructure:"cache" yaml:"cache"`
+	Notify  NotifyConfig   `mapstructure:"notify" yaml:"notify"`
+	Log     LogConfig      `mapstructure:"log" yaml:"log"`
+
+	Retry connect.RetryConfig `mapstructure:"retry" yaml:"retry"` // Connection retries with exponential backoff
+	Pool  connect.PoolConfig  `mapstructure:"pool" yaml:"pool"`   // Connection pool of collect-all and batch
+}
+
+// CacheConfig configures the local cache of search results
+type CacheConfig struct {
+	Enabled bool          `mapstructure:"enabled" yaml:"enabled"` // Serve repeated searches from the cache
+	TTL     time.Duration `mapstructure:"ttl" yaml:"ttl"`         // How long a result is served after it was fetched
+	Dir     string        `mapstructure:"dir" yaml:"dir"`         // Cache directory; empty for ~/.adgo/cache
+}
+
+// QueriesConfig configures user-defined queries
+type QueriesConfig struct {
+	Dir    string               `mapstructure:"dir" yaml:"dir"`                 // Directory of YAML/JSON query packs, relative to the config file
+	Custom []queries.Definition `mapstructure:"custom" yaml:"custom,omitempty"` // Queries added with config add-query
+}
+
+// PluginsConfig configures plugin discovery. It is only honoured in the
+// user config file ~/.adgo/adgo.yaml.
+type PluginsConfig struct {
+	Enabled bool   `mapstructure:"enabled" yaml:"enabled"` // Run the plugins of Dir
+	Dir     string `mapstructure:"dir" yaml:"dir"`         // Plugin executables, relative to the config file; empty for ~/.adgo/plugins
+}
+
+// CSVConfig controls the CSV dialect, e.g. for Excel-centric consumers
+type CSVConfig struct {
+	Delimiter string `mapstructure:"delimiter" yaml:"delimiter"` // Field delimiter; "tab" for a tab
+	QuoteAll  bool   `mapstructure:"quoteAll" yaml:"quoteAll"`   // Quote every field
+	CRLF      bool   `mapstructure:"crlf" yaml:"crlf"`           // End lines with CRLF
+	BOM       bool   `mapstructure:"bom" yaml:"bom"`             // Start with a UTF-8 byte order mark
+	Wide      bool   `mapstructure:"wide" yaml:"wide"`           // One row per entry instead of one per attribute
+}
+
+// TimeConfig controls how timestamps are rendered in output
+type TimeConfig struct {
+	Zone   string `mapstructure:"zone" yaml:"zone"`     // UTC (default), Local or an IANA zone name
+	Format string `mapstructure:"format" yaml:"format"` // Go time layout or datetime, rfc3339, iso8601, date, rfc1123
+}
+
+// NotifyConfig configures the webhook notified after a query finishes
+type NotifyConfig struct {
+	URL      string `mapstructure:"url" yaml:"url"`           // Slack, Teams or generic webhook URL; empty disables notifications
+	MinScore int    `mapstructure:"minScore" yaml:"minScore"` // Only report entries scoring at least this much; 0 sends a summary
+}
+
+// LogConfig configures log output
+type LogConfig struct {
+	Level      string `mapstructure:"level" yaml:"level"`           // debug, info, warn or error
+	Format     string `mapstructure:"format" yaml:"format"`         // console or json
+	File       string `mapstructure:"file" yaml:"file"`             // Also write logs to this file; empty for stderr only
+	MaxSize    int    `mapstructure:"maxSize" yaml:"maxSize"`       // Rotate the file at this many megabytes; 0 never rotates
+	MaxBackups int    `mapstructure:"maxBackups" yaml:"maxBackups"` // Rotated files kept as FILE.1, FILE.2, ...
 }
 
 // Manager handles configuration loading, saving, and access in a thread-safe manner
 type Manager struct {
-	viper *viper.Viper
-	cfg   AppConfig
-	mu    sync.RWMutex
+	viper   *viper.Viper
+	cfg     AppConfig
+	profile string                 // Active profile replacing the ldap section, if any
+	flags   map[string]*pflag.Flag // Flags bound to viper keys
+	mu      sync.RWMutex
 }
 
 // NewManager creates a new configuration manager
@@ -38,29 +113,11 @@ func NewManager() *Manager {
 	return &Manager{
 		viper: viper.New(),
 		cfg:   AppConfig{},
+		flags: make(map[string]*pflag.Flag),
 	}
 }
 
-const (
-	defaultConfigFileName = "adgo.yaml"
-	configTemplateName    = "config"
-)
-
-var yamlTmpl = `# ADGO Configuration File
-
-# LDAP Connection Configuration
-ldap:
-  server: "{{.LDAP.Server}}"
-  port: {{.LDAP.Port}}
-  baseDN: "{{.LDAP.BaseDN}}"
-  username: "{{.LDAP.Username}}"
-  password: "{{.LDAP.Password}}"
-  loginName: "{{.LDAP.LoginName}}"
-  security: {{.LDAP.Security}}
-
-# Output Configuration
-output: "{{.Output}}"
-`
+const defaultConfigFileName = "adgo.yaml"
 
 // configSearchPaths defines where to look for configuration files
 var configSearchPaths = []string{
@@ -86,18 +143,53 @@ func saveConfigToFile(cfg AppConfig, path string, perm os.FileMode) error {
 	return nil
 }
 
-// generateConfigContent generates configuration content from template
+// configComments are written above the top-level keys of adgo.yaml
+var configComments = map[string]string{
+	"ldap":     "LDAP Connection Configuration",
+	"profiles": "Profiles (select with --profile NAME or ADGO_PROFILE; each replaces the ldap section)",
+	"output":   "Output Configuration",
+	"time":     "Time Formatting (zone: UTC, Local or e.g. Europe/Berlin; format: datetime, rfc3339 or a Go layout)",
+	"csv":      "CSV Dialect (for Excel: delimiter \";\" in some locales, crlf and bom true, wide true)",
+	"queries":  "Query Packs (directory of YAML/JSON files adding quick subcommands)",
+	"plugins":  "Plugins (executables adding queries, attribute formatters and post-processing steps; only read from ~/.adgo/adgo.yaml)",
+	"targets":  "Targets for --targets (unset fields are taken from the ldap section)",
+	"cache":    "Result Cache (serves repeated identical searches locally until ttl expires)",
+	"notify":   "Webhook Notification (Slack, Teams or generic JSON)",
+	"log":      "Logging (level: debug, info, warn or error; format: console or json; file is rotated at maxSize megabytes)",
+	"retry":    "Connection Retries (exponential backoff from initialDelay up to maxDelay)",
+	"pool":     "Connection Pool of collect-all and batch (idleTimeout and maxLifetime 0 = never replace)",
+}
+
+// generateConfigContent marshals the whole configuration, so every key
+// viper can read is also written back
 func generateConfigContent(cfg AppConfig) ([]byte, error) {
-	tmpl, err := template.New(configTemplateName).Parse(yamlTmpl)
-	if err != nil {
-		return nil, fmt.Errorf("failed to parse config template: %w", err)
+	var doc yaml.Node
+	if err := doc.Encode(cfg); err != nil {
+		return nil, fmt.Errorf("failed to generate config content: %w", err)
+	}
+	for i := 0; i < len(doc.Content); i += 2 {
+		doc.Content[i].HeadComment = configComments[doc.Content[i].Value]
 	}
 
-	var buf bytes.Buffer
-	if err := tmpl.Execute(&buf, cfg); err != nil {
+	var body bytes.Buffer
+	enc := yaml.NewEncoder(&body)
+	enc.SetIndent(2)
+	if err := enc.Encode(&doc); err != nil {
+		return nil, fmt.Errorf("failed to generate config content: %w", err)
+	}
+	if err := enc.Close(); err != nil {
 		return nil, fmt.Errorf("failed to generate config content: %w", err)
 	}
 
+	// Separate the sections by a blank line
+	var buf bytes.Buffer
+	buf.WriteString("# ADGO Configuration File\n")
+	for _, line := range strings.SplitAfter(body.String(), "\n") {
+		if strings.HasPrefix(line, "# ") {
+			buf.WriteString("\n")
+		}
+		buf.WriteString(line)
+	}
 	return buf.Bytes(), nil
 }
 
@@ -133,11 +225,72 @@ func (m *Manager) Init() error {
 	return m.viper.Unmarshal(&m.cfg)
 }
 
-// Get returns the current application configuration
+// Get returns the current application configuration, with the ldap section
+// of the active profile
 func (m *Manager) Get() AppConfig {
 	m.mu.RLock()
 	defer m.mu.RUnlock()
-	return m.cfg
+	cfg := m.cfg
+	if m.profile != "" {
+		cfg.LDAP = m.flagOverrides(profileLDAP(m.cfg.Profiles[m.profile]), m.cfg.LDAP)
+	}
+	return cfg
+}
+
+// flagOverrides copies the ldap settings given as flags, which viper merged
+// into base, over the settings of a profile
+func (m *Manager) flagOverrides(c, base connect.Config) connect.Config {
+	for key, flag := range m.flags {
+		if !flag.Changed {
+			continue
+		}
+		switch key {
+		case analyze.ConfigLDAPServer:
+			c.Server = base.Server
+		case analyze.ConfigLDAPPort:
+			c.Port = base.Port
+		case analyze.ConfigLDAPBaseDN:
+			c.BaseDN = base.BaseDN
+		case analyze.ConfigLDAPUsername:
+			c.Username = base.Username
+		case analyze.ConfigLDAPPassword:
+			c.Password = base.Password
+		case analyze.ConfigLDAPLoginName:
+			c.LoginName = base.LoginName
+		case analyze.ConfigLDAPSecurity:
+			c.Security = base.Security
+		}
+	}
+	return c
+}
+
+// SetProfile makes the named profile replace the ldap section returned by
+// Get. The ldap section itself is still what Save writes.
+func (m *Manager) SetProfile(name string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	key := strings.ToLower(name)
+	if _, ok := m.cfg.Profiles[key]; !ok {
+		if len(m.cfg.Profiles) == 0 {
+			return fmt.Errorf("unknown profile '%s': no profiles configured", name)
+		}
+		names := make([]string, 0, len(m.cfg.Profiles))
+		for n := range m.cfg.Profiles {
+			names = append(names, n)
+		}
+		slices.Sort(names)
+		return fmt.Errorf("unknown profile '%s' (must be %s)", name, strings.Join(names, ", "))
+	}
+	m.profile = key
+	return nil
+}
+
+// Profile returns the name of the active profile, or an empty string
+func (m *Manager) Profile() string {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.profile
 }
 
 // Set sets a configuration value by key and updates the internal config struct
@@ -149,6 +302,22 @@ func (m *Manager) Set(key string, value interface{}) error {
 	return m.viper.Unmarshal(&m.cfg)
 }
 
+// Unset resets a dotted key to its default, removing map entries and list
+// elements. Like Set it changes what Save writes; unlike Set it does not
+// survive a Reload, since viper cannot delete keys.
+func (m *Manager) Unset(key string) error {
+	defaults := NewManager()
+	defaults.setDefaults()
+	var def AppConfig
+	if err := defaults.viper.Unmarshal(&def); err != nil {
+		return err
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	return unsetConfig(&m.cfg, def, key)
+}
+
 // Save saves the current configuration to adgo.yaml in the current directory
 // with file permissions 0600 (read/write for owner only).
 func (m *Manager) Save() error {
@@ -203,6 +372,34 @@ func (m *Manager) Validate() error {
 			analyze.SecurityModeNone, analyze.SecurityModeInsecureStartTLS)
 	}
 
+	if cfg.LDAP.Timeout < 0 {
+		return errors.New("LDAP timeout must not be negative")
+	}
+
+	if cfg.LDAP.SizeLimit < 0 {
+		return errors.New("LDAP size limit must not be negative")
+	}
+
+	if cfg.Retry.MaxAttempts < 1 {
+		return errors.New("retry max attempts must be at least 1")
+	}
+
+	if cfg.Retry.InitialDelay <= 0 || cfg.Retry.MaxDelay < cfg.Retry.InitialDelay {
+		return errors.New("retry delays must be positive, with maxDelay at least initialDelay")
+	}
+
+	if cfg.Retry.Multiplier < 1 {
+		return errors.New("retry multiplier must be at least 1")
+	}
+
+	if cfg.Pool.MaxConns < 1 {
+		return errors.New("pool max connections must be at least 1")
+	}
+
+	if cfg.Pool.IdleTimeout < 0 || cfg.Pool.MaxLifetime < 0 {
+		return errors.New("pool idle timeout and max lifetime must not be negative")
+	}
+
 	return nil
 }
 
@@ -221,9 +418,7 @@ func (m *Manager) ConfigPath() string {
 
 // LDAPConfig returns the LDAP connection configuration
 func (m *Manager) LDAPConfig() connect.Config {
-	m.mu.RLock()
-	defer m.mu.RUnlock()
-	return m.cfg.LDAP
+	return m.Get().LDAP
 }
 
 // OutputFormat returns the configured output format
@@ -233,10 +428,13 @@ func (m *Manager) OutputFormat() string {
 	return m.cfg.Output
 }
 
-// BindFlag binds a command line flag to a viper configuration key
-func (m *Manager) BindFlag(key string, flag interface{}) error {
-	// This method is kept for compatibility but not used in current implementation
-	return nil
+// BindFlag binds a command line flag to a viper configuration key, so a
+// flag given on the command line overrides the config file
+func (m *Manager) BindFlag(key string, flag *pflag.Flag) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.flags[key] = flag
+	return m.viper.BindPFlag(key, flag)
 }
 
 // setDefaults sets default values for configuration
@@ -249,9 +447,58 @@ func (m *Manager) setDefaults() {
 	m.viper.SetDefault(analyze.ConfigLDAPPassword, "")
 	m.viper.SetDefault(analyze.ConfigLDAPLoginName, analyze.DefaultLoginName)
 	m.viper.SetDefault(analyze.ConfigLDAPSecurity, analyze.DefaultLDAPSecurity)
+	m.viper.SetDefault(analyze.ConfigLDAPTimeout, analyze.DefaultConnectionTimeout)
+	m.viper.SetDefault(analyze.ConfigLDAPSizeLimit, 0)
 
 	// Output defaults
 	m.viper.SetDefault(analyze.ConfigOutput, analyze.DefaultOutputFormat)
+
+	// Time defaults
+	m.viper.SetDefault(analyze.ConfigTimeZone, "UTC")
+	m.viper.SetDefault(analyze.ConfigTimeFormat, "datetime")
+
+	// CSV defaults
+	m.viper.SetDefault(analyze.ConfigCSVDelimiter, ",")
+	m.viper.SetDefault(analyze.ConfigCSVQuoteAll, false)
+	m.viper.SetDefault(analyze.ConfigCSVCRLF, false)
+	m.viper.SetDefault(analyze.ConfigCSVBOM, false)
+	m.viper.SetDefault(analyze.ConfigCSVWide, false)
+
+	// Query pack defaults
+	m.viper.SetDefault(analyze.ConfigQueriesDir, "")
+
+	// Plugin defaults
+	m.viper.SetDefault(analyze.ConfigPluginsEnabled, false)
+	m.viper.SetDefault(analyze.ConfigPluginsDir, "")
+
+	// Cache defaults
+	m.viper.SetDefault(analyze.ConfigCacheEnabled, false)
+	m.viper.SetDefault(analyze.ConfigCacheTTL, analyze.DefaultCacheTTL)
+	m.viper.SetDefault(analyze.ConfigCacheDir, "")
+
+	// Notification defaults
+	m.viper.SetDefault(analyze.ConfigNotifyURL, "")
+	m.viper.SetDefault(analyze.ConfigNotifyMinScore, 0)
+
+	// Log defaults
+	m.viper.SetDefault(analyze.ConfigLogLevel, analyze.DefaultLogLevel)
+	m.viper.SetDefault(analyze.ConfigLogFormat, analyze.DefaultLogFormat)
+	m.viper.SetDefault(analyze.ConfigLogFile, "")
+	m.viper.SetDefault(analyze.ConfigLogMaxSize, analyze.DefaultLogMaxSize)
+	m.viper.SetDefault(analyze.ConfigLogMaxBackups, analyze.DefaultLogMaxBackups)
+
+	// Retry defaults
+	retry := connect.DefaultRetryConfig()
+	m.viper.SetDefault(analyze.ConfigRetryMaxAttempts, retry.MaxAttempts)
+	m.viper.SetDefault(analyze.ConfigRetryInitialDelay, retry.InitialDelay)
+	m.viper.SetDefault(analyze.ConfigRetryMaxDelay, retry.MaxDelay)
+	m.viper.SetDefault(analyze.ConfigRetryMultiplier, retry.Multiplier)
+
+	// Pool defaults
+	pool := connect.DefaultPoolConfig()
+	m.viper.SetDefault(analyze.ConfigPoolMaxConns, pool.MaxConns)
+	m.viper.SetDefault(analyze.ConfigPoolIdleTimeout, pool.IdleTimeout)
+	m.viper.SetDefault(analyze.ConfigPoolMaxLifetime, pool.MaxLifetime)
 }
 
 // Cobra Commands
@@ -268,12 +515,12 @@ var initCmd = &cobra.Command{
 	Use:   "init",
 	Short: "Initialize configuration file",
 	Long:  "Generate adgo.yaml in the current directory.",
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		if err := SaveConfig(); err != nil {
-			log.Errorf("Initializing configuration: %v", err)
-			return
+			return fmt.Errorf("initializing configuration: %w", err)
 		}
 		log.Info("Configuration initialized")
+		return nil
 	},
 }
 
@@ -283,26 +530,83 @@ var setCmd = &cobra.Command{
 	Short: "Set a configuration value",
 	Long:  "Set a value in adgo.yaml, e.g., ldap.server / ldap.baseDN / output.",
 	Args:  cobra.ExactArgs(2),
-	Run: func(cmd *cobra.Command, args []string) {
+	RunE: func(cmd *cobra.Command, args []string) error {
 		key := args[0]
 		value := args[1]
 
 		// Validate input
+		if err := checkConfigKey(key); err != nil {
+			return err
+		}
 		if err := validateConfigSet(key, value); err != nil {
-			log.Error(err.Error())
-			return
+			return err
 		}
 
 		if err := SetConfig(key, value); err != nil {
-			log.Errorf("Setting %s: %v", key, err)
-			return
+			return fmt.Errorf("setting %s: %w", key, err)
 		}
 
 		if err := SaveConfig(); err != nil {
-			log.Errorf("Saving configuration: %v", err)
-			return
+			return fmt.Errorf("saving configuration: %w", err)
+		}
+		if redact, _ := cmd.Flags().GetBool("redact"); redact && key == analyze.ConfigLDAPPassword {
+			value = output.Redacted
 		}
 		log.Infof("Configuration updated: %s = %s", key, value)
+		return nil
+	},
+}
+
+// getCmd represents the config get command
+var getCmd = &cobra.Command{
+	Use:   "get KEY",
+	Short: "Print a configuration value",
+	Long: "Print the value of a dotted key such as ldap.server, profiles.lab.port or targets.0.name. " +
+		"A section prints one \"key: value\" line per setting. Passwords are masked unless " +
+		"--reveal is given or they refer to the OS keyring.",
+	Example: `  adgo config get ldap.server
+  adgo config get profiles.lab
+  SERVER=$(adgo config get ldap.server)`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		v, err := lookupConfig(GetConfig(), key)
+		if err != nil {
+			return err
+		}
+
+		reveal, _ := cmd.Flags().GetBool("reveal")
+		out := cmd.OutOrStdout()
+		flattenConfig(key, v, func(k string, v reflect.Value) {
+			if k == key {
+				fmt.Fprintln(out, formatConfigValue(k, v, reveal))
+				return
+			}
+			fmt.Fprintf(out, "%s: %s\n", k, formatConfigValue(k, v, reveal))
+		})
+		return nil
+	},
+}
+
+// unsetCmd represents the config unset command
+var unsetCmd = &cobra.Command{
+	Use:   "unset KEY",
+	Short: "Reset a configuration value to its default",
+	Long: "Reset a dotted key in adgo.yaml to its default, e.g. ldap.password. Profiles " +
+		"(profiles.lab), targets (targets.0) and saved queries (queries.custom.0) are removed.",
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		key := args[0]
+		if err := UnsetConfig(key); err != nil {
+			return fmt.Errorf("unsetting %w", err)
+		}
+		if err := SaveConfig(); err != nil {
+			return fmt.Errorf("saving configuration: %w", err)
+		}
+		log.Infof("Configuration updated: %s unset", key)
+		return nil
 	},
 }
 
@@ -330,7 +634,11 @@ var showCmd = &cobra.Command{
 		cmd.Println()
 
 		// Show LDAP section
-		cmd.Println("LDAP:")
+		if profile := ActiveProfile(); profile != "" {
+			cmd.Printf("LDAP (profile %s):\n", profile)
+		} else {
+			cmd.Println("LDAP:")
+		}
 		cmd.Printf("  Server:   %s\n", valueOrNotSet(c.LDAP.Server))
 		cmd.Printf("  Port:     %d\n", c.LDAP.Port)
 		cmd.Printf("  BaseDN:   %s\n", valueOrNotSet(c.LDAP.BaseDN))
@@ -338,12 +646,95 @@ var showCmd = &cobra.Command{
 		cmd.Printf("  Login:    %s\n", c.LDAP.LoginName)
 		securityName, _ := analyze.SecurityModeName(int(c.LDAP.Security))
 		cmd.Printf("  Security: %s (%d)\n", securityName, c.LDAP.Security)
+		cmd.Printf("  Timeout:  %ds\n", c.LDAP.Timeout)
+		cmd.Printf("  SizeLimit: %d\n", c.LDAP.SizeLimit)
+		cmd.Println()
+
+		// Show Retry section
+		cmd.Println("Retry:")
+		cmd.Printf("  MaxAttempts:  %d\n", c.Retry.MaxAttempts)
+		cmd.Printf("  InitialDelay: %s\n", c.Retry.InitialDelay)
+		cmd.Printf("  MaxDelay:     %s\n", c.Retry.MaxDelay)
+		cmd.Printf("  Multiplier:   %g\n", c.Retry.Multiplier)
 		cmd.Println()
 
+		// Show Pool section
+		cmd.Println("Pool:")
+		cmd.Printf("  MaxConns:    %d\n", c.Pool.MaxConns)
+		cmd.Printf("  IdleTimeout: %s\n", c.Pool.IdleTimeout)
+		cmd.Printf("  MaxLifetime: %s\n", c.Pool.MaxLifetime)
+		cmd.Println()
+
+		// Show Profiles section
+		if len(c.Profiles) > 0 {
+			cmd.Println("Profiles:")
+			names := make([]string, 0, len(c.Profiles))
+			for name := range c.Profiles {
+				names = append(names, name)
+			}
+			slices.Sort(names)
+			for _, name := range names {
+				p := c.Profiles[name]
+				cmd.Printf("  %-9s %s (%s)\n", name+":", valueOrNotSet(p.Server), valueOrNotSet(p.BaseDN))
+			}
+			cmd.Println()
+		}
+
 		// Show Output section
 		cmd.Println("Output:")
 		cmd.Printf("  Format:   %s\n", c.Output)
 		cmd.Println()
+
+		// Show Time section
+		cmd.Println("Time:")
+		cmd.Printf("  Zone:     %s\n", c.Time.Zone)
+		cmd.Printf("  Format:   %s\n", c.Time.Format)
+		cmd.Println()
+
+		// Show CSV section
+		cmd.Println("CSV:")
+		cmd.Printf("  Delimiter: %q\n", c.CSV.Delimiter)
+		cmd.Printf("  QuoteAll:  %t\n", c.CSV.QuoteAll)
+		cmd.Printf("  CRLF:      %t\n", c.CSV.CRLF)
+		cmd.Printf("  BOM:       %t\n", c.CSV.BOM)
+		cmd.Printf("  Wide:      %t\n", c.CSV.Wide)
+		cmd.Println()
+
+		// Show Queries section
+		cmd.Println("Queries:")
+		cmd.Printf("  Dir:      %s\n", valueOrNotSet(c.Queries.Dir))
+		for _, q := range c.Queries.Custom {
+			cmd.Printf("  Custom:   %s %s\n", q.Name, q.Filter)
+		}
+		cmd.Println()
+
+		// Show Plugins section
+		cmd.Println("Plugins:")
+		cmd.Printf("  Enabled:  %t\n", c.Plugins.Enabled)
+		cmd.Printf("  Dir:      %s\n", valueOrNotSet(c.Plugins.Dir))
+		cmd.Println()
+
+		// Show Cache section
+		cmd.Println("Cache:")
+		cmd.Printf("  Enabled:  %t\n", c.Cache.Enabled)
+		cmd.Printf("  TTL:      %s\n", c.Cache.TTL)
+		cmd.Printf("  Dir:      %s\n", valueOrNotSet(c.Cache.Dir))
+		cmd.Println()
+
+		// Show Notify section
+		cmd.Println("Notify:")
+		cmd.Printf("  URL:      %s\n", valueOrNotSet(c.Notify.URL))
+		cmd.Printf("  MinScore: %d\n", c.Notify.MinScore)
+		cmd.Println()
+
+		// Show Log section
+		cmd.Println("Log:")
+		cmd.Printf("  Level:      %s\n", c.Log.Level)
+		cmd.Printf("  Format:     %s\n", c.Log.Format)
+		cmd.Printf("  File:       %s\n", valueOrNotSet(c.Log.File))
+		cmd.Printf("  MaxSize:    %d MB\n", c.Log.MaxSize)
+		cmd.Printf("  MaxBackups: %d\n", c.Log.MaxBackups)
+		cmd.Println()
 	},
 }
 
@@ -376,6 +767,15 @@ func SetConfig(key string, value interface{}) error {
 	return cfgManager.Set(key, value)
 }
 
+// UnsetConfig resets a configuration value to its default. The key should be
+// a dot-separated path (e.g., "ldap.timeout" or "profiles.lab").
+func UnsetConfig(key string) error {
+	if cfgManager == nil {
+		cfgManager = NewManager()
+	}
+	return cfgManager.Unset(key)
+}
+
 // SaveConfig saves the current configuration to adgo.yaml in the current directory
 // with file permissions 0600 (read/write for owner only).
 func SaveConfig() error {
@@ -404,6 +804,34 @@ func Validate() error {
 	return cfgManager.Validate()
 }
 
+// SetProfile selects the profile replacing the ldap section of the configuration.
+func SetProfile(name string) error {
+	if cfgManager == nil {
+		cfgManager = NewManager()
+	}
+	return cfgManager.SetProfile(name)
+}
+
+// ActiveProfile returns the name of the selected profile, or an empty string.
+func ActiveProfile() string {
+	if cfgManager == nil {
+		cfgManager = NewManager()
+	}
+	return cfgManager.Profile()
+}
+
+// profileLDAP returns the connection settings of a profile, with the
+// defaults for a port and login name it does not set
+func profileLDAP(p connect.Config) connect.Config {
+	if p.Port == 0 {
+		p.Port = analyze.DefaultLDAPPort
+	}
+	if p.LoginName == "" {
+		p.LoginName = analyze.DefaultLoginName
+	}
+	return p
+}
+
 // valueOrNotSet returns value or "(not set)" if empty
 func valueOrNotSet(s string) string {
 	if s == "" {
@@ -474,28 +902,27 @@ func bindFlag(cmd *cobra.Command, flagName, viperKey, usage string, defaultValue
 	case int:
 		cmd.PersistentFlags().Int(flagName, val, usage)
 	}
-	// Note: This uses the global viper for backward compatibility
-	// In a future refactor, this would use cfgManager.BindFlag
-	return viper.BindPFlag(viperKey, cmd.PersistentFlags().Lookup(flagName))
+	return cfgManager.BindFlag(viperKey, cmd.PersistentFlags().Lookup(flagName))
 }
 
 // BindFlags binds command line flags to viper configuration keys, allowing
 // flag values to override configuration file values.
 func BindFlags(cmd *cobra.Command) {
-	// Note: This uses the global viper for backward compatibility
-	// In a future refactor, this would be updated to use cfgManager
-	v := viper.New()
+	if cfgManager == nil {
+		cfgManager = NewManager()
+	}
+	v := cfgManager
 
-	v.BindPFlag(analyze.ConfigLDAPServer, cmd.PersistentFlags().Lookup("server"))
-	v.BindPFlag(analyze.ConfigLDAPPort, cmd.PersistentFlags().Lookup("port"))
-	v.BindPFlag(analyze.ConfigLDAPBaseDN, cmd.PersistentFlags().Lookup("baseDN"))
-	v.BindPFlag(analyze.ConfigLDAPUsername, cmd.PersistentFlags().Lookup("username"))
-	v.BindPFlag(analyze.ConfigLDAPPassword, cmd.PersistentFlags().Lookup("password"))
+	v.BindFlag(analyze.ConfigLDAPServer, cmd.PersistentFlags().Lookup("server"))
+	v.BindFlag(analyze.ConfigLDAPPort, cmd.PersistentFlags().Lookup("port"))
+	v.BindFlag(analyze.ConfigLDAPBaseDN, cmd.PersistentFlags().Lookup("baseDN"))
+	v.BindFlag(analyze.ConfigLDAPUsername, cmd.PersistentFlags().Lookup("username"))
+	v.BindFlag(analyze.ConfigLDAPPassword, cmd.PersistentFlags().Lookup("password"))
 
 	if cmd.PersistentFlags().Lookup("login-name") == nil {
 		bindFlag(cmd, "login-name", analyze.ConfigLDAPLoginName, "Login name format (userPrincipalName or sAMAccountName)", analyze.DefaultLoginName)
 	} else {
-		v.BindPFlag(analyze.ConfigLDAPLoginName, cmd.PersistentFlags().Lookup("login-name"))
+		v.BindFlag(analyze.ConfigLDAPLoginName, cmd.PersistentFlags().Lookup("login-name"))
 	}
 
 	if cmd.PersistentFlags().Lookup("security") == nil {
@@ -508,10 +935,10 @@ func BindFlags(cmd *cobra.Command) {
 				analyze.SecurityModeInsecureStartTLS),
 			analyze.DefaultLDAPSecurity)
 	} else {
-		v.BindPFlag(analyze.ConfigLDAPSecurity, cmd.PersistentFlags().Lookup("security"))
+		v.BindFlag(analyze.ConfigLDAPSecurity, cmd.PersistentFlags().Lookup("security"))
 	}
 
-	v.BindPFlag(analyze.ConfigOutput, cmd.PersistentFlags().Lookup("output"))
+	v.BindFlag(analyze.ConfigOutput, cmd.PersistentFlags().Lookup("output"))
 }
 
 // validateConfigSet validates the key-value pair for config set command
@@ -525,6 +952,36 @@ func validateConfigSet(key, value string) error {
 		return ValidateSecurityModeString(value)
 	case analyze.ConfigOutput:
 		return ValidateOutputFormat(value)
+	case analyze.ConfigTimeZone:
+		return ValidateTimeZone(value)
+	case analyze.ConfigCSVDelimiter:
+		_, err := output.ParseCSVDelimiter(value)
+		return err
+	case analyze.ConfigCSVQuoteAll, analyze.ConfigCSVCRLF, analyze.ConfigCSVBOM, analyze.ConfigCSVWide, analyze.ConfigCacheEnabled:
+		return ValidateBoolString(value)
+	case analyze.ConfigCacheTTL, analyze.ConfigRetryInitialDelay, analyze.ConfigRetryMaxDelay:
+		return ValidateDurationString(value)
+	case analyze.ConfigPoolIdleTimeout, analyze.ConfigPoolMaxLifetime:
+		if value == "0" {
+			return nil // Never replace connections
+		}
+		return ValidateDurationString(value)
+	case analyze.ConfigLDAPTimeout, analyze.ConfigLDAPSizeLimit:
+		return ValidateCountString(value, 0)
+	case analyze.ConfigRetryMaxAttempts, analyze.ConfigPoolMaxConns:
+		return ValidateCountString(value, 1)
+	case analyze.ConfigRetryMultiplier:
+		return ValidateMultiplierString(value)
+	case analyze.ConfigNotifyURL:
+		return ValidateWebhookURL(value)
+	case analyze.ConfigNotifyMinScore:
+		return ValidateMinScoreString(value)
+	case analyze.ConfigLogLevel:
+		return log.ValidateLevel(value)
+	case analyze.ConfigLogFormat:
+		return log.ValidateFormat(value)
+	case analyze.ConfigLogMaxSize, analyze.ConfigLogMaxBackups:
+		return ValidateCountString(value, 0)
 	}
 	return nil
 }
@@ -536,4 +993,8 @@ func init() {
 	configCmd.AddCommand(initCmd)
 	configCmd.AddCommand(setCmd)
 	configCmd.AddCommand(showCmd)
+	configCmd.AddCommand(getCmd)
+	configCmd.AddCommand(unsetCmd)
+
+	getCmd.Flags().Bool("reveal", false, "Print passwords instead of masking them")
 }
@@ -0,0 +1,237 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/output"
+	"fmt"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// configFieldName returns the config key of a struct field: its mapstructure
+// tag, its yaml tag or its name, as mapstructure matches them
+func configFieldName(f reflect.StructField) string {
+	for _, tag := range []string{"mapstructure", "yaml"} {
+		if name, _, _ := strings.Cut(f.Tag.Get(tag), ","); name != "" && name != "-" {
+			return name
+		}
+	}
+	return f.Name
+}
+
+// configChild returns the element of v named by one segment of a dotted
+// config key. Struct fields match case-insensitively like viper keys; map
+// keys are lowercase as viper stores them; slices are indexed by number.
+func configChild(v reflect.Value, name string) (reflect.Value, error) {
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return reflect.Value{}, fmt.Errorf("not set")
+		}
+		return configChild(v.Elem(), name)
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			if strings.EqualFold(configFieldName(v.Type().Field(i)), name) {
+				return v.Field(i), nil
+			}
+		}
+	case reflect.Map:
+		if e := v.MapIndex(reflect.ValueOf(strings.ToLower(name)).Convert(v.Type().Key())); e.IsValid() {
+			return e, nil
+		}
+		return reflect.Value{}, fmt.Errorf("not set")
+	case reflect.Slice:
+		if i, err := strconv.Atoi(name); err == nil && i >= 0 && i < v.Len() {
+			return v.Index(i), nil
+		}
+		return reflect.Value{}, fmt.Errorf("index out of range")
+	}
+	return reflect.Value{}, fmt.Errorf("unknown key")
+}
+
+// lookupConfig returns the value of cfg at a dotted key such as ldap.server,
+// profiles.lab.port or targets.0.name
+func lookupConfig(cfg AppConfig, key string) (reflect.Value, error) {
+	v := reflect.ValueOf(cfg)
+	for _, name := range strings.Split(key, ".") {
+		child, err := configChild(v, name)
+		if err != nil {
+			return reflect.Value{}, fmt.Errorf("%s: %w", key, err)
+		}
+		v = child
+	}
+	return v, nil
+}
+
+// checkConfigKey fails for keys that are not part of AppConfig, which Save
+// would silently drop, and for keys config set cannot change: whole sections
+// and list elements
+func checkConfigKey(key string) error {
+	t := reflect.TypeOf(AppConfig{})
+	for _, name := range strings.Split(key, ".") {
+		switch t.Kind() {
+		case reflect.Struct:
+			found := false
+			for i := 0; i < t.NumField(); i++ {
+				if strings.EqualFold(configFieldName(t.Field(i)), name) {
+					t, found = t.Field(i).Type, true
+					break
+				}
+			}
+			if !found {
+				return fmt.Errorf("unknown configuration key %s", key)
+			}
+		case reflect.Map:
+			t = t.Elem()
+		case reflect.Slice:
+			return fmt.Errorf("%s is inside a list; edit %s instead", key, defaultConfigFileName)
+		default:
+			return fmt.Errorf("unknown configuration key %s", key)
+		}
+		if t.Kind() == reflect.Pointer {
+			t = t.Elem()
+		}
+	}
+	if t.Kind() == reflect.Struct || t.Kind() == reflect.Map ||
+		(t.Kind() == reflect.Slice && t.Elem().Kind() == reflect.Struct) {
+		return fmt.Errorf("%s is a section; set one of its keys", key)
+	}
+	return nil
+}
+
+// unsetConfig resets the value of cfg at a dotted key to its value in
+// defaults. Map entries and slice elements are removed.
+func unsetConfig(cfg *AppConfig, defaults AppConfig, key string) error {
+	if err := unsetConfigValue(reflect.ValueOf(cfg).Elem(), reflect.ValueOf(defaults), strings.Split(key, ".")); err != nil {
+		return fmt.Errorf("%s: %w", key, err)
+	}
+	return nil
+}
+
+// unsetConfigValue resets the element of v at path; def holds the default
+// of v, or is invalid below maps and slices, which have no defaults
+func unsetConfigValue(v, def reflect.Value, path []string) error {
+	var defChild reflect.Value
+	if def.IsValid() && def.Kind() == reflect.Struct {
+		defChild, _ = configChild(def, path[0])
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if v.IsNil() {
+			return fmt.Errorf("not set")
+		}
+		if def.IsValid() && !def.IsNil() {
+			def = def.Elem()
+		} else {
+			def = reflect.Value{}
+		}
+		return unsetConfigValue(v.Elem(), def, path)
+	case reflect.Map:
+		k := reflect.ValueOf(strings.ToLower(path[0])).Convert(v.Type().Key())
+		e := v.MapIndex(k)
+		if !e.IsValid() {
+			return fmt.Errorf("not set")
+		}
+		if len(path) == 1 {
+			v.SetMapIndex(k, reflect.Value{})
+			return nil
+		}
+		// Map elements are not addressable; change a copy and store it back
+		cp := reflect.New(e.Type()).Elem()
+		cp.Set(e)
+		if err := unsetConfigValue(cp, reflect.Value{}, path[1:]); err != nil {
+			return err
+		}
+		v.SetMapIndex(k, cp)
+		return nil
+	case reflect.Slice:
+		i, err := strconv.Atoi(path[0])
+		if err != nil || i < 0 || i >= v.Len() {
+			return fmt.Errorf("index out of range")
+		}
+		if len(path) == 1 {
+			v.Set(reflect.AppendSlice(v.Slice(0, i), v.Slice(i+1, v.Len())))
+			return nil
+		}
+		return unsetConfigValue(v.Index(i), reflect.Value{}, path[1:])
+	}
+
+	field, err := configChild(v, path[0])
+	if err != nil {
+		return err
+	}
+	if len(path) > 1 {
+		return unsetConfigValue(field, defChild, path[1:])
+	}
+	if defChild.IsValid() {
+		field.Set(defChild)
+	} else {
+		field.Set(reflect.Zero(field.Type()))
+	}
+	return nil
+}
+
+// flattenConfig calls fn with the dotted key and value of every scalar
+// below v, in field order and sorted map order
+func flattenConfig(key string, v reflect.Value, fn func(key string, v reflect.Value)) {
+	join := func(name string) string {
+		if key == "" {
+			return name
+		}
+		return key + "." + name
+	}
+
+	switch v.Kind() {
+	case reflect.Pointer:
+		if !v.IsNil() {
+			flattenConfig(key, v.Elem(), fn)
+		}
+	case reflect.Struct:
+		for i := 0; i < v.NumField(); i++ {
+			flattenConfig(join(configFieldName(v.Type().Field(i))), v.Field(i), fn)
+		}
+	case reflect.Map:
+		keys := v.MapKeys()
+		names := make([]string, len(keys))
+		for i, k := range keys {
+			names[i] = fmt.Sprint(k.Interface())
+		}
+		slices.Sort(names)
+		for _, name := range names {
+			flattenConfig(join(name), v.MapIndex(reflect.ValueOf(name).Convert(v.Type().Key())), fn)
+		}
+	case reflect.Slice:
+		// Lists of scalars such as attributes are one value
+		if v.Type().Elem().Kind() != reflect.Struct {
+			fn(key, v)
+			return
+		}
+		for i := 0; i < v.Len(); i++ {
+			flattenConfig(join(strconv.Itoa(i)), v.Index(i), fn)
+		}
+	default:
+		fn(key, v)
+	}
+}
+
+// formatConfigValue renders a scalar config value, masking passwords that
+// are not keyring references unless reveal is set
+func formatConfigValue(key string, v reflect.Value, reveal bool) string {
+	s := fmt.Sprint(v.Interface())
+	switch x := v.Interface().(type) {
+	case time.Duration:
+		s = x.String()
+	case []string:
+		s = strings.Join(x, ",")
+	}
+
+	name := key[strings.LastIndex(key, ".")+1:]
+	if strings.EqualFold(name, "password") && s != "" && !reveal && !connect.IsKeyringPassword(s) {
+		return output.Redacted
+	}
+	return s
+}
@@ -0,0 +1,70 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// configTestCmd represents the config test command
+var configTestCmd = &cobra.Command{
+	Use:   "test",
+	Short: "Validate the configuration and test the connection",
+	Long: "Test validates the configuration, then connects to the server, binds, reads the " +
+		"RootDSE and checks that the base DN exists. It stops at the first stage that fails " +
+		"and prints a diagnosis for it. Flags and --profile apply as for any other command.",
+	Example: `  adgo config test
+  adgo config test --profile clienta
+  adgo config test -s dc01.corp.local -S ldaps`,
+	Args: cobra.NoArgs,
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConfigTest(cmd)
+	},
+}
+
+// runConfigTest prints one line per stage and returns the error of the
+// stage that failed
+func runConfigTest(cmd *cobra.Command) error {
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "STAGE\tDETAIL\tTIME\tSTATUS")
+
+	detail := GetConfigPath()
+	if detail == "" {
+		detail = "no config file"
+	}
+	if profile := ActiveProfile(); profile != "" {
+		detail += " (profile " + profile + ")"
+	}
+	if err := Validate(); err != nil {
+		fmt.Fprintf(w, "config\t%s\t-\tfailed\n", detail)
+		w.Flush()
+		return fmt.Errorf("config stage failed: %w", err)
+	}
+	fmt.Fprintf(w, "config\t%s\t-\tok\n", detail)
+
+	ldapCfg := GetConfig().LDAP
+	var failed *connect.StageResult
+	results := connect.TestConnection(&ldapCfg)
+	for i, r := range results {
+		status := "ok"
+		if r.Err != nil {
+			status = "failed"
+			failed = &results[i]
+		}
+		fmt.Fprintf(w, "%s\t%s\t%s\t%s\n", r.Stage, r.Detail, r.Duration.Round(time.Millisecond), status)
+	}
+	w.Flush()
+
+	if failed != nil {
+		return fmt.Errorf("%s stage failed: %w", failed.Stage, failed.Err)
+	}
+	fmt.Fprintln(cmd.OutOrStdout(), "\nConnection OK")
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(configTestCmd)
+}
@@ -0,0 +1,84 @@
+package cmd
+
+import (
+	"adgo/log"
+	"adgo/output"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// convertCmd represents the convert command
+var convertCmd = &cobra.Command{
+	Use:   "convert",
+	Short: "Re-export saved results in another output format, without connecting",
+	Long: "Convert reads entries saved with -o raw (JSON Lines with unformatted values) or -o ldif, " +
+		"optionally gzip or zstd compressed, and prints them in any other output format. " +
+		"Binary attributes are preserved, so security descriptors, SIDs and GUIDs are " +
+		"analyzed as if the results came from the domain controller. --where, --fields, " +
+		"--sort-by and the other output flags apply as for a query.",
+	Example: `  adgo quick users -o raw --out users.jsonl
+  adgo convert --in users.jsonl --format bloodhound --out users.json
+  adgo convert --in export.ldif.gz --format csv --where 'adminCount==1'`,
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runConvert(cmd)
+	},
+}
+
+// runConvert reads saved entries and prints them in the requested format
+func runConvert(cmd *cobra.Command) error {
+	in, _ := cmd.Flags().GetString("in")
+	format, _ := cmd.Flags().GetString("format")
+	if format == "" {
+		format, _ = cmd.Flags().GetString("output")
+	}
+	if format == "" {
+		format = GetConfig().Output
+	}
+	if err := ValidateOutputFormat(format); err != nil {
+		return err
+	}
+
+	var r io.Reader = cmd.InOrStdin()
+	if in != "-" {
+		f, err := os.Open(in)
+		if err != nil {
+			return fmt.Errorf("opening input: %w", err)
+		}
+		defer f.Close()
+		r = f
+	}
+	entries, err := output.ReadEntries(r)
+	if err != nil {
+		return fmt.Errorf("reading %s: %w", in, err)
+	}
+	log.Infof("Read %d entries from %s", len(entries), in)
+
+	pc, err := printerConfig(cmd, format)
+	if err != nil {
+		return err
+	}
+	printer, err := output.NewPrinter(pc)
+	if err != nil {
+		return fmt.Errorf("creating printer: %v", err)
+	}
+	if err := printer.Print(entries); err != nil {
+		return fmt.Errorf("printing results: %v", err)
+	}
+
+	if pc.Path != "" {
+		log.Infof("Output file generated: %s", pc.Path)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(convertCmd)
+
+	convertCmd.Flags().String("in", "", "File saved with -o raw or -o ldif, or - for stdin")
+	convertCmd.Flags().String("format", "", "Output format to convert to (default: --output)")
+	convertCmd.MarkFlagRequired("in")
+}
@@ -0,0 +1,163 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/log"
+	"encoding/csv"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// Credential validation results that are not AuthFailure categories
+const (
+	credResultValid   = "valid"
+	credResultSkipped = "skipped"
+	credResultError   = "error"
+)
+
+// credential holds a candidate username/password pair
+type credential struct {
+	Username string
+	Password string
+}
+
+// validateCredsCmd represents the validate-creds command
+var validateCredsCmd = &cobra.Command{
+	Use:   "validate-creds",
+	Short: "Validate credentials with a bind (no searches)",
+	Long: "Validate-creds attempts an LDAP bind for each candidate credential and classifies the result " +
+		"(valid, invalid, locked, expired, disabled, restricted) without performing any searches. " +
+		"Each candidate costs one logon attempt against the account lockout counter.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		file, err := cmd.Flags().GetString("file")
+		if err != nil {
+			return err
+		}
+		delay, err := cmd.Flags().GetDuration("delay")
+		if err != nil {
+			return err
+		}
+
+		cfg := GetConfig()
+		if err := ValidateServer(cfg.LDAP.Server); err != nil {
+			return err
+		}
+
+		// Use the candidates file if given, otherwise the configured credentials
+		creds := []credential{{Username: cfg.LDAP.Username, Password: cfg.LDAP.Password}}
+		if file != "" {
+			creds, err = loadCredentialsCSV(file)
+			if err != nil {
+				return err
+			}
+		}
+		if len(creds) == 0 {
+			log.Warn("No candidate credentials to validate")
+			return nil
+		}
+
+		out := cmd.OutOrStdout()
+		fmt.Fprintf(out, "%-40s %-12s %s\n", "USERNAME", "RESULT", "DETAIL")
+
+		valid := 0
+		for i, c := range creds {
+			if i > 0 && delay > 0 {
+				time.Sleep(delay)
+			}
+
+			result, detail := validateCredential(cfg.LDAP, c)
+			if result == credResultValid || result == connect.AuthFailureExpired.String() {
+				valid++
+			}
+			fmt.Fprintf(out, "%-40s %-12s %s\n", c.Username, result, detail)
+		}
+
+		log.Infof("%d of %d credential(s) have a correct password", valid, len(creds))
+		return nil
+	},
+}
+
+// validateCredential binds once with the candidate credential and returns the
+// result category along with a short detail message.
+func validateCredential(ldapCfg connect.Config, c credential) (string, string) {
+	// An empty password turns a simple bind into an unauthenticated bind,
+	// which AD accepts and would be misreported as valid
+	if c.Password == "" {
+		return credResultSkipped, "empty password (would be an unauthenticated bind)"
+	}
+
+	ldapCfg.Username = c.Username
+	ldapCfg.Password = c.Password
+
+	err := connect.Authenticate(&ldapCfg)
+	if err == nil {
+		return credResultValid, ""
+	}
+
+	switch failure := connect.ClassifyAuthError(err); failure {
+	case connect.AuthFailureNone:
+		return credResultError, err.Error()
+	case connect.AuthFailureExpired:
+		return failure.String(), "password is correct but expired or must be changed"
+	case connect.AuthFailureLocked:
+		return failure.String(), "account is locked out"
+	case connect.AuthFailureDisabled:
+		return failure.String(), "account is disabled"
+	case connect.AuthFailureRestricted:
+		return failure.String(), "logon hours or workstation restriction"
+	default:
+		return failure.String(), ""
+	}
+}
+
+// loadCredentialsCSV reads candidate credentials from a CSV file with
+// username,password records. A leading "username,password" header is skipped.
+func loadCredentialsCSV(path string) ([]credential, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening credentials file: %w", err)
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.FieldsPerRecord = -1
+	r.TrimLeadingSpace = true
+
+	var creds []credential
+	for record := 1; ; record++ {
+		fields, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("reading credentials file: %w", err)
+		}
+
+		if record == 1 && strings.EqualFold(strings.TrimSpace(fields[0]), "username") {
+			continue
+		}
+		if len(fields) < 2 {
+			log.Warnf("Skipping record %d: expected username,password", record)
+			continue
+		}
+
+		creds = append(creds, credential{
+			Username: strings.TrimSpace(fields[0]),
+			Password: fields[1],
+		})
+	}
+
+	return creds, nil
+}
+
+func init() {
+	rootCmd.AddCommand(validateCredsCmd)
+
+	validateCredsCmd.Flags().StringP("file", "f", "", "CSV file of candidate credentials (username,password)")
+	validateCredsCmd.Flags().Duration("delay", 0, "Delay between bind attempts (e.g., 2s) to stay under lockout thresholds")
+}
@@ -0,0 +1,270 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// delegationCmd represents the delegation command
+var delegationCmd = &cobra.Command{
+	Use:   "delegation",
+	Short: "Report every delegation relationship in one table",
+	Long: "Delegation combines unconstrained, constrained and resource-based constrained " +
+		"delegation into a single table of who can impersonate users to what. Constrained " +
+		"delegation SPNs are resolved to the accounts that own them and RBCD trustee SIDs to " +
+		"account names. Unconstrained delegation hosts other than DCs that have an SPN are " +
+		"marked \"coerce here\": coercing a DC to authenticate to them leaves its TGT. " +
+		"--by-target inverts the table into one row per target service (or " +
+		"RBCD target account) with the accounts that can delegate to it, which shows which " +
+		"critical hosts are exposed, such as CIFS/DC01. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDelegation(cmd)
+	},
+}
+
+// delegationFilter matches accounts with any kind of delegation configured
+var delegationFilter = fmt.Sprintf("(|(%s:%s:=%d)(%s=*)(%s=*))",
+	analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_TRUSTED_FOR_DELEGATION,
+	analyze.AttrMSDSAllowedToDelegateTo,
+	analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity,
+)
+
+// runDelegation collects, resolves and prints the delegation relationships
+func runDelegation(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	byTarget, _ := cmd.Flags().GetBool("by-target")
+	var write func(io.Writer, []analyze.Delegation) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeDelegationTable
+		if byTarget {
+			write = writeDelegationTargetsTable
+		}
+	case analyze.OutputFormatJSON:
+		write = writeDelegationJSON
+		if byTarget {
+			write = writeDelegationTargetsJSON
+		}
+	case analyze.OutputFormatCSV:
+		write = writeDelegationCSV
+		if byTarget {
+			write = writeDelegationTargetsCSV
+		}
+	default:
+		return fmt.Errorf("delegation output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	delegations, err := collectDelegations(cmd.Context(), ldapClient)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, delegations) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Delegation report generated: %s (%d relationships)", path, len(delegations))
+	return nil
+}
+
+// collectDelegations searches for delegation settings and resolves RBCD
+// trustees and constrained delegation targets to account names
+func collectDelegations(ctx context.Context, client connect.Client) ([]analyze.Delegation, error) {
+	entries, err := client.Search(ctx, delegationFilter, analyze.DelegationAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching delegation settings: %w", err)
+	}
+
+	var delegations []analyze.Delegation
+	var trustees []string
+	for _, e := range entries {
+		for _, d := range analyze.Delegations(e) {
+			if d.Kind == analyze.DelegationResourceBased {
+				trustees = append(trustees, d.SourceSID)
+			}
+			delegations = append(delegations, d)
+		}
+	}
+
+	resolver := newNameResolver(client)
+	if err := resolver.resolveSIDs(ctx, trustees); err != nil {
+		log.Warnf("Resolving RBCD trustees: %v", err)
+	}
+	for i := range delegations {
+		d := &delegations[i]
+		switch d.Kind {
+		case analyze.DelegationResourceBased:
+			d.Source = resolver.sidName(d.SourceSID)
+		case analyze.DelegationConstrained, analyze.DelegationProtocolTransition:
+			target, err := resolver.spnAccount(ctx, d.Service)
+			if err != nil {
+				log.Warnf("Resolving %s: %v", d.Service, err)
+			}
+			d.Target = target
+		}
+	}
+
+	sort.SliceStable(delegations, func(i, j int) bool {
+		if delegations[i].Source != delegations[j].Source {
+			return delegations[i].Source < delegations[j].Source
+		}
+		return delegations[i].Kind < delegations[j].Kind
+	})
+	return delegations, nil
+}
+
+// delegationSource formats the source column, marking domain controllers
+// and hosts to coerce DC authentication to
+func delegationSource(d analyze.Delegation) string {
+	switch {
+	case d.DC:
+		return d.Source + " (DC)"
+	case d.CoerceHere:
+		return d.Source + " (coerce here)"
+	}
+	return d.Source
+}
+
+// delegationTarget formats the target column
+func delegationTarget(d analyze.Delegation) string {
+	switch {
+	case d.Kind == analyze.DelegationUnconstrained:
+		return "any service"
+	case d.Target == "":
+		return "(unresolved)"
+	default:
+		return d.Target
+	}
+}
+
+// writeDelegationTable writes the delegations as an aligned table
+func writeDelegationTable(w io.Writer, delegations []analyze.Delegation) error {
+	if len(delegations) == 0 {
+		_, err := fmt.Fprintln(w, "No delegation configured")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SOURCE\tTYPE\tTARGET\tSERVICE")
+	coerceHere := 0
+	for _, d := range delegations {
+		service := d.Service
+		if service == "" {
+			service = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", delegationSource(d), d.Kind, delegationTarget(d), service)
+		if d.CoerceHere {
+			coerceHere++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d delegation relationships\n", len(delegations))
+	if coerceHere > 0 {
+		fmt.Fprintf(w, "%d hosts to coerce DC authentication to; the coercion command lists coercible computers\n", coerceHere)
+	}
+	return nil
+}
+
+// writeDelegationJSON writes the delegations as an indented JSON array
+func writeDelegationJSON(w io.Writer, delegations []analyze.Delegation) error {
+	if delegations == nil {
+		delegations = []analyze.Delegation{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(delegations)
+}
+
+// writeDelegationCSV writes the delegations as CSV with a header row
+func writeDelegationCSV(w io.Writer, delegations []analyze.Delegation) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"source", "sourceSid", "kind", "target", "service", "dc", "coerceHere"})
+	for _, d := range delegations {
+		cw.Write([]string{d.Source, d.SourceSID, d.Kind, d.Target, d.Service, fmt.Sprint(d.DC), fmt.Sprint(d.CoerceHere)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// writeDelegationTargetsTable writes one row per delegation target, with
+// the accounts that can delegate to it
+func writeDelegationTargetsTable(w io.Writer, delegations []analyze.Delegation) error {
+	targets := analyze.DelegationTargets(delegations)
+	if len(targets) == 0 {
+		_, err := fmt.Fprintln(w, "No constrained or resource-based delegation configured")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "TARGET\tSERVICE\tTYPE\tPROTOCOL TRANSITION\tSOURCES")
+	for _, t := range targets {
+		target, service := t.Target, t.Service
+		if target == "" {
+			target = "(unresolved)"
+		}
+		if service == "" {
+			service = "-"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%t\t%s\n", target, service, t.Kind, t.ProtocolTransition, strings.Join(t.Sources, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d delegation targets\n", len(targets))
+	return err
+}
+
+// writeDelegationTargetsJSON writes the delegation targets as an indented
+// JSON array
+func writeDelegationTargetsJSON(w io.Writer, delegations []analyze.Delegation) error {
+	targets := analyze.DelegationTargets(delegations)
+	if targets == nil {
+		targets = []analyze.DelegationTarget{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(targets)
+}
+
+// writeDelegationTargetsCSV writes one row per delegation target, with a
+// header row
+func writeDelegationTargetsCSV(w io.Writer, delegations []analyze.Delegation) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"target", "service", "kind", "protocolTransition", "sources"})
+	for _, t := range analyze.DelegationTargets(delegations) {
+		cw.Write([]string{t.Target, t.Service, t.Kind, fmt.Sprint(t.ProtocolTransition), strings.Join(t.Sources, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(delegationCmd)
+
+	delegationCmd.Flags().Bool("by-target", false, "Group by target service, listing the accounts that can delegate to each")
+}
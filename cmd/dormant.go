@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// dormantCmd represents the dormant command
+var dormantCmd = &cobra.Command{
+	Use:   "dormant",
+	Short: "Report privileged accounts that never logged on or are dormant",
+	Long: "Dormant runs the privilegedUsers query, user accounts with adminCount=1 or nested " +
+		"in Domain/Enterprise/Schema Admins, Administrators or Account/Backup/Server Operators, " +
+		"and reports those whose lastLogonTimestamp is absent or older than --days. Nobody " +
+		"notices when such an account is taken over, which makes them ideal targets and a " +
+		"common audit finding. lastLogonTimestamp can lag the real last logon by up to 14 " +
+		"days, so --days should stay well above that. Enabled accounts are listed first. " +
+		"Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runDormant(cmd)
+	},
+}
+
+// runDormant collects and prints the dormant privileged account report
+func runDormant(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.DormantAccount, int) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeDormantTable
+	case analyze.OutputFormatJSON:
+		write = writeDormantJSON
+	case analyze.OutputFormatCSV:
+		write = writeDormantCSV
+	default:
+		return fmt.Errorf("dormant output must be text, table, json or csv")
+	}
+	days, _ := cmd.Flags().GetInt("days")
+	if days <= 0 {
+		return fmt.Errorf("--days must be positive")
+	}
+	enabledOnly, _ := cmd.Flags().GetBool("enabled")
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	q, _ := queries.Get("privilegedUsers")
+	q = queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, cfg.LDAP.BaseDN).Build()
+	entries, err := ldapClient.Search(cmd.Context(), q.Filter, q.Attributes)
+	if err != nil {
+		return fmt.Errorf("searching privileged accounts: %w", err)
+	}
+	accounts := analyze.DormantPrivileged(entries, days, time.Now())
+	if enabledOnly {
+		enabled := accounts[:0]
+		for _, a := range accounts {
+			if a.Enabled {
+				enabled = append(enabled, a)
+			}
+		}
+		accounts = enabled
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, accounts, days) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Dormant privileged account report generated: %s (%d accounts)", path, len(accounts))
+	return nil
+}
+
+// dormantTime formats a time of an account, or "never" if it is zero
+func dormantTime(t time.Time) string {
+	if t.IsZero() {
+		return "never"
+	}
+	return analyze.FormatTime(t)
+}
+
+// writeDormantTable writes the accounts as an aligned table
+func writeDormantTable(w io.Writer, accounts []analyze.DormantAccount, days int) error {
+	if len(accounts) == 0 {
+		_, err := fmt.Fprintf(w, "No privileged accounts without a logon in %d days\n", days)
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT\tENABLED\tADMINCOUNT\tLAST LOGON\tDAYS INACTIVE\tPWD LAST SET\tGROUPS")
+	enabled, never := 0, 0
+	for _, a := range accounts {
+		fmt.Fprintf(tw, "%s\t%t\t%t\t%s\t%d\t%s\t%s\n", a.Name, a.Enabled, a.AdminCount, dormantTime(a.LastLogon),
+			a.DaysInactive, dormantTime(a.PwdLastSet), strings.Join(a.Groups, ", "))
+		if a.Enabled {
+			enabled++
+		}
+		if a.NeverLoggedOn {
+			never++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d privileged accounts without a logon in %d days (%d never logged on), %d enabled\n",
+		len(accounts), days, never, enabled)
+	return err
+}
+
+// writeDormantJSON writes the accounts as an indented JSON array
+func writeDormantJSON(w io.Writer, accounts []analyze.DormantAccount, _ int) error {
+	if accounts == nil {
+		accounts = []analyze.DormantAccount{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(accounts)
+}
+
+// writeDormantCSV writes one row per account, with a header row
+func writeDormantCSV(w io.Writer, accounts []analyze.DormantAccount, _ int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "dn", "enabled", "adminCount", "neverLoggedOn", "lastLogon", "daysInactive", "pwdLastSet", "groups"})
+	for _, a := range accounts {
+		var lastLogon, pwdLastSet string
+		if !a.LastLogon.IsZero() {
+			lastLogon = analyze.FormatTime(a.LastLogon)
+		}
+		if !a.PwdLastSet.IsZero() {
+			pwdLastSet = analyze.FormatTime(a.PwdLastSet)
+		}
+		cw.Write([]string{a.Name, a.DN, strconv.FormatBool(a.Enabled), strconv.FormatBool(a.AdminCount),
+			strconv.FormatBool(a.NeverLoggedOn), lastLogon, strconv.Itoa(a.DaysInactive), pwdLastSet, strings.Join(a.Groups, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(dormantCmd)
+
+	dormantCmd.Flags().Int("days", analyze.DefaultDormantDays, "Days without a logon after which a privileged account is dormant")
+	dormantCmd.Flags().Bool("enabled", false, "Only report enabled accounts")
+}
@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// tokenGroup is one group SID in the token of an account
+type tokenGroup struct {
+	Name      string `json:"name"`
+	SID       string `json:"sid"`
+	Privilege string `json:"privilege,omitempty"` // What the group confers, for known privileged groups
+}
+
+// effectiveGroupsCmd represents the effective-groups command
+var effectiveGroupsCmd = &cobra.Command{
+	Use:   "effective-groups <account>",
+	Short: "List the groups in an account's token from its tokenGroups attribute",
+	Long: "Effective-groups reads the constructed tokenGroups attribute of an account with a " +
+		"base-scope search on its object: the DC computes the SIDs of every security group " +
+		"in the account's token, nested and primary groups included, in one call. Each SID " +
+		"is resolved to a name and groups that confer known privileges are flagged. Unlike " +
+		"memberof, distribution groups are not listed and universal groups of other domains " +
+		"only appear when the DC is a global catalog. The account may be given as any " +
+		"identifier accepted by the object command. Supports text/table and json output.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runEffectiveGroups(cmd, args[0])
+	},
+}
+
+// runEffectiveGroups reads, resolves and prints the token groups of account
+func runEffectiveGroups(cmd *cobra.Command, account string) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, string, []tokenGroup) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeTokenGroupsTable
+	case analyze.OutputFormatJSON:
+		write = writeTokenGroupsJSON
+	default:
+		return fmt.Errorf("effective-groups output must be text, table or json")
+	}
+	privilegedOnly, _ := cmd.Flags().GetBool("privileged")
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	entry, err := findObject(ctx, ldapClient, account, []string{analyze.AttrSAMAccountName})
+	if err != nil {
+		return err
+	}
+
+	groups, err := collectTokenGroups(ctx, ldapClient, entry.DN)
+	if err != nil {
+		return err
+	}
+	if privilegedOnly {
+		var privileged []tokenGroup
+		for _, g := range groups {
+			if g.Privilege != "" {
+				privileged = append(privileged, g)
+			}
+		}
+		groups = privileged
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, entryName(entry), groups) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Token groups written: %s (%d groups)", path, len(groups))
+	return nil
+}
+
+// collectTokenGroups reads the tokenGroups of the object at dn, which is
+// only returned for base-scope searches, and resolves the SIDs
+func collectTokenGroups(ctx context.Context, client connect.Client, dn string) ([]tokenGroup, error) {
+	entries, err := client.Search(connect.WithBaseObject(ctx, dn), "(objectClass=*)", []string{analyze.AttrTokenGroups})
+	if err != nil {
+		return nil, fmt.Errorf("reading tokenGroups of %s: %w", dn, err)
+	}
+	if len(entries) == 0 {
+		return nil, fmt.Errorf("no object found at %s", dn)
+	}
+
+	var sids []string
+	for _, raw := range entries[0].GetEqualFoldRawAttributeValues(analyze.AttrTokenGroups) {
+		if sid, err := analyze.ParseObjectSID(raw); err == nil {
+			sids = append(sids, sid)
+		}
+	}
+	resolver := newNameResolver(client)
+	if err := resolver.resolveSIDs(ctx, sids); err != nil {
+		log.Warnf("Resolving token groups: %v", err)
+	}
+
+	groups := make([]tokenGroup, 0, len(sids))
+	for _, sid := range sids {
+		name := resolver.sidName(sid)
+		groups = append(groups, tokenGroup{Name: name, SID: sid, Privilege: analyze.GroupPrivilege(sid, name)})
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	return groups, nil
+}
+
+// writeTokenGroupsTable writes the token groups as an aligned table
+func writeTokenGroupsTable(w io.Writer, account string, groups []tokenGroup) error {
+	privileged := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tSID\tPRIVILEGE")
+	for _, g := range groups {
+		privilege := g.Privilege
+		if privilege == "" {
+			privilege = "-"
+		} else {
+			privileged++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", g.Name, g.SID, privilege)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s has %d groups in its token, %d privileged\n", account, len(groups), privileged)
+	return err
+}
+
+// writeTokenGroupsJSON writes the token groups as indented JSON
+func writeTokenGroupsJSON(w io.Writer, account string, groups []tokenGroup) error {
+	if groups == nil {
+		groups = []tokenGroup{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Account string       `json:"account"`
+		Groups  []tokenGroup `json:"groups"`
+	}{account, groups})
+}
+
+func init() {
+	rootCmd.AddCommand(effectiveGroupsCmd)
+
+	effectiveGroupsCmd.Flags().Bool("privileged", false, "Only list groups that confer known privileges")
+}
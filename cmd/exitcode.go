@@ -0,0 +1,65 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"errors"
+)
+
+// Exit codes of adgo, so wrappers and CI checks can branch on the failure
+const (
+	ExitOK         = 0 // Success
+	ExitUsage      = 1 // Invalid flags, arguments or configuration, and any other error
+	ExitConnection = 2 // The LDAP server could not be reached or TLS failed
+	ExitAuth       = 3 // The bind was rejected
+	ExitSearch     = 4 // A search failed before returning any entries
+	ExitPartial    = 5 // A search failed after some entries were written
+)
+
+// exitError sets the exit code of an error that cannot be told from its cause
+type exitError struct {
+	code int
+	err  error
+}
+
+func (e *exitError) Error() string {
+	return e.err.Error()
+}
+
+func (e *exitError) Unwrap() error {
+	return e.err
+}
+
+// withExitCode returns err with the exit code code
+func withExitCode(code int, err error) error {
+	if err == nil {
+		return nil
+	}
+	return &exitError{code: code, err: err}
+}
+
+// ExitCode returns the process exit code for an error returned by Execute.
+// Errors are classified by an explicit exit code, then by the LDAP
+// operation that failed; everything else is a usage error.
+func ExitCode(err error) int {
+	if err == nil {
+		return ExitOK
+	}
+
+	var exitErr *exitError
+	if errors.As(err, &exitErr) {
+		return exitErr.code
+	}
+
+	var ldapErr *connect.LDAPError
+	if errors.As(err, &ldapErr) {
+		switch ldapErr.Operation {
+		case "connect":
+			return ExitConnection
+		case "bind":
+			return ExitAuth
+		case "search", "query":
+			return ExitSearch
+		}
+	}
+	return ExitUsage
+}
@@ -0,0 +1,128 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// annotationDryRun marks commands that honor --dry-run. Other commands
+// that connect refuse to run with it rather than silently ignore it.
+const annotationDryRun = "dryRun"
+
+// checkDryRun fails if --dry-run is given to a command that would still connect
+func checkDryRun(cmd *cobra.Command) error {
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if !dryRun || cmd.Annotations[annotationDryRun] != "" || cmd.Annotations[annotationOffline] != "" {
+		return nil
+	}
+	return fmt.Errorf("--dry-run is not supported by %s", cmd.CommandPath())
+}
+
+// explainCmd represents the quick explain command
+var explainCmd = &cobra.Command{
+	Use:   "explain <query>",
+	Short: "Show the search request a quick query sends, without connecting",
+	Long: "Explain prints the exact filter, attributes, base DN, scope and controls a quick query " +
+		"would send, without connecting, for OPSEC review or to reuse the filter in other tools. " +
+		"Required parameters without a value are shown as {name} placeholders, and queries of the " +
+		"configuration or schema partition search it under --forest-dn, the base DN by default. " +
+		"Equivalent to running the query with --dry-run.",
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runExplain(cmd, args[0])
+	},
+}
+
+// runExplain prints the search plan of a quick query
+func runExplain(cmd *cobra.Command, name string) error {
+	queryName := name
+	if sub, _, err := quickCmd.Find([]string{name}); err == nil && sub.Annotations["query"] != "" {
+		queryName = sub.Annotations["query"]
+	}
+	q, ok := queries.Get(queryName)
+	if !ok {
+		return fmt.Errorf("query '%s' not found", name)
+	}
+
+	profile, err := attrProfile(cmd)
+	if err != nil {
+		return err
+	}
+	cfg := GetConfig()
+	b := queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, cfg.LDAP.BaseDN).WithBaseDN(cfg.LDAP.BaseDN).WithAttrProfile(profile)
+	built := b.Build()
+	if extra, _ := cmd.Flags().GetString("filter-and"); extra != "" {
+		if err := ValidateFilter(extra); err != nil {
+			return fmt.Errorf("invalid --filter-and: %w", err)
+		}
+		built.Filter = "(&" + built.Filter + extra + ")"
+	}
+	if missing := b.MissingParams(); len(missing) > 0 {
+		log.Warnf("Parameters without a value: --%s", strings.Join(missing, ", --"))
+	}
+
+	ctx, attributes := queryRequest(cmd.Context(), cmd, built.Attributes)
+	if q.Partition != "" {
+		base, _ := partitionBase(ctx, cmd, nil, q.Partition)
+		ctx = connect.WithSearchBase(ctx, base)
+	}
+	return printSearchPlan(cmd, connect.PlanSearch(ctx, &cfg.LDAP, built.Filter, attributes))
+}
+
+// printSearchPlan writes a search plan as text, or as JSON with -o json
+func printSearchPlan(cmd *cobra.Command, plan connect.SearchPlan) error {
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = GetConfig().Output
+	}
+	if format == analyze.OutputFormatJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Keep filters copyable
+		return enc.Encode(plan)
+	}
+
+	attributes := strings.Join(plan.Attributes, ", ")
+	if attributes == "" {
+		attributes = "(all)"
+	}
+	sizeLimit := "unlimited"
+	if plan.SizeLimit > 0 {
+		sizeLimit = fmt.Sprint(plan.SizeLimit)
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "Base DN:\t%s\n", valueOrNotSet(plan.BaseDN))
+	fmt.Fprintf(w, "Scope:\t%s\n", plan.Scope)
+	fmt.Fprintf(w, "Aliases:\t%s\n", plan.DerefAliases)
+	fmt.Fprintf(w, "Filter:\t%s\n", plan.Filter)
+	fmt.Fprintf(w, "Attributes:\t%s\n", attributes)
+	fmt.Fprintf(w, "Size limit:\t%s\n", sizeLimit)
+	fmt.Fprintf(w, "Controls:\t\n")
+	for _, c := range plan.Controls {
+		detail := c.Value
+		if c.Condition != "" {
+			detail += ", " + c.Condition
+		}
+		if c.Critical {
+			detail += ", critical"
+		}
+		fmt.Fprintf(w, "  %s\t%s (%s)\n", c.OID, c.Name, detail)
+	}
+	return w.Flush()
+}
+
+func init() {
+	quickCmd.AddCommand(explainCmd)
+
+	rootCmd.PersistentFlags().Bool("dry-run", false, "Print the search request that would be sent instead of connecting")
+}
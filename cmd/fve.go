@@ -0,0 +1,171 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// fveCmd represents the fve command
+var fveCmd = &cobra.Command{
+	Use:   "fve",
+	Short: "Report BitLocker recovery keys escrowed in AD and who can read them",
+	Long: "Fve runs the fve query for msFVE-RecoveryInformation objects, the BitLocker " +
+		"recovery information computers escrow under their computer object, and counts them per " +
+		"computer. msFVE-RecoveryPassword is confidential: the report shows whether the bind " +
+		"account could read it and which principals the security descriptors let read it (full " +
+		"control or control access). Recovery passwords themselves are not printed; use the fve " +
+		"query for them. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runFVE(cmd)
+	},
+}
+
+// runFVE collects, resolves and prints the recovery key report
+func runFVE(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.FVEComputer) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeFVETable
+	case analyze.OutputFormatJSON:
+		write = writeFVEJSON
+	case analyze.OutputFormatCSV:
+		write = writeFVECSV
+	default:
+		return fmt.Errorf("fve output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	computers, err := collectFVE(cmd.Context(), ldapClient)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, computers) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("BitLocker recovery report generated: %s (%d computers)", path, len(computers))
+	return nil
+}
+
+// collectFVE searches the recovery objects, groups them by computer and
+// resolves the principals able to read them
+func collectFVE(ctx context.Context, client connect.Client) ([]analyze.FVEComputer, error) {
+	q, _ := queries.Get("fve")
+	entries, err := client.Search(ctx, q.Filter, analyze.FVERecoveryAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching BitLocker recovery information: %w", err)
+	}
+	computers := analyze.FVEComputers(entries)
+
+	var sids []string
+	for _, c := range computers {
+		sids = append(sids, c.ReaderSIDs...)
+	}
+	resolver := newNameResolver(client)
+	if err := resolver.resolveSIDs(ctx, sids); err != nil {
+		log.Warnf("Resolving recovery key readers: %v", err)
+	}
+	for i := range computers {
+		for _, sid := range computers[i].ReaderSIDs {
+			computers[i].Readers = append(computers[i].Readers, resolver.sidName(sid))
+		}
+	}
+	return computers, nil
+}
+
+// writeFVETable writes the computers as an aligned table, followed by the
+// principals able to read recovery keys and on how many computers
+func writeFVETable(w io.Writer, computers []analyze.FVEComputer) error {
+	if len(computers) == 0 {
+		_, err := fmt.Fprintln(w, "No BitLocker recovery information found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "COMPUTER\tKEYS\tLAST ESCROWED\tREADABLE")
+	keys, readable := 0, 0
+	readers := make(map[string]int)
+	var order []string
+	for _, c := range computers {
+		fmt.Fprintf(tw, "%s\t%d\t%s\t%t\n", c.Computer, c.Keys, analyze.FormatTime(c.LastEscrowed), c.Readable)
+		keys += c.Keys
+		if c.Readable {
+			readable++
+		}
+		for _, r := range c.Readers {
+			if readers[r] == 0 {
+				order = append(order, r)
+			}
+			readers[r]++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(order) > 0 {
+		fmt.Fprintln(w, "\nPrincipals able to read recovery keys:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, r := range order {
+			fmt.Fprintf(tw, "  %s\t%d computers\n", r, readers[r])
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+	_, err := fmt.Fprintf(w, "\n%d recovery keys on %d computers (%d readable by the bind account)\n", keys, len(computers), readable)
+	return err
+}
+
+// writeFVEJSON writes the computers as an indented JSON array
+func writeFVEJSON(w io.Writer, computers []analyze.FVEComputer) error {
+	if computers == nil {
+		computers = []analyze.FVEComputer{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(computers)
+}
+
+// writeFVECSV writes one row per computer, with a header row
+func writeFVECSV(w io.Writer, computers []analyze.FVEComputer) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"computer", "dn", "keys", "lastEscrowed", "readable", "readers", "readerSids"})
+	for _, c := range computers {
+		cw.Write([]string{c.Computer, c.DN, strconv.Itoa(c.Keys), analyze.FormatTime(c.LastEscrowed),
+			strconv.FormatBool(c.Readable), strings.Join(c.Readers, ";"), strings.Join(c.ReaderSIDs, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(fveCmd)
+}
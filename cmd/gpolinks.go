@@ -0,0 +1,253 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// gpoLinksCmd represents the gpolinks command
+var gpoLinksCmd = &cobra.Command{
+	Use:   "gpolinks",
+	Short: "Show where GPOs are linked and which GPOs apply to each OU",
+	Long: "Gpolinks reads gPLink and gPOptions on the domain, its OUs and the sites of the " +
+		"forest, and resolves each linked GPO to its displayName. For every container it lists " +
+		"the links in link order, disabled and enforced links, and whether the container blocks " +
+		"inheritance. --applied lists instead the GPOs that apply to the objects of the domain " +
+		"and of each of these OUs, in precedence order, following enforced links through " +
+		"blocked inheritance. Sites are searched under CN=Sites,CN=Configuration of " +
+		"--forest-dn, the base DN by default. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGPOLinks(cmd)
+	},
+}
+
+// runGPOLinks collects and prints the GPO links
+func runGPOLinks(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	applied, _ := cmd.Flags().GetBool("applied")
+	var write func(io.Writer, []analyze.GPOScope, bool) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeGPOLinksTable
+	case analyze.OutputFormatJSON:
+		write = writeGPOLinksJSON
+	case analyze.OutputFormatCSV:
+		write = writeGPOLinksCSV
+	default:
+		return fmt.Errorf("gpolinks output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	names, err := gpoNames(ctx, ldapClient)
+	if err != nil {
+		return err
+	}
+	q, _ := queries.Get("gpolinks")
+	containers, err := ldapClient.Search(ctx, q.Filter, analyze.GPOLinkAttributes)
+	if err != nil {
+		return fmt.Errorf("searching GPO links: %w", err)
+	}
+	scopes := analyze.GPOScopes(containers, names)
+
+	forestDN, _ := cmd.Flags().GetString("forest-dn")
+	if forestDN == "" {
+		forestDN = cfg.LDAP.BaseDN
+	}
+	if sites, err := collectSiteGPOLinks(ctx, cfg.LDAP, forestDN, names); err != nil {
+		log.Warnf("Reading site GPO links: %v", err)
+	} else {
+		scopes = append(sites, scopes...)
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, scopes, applied) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("GPO link report generated: %s (%d containers)", path, len(scopes))
+	return nil
+}
+
+// collectSiteGPOLinks searches the sites of the forest for GPO links,
+// resolving the linked GPOs through names. Links to GPOs of other domains
+// are shown by GUID.
+func collectSiteGPOLinks(ctx context.Context, base connect.Config, forestDN string, names map[string]string) ([]analyze.GPOScope, error) {
+	sitesDN := "CN=Sites," + queries.PartitionDN(queries.PartitionConfiguration, forestDN)
+	client, err := newClientAt(base, sitesDN)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	q, _ := queries.Get("gpolinks")
+	sites, err := client.Search(ctx, fmt.Sprintf("(&(objectClass=site)%s)", q.Filter), analyze.GPOLinkAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching %s: %w", sitesDN, err)
+	}
+	return analyze.GPOScopes(sites, names), nil
+}
+
+// gpoNames maps the uppercase GUIDs of the GPOs of the domain to their
+// displayNames
+func gpoNames(ctx context.Context, client connect.Client) (map[string]string, error) {
+	q, _ := queries.Get("gpo")
+	gpos, err := client.Search(ctx, q.Filter, []string{analyze.AttrName, analyze.AttrDisplayName})
+	if err != nil {
+		return nil, fmt.Errorf("searching GPOs: %w", err)
+	}
+	names := make(map[string]string, len(gpos))
+	for _, e := range gpos {
+		names[strings.ToUpper(e.GetEqualFoldAttributeValue(analyze.AttrName))] = e.GetEqualFoldAttributeValue(analyze.AttrDisplayName)
+	}
+	return names, nil
+}
+
+// gpoLinkFlags describes the state of a link
+func gpoLinkFlags(l analyze.GPOLink) string {
+	var flags []string
+	if l.Enforced {
+		flags = append(flags, "enforced")
+	}
+	if !l.Enabled {
+		flags = append(flags, "disabled")
+	}
+	return strings.Join(flags, ", ")
+}
+
+// gpoScopeLinks returns the links of a scope, or if applied is set the
+// links that apply to it. Sites have no applied links.
+func gpoScopeLinks(scopes []analyze.GPOScope, s analyze.GPOScope, applied bool) []analyze.GPOLink {
+	if !applied {
+		return s.Links
+	}
+	if s.Type == analyze.GPOScopeSite {
+		return nil
+	}
+	return analyze.AppliedGPOs(scopes, s)
+}
+
+// writeGPOLinksTable writes one block per container listing its links, or
+// the GPOs that apply to it
+func writeGPOLinksTable(w io.Writer, scopes []analyze.GPOScope, applied bool) error {
+	if len(scopes) == 0 {
+		_, err := fmt.Fprintln(w, "No GPO links found")
+		return err
+	}
+
+	links, containers := 0, 0
+	for _, s := range scopes {
+		if applied && s.Type == analyze.GPOScopeSite {
+			continue
+		}
+		containers++
+		header := fmt.Sprintf("%s (%s)", s.DN, s.Type)
+		if s.BlockInheritance {
+			header += " [blocks inheritance]"
+		}
+		fmt.Fprintln(w, header)
+
+		scopeLinks := gpoScopeLinks(scopes, s, applied)
+		if len(scopeLinks) == 0 {
+			fmt.Fprintln(w, "  (no GPOs)")
+		}
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for i, l := range scopeLinks {
+			if applied {
+				from := ""
+				if !strings.EqualFold(l.Scope, s.DN) {
+					from = "inherited from " + l.Scope
+				}
+				fmt.Fprintf(tw, "  %d\t%s\t%s\t%s\n", i+1, l.GPO, gpoLinkFlags(l), from)
+			} else {
+				fmt.Fprintf(tw, "  %d\t%s\t%s\n", l.Order, l.GPO, gpoLinkFlags(l))
+			}
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+		links += len(scopeLinks)
+	}
+
+	if applied {
+		_, err := fmt.Fprintf(w, "%d GPOs applied across %d containers\n", links, containers)
+		return err
+	}
+	_, err := fmt.Fprintf(w, "%d GPO links on %d containers\n", links, containers)
+	return err
+}
+
+// writeGPOLinksJSON writes the containers as an indented JSON array, with
+// the GPOs that apply to each in place of its links if applied is set
+func writeGPOLinksJSON(w io.Writer, scopes []analyze.GPOScope, applied bool) error {
+	result := make([]analyze.GPOScope, 0, len(scopes))
+	for _, s := range scopes {
+		if applied {
+			if s.Type == analyze.GPOScopeSite {
+				continue
+			}
+			s.Links = gpoScopeLinks(scopes, s, true)
+		}
+		if s.Links == nil {
+			s.Links = []analyze.GPOLink{}
+		}
+		result = append(result, s)
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(result)
+}
+
+// writeGPOLinksCSV writes one row per link, or per applied GPO, with a
+// header row. The link's own container is in linkedAt.
+func writeGPOLinksCSV(w io.Writer, scopes []analyze.GPOScope, applied bool) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"scope", "scopeType", "blockInheritance", "order", "gpo", "guid", "enabled", "enforced", "linkedAt"})
+	for _, s := range scopes {
+		if applied && s.Type == analyze.GPOScopeSite {
+			continue
+		}
+		for i, l := range gpoScopeLinks(scopes, s, applied) {
+			order := l.Order
+			if applied {
+				order = i + 1
+			}
+			cw.Write([]string{s.DN, s.Type, strconv.FormatBool(s.BlockInheritance), strconv.Itoa(order),
+				l.GPO, l.GUID, strconv.FormatBool(l.Enabled), strconv.FormatBool(l.Enforced), l.Scope})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(gpoLinksCmd)
+
+	gpoLinksCmd.Flags().Bool("applied", false, "List the GPOs that apply to each domain and OU instead of their links")
+	gpoLinksCmd.Flags().String("forest-dn", "", "Forest root DN whose sites are searched (default: the base DN)")
+}
@@ -0,0 +1,188 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/gpp"
+	"adgo/log"
+	"adgo/output"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// gppCmd represents the gpp command
+var gppCmd = &cobra.Command{
+	Use:   "gpp",
+	Short: "Recover Group Policy Preferences passwords (cpassword) from SYSVOL",
+	Long: "Gpp runs the gpo query and reads the gPCFileSysPath folder of each GPO for " +
+		"Group Policy Preferences files (Groups.xml, Services.xml, ScheduledTasks.xml, " +
+		"DataSources.xml, Drives.xml and Printers.xml). Their cpassword values are encrypted " +
+		"with a published key (MS14-025) and are decrypted. On Windows SYSVOL is read over SMB " +
+		"with the credentials of the logon session (use runas /netonly or net use for others); " +
+		"elsewhere mount the SYSVOL share and pass its mount point with --sysvol. --redact masks " +
+		"the passwords. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runGPP(cmd)
+	},
+}
+
+// gppCredential is a GPP password and the GPO it was found in
+type gppCredential struct {
+	GPO  string `json:"gpo"`
+	GUID string `json:"guid"`
+	gpp.Credential
+}
+
+// runGPP collects and prints the GPP passwords
+func runGPP(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []gppCredential) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeGPPTable
+	case analyze.OutputFormatJSON:
+		write = writeGPPJSON
+	case analyze.OutputFormatCSV:
+		write = writeGPPCSV
+	default:
+		return fmt.Errorf("gpp output must be text, table, json or csv")
+	}
+
+	sysvol, _ := cmd.Flags().GetString("sysvol")
+	if sysvol == "" && runtime.GOOS != "windows" {
+		return fmt.Errorf("reading SYSVOL over SMB needs Windows; mount the SYSVOL share and pass its mount point with --sysvol")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	creds, err := collectGPP(cmd.Context(), ldapClient, sysvol)
+	if err != nil {
+		return err
+	}
+	if redact, _ := cmd.Flags().GetBool("redact"); redact {
+		for i := range creds {
+			creds[i].Password, creds[i].CPassword = output.Redacted, output.Redacted
+		}
+	}
+
+	// Decrypted passwords; keep the file private
+	path, err := writeReport(cmd, format, 0600, func(w io.Writer) error { return write(w, creds) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("GPP password report generated: %s (%d passwords)", path, len(creds))
+	return nil
+}
+
+// collectGPP scans the folder of each GPO for preference passwords. GPOs
+// whose folder cannot be read are logged and skipped.
+func collectGPP(ctx context.Context, client connect.Client, sysvol string) ([]gppCredential, error) {
+	q, _ := queries.Get("gpo")
+	gpos, err := client.Search(ctx, q.Filter, []string{analyze.AttrName, analyze.AttrDisplayName, analyze.AttrGPCFileSysPath})
+	if err != nil {
+		return nil, fmt.Errorf("searching GPOs: %w", err)
+	}
+
+	var result []gppCredential
+	for _, e := range gpos {
+		path := e.GetEqualFoldAttributeValue(analyze.AttrGPCFileSysPath)
+		if path == "" {
+			continue
+		}
+		if sysvol != "" {
+			path = sysvolPath(sysvol, path)
+		}
+		name := e.GetEqualFoldAttributeValue(analyze.AttrDisplayName)
+		log.Debugf("Scanning %s (%s)", path, name)
+
+		creds, err := gpp.Scan(path)
+		if err != nil {
+			log.Warnf("Reading GPO %s: %v", name, err)
+		}
+		for _, c := range creds {
+			if c.Password == "" {
+				log.Warnf("Decrypting cpassword of %s in GPO %s failed", c.Name, name)
+			}
+			result = append(result, gppCredential{GPO: name, GUID: e.GetEqualFoldAttributeValue(analyze.AttrName), Credential: c})
+		}
+	}
+	return result, nil
+}
+
+// sysvolPath maps a gPCFileSysPath (\\server\SysVol\domain\Policies\{GUID})
+// to the same folder under root, where the SYSVOL share is mounted
+func sysvolPath(root, unc string) string {
+	parts := strings.FieldsFunc(unc, func(r rune) bool { return r == '\\' || r == '/' })
+	// Drop the server and share
+	if len(parts) > 2 {
+		parts = parts[2:]
+	}
+	return filepath.Join(append([]string{root}, parts...)...)
+}
+
+// writeGPPTable writes the passwords as an aligned table
+func writeGPPTable(w io.Writer, creds []gppCredential) error {
+	if len(creds) == 0 {
+		_, err := fmt.Fprintln(w, "No GPP passwords found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GPO\tFILE\tTYPE\tUSER\tPASSWORD\tCHANGED")
+	for _, c := range creds {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\t%s\t%s\n", c.GPO, c.File, c.Type, c.UserName, c.Password, c.Changed)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d GPP passwords\n", len(creds))
+	return err
+}
+
+// writeGPPJSON writes the passwords as an indented JSON array
+func writeGPPJSON(w io.Writer, creds []gppCredential) error {
+	if creds == nil {
+		creds = []gppCredential{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(creds)
+}
+
+// writeGPPCSV writes the passwords as CSV with a header row
+func writeGPPCSV(w io.Writer, creds []gppCredential) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"gpo", "guid", "file", "type", "name", "userName", "password", "cpassword", "changed"})
+	for _, c := range creds {
+		cw.Write([]string{c.GPO, c.GUID, c.File, c.Type, c.Name, c.UserName, c.Password, c.CPassword, c.Changed})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(gppCmd)
+
+	gppCmd.Flags().String("sysvol", "", "Local mount point of the SYSVOL share, instead of reading gPCFileSysPath over SMB")
+}
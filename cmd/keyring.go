@@ -0,0 +1,72 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"bufio"
+	"fmt"
+	"os"
+
+	"github.com/spf13/cobra"
+)
+
+// setPasswordCmd represents the config set-password command
+var setPasswordCmd = &cobra.Command{
+	Use:   "set-password",
+	Short: "Store the bind password in the OS keyring",
+	Long: "Set-password prompts for the bind password, stores it in the OS keyring (Windows " +
+		"Credential Manager, macOS Keychain or the Secret Service via secret-tool) and sets " +
+		"the password in adgo.yaml to \"keyring:\", so the file never contains the cleartext " +
+		"password. With --profile the password of that profile is set.",
+	Example: `  adgo config set-password
+  adgo config set-password --profile clienta
+  adgo config set-password --account lab-admin`,
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSetPassword(cmd)
+	},
+}
+
+// runSetPassword stores the prompted password and references it from the config
+func runSetPassword(cmd *cobra.Command) error {
+	cfg := GetConfig()
+
+	// The keyring account defaults to the username, which "keyring:" refers to
+	account, _ := cmd.Flags().GetString("account")
+	ref := connect.KeyringPrefix + account
+	if account == "" {
+		account = cfg.LDAP.Username
+	}
+	if account == "" {
+		return fmt.Errorf("no username configured; set ldap.username or use --account")
+	}
+
+	password := prompt(bufio.NewScanner(os.Stdin), fmt.Sprintf("Password for %s: ", account), nil, true)
+	if password == "" {
+		return fmt.Errorf("no password entered")
+	}
+	if err := connect.KeyringSet(account, password); err != nil {
+		return err
+	}
+
+	key := analyze.ConfigLDAPPassword
+	if profile := ActiveProfile(); profile != "" {
+		key = "profiles." + profile + ".password"
+	}
+	if err := SetConfig(key, ref); err != nil {
+		return fmt.Errorf("setting %s: %w", key, err)
+	}
+	if err := SaveConfig(); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
+	}
+	log.Infof("Password for %s stored in the OS keyring; %s = %s", account, key, ref)
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(setPasswordCmd)
+
+	setPasswordCmd.Flags().String("account", "", "Keyring account to store the password under (default: the username)")
+}
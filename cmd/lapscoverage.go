@@ -0,0 +1,138 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// lapsCoverageCmd represents the laps-coverage command
+var lapsCoverageCmd = &cobra.Command{
+	Use:   "laps-coverage",
+	Short: "List enabled computers not managed by LAPS, grouped by OU",
+	Long: "Laps-coverage runs the computers query with the legacy and Windows LAPS password " +
+		"expiration attributes, which authenticated users can read, and lists the enabled " +
+		"member computers that have neither, grouped by their OU. Their local administrator " +
+		"passwords are not rotated and are likely shared between hosts. OUs with the most " +
+		"unmanaged computers come first; fully covered OUs are left out. Domain controllers " +
+		"are skipped. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLAPSCoverage(cmd)
+	},
+}
+
+// runLAPSCoverage collects and prints the LAPS coverage report
+func runLAPSCoverage(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.LAPSGap, int) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeLAPSCoverageTable
+	case analyze.OutputFormatJSON:
+		write = writeLAPSCoverageJSON
+	case analyze.OutputFormatCSV:
+		write = writeLAPSCoverageCSV
+	default:
+		return fmt.Errorf("laps-coverage output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	q, _ := queries.Get("computers")
+	entries, err := ldapClient.Search(cmd.Context(), q.Filter, analyze.LAPSAttributes)
+	if err != nil {
+		return fmt.Errorf("searching computers: %w", err)
+	}
+	gaps, total := analyze.LAPSCoverage(entries)
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, gaps, total) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("LAPS coverage report generated: %s (%d OUs with unmanaged computers)", path, len(gaps))
+	return nil
+}
+
+// lapsUnmanaged counts the unmanaged computers of all OUs
+func lapsUnmanaged(gaps []analyze.LAPSGap) int {
+	n := 0
+	for _, g := range gaps {
+		n += len(g.Unmanaged)
+	}
+	return n
+}
+
+// writeLAPSCoverageTable writes each OU with its unmanaged computers
+func writeLAPSCoverageTable(w io.Writer, gaps []analyze.LAPSGap, total int) error {
+	if len(gaps) == 0 {
+		_, err := fmt.Fprintf(w, "All %d enabled member computers are managed by LAPS\n", total)
+		return err
+	}
+
+	for _, g := range gaps {
+		fmt.Fprintf(w, "%s (%d of %d without LAPS)\n", g.OU, len(g.Unmanaged), g.Computers)
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, c := range g.Unmanaged {
+			system := c.OperatingSystem
+			if system == "" {
+				system = "-"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\n", c.Name, system)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+	unmanaged := lapsUnmanaged(gaps)
+	_, err := fmt.Fprintf(w, "%d of %d enabled member computers without LAPS (%.0f%% covered) in %d OUs\n",
+		unmanaged, total, 100*float64(total-unmanaged)/float64(total), len(gaps))
+	return err
+}
+
+// writeLAPSCoverageJSON writes the OUs as an indented JSON array
+func writeLAPSCoverageJSON(w io.Writer, gaps []analyze.LAPSGap, _ int) error {
+	if gaps == nil {
+		gaps = []analyze.LAPSGap{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(gaps)
+}
+
+// writeLAPSCoverageCSV writes one row per unmanaged computer, with a
+// header row
+func writeLAPSCoverageCSV(w io.Writer, gaps []analyze.LAPSGap, _ int) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"ou", "name", "dn", "operatingSystem"})
+	for _, g := range gaps {
+		for _, c := range g.Unmanaged {
+			cw.Write([]string{g.OU, c.Name, c.DN, c.OperatingSystem})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(lapsCoverageCmd)
+}
@@ -0,0 +1,79 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+// setupLogging applies the log level, format and file from the flags and
+// the log section of the config
+func setupLogging(cmd *cobra.Command) error {
+	c := GetConfig().Log
+
+	level, err := logLevel(cmd, c.Level)
+	if err != nil {
+		return err
+	}
+	if err := log.SetLevel(level); err != nil {
+		return err
+	}
+
+	format := c.Format
+	if cmd.Flags().Changed("log-format") {
+		format, _ = cmd.Flags().GetString("log-format")
+	}
+	if format != "" {
+		if err := log.SetFormat(format); err != nil {
+			return err
+		}
+	}
+
+	file := c.File
+	if cmd.Flags().Changed("log-file") {
+		file, _ = cmd.Flags().GetString("log-file")
+	}
+	if file != "" {
+		if err := log.SetFile(file, c.MaxSize, c.MaxBackups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// logLevel returns the level set by --log-level, --verbose or --quiet, or
+// configured otherwise
+func logLevel(cmd *cobra.Command, configured string) (string, error) {
+	verbose, _ := cmd.Flags().GetBool("verbose")
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	switch {
+	case verbose && quiet:
+		return "", fmt.Errorf("--verbose and --quiet are mutually exclusive")
+	case cmd.Flags().Changed("log-level"):
+		return cmd.Flags().GetString("log-level")
+	case verbose:
+		return "debug", nil
+	case quiet:
+		return "warn", nil
+	case configured != "":
+		return configured, nil
+	}
+	return analyze.DefaultLogLevel, nil
+}
+
+// quietMode reports whether --quiet was given: informational output such as
+// the text report header, summary and progress is left out
+func quietMode(cmd *cobra.Command) bool {
+	quiet, _ := cmd.Flags().GetBool("quiet")
+	return quiet
+}
+
+func init() {
+	rootCmd.PersistentFlags().BoolP("verbose", "v", false, "Log everything, including debug messages")
+	rootCmd.PersistentFlags().BoolP("quiet", "q", false, "Log only warnings and errors, and omit report headers, summaries and progress")
+	rootCmd.PersistentFlags().String("log-level", "", "Log level: debug, info, warn or error (default from config, debug)")
+	rootCmd.PersistentFlags().String("log-format", "", "Log format: console or json (default from config, console)")
+	rootCmd.PersistentFlags().String("log-file", "", "Also write logs to this file, rotated at log.maxSize megabytes")
+}
@@ -0,0 +1,249 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// lookupKind describes how one identifier type is searched and matched
+type lookupKind struct {
+	attr      string                                // Attribute searched
+	canonical func(string) (string, error)          // Normalizes user input
+	value     func(*ldap.Entry) string              // Canonical identifier of a result
+	filter    func(canonical string) string         // Filter component for one identifier
+	fallback  func(canonical string) (string, bool) // Name of identifiers not found in the directory
+}
+
+// sidLookup resolves SIDs through objectSid
+var sidLookup = lookupKind{
+	attr: analyze.AttrObjectSID,
+	canonical: func(s string) (string, error) {
+		if !analyze.IsSIDString(s) {
+			return "", fmt.Errorf("not a SID: %s", s)
+		}
+		return strings.ToUpper(s), nil
+	},
+	value: func(e *ldap.Entry) string {
+		if raw := e.GetEqualFoldRawAttributeValues(analyze.AttrObjectSID); len(raw) > 0 {
+			sid, _ := analyze.ParseObjectSID(raw[0])
+			return sid
+		}
+		return ""
+	},
+	filter: func(sid string) string {
+		return "(" + analyze.AttrObjectSID + "=" + ldap.EscapeFilter(sid) + ")"
+	},
+	fallback: func(sid string) (string, bool) {
+		name := analyze.WellKnownSIDName(sid)
+		return name, name != ""
+	},
+}
+
+// guidLookup resolves GUIDs through objectGUID
+var guidLookup = lookupKind{
+	attr: analyze.AttrObjectGUID,
+	canonical: func(s string) (string, error) {
+		b, err := analyze.EncodeObjectGUID(s)
+		if err != nil {
+			return "", err
+		}
+		return analyze.ParseObjectGUID(b)
+	},
+	value: func(e *ldap.Entry) string {
+		if raw := e.GetEqualFoldRawAttributeValues(analyze.AttrObjectGUID); len(raw) > 0 {
+			guid, _ := analyze.ParseObjectGUID(raw[0])
+			return guid
+		}
+		return ""
+	},
+	filter: func(guid string) string {
+		filter, _ := analyze.IdentifierFilter(guid)
+		return filter
+	},
+	fallback: func(string) (string, bool) { return "", false },
+}
+
+// lookupResult is the object an identifier resolved to
+type lookupResult struct {
+	ID    string `json:"id"`
+	Name  string `json:"name,omitempty"`
+	Class string `json:"class,omitempty"`
+	DN    string `json:"dn,omitempty"`
+	Found bool   `json:"found"`
+	Error string `json:"error,omitempty"`
+}
+
+// sidCmd represents the sid command
+var sidCmd = &cobra.Command{
+	Use:   "sid [S-1-5-...]...",
+	Short: "Resolve SIDs to objects",
+	Long: "Sid resolves one or more SIDs to the objects they identify. Without arguments, or with " +
+		"\"-\", SIDs are read from stdin one per line, so lists taken from ACLs, logs or BloodHound " +
+		"data can be resolved in one go. Well-known SIDs that are not directory objects are " +
+		"named as well.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLookup(cmd, args, sidLookup)
+	},
+}
+
+// guidCmd represents the guid command
+var guidCmd = &cobra.Command{
+	Use:   "guid [GUID]...",
+	Short: "Resolve object GUIDs to objects",
+	Long: "Guid resolves one or more objectGUIDs, with or without braces, to the objects they " +
+		"identify. Without arguments, or with \"-\", GUIDs are read from stdin one per line.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runLookup(cmd, args, guidLookup)
+	},
+}
+
+// runLookup resolves the identifiers given as arguments or on stdin
+func runLookup(cmd *cobra.Command, args []string, kind lookupKind) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	if format != analyze.OutputFormatText && format != analyze.OutputFormatTable && format != analyze.OutputFormatJSON {
+		return fmt.Errorf("%s output must be text, table or json", cmd.Name())
+	}
+
+	ids := args
+	if len(ids) == 0 || (len(ids) == 1 && ids[0] == "-") {
+		var err error
+		if ids, err = readIdentifiers(cmd.InOrStdin()); err != nil {
+			return err
+		}
+	}
+	if len(ids) == 0 {
+		return fmt.Errorf("no identifiers given")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	results, err := lookupIdentifiers(cmd.Context(), ldapClient, ids, kind)
+	if err != nil {
+		return err
+	}
+
+	if format == analyze.OutputFormatJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(results)
+	}
+	tw := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tCLASS\tDN")
+	for _, r := range results {
+		name, class, dn := r.Name, r.Class, r.DN
+		switch {
+		case r.Error != "":
+			name = "error: " + r.Error
+		case !r.Found && name == "":
+			name = "not found"
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", r.ID, name, orDash(class), orDash(dn))
+	}
+	return tw.Flush()
+}
+
+// readIdentifiers reads one identifier per line, skipping blank lines and # comments
+func readIdentifiers(r io.Reader) ([]string, error) {
+	var ids []string
+	scanner := bufio.NewScanner(r)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line != "" && !strings.HasPrefix(line, "#") {
+			ids = append(ids, line)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading identifiers: %w", err)
+	}
+	return ids, nil
+}
+
+// lookupIdentifiers resolves ids in batches, returning one result per id in input order
+func lookupIdentifiers(ctx context.Context, client connect.Client, ids []string, kind lookupKind) ([]lookupResult, error) {
+	results := make([]lookupResult, len(ids))
+	var pending []string
+	for i, id := range ids {
+		results[i].ID = id
+		canonical, err := kind.canonical(id)
+		if err != nil {
+			results[i].Error = err.Error()
+			continue
+		}
+		pending = append(pending, canonical)
+	}
+
+	attrs := []string{analyze.AttrSAMAccountName, analyze.AttrCN, analyze.AttrObjectClass, kind.attr}
+	found := make(map[string]*ldap.Entry)
+	for start := 0; start < len(pending); start += resolveBatchSize {
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, id := range pending[start:min(start+resolveBatchSize, len(pending))] {
+			filter.WriteString(kind.filter(id))
+		}
+		filter.WriteString(")")
+
+		entries, err := client.Search(ctx, filter.String(), attrs)
+		if err != nil {
+			return nil, fmt.Errorf("searching %s: %w", kind.attr, err)
+		}
+		for _, e := range entries {
+			found[kind.value(e)] = e
+		}
+	}
+
+	for i := range results {
+		if results[i].Error != "" {
+			continue
+		}
+		canonical, _ := kind.canonical(results[i].ID)
+		if e, ok := found[canonical]; ok {
+			results[i].Found = true
+			results[i].Name = e.GetEqualFoldAttributeValue(analyze.AttrSAMAccountName)
+			if results[i].Name == "" {
+				results[i].Name = e.GetEqualFoldAttributeValue(analyze.AttrCN)
+			}
+			if classes := e.GetEqualFoldAttributeValues(analyze.AttrObjectClass); len(classes) > 0 {
+				results[i].Class = classes[len(classes)-1]
+			}
+			results[i].DN = e.DN
+		} else if name, ok := kind.fallback(canonical); ok {
+			results[i].Name = name
+		}
+	}
+	return results, nil
+}
+
+// orDash returns s, or "-" if it is empty
+func orDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(sidCmd)
+	rootCmd.AddCommand(guidCmd)
+}
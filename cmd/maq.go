@@ -0,0 +1,189 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// maqCmd represents the maq command
+var maqCmd = &cobra.Command{
+	Use:   "maq",
+	Short: "Report the machine account quota and who has consumed it",
+	Long: "Maq reads ms-DS-MachineAccountQuota, the number of computer accounts any " +
+		"authenticated user may create, and groups the computers created through it by their " +
+		"mS-DS-CreatorSID. For each creator it shows the computers, the quota used and what is " +
+		"left: a quota above 0 makes RBCD and relay attacks feasible for every user, and " +
+		"computers created by ordinary users may be traces of earlier attacks. Supports " +
+		"text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMAQ(cmd)
+	},
+}
+
+// maqComputerFilter matches computers created through the machine account quota
+var maqComputerFilter = fmt.Sprintf("(&(objectCategory=computer)(%s=*))", analyze.AttrMSDSCreatorSID)
+
+// maqReport is the output of the maq command
+type maqReport struct {
+	Quota    int                      `json:"quota"`
+	Creators []analyze.MachineCreator `json:"creators"`
+}
+
+// runMAQ collects, resolves and prints the quota report
+func runMAQ(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, maqReport) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeMAQTable
+	case analyze.OutputFormatJSON:
+		write = writeMAQJSON
+	case analyze.OutputFormatCSV:
+		write = writeMAQCSV
+	default:
+		return fmt.Errorf("maq output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	report, err := collectMAQ(cmd.Context(), ldapClient)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, report) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Machine account quota report generated: %s (%d creators)", path, len(report.Creators))
+	return nil
+}
+
+// collectMAQ reads the quota of the domain and the computers created
+// through it, and resolves their creators to account names
+func collectMAQ(ctx context.Context, client connect.Client) (maqReport, error) {
+	quota, err := readMachineAccountQuota(ctx, client)
+	if err != nil {
+		return maqReport{}, err
+	}
+
+	computers, err := client.Search(ctx, maqComputerFilter, analyze.MachineCreatorAttributes)
+	if err != nil {
+		return maqReport{}, fmt.Errorf("searching computer creators: %w", err)
+	}
+	creators := analyze.MachineCreators(computers, quota)
+
+	sids := make([]string, len(creators))
+	for i, c := range creators {
+		sids[i] = c.CreatorSID
+	}
+	resolver := newNameResolver(client)
+	if err := resolver.resolveSIDs(ctx, sids); err != nil {
+		log.Warnf("Resolving computer creators: %v", err)
+	}
+	for i := range creators {
+		creators[i].Creator = resolver.sidName(creators[i].CreatorSID)
+	}
+	return maqReport{Quota: quota, Creators: creators}, nil
+}
+
+// readMachineAccountQuota reads ms-DS-MachineAccountQuota from the domain
+// object, assuming the default if it is not readable
+func readMachineAccountQuota(ctx context.Context, client connect.Client) (int, error) {
+	q, _ := queries.Get("machineAccountQuota")
+	domains, err := client.Search(ctx, q.Filter, q.Attributes)
+	if err != nil {
+		return 0, fmt.Errorf("searching the machine account quota: %w", err)
+	}
+	value := ""
+	if len(domains) > 0 {
+		value = domains[0].GetEqualFoldAttributeValue(analyze.AttrMSDSMachineAccountQuota)
+	}
+	if value == "" {
+		log.Warnf("%s is not readable, assuming the default of %d", analyze.AttrMSDSMachineAccountQuota, analyze.DefaultMachineAccountQuota)
+		return analyze.DefaultMachineAccountQuota, nil
+	}
+	quota, err := strconv.Atoi(value)
+	if err != nil {
+		return 0, fmt.Errorf("parsing %s: %w", analyze.AttrMSDSMachineAccountQuota, err)
+	}
+	return quota, nil
+}
+
+// writeMAQTable writes the quota and its creators as an aligned table
+func writeMAQTable(w io.Writer, r maqReport) error {
+	if r.Quota > 0 {
+		fmt.Fprintf(w, "Machine account quota: %d (every authenticated user can create %d computer accounts)\n\n", r.Quota, r.Quota)
+	} else {
+		fmt.Fprintf(w, "Machine account quota: 0 (users cannot create computer accounts)\n\n")
+	}
+	if len(r.Creators) == 0 {
+		_, err := fmt.Fprintln(w, "No computers created through the quota")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "CREATOR\tUSED\tREMAINING\tLAST CREATED\tCOMPUTERS")
+	computers := 0
+	for _, c := range r.Creators {
+		fmt.Fprintf(tw, "%s\t%d\t%d\t%s\t%s\n", c.Creator, c.Used, c.Remaining,
+			analyze.FormatTime(c.LastCreated), strings.Join(c.Computers, ", "))
+		computers += c.Used
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d computers created by %d principals through the quota\n", computers, len(r.Creators))
+	return err
+}
+
+// writeMAQJSON writes the report as indented JSON
+func writeMAQJSON(w io.Writer, r maqReport) error {
+	if r.Creators == nil {
+		r.Creators = []analyze.MachineCreator{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeMAQCSV writes one row per creator, with a header row. The quota
+// is repeated on every row so that each row stands alone.
+func writeMAQCSV(w io.Writer, r maqReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"creator", "creatorSid", "quota", "used", "remaining", "lastCreated", "computers"})
+	for _, c := range r.Creators {
+		cw.Write([]string{c.Creator, c.CreatorSID, strconv.Itoa(r.Quota), strconv.Itoa(c.Used),
+			strconv.Itoa(c.Remaining), analyze.FormatTime(c.LastCreated), strings.Join(c.Computers, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(maqCmd)
+}
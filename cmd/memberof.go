@@ -0,0 +1,221 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// Membership kinds
+const (
+	membershipDirect  = "direct"
+	membershipPrimary = "primary"
+	membershipNested  = "nested"
+)
+
+// groupMembership is one group an account belongs to
+type groupMembership struct {
+	Name       string `json:"name"`
+	SID        string `json:"sid,omitempty"`
+	DN         string `json:"dn"`
+	Membership string `json:"membership"`          // direct, primary or nested
+	Privilege  string `json:"privilege,omitempty"` // What the group confers, for known privileged groups
+}
+
+// memberofCmd represents the memberof command
+var memberofCmd = &cobra.Command{
+	Use:   "memberof <account>",
+	Short: "List all groups an account belongs to, including nested ones",
+	Long: "Memberof lists every group an account is a member of, directly, through its primary " +
+		"group or through nesting (resolved with the LDAP_MATCHING_RULE_IN_CHAIN rule), and flags " +
+		"groups that confer known privileges. The account may be given as any identifier " +
+		"accepted by the object command. Supports text/table and json output.",
+	Args: cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runMemberOf(cmd, args[0])
+	},
+}
+
+// runMemberOf resolves and prints the group memberships of account
+func runMemberOf(cmd *cobra.Command, account string) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, string, []groupMembership) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeMembershipTable
+	case analyze.OutputFormatJSON:
+		write = writeMembershipJSON
+	default:
+		return fmt.Errorf("memberof output must be text, table or json")
+	}
+	privilegedOnly, _ := cmd.Flags().GetBool("privileged")
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	entry, err := findObject(ctx, ldapClient, account, []string{
+		analyze.AttrSAMAccountName, analyze.AttrObjectSID, analyze.AttrMemberOf, analyze.AttrPrimaryGroupID,
+	})
+	if err != nil {
+		return err
+	}
+
+	groups, err := collectMemberships(ctx, ldapClient, entry)
+	if err != nil {
+		return err
+	}
+	if privilegedOnly {
+		var privileged []groupMembership
+		for _, g := range groups {
+			if g.Privilege != "" {
+				privileged = append(privileged, g)
+			}
+		}
+		groups = privileged
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, entryName(entry), groups) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Group memberships written: %s (%d groups)", path, len(groups))
+	return nil
+}
+
+// collectMemberships returns the direct, primary and nested groups of entry.
+// The primary group is not listed in member attributes, so groups it is
+// nested in are looked up separately.
+func collectMemberships(ctx context.Context, client connect.Client, entry *ldap.Entry) ([]groupMembership, error) {
+	groupAttrs := []string{analyze.AttrSAMAccountName, analyze.AttrObjectSID, analyze.AttrDistinguishedName}
+	byDN := make(map[string]*groupMembership)
+	add := func(e *ldap.Entry, membership string) {
+		key := strings.ToLower(e.DN)
+		if _, ok := byDN[key]; ok {
+			return
+		}
+		g := &groupMembership{Name: entryName(e), DN: e.DN, Membership: membership}
+		if raw := e.GetEqualFoldRawAttributeValues(analyze.AttrObjectSID); len(raw) > 0 {
+			g.SID, _ = analyze.ParseObjectSID(raw[0])
+		}
+		g.Privilege = analyze.GroupPrivilege(g.SID, g.Name)
+		byDN[key] = g
+	}
+
+	// Groups in the entry's own memberOf are direct, anything else found
+	// through the in-chain rule is nested
+	direct := make(map[string]bool)
+	for _, dn := range entry.GetEqualFoldAttributeValues(analyze.AttrMemberOf) {
+		direct[strings.ToLower(dn)] = true
+	}
+	inChain := func(dn string) string {
+		return fmt.Sprintf("(&(%s=group)(%s:%s:=%s))",
+			analyze.AttrObjectClass, analyze.AttrMember, analyze.OIDMatchRuleInChain, ldap.EscapeFilter(dn))
+	}
+
+	// The primary group is not in memberOf but confers the same rights
+	var domainSID string
+	if raw := entry.GetEqualFoldRawAttributeValues(analyze.AttrObjectSID); len(raw) > 0 {
+		sid, _ := analyze.ParseObjectSID(raw[0])
+		domainSID = analyze.DomainSID(sid)
+	}
+	if rid := entry.GetEqualFoldAttributeValue(analyze.AttrPrimaryGroupID); rid != "" && domainSID != "" {
+		primary, err := client.Search(ctx, fmt.Sprintf("(%s=%s-%s)", analyze.AttrObjectSID, domainSID, ldap.EscapeFilter(rid)), groupAttrs)
+		if err != nil {
+			return nil, fmt.Errorf("looking up primary group: %w", err)
+		}
+		for _, e := range primary {
+			add(e, membershipPrimary)
+			nested, err := client.Search(ctx, inChain(e.DN), groupAttrs)
+			if err != nil {
+				return nil, fmt.Errorf("searching primary group nesting: %w", err)
+			}
+			for _, n := range nested {
+				add(n, membershipNested)
+			}
+		}
+	}
+
+	entries, err := client.Search(ctx, inChain(entry.DN), groupAttrs)
+	if err != nil {
+		return nil, fmt.Errorf("searching group memberships: %w", err)
+	}
+	for _, e := range entries {
+		if direct[strings.ToLower(e.DN)] {
+			add(e, membershipDirect)
+		}
+	}
+	for _, e := range entries {
+		add(e, membershipNested)
+	}
+
+	groups := make([]groupMembership, 0, len(byDN))
+	for _, g := range byDN {
+		groups = append(groups, *g)
+	}
+	sort.Slice(groups, func(i, j int) bool {
+		return strings.ToLower(groups[i].Name) < strings.ToLower(groups[j].Name)
+	})
+	return groups, nil
+}
+
+// writeMembershipTable writes the memberships as an aligned table
+func writeMembershipTable(w io.Writer, account string, groups []groupMembership) error {
+	privileged := 0
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "GROUP\tMEMBERSHIP\tPRIVILEGE")
+	for _, g := range groups {
+		privilege := g.Privilege
+		if privilege == "" {
+			privilege = "-"
+		} else {
+			privileged++
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", g.Name, g.Membership, privilege)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%s is a member of %d groups, %d privileged\n", account, len(groups), privileged)
+	return err
+}
+
+// writeMembershipJSON writes the memberships as indented JSON
+func writeMembershipJSON(w io.Writer, account string, groups []groupMembership) error {
+	if groups == nil {
+		groups = []groupMembership{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(struct {
+		Account string            `json:"account"`
+		Groups  []groupMembership `json:"groups"`
+	}{account, groups})
+}
+
+func init() {
+	rootCmd.AddCommand(memberofCmd)
+
+	memberofCmd.Flags().Bool("privileged", false, "Only list groups that confer known privileges")
+}
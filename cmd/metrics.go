@@ -0,0 +1,74 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/log"
+	"adgo/metrics"
+	"context"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// ldapMetrics counts LDAP activity when --metrics-listen is given
+	ldapMetrics *metrics.LDAP
+
+	// metricsServer serves ldapMetrics
+	metricsServer *http.Server
+)
+
+// setupMetrics starts serving /metrics on the --metrics-listen address
+func setupMetrics(cmd *cobra.Command) error {
+	addr, _ := cmd.Flags().GetString("metrics-listen")
+	if addr == "" || cmd.Annotations[annotationOffline] != "" {
+		return nil
+	}
+
+	ln, err := net.Listen("tcp", addr)
+	if err != nil {
+		return fmt.Errorf("--metrics-listen: %w", err)
+	}
+
+	ldapMetrics = metrics.NewLDAP()
+	mux := http.NewServeMux()
+	mux.Handle("/metrics", ldapMetrics.Registry)
+	metricsServer = &http.Server{Handler: mux, ReadHeaderTimeout: 10 * time.Second}
+	go func() {
+		if err := metricsServer.Serve(ln); err != nil && !errors.Is(err, http.ErrServerClosed) {
+			log.Warnf("Metrics endpoint: %v", err)
+		}
+	}()
+
+	useMiddleware(ldapMetrics.Middleware())
+	connect.SetRetryHook(ldapMetrics.Retry)
+	log.Infof("Serving metrics on http://%s/metrics", ln.Addr())
+	return nil
+}
+
+// trackPool adds the pool of client, if it has one, to the pool metrics
+func trackPool(client connect.Client) {
+	if pc, ok := client.(*connect.PoolingClient); ok && ldapMetrics != nil {
+		ldapMetrics.TrackPool(pc.Pool())
+	}
+}
+
+// closeMetrics stops the metrics endpoint, if any
+func closeMetrics() {
+	if metricsServer == nil {
+		return
+	}
+	connect.SetRetryHook(nil)
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	_ = metricsServer.Shutdown(ctx)
+	metricsServer, ldapMetrics = nil, nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("metrics-listen", "", "Serve Prometheus metrics on this address (e.g., :9090) while the command runs")
+}
@@ -0,0 +1,32 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/log"
+
+	"github.com/spf13/cobra"
+)
+
+// clientMiddleware wraps every client created by newClient and
+// newPoolingClient, in order
+var clientMiddleware []connect.Middleware
+
+// useMiddleware adds mw to the clients created from now on
+func useMiddleware(mw ...connect.Middleware) {
+	clientMiddleware = append(clientMiddleware, mw...)
+}
+
+// setupMiddleware builds the client middleware from the flags
+func setupMiddleware(cmd *cobra.Command) error {
+	clientMiddleware = nil
+
+	if throttle, _ := cmd.Flags().GetDuration("throttle"); throttle > 0 {
+		useMiddleware(connect.NewThrottle(throttle))
+		log.Debugf("Throttling searches to one every %s", throttle)
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().Duration("throttle", 0, "Minimum time between the start of two searches (e.g., 500ms)")
+}
@@ -0,0 +1,41 @@
+package cmd
+
+import (
+	"adgo/analyze"
+
+	"github.com/spf13/cobra"
+)
+
+// objectCmd represents the object command
+var objectCmd = &cobra.Command{
+	Use:   "object <identifier>",
+	Short: "Look up a single object by name, DN, SID or GUID",
+	Long: "Object looks up one object by sAMAccountName, userPrincipalName, distinguished name, " +
+		"SID or GUID, building the matching filter automatically, and prints all of its " +
+		"attributes. Binary attributes such as objectSid, objectGUID and security descriptors " +
+		"are decoded by the selected output format.",
+	Example: `  adgo object jdoe
+  adgo object WS01$
+  adgo object "CN=John Doe,OU=Staff,DC=corp,DC=local"
+  adgo object S-1-5-21-3623811015-3361044348-30300820-1013
+  adgo object {6f2c1a9e-3b4d-4e5f-8a7b-9c0d1e2f3a4b} -o json`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationDryRun: "true", annotationMultiDomain: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		filter, err := analyze.IdentifierFilter(args[0])
+		if err != nil {
+			return err
+		}
+		attrs, err := cmd.Flags().GetStringSlice("attrs")
+		if err != nil {
+			return err
+		}
+		return RunQuery(cmd, filter, attrs)
+	},
+}
+
+func init() {
+	rootCmd.AddCommand(objectCmd)
+
+	objectCmd.Flags().StringSliceP("attrs", "a", []string{"*"}, "Attributes to return (default: *)")
+}
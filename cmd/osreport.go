@@ -0,0 +1,160 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// osReportCmd represents the osreport command
+var osReportCmd = &cobra.Command{
+	Use:   "osreport",
+	Short: "Inventory computers by operating system and flag end-of-life versions",
+	Long: "Osreport runs the computers query and groups the results by operatingSystem and " +
+		"operatingSystemVersion. Versions past the end of extended support (Windows Server " +
+		"2003, 2008, 2012, Windows XP, 7, 8 and 10, ...) are flagged and listed first, and " +
+		"domain controllers running them are listed separately. Supports text/table, json " +
+		"and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runOSReport(cmd)
+	},
+}
+
+// runOSReport collects and prints the operating system inventory
+func runOSReport(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.OSVersion) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeOSReportTable
+	case analyze.OutputFormatJSON:
+		write = writeOSReportJSON
+	case analyze.OutputFormatCSV:
+		write = writeOSReportCSV
+	default:
+		return fmt.Errorf("osreport output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	q, _ := queries.Get("computers")
+	computers, err := ldapClient.Search(cmd.Context(), q.Filter, analyze.OSInventoryAttributes)
+	if err != nil {
+		return fmt.Errorf("searching computers: %w", err)
+	}
+	inventory := analyze.OSInventory(computers, time.Now())
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, inventory) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Operating system report generated: %s (%d versions)", path, len(inventory))
+	return nil
+}
+
+// osName returns the operating system of a group, or a placeholder
+func osName(v analyze.OSVersion) string {
+	if v.OperatingSystem == "" {
+		return "(unknown)"
+	}
+	return v.OperatingSystem
+}
+
+// osSupport formats the end of support of a group
+func osSupport(v analyze.OSVersion) string {
+	switch {
+	case v.EndOfSupport == nil:
+		return ""
+	case v.EOL:
+		return v.EndOfSupport.Format(time.DateOnly) + " (EOL)"
+	}
+	return v.EndOfSupport.Format(time.DateOnly)
+}
+
+// writeOSReportTable writes the inventory as an aligned table, followed
+// by the domain controllers on end-of-life versions
+func writeOSReportTable(w io.Writer, inventory []analyze.OSVersion) error {
+	if len(inventory) == 0 {
+		_, err := fmt.Fprintln(w, "No computers found")
+		return err
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OPERATING SYSTEM\tVERSION\tCOMPUTERS\tDCS\tEND OF SUPPORT")
+	computers, eol := 0, 0
+	var legacyDCs []string
+	for _, v := range inventory {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", osName(v), v.Version, len(v.Computers), len(v.DCs), osSupport(v))
+		computers += len(v.Computers)
+		if v.EOL {
+			eol += len(v.Computers)
+			for _, dc := range v.DCs {
+				legacyDCs = append(legacyDCs, fmt.Sprintf("%s\t%s\t%s", dc, osName(v), v.Version))
+			}
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	fmt.Fprintf(w, "\n%d computers, %d on end-of-life versions\n", computers, eol)
+
+	if len(legacyDCs) > 0 {
+		fmt.Fprintln(w, "\nDomain controllers on end-of-life versions:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, line := range legacyDCs {
+			fmt.Fprintln(tw, "  "+line)
+		}
+		return tw.Flush()
+	}
+	return nil
+}
+
+// writeOSReportJSON writes the inventory as an indented JSON array
+func writeOSReportJSON(w io.Writer, inventory []analyze.OSVersion) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(inventory)
+}
+
+// writeOSReportCSV writes one row per operating system version, with a
+// header row
+func writeOSReportCSV(w io.Writer, inventory []analyze.OSVersion) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"operatingSystem", "version", "computers", "dcs", "endOfSupport", "eol"})
+	for _, v := range inventory {
+		var end string
+		if v.EndOfSupport != nil {
+			end = v.EndOfSupport.Format(time.DateOnly)
+		}
+		cw.Write([]string{v.OperatingSystem, v.Version, strconv.Itoa(len(v.Computers)),
+			strings.Join(v.DCs, ";"), end, strconv.FormatBool(v.EOL)})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(osReportCmd)
+}
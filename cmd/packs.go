@@ -0,0 +1,136 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+
+	"github.com/spf13/cobra"
+)
+
+// loadQueryPacks registers the queries saved in adgo.yaml and those of the
+// packs in the configured queries.dir as quick subcommands. It runs before
+// the command line is parsed, so user queries can be invoked like built-in
+// ones.
+func loadQueryPacks() {
+	// Configuration errors are reported once the command runs
+	if err := InitConfig(); err != nil {
+		return
+	}
+
+	for _, d := range GetConfig().Queries.Custom {
+		if err := queries.Add(d); err != nil {
+			log.Warnf("Loading custom query: %v", err)
+			continue
+		}
+		registerQueryCommand(d)
+	}
+
+	dir := os.ExpandEnv(GetConfig().Queries.Dir)
+	if dir == "" {
+		return
+	}
+	if !filepath.IsAbs(dir) {
+		if path := GetConfigPath(); path != "" {
+			dir = filepath.Join(filepath.Dir(path), dir)
+		}
+	}
+
+	defs, err := queries.LoadFromDir(dir)
+	if err != nil {
+		log.Warnf("Loading query packs: %v", err)
+	}
+	for _, d := range defs {
+		registerQueryCommand(d)
+	}
+}
+
+// registerQueryCommand adds the quick subcommand of a user-defined query,
+// whose description and category come from the registry
+func registerQueryCommand(d queries.Definition) {
+	addQuickSubcommand(d.Name)
+}
+
+// addQueryCmd represents the config add-query command
+var addQueryCmd = &cobra.Command{
+	Use:   "add-query NAME",
+	Short: "Save a custom query as a quick subcommand",
+	Long: "Add-query stores a query in adgo.yaml under queries.custom. It is registered as " +
+		"`adgo quick NAME` with its own help entry on every run. Adding a name that is already " +
+		"saved replaces that query.",
+	Example: `  adgo config add-query financeusers --filter "(&(objectClass=user)(department=Finance))" \
+    --attrs sAMAccountName,title --description "Finance department users"`,
+	Args:        cobra.ExactArgs(1),
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runAddQuery(cmd, args[0])
+	},
+}
+
+// runAddQuery validates the query and saves it to adgo.yaml
+func runAddQuery(cmd *cobra.Command, name string) error {
+	filter, _ := cmd.Flags().GetString("filter")
+	attrs, _ := cmd.Flags().GetStringSlice("attrs")
+	description, _ := cmd.Flags().GetString("description")
+	category, _ := cmd.Flags().GetString("category")
+
+	if err := ValidateFilter(filter); err != nil {
+		return err
+	}
+	for _, attr := range attrs {
+		if attr == "*" {
+			continue
+		}
+		if err := ValidateAttribute(attr); err != nil {
+			return fmt.Errorf("attribute %q: %w", attr, err)
+		}
+	}
+
+	def := queries.Definition{
+		Name:        name,
+		Description: description,
+		Category:    category,
+		Filter:      filter,
+		Attributes:  attrs,
+	}
+
+	// A saved query of the same name is replaced; any other registered
+	// query, built-in or from a pack, must not be shadowed
+	custom := GetConfig().Queries.Custom
+	i := slices.IndexFunc(custom, func(d queries.Definition) bool { return d.Name == name })
+	if i >= 0 {
+		custom[i] = def
+	} else {
+		if err := queries.ValidateDefinition(def); err != nil {
+			return err
+		}
+		custom = append(custom, def)
+	}
+
+	if err := SetConfig(analyze.ConfigQueriesCustom, custom); err != nil {
+		return fmt.Errorf("setting %s: %w", analyze.ConfigQueriesCustom, err)
+	}
+	if err := SaveConfig(); err != nil {
+		return fmt.Errorf("saving configuration: %w", err)
+	}
+	if i >= 0 {
+		log.Infof("Custom query updated: %s (adgo quick %s)", name, simplifyCommandName(name))
+	} else {
+		log.Infof("Custom query added: %s (adgo quick %s)", name, simplifyCommandName(name))
+	}
+	return nil
+}
+
+func init() {
+	configCmd.AddCommand(addQueryCmd)
+
+	addQueryCmd.Flags().StringP("filter", "f", "", "LDAP filter (required)")
+	addQueryCmd.Flags().StringSliceP("attrs", "a", nil, "Attributes to return (default: all)")
+	addQueryCmd.Flags().StringP("description", "d", "", "Description shown in quick help")
+	addQueryCmd.Flags().String("category", "", "Category shown in quick help (default: Custom Queries)")
+	addQueryCmd.MarkFlagRequired("filter")
+}
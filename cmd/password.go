@@ -0,0 +1,91 @@
+package cmd
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// setupPassword reads the bind password from --password-stdin or
+// --password-file, or prompts for it when a server and username are
+// configured without one, and sets it as if given with -w
+func setupPassword(cmd *cobra.Command) error {
+	fromStdin, _ := cmd.Flags().GetBool("password-stdin")
+	file, _ := cmd.Flags().GetString("password-file")
+	sources := 0
+	for _, given := range []bool{cmd.Flags().Changed("password"), fromStdin, file != ""} {
+		if given {
+			sources++
+		}
+	}
+	if sources > 1 {
+		return fmt.Errorf("--password, --password-stdin and --password-file are mutually exclusive")
+	}
+
+	var password string
+	var err error
+	switch {
+	case fromStdin:
+		password, err = readPasswordLine(cmd.InOrStdin())
+		if err != nil {
+			return fmt.Errorf("reading password from stdin: %w", err)
+		}
+		if password == "" {
+			return fmt.Errorf("no password on stdin")
+		}
+	case file != "":
+		data, err := os.ReadFile(file)
+		if err != nil {
+			return fmt.Errorf("reading password file: %w", err)
+		}
+		password = strings.TrimRight(string(data), "\r\n")
+		if password == "" {
+			return fmt.Errorf("password file %s is empty", file)
+		}
+	case needsPasswordPrompt(cmd):
+		password, err = readPassword(fmt.Sprintf("Password for %s: ", GetConfig().LDAP.Username))
+		if err != nil {
+			return fmt.Errorf("reading password: %w", err)
+		}
+		if password == "" {
+			// An empty password would turn the bind into an unauthenticated one
+			return fmt.Errorf("no password entered")
+		}
+	default:
+		return nil
+	}
+
+	if err := cmd.Flags().Set("password", password); err != nil {
+		return err
+	}
+	return Reload()
+}
+
+// readPasswordLine returns the first line of r
+func readPasswordLine(r io.Reader) (string, error) {
+	line, err := bufio.NewReader(r).ReadString('\n')
+	if err != nil && err != io.EOF {
+		return "", err
+	}
+	return strings.TrimRight(line, "\r\n"), nil
+}
+
+// needsPasswordPrompt reports whether a command will bind with a configured
+// server and username but no password, and stdin is a terminal to ask on
+func needsPasswordPrompt(cmd *cobra.Command) bool {
+	ldapCfg := GetConfig().LDAP
+	if ldapCfg.Server == "" || ldapCfg.Username == "" || ldapCfg.Password != "" {
+		return false
+	}
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
+	if cmd.Annotations[annotationOffline] != "" || dryRun || replay != nil || offlineRoast(cmd) ||
+		cmd.Name() == "help" || cmd.Name() == "version" {
+		return false
+	}
+	return term.IsTerminal(int(os.Stdin.Fd()))
+}
@@ -0,0 +1,179 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// pathsCmd represents the paths command
+var pathsCmd = &cobra.Command{
+	Use:   "paths",
+	Short: "Suggest attack chains from the bound account toward Domain Admins",
+	Long: "Paths collects the objects of the collect command (users, computers, groups, " +
+		"domains, GPOs, OUs and containers) and the machine account quota, and links them by " +
+		"group membership, abusable ACL rights, delegation, GPO links and containment. A " +
+		"breadth-first search from the bound account, or --from, and the Everyone and " +
+		"Authenticated Users groups finds the shortest chain to Domain Admins, Enterprise " +
+		"Admins, Administrators and each domain object. Every step is labelled high, medium or " +
+		"low confidence, e.g. writing RBCD is high with a machine account quota and medium " +
+		"without, and a chain is as reliable as its weakest step. The chains are candidates " +
+		"to verify, not proof: deny ACEs, protected accounts and tiering are not evaluated. " +
+		"Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runPaths(cmd)
+	},
+}
+
+// runPaths collects the graph, searches it and prints the attack paths
+func runPaths(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.AttackPath) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writePathsText
+	case analyze.OutputFormatJSON:
+		write = writePathsJSON
+	case analyze.OutputFormatCSV:
+		write = writePathsCSV
+	default:
+		return fmt.Errorf("paths output must be text, table, json or csv")
+	}
+	from, _ := cmd.Flags().GetString("from")
+	if from == "" {
+		// DOMAIN\user bind names are looked up by their account name
+		from = cfg.LDAP.Username
+		if i := strings.LastIndex(from, `\`); i >= 0 {
+			from = from[i+1:]
+		}
+	}
+	if from == "" {
+		return fmt.Errorf("no start principal: bind with a username or set --from")
+	}
+	maxPaths, _ := cmd.Flags().GetInt("max")
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	start, err := findObject(ctx, ldapClient, from, []string{analyze.AttrSAMAccountName, analyze.AttrObjectSID})
+	if err != nil {
+		return err
+	}
+	raw := start.GetEqualFoldRawAttributeValues(analyze.AttrObjectSID)
+	if len(raw) == 0 {
+		return fmt.Errorf("%s has no objectSid", entryName(start))
+	}
+	startSID, err := analyze.ParseObjectSID(raw[0])
+	if err != nil {
+		return fmt.Errorf("parsing the objectSid of %s: %w", entryName(start), err)
+	}
+
+	graph, err := collectPathGraph(ctx, ldapClient)
+	if err != nil {
+		return err
+	}
+	paths := analyze.FindAttackPaths(graph, startSID, maxPaths)
+	log.Infof("Found %d attack paths from %s", len(paths), entryName(start))
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, paths) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Attack paths report generated: %s (%d paths)", path, len(paths))
+	return nil
+}
+
+// collectPathGraph runs the collections, except trusts, with member DNs in
+// extended form and builds the path graph
+func collectPathGraph(ctx context.Context, client connect.Client) (*analyze.PathGraph, error) {
+	quota, err := readMachineAccountQuota(ctx, client)
+	if err != nil {
+		return nil, err
+	}
+
+	ctx = connect.WithExtendedDN(ctx)
+	var entries []*ldap.Entry
+	for _, c := range queries.Collections {
+		if c.Name == "trusts" {
+			continue
+		}
+		results, err := client.Search(ctx, c.Query.Filter, c.Query.Attributes)
+		if err != nil {
+			return nil, fmt.Errorf("collecting %s: %w", c.Name, err)
+		}
+		log.Infof("Collected %d %s", len(results), c.Name)
+		entries = append(entries, results...)
+	}
+	return analyze.NewPathGraph(entries, quota), nil
+}
+
+// writePathsText writes each path as a numbered list of steps
+func writePathsText(w io.Writer, paths []analyze.AttackPath) error {
+	if len(paths) == 0 {
+		_, err := fmt.Fprintln(w, "No attack paths found")
+		return err
+	}
+	for i, p := range paths {
+		fmt.Fprintf(w, "Path %d to %s (%s confidence, %d steps)\n", i+1, p.Target.Name, p.Confidence, len(p.Steps))
+		for j, s := range p.Steps {
+			fmt.Fprintf(w, "  %d. %s [%s, %s]\n", j+1, s.Description, s.Kind, s.Confidence)
+		}
+		fmt.Fprintln(w)
+	}
+	_, err := fmt.Fprintf(w, "%d attack paths\n", len(paths))
+	return err
+}
+
+// writePathsJSON writes the paths as an indented JSON array
+func writePathsJSON(w io.Writer, paths []analyze.AttackPath) error {
+	if paths == nil {
+		paths = []analyze.AttackPath{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(paths)
+}
+
+// writePathsCSV writes one row per step, with a header row
+func writePathsCSV(w io.Writer, paths []analyze.AttackPath) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"path", "target", "pathConfidence", "step", "from", "to", "kind", "confidence", "description"})
+	for i, p := range paths {
+		for j, s := range p.Steps {
+			cw.Write([]string{strconv.Itoa(i + 1), p.Target.Name, p.Confidence, strconv.Itoa(j + 1),
+				s.From.Name, s.To.Name, s.Kind, s.Confidence, s.Description})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(pathsCmd)
+
+	pathsCmd.Flags().String("from", "", "Account to start from (default: the bind username)")
+	pathsCmd.Flags().Int("max", 20, "Maximum number of paths to report, 0 for all")
+}
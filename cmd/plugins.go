@@ -0,0 +1,263 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/output"
+	"adgo/plugin"
+	"adgo/queries"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// plugins are the plugins discovered by loadPlugins
+var plugins []*plugin.Plugin
+
+// pluginsLoaded is set once loadPlugins has run
+var pluginsLoaded bool
+
+// userConfigDir returns the directory of the user config file, ~/.adgo
+func userConfigDir() string {
+	return filepath.Dir(connect.DefaultCacheDir())
+}
+
+// pluginsDir returns the plugins directory and whether plugins are enabled:
+// the --plugins-dir flag of args, or plugins.dir if plugins.enabled is set
+// in the user config file. Plugins are executables, so an adgo.yaml found
+// in the current directory, e.g. in an untrusted checkout, cannot enable
+// them or choose their directory.
+func pluginsDir(args []string) (string, bool) {
+	if dir := flagValue(args, "plugins-dir"); dir != "" {
+		return dir, true
+	}
+
+	cfg := GetConfig().Plugins
+	path := GetConfigPath()
+	if !cfg.Enabled || path == "" {
+		return "", false
+	}
+	if !sameDir(filepath.Dir(path), userConfigDir()) {
+		log.Warnf("Ignoring the plugins section of %s; enable plugins in %s or use --plugins-dir", path, filepath.Join(userConfigDir(), defaultConfigFileName))
+		return "", false
+	}
+
+	dir := os.ExpandEnv(cfg.Dir)
+	if dir == "" {
+		return filepath.Join(userConfigDir(), "plugins"), true
+	}
+	if !filepath.IsAbs(dir) {
+		dir = filepath.Join(filepath.Dir(path), dir)
+	}
+	return dir, true
+}
+
+// pluginsEnabled reports whether args or the user config enable plugins
+func pluginsEnabled(args []string) bool {
+	_, enabled := pluginsDir(args)
+	return enabled
+}
+
+// sameDir reports whether a and b name the same directory
+func sameDir(a, b string) bool {
+	a, errA := filepath.Abs(a)
+	b, errB := filepath.Abs(b)
+	return errA == nil && errB == nil && filepath.Clean(a) == filepath.Clean(b)
+}
+
+// flagValue returns the value of a --name flag in args before cobra has
+// parsed them, or "" if it is not given
+func flagValue(args []string, name string) string {
+	for i, arg := range args {
+		if arg == "--" {
+			break
+		}
+		if value, ok := strings.CutPrefix(arg, "--"+name+"="); ok {
+			return value
+		}
+		if arg == "--"+name && i+1 < len(args) {
+			return args[i+1]
+		}
+	}
+	return ""
+}
+
+// usesPlugins reports whether the command line args runs a quick query
+// or a plugins subcommand, the commands whose behaviour plugins extend
+func usesPlugins(args []string) bool {
+	cmd, _, err := rootCmd.Find(args)
+	if err != nil {
+		return false
+	}
+	for ; cmd != nil; cmd = cmd.Parent() {
+		if cmd == quickCmd || cmd == pluginsCmd {
+			return true
+		}
+	}
+	return false
+}
+
+// loadPlugins discovers the plugins, if enabled, and registers their
+// queries as quick subcommands and their attribute formatters. It runs
+// before the command line is parsed for quick and plugins commands, like
+// loadQueryPacks, and otherwise when --postprocess first needs a plugin.
+func loadPlugins() {
+	if pluginsLoaded {
+		return
+	}
+	pluginsLoaded = true
+	if err := InitConfig(); err != nil {
+		return
+	}
+	dir, enabled := pluginsDir(os.Args[1:])
+	if !enabled {
+		return
+	}
+
+	var err error
+	plugins, err = plugin.Discover(dir)
+	if err != nil {
+		log.Warnf("Loading plugins: %v", err)
+	}
+
+	for _, p := range plugins {
+		for _, d := range p.Manifest.Queries {
+			if d.Category == "" {
+				d.Category = queries.CategoryPlugin
+			}
+			if err := queries.Add(d); err != nil {
+				log.Warnf("Plugin %s: %v", p.Manifest.Name, err)
+				continue
+			}
+			registerQueryCommand(d)
+		}
+		for _, attr := range p.Manifest.Formatters {
+			analyze.RegisterFormatter(attr, p.Format)
+		}
+	}
+}
+
+// closePlugins stops the plugins that were started
+func closePlugins() {
+	for _, p := range plugins {
+		if err := p.Close(); err != nil {
+			log.Warnf("Plugin %s: %v", p.Manifest.Name, err)
+		}
+	}
+}
+
+// postProcessSteps resolves the --postprocess steps. A step is named
+// PLUGIN.STEP, or STEP alone if only one plugin provides it.
+func postProcessSteps(cmd *cobra.Command) ([]output.ProcessFunc, error) {
+	names, _ := cmd.Flags().GetStringSlice("postprocess")
+	if len(names) > 0 {
+		loadPlugins()
+	}
+
+	var steps []output.ProcessFunc
+	for _, name := range names {
+		p, step, err := findStep(name)
+		if err != nil {
+			return nil, err
+		}
+		steps = append(steps, func(e *ldap.Entry) (*ldap.Entry, error) {
+			return p.Process(step, e)
+		})
+	}
+	return steps, nil
+}
+
+// findStep returns the plugin providing a post-processing step
+func findStep(name string) (*plugin.Plugin, string, error) {
+	pluginName, step, qualified := strings.Cut(name, ".")
+	if !qualified {
+		step = name
+	}
+
+	var found []*plugin.Plugin
+	for _, p := range plugins {
+		if qualified && p.Manifest.Name != pluginName {
+			continue
+		}
+		for _, s := range p.Manifest.Steps {
+			if s == step {
+				found = append(found, p)
+			}
+		}
+	}
+
+	switch len(found) {
+	case 0:
+		return nil, "", fmt.Errorf("unknown post-processing step %q (see adgo plugins list)", name)
+	case 1:
+		return found[0], step, nil
+	default:
+		return nil, "", fmt.Errorf("post-processing step %q is provided by several plugins; use PLUGIN.%s", name, step)
+	}
+}
+
+// pluginsCmd represents the plugins command group
+var pluginsCmd = &cobra.Command{
+	Use:   "plugins",
+	Short: "Manage plugins",
+	Long: "Plugins are executables in plugins.dir (default ~/.adgo/plugins) that add queries, " +
+		"attribute formatters and post-processing steps. They only run when enabled with " +
+		"plugins.enabled in ~/.adgo/adgo.yaml or a --plugins-dir flag. For quick queries, " +
+		"--postprocess and plugins list, adgo runs \"PLUGIN describe\" to read a JSON manifest " +
+		"and \"PLUGIN serve\" to exchange JSON lines for formatting and --postprocess steps.",
+}
+
+// pluginsListCmd represents the plugins list command
+var pluginsListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List discovered plugins and what they provide",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		dir, enabled := pluginsDir(os.Args[1:])
+		if !enabled {
+			log.Infof("Plugins are disabled; set plugins.enabled in %s or use --plugins-dir", filepath.Join(userConfigDir(), defaultConfigFileName))
+			return nil
+		}
+		if len(plugins) == 0 {
+			log.Infof("No plugins in %s", dir)
+			return nil
+		}
+
+		w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+		fmt.Fprintln(w, "PLUGIN\tVERSION\tQUERIES\tFORMATTERS\tSTEPS\tPATH")
+		for _, p := range plugins {
+			m := p.Manifest
+			names := make([]string, len(m.Queries))
+			for i, q := range m.Queries {
+				names[i] = q.Name
+			}
+			fmt.Fprintf(w, "%s\t%s\t%s\t%s\t%s\t%s\n", m.Name, valueOrDash(m.Version),
+				valueOrDash(strings.Join(names, ",")), valueOrDash(strings.Join(m.Formatters, ",")),
+				valueOrDash(strings.Join(m.Steps, ",")), p.Path)
+		}
+		return w.Flush()
+	},
+}
+
+// valueOrDash returns s, or "-" if it is empty
+func valueOrDash(s string) string {
+	if s == "" {
+		return "-"
+	}
+	return s
+}
+
+func init() {
+	rootCmd.AddCommand(pluginsCmd)
+	pluginsCmd.AddCommand(pluginsListCmd)
+
+	rootCmd.PersistentFlags().String("plugins-dir", "", "Enable the plugins in this directory (default: plugins.dir if enabled in ~/.adgo/adgo.yaml)")
+	rootCmd.PersistentFlags().StringSlice("postprocess", nil, "Run entries through plugin post-processing steps (PLUGIN.STEP or STEP, comma-separated)")
+}
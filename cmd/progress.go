@@ -0,0 +1,114 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"context"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/spf13/cobra"
+	"golang.org/x/term"
+)
+
+// progressInterval is how often the progress line is redrawn
+const progressInterval = 500 * time.Millisecond
+
+// progress reports entries received, pages fetched, elapsed time and, when
+// the total is bounded by a size limit, an ETA on a single stderr line.
+type progress struct {
+	w     io.Writer
+	total int // Expected number of entries; 0 if unknown
+	start time.Time
+
+	mu      sync.Mutex
+	entries int
+	pages   int
+
+	done     chan struct{}
+	wg       sync.WaitGroup
+	stopOnce sync.Once
+}
+
+// progressEnabled reports whether progress should be shown: --progress and
+// --no-progress force it on or off. Otherwise it is shown when stderr is a
+// terminal, unless results are printed to the same terminal, where the
+// progress line would break up the output.
+func progressEnabled(cmd *cobra.Command, toStdout bool) bool {
+	if off, _ := cmd.Flags().GetBool("no-progress"); off || quietMode(cmd) {
+		return false
+	}
+	if on, _ := cmd.Flags().GetBool("progress"); on {
+		return true
+	}
+	if toStdout && term.IsTerminal(int(os.Stdout.Fd())) {
+		return false
+	}
+	return term.IsTerminal(int(os.Stderr.Fd()))
+}
+
+// startProgress starts reporting progress for searches run with the returned
+// context. Call stop when the search has finished; it may be called again.
+func startProgress(ctx context.Context, total int) (context.Context, func()) {
+	p := &progress{w: os.Stderr, total: total, start: time.Now(), done: make(chan struct{})}
+
+	p.wg.Add(1)
+	go p.run()
+
+	return connect.WithPageHook(ctx, p.page), p.stop
+}
+
+// page records a received page of entries
+func (p *progress) page(entries int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.pages++
+	p.entries += entries
+}
+
+// run redraws the progress line until stopped
+func (p *progress) run() {
+	defer p.wg.Done()
+
+	ticker := time.NewTicker(progressInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-p.done:
+			p.draw()
+			fmt.Fprintln(p.w)
+			return
+		case <-ticker.C:
+			p.draw()
+		}
+	}
+}
+
+// stop draws the final state and ends the progress line
+func (p *progress) stop() {
+	p.stopOnce.Do(func() {
+		close(p.done)
+		p.wg.Wait()
+	})
+}
+
+// draw writes the current progress over the previous line
+func (p *progress) draw() {
+	p.mu.Lock()
+	entries, pages := p.entries, p.pages
+	p.mu.Unlock()
+
+	elapsed := time.Since(p.start).Round(time.Second)
+	line := fmt.Sprintf("%d entries, %d pages, %s elapsed", entries, pages, elapsed)
+	if rate := float64(entries) / time.Since(p.start).Seconds(); rate > 0 {
+		line += fmt.Sprintf(", %.0f/s", rate)
+		if p.total > entries {
+			eta := time.Duration(float64(p.total-entries) / rate * float64(time.Second))
+			line += fmt.Sprintf(", ETA %s", eta.Round(time.Second))
+		}
+	}
+	// Clear to end of line in case the previous line was longer
+	fmt.Fprintf(p.w, "\r%s\033[K", line)
+}
@@ -0,0 +1,140 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/queries"
+	"encoding/json"
+	"fmt"
+	"slices"
+	"strings"
+
+	"github.com/spf13/cobra"
+)
+
+// queryInfo describes a registered query for queries list
+type queryInfo struct {
+	Name        string          `json:"name"`
+	Command     string          `json:"command"`
+	Category    string          `json:"category"`
+	Description string          `json:"description"`
+	Filter      string          `json:"filter"`
+	Attributes  []string        `json:"attributes"`
+	Params      []queries.Param `json:"params,omitempty"`
+}
+
+// queriesCmd represents the queries command group
+var queriesCmd = &cobra.Command{
+	Use:   "queries",
+	Short: "Inspect the query registry",
+	Long:  "Queries inspects the registered queries: built-in ones, query packs and queries saved with config add-query.",
+	Run: func(cmd *cobra.Command, args []string) {
+		cmd.Help()
+	},
+}
+
+// queriesListCmd represents the queries list command
+var queriesListCmd = &cobra.Command{
+	Use:   "list",
+	Short: "List every registered query with its filter and attributes",
+	Long: "List prints the name, quick command, category, description, filter, attributes and " +
+		"parameters of every registered query, optionally limited to one --category, as text " +
+		"or as JSON for scripting.",
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runQueriesList(cmd)
+	},
+}
+
+// runQueriesList prints the registered queries
+func runQueriesList(cmd *cobra.Command) error {
+	category, _ := cmd.Flags().GetString("category")
+	if c, ok := querySets[strings.ToLower(category)]; ok {
+		category = c
+	}
+
+	var infos []queryInfo
+	for _, name := range queries.GetNames() {
+		info := queryInfo{
+			Name:        name,
+			Command:     simplifyCommandName(name),
+			Category:    getCommandCategory(name),
+			Description: getCommandDescription(name),
+		}
+		if category != "" && !strings.EqualFold(info.Category, category) {
+			continue
+		}
+		q, _ := queries.Get(name)
+		info.Filter = q.Filter
+		info.Attributes = q.Attributes
+		info.Params = q.Params
+		infos = append(infos, info)
+	}
+	if len(infos) == 0 && category != "" {
+		return fmt.Errorf("no queries in category %q", category)
+	}
+
+	asJSON, _ := cmd.Flags().GetBool("json")
+	if format, _ := cmd.Flags().GetString("output"); format == analyze.OutputFormatJSON {
+		asJSON = true
+	}
+	if asJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Keep filters copyable
+		if infos == nil {
+			infos = []queryInfo{}
+		}
+		return enc.Encode(infos)
+	}
+
+	// Group by category in quick help order
+	slices.SortStableFunc(infos, func(a, b queryInfo) int {
+		if d := categoryIndex(a.Category) - categoryIndex(b.Category); d != 0 {
+			return d
+		}
+		return strings.Compare(a.Category, b.Category)
+	})
+	out := cmd.OutOrStdout()
+	for i, info := range infos {
+		if i == 0 || info.Category != infos[i-1].Category {
+			if i > 0 {
+				fmt.Fprintln(out)
+			}
+			fmt.Fprintf(out, "%s:\n", info.Category)
+		}
+		fmt.Fprintf(out, "\n  %s - %s\n", info.Name, info.Description)
+		fmt.Fprintf(out, "    Command:    adgo quick %s\n", info.Command)
+		fmt.Fprintf(out, "    Filter:     %s\n", info.Filter)
+		fmt.Fprintf(out, "    Attributes: %s\n", strings.Join(info.Attributes, ", "))
+		for _, p := range info.Params {
+			usage := p.Description
+			switch {
+			case p.Default != "":
+				usage += fmt.Sprintf(" (default %q)", p.Default)
+			case p.Required:
+				usage += " (required)"
+			}
+			fmt.Fprintf(out, "    Parameter:  --%s %s\n", p.Name, usage)
+		}
+	}
+	fmt.Fprintf(out, "\n%d queries\n", len(infos))
+	return nil
+}
+
+// categoryIndex orders categories as quick help does: built-in categories
+// first, then others
+func categoryIndex(category string) int {
+	order := []string{queries.CategoryBasic, queries.CategoryAdmin, queries.CategoryKerberos, queries.CategoryDelegation, queries.CategoryADCS, queries.CategoryPermissions, queries.CategoryCustom}
+	if i := slices.Index(order, category); i >= 0 {
+		return i
+	}
+	return len(order)
+}
+
+func init() {
+	rootCmd.AddCommand(queriesCmd)
+	queriesCmd.AddCommand(queriesListCmd)
+
+	queriesListCmd.Flags().String("category", "", "Only list queries of this category (e.g., Kerberos Attacks, or a collect-all set such as kerberos)")
+	queriesListCmd.Flags().Bool("json", false, "Print JSON (same as -o json)")
+}
@@ -1,27 +1,24 @@
 package cmd
 
 import (
-	"adgo/log"
-
 	"github.com/spf13/cobra"
 )
 
 // queryCmd represents the query command group
 var queryCmd = &cobra.Command{
-	Use:   "query",
-	Short: "Run a custom LDAP query (filter/attrs)",
-	Long:  "Query executes a custom LDAP filter for targeted recon and returns the requested attributes.",
-	Run: func(cmd *cobra.Command, args []string) {
+	Use:         "query",
+	Short:       "Run a custom LDAP query (filter/attrs)",
+	Long:        "Query executes a custom LDAP filter for targeted recon and returns the requested attributes.",
+	Annotations: map[string]string{annotationDryRun: "true", annotationMultiDomain: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
 		// Get flags
 		filter, err := cmd.Flags().GetString("filter")
 		if err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 		attrs, err := cmd.Flags().GetStringSlice("attrs")
 		if err != nil {
-			log.Error(err)
-			return
+			return err
 		}
 
 		// Use default filter if none provided
@@ -30,9 +27,7 @@ var queryCmd = &cobra.Command{
 		}
 
 		// Execute common LDAP query logic
-		if err := RunQuery(cmd, filter, attrs); err != nil {
-			log.Error(err)
-		}
+		return RunQuery(cmd, filter, attrs)
 	},
 }
 
@@ -41,5 +36,6 @@ func init() {
 
 	queryCmd.Flags().StringP("filter", "f", "", "LDAP filter (e.g., (objectClass=user))")
 	queryCmd.Flags().StringSliceP("attrs", "a", []string{"*"}, "Attributes to return (default: *)")
+	queryCmd.Flags().Duration("watch", 0, "Re-run the query at this interval and print only changes (e.g., 5m)")
 
 }
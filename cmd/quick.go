@@ -1,101 +1,33 @@
 package cmd
 
 import (
-	"adgo/log"
 	"adgo/queries"
 	"fmt"
 	"io"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
 
 	"github.com/spf13/cobra"
 	"github.com/spf13/pflag"
 )
 
-// Command categories
-const (
-	CategoryBasic       = "Basic Queries"
-	CategoryAdmin       = "Admin Queries"
-	CategoryKerberos    = "Kerberos Attacks"
-	CategoryDelegation  = "Delegation"
-	CategoryADCS        = "AD CS"
-	CategoryPermissions = "Permissions"
-)
-
-// CommandMetadata holds the metadata for a quick query command
-type CommandMetadata struct {
-	Name        string
-	Description string
-	Category    string
-}
-
-// commandMetadata contains metadata for all predefined quick query commands
-var commandMetadata = []CommandMetadata{
-	// Basic Queries
-	{Name: "users", Description: "All user accounts", Category: CategoryBasic},
-	{Name: "computers", Description: "All computer accounts", Category: CategoryBasic},
-	{Name: "dc", Description: "All domain controllers", Category: CategoryBasic},
-	{Name: "ou", Description: "All organizational units", Category: CategoryBasic},
-	{Name: "spn", Description: "All service principal names", Category: CategoryBasic},
-	{Name: "gpo", Description: "All group policy objects", Category: CategoryBasic},
-	{Name: "gpomachine", Description: "GPOs with machine settings", Category: CategoryBasic},
-	{Name: "gpouser", Description: "GPOs with user settings", Category: CategoryBasic},
-	{Name: "trustDomain", Description: "Trusted domains", Category: CategoryBasic},
-	{Name: "trustattributes", Description: "Trusted domain attributes", Category: CategoryBasic},
-	{Name: "machineAccountQuota", Description: "Machine account quota for the domain", Category: CategoryBasic},
-
-	// Admin Queries
-	{Name: "admin", Description: "All admin accounts and groups", Category: CategoryAdmin},
-	{Name: "domainadmins", Description: "Domain admin group members", Category: CategoryAdmin},
-	{Name: "enterprise", Description: "Enterprise related information", Category: CategoryAdmin},
-	{Name: "enterpriseadmins", Description: "Enterprise admin group members", Category: CategoryAdmin},
-	{Name: "schemaadmins", Description: "Schema admin group members", Category: CategoryAdmin},
-	{Name: "adminSDHolder", Description: "Accounts with AdminSDHolder protection", Category: CategoryAdmin},
-	{Name: "adminholders", Description: "Admin account holders", Category: CategoryAdmin},
-	{Name: "sensitivegroups", Description: "Sensitive AD groups", Category: CategoryAdmin},
-	{Name: "disabled", Description: "Disabled user accounts", Category: CategoryAdmin},
-
-	// Kerberos Attacks
-	{Name: "kerberoasting", Description: "Accounts vulnerable to Kerberoasting", Category: CategoryKerberos},
-	{Name: "asreproast", Description: "Accounts vulnerable to AS-REP roasting", Category: CategoryKerberos},
-
-	// Delegation
-	{Name: "delegate", Description: "Accounts with delegation rights", Category: CategoryDelegation},
-	{Name: "unconstraineddelegate", Description: "Accounts with unconstrained delegation", Category: CategoryDelegation},
-	{Name: "constraineddelegate", Description: "Accounts with constrained delegation", Category: CategoryDelegation},
-	{Name: "resourceconstraineddelegate", Description: "Accounts with resource constrained delegation", Category: CategoryDelegation},
-
-	// AD CS
-	{Name: "caComputer", Description: "Certificate authorities", Category: CategoryADCS},
-	{Name: "esc1", Description: "ESC1 vulnerable certificate templates", Category: CategoryADCS},
-	{Name: "esc2", Description: "ESC2 vulnerable certificate templates", Category: CategoryADCS},
-
-	// Permissions
-	{Name: "permissions", Description: "Account permissions", Category: CategoryPermissions},
-	{Name: "highpriv", Description: "High privilege accounts", Category: CategoryPermissions},
-	{Name: "group", Description: "Admin groups", Category: CategoryPermissions},
-	{Name: "groupnested", Description: "Nested groups", Category: CategoryPermissions},
-	{Name: "managedby", Description: "Objects with managedBy attribute", Category: CategoryPermissions},
-	{Name: "acl", Description: "Objects with ACLs", Category: CategoryPermissions},
-	{Name: "sidhistory", Description: "Accounts with SID history", Category: CategoryPermissions},
-}
+// categoryTools groups quick subcommands that are not queries
+const categoryTools = "Tools"
 
-// getCommandCategory returns the category for a given query name
+// getCommandCategory returns the category of a registered query
 func getCommandCategory(queryName string) string {
-	for _, meta := range commandMetadata {
-		if meta.Name == queryName {
-			return meta.Category
-		}
+	if q, ok := queries.Get(queryName); ok && q.Category != "" {
+		return q.Category
 	}
-	return CategoryBasic // Default category
+	return queries.CategoryBasic // Default category
 }
 
-// getCommandDescription returns the description for a given query name
+// getCommandDescription returns the description of a registered query
 func getCommandDescription(queryName string) string {
-	for _, meta := range commandMetadata {
-		if meta.Name == queryName {
-			return meta.Description
-		}
+	if q, ok := queries.Get(queryName); ok && q.Description != "" {
+		return q.Description
 	}
 	return fmt.Sprintf("Run query: %s", queryName) // Default description
 }
@@ -116,6 +48,10 @@ func init() {
 	// Add quick subcommands for all predefined queries
 	addQuickSubcommands()
 
+	quickCmd.PersistentFlags().Duration("watch", 0, "Re-run the query at this interval and print only changes (e.g., 5m)")
+	quickCmd.PersistentFlags().String("filter-and", "", "Additional LDAP condition ANDed onto the query filter (e.g., (department=Finance))")
+	quickCmd.PersistentFlags().String("forest-dn", "", "Forest root DN whose configuration or schema partition is searched (default: read from the server)")
+
 	// Override the help function to display categorized commands
 	quickCmd.SetHelpFunc(customQuickHelpFunc)
 }
@@ -123,26 +59,48 @@ func init() {
 // addQuickSubcommands adds all quick subcommands based on predefined queries
 func addQuickSubcommands() {
 	for _, name := range queries.GetNames() {
-		use := simplifyCommandName(name)
-		aliases := []string{name}
-
-		// Get description for this command
-		desc := getCommandDescription(name)
-
-		// Standard query command creation
-		cmd := &cobra.Command{
-			Use:     use,
-			Aliases: aliases,
-			Short:   desc,
-			Long:    desc,
-			Run: func(cmd *cobra.Command, args []string) {
-				standardQueryHandler(cmd)
-			},
-		}
-		cmd.Annotations = map[string]string{"query": name}
+		addQuickSubcommand(name)
+	}
+}
 
-		quickCmd.AddCommand(cmd)
+// addQuickSubcommand adds the quick subcommand running a registered query
+func addQuickSubcommand(name string) {
+	use := simplifyCommandName(name)
+	aliases := []string{name}
+
+	// Get description for this command
+	desc := getCommandDescription(name)
+
+	// Standard query command creation
+	cmd := &cobra.Command{
+		Use:     use,
+		Aliases: aliases,
+		Short:   desc,
+		Long:    desc,
+		RunE: func(cmd *cobra.Command, args []string) error {
+			return standardQueryHandler(cmd)
+		},
 	}
+	cmd.Annotations = map[string]string{"query": name, annotationDryRun: "true", annotationMultiDomain: "true"}
+
+	// Each query parameter becomes a flag; the domain defaults to the base DN
+	q, _ := queries.Get(name)
+	for _, p := range q.Params {
+		if cmd.Flags().Lookup(p.Name) != nil || rootCmd.PersistentFlags().Lookup(p.Name) != nil {
+			continue
+		}
+		if p.Type == queries.ParamTypeDays {
+			days, _ := strconv.Atoi(p.Default)
+			cmd.Flags().Int(p.Name, days, p.Description)
+		} else {
+			cmd.Flags().String(p.Name, p.Default, p.Description)
+		}
+		if p.Required && p.Default == "" {
+			cmd.MarkFlagRequired(p.Name)
+		}
+	}
+
+	quickCmd.AddCommand(cmd)
 }
 
 // printFlagsAligned prints flags with aligned descriptions
@@ -190,6 +148,8 @@ func customQuickHelpFunc(cmd *cobra.Command, args []string) {
 		// Get the query name from annotations
 		queryName := subcmd.Annotations["query"]
 		if queryName == "" {
+			// Tools such as explain are listed last
+			categoryCommands[categoryTools] = append(categoryCommands[categoryTools], fmt.Sprintf("  %-30s %s", subcmd.Use, subcmd.Short))
 			continue
 		}
 
@@ -204,7 +164,18 @@ func customQuickHelpFunc(cmd *cobra.Command, args []string) {
 	fmt.Fprintf(cmd.OutOrStdout(), "Available Commands:\n")
 
 	// Define category order
-	categories := []string{CategoryBasic, CategoryAdmin, CategoryKerberos, CategoryDelegation, CategoryADCS, CategoryPermissions}
+	categories := []string{queries.CategoryBasic, queries.CategoryAdmin, queries.CategoryKerberos, queries.CategoryDelegation, queries.CategoryADCS, queries.CategoryPermissions, queries.CategoryCustom}
+
+	// Categories introduced by query packs follow in name order
+	var extra []string
+	for category := range categoryCommands {
+		if !slices.Contains(categories, category) && category != categoryTools {
+			extra = append(extra, category)
+		}
+	}
+	sort.Strings(extra)
+	categories = append(categories, extra...)
+	categories = append(categories, categoryTools)
 
 	for _, category := range categories {
 		if cmds, ok := categoryCommands[category]; ok && len(cmds) > 0 {
@@ -238,6 +209,7 @@ var specialCaseNames = map[string]string{
 	"cacomputer":  "CaComputer",   // Lowercase "a" instead of "A"
 	"gpomachine":  "GpoMachine",   // Lowercase "po" instead of "PO"
 	"gpouser":     "GpoUser",      // Lowercase "po" instead of "PO"
+	"gpolinks":    "GpoLinks",     // Lowercase "po" instead of "PO"
 }
 
 // pureAcronyms are uppercase-only command names (all caps, typically 2-3 chars)
@@ -247,6 +219,8 @@ var pureAcronyms = map[string]bool{
 	"spn":     true,
 	"ou":      true,
 	"acl":     true,
+	"fve":     true,
+	"dmsa":    true,
 	"esc1":    true,
 	"esc2":    true,
 }
@@ -293,7 +267,7 @@ func isUpper(r rune) bool {
 }
 
 // standardQueryHandler handles the execution of standard LDAP queries
-func standardQueryHandler(cmd *cobra.Command) {
+func standardQueryHandler(cmd *cobra.Command) error {
 	// Get the query name from the command annotation
 	queryName := cmd.Annotations["query"]
 	if queryName == "" {
@@ -303,12 +277,55 @@ func standardQueryHandler(cmd *cobra.Command) {
 	// Get query definition
 	q, ok := queries.Get(queryName)
 	if !ok {
-		log.Errorf("query '%s' not found", queryName)
-		return
+		return fmt.Errorf("query '%s' not found", queryName)
+	}
+
+	q, err := buildQuery(cmd, q, GetConfig().LDAP.BaseDN)
+	if err != nil {
+		return err
 	}
 
 	// Execute common LDAP query logic
-	if err := RunQuery(cmd, q.Filter, q.Attributes); err != nil {
-		log.Error(err)
+	return RunQuery(cmd, q.Filter, q.Attributes)
+}
+
+// attrProfile returns the --attr-profile of cmd
+func attrProfile(cmd *cobra.Command) (string, error) {
+	profile, _ := cmd.Flags().GetString("attr-profile")
+	if !slices.Contains(queries.AttrProfiles, profile) && profile != "" {
+		return "", fmt.Errorf("--attr-profile must be %s", strings.Join(queries.AttrProfiles, ", "))
+	}
+	return profile, nil
+}
+
+// buildQuery substitutes the query parameters given as flags, selects the
+// attributes of --attr-profile and ANDs on the --filter-and condition. The
+// domain and base DN parameters are baseDN.
+func buildQuery(cmd *cobra.Command, q queries.Query, baseDN string) (queries.Query, error) {
+	profile, err := attrProfile(cmd)
+	if err != nil {
+		return queries.Query{}, err
+	}
+	b := queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, baseDN).WithBaseDN(baseDN).WithAttrProfile(profile)
+	for _, p := range q.Params {
+		flag := cmd.Flags().Lookup(p.Name)
+		if flag == nil {
+			continue
+		}
+		if flag.Changed || flag.Value.String() != "" {
+			b.WithParam(p.Name, flag.Value.String())
+		}
+	}
+	if missing := b.MissingParams(); len(missing) > 0 {
+		return queries.Query{}, fmt.Errorf("missing query parameters: --%s", strings.Join(missing, ", --"))
+	}
+	built := b.Build()
+
+	if extra, _ := cmd.Flags().GetString("filter-and"); extra != "" {
+		if err := ValidateFilter(extra); err != nil {
+			return queries.Query{}, fmt.Errorf("invalid --filter-and: %w", err)
+		}
+		built.Filter = "(&" + built.Filter + extra + ")"
 	}
+	return built, nil
 }
@@ -0,0 +1,154 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"fmt"
+
+	"github.com/spf13/cobra"
+)
+
+var (
+	// recorder records every search when --record is given
+	recorder *connect.Recorder
+
+	// replay answers every search when --replay is given
+	replay *connect.Recording
+)
+
+// setupRecording opens the --record file or loads the --replay recording.
+// A replay fills in the server and base DN of the recording when they are
+// not configured, so a recording can be replayed without a config file.
+func setupRecording(cmd *cobra.Command) error {
+	recordPath, _ := cmd.Flags().GetString("record")
+	replayPath, _ := cmd.Flags().GetString("replay")
+	switch {
+	case recordPath != "" && replayPath != "":
+		return fmt.Errorf("--record and --replay are mutually exclusive")
+	case recordPath != "":
+		r, err := connect.NewRecorder(recordPath)
+		if err != nil {
+			return err
+		}
+		recorder = r
+		log.Infof("Recording searches to %s", recordPath)
+	case replayPath != "":
+		r, err := connect.LoadRecording(replayPath)
+		if err != nil {
+			return err
+		}
+		replay = r
+
+		cfg := GetConfig()
+		if cfg.LDAP.Server == "" {
+			if err := SetConfig(analyze.ConfigLDAPServer, r.Server()); err != nil {
+				return err
+			}
+		}
+		if cfg.LDAP.BaseDN == "" {
+			if err := SetConfig(analyze.ConfigLDAPBaseDN, r.BaseDN()); err != nil {
+				return err
+			}
+		}
+		log.Infof("Replaying searches from %s", replayPath)
+	}
+	return nil
+}
+
+// closeRecording closes the --record file, if any
+func closeRecording() {
+	if recorder == nil {
+		return
+	}
+	if err := recorder.Close(); err != nil {
+		log.Warnf("Closing recording: %v", err)
+	}
+	recorder = nil
+}
+
+// newClient connects to the server of c, or answers from the --replay
+// recording. Searches are recorded with --record and served from the
+// result cache when it is enabled.
+func newClient(c *connect.Config) (connect.Client, error) {
+	return dialClient(c, resultCache)
+}
+
+// newLiveClient is newClient bypassing the result cache, for watch mode
+// where every search must reach the server to see changes
+func newLiveClient(c *connect.Config) (connect.Client, error) {
+	return dialClient(c, nil)
+}
+
+// dialClient connects to the server of c, serving searches from cache
+// when it is not nil
+func dialClient(c *connect.Config, cache *connect.Cache) (connect.Client, error) {
+	retry := GetConfig().Retry
+	return wrapClient(c, cache, func() (connect.Client, error) { return connect.NewClientWithRetry(c, retry) })
+}
+
+// newClientAt is newClient searching under baseDN, such as the
+// configuration or schema partition, instead of the configured base DN
+func newClientAt(c connect.Config, baseDN string) (connect.Client, error) {
+	c.BaseDN = baseDN
+	return newClient(&c)
+}
+
+// newPoolingClient is newClient over a connection pool
+func newPoolingClient(c *connect.Config, poolCfg connect.PoolConfig) (connect.Client, error) {
+	return wrapClient(c, resultCache, func() (connect.Client, error) {
+		client, err := connect.NewPoolingClient(c, poolCfg)
+		if err == nil {
+			trackPool(client)
+		}
+		return client, err
+	})
+}
+
+// wrapClient applies --replay, --record, cache and the client middleware
+// to the client created by dial. With a cache, dial only runs on a cache
+// miss.
+func wrapClient(c *connect.Config, cache *connect.Cache, dial func() (connect.Client, error)) (connect.Client, error) {
+	client, err := baseClient(c, cache, dial)
+	if err != nil {
+		return nil, err
+	}
+	return connect.WithMiddleware(client, clientMiddleware...), nil
+}
+
+// baseClient applies --replay, --record, --audit-trail and cache, if not
+// nil. Only searches that reach the server are audited.
+func baseClient(c *connect.Config, cache *connect.Cache, dial func() (connect.Client, error)) (connect.Client, error) {
+	if replay != nil {
+		return connect.NewReplayClient(replay, c), nil
+	}
+	if recorder != nil {
+		dialDirect := dial
+		dial = func() (connect.Client, error) {
+			client, err := dialDirect()
+			if err != nil {
+				return nil, err
+			}
+			return connect.NewRecordingClient(client, c, recorder), nil
+		}
+	}
+	if auditTrail != nil {
+		dialDirect := dial
+		dial = func() (connect.Client, error) {
+			client, err := dialDirect()
+			if err != nil {
+				return nil, err
+			}
+			return connect.WithMiddleware(client, connect.NewAuditMiddleware(auditTrail, c)), nil
+		}
+	}
+	if cache != nil {
+		return connect.NewCachingClient(cache, c, dial), nil
+	}
+	return dial()
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("record", "", "Record every search and its results to this file")
+	rootCmd.PersistentFlags().String("replay", "", "Answer searches from a --record file instead of connecting")
+}
@@ -0,0 +1,218 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// relaySurfaceCmd represents the relay-surface command
+var relaySurfaceCmd = &cobra.Command{
+	Use:   "relay-surface",
+	Short: "Summarize NTLM relay targets from the directory and connection probes",
+	Long: "Relay-surface combines three sources into one list of relay targets. The server " +
+		"is probed with NTLM binds as a relay would make them: without signing over LDAP (389) " +
+		"and without a channel binding token over LDAPS (636). The certificate authorities of " +
+		"the configuration partition of --forest-dn are read and their /certsrv/ web " +
+		"enrollment pages requested over HTTP and HTTPS; NTLM over HTTP is ESC8. Hosts with " +
+		"unconstrained delegation, DCs excepted, are listed as places to coerce DC " +
+		"authentication to. The two binds use the configured credentials and count as logons; " +
+		"--no-probe reports from the directory only. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runRelaySurface(cmd)
+	},
+}
+
+// runRelaySurface probes and collects the relay targets and prints them
+func runRelaySurface(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.RelayTarget) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeRelaySurfaceTable
+	case analyze.OutputFormatJSON:
+		write = writeRelaySurfaceJSON
+	case analyze.OutputFormatCSV:
+		write = writeRelaySurfaceCSV
+	default:
+		return fmt.Errorf("relay-surface output must be text, table, json or csv")
+	}
+	noProbe, _ := cmd.Flags().GetBool("no-probe")
+	forestDN, _ := cmd.Flags().GetString("forest-dn")
+	if forestDN == "" {
+		forestDN = cfg.LDAP.BaseDN
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	var targets []analyze.RelayTarget
+	if noProbe {
+		targets = append(targets,
+			analyze.RelayTarget{Service: analyze.RelayServiceLDAP, Host: cfg.LDAP.Server, Status: analyze.RelayUnknown, Detail: "not probed"},
+			analyze.RelayTarget{Service: analyze.RelayServiceLDAPS, Host: cfg.LDAP.Server, Status: analyze.RelayUnknown, Detail: "not probed"})
+	} else {
+		log.Infof("Probing LDAP signing and LDAPS channel binding on %s", cfg.LDAP.Server)
+		targets = append(targets,
+			ldapRelayTarget(analyze.RelayServiceLDAP, cfg.LDAP.Server, connect.ProbeLDAPSigning(&cfg.LDAP)),
+			ldapRelayTarget(analyze.RelayServiceLDAPS, cfg.LDAP.Server, connect.ProbeChannelBinding(&cfg.LDAP)))
+	}
+
+	cas, err := webEnrollmentTargets(ctx, cfg.LDAP, forestDN, !noProbe)
+	if err != nil {
+		log.Warnf("Reading certificate authorities: %v", err)
+	}
+	targets = append(targets, cas...)
+
+	delegations, err := collectDelegations(ctx, ldapClient)
+	if err != nil {
+		return err
+	}
+	for _, d := range delegations {
+		if d.CoerceHere {
+			targets = append(targets, analyze.RelayTarget{
+				Service: analyze.RelayServiceUnconstrained,
+				Host:    d.Source,
+				Status:  analyze.RelayViable,
+				Detail:  "coerce DC authentication here to capture TGTs",
+			})
+		}
+	}
+	analyze.SortRelayTargets(targets)
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, targets) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Relay surface report generated: %s (%d targets)", path, len(targets))
+	return nil
+}
+
+// ldapRelayTarget turns a signing or channel binding probe into a target:
+// relaying is viable where the protection is not enforced
+func ldapRelayTarget(service, server string, r connect.ProbeResult) analyze.RelayTarget {
+	t := analyze.RelayTarget{Service: service, Host: server, Status: analyze.RelayUnknown, Detail: r.Detail}
+	protection := "signing"
+	if service == analyze.RelayServiceLDAPS {
+		protection = "channel binding"
+	}
+	switch r.Outcome {
+	case connect.ProbeNotEnforced:
+		t.Status, t.Detail = analyze.RelayViable, protection+" not required"
+	case connect.ProbeEnforced:
+		t.Status, t.Detail = analyze.RelayProtected, protection+" required"
+	}
+	return t
+}
+
+// webEnrollmentTargets reads the certificate authorities of the forest and,
+// if probe is set, requests their web enrollment pages
+func webEnrollmentTargets(ctx context.Context, base connect.Config, forestDN string, probe bool) ([]analyze.RelayTarget, error) {
+	q, _ := queries.Get("caComputer")
+	client, err := newClientAt(base, queries.PartitionDN(q.Partition, forestDN))
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	cas, err := client.Search(ctx, q.Filter, q.Attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := time.Duration(base.Timeout) * time.Second
+	var targets []analyze.RelayTarget
+	for _, ca := range cas {
+		host := ca.GetEqualFoldAttributeValue(analyze.AttrDNSHostName)
+		if host == "" {
+			continue
+		}
+		t := analyze.RelayTarget{Service: analyze.RelayServiceWebEnrollment, Host: host, Status: analyze.RelayUnknown, Detail: "not probed"}
+		if probe {
+			log.Infof("Probing web enrollment on %s", host)
+			httpNTLM, httpErr := connect.ProbeWebEnrollment(ctx, "http://"+host+"/certsrv/", timeout)
+			httpsNTLM, httpsErr := connect.ProbeWebEnrollment(ctx, "https://"+host+"/certsrv/", timeout)
+			switch {
+			case httpNTLM:
+				t.Status, t.Detail = analyze.RelayViable, "NTLM over HTTP (ESC8)"
+			case httpsNTLM:
+				t.Detail = "NTLM over HTTPS only; viable unless EPA is enforced"
+			case httpErr != nil && httpsErr != nil:
+				t.Status, t.Detail = analyze.RelayProtected, "web enrollment not reachable"
+			default:
+				t.Status, t.Detail = analyze.RelayProtected, "no NTLM authentication offered"
+			}
+		}
+		targets = append(targets, t)
+	}
+	return targets, nil
+}
+
+// writeRelaySurfaceTable writes the targets as an aligned table
+func writeRelaySurfaceTable(w io.Writer, targets []analyze.RelayTarget) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "SERVICE\tHOST\tSTATUS\tDETAIL")
+	viable := 0
+	for _, t := range targets {
+		fmt.Fprintf(tw, "%s\t%s\t%s\t%s\n", t.Service, t.Host, strings.ToUpper(t.Status), t.Detail)
+		if t.Status == analyze.RelayViable {
+			viable++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d targets, %d viable\n", len(targets), viable)
+	return err
+}
+
+// writeRelaySurfaceJSON writes the targets as an indented JSON array
+func writeRelaySurfaceJSON(w io.Writer, targets []analyze.RelayTarget) error {
+	if targets == nil {
+		targets = []analyze.RelayTarget{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(targets)
+}
+
+// writeRelaySurfaceCSV writes one row per target, with a header row
+func writeRelaySurfaceCSV(w io.Writer, targets []analyze.RelayTarget) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"service", "host", "status", "detail"})
+	for _, t := range targets {
+		cw.Write([]string{t.Service, t.Host, t.Status, t.Detail})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(relaySurfaceCmd)
+
+	relaySurfaceCmd.Flags().Bool("no-probe", false, "Skip the LDAP binds and web enrollment requests and report from the directory only")
+	relaySurfaceCmd.Flags().String("forest-dn", "", "Forest root DN whose certificate authorities are read (default: the base DN)")
+}
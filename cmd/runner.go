@@ -1,12 +1,23 @@
 package cmd
 
 import (
+	"adgo/analyze"
 	"adgo/connect"
 	"adgo/log"
 	"adgo/output"
+	"adgo/queries"
+	"adgo/tracing"
 	"context"
+	"errors"
 	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync/atomic"
 
+	"github.com/go-ldap/ldap/v3"
 	"github.com/spf13/cobra"
 )
 
@@ -21,55 +32,423 @@ import (
 // The filter is the LDAP search filter string.
 // The attributes are the LDAP attributes to retrieve.
 //
-// Returns an error if any step fails.
-func RunQuery(cmd *cobra.Command, filter string, attributes []string) error {
+// Returns an error if any step fails. A search that fails after entries were
+// printed returns an error with exit code ExitPartial.
+func RunQuery(cmd *cobra.Command, filter string, attributes []string) (err error) {
 	ctx, cancel := context.WithCancel(cmd.Context())
 	defer cancel()
 
+	ctx, span := tracing.Start(ctx, "query", tracing.String("adgo.query", queryName(cmd)), tracing.String("ldap.filter", filter))
+	defer func() {
+		span.SetError(err)
+		span.End()
+	}()
+
 	// 1. Get configuration
 	cfg := GetConfig()
 
-	// 2. Initialize LDAP client
-	ldapClient, err := connect.NewClient(&cfg.LDAP)
-	if err != nil {
-		return fmt.Errorf("creating LDAP client: %w", err)
+	// Queries of the configuration and schema partitions search them in
+	// the forest of the server
+	searchDN := cfg.LDAP.BaseDN
+	partition := ""
+	if q, ok := queries.Get(queryName(cmd)); ok && q.Partition != "" {
+		if multiTarget(cmd) {
+			return fmt.Errorf("the %s query searches the %s partition and cannot be combined with --targets or --all-domains", queryName(cmd), q.Partition)
+		}
+		partition = q.Partition
+	}
+
+	// Constructed attributes are not returned by the subtree search; each
+	// entry found is read again with a base-scope search for them
+	attributes, constructed := analyze.SplitConstructedAttributes(attributes)
+
+	if dryRun, _ := cmd.Flags().GetBool("dry-run"); dryRun {
+		ctx, attributes := queryRequest(ctx, cmd, attributes)
+		if multiTarget(cmd) {
+			return planTargets(ctx, cmd, filter, attributes)
+		}
+		if partition != "" {
+			base, _ := partitionBase(ctx, cmd, nil, partition)
+			ctx = connect.WithSearchBase(ctx, base)
+		}
+		return printSearchPlan(cmd, connect.PlanSearch(ctx, &cfg.LDAP, filter, attributes))
+	}
+
+	// 2. Initialize LDAP client, or resolve the domains of a multi-domain query
+	var streamSearch func(context.Context, string, []string) (<-chan *ldap.Entry, <-chan error)
+	var resolveClient connect.Client
+	watch, _ := cmd.Flags().GetDuration("watch")
+	if multiTarget(cmd) {
+		if watch > 0 {
+			return fmt.Errorf("--watch cannot be combined with --targets or --all-domains")
+		}
+		targets, err := resolveTargets(ctx, cmd)
+		if err != nil {
+			return err
+		}
+		filters, err := targetFilters(cmd, targets, filter)
+		if err != nil {
+			return err
+		}
+		streamSearch = func(ctx context.Context, _ string, attributes []string) (<-chan *ldap.Entry, <-chan error) {
+			return streamTargets(ctx, targets, filters, attributes)
+		}
+	} else {
+		// Watch mode must see every change, so it never reads the cache
+		connectClient := newClient
+		if watch > 0 {
+			connectClient = newLiveClient
+		}
+		ldapClient, err := connectClient(&cfg.LDAP)
+		if err != nil {
+			return fmt.Errorf("creating LDAP client: %w", err)
+		}
+		defer ldapClient.Close()
+
+		if partition != "" {
+			if searchDN, err = partitionBase(ctx, cmd, ldapClient, partition); err != nil {
+				return fmt.Errorf("finding the %s partition: %w", partition, err)
+			}
+			ctx = connect.WithSearchBase(ctx, searchDN)
+		}
+
+		if watch > 0 {
+			return runWatch(ctx, cmd, ldapClient, filter, attributes, watch)
+		}
+		streamSearch = ldapClient.StreamSearch
+		if partition == "" {
+			resolveClient = ldapClient
+		}
 	}
-	defer ldapClient.Close()
 
 	// 3. Handle Output Setup
 	format, _ := cmd.Flags().GetString("output")
 	if format == "" {
 		format = cfg.Output
 	}
+	pc, err := printerConfig(cmd, format)
+	if err != nil {
+		return err
+	}
+	outPath := pc.Path
 
-	var csvPath string
-	if format == "csv" {
-		csvPath = connect.GenerateFilename(cfg.LDAP.BaseDN)
+	// SIDs are resolved in the domain searched, so not for several domains
+	if q, ok := queries.Get(queryName(cmd)); ok && len(q.ResolveSIDs) > 0 && resolveClient != nil {
+		pc.PostProcess = append([]output.ProcessFunc{sidNameStep(ctx, resolveClient, q.ResolveSIDs)}, pc.PostProcess...)
+	}
+	if len(constructed) > 0 {
+		if resolveClient == nil {
+			log.Warnf("%s can only be read in the base DN of a single domain and are left out", strings.Join(constructed, ", "))
+		} else {
+			pc.PostProcess = append([]output.ProcessFunc{constructedStep(ctx, resolveClient, constructed)}, pc.PostProcess...)
+		}
 	}
 
+	// The search error is read once the entry stream ends, so printers can
+	// mark their output as partial
+	var errChan <-chan error
+	var searchErr error
+	pc.StreamErr = func() error {
+		if errChan != nil {
+			searchErr = <-errChan
+			errChan = nil
+		}
+		return searchErr
+	}
+	streamErr := pc.StreamErr
+
 	// Create printer
-	printer, err := output.NewPrinter(output.PrinterConfig{
-		Format: format,
-		Path:   csvPath,
-	})
+	printer, err := output.NewPrinter(pc)
 	if err != nil {
 		return fmt.Errorf("creating printer: %v", err)
 	}
+	printer = withTracing(ctx, printer, format)
 
 	// 4. Perform Streaming Search and Print
-	entriesChan, errChan := ldapClient.StreamSearch(ctx, filter, attributes)
+	ctx, attributes = queryRequest(ctx, cmd, attributes)
+	stopProgress := func() {}
+	if progressEnabled(cmd, outPath == "") {
+		ctx, stopProgress = startProgress(ctx, cfg.LDAP.SizeLimit)
+		defer stopProgress()
+	}
+
+	var entriesChan <-chan *ldap.Entry
+	var received atomic.Int64
+	entriesChan, errChan = streamSearch(ctx, filter, attributes)
+	entriesChan = countEntries(ctx, entriesChan, &received)
 
 	if err := printer.StreamPrint(entriesChan); err != nil {
 		return fmt.Errorf("printing results: %v", err)
 	}
+	stopProgress()
 
-	if err := <-errChan; err != nil {
-		return fmt.Errorf("executing query: %v", err)
+	span.SetAttributes(tracing.Int("adgo.entries", int(received.Load())))
+	if err := streamErr(); err != nil {
+		// Connection and bind failures of lazily dialed clients keep their class
+		var ldapErr *connect.LDAPError
+		if !errors.As(err, &ldapErr) {
+			err = connect.WrapSearchError(searchDN, err)
+		}
+		if n := received.Load(); n > 0 {
+			return withExitCode(ExitPartial, fmt.Errorf("executing query (partial results, %d entries): %w", n, err))
+		}
+		return fmt.Errorf("executing query: %w", err)
 	}
 
-	if csvPath != "" {
-		log.Infof("CSV file generated: %s", csvPath)
+	if outPath != "" {
+		log.Infof("Output file generated: %s", outPath)
 	}
 
 	return nil
 }
+
+// partitionBase returns the DN of the forest partition a query searches:
+// below --forest-dn if given, otherwise as named in the RootDSE of client.
+// Dry runs have no client and take the base DN as the forest root.
+func partitionBase(ctx context.Context, cmd *cobra.Command, client connect.Client, partition string) (string, error) {
+	forestDN, _ := cmd.Flags().GetString("forest-dn")
+	switch {
+	case forestDN != "":
+		return queries.PartitionDN(partition, forestDN), nil
+	case client != nil:
+		return connect.ReadNamingContext(ctx, client, queries.NamingContext(partition))
+	default:
+		return queries.PartitionDN(partition, GetConfig().LDAP.BaseDN), nil
+	}
+}
+
+// constructedStep returns a post-processing step that adds the constructed
+// attributes of each entry, read with a base-scope search on the entry
+func constructedStep(ctx context.Context, client connect.Client, attributes []string) output.ProcessFunc {
+	return func(e *ldap.Entry) (*ldap.Entry, error) {
+		entries, err := client.Search(connect.WithBaseObject(ctx, e.DN), "(objectClass=*)", attributes)
+		if err != nil {
+			log.Warnf("Reading %s of %s: %v", strings.Join(attributes, ", "), e.DN, err)
+			return e, nil
+		}
+		if len(entries) > 0 {
+			e.Attributes = append(e.Attributes, entries[0].Attributes...)
+		}
+		return e, nil
+	}
+}
+
+// countEntries forwards the entries of in until ctx is done, counting them in n
+func countEntries(ctx context.Context, in <-chan *ldap.Entry, n *atomic.Int64) <-chan *ldap.Entry {
+	out := make(chan *ldap.Entry)
+	go func() {
+		defer close(out)
+		for entry := range in {
+			n.Add(1)
+			select {
+			case out <- entry:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+	return out
+}
+
+// printerConfig builds the printer configuration of a query from the
+// output flags and config: the output file, post-processing, sinks,
+// notifications and the CSV dialect
+func printerConfig(cmd *cobra.Command, format string) (output.PrinterConfig, error) {
+	cfg := GetConfig()
+	templateFile, _ := cmd.Flags().GetString("template-file")
+	where, _ := cmd.Flags().GetString("where")
+	fields, _ := cmd.Flags().GetStringSlice("fields")
+	excludeFields, _ := cmd.Flags().GetStringSlice("exclude-fields")
+	sortBy, _ := cmd.Flags().GetString("sort-by")
+	sortDesc, _ := cmd.Flags().GetBool("desc")
+	limit, _ := cmd.Flags().GetInt("limit")
+	if limit < 0 {
+		return output.PrinterConfig{}, fmt.Errorf("--limit must not be negative")
+	}
+	summary, _ := cmd.Flags().GetBool("summary")
+	count, _ := cmd.Flags().GetBool("count")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+	redact, _ := cmd.Flags().GetBool("redact")
+	jsonRaw, _ := cmd.Flags().GetBool("json-raw")
+	steps, err := postProcessSteps(cmd)
+	if err != nil {
+		return output.PrinterConfig{}, err
+	}
+	csvCfg := csvConfig(cmd, cfg.CSV)
+	if _, err := output.ParseCSVDelimiter(csvCfg.Delimiter); err != nil {
+		return output.PrinterConfig{}, err
+	}
+
+	compressFlag, _ := cmd.Flags().GetString("compress")
+	compress, err := output.ParseCompression(compressFlag)
+	if err != nil {
+		return output.PrinterConfig{}, err
+	}
+
+	sinkURL, _ := cmd.Flags().GetString("sink-url")
+	sinkToken, _ := cmd.Flags().GetString("sink-token")
+	if sinkToken == "" {
+		sinkToken = os.Getenv("ADGO_SINK_TOKEN")
+	}
+	sinkIndex, _ := cmd.Flags().GetString("sink-index")
+
+	out, _ := cmd.Flags().GetString("out")
+	outFormat := format
+	if summary || count {
+		// Counts and statistics go to stdout unless --out is given
+		outFormat = analyze.OutputFormatText
+	}
+	outPath, err := resolveOutputPath(out, outFormat, cfg.LDAP.BaseDN, compress)
+	if err != nil {
+		return output.PrinterConfig{}, err
+	}
+
+	return output.PrinterConfig{
+		Format:        format,
+		Path:          outPath,
+		TemplateFile:  templateFile,
+		Where:         where,
+		Fields:        fields,
+		ExcludeFields: excludeFields,
+		Compress:      compress,
+		Query:         queryName(cmd),
+		SinkURL:       sinkURL,
+		SinkToken:     sinkToken,
+		SinkIndex:     sinkIndex,
+		NotifyURL:     cfg.Notify.URL,
+		NotifyMin:     cfg.Notify.MinScore,
+		SortBy:        sortBy,
+		SortDesc:      sortDesc,
+		Limit:         limit,
+		Summary:       summary,
+		Count:         count,
+		GroupBy:       groupBy,
+		Redact:        redact,
+		Quiet:         quietMode(cmd),
+		CSVDelimiter:  csvCfg.Delimiter,
+		CSVQuoteAll:   csvCfg.QuoteAll,
+		CSVCRLF:       csvCfg.CRLF,
+		CSVBOM:        csvCfg.BOM,
+		CSVWide:       csvCfg.Wide,
+		JSONRaw:       jsonRaw,
+		PostProcess:   steps,
+	}, nil
+}
+
+// queryRequest adds the attributes the output format, --where, --sort-by
+// and --group-by need, and the search options of the format, to a query
+func queryRequest(ctx context.Context, cmd *cobra.Command, attributes []string) (context.Context, []string) {
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = GetConfig().Output
+	}
+	where, _ := cmd.Flags().GetString("where")
+	sortBy, _ := cmd.Flags().GetString("sort-by")
+	groupBy, _ := cmd.Flags().GetString("group-by")
+
+	attributes = withAttributes(attributes, output.RequiredAttributes(format)...)
+	attributes = withAttributes(attributes, output.WhereAttributes(where)...)
+	attributes = withAttributes(attributes, output.SortAttributes(sortBy)...)
+	attributes = withAttributes(attributes, output.GroupByAttributes(groupBy)...)
+	if output.IsBloodHoundFormat(format) {
+		// Member DNs carry SIDs in extended form so groups link by SID
+		ctx = connect.WithExtendedDN(ctx)
+	}
+	return ctx, attributes
+}
+
+// resolveOutputPath returns the file RunQuery writes to, or "" for stdout.
+// A directory (existing, or ending in a path separator) gets a generated
+// domain-timestamp filename and is created if missing. CSV defaults to a
+// generated file in the current directory. Generated names carry the
+// extension of the compression method, if any.
+func resolveOutputPath(out, format, baseDN, compress string) (string, error) {
+	filename := connect.GenerateFilename(baseDN, output.FileExtension(format)) + output.CompressionExtension(compress)
+	if out == "" {
+		if format == analyze.OutputFormatCSV {
+			return filename, nil
+		}
+		return "", nil
+	}
+
+	if info, err := os.Stat(out); err == nil && info.IsDir() {
+		return filepath.Join(out, filename), nil
+	}
+	if strings.HasSuffix(out, "/") || strings.HasSuffix(out, string(os.PathSeparator)) {
+		if err := os.MkdirAll(out, 0755); err != nil {
+			return "", fmt.Errorf("creating output directory: %w", err)
+		}
+		return filepath.Join(out, filename), nil
+	}
+	return out, nil
+}
+
+// writeReport runs write on the output of a report command: stdout, or the
+// --out file or a generated file in an --out directory, compressed with
+// --compress or a .gz/.zst extension. Files are created with perm, or the
+// default permissions if it is zero. It returns the file written, "" for
+// stdout.
+func writeReport(cmd *cobra.Command, format string, perm os.FileMode, write func(io.Writer) error) (string, error) {
+	compressFlag, _ := cmd.Flags().GetString("compress")
+	compress, err := output.ParseCompression(compressFlag)
+	if err != nil {
+		return "", err
+	}
+	out, _ := cmd.Flags().GetString("out")
+	if out == "" && compress == "" {
+		return "", write(cmd.OutOrStdout())
+	}
+
+	var path string
+	if out != "" {
+		if path, err = resolveOutputPath(out, format, GetConfig().LDAP.BaseDN, compress); err != nil {
+			return "", err
+		}
+	}
+	w, closeOutput, err := output.CreateOutput(output.PrinterConfig{Path: path, Compress: compress, FileMode: perm})
+	if err != nil {
+		return "", err
+	}
+	err = write(w)
+	if cerr := closeOutput(); err == nil {
+		err = cerr
+	}
+	return path, err
+}
+
+// withAttributes appends extra attributes that are not already requested
+func withAttributes(attributes []string, extra ...string) []string {
+	result := slices.Clone(attributes)
+	for _, attr := range extra {
+		if !slices.ContainsFunc(result, func(a string) bool { return strings.EqualFold(a, attr) }) {
+			result = append(result, attr)
+		}
+	}
+	return result
+}
+
+// queryName returns the predefined query a command runs, or the command name
+func queryName(cmd *cobra.Command) string {
+	if name := cmd.Annotations["query"]; name != "" {
+		return name
+	}
+	return cmd.Name()
+}
+
+// csvConfig returns the CSV dialect from config, overridden by the --csv-* flags
+func csvConfig(cmd *cobra.Command, c CSVConfig) CSVConfig {
+	if d, _ := cmd.Flags().GetString("csv-delimiter"); d != "" {
+		c.Delimiter = d
+	}
+	for name, field := range map[string]*bool{
+		"csv-quote-all": &c.QuoteAll,
+		"csv-crlf":      &c.CRLF,
+		"csv-bom":       &c.BOM,
+		"csv-wide":      &c.Wide,
+	} {
+		if cmd.Flags().Changed(name) {
+			*field, _ = cmd.Flags().GetBool(name)
+		}
+	}
+	return c
+}
@@ -0,0 +1,236 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// schemaCmd represents the schema command
+var schemaCmd = &cobra.Command{
+	Use:   "schema",
+	Short: "Report the schema version, functional levels and schema extensions",
+	Long: "Schema reads the objectVersion of the schema partition, the domain and forest " +
+		"functional levels (msDS-Behavior-Version) and maps them to Windows Server versions. " +
+		"It reports whether LAPS (legacy and Windows), Exchange and SCCM have extended the " +
+		"schema, with the Exchange release, and notes what the versions make possible, such as " +
+		"dMSA abuse on a 2025 schema or RC4-only tickets below the 2008 domain level. The schema " +
+		"and configuration partitions of --forest-dn, the base DN by default, are read. " +
+		"Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSchema(cmd)
+	},
+}
+
+// runSchema collects and prints the schema report
+func runSchema(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, analyze.SchemaReport) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeSchemaTable
+	case analyze.OutputFormatJSON:
+		write = writeSchemaJSON
+	case analyze.OutputFormatCSV:
+		write = writeSchemaCSV
+	default:
+		return fmt.Errorf("schema output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	forestDN, _ := cmd.Flags().GetString("forest-dn")
+	if forestDN == "" {
+		forestDN = cfg.LDAP.BaseDN
+	}
+	report, err := collectSchema(cmd.Context(), ldapClient, cfg.LDAP, forestDN)
+	if err != nil {
+		return err
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, report) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Schema report generated: %s", path)
+	return nil
+}
+
+// collectSchema reads the domain functional level through client, and the
+// forest functional level and schema from the partitions of forestDN.
+// Partitions that cannot be read are logged and left out of the report.
+func collectSchema(ctx context.Context, client connect.Client, base connect.Config, forestDN string) (analyze.SchemaReport, error) {
+	report := analyze.SchemaReport{SchemaVersion: -1, ForestLevel: -1}
+
+	level, err := firstValue(ctx, client, "(objectClass=domain)", analyze.AttrMSDSBehaviorVersion)
+	if err != nil {
+		return report, fmt.Errorf("reading the domain functional level: %w", err)
+	}
+	report.DomainLevel, _ = strconv.Atoi(level)
+	report.Domain = analyze.FunctionalLevel(level)
+
+	if err := readForestLevel(ctx, base, forestDN, &report); err != nil {
+		log.Warnf("Reading the forest functional level: %v", err)
+	}
+	if err := readSchema(ctx, base, forestDN, &report); err != nil {
+		log.Warnf("Reading the schema: %v", err)
+	}
+	report.Notes = analyze.SchemaNotes(report)
+	return report, nil
+}
+
+// readForestLevel reads the forest functional level from the partitions
+// container of forestDN
+func readForestLevel(ctx context.Context, base connect.Config, forestDN string, report *analyze.SchemaReport) error {
+	client, err := newClientAt(base, "CN=Partitions,"+queries.PartitionDN(queries.PartitionConfiguration, forestDN))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	level, err := firstValue(ctx, client, "(objectClass=crossRefContainer)", analyze.AttrMSDSBehaviorVersion)
+	if err != nil {
+		return err
+	}
+	report.ForestLevel, _ = strconv.Atoi(level)
+	report.Forest = analyze.FunctionalLevel(level)
+	return nil
+}
+
+// readSchema reads the schema version and extensions from the schema
+// partition of forestDN
+func readSchema(ctx context.Context, base connect.Config, forestDN string, report *analyze.SchemaReport) error {
+	client, err := newClientAt(base, queries.PartitionDN(queries.PartitionSchema, forestDN))
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	version, err := firstValue(ctx, client, "(objectClass=dMD)", analyze.AttrObjectVersion)
+	if err != nil {
+		return err
+	}
+	report.SchemaVersion, _ = strconv.Atoi(version)
+	report.Schema = analyze.SchemaVersion(report.SchemaVersion)
+
+	entries, err := client.Search(ctx, analyze.SchemaExtensionFilter(), analyze.SchemaExtensionAttributes)
+	if err != nil {
+		return fmt.Errorf("searching schema extensions: %w", err)
+	}
+	report.Extensions = analyze.SchemaExtensionsPresent(entries)
+	return nil
+}
+
+// firstValue returns an attribute of the first entry matching filter
+func firstValue(ctx context.Context, client connect.Client, filter, attribute string) (string, error) {
+	entries, err := client.Search(ctx, filter, []string{attribute})
+	if err != nil {
+		return "", err
+	}
+	if len(entries) == 0 || entries[0].GetEqualFoldAttributeValue(attribute) == "" {
+		return "", fmt.Errorf("%s not readable", attribute)
+	}
+	return entries[0].GetEqualFoldAttributeValue(attribute), nil
+}
+
+// schemaLevel formats a version number and the Windows Server version it
+// maps to
+func schemaLevel(level int, version string) string {
+	switch {
+	case level < 0:
+		return "(not read)"
+	case version == "":
+		return strconv.Itoa(level)
+	}
+	return fmt.Sprintf("Windows Server %s (%d)", version, level)
+}
+
+// writeSchemaTable writes the versions, extensions and notes as text
+func writeSchemaTable(w io.Writer, r analyze.SchemaReport) error {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(tw, "Schema version:\t%s\n", schemaLevel(r.SchemaVersion, r.Schema))
+	fmt.Fprintf(tw, "Forest functional level:\t%s\n", schemaLevel(r.ForestLevel, r.Forest))
+	fmt.Fprintf(tw, "Domain functional level:\t%s\n", schemaLevel(r.DomainLevel, r.Domain))
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if len(r.Extensions) > 0 {
+		fmt.Fprintln(w, "\nSchema extensions:")
+		tw = tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, e := range r.Extensions {
+			state := "absent"
+			if e.Present {
+				state = "present"
+			}
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", e.Name, state, e.Version)
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if len(r.Notes) > 0 {
+		fmt.Fprintln(w, "\nNotes:")
+		for _, n := range r.Notes {
+			fmt.Fprintf(w, "  - %s\n", n)
+		}
+	}
+	return nil
+}
+
+// writeSchemaJSON writes the report as indented JSON
+func writeSchemaJSON(w io.Writer, r analyze.SchemaReport) error {
+	if r.Extensions == nil {
+		r.Extensions = []analyze.ExtensionPresent{}
+	}
+	if r.Notes == nil {
+		r.Notes = []string{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(r)
+}
+
+// writeSchemaCSV writes one item, value and detail row per version and
+// extension, with a header row
+func writeSchemaCSV(w io.Writer, r analyze.SchemaReport) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"item", "value", "detail"})
+	cw.Write([]string{"schemaVersion", strconv.Itoa(r.SchemaVersion), r.Schema})
+	cw.Write([]string{"forestFunctionalLevel", strconv.Itoa(r.ForestLevel), r.Forest})
+	cw.Write([]string{"domainFunctionalLevel", strconv.Itoa(r.DomainLevel), r.Domain})
+	for _, e := range r.Extensions {
+		cw.Write([]string{e.Name, strconv.FormatBool(e.Present), e.Version})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(schemaCmd)
+
+	schemaCmd.Flags().String("forest-dn", "", "Forest root DN whose schema and configuration are read (default: the base DN)")
+}
@@ -95,13 +95,10 @@ func setup() {
 // prompt helper for user input
 func prompt(scanner *bufio.Scanner, label string, validator func(string) error, isPassword bool) string {
 	if isPassword {
-		fmt.Print(label)
-		bytePassword, err := term.ReadPassword(int(syscall.Stdin))
-		fmt.Println()
+		password, err := readPassword(label)
 		if err != nil {
 			return ""
 		}
-		password := string(bytePassword)
 		if validator != nil {
 			if err := validator(password); err != nil {
 				log.Warn(err.Error())
@@ -127,3 +124,15 @@ func prompt(scanner *bufio.Scanner, label string, validator func(string) error,
 		return input
 	}
 }
+
+// readPassword reads a password from the terminal without echoing it. The
+// label goes to stderr so it does not end up in redirected output.
+func readPassword(label string) (string, error) {
+	fmt.Fprint(os.Stderr, label)
+	bytePassword, err := term.ReadPassword(int(syscall.Stdin))
+	fmt.Fprintln(os.Stderr)
+	if err != nil {
+		return "", err
+	}
+	return string(bytePassword), nil
+}
@@ -0,0 +1,164 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"adgo/snapshot"
+	"encoding/json"
+	"fmt"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// snapshotCmd represents the snapshot command group
+var snapshotCmd = &cobra.Command{
+	Use:   "snapshot",
+	Short: "Save query results and diff them over time",
+	Long: "Snapshot stores normalized query results as JSON and compares two snapshots " +
+		"object by object: new and removed accounts, changed UAC flags, new SPNs and ACL changes.",
+}
+
+// snapshotSaveCmd represents the snapshot save command
+var snapshotSaveCmd = &cobra.Command{
+	Use:   "save QUERY",
+	Short: "Run a predefined query and save the results",
+	Args:  cobra.ExactArgs(1),
+	RunE: func(cmd *cobra.Command, args []string) error {
+		name, _ := cmd.Flags().GetString("name")
+		return runSnapshotSave(cmd, args[0], name)
+	},
+}
+
+// snapshotListCmd represents the snapshot list command
+var snapshotListCmd = &cobra.Command{
+	Use:         "list",
+	Short:       "List saved snapshots",
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSnapshotList(cmd)
+	},
+}
+
+// snapshotDiffCmd represents the snapshot diff command
+var snapshotDiffCmd = &cobra.Command{
+	Use:         "diff OLD NEW",
+	Short:       "Show object-level changes between two snapshots",
+	Args:        cobra.ExactArgs(2),
+	Annotations: map[string]string{annotationOffline: "true"},
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSnapshotDiff(cmd, args[0], args[1])
+	},
+}
+
+// snapshotStore returns the store selected by --dir
+func snapshotStore(cmd *cobra.Command) *snapshot.Store {
+	dir, _ := cmd.Flags().GetString("dir")
+	if dir == "" {
+		dir = snapshot.DefaultDir()
+	}
+	return snapshot.NewStore(dir)
+}
+
+// runSnapshotSave runs a predefined query and stores its results
+func runSnapshotSave(cmd *cobra.Command, queryName, name string) error {
+	q, ok := queries.Get(queryName)
+	if !ok {
+		return fmt.Errorf("query '%s' not found", queryName)
+	}
+
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	// objectGUID matches objects across renames; security descriptors enable ACL diffs
+	attributes := withAttributes(q.Attributes, analyze.AttrObjectGUID, analyze.AttrObjectClass, analyze.AttrNTSecurityDescriptor)
+	ctx, stopProgress := cmd.Context(), func() {}
+	if progressEnabled(cmd, false) {
+		ctx, stopProgress = startProgress(ctx, cfg.LDAP.SizeLimit)
+	}
+	entries, err := ldapClient.Search(ctx, q.Filter, attributes)
+	stopProgress()
+	if err != nil {
+		return fmt.Errorf("executing query: %w", err)
+	}
+
+	if name == "" {
+		name = fmt.Sprintf("%s-%s", queryName, time.Now().Format("20060102-150405"))
+	}
+	s := snapshot.New(name, queryName, q.Filter, cfg.LDAP.BaseDN, entries)
+
+	path, err := snapshotStore(cmd).Save(s)
+	if err != nil {
+		return err
+	}
+	log.Infof("Snapshot saved: %s (%d objects)", path, len(s.Objects))
+	return nil
+}
+
+// runSnapshotList prints the stored snapshots
+func runSnapshotList(cmd *cobra.Command) error {
+	infos, err := snapshotStore(cmd).List()
+	if err != nil {
+		return err
+	}
+	if len(infos) == 0 {
+		fmt.Fprintln(cmd.OutOrStdout(), "No snapshots found")
+		return nil
+	}
+
+	w := tabwriter.NewWriter(cmd.OutOrStdout(), 0, 0, 2, ' ', 0)
+	fmt.Fprintln(w, "NAME\tQUERY\tOBJECTS\tCREATED")
+	for _, info := range infos {
+		fmt.Fprintf(w, "%s\t%s\t%d\t%s\n", info.Name, info.Query, info.Objects, analyze.FormatTime(info.Created))
+	}
+	return w.Flush()
+}
+
+// runSnapshotDiff prints the changes between two stored snapshots
+func runSnapshotDiff(cmd *cobra.Command, oldName, newName string) error {
+	store := snapshotStore(cmd)
+	prev, err := store.Load(oldName)
+	if err != nil {
+		return err
+	}
+	cur, err := store.Load(newName)
+	if err != nil {
+		return err
+	}
+	if prev.Query != cur.Query {
+		log.Warnf("Comparing snapshots of different queries (%s, %s)", prev.Query, cur.Query)
+	}
+
+	changes := snapshot.Diff(prev.Objects, cur.Objects)
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == analyze.OutputFormatJSON {
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		return enc.Encode(changes)
+	}
+
+	for _, c := range changes {
+		fmt.Fprintln(cmd.OutOrStdout(), c.String())
+	}
+	fmt.Fprintf(cmd.OutOrStdout(), "%d changes between %s and %s\n", len(changes), prev.Name, cur.Name)
+	return nil
+}
+
+func init() {
+	rootCmd.AddCommand(snapshotCmd)
+	snapshotCmd.AddCommand(snapshotSaveCmd, snapshotListCmd, snapshotDiffCmd)
+
+	snapshotCmd.PersistentFlags().String("dir", "", "Snapshot directory (default $HOME/.adgo/snapshots)")
+	snapshotSaveCmd.Flags().String("name", "", "Snapshot name (default <query>-<timestamp>)")
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// spnCheckCmd represents the spncheck command
+var spnCheckCmd = &cobra.Command{
+	Use:   "spncheck",
+	Short: "Find duplicate, malformed and dangling service principal names",
+	Long: "Spncheck analyzes the results of the spn query. It reports SPNs registered on more " +
+		"than one account, which break Kerberos for the service or let either account receive " +
+		"its tickets, SPNs that do not parse, and SPNs naming a host that is not a computer of " +
+		"the domain: whoever registers that name in DNS, or a computer of that name, receives " +
+		"the tickets. Hosts may also be DNS aliases; --dns looks unknown hosts up and drops " +
+		"those that resolve. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runSPNCheck(cmd)
+	},
+}
+
+// runSPNCheck collects and prints the SPN issues
+func runSPNCheck(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.SPNIssue) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeSPNIssuesTable
+	case analyze.OutputFormatJSON:
+		write = writeSPNIssuesJSON
+	case analyze.OutputFormatCSV:
+		write = writeSPNIssuesCSV
+	default:
+		return fmt.Errorf("spncheck output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	ctx := cmd.Context()
+	issues, err := collectSPNIssues(ctx, ldapClient, cfg.LDAP.BaseDN)
+	if err != nil {
+		return err
+	}
+	if dns, _ := cmd.Flags().GetBool("dns"); dns {
+		issues = dropResolvedHosts(ctx, issues)
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, issues) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("SPN report generated: %s (%d issues)", path, len(issues))
+	return nil
+}
+
+// collectSPNIssues runs the spn query and checks its results against
+// each other and against the computers of the domain
+func collectSPNIssues(ctx context.Context, client connect.Client, baseDN string) ([]analyze.SPNIssue, error) {
+	q, _ := queries.Get("spn")
+	entries, err := client.Search(ctx, q.Filter, analyze.SPNAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching SPNs: %w", err)
+	}
+	q, _ = queries.Get("computers")
+	computers, err := client.Search(ctx, q.Filter, analyze.SPNHostAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching computers: %w", err)
+	}
+
+	// DCs register SPNs for the domain name and its NetBIOS name, which
+	// is usually the first label
+	var domains []string
+	if domain, err := connect.BaseDNToDomain(baseDN); err == nil {
+		label, _, _ := strings.Cut(domain, ".")
+		domains = append(domains, domain, label)
+	}
+
+	issues := analyze.DuplicateSPNs(entries)
+	return append(issues, analyze.SPNHostIssues(entries, analyze.KnownHosts(computers, domains...))...), nil
+}
+
+// dropResolvedHosts removes the unknown host issues of hosts that resolve
+// in DNS, looking each host up once
+func dropResolvedHosts(ctx context.Context, issues []analyze.SPNIssue) []analyze.SPNIssue {
+	resolved := make(map[string]bool)
+	kept := issues[:0]
+	for _, issue := range issues {
+		if issue.Kind == analyze.SPNUnknownHost {
+			host := strings.ToLower(issue.Host)
+			ok, seen := resolved[host]
+			if !seen {
+				_, err := net.DefaultResolver.LookupHost(ctx, host)
+				ok = err == nil
+				resolved[host] = ok
+				log.Debugf("DNS lookup of %s: resolved=%t", host, ok)
+			}
+			if ok {
+				continue
+			}
+		}
+		kept = append(kept, issue)
+	}
+	return kept
+}
+
+// writeSPNIssuesTable writes the issues as an aligned table
+func writeSPNIssuesTable(w io.Writer, issues []analyze.SPNIssue) error {
+	if len(issues) == 0 {
+		_, err := fmt.Fprintln(w, "No SPN issues found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ISSUE\tSPN\tACCOUNTS")
+	for _, issue := range issues {
+		fmt.Fprintf(tw, "%s\t%s\t%s\n", issue.Kind, issue.SPN, strings.Join(issue.Accounts, ", "))
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d SPN issues\n", len(issues))
+	return err
+}
+
+// writeSPNIssuesJSON writes the issues as an indented JSON array
+func writeSPNIssuesJSON(w io.Writer, issues []analyze.SPNIssue) error {
+	if issues == nil {
+		issues = []analyze.SPNIssue{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(issues)
+}
+
+// writeSPNIssuesCSV writes the issues as CSV with a header row
+func writeSPNIssuesCSV(w io.Writer, issues []analyze.SPNIssue) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"kind", "spn", "host", "accounts"})
+	for _, issue := range issues {
+		cw.Write([]string{issue.Kind, issue.SPN, issue.Host, strings.Join(issue.Accounts, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(spnCheckCmd)
+
+	spnCheckCmd.Flags().Bool("dns", false, "Look up unknown SPN hosts in DNS and drop those that resolve")
+}
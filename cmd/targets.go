@@ -0,0 +1,349 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/queries"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+const (
+	// annotationMultiDomain marks commands that honor --targets and --all-domains
+	annotationMultiDomain = "multiDomain"
+
+	// targetsAll selects every configured target
+	targetsAll = "all"
+
+	// targetDomainAttribute is added to every entry of a multi-domain query
+	targetDomainAttribute = "domain"
+)
+
+// TargetConfig is one domain of the targets list. Unset fields are taken
+// from the ldap section; credentials are inherited together.
+type TargetConfig struct {
+	Name      string                `mapstructure:"name" yaml:"name"`                     // Label for the domain column; defaults to the domain of baseDN
+	Server    string                `mapstructure:"server" yaml:"server"`                 // LDAP server address
+	Port      int                   `mapstructure:"port" yaml:"port,omitempty"`           // LDAP server port
+	BaseDN    string                `mapstructure:"baseDN" yaml:"baseDN"`                 // LDAP base DN
+	Username  string                `mapstructure:"username" yaml:"username,omitempty"`   // LDAP username
+	Password  string                `mapstructure:"password" yaml:"password,omitempty"`   // LDAP password
+	LoginName connect.LoginName     `mapstructure:"loginName" yaml:"loginName,omitempty"` // Username type for authentication
+	Security  *connect.SecurityType `mapstructure:"security" yaml:"security,omitempty"`   // Connection security type
+}
+
+// queryTarget is one domain a multi-domain query runs against
+type queryTarget struct {
+	Domain string
+	Config connect.Config
+}
+
+// checkTargets fails if --targets or --all-domains is given to a command
+// that only queries one domain
+func checkTargets(cmd *cobra.Command) error {
+	if !multiTarget(cmd) || cmd.Annotations[annotationMultiDomain] != "" {
+		return nil
+	}
+	return fmt.Errorf("--targets and --all-domains are not supported by %s", cmd.CommandPath())
+}
+
+// multiTarget reports whether --targets or --all-domains is given
+func multiTarget(cmd *cobra.Command) bool {
+	targets, _ := cmd.Flags().GetStringSlice("targets")
+	allDomains, _ := cmd.Flags().GetBool("all-domains")
+	return len(targets) > 0 || allDomains
+}
+
+// resolveTargets returns the domains selected by --targets or discovered
+// from the trust list with --all-domains
+func resolveTargets(ctx context.Context, cmd *cobra.Command) ([]queryTarget, error) {
+	cfg := GetConfig()
+	names, _ := cmd.Flags().GetStringSlice("targets")
+	allDomains, _ := cmd.Flags().GetBool("all-domains")
+	if len(names) > 0 && allDomains {
+		return nil, fmt.Errorf("--targets and --all-domains are mutually exclusive")
+	}
+	if allDomains {
+		return discoverDomains(ctx, cfg.LDAP)
+	}
+	return configuredTargets(cfg, names)
+}
+
+// configuredTargets returns the named targets of the config, or all of
+// them for "all", in config order
+func configuredTargets(cfg AppConfig, names []string) ([]queryTarget, error) {
+	if len(cfg.Targets) == 0 {
+		return nil, fmt.Errorf("no targets configured; add a targets list to %s", defaultConfigFileName)
+	}
+
+	var targets []queryTarget
+	for _, name := range names {
+		if !strings.EqualFold(name, targetsAll) && !slices.ContainsFunc(cfg.Targets, func(t TargetConfig) bool {
+			return strings.EqualFold(t.Name, name)
+		}) {
+			return nil, fmt.Errorf("unknown target '%s'", name)
+		}
+	}
+	for _, t := range cfg.Targets {
+		selected := slices.ContainsFunc(names, func(name string) bool {
+			return strings.EqualFold(name, targetsAll) || strings.EqualFold(name, t.Name)
+		})
+		if !selected {
+			continue
+		}
+		target, err := newQueryTarget(t, cfg.LDAP)
+		if err != nil {
+			return nil, err
+		}
+		targets = append(targets, target)
+	}
+	return targets, nil
+}
+
+// newQueryTarget fills the unset fields of a target from the ldap section
+func newQueryTarget(t TargetConfig, base connect.Config) (queryTarget, error) {
+	if t.Server == "" || t.BaseDN == "" {
+		return queryTarget{}, fmt.Errorf("target '%s' needs a server and baseDN", t.Name)
+	}
+
+	c := base
+	c.Server = t.Server
+	c.BaseDN = t.BaseDN
+	if t.Port != 0 {
+		c.Port = t.Port
+	}
+	if t.Security != nil {
+		c.Security = *t.Security
+	}
+	if t.Username != "" {
+		c.Username = t.Username
+		c.Password = t.Password
+		c.LoginName = t.LoginName
+	} else if upn, err := homeUPN(base); err == nil {
+		// Inherited credentials keep the domain of the ldap section
+		c.Username = upn
+	}
+
+	domain := t.Name
+	if domain == "" {
+		domain, _ = connect.BaseDNToDomain(t.BaseDN)
+	}
+	return queryTarget{Domain: domain, Config: c}, nil
+}
+
+// homeUPN returns the bind username of c qualified with its own domain, so
+// it can bind to other domains of the forest. Fails for sAMAccountName logins.
+func homeUPN(c connect.Config) (string, error) {
+	if c.LoginName == connect.SAMAccountName {
+		return "", fmt.Errorf("sAMAccountName logins are not qualified")
+	}
+	return connect.UserPrincipal(c.BaseDN, c.Username)
+}
+
+// discoverDomains returns the configured domain followed by every domain
+// reachable through within-forest trusts, found breadth-first. Each domain
+// is queried through its DNS name with the credentials of the ldap section.
+func discoverDomains(ctx context.Context, base connect.Config) ([]queryTarget, error) {
+	home, err := connect.BaseDNToDomain(base.BaseDN)
+	if err != nil {
+		return nil, err
+	}
+	derived := base
+	if upn, err := homeUPN(base); err == nil {
+		derived.Username = upn
+	}
+
+	targets := []queryTarget{{Domain: home, Config: base}}
+	seen := map[string]bool{strings.ToLower(home): true}
+	for i := 0; i < len(targets); i++ {
+		partners, err := forestTrusts(ctx, targets[i].Config)
+		if err != nil {
+			if i == 0 {
+				return nil, fmt.Errorf("listing trusts: %w", err)
+			}
+			log.Warnf("Listing trusts of %s: %v", targets[i].Domain, err)
+			continue
+		}
+		for _, partner := range partners {
+			if seen[strings.ToLower(partner)] {
+				continue
+			}
+			seen[strings.ToLower(partner)] = true
+
+			baseDN, err := connect.DomainToBaseDN(partner)
+			if err != nil {
+				continue
+			}
+			c := derived
+			c.Server = partner
+			c.BaseDN = baseDN
+			targets = append(targets, queryTarget{Domain: partner, Config: c})
+		}
+	}
+
+	names := make([]string, len(targets))
+	for i, t := range targets {
+		names[i] = t.Domain
+	}
+	log.Infof("Discovered %d domains: %s", len(targets), strings.Join(names, ", "))
+	return targets, nil
+}
+
+// forestTrusts returns the DNS names of the enabled within-forest trust
+// partners of a domain
+func forestTrusts(ctx context.Context, c connect.Config) ([]string, error) {
+	client, err := newClient(&c)
+	if err != nil {
+		return nil, err
+	}
+	defer client.Close()
+
+	entries, err := client.Search(ctx, fmt.Sprintf("(%s=trustedDomain)", analyze.AttrObjectClass), []string{
+		analyze.AttrTrustPartner,
+		analyze.AttrTrustAttributes,
+		analyze.AttrTrustDirection,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var partners []string
+	for _, e := range entries {
+		attrs, _ := strconv.Atoi(e.GetAttributeValue(analyze.AttrTrustAttributes))
+		direction, _ := strconv.Atoi(e.GetAttributeValue(analyze.AttrTrustDirection))
+		partner := e.GetAttributeValue(analyze.AttrTrustPartner)
+		if partner != "" && attrs&analyze.TRUST_ATTRIBUTE_WITHIN_FOREST != 0 && direction != analyze.TRUST_DIRECTION_DISABLED {
+			partners = append(partners, partner)
+		}
+	}
+	return partners, nil
+}
+
+// targetFilters returns the filter sent to each target. Quick queries with
+// a {domain} or {baseDN} parameter are built again for the base DN of each
+// target, so they name the groups of that domain; other filters are the
+// same for every target.
+func targetFilters(cmd *cobra.Command, targets []queryTarget, filter string) ([]string, error) {
+	filters := make([]string, len(targets))
+	q, ok := queries.Get(queryName(cmd))
+	perDomain := ok && (strings.Contains(q.Filter, "{"+queries.ParamDomain+"}") || strings.Contains(q.Filter, "{"+queries.ParamBaseDN+"}"))
+	for i, t := range targets {
+		filters[i] = filter
+		if !perDomain {
+			continue
+		}
+		built, err := buildQuery(cmd, q, t.Config.BaseDN)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", t.Domain, err)
+		}
+		filters[i] = built.Filter
+	}
+	return filters, nil
+}
+
+// streamTargets runs a search against each target in turn, with the filter
+// of the same index, and merges the results into one stream, adding a
+// domain attribute to every entry. Failed targets are logged and reported
+// together once the stream ends.
+func streamTargets(ctx context.Context, targets []queryTarget, filters []string, attributes []string) (<-chan *ldap.Entry, <-chan error) {
+	entries := make(chan *ldap.Entry)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(entries)
+		var errs []error
+		for i, t := range targets {
+			if err := streamTarget(ctx, t, filters[i], attributes, entries); err != nil {
+				if ctx.Err() != nil {
+					errChan <- ctx.Err()
+					return
+				}
+				log.Warnf("Target %s failed: %v", t.Domain, err)
+				errs = append(errs, fmt.Errorf("%s: %w", t.Domain, err))
+			}
+		}
+		errChan <- errors.Join(errs...)
+	}()
+
+	return entries, errChan
+}
+
+// streamTarget forwards the entries of one target to out
+func streamTarget(ctx context.Context, t queryTarget, filter string, attributes []string, out chan<- *ldap.Entry) error {
+	client, err := newClient(&t.Config)
+	if err != nil {
+		return err
+	}
+	defer client.Close()
+
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	in, errChan := client.StreamSearch(ctx, filter, attributes)
+	for e := range in {
+		e.Attributes = append(e.Attributes, ldap.NewEntryAttribute(targetDomainAttribute, []string{t.Domain}))
+		select {
+		case out <- e:
+		case <-ctx.Done():
+			return ctx.Err()
+		}
+	}
+	return <-errChan
+}
+
+// planTargets prints the search request sent to each configured target, as
+// a JSON object keyed by domain with -o json
+func planTargets(ctx context.Context, cmd *cobra.Command, filter string, attributes []string) error {
+	if allDomains, _ := cmd.Flags().GetBool("all-domains"); allDomains {
+		return fmt.Errorf("--all-domains needs a connection to discover domains and cannot be used with --dry-run")
+	}
+	names, _ := cmd.Flags().GetStringSlice("targets")
+	targets, err := configuredTargets(GetConfig(), names)
+	if err != nil {
+		return err
+	}
+	filters, err := targetFilters(cmd, targets, filter)
+	if err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = GetConfig().Output
+	}
+	if format == analyze.OutputFormatJSON {
+		plans := make(map[string]connect.SearchPlan, len(targets))
+		for i, t := range targets {
+			plans[t.Domain] = connect.PlanSearch(ctx, &t.Config, filters[i], attributes)
+		}
+		enc := json.NewEncoder(cmd.OutOrStdout())
+		enc.SetIndent("", "  ")
+		enc.SetEscapeHTML(false) // Keep filters copyable
+		return enc.Encode(plans)
+	}
+
+	for i, t := range targets {
+		if i > 0 {
+			fmt.Fprintln(cmd.OutOrStdout())
+		}
+		fmt.Fprintf(cmd.OutOrStdout(), "# %s (%s)\n", t.Domain, t.Config.Server)
+		if err := printSearchPlan(cmd, connect.PlanSearch(ctx, &t.Config, filters[i], attributes)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func init() {
+	rootCmd.PersistentFlags().StringSlice("targets", nil, "Run the query against these configured targets (comma-separated, or all) with a domain column")
+	rootCmd.PersistentFlags().Bool("all-domains", false, "Run the query against every domain of the forest, discovered from the trust list")
+}
@@ -0,0 +1,118 @@
+package cmd
+
+import (
+	"adgo/log"
+	"adgo/output"
+	"adgo/tracing"
+	"context"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/spf13/cobra"
+)
+
+var (
+	// commandSpan spans the command when tracing is enabled
+	commandSpan *tracing.Span
+
+	// stopTracing exports the remaining spans
+	stopTracing func(context.Context) error
+)
+
+// setupTracing exports spans to --otlp-endpoint or the collector of the
+// OTEL_EXPORTER_OTLP_* environment variables, and starts the command span
+func setupTracing(cmd *cobra.Command) error {
+	c := tracing.ConfigFromEnv()
+	if endpoint, _ := cmd.Flags().GetString("otlp-endpoint"); endpoint != "" {
+		c.Endpoint = tracing.TracesURL(endpoint)
+	}
+	if c.Endpoint == "" || cmd.Annotations[annotationOffline] != "" {
+		return nil
+	}
+	c.Version, _, _ = buildInfo()
+
+	stop, err := tracing.Enable(c)
+	if err != nil {
+		return err
+	}
+	stopTracing = stop
+	useMiddleware(tracing.Middleware())
+
+	ctx, span := tracing.Start(cmd.Context(), cmd.CommandPath(),
+		tracing.String("adgo.args", redactArgs(os.Args[1:])))
+	commandSpan = span
+	cmd.SetContext(ctx)
+	log.Debugf("Exporting traces to %s (trace %s)", c.Endpoint, tracing.TraceID(ctx))
+	return nil
+}
+
+// closeTracing ends the command span with err and exports the remaining spans
+func closeTracing(err error) {
+	if stopTracing == nil {
+		return
+	}
+	commandSpan.SetError(err)
+	commandSpan.End()
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+	if err := stopTracing(ctx); err != nil {
+		log.Warnf("Tracing: %v", err)
+	}
+	commandSpan, stopTracing = nil, nil
+}
+
+// redactArgs joins the command line, masking the value of --password
+func redactArgs(args []string) string {
+	out := make([]string, len(args))
+	for i, arg := range args {
+		switch {
+		case i > 0 && (args[i-1] == "--password" || args[i-1] == "-w"):
+			out[i] = "***"
+		case strings.HasPrefix(arg, "--password="):
+			out[i] = "--password=***"
+		case strings.HasPrefix(arg, "-w") && len(arg) > 2:
+			out[i] = "-w***"
+		default:
+			out[i] = arg
+		}
+	}
+	return strings.Join(out, " ")
+}
+
+// tracedPrinter records the printing of results as an "output" span
+type tracedPrinter struct {
+	ctx    context.Context
+	next   output.Printer
+	format string
+}
+
+// withTracing wraps p so printing is traced as a child of the span in ctx
+func withTracing(ctx context.Context, p output.Printer, format string) output.Printer {
+	if tracing.FromContext(ctx) == nil {
+		return p
+	}
+	return &tracedPrinter{ctx: ctx, next: p, format: format}
+}
+
+func (p *tracedPrinter) Print(entries []*ldap.Entry) error {
+	_, span := tracing.Start(p.ctx, "output", tracing.String("adgo.format", p.format), tracing.Int("adgo.entries", len(entries)))
+	defer span.End()
+	err := p.next.Print(entries)
+	span.SetError(err)
+	return err
+}
+
+func (p *tracedPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	_, span := tracing.Start(p.ctx, "output", tracing.String("adgo.format", p.format))
+	defer span.End()
+	err := p.next.StreamPrint(entriesChan)
+	span.SetError(err)
+	return err
+}
+
+func init() {
+	rootCmd.PersistentFlags().String("otlp-endpoint", "", "Export traces to this OpenTelemetry collector (OTLP/HTTP, e.g. http://localhost:4318)")
+}
@@ -0,0 +1,174 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/log"
+	"adgo/queries"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// unconstrainedCmd represents the unconstrained command
+var unconstrainedCmd = &cobra.Command{
+	Use:   "unconstrained",
+	Short: "Report unconstrained delegation exposure outside domain controllers",
+	Long: "Unconstrained runs the unconstraineddelegate query and separates domain " +
+		"controllers, where unconstrained delegation is expected, from member servers, " +
+		"workstations and users, which are findings: whoever controls them can coerce a DC " +
+		"to authenticate (PrinterBug, PetitPotam) and capture its TGT. Findings are ranked by " +
+		"how likely coercion succeeds: enabled computers that logged on within 30 days and " +
+		"have a DNS name first, then stale computers and users with SPNs, then users without " +
+		"SPNs and disabled accounts. The operating system and lastLogonTimestamp are shown. " +
+		"Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runUnconstrained(cmd)
+	},
+}
+
+// runUnconstrained collects and prints the unconstrained delegation report
+func runUnconstrained(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.UnconstrainedAccount) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeUnconstrainedTable
+	case analyze.OutputFormatJSON:
+		write = writeUnconstrainedJSON
+	case analyze.OutputFormatCSV:
+		write = writeUnconstrainedCSV
+	default:
+		return fmt.Errorf("unconstrained output must be text, table, json or csv")
+	}
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	q, _ := queries.Get("unconstraineddelegate")
+	entries, err := ldapClient.Search(cmd.Context(), q.Filter, analyze.UnconstrainedAttributes)
+	if err != nil {
+		return fmt.Errorf("searching unconstrained delegation: %w", err)
+	}
+	accounts := analyze.UnconstrainedExposure(entries, time.Now())
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, accounts) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Unconstrained delegation report generated: %s (%d accounts)", path, len(accounts))
+	return nil
+}
+
+// unconstrainedLastLogon formats the last logon of an account
+func unconstrainedLastLogon(a analyze.UnconstrainedAccount) string {
+	if a.LastLogon.IsZero() {
+		return "never"
+	}
+	return analyze.FormatTime(a.LastLogon)
+}
+
+// unconstrainedOS returns the operating system of an account, or "-"
+func unconstrainedOS(a analyze.UnconstrainedAccount) string {
+	if a.OperatingSystem == "" {
+		return "-"
+	}
+	return a.OperatingSystem
+}
+
+// writeUnconstrainedTable writes the domain controllers, then the
+// findings ranked by coercion likelihood
+func writeUnconstrainedTable(w io.Writer, accounts []analyze.UnconstrainedAccount) error {
+	if len(accounts) == 0 {
+		_, err := fmt.Fprintln(w, "No accounts with unconstrained delegation found")
+		return err
+	}
+
+	var dcs, findings []analyze.UnconstrainedAccount
+	for _, a := range accounts {
+		if a.DC {
+			dcs = append(dcs, a)
+		} else {
+			findings = append(findings, a)
+		}
+	}
+
+	if len(dcs) > 0 {
+		fmt.Fprintln(w, "Domain controllers (expected):")
+		tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+		for _, a := range dcs {
+			fmt.Fprintf(tw, "  %s\t%s\t%s\n", a.Name, unconstrainedOS(a), unconstrainedLastLogon(a))
+		}
+		if err := tw.Flush(); err != nil {
+			return err
+		}
+		fmt.Fprintln(w)
+	}
+
+	if len(findings) == 0 {
+		_, err := fmt.Fprintln(w, "No unconstrained delegation outside domain controllers")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ACCOUNT\tTYPE\tENABLED\tOPERATING SYSTEM\tLAST LOGON\tLIKELIHOOD\tREASONS")
+	high := 0
+	for _, a := range findings {
+		fmt.Fprintf(tw, "%s\t%s\t%t\t%s\t%s\t%s\t%s\n", a.Name, a.Type, a.Enabled, unconstrainedOS(a),
+			unconstrainedLastLogon(a), a.Likelihood, strings.Join(a.Reasons, "; "))
+		if a.Likelihood == analyze.CoercionHigh {
+			high++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d accounts with unconstrained delegation outside domain controllers, %d highly likely coercible\n", len(findings), high)
+	return err
+}
+
+// writeUnconstrainedJSON writes the accounts as an indented JSON array
+func writeUnconstrainedJSON(w io.Writer, accounts []analyze.UnconstrainedAccount) error {
+	if accounts == nil {
+		accounts = []analyze.UnconstrainedAccount{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(accounts)
+}
+
+// writeUnconstrainedCSV writes one row per account, with a header row
+func writeUnconstrainedCSV(w io.Writer, accounts []analyze.UnconstrainedAccount) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "dn", "type", "dc", "enabled", "operatingSystem", "dnsHostName", "lastLogon", "likelihood", "reasons"})
+	for _, a := range accounts {
+		lastLogon := ""
+		if !a.LastLogon.IsZero() {
+			lastLogon = analyze.FormatTime(a.LastLogon)
+		}
+		cw.Write([]string{a.Name, a.DN, a.Type, strconv.FormatBool(a.DC), strconv.FormatBool(a.Enabled),
+			a.OperatingSystem, a.DNSHostName, lastLogon, a.Likelihood, strings.Join(a.Reasons, ";")})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(unconstrainedCmd)
+}
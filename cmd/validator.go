@@ -3,9 +3,11 @@ package cmd
 import (
 	"adgo/analyze"
 	"fmt"
+	"net/url"
 	"regexp"
 	"strconv"
 	"strings"
+	"time"
 )
 
 const (
@@ -163,13 +165,77 @@ func ValidateSecurityModeString(modeStr string) error {
 // ValidateOutputFormat validates that the output format is supported.
 func ValidateOutputFormat(format string) error {
 	switch format {
-	case analyze.OutputFormatText, analyze.OutputFormatJSON, analyze.OutputFormatCSV, "bloodhound", "bh":
+	case analyze.OutputFormatText, analyze.OutputFormatTable, analyze.OutputFormatJSON, analyze.OutputFormatJSONL, "ndjson", analyze.OutputFormatRaw, analyze.OutputFormatLDIF, analyze.OutputFormatGrep, analyze.OutputFormatCSV, analyze.OutputFormatXLSX, analyze.OutputFormatHTML, analyze.OutputFormatTemplate, analyze.OutputFormatStats, analyze.OutputFormatDOT, analyze.OutputFormatMermaid, analyze.OutputFormatSplunk, analyze.OutputFormatElastic, analyze.OutputFormatHashcat, "bloodhound", "bh", "bloodhound-ce", "bhce":
 		return nil
 	default:
-		return fmt.Errorf("output format must be text, json, csv, or bloodhound")
+		return fmt.Errorf("output format must be text, table, json, jsonl, raw, ldif, grep, csv, xlsx, html, template, stats, dot, mermaid, bloodhound, bloodhound-ce, splunk, elastic, or hashcat (john is only an output format of roast)")
 	}
 }
 
+// ValidateTimeZone validates that a time zone is UTC, Local or a known IANA zone name.
+func ValidateTimeZone(zone string) error {
+	if _, err := time.LoadLocation(zone); err != nil {
+		return fmt.Errorf("time zone must be UTC, Local or an IANA name such as Europe/Berlin")
+	}
+	return nil
+}
+
+// ValidateWebhookURL validates that a notification webhook URL uses http or https.
+func ValidateWebhookURL(rawURL string) error {
+	if rawURL == "" {
+		return nil // Empty URL disables notifications
+	}
+	u, err := url.Parse(rawURL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return fmt.Errorf("webhook URL must be an http or https URL")
+	}
+	return nil
+}
+
+// ValidateMinScoreString validates a notification score threshold provided as a string.
+func ValidateMinScoreString(scoreStr string) error {
+	s, err := strconv.Atoi(scoreStr)
+	if err != nil || s < 0 {
+		return fmt.Errorf("minimum score must be a non-negative number")
+	}
+	return nil
+}
+
+// ValidateBoolString validates a boolean setting provided as a string.
+func ValidateBoolString(boolStr string) error {
+	if _, err := strconv.ParseBool(boolStr); err != nil {
+		return fmt.Errorf("value must be true or false")
+	}
+	return nil
+}
+
+// ValidateDurationString validates a positive duration setting such as "10m".
+func ValidateDurationString(durationStr string) error {
+	d, err := time.ParseDuration(durationStr)
+	if err != nil || d <= 0 {
+		return fmt.Errorf("value must be a positive duration such as 30s, 10m or 1h")
+	}
+	return nil
+}
+
+// ValidateCountString validates an integer setting of at least min provided as a string.
+func ValidateCountString(countStr string, min int) error {
+	n, err := strconv.Atoi(countStr)
+	if err != nil || n < min {
+		return fmt.Errorf("value must be a whole number of at least %d", min)
+	}
+	return nil
+}
+
+// ValidateMultiplierString validates a backoff multiplier of at least 1 provided as a string.
+func ValidateMultiplierString(multiplierStr string) error {
+	m, err := strconv.ParseFloat(multiplierStr, 64)
+	if err != nil || m < 1 {
+		return fmt.Errorf("multiplier must be a number of at least 1")
+	}
+	return nil
+}
+
 // ValidateBaseDN validates that a base DN string appears to be a valid distinguished name.
 // This is a basic check - it only verifies that "DC=" is present.
 func ValidateBaseDN(dn string) error {
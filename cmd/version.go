@@ -0,0 +1,127 @@
+package cmd
+
+import (
+	"adgo/connect"
+	"adgo/queries"
+	"fmt"
+	"os"
+	"runtime"
+	"runtime/debug"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// Build metadata, injected at build time with
+//
+//	go build -ldflags "-X adgo/cmd.Version=v1.2.0 -X adgo/cmd.Commit=$(git rev-parse --short HEAD) -X adgo/cmd.BuildDate=$(date -u +%Y-%m-%dT%H:%M:%SZ)"
+//
+// Values left unset fall back to the module and VCS information Go embeds.
+var (
+	Version   = ""
+	Commit    = ""
+	BuildDate = ""
+)
+
+// versionCmd represents the version command
+var versionCmd = &cobra.Command{
+	Use:   "version",
+	Short: "Print version, build metadata and enabled features",
+	Long: "Version prints the adgo version, git commit, build date, Go version and platform, " +
+		"followed by the features enabled in this build and configuration. Include it in " +
+		"bug reports and engagement notes.",
+	Args:        cobra.NoArgs,
+	Annotations: map[string]string{annotationOffline: "true"},
+	Run: func(cmd *cobra.Command, args []string) {
+		fmt.Fprint(cmd.OutOrStdout(), versionText())
+	},
+}
+
+// buildInfo returns Version, Commit and BuildDate, filled in from the
+// embedded build information where they were not injected
+func buildInfo() (version, commit, date string) {
+	version, commit, date = Version, Commit, BuildDate
+	if bi, ok := debug.ReadBuildInfo(); ok {
+		if version == "" && bi.Main.Version != "(devel)" {
+			version = bi.Main.Version
+		}
+		modified := false
+		for _, s := range bi.Settings {
+			switch s.Key {
+			case "vcs.revision":
+				if commit == "" {
+					commit = s.Value
+				}
+			case "vcs.time":
+				if date == "" {
+					date = s.Value
+				}
+			case "vcs.modified":
+				modified = s.Value == "true"
+			}
+		}
+		// Only mark revisions taken from the build information
+		if modified && Commit == "" && commit != "" {
+			commit += "-dirty"
+		}
+	}
+	if version == "" {
+		version = "dev"
+	}
+	if commit == "" {
+		commit = "unknown"
+	}
+	if date == "" {
+		date = "unknown"
+	}
+	return version, commit, date
+}
+
+// enabledFeatures lists the optional features of this build and the loaded
+// configuration as name/value pairs
+func enabledFeatures() [][2]string {
+	cfg := GetConfig()
+	onOff := func(on bool) string {
+		if on {
+			return "enabled"
+		}
+		return "disabled"
+	}
+
+	return [][2]string{
+		{"keyring", connect.KeyringBackend()},
+		{"queries", fmt.Sprintf("%d registered (%d custom)", len(queries.GetNames()), len(cfg.Queries.Custom))},
+		{"query packs", onOff(cfg.Queries.Dir != "")},
+		{"plugins", onOff(pluginsEnabled(os.Args[1:]))},
+		{"profiles", fmt.Sprintf("%d configured", len(cfg.Profiles))},
+		{"result cache", onOff(cfg.Cache.Enabled)},
+		{"notifications", onOff(cfg.Notify.URL != "")},
+	}
+}
+
+// versionText renders the output of adgo version and adgo --version
+func versionText() string {
+	version, commit, date := buildInfo()
+
+	var sb strings.Builder
+	w := tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	fmt.Fprintf(w, "adgo\t%s\n", version)
+	fmt.Fprintf(w, "commit\t%s\n", commit)
+	fmt.Fprintf(w, "built\t%s\n", date)
+	fmt.Fprintf(w, "go\t%s\n", runtime.Version())
+	fmt.Fprintf(w, "platform\t%s/%s\n", runtime.GOOS, runtime.GOARCH)
+	w.Flush()
+
+	sb.WriteString("\nFeatures:\n")
+	w = tabwriter.NewWriter(&sb, 0, 0, 2, ' ', 0)
+	for _, f := range enabledFeatures() {
+		fmt.Fprintf(w, "  %s\t%s\n", f[0], f[1])
+	}
+	w.Flush()
+	return sb.String()
+}
+
+func init() {
+	rootCmd.AddCommand(versionCmd)
+}
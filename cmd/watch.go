@@ -0,0 +1,101 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"adgo/snapshot"
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"github.com/spf13/cobra"
+)
+
+// minWatchInterval keeps watch mode from hammering the domain controller
+const minWatchInterval = 10 * time.Second
+
+// runWatch re-runs a search every interval and prints only the objects that
+// were added, removed or modified since the previous run. The first run
+// establishes the baseline. Watch mode ends on Ctrl+C.
+func runWatch(ctx context.Context, cmd *cobra.Command, ldapClient connect.Client, filter string, attributes []string, interval time.Duration) error {
+	if interval < minWatchInterval {
+		return fmt.Errorf("watch interval must be at least %s", minWatchInterval)
+	}
+
+	ctx, stop := signal.NotifyContext(ctx, os.Interrupt, syscall.SIGTERM)
+	defer stop()
+
+	// objectGUID matches objects across renames
+	attributes = withAttributes(attributes, analyze.AttrObjectGUID, analyze.AttrObjectClass)
+
+	format, _ := cmd.Flags().GetString("output")
+	jsonOutput := format == analyze.OutputFormatJSON || format == analyze.OutputFormatJSONL
+	out := cmd.OutOrStdout()
+
+	prev, err := watchSearch(ctx, ldapClient, filter, attributes)
+	if err != nil {
+		return err
+	}
+	log.Infof("Watching %d objects every %s (Ctrl+C to stop)", len(prev), interval)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			log.Info("Watch stopped")
+			return nil
+		case <-ticker.C:
+		}
+
+		cur, err := watchSearch(ctx, ldapClient, filter, attributes)
+		if err != nil {
+			if ctx.Err() != nil {
+				continue
+			}
+			// Keep watching through transient failures; the next tick retries
+			log.Warnf("Watch search failed: %v", err)
+			continue
+		}
+
+		now := time.Now()
+		for _, c := range snapshot.Diff(prev, cur) {
+			log.Warnf("Change detected: %s", c.String())
+			if jsonOutput {
+				data, err := json.Marshal(struct {
+					Time time.Time `json:"time"`
+					snapshot.Change
+				}{now, c})
+				if err != nil {
+					return fmt.Errorf("marshaling change: %w", err)
+				}
+				fmt.Fprintln(out, string(data))
+				continue
+			}
+			fmt.Fprintf(out, "%s %s\n", analyze.FormatTime(now), c.String())
+		}
+		prev = cur
+	}
+}
+
+// watchSearch runs one watch iteration and normalizes the results
+func watchSearch(ctx context.Context, ldapClient connect.Client, filter string, attributes []string) ([]snapshot.Object, error) {
+	entries, err := ldapClient.Search(ctx, filter, attributes)
+	if err != nil {
+		return nil, fmt.Errorf("executing query: %w", err)
+	}
+
+	objects := make([]snapshot.Object, 0, len(entries))
+	for _, e := range entries {
+		if e != nil {
+			objects = append(objects, snapshot.NewObject(e))
+		}
+	}
+	return objects, nil
+}
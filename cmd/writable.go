@@ -0,0 +1,182 @@
+package cmd
+
+import (
+	"adgo/analyze"
+	"adgo/connect"
+	"adgo/log"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/spf13/cobra"
+)
+
+// writableCmd represents the writable command
+var writableCmd = &cobra.Command{
+	Use:   "writable",
+	Short: "List the objects and attributes the bound account can modify",
+	Long: "Writable reads the constructed allowedAttributesEffective, allowedChildClassesEffective " +
+		"and sDRightsEffective attributes of every candidate object (accounts, groups, OUs, GPOs " +
+		"and the domain, or the objects of --filter) with a base-scope search each. The DC " +
+		"evaluates the ACLs for the bound account, group memberships included, so the report " +
+		"shows exactly which attributes it can write, which objects it can create and whether " +
+		"it can change owners or DACLs. Writable attributes that enable attacks (member, " +
+		"servicePrincipalName, msDS-KeyCredentialLink, RBCD, gPCFileSysPath and others) are " +
+		"flagged; --abusable only lists those objects. One search is sent per candidate, so " +
+		"narrow large domains with --filter. Supports text/table, json and csv output.",
+	RunE: func(cmd *cobra.Command, args []string) error {
+		return runWritable(cmd)
+	},
+}
+
+// runWritable collects and prints the objects the bound account can modify
+func runWritable(cmd *cobra.Command) error {
+	cfg := GetConfig()
+	if err := ValidateServer(cfg.LDAP.Server); err != nil {
+		return err
+	}
+
+	format, _ := cmd.Flags().GetString("output")
+	if format == "" {
+		format = cfg.Output
+	}
+	var write func(io.Writer, []analyze.WritableObject) error
+	switch format {
+	case analyze.OutputFormatText, analyze.OutputFormatTable:
+		write = writeWritableTable
+	case analyze.OutputFormatJSON:
+		write = writeWritableJSON
+	case analyze.OutputFormatCSV:
+		write = writeWritableCSV
+	default:
+		return fmt.Errorf("writable output must be text, table, json or csv")
+	}
+	filter, _ := cmd.Flags().GetString("filter")
+	if filter == "" {
+		filter = analyze.WritableCandidateFilter
+	}
+	abusableOnly, _ := cmd.Flags().GetBool("abusable")
+
+	ldapClient, err := newClient(&cfg.LDAP)
+	if err != nil {
+		return fmt.Errorf("creating LDAP client: %w", err)
+	}
+	defer ldapClient.Close()
+
+	objects, err := collectWritable(cmd.Context(), ldapClient, filter)
+	if err != nil {
+		return err
+	}
+	if abusableOnly {
+		var abusable []analyze.WritableObject
+		for _, o := range objects {
+			if o.Abuse() {
+				abusable = append(abusable, o)
+			}
+		}
+		objects = abusable
+	}
+
+	path, err := writeReport(cmd, format, 0, func(w io.Writer) error { return write(w, objects) })
+	if err != nil || path == "" {
+		return err
+	}
+	log.Infof("Writable objects report generated: %s (%d objects)", path, len(objects))
+	return nil
+}
+
+// collectWritable reads the effective rights of the bound account on every
+// object matching filter. Objects whose rights cannot be read are logged
+// and skipped.
+func collectWritable(ctx context.Context, client connect.Client, filter string) ([]analyze.WritableObject, error) {
+	candidates, err := client.Search(ctx, filter, analyze.WritableCandidateAttributes)
+	if err != nil {
+		return nil, fmt.Errorf("searching candidate objects: %w", err)
+	}
+	log.Infof("Checking the effective rights on %d objects", len(candidates))
+
+	var objects []analyze.WritableObject
+	for _, c := range candidates {
+		entries, err := client.Search(connect.WithBaseObject(ctx, c.DN), "(objectClass=*)", analyze.WritableAttributes)
+		if err != nil {
+			if ctx.Err() != nil {
+				return objects, ctx.Err()
+			}
+			log.Warnf("Reading the effective rights on %s: %v", c.DN, err)
+			continue
+		}
+		if len(entries) == 0 {
+			continue
+		}
+		if o, ok := analyze.NewWritableObject(c, entries[0]); ok {
+			objects = append(objects, o)
+		}
+	}
+	return objects, nil
+}
+
+// writableSummary joins the abuses of an object and its writable
+// security descriptor parts, or returns "-"
+func writableSummary(o analyze.WritableObject) string {
+	items := append(append([]string{}, o.SDRights...), o.Abusable...)
+	if len(items) == 0 {
+		return "-"
+	}
+	return strings.Join(items, ", ")
+}
+
+// writeWritableTable writes the writable objects as an aligned table
+func writeWritableTable(w io.Writer, objects []analyze.WritableObject) error {
+	if len(objects) == 0 {
+		_, err := fmt.Fprintln(w, "No writable objects found")
+		return err
+	}
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "OBJECT\tCLASS\tATTRIBUTES\tCHILD CLASSES\tABUSE")
+	abusable := 0
+	for _, o := range objects {
+		fmt.Fprintf(tw, "%s\t%s\t%d\t%d\t%s\n", o.Name, o.Class, len(o.Attributes), len(o.ChildClasses), writableSummary(o))
+		if o.Abuse() {
+			abusable++
+		}
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+	_, err := fmt.Fprintf(w, "\n%d writable objects, %d abusable\n", len(objects), abusable)
+	return err
+}
+
+// writeWritableJSON writes the writable objects as an indented JSON array
+func writeWritableJSON(w io.Writer, objects []analyze.WritableObject) error {
+	if objects == nil {
+		objects = []analyze.WritableObject{}
+	}
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(objects)
+}
+
+// writeWritableCSV writes one row per writable object, with a header row
+func writeWritableCSV(w io.Writer, objects []analyze.WritableObject) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"name", "dn", "class", "sdRights", "abusable", "attributes", "childClasses", "attributeCount"})
+	for _, o := range objects {
+		cw.Write([]string{o.Name, o.DN, o.Class, strings.Join(o.SDRights, ";"), strings.Join(o.Abusable, ";"),
+			strings.Join(o.Attributes, ";"), strings.Join(o.ChildClasses, ";"), strconv.Itoa(len(o.Attributes))})
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+func init() {
+	rootCmd.AddCommand(writableCmd)
+
+	writableCmd.Flags().StringP("filter", "f", "", "LDAP filter of the objects to check (default: accounts, groups, OUs, GPOs and the domain)")
+	writableCmd.Flags().Bool("abusable", false, "Only list objects with an abusable attribute or a writable owner or DACL")
+}
@@ -0,0 +1,152 @@
+// Package collector is the library API of adgo. It connects to Active
+// Directory, runs registered queries or raw LDAP filters and returns typed
+// results with adgo's attribute decoding and target scoring, without the
+// command line layer.
+//
+//	c, err := collector.Connect(collector.Config{
+//		Server:   "dc01.corp.local",
+//		BaseDN:   "DC=corp,DC=local",
+//		Username: "auditor",
+//		Password: os.Getenv("ADGO_PASSWORD"),
+//		Security: collector.SecurityTLS,
+//	})
+//	if err != nil {
+//		return err
+//	}
+//	defer c.Close()
+//
+//	res, err := c.RunNamedQuery(ctx, "kerberoasting", nil)
+package collector
+
+import (
+	"adgo/connect"
+	"adgo/queries"
+	"context"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// Config holds the connection settings; it is the same structure as the
+// ldap section of adgo.yaml
+type Config = connect.Config
+
+// Connection security modes of Config.Security
+const (
+	SecurityNone             = connect.SecurityNone
+	SecurityTLS              = connect.SecurityTLS
+	SecurityStartTLS         = connect.SecurityStartTLS
+	SecurityInsecureTLS      = connect.SecurityInsecureTLS
+	SecurityInsecureStartTLS = connect.SecurityInsecureStartTLS
+)
+
+// Collector runs queries over one LDAP connection. It is safe for
+// concurrent use when the underlying client is.
+type Collector struct {
+	client connect.Client
+	baseDN string
+}
+
+// Connect dials and binds to the server of cfg, retrying transient
+// failures with the default retry policy
+func Connect(cfg Config) (*Collector, error) {
+	return ConnectWithRetry(cfg, connect.DefaultRetryConfig())
+}
+
+// ConnectWithRetry is Connect with an explicit retry policy
+func ConnectWithRetry(cfg Config, retryCfg connect.RetryConfig) (*Collector, error) {
+	if cfg.Server == "" {
+		return nil, fmt.Errorf("LDAP server is not configured")
+	}
+	if cfg.BaseDN == "" {
+		return nil, fmt.Errorf("base DN is not configured")
+	}
+
+	client, err := connect.NewClientWithRetry(&cfg, retryCfg)
+	if err != nil {
+		return nil, err
+	}
+	return New(client, cfg.BaseDN), nil
+}
+
+// New returns a collector over an existing client, such as a pooling,
+// caching or replay client. baseDN fills in the domain and base DN
+// parameters of named queries.
+func New(client connect.Client, baseDN string) *Collector {
+	return &Collector{client: client, baseDN: baseDN}
+}
+
+// Close closes the underlying connection
+func (c *Collector) Close() error {
+	return c.client.Close()
+}
+
+// QueryNames returns the names of all registered queries, sorted
+func QueryNames() []string {
+	return queries.GetNames()
+}
+
+// RunNamedQuery runs a registered query. params sets its {name}
+// placeholders; the domain and base DN default to the collector's base DN.
+// Queries of the configuration or schema partition search the partition
+// named in the RootDSE of the server. Returns an error for unknown queries
+// and missing required parameters.
+func (c *Collector) RunNamedQuery(ctx context.Context, name string, params map[string]string) (*Result, error) {
+	q, ok := queries.Get(name)
+	if !ok {
+		return nil, fmt.Errorf("unknown query %q", name)
+	}
+
+	b := queries.NewQueryBuilder(q).WithParam(queries.ParamDomain, c.baseDN).WithBaseDN(c.baseDN)
+	for k, v := range params {
+		b.WithParam(k, v)
+	}
+	if missing := b.MissingParams(); len(missing) > 0 {
+		return nil, fmt.Errorf("query %s requires %s", name, strings.Join(missing, ", "))
+	}
+
+	built := b.Build()
+	baseDN := c.baseDN
+	if q.Partition != "" {
+		var err error
+		if baseDN, err = connect.ReadNamingContext(ctx, c.client, queries.NamingContext(q.Partition)); err != nil {
+			return nil, fmt.Errorf("query %s: finding the %s partition: %w", name, q.Partition, err)
+		}
+		ctx = connect.WithSearchBase(ctx, baseDN)
+	}
+	res, err := c.runFilter(ctx, baseDN, built.Filter, built.Attributes)
+	if res != nil {
+		res.Query = name
+	}
+	return res, err
+}
+
+// RunFilter runs a raw LDAP filter below the base DN, returning attributes
+// (all user attributes when empty). Entries received before a search error
+// are returned along with it.
+func (c *Collector) RunFilter(ctx context.Context, filter string, attributes []string) (*Result, error) {
+	return c.runFilter(ctx, c.baseDN, filter, attributes)
+}
+
+// runFilter runs filter with ctx, which searches below baseDN
+func (c *Collector) runFilter(ctx context.Context, baseDN, filter string, attributes []string) (*Result, error) {
+	if filter == "" {
+		return nil, fmt.Errorf("filter must not be empty")
+	}
+	if len(attributes) == 0 {
+		attributes = []string{"*"}
+	}
+
+	res := &Result{Filter: filter, BaseDN: baseDN, Attributes: attributes, Started: time.Now()}
+	entries, errs := c.client.StreamSearch(ctx, filter, attributes)
+	for e := range entries {
+		res.Entries = append(res.Entries, newEntry(e))
+	}
+	res.Duration = time.Since(res.Started)
+
+	if err := <-errs; err != nil {
+		res.Partial = len(res.Entries) > 0
+		return res, fmt.Errorf("search %s: %w", filter, err)
+	}
+	return res, nil
+}
@@ -0,0 +1,80 @@
+package collector
+
+import (
+	"adgo/analyze"
+	"adgo/output"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Result is the outcome of one query
+type Result struct {
+	Query      string        `json:"query,omitempty"`   // Registered query name; empty for RunFilter
+	Filter     string        `json:"filter"`            // LDAP filter that was executed
+	BaseDN     string        `json:"baseDN"`            // Search base
+	Attributes []string      `json:"attributes"`        // Requested attributes
+	Started    time.Time     `json:"started"`           // Time the search started
+	Duration   time.Duration `json:"duration"`          // Time until the last entry was received
+	Partial    bool          `json:"partial,omitempty"` // The search failed after some entries were received
+	Entries    []Entry       `json:"entries"`
+}
+
+// HighValue returns the entries that are high-value targets
+func (r *Result) HighValue() []Entry {
+	var entries []Entry
+	for _, e := range r.Entries {
+		if e.HighValue {
+			entries = append(entries, e)
+		}
+	}
+	return entries
+}
+
+// Entry is one directory object
+type Entry struct {
+	DN         string              `json:"dn"`
+	Attributes map[string][]string `json:"attributes"` // Raw values; binary values as received
+	Formatted  map[string]string   `json:"formatted"`  // Decoded values as adgo prints them (flags, times, SIDs, GUIDs)
+	Score      int                 `json:"score"`      // Target value score, as used to order results
+	HighValue  bool                `json:"highValue"`  // Admin account, domain controller or sensitive SPN
+
+	raw *ldap.Entry
+}
+
+// newEntry converts an LDAP entry, decoding its attributes and scoring it
+func newEntry(e *ldap.Entry) Entry {
+	entry := Entry{
+		DN:         e.DN,
+		Attributes: make(map[string][]string, len(e.Attributes)),
+		Formatted:  make(map[string]string, len(e.Attributes)),
+		Score:      output.ScoreEntry(e),
+		HighValue:  output.IsHighValue(e),
+		raw:        e,
+	}
+	for _, attr := range e.Attributes {
+		entry.Attributes[attr.Name] = attr.Values
+		if v, err := analyze.FormatAttributeValue(e, attr.Name); err == nil && v != "" {
+			entry.Formatted[attr.Name] = v
+		}
+	}
+	return entry
+}
+
+// Get returns the first raw value of an attribute, matching its name
+// case-insensitively, or "" if it is not set
+func (e Entry) Get(name string) string {
+	for k, v := range e.Attributes {
+		if strings.EqualFold(k, name) && len(v) > 0 {
+			return v[0]
+		}
+	}
+	return ""
+}
+
+// LDAP returns the entry as received, for use with the analyze and output
+// packages; nil for entries not created by a Collector
+func (e Entry) LDAP() *ldap.Entry {
+	return e.raw
+}
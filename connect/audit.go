@@ -0,0 +1,261 @@
+package connect
+
+import (
+	"bufio"
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// maxAuditLine bounds a single record of an audit trail
+const maxAuditLine = 1 << 20
+
+// Audit trail operations
+const (
+	AuditBind   = "bind"   // A bind attempt
+	AuditSearch = "search" // A search sent through an AuditMiddleware
+)
+
+// AuditRecord is one line of an audit trail. Each record carries the hash
+// of the record before it, so removing or editing a line breaks the chain.
+// The chain is not keyed: anyone able to rewrite the whole file can also
+// recompute it, and dropping the last records leaves a valid chain. Close
+// returns the hash of the last record so it can be kept elsewhere and
+// checked with VerifyAuditLog.
+type AuditRecord struct {
+	Seq        int64     `json:"seq"`                  // Position in the trail, from 1
+	Time       time.Time `json:"time"`                 // Start of the operation, UTC
+	Op         string    `json:"op"`                   // AuditBind or AuditSearch
+	Server     string    `json:"server,omitempty"`     // LDAP server
+	User       string    `json:"user,omitempty"`       // Bind username
+	BaseDN     string    `json:"baseDN,omitempty"`     // Search base
+	Scope      string    `json:"scope,omitempty"`      // Search scope, e.g. "Base Object" for a single object read
+	Filter     string    `json:"filter,omitempty"`     // Search filter
+	Attributes []string  `json:"attributes,omitempty"` // Requested attributes
+	Entries    int       `json:"entries"`              // Entries returned by a search
+	DurationMS int64     `json:"durationMs"`           // Duration in milliseconds
+	Error      string    `json:"error,omitempty"`      // Failure, if any
+	Prev       string    `json:"prev"`                 // Hash of the previous record, empty for the first
+	Hash       string    `json:"hash"`                 // SHA-256 of this record with Hash empty
+}
+
+// hash returns the chain hash of r
+func (r AuditRecord) hash() (string, error) {
+	r.Hash = ""
+	data, err := json.Marshal(r)
+	if err != nil {
+		return "", err
+	}
+	sum := sha256.Sum256(data)
+	return hex.EncodeToString(sum[:]), nil
+}
+
+// AuditLog appends records to a hash-chained JSON Lines file. It is safe
+// for concurrent use.
+type AuditLog struct {
+	mu   sync.Mutex
+	file *os.File
+	seq  int64
+	prev string
+	err  error // First failed write, returned by Close
+}
+
+// OpenAuditLog opens the audit trail at path for appending, creating it if
+// needed. An existing trail is continued from its last record.
+func OpenAuditLog(path string) (*AuditLog, error) {
+	// Audit trails name every account and filter used; keep them private
+	f, err := os.OpenFile(path, os.O_RDWR|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("opening audit trail: %w", err)
+	}
+
+	a := &AuditLog{file: f}
+	last, err := lastAuditRecord(f)
+	if err != nil {
+		f.Close()
+		return nil, fmt.Errorf("reading audit trail %s: %w", path, err)
+	}
+	if last != nil {
+		a.seq, a.prev = last.Seq, last.Hash
+	}
+	return a, nil
+}
+
+// lastAuditRecord returns the last record of a trail, or nil if it is empty
+func lastAuditRecord(r io.Reader) (*AuditRecord, error) {
+	var last *AuditRecord
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxAuditLine)
+	for scanner.Scan() {
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return nil, fmt.Errorf("record %d: %w", lineSeq(last)+1, err)
+		}
+		last = &rec
+	}
+	return last, scanner.Err()
+}
+
+// lineSeq returns the sequence number of rec, 0 for none
+func lineSeq(rec *AuditRecord) int64 {
+	if rec == nil {
+		return 0
+	}
+	return rec.Seq
+}
+
+// Log appends rec to the trail, filling in its sequence number and hashes
+func (a *AuditLog) Log(rec AuditRecord) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	rec.Seq = a.seq + 1
+	rec.Time = rec.Time.UTC()
+	rec.Prev = a.prev
+	hash, err := rec.hash()
+	if err == nil {
+		rec.Hash = hash
+		var data []byte
+		if data, err = json.Marshal(rec); err == nil {
+			_, err = a.file.Write(append(data, '\n'))
+		}
+	}
+	if err != nil {
+		err = fmt.Errorf("writing audit trail: %w", err)
+		if a.err == nil {
+			a.err = err
+		}
+		return err
+	}
+
+	a.seq, a.prev = rec.Seq, rec.Hash
+	return nil
+}
+
+// Bind records a bind attempt. It has the signature of a BindHook.
+func (a *AuditLog) Bind(c *Config, username string, started time.Time, err error) {
+	rec := AuditRecord{
+		Time:       started,
+		Op:         AuditBind,
+		Server:     c.Server,
+		User:       username,
+		DurationMS: time.Since(started).Milliseconds(),
+	}
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	_ = a.Log(rec)
+}
+
+// Close closes the trail and returns the hash of its last record, empty if
+// it has none. Kept outside the trail, the hash detects records removed
+// from the end and a rewritten chain. Close also returns the first write
+// that failed, so a trail with missing records does not go unnoticed.
+func (a *AuditLog) Close() (string, error) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if err := a.file.Close(); err != nil && a.err == nil {
+		a.err = err
+	}
+	return a.prev, a.err
+}
+
+// VerifyAuditLog checks the hash chain of the trail at path and returns
+// the number of records. The error names the first record that does not
+// match its hash or the record before it. A non-empty head is the hash
+// returned by Close, which the last record must carry.
+func VerifyAuditLog(path, head string) (int64, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return 0, fmt.Errorf("opening audit trail: %w", err)
+	}
+	defer f.Close()
+
+	var seq int64
+	prev := ""
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, maxAuditLine)
+	for scanner.Scan() {
+		seq++
+		var rec AuditRecord
+		if err := json.Unmarshal(scanner.Bytes(), &rec); err != nil {
+			return seq - 1, fmt.Errorf("record %d: %w", seq, err)
+		}
+		hash, err := rec.hash()
+		if err != nil {
+			return seq - 1, fmt.Errorf("record %d: %w", seq, err)
+		}
+		switch {
+		case rec.Seq != seq:
+			return seq - 1, fmt.Errorf("record %d: has sequence number %d; records were removed or reordered", seq, rec.Seq)
+		case rec.Prev != prev:
+			return seq - 1, fmt.Errorf("record %d: does not follow the previous record", seq)
+		case rec.Hash != hash:
+			return seq - 1, fmt.Errorf("record %d: was modified", seq)
+		}
+		prev = rec.Hash
+	}
+	if err := scanner.Err(); err != nil {
+		return seq, fmt.Errorf("reading audit trail: %w", err)
+	}
+	if head != "" && !strings.EqualFold(prev, head) {
+		return seq, fmt.Errorf("last record %d does not have hash %s; records were removed from the end or the trail was rewritten", seq, head)
+	}
+	return seq, nil
+}
+
+// AuditMiddleware records every search of a client in an AuditLog
+type AuditMiddleware struct {
+	BaseMiddleware
+	log    *AuditLog
+	config *Config
+}
+
+// NewAuditMiddleware records the searches sent to the server of config
+func NewAuditMiddleware(log *AuditLog, config *Config) *AuditMiddleware {
+	return &AuditMiddleware{log: log, config: config}
+}
+
+// auditSearchKey carries the search record from start to end
+type auditSearchKey struct{}
+
+// OnSearchStart notes the search, the object or subtree it reads and its
+// start time
+func (m *AuditMiddleware) OnSearchStart(ctx context.Context, filter string, attributes []string) (context.Context, error) {
+	base, scope := searchBase(ctx, m.config.BaseDN)
+	rec := &AuditRecord{
+		Time:       time.Now(),
+		Op:         AuditSearch,
+		Server:     m.config.Server,
+		User:       m.config.Username,
+		BaseDN:     base,
+		Scope:      ldap.ScopeMap[scope],
+		Filter:     filter,
+		Attributes: attributes,
+	}
+	return context.WithValue(ctx, auditSearchKey{}, rec), nil
+}
+
+// OnSearchEnd appends the finished search to the trail
+func (m *AuditMiddleware) OnSearchEnd(ctx context.Context, filter string, entries int, err error) {
+	rec, ok := ctx.Value(auditSearchKey{}).(*AuditRecord)
+	if !ok {
+		// An earlier middleware refused the search before it started
+		return
+	}
+	rec.Entries = entries
+	rec.DurationMS = time.Since(rec.Time).Milliseconds()
+	if err != nil {
+		rec.Error = err.Error()
+	}
+	_ = m.log.Log(*rec)
+}
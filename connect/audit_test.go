@@ -0,0 +1,183 @@
+package connect
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+	"time"
+)
+
+// writeAuditTrail logs three searches to a new trail and returns its path,
+// lines and head hash
+func writeAuditTrail(t *testing.T) (string, []string, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "engagement.audit")
+	a, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, filter := range []string{"(objectClass=user)", "(objectClass=group)", "(objectClass=computer)"} {
+		if err := a.Log(AuditRecord{Time: time.Now(), Op: AuditSearch, Filter: filter}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	head, err := a.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return path, strings.Split(strings.TrimSuffix(string(data), "\n"), "\n"), head
+}
+
+// rewriteAuditTrail replaces the trail at path with lines
+func rewriteAuditTrail(t *testing.T, path string, lines []string) {
+	t.Helper()
+	if err := os.WriteFile(path, []byte(strings.Join(lines, "\n")+"\n"), 0600); err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestVerifyAuditLog(t *testing.T) {
+	path, lines, head := writeAuditTrail(t)
+	if len(lines) != 3 {
+		t.Fatalf("trail has %d lines, want 3", len(lines))
+	}
+	if !strings.Contains(lines[2], `"hash":"`+head+`"`) {
+		t.Errorf("Close returned %s, not the hash of the last record", head)
+	}
+	n, err := VerifyAuditLog(path, "")
+	if err != nil || n != 3 {
+		t.Fatalf("VerifyAuditLog = %d, %v; want 3, nil", n, err)
+	}
+	if n, err := VerifyAuditLog(path, strings.ToUpper(head)); err != nil || n != 3 {
+		t.Fatalf("VerifyAuditLog with the head = %d, %v; want 3, nil", n, err)
+	}
+
+	// A reopened trail continues the chain
+	a, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := a.Log(AuditRecord{Time: time.Now(), Op: AuditSearch, Filter: "(cn=x)"}); err != nil {
+		t.Fatal(err)
+	}
+	newHead, err := a.Close()
+	if err != nil || newHead == head {
+		t.Fatalf("Close after reopening = %s, %v", newHead, err)
+	}
+	if n, err := VerifyAuditLog(path, newHead); err != nil || n != 4 {
+		t.Errorf("VerifyAuditLog after reopening = %d, %v; want 4, nil", n, err)
+	}
+	if _, err := VerifyAuditLog(path, head); err == nil {
+		t.Error("VerifyAuditLog accepted an earlier head")
+	}
+}
+
+func TestVerifyAuditLogHead(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func(t *testing.T, path string, lines []string)
+	}{
+		{"cut from the end", func(t *testing.T, path string, lines []string) {
+			rewriteAuditTrail(t, path, lines[:2])
+		}},
+		{"emptied", func(t *testing.T, path string, lines []string) {
+			if err := os.WriteFile(path, nil, 0600); err != nil {
+				t.Fatal(err)
+			}
+		}},
+		// Without a key the chain of a rewritten trail is valid again
+		{"rewritten", func(t *testing.T, path string, lines []string) {
+			if err := os.Remove(path); err != nil {
+				t.Fatal(err)
+			}
+			a, err := OpenAuditLog(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			a.Log(AuditRecord{Time: time.Now(), Op: AuditSearch, Filter: "(objectClass=user)"})
+			a.Close()
+		}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, lines, head := writeAuditTrail(t)
+			tt.tamper(t, path, lines)
+			if _, err := VerifyAuditLog(path, ""); err != nil {
+				t.Fatalf("chain of the tampered trail is broken: %v", err)
+			}
+			_, err := VerifyAuditLog(path, head)
+			if err == nil || !strings.Contains(err.Error(), "does not have hash "+head) {
+				t.Errorf("VerifyAuditLog with the head = %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyAuditLogDetectsTampering(t *testing.T) {
+	tests := []struct {
+		name   string
+		tamper func([]string) []string
+		want   string
+	}{
+		{"edited", func(l []string) []string {
+			l[1] = strings.Replace(l[1], "(objectClass=group)", "(objectClass=printQueue)", 1)
+			return l
+		}, "record 2: was modified"},
+		{"removed", func(l []string) []string {
+			return []string{l[0], l[2]}
+		}, "record 2: has sequence number 3"},
+		{"removed first", func(l []string) []string {
+			return l[1:]
+		}, "record 1: has sequence number 2"},
+		{"reordered", func(l []string) []string {
+			return []string{l[0], l[2], l[1]}
+		}, "record 2: has sequence number 3"},
+		{"truncated", func(l []string) []string {
+			l[2] = l[2][:len(l[2])/2]
+			return l
+		}, "record 3:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			path, lines, _ := writeAuditTrail(t)
+			rewriteAuditTrail(t, path, tt.tamper(lines))
+			_, err := VerifyAuditLog(path, "")
+			if err == nil {
+				t.Fatal("VerifyAuditLog accepted a tampered trail")
+			}
+			if !strings.Contains(err.Error(), tt.want) {
+				t.Errorf("error = %q, want it to contain %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditMiddlewareRecordsBaseObject(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "engagement.audit")
+	a, err := OpenAuditLog(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	m := NewAuditMiddleware(a, &Config{Server: "dc01", BaseDN: "DC=example,DC=com"})
+
+	ctx, _ := m.OnSearchStart(context.Background(), "(objectClass=*)", nil)
+	rec := ctx.Value(auditSearchKey{}).(*AuditRecord)
+	if rec.BaseDN != "DC=example,DC=com" || rec.Scope != "Whole Subtree" {
+		t.Errorf("subtree search recorded as %s, %s", rec.BaseDN, rec.Scope)
+	}
+
+	const dn = "CN=svc_sql,CN=Users,DC=example,DC=com"
+	ctx, _ = m.OnSearchStart(WithBaseObject(context.Background(), dn), "(objectClass=*)", nil)
+	rec = ctx.Value(auditSearchKey{}).(*AuditRecord)
+	if rec.BaseDN != dn || rec.Scope != "Base Object" {
+		t.Errorf("object read recorded as %s, %s; want %s, Base Object", rec.BaseDN, rec.Scope, dn)
+	}
+	a.Close()
+}
@@ -0,0 +1,256 @@
+package connect
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// cacheFileExt is the extension of cached search results
+const cacheFileExt = ".json"
+
+// Cache stores search results on disk for a limited time
+type Cache struct {
+	Dir string        // Directory holding one file per search
+	TTL time.Duration // How long a result is served after it was fetched
+}
+
+// NewCache creates a cache in dir
+func NewCache(dir string, ttl time.Duration) *Cache {
+	return &Cache{Dir: dir, TTL: ttl}
+}
+
+// DefaultCacheDir returns the default cache directory ($HOME/.adgo/cache)
+func DefaultCacheDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "cache"
+	}
+	return filepath.Join(home, ".adgo", "cache")
+}
+
+// path returns the file of a search, named after the hash of its key
+func (c *Cache) path(key string) string {
+	sum := sha256.Sum256([]byte(key))
+	return filepath.Join(c.Dir, hex.EncodeToString(sum[:])+cacheFileExt)
+}
+
+// get returns the cached result of a search if it has not expired
+func (c *Cache) get(key string) (Exchange, bool) {
+	data, err := os.ReadFile(c.path(key))
+	if err != nil {
+		return Exchange{}, false
+	}
+	var x Exchange
+	if err := json.Unmarshal(data, &x); err != nil {
+		return Exchange{}, false
+	}
+	if time.Since(x.Time) > c.TTL {
+		return Exchange{}, false
+	}
+	return x, true
+}
+
+// put stores the result of a search
+func (c *Cache) put(key string, x Exchange) error {
+	if err := os.MkdirAll(c.Dir, 0700); err != nil {
+		return fmt.Errorf("creating cache directory: %w", err)
+	}
+	data, err := json.Marshal(x)
+	if err != nil {
+		return fmt.Errorf("marshaling cache entry: %w", err)
+	}
+
+	// Write to a temporary file first so concurrent readers never see a
+	// partial result; cached results may contain sensitive attributes
+	path := c.path(key)
+	tmp, err := os.CreateTemp(c.Dir, ".tmp-*")
+	if err != nil {
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return fmt.Errorf("writing cache entry: %w", err)
+	}
+	return os.Rename(tmp.Name(), path)
+}
+
+// Clear removes every cached result and returns how many were removed
+func (c *Cache) Clear() (int, error) {
+	files, err := os.ReadDir(c.Dir)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return 0, nil
+		}
+		return 0, fmt.Errorf("reading cache directory: %w", err)
+	}
+
+	removed := 0
+	for _, f := range files {
+		if f.IsDir() || !strings.HasSuffix(f.Name(), cacheFileExt) {
+			continue
+		}
+		if err := os.Remove(filepath.Join(c.Dir, f.Name())); err != nil {
+			return removed, fmt.Errorf("removing cache entry: %w", err)
+		}
+		removed++
+	}
+	return removed, nil
+}
+
+// cacheKey is the searchKey of a search sent with config, extended with
+// the server and the bind identity so profiles sharing a base DN, or a
+// less privileged user, never get each other's results
+func cacheKey(ctx context.Context, config *Config, filter string, attributes []string) string {
+	bindUser, err := formatBindUsername(config)
+	if err != nil {
+		bindUser = config.Username
+	}
+	return fmt.Sprintf("%s:%d\x00%s\x00%s", strings.ToLower(config.Server), config.Port, strings.ToLower(bindUser), searchKey(ctx, config.BaseDN, filter, attributes))
+}
+
+// CachingClient serves searches from a Cache and only connects on a miss.
+// Results of failed searches are not cached.
+type CachingClient struct {
+	cache   *Cache
+	config  *Config
+	connect func() (Client, error)
+
+	mu     sync.Mutex
+	client Client // Connected on the first cache miss
+}
+
+// NewCachingClient creates a client answering from cache, calling connect
+// to create the underlying client the first time a search is not cached
+func NewCachingClient(cache *Cache, config *Config, connect func() (Client, error)) *CachingClient {
+	return &CachingClient{cache: cache, config: config, connect: connect}
+}
+
+// Search returns a cached result, or executes the search and caches it
+func (cc *CachingClient) Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error) {
+	key := cacheKey(ctx, cc.config, filter, attributes)
+	if x, ok := cc.cache.get(key); ok {
+		return x.entries(), nil
+	}
+
+	client, err := cc.upstream()
+	if err != nil {
+		return nil, err
+	}
+	x := newExchange(ctx, cc.config, filter, attributes)
+	entries, err := client.Search(ctx, filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+	for _, e := range entries {
+		x.Entries = append(x.Entries, newRecordedEntry(e))
+	}
+	_ = cc.cache.put(key, x) // A failed write only costs the next search a round trip
+	return entries, nil
+}
+
+// StreamSearch streams a cached result, or streams the search and caches
+// the result once it completes
+func (cc *CachingClient) StreamSearch(ctx context.Context, filter string, attributes []string) (<-chan *ldap.Entry, <-chan error) {
+	out := make(chan *ldap.Entry)
+	errChan := make(chan error, 1)
+
+	key := cacheKey(ctx, cc.config, filter, attributes)
+	if x, ok := cc.cache.get(key); ok {
+		go func() {
+			defer close(out)
+			for _, e := range x.Entries {
+				select {
+				case out <- e.entry():
+				case <-ctx.Done():
+					errChan <- ctx.Err()
+					return
+				}
+			}
+			errChan <- nil
+		}()
+		return out, errChan
+	}
+
+	client, err := cc.upstream()
+	if err != nil {
+		close(out)
+		errChan <- err
+		return out, errChan
+	}
+
+	in, inErr := client.StreamSearch(ctx, filter, attributes)
+	go func() {
+		defer close(out)
+		x := newExchange(ctx, cc.config, filter, attributes)
+		for e := range in {
+			x.Entries = append(x.Entries, newRecordedEntry(e))
+			select {
+			case out <- e:
+			case <-ctx.Done(): // Keep draining so the search can finish
+			}
+		}
+		err := <-inErr
+		if err == nil && ctx.Err() == nil {
+			_ = cc.cache.put(key, x)
+		}
+		errChan <- err
+	}()
+	return out, errChan
+}
+
+// Ping checks the underlying client, connecting if needed
+func (cc *CachingClient) Ping(ctx context.Context) error {
+	client, err := cc.upstream()
+	if err != nil {
+		return err
+	}
+	return client.Ping(ctx)
+}
+
+// Close closes the underlying client if one was connected
+func (cc *CachingClient) Close() error {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.client == nil {
+		return nil
+	}
+	return cc.client.Close()
+}
+
+// upstream returns the underlying client, connecting on first use
+func (cc *CachingClient) upstream() (Client, error) {
+	cc.mu.Lock()
+	defer cc.mu.Unlock()
+	if cc.client == nil {
+		client, err := cc.connect()
+		if err != nil {
+			return nil, err
+		}
+		cc.client = client
+	}
+	return cc.client, nil
+}
+
+// entries converts the recorded entries of an exchange back into LDAP entries
+func (x Exchange) entries() []*ldap.Entry {
+	entries := make([]*ldap.Entry, len(x.Entries))
+	for i, e := range x.Entries {
+		entries[i] = e.entry()
+	}
+	return entries
+}
@@ -2,6 +2,7 @@ package connect
 
 import (
 	"adgo/analyze"
+	"adgo/redact"
 	"crypto/tls"
 	"fmt"
 	"net"
@@ -32,15 +33,15 @@ const (
 
 // Config LDAP connection configuration
 type Config struct {
-	Server    string       `mapstructure:"server"`    // LDAP server address
-	Port      int          `mapstructure:"port"`      // LDAP server port
-	BaseDN    string       `mapstructure:"baseDN"`    // LDAP base DN
-	Username  string       `mapstructure:"username"`  // LDAP username
-	Password  string       `mapstructure:"password"`  // LDAP password
-	LoginName LoginName    `mapstructure:"loginName"` // Username type for authentication
-	Security  SecurityType `mapstructure:"security"`  // Connection security type
-	Timeout   int          `mapstructure:"timeout"`   // Connection timeout in seconds (default: 30)
-	SizeLimit int          `mapstructure:"sizeLimit"` // Maximum number of entries to return (0 = unlimited)
+	Server    string       `mapstructure:"server" yaml:"server"`       // LDAP server address
+	Port      int          `mapstructure:"port" yaml:"port"`           // LDAP server port
+	BaseDN    string       `mapstructure:"baseDN" yaml:"baseDN"`       // LDAP base DN
+	Username  string       `mapstructure:"username" yaml:"username"`   // LDAP username
+	Password  string       `mapstructure:"password" yaml:"password"`   // LDAP password
+	LoginName LoginName    `mapstructure:"loginName" yaml:"loginName"` // Username type for authentication
+	Security  SecurityType `mapstructure:"security" yaml:"security"`   // Connection security type
+	Timeout   int          `mapstructure:"timeout" yaml:"timeout"`     // Connection timeout in seconds (default: 30)
+	SizeLimit int          `mapstructure:"sizeLimit" yaml:"sizeLimit"` // Maximum number of entries to return (0 = unlimited)
 }
 
 func formatBindUsername(c *Config) (string, error) {
@@ -63,6 +64,28 @@ func ldapBind(c *Config) (*ldap.Conn, error) {
 	if c.Server == "" {
 		return nil, fmt.Errorf("LDAP server is not configured")
 	}
+	password, err := BindPassword(c)
+	if err != nil {
+		return nil, err
+	}
+
+	conn, err := ldapDial(c)
+	if err != nil {
+		return nil, err
+	}
+	if err := bindConn(conn, c, password); err != nil {
+		conn.Close()
+		return nil, err
+	}
+	return conn, nil
+}
+
+// ldapDial connects to the server of c, negotiating TLS as configured,
+// without binding
+func ldapDial(c *Config) (*ldap.Conn, error) {
+	if c.Server == "" {
+		return nil, fmt.Errorf("LDAP server is not configured")
+	}
 
 	scheme, port, baseTLSConf := securitySettings(c)
 	url := fmt.Sprintf("%s://%s:%d", scheme, c.Server, port)
@@ -81,41 +104,61 @@ func ldapBind(c *Config) (*ldap.Conn, error) {
 	if baseTLSConf == nil {
 		conn, err := ldap.DialURL(url, ldap.DialWithDialer(dialer))
 		if err != nil {
-			return nil, fmt.Errorf("failed to connect to LDAP server %s: %w", c.Server, err)
-		}
-
-		username, err := formatBindUsername(c)
-		if err != nil {
-			defer conn.Close()
-			return nil, fmt.Errorf("failed to format username: %w", err)
+			return nil, WrapConnectError(c.Server, err)
 		}
-
-		if bindErr := conn.Bind(username, c.Password); bindErr != nil {
-			defer conn.Close()
-			return nil, fmt.Errorf("failed to bind: %w", bindErr)
-		}
-
 		return conn, nil
 	}
 
 	// For TLS connections, try with intelligent version negotiation
 	conn, err := dialWithTLSNegotiation(url, dialer, baseTLSConf, c)
 	if err != nil {
-		return nil, fmt.Errorf("failed to connect to LDAP server %s: %w", c.Server, err)
+		return nil, WrapConnectError(c.Server, err)
 	}
+	return conn, nil
+}
 
+// bindConn binds conn with the username of c and password
+func bindConn(conn *ldap.Conn, c *Config, password string) error {
 	username, err := formatBindUsername(c)
 	if err != nil {
-		defer conn.Close()
-		return nil, fmt.Errorf("failed to format username: %w", err)
+		return fmt.Errorf("failed to format username: %w", err)
 	}
 
-	if bindErr := conn.Bind(username, c.Password); bindErr != nil {
-		defer conn.Close()
-		return nil, fmt.Errorf("failed to bind: %w", bindErr)
+	// Keep the password out of logs and error messages from here on
+	redact.AddSecret(password)
+
+	started := time.Now()
+	bindErr := conn.Bind(username, password)
+	if bindHook != nil {
+		bindHook(c, username, started, bindErr)
 	}
+	if bindErr != nil {
+		return WrapBindError(username, bindErr)
+	}
+	return nil
+}
 
-	return conn, nil
+// BindHook observes every bind attempt, whether it succeeded or not
+type BindHook func(c *Config, username string, started time.Time, err error)
+
+// bindHook is set by SetBindHook
+var bindHook BindHook
+
+// SetBindHook makes hook observe every bind from now on; nil removes it.
+// Set it before connecting: it is not synchronized with running binds.
+func SetBindHook(hook BindHook) {
+	bindHook = hook
+}
+
+// Authenticate performs a single bind with the given configuration and closes
+// the connection without issuing any searches. No retries are attempted so that
+// each call costs exactly one logon attempt against the account lockout counter.
+func Authenticate(c *Config) error {
+	conn, err := ldapBind(c)
+	if err != nil {
+		return err
+	}
+	return conn.Close()
 }
 
 // securitySettings gets base security configuration (TLS version negotiation handled separately)
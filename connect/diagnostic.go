@@ -1,8 +1,12 @@
 package connect
 
 import (
+	"adgo/redact"
 	"fmt"
 	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
 )
 
 // ErrorWithHelp provides structured error information with diagnosis and solutions
@@ -13,9 +17,11 @@ type ErrorWithHelp struct {
 	Details   map[string]string
 }
 
+// Error formats the error, diagnosis, details and fixes. Secrets in the
+// error text and details are masked.
 func (e *ErrorWithHelp) Error() string {
 	var sb strings.Builder
-	sb.WriteString(fmt.Sprintf("[ERROR] %v\n", e.Err))
+	sb.WriteString(fmt.Sprintf("[ERROR] %s\n", redact.Text(fmt.Sprint(e.Err))))
 
 	if e.Diagnosis != "" {
 		sb.WriteString(fmt.Sprintf("\n[DIAGNOSIS] %s\n", e.Diagnosis))
@@ -23,7 +29,7 @@ func (e *ErrorWithHelp) Error() string {
 
 	if len(e.Details) > 0 {
 		sb.WriteString("\n[DETAILS]\n")
-		for k, v := range e.Details {
+		for k, v := range redact.Map(e.Details) {
 			sb.WriteString(fmt.Sprintf("  %s: %s\n", k, v))
 		}
 	}
@@ -246,3 +252,103 @@ func truncateDisplay(s string, maxLen int) string {
 	}
 	return s[:maxLen] + "..."
 }
+
+// Stages reported by TestConnection, in the order they run
+const (
+	StageConnect = "connect"
+	StageBind    = "bind"
+	StageRootDSE = "rootDSE"
+	StageBaseDN  = "baseDN"
+)
+
+// StageResult is the outcome of one stage of TestConnection
+type StageResult struct {
+	Stage    string
+	Detail   string // What was contacted or found
+	Duration time.Duration
+	Err      error // Diagnostic error; nil if the stage passed
+}
+
+// TestConnection connects to the server of c, binds, reads the RootDSE and
+// checks the base DN, stopping at the first stage that fails. Failures are
+// wrapped with the matching Analyze*Error diagnosis.
+func TestConnection(c *Config) []StageResult {
+	var results []StageResult
+	run := func(stage string, fn func() (string, error)) bool {
+		start := time.Now()
+		detail, err := fn()
+		results = append(results, StageResult{Stage: stage, Detail: redact.Text(detail), Duration: time.Since(start), Err: err})
+		return err == nil
+	}
+
+	var conn *ldap.Conn
+	defer func() {
+		if conn != nil {
+			conn.Close()
+		}
+	}()
+
+	ok := run(StageConnect, func() (string, error) {
+		scheme, port, _ := securitySettings(c)
+		url := fmt.Sprintf("%s://%s:%d", scheme, c.Server, port)
+		var err error
+		if conn, err = ldapDial(c); err != nil {
+			return url, AnalyzeConnectionError(c.Server, err)
+		}
+		return url, nil
+	})
+
+	ok = ok && run(StageBind, func() (string, error) {
+		username, err := formatBindUsername(c)
+		if err != nil {
+			return c.Username, AnalyzeBindError(c.Username, err)
+		}
+		password, err := BindPassword(c)
+		if err != nil {
+			return username, AnalyzeBindError(username, err)
+		}
+		if err := bindConn(conn, c, password); err != nil {
+			return username, AnalyzeBindError(username, err)
+		}
+		return username, nil
+	})
+
+	ok = ok && run(StageRootDSE, func() (string, error) {
+		req := ldap.NewSearchRequest(
+			"", // RootDSE has empty base DN
+			ldap.ScopeBaseObject,
+			ldap.NeverDerefAliases,
+			1, 0, false,
+			"(objectClass=*)",
+			[]string{"dnsHostName", "defaultNamingContext"},
+			nil,
+		)
+		sr, err := conn.Search(req)
+		if err == nil && len(sr.Entries) == 0 {
+			err = fmt.Errorf("no entries returned from RootDSE")
+		}
+		if err != nil {
+			return "", AnalyzeSearchError("", req.Filter, WrapSearchError("", err))
+		}
+		entry := sr.Entries[0]
+		return fmt.Sprintf("%s, %s", entry.GetAttributeValue("dnsHostName"), entry.GetAttributeValue("defaultNamingContext")), nil
+	})
+
+	_ = ok && run(StageBaseDN, func() (string, error) {
+		req := ldap.NewSearchRequest(
+			c.BaseDN,
+			ldap.ScopeBaseObject,
+			ldap.NeverDerefAliases,
+			1, 0, false,
+			"(objectClass=*)",
+			[]string{"distinguishedName"},
+			nil,
+		)
+		if _, err := conn.Search(req); err != nil {
+			return c.BaseDN, AnalyzeSearchError(c.BaseDN, req.Filter, WrapSearchError(c.BaseDN, err))
+		}
+		return c.BaseDN, nil
+	})
+
+	return results
+}
@@ -1,8 +1,13 @@
 package connect
 
 import (
+	"adgo/redact"
+	"errors"
 	"fmt"
+	"regexp"
 	"strings"
+
+	"github.com/go-ldap/ldap/v3"
 )
 
 // LDAPError represents an LDAP operation error with context
@@ -24,14 +29,14 @@ func (e *LDAPError) Error() string {
 			if !first {
 				sb.WriteString(", ")
 			}
-			sb.WriteString(fmt.Sprintf("%s=%v", k, v))
+			sb.WriteString(fmt.Sprintf("%s=%v", k, redact.Field(k, v)))
 			first = false
 		}
 		sb.WriteString("]")
 	}
 
 	if e.Err != nil {
-		sb.WriteString(fmt.Sprintf(": %v", e.Err))
+		sb.WriteString(": " + redact.Text(e.Err.Error()))
 	}
 
 	return sb.String()
@@ -157,6 +162,80 @@ func IsAuthError(err error) bool {
 	return false
 }
 
+// AuthFailure classifies why Active Directory rejected a bind
+type AuthFailure int
+
+const (
+	AuthFailureNone       AuthFailure = iota // Not an authentication failure
+	AuthFailureInvalid                       // Wrong password or unknown user (data 52e/525)
+	AuthFailureLocked                        // Account locked out (data 775)
+	AuthFailureExpired                       // Password or account expired, or must be reset (data 532/701/773)
+	AuthFailureDisabled                      // Account disabled (data 533)
+	AuthFailureRestricted                    // Logon hours or workstation restriction (data 530/531)
+	AuthFailureUnknown                       // Authentication failed for an unrecognized reason
+)
+
+// authFailureNames maps auth failure categories to their display names
+var authFailureNames = map[AuthFailure]string{
+	AuthFailureNone:       "none",
+	AuthFailureInvalid:    "invalid",
+	AuthFailureLocked:     "locked",
+	AuthFailureExpired:    "expired",
+	AuthFailureDisabled:   "disabled",
+	AuthFailureRestricted: "restricted",
+	AuthFailureUnknown:    "unknown",
+}
+
+// String returns the display name of the auth failure category
+func (f AuthFailure) String() string {
+	if name, ok := authFailureNames[f]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// adBindDataPattern extracts the sub-status code AD appends to bind errors,
+// e.g. "AcceptSecurityContext error, data 52e, v4563"
+var adBindDataPattern = regexp.MustCompile(`data ([0-9a-fA-F]+)`)
+
+// ClassifyAuthError maps a bind error to an AuthFailure category using the
+// AD sub-status code when present. Returns AuthFailureNone for errors that
+// are not authentication failures (network, TLS, etc).
+func ClassifyAuthError(err error) AuthFailure {
+	if err == nil {
+		return AuthFailureNone
+	}
+
+	var ldapErr *ldap.Error
+	isInvalidCreds := errors.As(err, &ldapErr) && ldapErr.ResultCode == ldap.LDAPResultInvalidCredentials
+	if !isInvalidCreds && !IsAuthError(err) {
+		return AuthFailureNone
+	}
+
+	match := adBindDataPattern.FindStringSubmatch(err.Error())
+	if match == nil {
+		if isInvalidCreds {
+			return AuthFailureInvalid
+		}
+		return AuthFailureUnknown
+	}
+
+	switch strings.ToLower(match[1]) {
+	case "52e", "525":
+		return AuthFailureInvalid
+	case "775":
+		return AuthFailureLocked
+	case "532", "701", "773":
+		return AuthFailureExpired
+	case "533":
+		return AuthFailureDisabled
+	case "530", "531":
+		return AuthFailureRestricted
+	default:
+		return AuthFailureUnknown
+	}
+}
+
 // IsTLSError checks if an error is TLS-related
 func IsTLSError(err error) bool {
 	if err == nil {
@@ -0,0 +1,71 @@
+package connect
+
+import (
+	"adgo/analyze"
+	"context"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// SearchPlan describes the search request a query would send, so it can be
+// reviewed (or the filter reused elsewhere) without connecting
+type SearchPlan struct {
+	BaseDN       string           `json:"baseDN"`
+	Scope        string           `json:"scope"`
+	DerefAliases string           `json:"derefAliases"`
+	Filter       string           `json:"filter"`
+	Attributes   []string         `json:"attributes"`
+	SizeLimit    int              `json:"sizeLimit"` // 0 means unlimited
+	TimeLimit    int              `json:"timeLimit"` // Seconds; 0 means unlimited
+	Controls     []PlannedControl `json:"controls"`
+}
+
+// PlannedControl is a request control of a SearchPlan
+type PlannedControl struct {
+	OID       string `json:"oid"`
+	Name      string `json:"name"`
+	Critical  bool   `json:"critical"`
+	Value     string `json:"value,omitempty"`     // Human-readable control value
+	Condition string `json:"condition,omitempty"` // When the control is sent, if not always
+}
+
+// PlanSearch returns the search request executeSearch would send for filter
+// and attributes with the given configuration and context options
+func PlanSearch(ctx context.Context, c *Config, filter string, attributes []string) SearchPlan {
+	baseDN, scope := searchBase(ctx, c.BaseDN)
+	plan := SearchPlan{
+		BaseDN:       baseDN,
+		Scope:        ldap.ScopeMap[scope],
+		DerefAliases: ldap.DerefMap[ldap.NeverDerefAliases],
+		Filter:       filter,
+		Attributes:   attributes,
+		SizeLimit:    max(c.SizeLimit, 0),
+		Controls: []PlannedControl{{
+			OID:       analyze.OIDControlTypePaging,
+			Name:      ldap.ControlTypeMap[analyze.OIDControlTypePaging],
+			Value:     fmt.Sprintf("page size %d", analyze.DefaultPagingSize),
+			Condition: "if the server supports paging",
+		}},
+	}
+	if plan.Attributes == nil {
+		plan.Attributes = []string{}
+	}
+
+	for _, control := range searchControls(ctx, attributes) {
+		planned := PlannedControl{OID: control.GetControlType(), Name: ldap.ControlTypeMap[control.GetControlType()]}
+		switch ctrl := control.(type) {
+		case *ldap.ControlMicrosoftSDFlags:
+			planned.Critical = ctrl.Criticality
+			planned.Value = fmt.Sprintf("owner, group and DACL (0x%x)", ctrl.ControlValue)
+		case *ldap.ControlString:
+			planned.Critical = ctrl.Criticality
+			if ctrl.ControlType == analyze.OIDControlTypeExtendedDN {
+				planned.Name = "Extended DN"
+				planned.Value = "string format (GUID and SID in DNs)"
+			}
+		}
+		plan.Controls = append(plan.Controls, planned)
+	}
+	return plan
+}
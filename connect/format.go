@@ -59,14 +59,30 @@ func BaseDNToDomain(baseDN string) (string, error) {
 	return strings.Join(domainParts, "."), nil
 }
 
-// GenerateFilename generates a CSV filename with domain and timestamp
-func GenerateFilename(baseDN string) string {
+// DomainToBaseDN converts a domain name to its BaseDN
+// domain: DNS domain name (e.g., "sec.lab")
+// Returns: BaseDN (e.g., "DC=sec,DC=lab") or error if empty
+func DomainToBaseDN(domain string) (string, error) {
+	domain = strings.Trim(strings.TrimSpace(domain), ".")
+	if domain == "" {
+		return "", fmt.Errorf("empty domain")
+	}
+
+	parts := strings.Split(domain, ".")
+	for i, part := range parts {
+		parts[i] = "DC=" + part
+	}
+	return strings.Join(parts, ","), nil
+}
+
+// GenerateFilename generates an output filename with domain, timestamp and extension
+func GenerateFilename(baseDN, ext string) string {
 	domain, err := BaseDNToDomain(baseDN)
 	if err != nil {
 		domain = "ad"
 	}
 	timestamp := time.Now().Format("20060102-150405")
-	return fmt.Sprintf("%s-%s.csv", domain, timestamp)
+	return fmt.Sprintf("%s-%s.%s", domain, timestamp, ext)
 }
 
 // DomainAdminsDN returns the distinguished name for Domain Admins group
@@ -0,0 +1,66 @@
+package connect
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// KeyringPrefix marks a password stored in the OS keyring. "keyring:" uses
+// the username as the keyring account, "keyring:NAME" the account NAME.
+const KeyringPrefix = "keyring:"
+
+// keyringService names the credentials adgo stores in the OS keyring
+const keyringService = "adgo"
+
+// ErrKeyringNotFound is returned when the keyring has no password for an account
+var ErrKeyringNotFound = errors.New("password not found in keyring")
+
+// IsKeyringPassword reports whether a configured password refers to the OS keyring
+func IsKeyringPassword(password string) bool {
+	return strings.HasPrefix(password, KeyringPrefix)
+}
+
+// KeyringAccount returns the keyring account the password of c refers to
+func KeyringAccount(c *Config) string {
+	if account := strings.TrimPrefix(c.Password, KeyringPrefix); account != "" {
+		return account
+	}
+	return c.Username
+}
+
+// KeyringBackend names the OS keyring passwords are stored in
+func KeyringBackend() string {
+	return keyringBackend()
+}
+
+// KeyringGet reads the password of account from the OS keyring
+func KeyringGet(account string) (string, error) {
+	password, err := keyringGet(account)
+	if err != nil {
+		return "", fmt.Errorf("reading keyring account %s: %w", account, err)
+	}
+	return password, nil
+}
+
+// KeyringSet stores the password of account in the OS keyring, replacing
+// any previous one
+func KeyringSet(account, password string) error {
+	if err := keyringSet(account, password); err != nil {
+		return fmt.Errorf("writing keyring account %s: %w", account, err)
+	}
+	return nil
+}
+
+// BindPassword returns the password of c, read from the OS keyring when it
+// is a "keyring:" reference
+func BindPassword(c *Config) (string, error) {
+	if !IsKeyringPassword(c.Password) {
+		return c.Password, nil
+	}
+	account := KeyringAccount(c)
+	if account == "" {
+		return "", fmt.Errorf("keyring password needs a username or an account name")
+	}
+	return KeyringGet(account)
+}
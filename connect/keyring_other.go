@@ -0,0 +1,59 @@
+//go:build !windows
+
+package connect
+
+import (
+	"bytes"
+	"fmt"
+	"os/exec"
+	"runtime"
+	"strings"
+)
+
+// keyringBackend names the macOS Keychain or the Secret Service
+func keyringBackend() string {
+	if runtime.GOOS == "darwin" {
+		return "macOS Keychain"
+	}
+	return "Secret Service (secret-tool)"
+}
+
+// keyringGet reads a password with the macOS security tool or, elsewhere,
+// the libsecret secret-tool
+func keyringGet(account string) (string, error) {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "find-generic-password", "-s", keyringService, "-a", account, "-w")
+	} else {
+		cmd = exec.Command("secret-tool", "lookup", "service", keyringService, "account", account)
+	}
+	var stdout, stderr bytes.Buffer
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if _, ok := err.(*exec.ExitError); ok {
+			return "", ErrKeyringNotFound
+		}
+		return "", fmt.Errorf("%s: %w", cmd.Path, err)
+	}
+	return strings.TrimSuffix(stdout.String(), "\n"), nil
+}
+
+// keyringSet stores a password with the macOS security tool or, elsewhere,
+// the libsecret secret-tool. The password is passed on stdin so it does
+// not show up in the process list.
+func keyringSet(account, password string) error {
+	var cmd *exec.Cmd
+	if runtime.GOOS == "darwin" {
+		cmd = exec.Command("security", "-i")
+		cmd.Stdin = strings.NewReader(fmt.Sprintf("add-generic-password -U -s %s -a %q -w %q\n", keyringService, account, password))
+	} else {
+		cmd = exec.Command("secret-tool", "store", "--label", keyringService+" "+account,
+			"service", keyringService, "account", account)
+		cmd.Stdin = strings.NewReader(password)
+	}
+	if out, err := cmd.CombinedOutput(); err != nil {
+		return fmt.Errorf("%s: %w: %s", cmd.Path, err, strings.TrimSpace(string(out)))
+	}
+	return nil
+}
@@ -0,0 +1,106 @@
+package connect
+
+import (
+	"fmt"
+	"unsafe"
+
+	"golang.org/x/sys/windows"
+)
+
+// Windows Credential Manager constants
+// Reference: https://learn.microsoft.com/en-us/windows/win32/api/wincred/ns-wincred-credentialw
+const (
+	credTypeGeneric         = 1
+	credPersistLocalMachine = 2
+)
+
+// credential mirrors the CREDENTIALW structure
+type credential struct {
+	Flags              uint32
+	Type               uint32
+	TargetName         *uint16
+	Comment            *uint16
+	LastWritten        windows.Filetime
+	CredentialBlobSize uint32
+	CredentialBlob     *byte
+	Persist            uint32
+	AttributeCount     uint32
+	Attributes         uintptr
+	TargetAlias        *uint16
+	UserName           *uint16
+}
+
+var (
+	advapi32      = windows.NewLazySystemDLL("advapi32.dll")
+	procCredRead  = advapi32.NewProc("CredReadW")
+	procCredWrite = advapi32.NewProc("CredWriteW")
+	procCredFree  = advapi32.NewProc("CredFree")
+)
+
+// keyringBackend names the Windows Credential Manager
+func keyringBackend() string {
+	return "Windows Credential Manager"
+}
+
+// keyringTarget returns the Credential Manager target name of an account
+func keyringTarget(account string) (*uint16, error) {
+	return windows.UTF16PtrFromString(keyringService + ":" + account)
+}
+
+// keyringGet reads a generic credential from the Windows Credential Manager
+func keyringGet(account string) (string, error) {
+	target, err := keyringTarget(account)
+	if err != nil {
+		return "", err
+	}
+
+	var cred *credential
+	r1, _, err := procCredRead.Call(
+		uintptr(unsafe.Pointer(target)),
+		uintptr(credTypeGeneric),
+		0,
+		uintptr(unsafe.Pointer(&cred)),
+	)
+	if r1 == 0 {
+		if err == windows.ERROR_NOT_FOUND {
+			return "", ErrKeyringNotFound
+		}
+		return "", fmt.Errorf("CredReadW: %w", err)
+	}
+	defer procCredFree.Call(uintptr(unsafe.Pointer(cred)))
+
+	if cred.CredentialBlobSize == 0 {
+		return "", nil
+	}
+	return string(unsafe.Slice(cred.CredentialBlob, cred.CredentialBlobSize)), nil
+}
+
+// keyringSet writes a generic credential to the Windows Credential Manager
+func keyringSet(account, password string) error {
+	target, err := keyringTarget(account)
+	if err != nil {
+		return err
+	}
+	user, err := windows.UTF16PtrFromString(account)
+	if err != nil {
+		return err
+	}
+
+	blob := []byte(password)
+	cred := credential{
+		Type:               credTypeGeneric,
+		TargetName:         target,
+		CredentialBlobSize: uint32(len(blob)),
+		Persist:            credPersistLocalMachine,
+		UserName:           user,
+	}
+	if len(blob) > 0 {
+		cred.CredentialBlob = &blob[0]
+	}
+
+	r1, _, err := procCredWrite.Call(uintptr(unsafe.Pointer(&cred)), 0)
+	if r1 == 0 {
+		return fmt.Errorf("CredWriteW: %w", err)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package connect
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Middleware observes and shapes the searches of a Client, so features
+// such as throttling, auditing, metrics and redaction compose without
+// changes to each client type. Embed BaseMiddleware to implement only
+// some of the hooks.
+type Middleware interface {
+	// OnSearchStart runs before a search is sent. It may return a derived
+	// context, or an error to refuse the search.
+	OnSearchStart(ctx context.Context, filter string, attributes []string) (context.Context, error)
+
+	// OnPage runs with the number of entries in each page received from
+	// the server. Clients that do not page (replay, cache hits) never call
+	// it. It runs on the search goroutine and must not block.
+	OnPage(ctx context.Context, entries int)
+
+	// OnEntry runs for each entry and returns the entry to pass on,
+	// possibly modified, or nil to drop it.
+	OnEntry(ctx context.Context, entry *ldap.Entry) *ldap.Entry
+
+	// OnError runs when a search fails and returns the error to report.
+	OnError(ctx context.Context, filter string, err error) error
+
+	// OnSearchEnd runs once a search has finished, with the number of
+	// entries passed on and the error reported, if any.
+	OnSearchEnd(ctx context.Context, filter string, entries int, err error)
+}
+
+// BaseMiddleware implements every Middleware hook as a no-op
+type BaseMiddleware struct{}
+
+func (BaseMiddleware) OnSearchStart(ctx context.Context, filter string, attributes []string) (context.Context, error) {
+	return ctx, nil
+}
+
+func (BaseMiddleware) OnPage(ctx context.Context, entries int) {}
+
+func (BaseMiddleware) OnEntry(ctx context.Context, entry *ldap.Entry) *ldap.Entry {
+	return entry
+}
+
+func (BaseMiddleware) OnError(ctx context.Context, filter string, err error) error {
+	return err
+}
+
+func (BaseMiddleware) OnSearchEnd(ctx context.Context, filter string, entries int, err error) {}
+
+// middlewareClient runs the searches of a Client through a Middleware chain
+type middlewareClient struct {
+	next  Client
+	chain []Middleware
+}
+
+// WithMiddleware wraps client so its searches pass through chain. Search
+// start, page and entry hooks run in chain order, error and search end
+// hooks in reverse order. Without middleware, client is returned unchanged.
+func WithMiddleware(client Client, chain ...Middleware) Client {
+	if len(chain) == 0 {
+		return client
+	}
+	return &middlewareClient{next: client, chain: chain}
+}
+
+// start runs the OnSearchStart hooks and installs the OnPage hooks,
+// keeping a page hook already set on ctx
+func (c *middlewareClient) start(ctx context.Context, filter string, attributes []string) (context.Context, error) {
+	for _, mw := range c.chain {
+		var err error
+		if ctx, err = mw.OnSearchStart(ctx, filter, attributes); err != nil {
+			err = c.fail(ctx, filter, err)
+			c.end(ctx, filter, 0, err)
+			return ctx, err
+		}
+	}
+
+	prev := pageHook(ctx)
+	hookCtx := ctx
+	return WithPageHook(ctx, func(entries int) {
+		if prev != nil {
+			prev(entries)
+		}
+		for _, mw := range c.chain {
+			mw.OnPage(hookCtx, entries)
+		}
+	}), nil
+}
+
+// entry runs the OnEntry hooks; nil means the entry was dropped
+func (c *middlewareClient) entry(ctx context.Context, e *ldap.Entry) *ldap.Entry {
+	for _, mw := range c.chain {
+		if e = mw.OnEntry(ctx, e); e == nil {
+			return nil
+		}
+	}
+	return e
+}
+
+// fail runs the OnError hooks from the last middleware to the first
+func (c *middlewareClient) fail(ctx context.Context, filter string, err error) error {
+	for i := len(c.chain) - 1; i >= 0 && err != nil; i-- {
+		err = c.chain[i].OnError(ctx, filter, err)
+	}
+	return err
+}
+
+// end runs the OnSearchEnd hooks from the last middleware to the first
+func (c *middlewareClient) end(ctx context.Context, filter string, entries int, err error) {
+	for i := len(c.chain) - 1; i >= 0; i-- {
+		c.chain[i].OnSearchEnd(ctx, filter, entries, err)
+	}
+}
+
+// Search runs a search through the middleware chain
+func (c *middlewareClient) Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error) {
+	ctx, err := c.start(ctx, filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+
+	entries, err := c.next.Search(ctx, filter, attributes)
+	kept := entries[:0]
+	for _, e := range entries {
+		if e = c.entry(ctx, e); e != nil {
+			kept = append(kept, e)
+		}
+	}
+	if err != nil {
+		err = c.fail(ctx, filter, err)
+	}
+	c.end(ctx, filter, len(kept), err)
+	return kept, err
+}
+
+// StreamSearch streams a search through the middleware chain
+func (c *middlewareClient) StreamSearch(ctx context.Context, filter string, attributes []string) (<-chan *ldap.Entry, <-chan error) {
+	entriesChan := make(chan *ldap.Entry, 100)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(entriesChan)
+		defer close(errChan)
+
+		ctx, err := c.start(ctx, filter, attributes)
+		if err != nil {
+			errChan <- err
+			return
+		}
+
+		entries, errs := c.next.StreamSearch(ctx, filter, attributes)
+		sent := 0
+		for e := range entries {
+			if e = c.entry(ctx, e); e == nil {
+				continue
+			}
+			select {
+			case entriesChan <- e:
+				sent++
+			case <-ctx.Done():
+				// Let the wrapped client see the cancellation and finish
+				for range entries {
+				}
+				err := c.fail(ctx, filter, ctx.Err())
+				c.end(ctx, filter, sent, err)
+				errChan <- err
+				return
+			}
+		}
+		err = <-errs
+		if err != nil {
+			err = c.fail(ctx, filter, err)
+		}
+		c.end(ctx, filter, sent, err)
+		if err != nil {
+			errChan <- err
+		}
+	}()
+
+	return entriesChan, errChan
+}
+
+// Ping checks the wrapped client
+func (c *middlewareClient) Ping(ctx context.Context) error {
+	return c.next.Ping(ctx)
+}
+
+// Close closes the wrapped client
+func (c *middlewareClient) Close() error {
+	return c.next.Close()
+}
+
+// Throttle is a Middleware that spaces the start of searches at least
+// Interval apart, to stay below detection or rate thresholds
+type Throttle struct {
+	BaseMiddleware
+	Interval time.Duration
+
+	mu   sync.Mutex
+	next time.Time // Earliest start of the next search
+}
+
+// NewThrottle returns a Throttle with the given interval
+func NewThrottle(interval time.Duration) *Throttle {
+	return &Throttle{Interval: interval}
+}
+
+// OnSearchStart waits until the interval since the previous search has passed
+func (t *Throttle) OnSearchStart(ctx context.Context, filter string, attributes []string) (context.Context, error) {
+	t.mu.Lock()
+	now := time.Now()
+	start := t.next
+	if start.Before(now) {
+		start = now
+	}
+	t.next = start.Add(t.Interval)
+	t.mu.Unlock()
+
+	if wait := time.Until(start); wait > 0 {
+		timer := time.NewTimer(wait)
+		defer timer.Stop()
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			return ctx, ctx.Err()
+		}
+	}
+	return ctx, nil
+}
@@ -0,0 +1,32 @@
+package connect
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Searcher runs a search below the base DN; Client satisfies it
+type Searcher interface {
+	Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error)
+}
+
+// ReadNamingContext returns the DN of the partition named by a RootDSE
+// attribute, e.g. configurationNamingContext, of the server s searches.
+// The configuration and schema partitions belong to the forest root, so
+// they cannot be derived from the base DN of a child domain.
+func ReadNamingContext(ctx context.Context, s Searcher, name string) (string, error) {
+	entries, err := s.Search(WithBaseObject(ctx, ""), "(objectClass=*)", []string{name})
+	if err != nil {
+		return "", fmt.Errorf("reading RootDSE: %w", err)
+	}
+	if len(entries) == 0 {
+		return "", fmt.Errorf("no entries returned from RootDSE")
+	}
+	dn := entries[0].GetEqualFoldAttributeValue(name)
+	if dn == "" {
+		return "", fmt.Errorf("RootDSE does not name a %s", name)
+	}
+	return dn, nil
+}
@@ -0,0 +1,60 @@
+package connect
+
+import (
+	"context"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// rootDSESearcher answers RootDSE reads with the naming contexts of a child
+// domain of corp.local
+type rootDSESearcher struct{}
+
+func (rootDSESearcher) Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error) {
+	if base, scope := searchBase(ctx, "DC=child,DC=corp,DC=local"); base != "" || scope != ldap.ScopeBaseObject {
+		return nil, nil
+	}
+	return []*ldap.Entry{ldap.NewEntry("", map[string][]string{
+		"configurationNamingContext": {"CN=Configuration,DC=corp,DC=local"},
+		"schemaNamingContext":        {"CN=Schema,CN=Configuration,DC=corp,DC=local"},
+	})}, nil
+}
+
+func TestReadNamingContext(t *testing.T) {
+	ctx := context.Background()
+	dn, err := ReadNamingContext(ctx, rootDSESearcher{}, "configurationNamingContext")
+	if err != nil || dn != "CN=Configuration,DC=corp,DC=local" {
+		t.Errorf("configuration = %q, %v", dn, err)
+	}
+	dn, err = ReadNamingContext(ctx, rootDSESearcher{}, "schemaNamingContext")
+	if err != nil || dn != "CN=Schema,CN=Configuration,DC=corp,DC=local" {
+		t.Errorf("schema = %q, %v", dn, err)
+	}
+	if _, err := ReadNamingContext(ctx, rootDSESearcher{}, "rootDomainNamingContext"); err == nil {
+		t.Error("ReadNamingContext accepted a RootDSE without the naming context")
+	}
+}
+
+func TestSearchBase(t *testing.T) {
+	const baseDN = "DC=child,DC=corp,DC=local"
+	const configDN = "CN=Configuration,DC=corp,DC=local"
+	partition := WithSearchBase(context.Background(), configDN)
+	tests := []struct {
+		name  string
+		ctx   context.Context
+		base  string
+		scope int
+	}{
+		{"default", context.Background(), baseDN, ldap.ScopeWholeSubtree},
+		{"search base", partition, configDN, ldap.ScopeWholeSubtree},
+		{"base object", WithBaseObject(context.Background(), "CN=x"), "CN=x", ldap.ScopeBaseObject},
+		{"base object in partition", WithBaseObject(partition, "CN=x"), "CN=x", ldap.ScopeBaseObject},
+	}
+	for _, tt := range tests {
+		base, scope := searchBase(tt.ctx, baseDN)
+		if base != tt.base || scope != tt.scope {
+			t.Errorf("%s: searchBase = %s, %d; want %s, %d", tt.name, base, scope, tt.base, tt.scope)
+		}
+	}
+}
@@ -21,21 +21,26 @@ type ConnPool struct {
 	closed    int32 // atomic
 	connCount int32 // atomic
 	maxSize   int
+
+	idleTimeout time.Duration
+	maxLifetime time.Duration
+	created     map[*ldap.Conn]time.Time // When each connection was opened, guarded by mu
+	lastUsed    map[*ldap.Conn]time.Time // When each connection was last returned, guarded by mu
 }
 
 // PoolConfig defines connection pool configuration
 type PoolConfig struct {
-	MaxConns     int           // Maximum number of connections in the pool
-	IdleTimeout  time.Duration // Idle timeout for connections
-	MaxLifetime  time.Duration // Maximum lifetime of a connection
+	MaxConns    int           `mapstructure:"maxConns" yaml:"maxConns"`       // Maximum number of connections in the pool
+	IdleTimeout time.Duration `mapstructure:"idleTimeout" yaml:"idleTimeout"` // Idle connections are replaced after this long (0 = never)
+	MaxLifetime time.Duration `mapstructure:"maxLifetime" yaml:"maxLifetime"` // Connections are replaced after this long (0 = never)
 }
 
 // DefaultPoolConfig returns default pool configuration
 func DefaultPoolConfig() PoolConfig {
 	return PoolConfig{
-		MaxConns:    5,  // 5 connections by default
-		IdleTimeout:  5 * time.Minute,
-		MaxLifetime: 30 * time.Minute,
+		MaxConns:    analyze.DefaultPoolMaxConns,
+		IdleTimeout: time.Duration(analyze.DefaultPoolIdleTimeout) * time.Minute,
+		MaxLifetime: time.Duration(analyze.DefaultPoolMaxLifetime) * time.Minute,
 	}
 }
 
@@ -53,6 +58,11 @@ func NewConnPool(config *Config, poolCfg PoolConfig) (*ConnPool, error) {
 		conns:   make(chan *ldap.Conn, poolCfg.MaxConns),
 		config:   config,
 		maxSize:  poolCfg.MaxConns,
+
+		idleTimeout: poolCfg.IdleTimeout,
+		maxLifetime: poolCfg.MaxLifetime,
+		created:     make(map[*ldap.Conn]time.Time),
+		lastUsed:    make(map[*ldap.Conn]time.Time),
 	}
 
 	// Create factory function
@@ -72,6 +82,7 @@ func NewConnPool(config *Config, poolCfg PoolConfig) (*ConnPool, error) {
 			// Log warning but continue
 			continue
 		}
+		pool.track(conn)
 		pool.conns <- conn
 		atomic.AddInt32(&pool.connCount, 1)
 	}
@@ -88,13 +99,12 @@ func (p *ConnPool) Get(ctx context.Context) (*ldap.Conn, error) {
 
 	select {
 	case conn := <-p.conns:
-		// Verify connection is still alive
-		if p.isAlive(conn) {
+		// Verify connection is still alive and not due for replacement
+		if !p.expired(conn) && p.isAlive(conn) {
 			return conn, nil
 		}
-		// Connection is dead, close it
-		_ = conn.Close()
-		atomic.AddInt32(&p.connCount, -1)
+		// Connection is dead or expired, close it
+		p.discard(conn)
 
 		// Fall through to create new connection
 	case <-ctx.Done():
@@ -105,11 +115,10 @@ func (p *ConnPool) Get(ctx context.Context) (*ldap.Conn, error) {
 			// Wait for a connection to become available
 			select {
 			case conn := <-p.conns:
-				if p.isAlive(conn) {
+				if !p.expired(conn) && p.isAlive(conn) {
 					return conn, nil
 				}
-				_ = conn.Close()
-				atomic.AddInt32(&p.connCount, -1)
+				p.discard(conn)
 			case <-ctx.Done():
 				return nil, ctx.Err()
 			}
@@ -122,6 +131,7 @@ func (p *ConnPool) Get(ctx context.Context) (*ldap.Conn, error) {
 		return nil, fmt.Errorf("creating new connection: %w", err)
 	}
 
+	p.track(conn)
 	atomic.AddInt32(&p.connCount, 1)
 	return conn, nil
 }
@@ -138,17 +148,52 @@ func (p *ConnPool) Put(conn *ldap.Conn) error {
 		return conn.Close()
 	}
 
+	p.mu.Lock()
+	p.lastUsed[conn] = time.Now()
+	p.mu.Unlock()
+
 	select {
 	case p.conns <- conn:
 		// Successfully returned to pool
 		return nil
 	default:
 		// Pool is full, close the connection
-		atomic.AddInt32(&p.connCount, -1)
-		return conn.Close()
+		p.discard(conn)
+		return nil
 	}
 }
 
+// track records when a new connection was opened
+func (p *ConnPool) track(conn *ldap.Conn) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	now := time.Now()
+	p.created[conn] = now
+	p.lastUsed[conn] = now
+}
+
+// expired reports whether a pooled connection exceeded the maximum lifetime
+// or was idle longer than the idle timeout
+func (p *ConnPool) expired(conn *ldap.Conn) bool {
+	p.mu.RLock()
+	defer p.mu.RUnlock()
+	if p.maxLifetime > 0 && time.Since(p.created[conn]) > p.maxLifetime {
+		return true
+	}
+	return p.idleTimeout > 0 && time.Since(p.lastUsed[conn]) > p.idleTimeout
+}
+
+// discard closes a connection and removes it from the pool
+func (p *ConnPool) discard(conn *ldap.Conn) {
+	p.mu.Lock()
+	delete(p.created, conn)
+	delete(p.lastUsed, conn)
+	p.mu.Unlock()
+
+	_ = conn.Close()
+	atomic.AddInt32(&p.connCount, -1)
+}
+
 // Close closes all connections in the pool and prevents new connections from being created
 func (p *ConnPool) Close() error {
 	// Mark pool as closed
@@ -241,6 +286,11 @@ func NewPoolingClient(config *Config, poolCfg PoolConfig) (Client, error) {
 	}, nil
 }
 
+// Pool returns the connection pool of the client
+func (pc *PoolingClient) Pool() *ConnPool {
+	return pc.pool
+}
+
 // Search executes a search using a connection from the pool
 func (pc *PoolingClient) Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error) {
 	// Get connection from pool
@@ -329,9 +379,10 @@ func (pc *PoolingClient) Close() error {
 
 // searchWithConn performs a search using a specific connection
 func (pc *PoolingClient) searchWithConn(ctx context.Context, conn *ldap.Conn, filter string, attributes []string) ([]*ldap.Entry, error) {
+	baseDN, scope := searchBase(ctx, pc.config.BaseDN)
 	searchReq := ldap.NewSearchRequest(
-		pc.config.BaseDN,
-		ldap.ScopeWholeSubtree,
+		baseDN,
+		scope,
 		ldap.NeverDerefAliases,
 		0, // SizeLimit: set from config
 		0, // TimeLimit: set from config
@@ -349,6 +400,7 @@ func (pc *PoolingClient) searchWithConn(ctx context.Context, conn *ldap.Conn, fi
 	// Add paging control
 	pagingControl := ldap.NewControlPaging(uint32(analyze.DefaultPagingSize))
 	searchReq.Controls = []ldap.Control{pagingControl}
+	searchReq.Controls = append(searchReq.Controls, searchControls(ctx, attributes)...)
 
 	var allEntries []*ldap.Entry
 
@@ -360,6 +412,7 @@ func (pc *PoolingClient) searchWithConn(ctx context.Context, conn *ldap.Conn, fi
 		}
 
 		// Append entries
+		normalizeEntries(ctx, sr.Entries)
 		allEntries = append(allEntries, sr.Entries...)
 
 		// Check if there are more pages
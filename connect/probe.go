@@ -0,0 +1,142 @@
+package connect
+
+import (
+	"adgo/analyze"
+	"adgo/redact"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Probe outcomes of a relay protection
+const (
+	ProbeEnforced    = "enforced"
+	ProbeNotEnforced = "not enforced"
+	ProbeUnknown     = "unknown"
+)
+
+// ntlmChannelBindingError is the SSPI status in the diagnostic message of a
+// bind rejected for lacking a channel binding token
+// (SEC_E_BAD_BINDINGS)
+const ntlmChannelBindingError = "80090346"
+
+// ProbeResult is the outcome of a relay protection probe
+type ProbeResult struct {
+	Outcome string `json:"outcome"`          // One of the Probe* outcomes
+	Detail  string `json:"detail,omitempty"` // Error or explanation behind the outcome
+}
+
+// ProbeLDAPSigning tells whether the server of c requires LDAP signing by
+// binding with NTLM over plain LDAP on port 389 without signing, as a relay
+// would. The bind uses the credentials of c and counts as one logon.
+func ProbeLDAPSigning(c *Config) ProbeResult {
+	probe := *c
+	probe.Security, probe.Port = SecurityNone, 389
+	conn, err := ldapDial(&probe)
+	if err != nil {
+		return ProbeResult{Outcome: ProbeUnknown, Detail: redact.Text(err.Error())}
+	}
+	defer conn.Close()
+
+	err = ntlmBind(conn, &probe)
+	switch {
+	case err == nil:
+		return ProbeResult{Outcome: ProbeNotEnforced, Detail: "NTLM bind without signing accepted"}
+	case ldap.IsErrorWithCode(err, ldap.LDAPResultStrongAuthRequired):
+		return ProbeResult{Outcome: ProbeEnforced, Detail: "strongerAuthRequired"}
+	}
+	return ProbeResult{Outcome: ProbeUnknown, Detail: redact.Text(err.Error())}
+}
+
+// ProbeChannelBinding tells whether the server of c requires LDAPS channel
+// binding by binding with NTLM over LDAPS on port 636 without a channel
+// binding token. Certificates are not verified: no data is exchanged
+// beyond the bind. The bind uses the credentials of c and counts as one
+// logon.
+func ProbeChannelBinding(c *Config) ProbeResult {
+	probe := *c
+	probe.Security, probe.Port = SecurityInsecureTLS, 636
+	conn, err := ldapDial(&probe)
+	if err != nil {
+		return ProbeResult{Outcome: ProbeUnknown, Detail: "LDAPS unavailable: " + redact.Text(err.Error())}
+	}
+	defer conn.Close()
+
+	err = ntlmBind(conn, &probe)
+	switch {
+	case err == nil:
+		return ProbeResult{Outcome: ProbeNotEnforced, Detail: "NTLM bind without channel binding accepted"}
+	case strings.Contains(err.Error(), ntlmChannelBindingError):
+		return ProbeResult{Outcome: ProbeEnforced, Detail: "bind rejected with " + ntlmChannelBindingError}
+	}
+	return ProbeResult{Outcome: ProbeUnknown, Detail: redact.Text(err.Error())}
+}
+
+// ntlmBind binds conn with NTLM, which go-ldap performs without signing
+// or a channel binding token
+func ntlmBind(conn *ldap.Conn, c *Config) error {
+	username, err := formatBindUsername(c)
+	if err != nil {
+		return fmt.Errorf("failed to format username: %w", err)
+	}
+	password, err := BindPassword(c)
+	if err != nil {
+		return err
+	}
+	redact.AddSecret(password)
+
+	started := time.Now()
+	bindErr := conn.NTLMBind("", username, password)
+	if bindHook != nil {
+		bindHook(c, username, started, bindErr)
+	}
+	return bindErr
+}
+
+// ProbeWebEnrollment requests the AD CS web enrollment page at url (e.g.
+// "http://ca01.corp.local/certsrv/") and reports whether it asks for NTLM
+// or Negotiate authentication, which makes it an NTLM relay target (ESC8).
+// An error means the page could not be reached.
+func ProbeWebEnrollment(ctx context.Context, url string, timeout time.Duration) (bool, error) {
+	if timeout == 0 {
+		timeout = time.Duration(analyze.DefaultConnectionTimeout) * time.Second
+	}
+	client := &http.Client{
+		Timeout: timeout,
+		Transport: &http.Transport{
+			DialContext:     (&net.Dialer{Timeout: timeout}).DialContext,
+			TLSClientConfig: &tls.Config{InsecureSkipVerify: true},
+		},
+		CheckRedirect: func(*http.Request, []*http.Request) error { return http.ErrUseLastResponse },
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return false, errors.New("web enrollment not installed (404)")
+	}
+	if resp.StatusCode != http.StatusUnauthorized {
+		return false, nil
+	}
+	for _, scheme := range resp.Header.Values("WWW-Authenticate") {
+		scheme = strings.ToUpper(strings.TrimSpace(scheme))
+		if strings.HasPrefix(scheme, "NTLM") || strings.HasPrefix(scheme, "NEGOTIATE") {
+			return true, nil
+		}
+	}
+	return false, nil
+}
@@ -0,0 +1,334 @@
+package connect
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ErrNotRecorded is returned by a ReplayClient for searches missing from the recording
+var ErrNotRecorded = errors.New("search not found in recording")
+
+// Exchange is one recorded search request and its response
+type Exchange struct {
+	Time       time.Time       `json:"time"`                 // When the search was sent
+	Server     string          `json:"server"`               // Server the search was sent to
+	BaseDN     string          `json:"baseDN"`               // Search base
+	BaseObject bool            `json:"baseObject,omitempty"` // Only the base object was read (WithBaseObject)
+	Filter     string          `json:"filter"`               // LDAP filter
+	Attributes []string        `json:"attributes"`           // Requested attributes
+	ExtendedDN bool            `json:"extendedDN"`           // DN-valued attributes were requested in extended form
+	Entries    []RecordedEntry `json:"entries"`              // Returned entries
+	Error      string          `json:"error,omitempty"`      // Search error, if any
+}
+
+// RecordedEntry is an LDAP entry with its raw values (base64 in JSON)
+type RecordedEntry struct {
+	DN         string              `json:"dn"`
+	Attributes []RecordedAttribute `json:"attributes"`
+}
+
+// RecordedAttribute is one attribute of a RecordedEntry
+type RecordedAttribute struct {
+	Name   string   `json:"name"`
+	Values [][]byte `json:"values"`
+}
+
+// newRecordedEntry copies the raw values of e
+func newRecordedEntry(e *ldap.Entry) RecordedEntry {
+	r := RecordedEntry{DN: e.DN, Attributes: make([]RecordedAttribute, len(e.Attributes))}
+	for i, attr := range e.Attributes {
+		r.Attributes[i] = RecordedAttribute{Name: attr.Name, Values: attr.ByteValues}
+	}
+	return r
+}
+
+// entry converts a recorded entry back into an LDAP entry
+func (r RecordedEntry) entry() *ldap.Entry {
+	e := &ldap.Entry{DN: r.DN, Attributes: make([]*ldap.EntryAttribute, len(r.Attributes))}
+	for i, attr := range r.Attributes {
+		values := make([]string, len(attr.Values))
+		for j, v := range attr.Values {
+			values[j] = string(v)
+		}
+		e.Attributes[i] = &ldap.EntryAttribute{Name: attr.Name, Values: values, ByteValues: attr.Values}
+	}
+	return e
+}
+
+// exchangeKey identifies a search independent of attribute order and case
+func exchangeKey(baseDN string, baseObject bool, filter string, attributes []string, extendedDN bool) string {
+	attrs := make([]string, len(attributes))
+	for i, a := range attributes {
+		attrs[i] = strings.ToLower(a)
+	}
+	slices.Sort(attrs)
+	return fmt.Sprintf("%s\x00%t\x00%s\x00%s\x00%t", strings.ToLower(baseDN), baseObject, filter, strings.Join(attrs, ","), extendedDN)
+}
+
+// searchKey is the exchangeKey of a search sent with ctx under baseDN
+func searchKey(ctx context.Context, baseDN, filter string, attributes []string) string {
+	base, scope := searchBase(ctx, baseDN)
+	return exchangeKey(base, scope == ldap.ScopeBaseObject, filter, attributes, extendedDNRequested(ctx))
+}
+
+// newExchange starts the record of a search sent with config
+func newExchange(ctx context.Context, config *Config, filter string, attributes []string) Exchange {
+	base, scope := searchBase(ctx, config.BaseDN)
+	return Exchange{
+		Time:       time.Now().UTC(),
+		Server:     config.Server,
+		BaseDN:     base,
+		BaseObject: scope == ldap.ScopeBaseObject,
+		Filter:     filter,
+		Attributes: attributes,
+		ExtendedDN: extendedDNRequested(ctx),
+	}
+}
+
+// Recorder writes exchanges to a JSON Lines file. It is safe for
+// concurrent use by several RecordingClients.
+type Recorder struct {
+	mu   sync.Mutex
+	file *os.File
+	enc  *json.Encoder
+}
+
+// NewRecorder creates (or truncates) the recording file at path
+func NewRecorder(path string) (*Recorder, error) {
+	// Recordings contain every returned attribute; keep them private
+	f, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return nil, fmt.Errorf("creating recording: %w", err)
+	}
+	enc := json.NewEncoder(f)
+	enc.SetEscapeHTML(false)
+	return &Recorder{file: f, enc: enc}, nil
+}
+
+// Record appends one exchange to the recording
+func (r *Recorder) Record(x Exchange) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if err := r.enc.Encode(x); err != nil {
+		return fmt.Errorf("writing recording: %w", err)
+	}
+	return nil
+}
+
+// Close closes the recording file
+func (r *Recorder) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
+
+// RecordingClient wraps a Client and records every search and its response
+type RecordingClient struct {
+	client   Client
+	config   *Config
+	recorder *Recorder
+}
+
+// NewRecordingClient creates a client that records the searches of client
+func NewRecordingClient(client Client, config *Config, recorder *Recorder) *RecordingClient {
+	return &RecordingClient{client: client, config: config, recorder: recorder}
+}
+
+// Search executes a search and records the request and its result
+func (rc *RecordingClient) Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error) {
+	x := newExchange(ctx, rc.config, filter, attributes)
+	entries, err := rc.client.Search(ctx, filter, attributes)
+	for _, e := range entries {
+		x.Entries = append(x.Entries, newRecordedEntry(e))
+	}
+	rc.record(x, err)
+	return entries, err
+}
+
+// StreamSearch streams a search, recording the entries as they pass through
+// and the request once the stream ends
+func (rc *RecordingClient) StreamSearch(ctx context.Context, filter string, attributes []string) (<-chan *ldap.Entry, <-chan error) {
+	in, inErr := rc.client.StreamSearch(ctx, filter, attributes)
+	out := make(chan *ldap.Entry)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		x := newExchange(ctx, rc.config, filter, attributes)
+		for e := range in {
+			x.Entries = append(x.Entries, newRecordedEntry(e))
+			select {
+			case out <- e:
+			case <-ctx.Done(): // Keep draining so the search can finish
+			}
+		}
+		err := <-inErr
+		rc.record(x, err)
+		errChan <- err
+	}()
+
+	return out, errChan
+}
+
+// Ping checks the wrapped client
+func (rc *RecordingClient) Ping(ctx context.Context) error {
+	return rc.client.Ping(ctx)
+}
+
+// Close closes the wrapped client; the Recorder stays open
+func (rc *RecordingClient) Close() error {
+	return rc.client.Close()
+}
+
+// record writes a finished exchange. A failed write does not fail the search.
+func (rc *RecordingClient) record(x Exchange, err error) {
+	if err != nil {
+		x.Error = err.Error()
+	}
+	_ = rc.recorder.Record(x)
+}
+
+// Recording is a recording loaded for replay
+type Recording struct {
+	mu        sync.Mutex
+	exchanges []Exchange
+	responses map[string][]int // Exchange indexes by key, in recorded order
+	served    map[string]int   // Number of responses served per key
+}
+
+// LoadRecording reads a recording written by a Recorder
+func LoadRecording(path string) (*Recording, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("opening recording: %w", err)
+	}
+	defer f.Close()
+
+	rec := &Recording{responses: make(map[string][]int), served: make(map[string]int)}
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(nil, 256<<20)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if strings.TrimSpace(scanner.Text()) == "" {
+			continue
+		}
+		var x Exchange
+		if err := json.Unmarshal(scanner.Bytes(), &x); err != nil {
+			return nil, fmt.Errorf("recording line %d: %w", line, err)
+		}
+		key := exchangeKey(x.BaseDN, x.BaseObject, x.Filter, x.Attributes, x.ExtendedDN)
+		rec.responses[key] = append(rec.responses[key], len(rec.exchanges))
+		rec.exchanges = append(rec.exchanges, x)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading recording: %w", err)
+	}
+	if len(rec.exchanges) == 0 {
+		return nil, fmt.Errorf("recording %s is empty", path)
+	}
+	return rec, nil
+}
+
+// Server returns the server of the first recorded exchange
+func (r *Recording) Server() string {
+	return r.exchanges[0].Server
+}
+
+// BaseDN returns the base DN of the first recorded exchange
+func (r *Recording) BaseDN() string {
+	return r.exchanges[0].BaseDN
+}
+
+// next returns the response to a search. Repeated searches get the
+// recorded responses in order; the last one is served again once all
+// have been used, so polling commands keep working.
+func (r *Recording) next(ctx context.Context, baseDN, filter string, attributes []string) (Exchange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := searchKey(ctx, baseDN, filter, attributes)
+	indexes := r.responses[key]
+	if len(indexes) == 0 {
+		base, _ := searchBase(ctx, baseDN)
+		return Exchange{}, fmt.Errorf("%w: base %s, filter %s", ErrNotRecorded, base, filter)
+	}
+	n := min(r.served[key], len(indexes)-1)
+	r.served[key]++
+	return r.exchanges[indexes[n]], nil
+}
+
+// ReplayClient serves searches from a recording without connecting
+type ReplayClient struct {
+	recording *Recording
+	config    *Config
+}
+
+// NewReplayClient creates a client answering from a recording. Searches are
+// matched by the base DN of config, the filter and the attributes.
+func NewReplayClient(recording *Recording, config *Config) *ReplayClient {
+	return &ReplayClient{recording: recording, config: config}
+}
+
+// Search returns the recorded result of a search
+func (rc *ReplayClient) Search(ctx context.Context, filter string, attributes []string) ([]*ldap.Entry, error) {
+	x, err := rc.recording.next(ctx, rc.config.BaseDN, filter, attributes)
+	if err != nil {
+		return nil, err
+	}
+	if x.Error != "" {
+		return nil, errors.New(x.Error)
+	}
+	return x.entries(), nil
+}
+
+// StreamSearch streams the recorded result of a search
+func (rc *ReplayClient) StreamSearch(ctx context.Context, filter string, attributes []string) (<-chan *ldap.Entry, <-chan error) {
+	out := make(chan *ldap.Entry)
+	errChan := make(chan error, 1)
+
+	go func() {
+		defer close(out)
+		x, err := rc.recording.next(ctx, rc.config.BaseDN, filter, attributes)
+		if err != nil {
+			errChan <- err
+			return
+		}
+		// Entries received before a recorded failure are streamed too
+		for _, e := range x.Entries {
+			select {
+			case out <- e.entry():
+			case <-ctx.Done():
+				errChan <- ctx.Err()
+				return
+			}
+		}
+		if x.Error != "" {
+			errChan <- errors.New(x.Error)
+			return
+		}
+		errChan <- nil
+	}()
+
+	return out, errChan
+}
+
+// Ping always succeeds
+func (rc *ReplayClient) Ping(ctx context.Context) error {
+	return nil
+}
+
+// Close does nothing; the recording stays loaded
+func (rc *ReplayClient) Close() error {
+	return nil
+}
@@ -11,10 +11,10 @@ import (
 
 // RetryConfig defines the retry behavior for LDAP connections
 type RetryConfig struct {
-	MaxAttempts  int           // Maximum number of retry attempts
-	InitialDelay time.Duration // Initial delay before first retry
-	MaxDelay     time.Duration // Maximum delay between retries
-	Multiplier   float64       // Multiplier for exponential backoff
+	MaxAttempts  int           `mapstructure:"maxAttempts" yaml:"maxAttempts"`   // Maximum number of retry attempts
+	InitialDelay time.Duration `mapstructure:"initialDelay" yaml:"initialDelay"` // Initial delay before first retry
+	MaxDelay     time.Duration `mapstructure:"maxDelay" yaml:"maxDelay"`         // Maximum delay between retries
+	Multiplier   float64       `mapstructure:"multiplier" yaml:"multiplier"`     // Multiplier for exponential backoff
 }
 
 // DefaultRetryConfig returns the default retry configuration
@@ -35,6 +35,9 @@ func ldapBindWithRetry(c *Config, retryCfg RetryConfig) (*ldap.Conn, error) {
 		if attempt > 0 {
 			// Calculate backoff delay
 			delay := calculateBackoff(attempt, retryCfg)
+			if retryHook != nil {
+				retryHook(attempt, lastErr)
+			}
 			fmt.Printf("Retry attempt %d/%d after %v (previous error: %v)\n",
 				attempt+1, retryCfg.MaxAttempts, delay, lastErr)
 			time.Sleep(delay)
@@ -55,6 +58,18 @@ func ldapBindWithRetry(c *Config, retryCfg RetryConfig) (*ldap.Conn, error) {
 	return nil, fmt.Errorf("failed after %d attempt(s): %w", retryCfg.MaxAttempts, lastErr)
 }
 
+// RetryHook observes every retried connection attempt
+type RetryHook func(attempt int, err error)
+
+// retryHook is set by SetRetryHook
+var retryHook RetryHook
+
+// SetRetryHook makes hook observe every retry from now on; nil removes it.
+// Set it before connecting: it is not synchronized with running retries.
+func SetRetryHook(hook RetryHook) {
+	retryHook = hook
+}
+
 // calculateBackoff calculates the delay for a given retry attempt using exponential backoff
 func calculateBackoff(attempt int, cfg RetryConfig) time.Duration {
 	delay := cfg.InitialDelay * time.Duration(math.Pow(cfg.Multiplier, float64(attempt)))
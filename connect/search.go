@@ -4,6 +4,7 @@ import (
 	"adgo/analyze"
 	"context"
 	"fmt"
+	"strings"
 	"sync"
 	"time"
 
@@ -47,12 +48,20 @@ type ldapClient struct {
 
 // NewClient creates and initializes a new LDAP client with retry support
 func NewClient(c *Config) (Client, error) {
+	return NewClientWithRetry(c, DefaultRetryConfig())
+}
+
+// NewClientWithRetry creates a client, retrying the connection as configured
+// by retryCfg
+func NewClientWithRetry(c *Config, retryCfg RetryConfig) (Client, error) {
 	if c == nil {
 		return nil, fmt.Errorf("config cannot be nil")
 	}
+	if retryCfg.MaxAttempts < 1 {
+		retryCfg.MaxAttempts = 1
+	}
 
 	// Use retry mechanism for connection
-	retryCfg := DefaultRetryConfig()
 	conn, err := ldapBindWithRetry(c, retryCfg)
 	if err != nil {
 		return nil, fmt.Errorf("failed to connect/bind to LDAP server: %w", err)
@@ -167,9 +176,10 @@ func (c *ldapClient) StreamSearch(ctx context.Context, filter string, attributes
 // executeSearch handles the core search logic with pagination
 func (c *ldapClient) executeSearch(ctx context.Context, filter string, attributes []string, handler func([]*ldap.Entry) error) error {
 	// 1. Build base search request
+	baseDN, scope := searchBase(ctx, c.config.BaseDN)
 	searchReq := ldap.NewSearchRequest(
-		c.config.BaseDN,
-		ldap.ScopeWholeSubtree,
+		baseDN,
+		scope,
 		ldap.NeverDerefAliases,
 		0, // SizeLimit: set below from config
 		0, // TimeLimit: 0 means unlimited (can be configured)
@@ -191,6 +201,8 @@ func (c *ldapClient) executeSearch(ctx context.Context, filter string, attribute
 		searchReq.Controls = []ldap.Control{pagingControl}
 	}
 
+	searchReq.Controls = append(searchReq.Controls, searchControls(ctx, attributes)...)
+
 	for {
 		select {
 		case <-ctx.Done():
@@ -211,6 +223,10 @@ func (c *ldapClient) executeSearch(ctx context.Context, filter string, attribute
 		}
 
 		// Process current page
+		normalizeEntries(ctx, result.Entries)
+		if hook := pageHook(ctx); hook != nil {
+			hook(len(result.Entries))
+		}
 		if err := handler(result.Entries); err != nil {
 			if pagingControl != nil {
 				_ = c.abandonPaging(searchReq)
@@ -275,3 +291,114 @@ func (c *ldapClient) abandonPaging(req *ldap.SearchRequest) error {
 	_, err := c.conn.Search(abandonReq)
 	return err
 }
+
+// sdFlagsOwnerGroupDACL selects the OWNER, GROUP and DACL security information
+const sdFlagsOwnerGroupDACL = 0x7
+
+// extendedDNStringFormat is the BER-encoded extended DN control value
+// SEQUENCE { INTEGER 1 }, which selects string GUIDs and SIDs instead of hex
+const extendedDNStringFormat = "\x30\x03\x02\x01\x01"
+
+// extendedDNKey is the context key set by WithExtendedDN
+type extendedDNKey struct{}
+
+// WithExtendedDN returns a context whose searches return DN-valued attributes
+// in extended form (<GUID=...>;<SID=...>;CN=...), so references can be
+// resolved to SIDs without extra lookups. Entry DNs stay in plain form.
+func WithExtendedDN(ctx context.Context) context.Context {
+	return context.WithValue(ctx, extendedDNKey{}, true)
+}
+
+// extendedDNRequested reports whether ctx was created by WithExtendedDN
+func extendedDNRequested(ctx context.Context) bool {
+	requested, _ := ctx.Value(extendedDNKey{}).(bool)
+	return requested
+}
+
+// pageHookKey is the context key set by WithPageHook
+type pageHookKey struct{}
+
+// WithPageHook returns a context whose searches call hook with the number
+// of entries in each page as it is received, e.g. to report progress.
+// The hook runs on the search goroutine and must not block.
+func WithPageHook(ctx context.Context, hook func(entries int)) context.Context {
+	return context.WithValue(ctx, pageHookKey{}, hook)
+}
+
+// pageHook returns the hook set by WithPageHook, if any
+func pageHook(ctx context.Context) func(int) {
+	hook, _ := ctx.Value(pageHookKey{}).(func(int))
+	return hook
+}
+
+// baseObjectKey is the context key set by WithBaseObject
+type baseObjectKey struct{}
+
+// WithBaseObject returns a context whose searches read only the object at
+// dn instead of the subtree of the base DN, as constructed attributes such
+// as tokenGroups and allowedAttributesEffective require
+func WithBaseObject(ctx context.Context, dn string) context.Context {
+	return context.WithValue(ctx, baseObjectKey{}, dn)
+}
+
+// searchBaseKey is the context key set by WithSearchBase
+type searchBaseKey struct{}
+
+// WithSearchBase returns a context whose searches cover the subtree of dn
+// instead of the base DN, such as the configuration partition of the forest
+func WithSearchBase(ctx context.Context, dn string) context.Context {
+	return context.WithValue(ctx, searchBaseKey{}, dn)
+}
+
+// searchBase returns the base DN and scope of searches with ctx: the object
+// given to WithBaseObject, or the subtree of the DN given to WithSearchBase
+// or of baseDN
+func searchBase(ctx context.Context, baseDN string) (string, int) {
+	if dn, ok := ctx.Value(baseObjectKey{}).(string); ok {
+		return dn, ldap.ScopeBaseObject
+	}
+	if dn, ok := ctx.Value(searchBaseKey{}).(string); ok {
+		return dn, ldap.ScopeWholeSubtree
+	}
+	return baseDN, ldap.ScopeWholeSubtree
+}
+
+// searchControls returns the controls a search needs besides paging
+func searchControls(ctx context.Context, attributes []string) []ldap.Control {
+	var controls []ldap.Control
+
+	// Request owner, group and DACL without the SACL so that
+	// unprivileged accounts can still read nTSecurityDescriptor
+	if requestsSecurityDescriptor(attributes) {
+		controls = append(controls, &ldap.ControlMicrosoftSDFlags{
+			ControlValue: sdFlagsOwnerGroupDACL,
+		})
+	}
+
+	if extendedDNRequested(ctx) {
+		controls = append(controls, ldap.NewControlString(analyze.OIDControlTypeExtendedDN, false, extendedDNStringFormat))
+	}
+
+	return controls
+}
+
+// normalizeEntries strips extended DN components from entry DNs, which the
+// extended DN control applies to the object name as well as to attributes
+func normalizeEntries(ctx context.Context, entries []*ldap.Entry) {
+	if !extendedDNRequested(ctx) {
+		return
+	}
+	for _, entry := range entries {
+		entry.DN, _, _ = analyze.ParseExtendedDN(entry.DN)
+	}
+}
+
+// requestsSecurityDescriptor reports whether attributes include nTSecurityDescriptor
+func requestsSecurityDescriptor(attributes []string) bool {
+	for _, attr := range attributes {
+		if strings.EqualFold(attr, analyze.AttrNTSecurityDescriptor) {
+			return true
+		}
+	}
+	return false
+}
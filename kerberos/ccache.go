@@ -0,0 +1,197 @@
+package kerberos
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+)
+
+// ccacheConfigRealm is the realm of the configuration entries MIT
+// Kerberos stores in credential caches
+const ccacheConfigRealm = "X-CACHECONF:"
+
+// DefaultCCache returns the credential cache file named by KRB5CCNAME,
+// or "" if it is unset or not a file cache
+func DefaultCCache() string {
+	name := os.Getenv("KRB5CCNAME")
+	if kind, path, ok := strings.Cut(name, ":"); ok {
+		if kind != "FILE" {
+			return ""
+		}
+		return path
+	}
+	return name
+}
+
+// LoadCCache uses the TGT of the realm of the client from an MIT credential
+// cache file, as written by kinit or exported by ticket tools. An empty
+// Realm or Username of the client is set from the cache principal.
+func (c *Client) LoadCCache(path string) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("reading credential cache: %w", err)
+	}
+	cc, err := parseCCache(data)
+	if err != nil {
+		return fmt.Errorf("parsing credential cache %s: %w", path, err)
+	}
+
+	if c.Realm == "" {
+		c.Realm = cc.principal.realm
+	}
+	if c.Username == "" {
+		c.Username = cc.principal.name.String()
+	}
+	for _, cred := range cc.credentials {
+		if len(cred.server.name.NameString) == 2 && cred.server.name.NameString[0] == "krbtgt" &&
+			strings.EqualFold(cred.server.name.NameString[1], c.Realm) && supportedEType(cred.key.etype) {
+			c.tgt = &credential{ticket: cred.ticket, sessionKey: cred.key}
+			return nil
+		}
+	}
+	return fmt.Errorf("credential cache %s has no TGT for %s", path, c.Realm)
+}
+
+// ccachePrincipal is a principal and its realm in a credential cache
+type ccachePrincipal struct {
+	realm string
+	name  principalName
+}
+
+// ccacheCredential is one credential in a credential cache
+type ccacheCredential struct {
+	client ccachePrincipal
+	server ccachePrincipal
+	key    key
+	ticket []byte
+}
+
+// ccache is a parsed credential cache
+type ccache struct {
+	principal   ccachePrincipal
+	credentials []ccacheCredential
+}
+
+// ccacheReader reads the big-endian fields of a version 3 or 4 cache
+type ccacheReader struct {
+	r   *bytes.Reader
+	err error
+}
+
+// read reads v. Every read expects data, so running out of it at a field
+// boundary is a truncated cache too.
+func (r *ccacheReader) read(v any) {
+	if r.err == nil {
+		r.err = binary.Read(r.r, binary.BigEndian, v)
+		if r.err == io.EOF {
+			r.err = io.ErrUnexpectedEOF
+		}
+	}
+}
+
+func (r *ccacheReader) uint8() uint8 {
+	var v uint8
+	r.read(&v)
+	return v
+}
+
+func (r *ccacheReader) uint16() uint16 {
+	var v uint16
+	r.read(&v)
+	return v
+}
+
+func (r *ccacheReader) uint32() uint32 {
+	var v uint32
+	r.read(&v)
+	return v
+}
+
+// data reads a length-prefixed byte string
+func (r *ccacheReader) data() []byte {
+	n := r.uint32()
+	if r.err != nil {
+		return nil
+	}
+	if int64(n) > int64(r.r.Len()) {
+		r.err = io.ErrUnexpectedEOF
+		return nil
+	}
+	b := make([]byte, n)
+	r.read(b)
+	return b
+}
+
+func (r *ccacheReader) principal() ccachePrincipal {
+	var p ccachePrincipal
+	p.name.NameType = int32(r.uint32())
+	count := r.uint32()
+	p.realm = string(r.data())
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		p.name.NameString = append(p.name.NameString, string(r.data()))
+	}
+	return p
+}
+
+// skipTagged reads and discards count items of a uint16 type and data each
+func (r *ccacheReader) skipTagged(count uint32) {
+	for i := uint32(0); i < count && r.err == nil; i++ {
+		r.uint16()
+		r.data()
+	}
+}
+
+// parseCCache parses a credential cache of file format version 3 or 4
+func parseCCache(data []byte) (*ccache, error) {
+	r := &ccacheReader{r: bytes.NewReader(data)}
+	version := r.uint16()
+	if r.err != nil {
+		return nil, r.err
+	}
+	switch version {
+	case 0x0504:
+		// The header holds tagged fields such as the KDC time offset
+		n := r.uint16()
+		if r.err == nil && int(n) > r.r.Len() {
+			r.err = io.ErrUnexpectedEOF
+		}
+		if r.err != nil {
+			return nil, r.err
+		}
+		r.r.Seek(int64(n), io.SeekCurrent)
+	case 0x0503:
+	default:
+		return nil, fmt.Errorf("unsupported credential cache version %#04x", version)
+	}
+
+	cc := &ccache{principal: r.principal()}
+	if r.err != nil {
+		return nil, r.err
+	}
+	for r.err == nil && r.r.Len() > 0 {
+		var cred ccacheCredential
+		cred.client = r.principal()
+		cred.server = r.principal()
+		cred.key.etype = int32(r.uint16())
+		cred.key.value = r.data()
+		for range 4 {
+			r.uint32() // Auth, start, end and renew-till times
+		}
+		r.uint8()                // Is session key
+		r.uint32()               // Ticket flags
+		r.skipTagged(r.uint32()) // Addresses
+		r.skipTagged(r.uint32()) // Authorization data
+		cred.ticket = r.data()
+		r.data() // Second ticket
+		if r.err == nil && cred.server.realm != ccacheConfigRealm {
+			cc.credentials = append(cc.credentials, cred)
+		}
+	}
+	if r.err != nil {
+		return nil, r.err
+	}
+	return cc, nil
+}
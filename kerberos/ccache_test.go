@@ -0,0 +1,142 @@
+package kerberos
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// testCCache is a version 4 credential cache written by MIT kinit for
+// testuser1@TEST.GOKRB5 (from the gokrb5 test suite). It holds an
+// aes256-cts-hmac-sha1-96 TGT, a configuration entry and a service
+// ticket for HTTP/host.test.gokrb5.
+const testCCache = "0504000c00010008000000060000000000000001000000010000000b54455354" +
+	"2e474f4b5242350000000974657374757365723100000001000000010000000b" +
+	"544553542e474f4b524235000000097465737475736572310000000200000002" +
+	"0000000b544553542e474f4b524235000000066b72627467740000000b544553" +
+	"542e474f4b52423500120000002088b94319f2dcd1de20ebd3bf317477876932" +
+	"3bce76ef71fb37a8ba4be93c38df59665b8e59665b8e5967044e5967ad080040" +
+	"c1000000000000000000000000015a6182015630820152a003020105a10d1b0b" +
+	"544553542e474f4b524235a220301ea003020102a11730151b066b7262746774" +
+	"1b0b544553542e474f4b524235a382011830820114a003020112a103020101a2" +
+	"82010604820102ee32bb7e27ad6f71869be098c4002b291f370d26302c87ffa3" +
+	"eb670345a11fc113a9e5ab9e26ea659104b29e2a60c07dda559654c58aaf5f48" +
+	"bbb3bb9a238745861be336a0672554dac9b38126b2929ce9df2add185d1043c6" +
+	"dd89c7308b9def7b98ba7bcdcd1c00eeb5d99e273e1fe53b88c057106ec3dbcf" +
+	"2a86c38a4c1372418f1afb0227975747edf2172e23716ab5f6fa9a2ee5c0d94e" +
+	"9f66936df767498677861926812d1f887de6f44e5ebd93b63fd8313a499372ea" +
+	"9e889620bd0842bc8a8f8a17e5dea328c77b771cfcd49ac7afa4a9c7236efa30" +
+	"fec1b2072255543aee48cd935ece367e08d24f51bea4b407ace8ed7e67a8d5e1" +
+	"cb528eb16c7ebe7ac50000000000000001000000010000000b544553542e474f" +
+	"4b5242350000000974657374757365723100000000000000030000000c582d43" +
+	"41434845434f4e463a000000156b7262355f6363616368655f636f6e665f6461" +
+	"74610000000a666173745f617661696c0000001e6b72627467742f544553542e" +
+	"474f4b52423540544553542e474f4b5242350000000000000000000000000000" +
+	"0000000000000000000000000000000000000000000000000379657300000000" +
+	"00000001000000010000000b544553542e474f4b524235000000097465737475" +
+	"7365723100000001000000020000000b544553542e474f4b5242350000000448" +
+	"54545000000010686f73742e746573742e676f6b726235001200000020fd325d" +
+	"a3f905d743894e828de41b21af7876b6281b66d9e4bb2eefd64078b47659665b" +
+	"8e59665bce5967044e5967ad0800408900000000000000000000000001706182" +
+	"016c30820168a003020105a10d1b0b544553542e474f4b524235a2233021a003" +
+	"020101a11a30181b04485454501b10686f73742e746573742e676f6b726235a3" +
+	"82012b30820127a003020112a103020101a282011904820115ad55d79858ce41" +
+	"647e835769b40540bc32ff4debe101217a7a024016697ee5ff758829940ca576" +
+	"905a260732c43c2996d96b83f9bff010fdbfc8f3bff51cef202a956f8d73d18c" +
+	"2c8865553f55229075270f42dca23d7618ff35e578a972d40746398efd478cf4" +
+	"f1094d99371273b3fbe5b95707011b446ff605ea8cb0e6631ea0ffdd7b562b5a" +
+	"a2de5dd455388e1aa18d8a3a8e81dab058e1b223410a752e5ec82797164dabaf" +
+	"dbec8eeef7b072304e46d7d15b575f44cce69a368a9004612ba179b41d465596" +
+	"4933f7eb114a457aa1127291fc6d63deb271e5504de6fccca33260645ef5bd1e" +
+	"a301d74a8dbf751aa181ed92f5edb493d68222e1a34892035b88b6fb0ce104db" +
+	"23f7da22a8e73359d9c322b8e1cc00000000"
+
+func TestParseCCache(t *testing.T) {
+	cc, err := parseCCache(unhex(t, testCCache))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if cc.principal.realm != "TEST.GOKRB5" || cc.principal.name.String() != "testuser1" {
+		t.Errorf("principal = %s@%s", cc.principal.name, cc.principal.realm)
+	}
+
+	// The X-CACHECONF: entry is left out
+	if len(cc.credentials) != 2 {
+		t.Fatalf("got %d credentials, want 2", len(cc.credentials))
+	}
+	tgt, service := cc.credentials[0], cc.credentials[1]
+	if tgt.server.name.String() != "krbtgt/TEST.GOKRB5" || service.server.name.String() != "HTTP/host.test.gokrb5" {
+		t.Errorf("servers = %s, %s", tgt.server.name, service.server.name)
+	}
+	for _, cred := range cc.credentials {
+		if cred.client.name.String() != "testuser1" || cred.key.etype != ETypeAES256 || len(cred.key.value) != 32 {
+			t.Errorf("%s: client %s, %s key of %d bytes", cred.server.name, cred.client.name, ETypeName(cred.key.etype), len(cred.key.value))
+		}
+		var tkt ticket
+		if err := unmarshalApplication(cred.ticket, tagTicket, &tkt); err != nil {
+			t.Errorf("%s: parsing ticket: %v", cred.server.name, err)
+		} else if tkt.SName.String() != cred.server.name.String() {
+			t.Errorf("ticket for %s in the credential of %s", tkt.SName, cred.server.name)
+		}
+	}
+}
+
+func TestParseCCacheRejects(t *testing.T) {
+	data := unhex(t, testCCache)
+	tests := []struct {
+		name string
+		data []byte
+	}{
+		{"empty", nil},
+		{"version 1", append([]byte{0x05, 0x01}, data[2:]...)},
+		{"truncated header", data[:6]},
+		{"truncated ticket", data[:len(data)-10]},
+	}
+	for _, tt := range tests {
+		if _, err := parseCCache(tt.data); err == nil {
+			t.Errorf("%s: parsed without error", tt.name)
+		}
+	}
+}
+
+func TestLoadCCache(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "krb5cc_1000")
+	if err := os.WriteFile(path, unhex(t, testCCache), 0600); err != nil {
+		t.Fatal(err)
+	}
+
+	c := &Client{}
+	if err := c.LoadCCache(path); err != nil {
+		t.Fatal(err)
+	}
+	if c.Realm != "TEST.GOKRB5" || c.Username != "testuser1" {
+		t.Errorf("client = %s@%s, want testuser1@TEST.GOKRB5", c.Username, c.Realm)
+	}
+	if c.tgt == nil || c.tgt.sessionKey.etype != ETypeAES256 || applicationTag(c.tgt.ticket) != tagTicket {
+		t.Errorf("TGT not loaded: %+v", c.tgt)
+	}
+
+	other := NewClient("dc01", "corp.local", "", "")
+	if err := other.LoadCCache(path); err == nil {
+		t.Error("loaded a TGT of TEST.GOKRB5 for CORP.LOCAL")
+	}
+	if err := c.LoadCCache(filepath.Join(t.TempDir(), "missing")); err == nil {
+		t.Error("loaded a missing cache")
+	}
+}
+
+func TestDefaultCCache(t *testing.T) {
+	tests := []struct{ env, want string }{
+		{"", ""},
+		{"/tmp/krb5cc_1000", "/tmp/krb5cc_1000"},
+		{"FILE:/tmp/krb5cc_1000", "/tmp/krb5cc_1000"},
+		{"KEYRING:persistent:1000", ""},
+		{"API:", ""},
+	}
+	for _, tt := range tests {
+		t.Setenv("KRB5CCNAME", tt.env)
+		if got := DefaultCCache(); got != tt.want {
+			t.Errorf("KRB5CCNAME=%q: DefaultCCache() = %q, want %q", tt.env, got, tt.want)
+		}
+	}
+}
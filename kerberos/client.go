@@ -0,0 +1,343 @@
+// Package kerberos is a minimal Kerberos 5 client for requesting the
+// tickets that roasting attacks crack offline: a TGT from a password or a
+// credential cache, and service tickets for SPNs. Only the RC4-HMAC and
+// AES CTS-HMAC-SHA1-96 encryption types are supported.
+package kerberos
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"slices"
+	"strings"
+	"time"
+)
+
+// DefaultPort is the KDC port
+const DefaultPort = 88
+
+// maxMessage bounds the size of a KDC reply
+const maxMessage = 1 << 20
+
+// Client requests tickets from a KDC as one principal
+type Client struct {
+	Realm    string        // Realm in upper case, e.g. CORP.LOCAL
+	KDC      string        // KDC host, or host:port
+	Username string        // Client principal name without the realm
+	Password string        // Password of the client principal
+	Timeout  time.Duration // Timeout of each KDC exchange (0 = none)
+
+	tgt *credential
+}
+
+// credential is a ticket and its session key
+type credential struct {
+	ticket     []byte // DER encoded Ticket
+	sessionKey key
+}
+
+// Ticket is a service ticket, whose encrypted part is under the key of
+// the service account
+type Ticket struct {
+	Realm  string // Realm of the service
+	SPN    string // Service principal name the ticket is for
+	EType  int32  // Encryption type of the encrypted part
+	Cipher []byte // Encrypted part
+}
+
+// ASREP is the part of an AS-REP encrypted under the key of an account
+// that does not require pre-authentication
+type ASREP struct {
+	User   string // Account the AS-REP was requested for
+	Realm  string // Realm of the account
+	EType  int32  // Encryption type of the encrypted part
+	Cipher []byte // Encrypted part
+}
+
+// NewClient returns a client for username in realm, which is upper-cased
+func NewClient(kdc, realm, username, password string) *Client {
+	return &Client{
+		Realm:    strings.ToUpper(realm),
+		KDC:      kdc,
+		Username: username,
+		Password: password,
+	}
+}
+
+// Login requests a TGT with the password of the client. The encryption
+// type and salt of the key are those the KDC announces for the account.
+func (c *Client) Login(ctx context.Context) error {
+	if c.Password == "" {
+		return fmt.Errorf("no password for %s@%s", c.Username, c.Realm)
+	}
+	cname := principalName{NameType: NameTypePrincipal, NameString: []string{c.Username}}
+	etypes := []int32{ETypeAES256, ETypeAES128, ETypeRC4}
+
+	// Without pre-authentication the KDC replies with the etypes and salt
+	// of the account, or with the AS-REP itself if it does not require it
+	rep, err := c.asExchange(ctx, cname, etypes, nil)
+	var info []eTypeInfo2Entry
+	var kerr *Error
+	switch {
+	case errors.As(err, &kerr) && kerr.Code == ErrPreauthRequired:
+		info = kerr.eTypeInfo()
+	case err != nil:
+		return err
+	default:
+		// The salt is still announced, but the key must be the one the
+		// reply is encrypted with
+		info = slices.DeleteFunc(eTypeInfo(rep.PAData), func(e eTypeInfo2Entry) bool { return e.EType != rep.EncPart.EType })
+		if len(info) == 0 {
+			info = []eTypeInfo2Entry{{EType: rep.EncPart.EType}}
+		}
+	}
+
+	entry, ok := chooseEType(info)
+	if !ok {
+		return fmt.Errorf("KDC offers no supported encryption type for %s", c.Username)
+	}
+	salt := entry.Salt
+	if salt == "" {
+		salt = c.Realm + c.Username
+	}
+	iterations := 0
+	if len(entry.S2KParams) == 4 {
+		iterations = int(binary.BigEndian.Uint32(entry.S2KParams))
+	}
+	k, err := stringToKey(entry.EType, c.Password, salt, iterations)
+	if err != nil {
+		return err
+	}
+
+	if rep == nil {
+		ts, err := marshalTimestamp(time.Now())
+		if err != nil {
+			return err
+		}
+		encTS, err := k.encrypt(usageASReqTimestamp, ts)
+		if err != nil {
+			return err
+		}
+		value, err := marshalEncryptedData(k.etype, encTS)
+		if err != nil {
+			return err
+		}
+		rep, err = c.asExchange(ctx, cname, []int32{k.etype}, []paData{{Type: paEncTimestamp, Value: value}})
+		if err != nil {
+			return err
+		}
+	}
+
+	sessionKey, err := decryptRepPart(k, usageASRepEncPart, rep.EncPart)
+	if err != nil {
+		return fmt.Errorf("decrypting AS-REP: %w", err)
+	}
+	c.tgt = &credential{ticket: rep.Ticket.Bytes, sessionKey: sessionKey}
+	return nil
+}
+
+// ServiceTicket requests a ticket for spn with the TGT of the client,
+// asking for the given encryption types in order of preference
+func (c *Client) ServiceTicket(ctx context.Context, spn string, etypes []int32) (*Ticket, error) {
+	if c.tgt == nil {
+		return nil, fmt.Errorf("no TGT: log in or load a credential cache first")
+	}
+
+	now := time.Now()
+	cname := principalName{NameType: NameTypePrincipal, NameString: []string{c.Username}}
+	auth, err := marshalAuthenticator(c.Realm, cname, now)
+	if err != nil {
+		return nil, err
+	}
+	encAuth, err := c.tgt.sessionKey.encrypt(usageTGSReqAuthenticator, auth)
+	if err != nil {
+		return nil, err
+	}
+	apReq, err := marshalAPReq(c.tgt.ticket, c.tgt.sessionKey.etype, encAuth)
+	if err != nil {
+		return nil, err
+	}
+
+	req := &kdcRequest{
+		msgType: msgTGSReq,
+		paData:  []paData{{Type: paTGSReq, Value: apReq}},
+		realm:   c.Realm,
+		sname:   principalName{NameType: NameTypeSrvInst, NameString: strings.Split(spn, "/")},
+		nonce:   nonce(),
+		etypes:  etypes,
+	}
+	rep, err := c.kdcExchange(ctx, req, msgTGSRep)
+	if err != nil {
+		return nil, err
+	}
+
+	var t ticket
+	if err := unmarshalApplication(rep.Ticket.Bytes, tagTicket, &t); err != nil {
+		return nil, fmt.Errorf("parsing ticket: %w", err)
+	}
+	return &Ticket{Realm: t.Realm, SPN: spn, EType: t.EncPart.EType, Cipher: t.EncPart.Cipher}, nil
+}
+
+// ASREP requests a TGT for user without pre-authentication, which needs
+// no credentials. Accounts that require pre-authentication fail with an
+// *Error of code ErrPreauthRequired.
+func (c *Client) ASREP(ctx context.Context, user string, etypes []int32) (*ASREP, error) {
+	cname := principalName{NameType: NameTypePrincipal, NameString: []string{user}}
+	rep, err := c.asExchange(ctx, cname, etypes, nil)
+	if err != nil {
+		return nil, err
+	}
+	return &ASREP{User: user, Realm: c.Realm, EType: rep.EncPart.EType, Cipher: rep.EncPart.Cipher}, nil
+}
+
+// asExchange sends an AS-REQ for a TGT of cname
+func (c *Client) asExchange(ctx context.Context, cname principalName, etypes []int32, pa []paData) (*kdcRep, error) {
+	pacReq, err := marshalPACRequest()
+	if err != nil {
+		return nil, err
+	}
+	req := &kdcRequest{
+		msgType: msgASReq,
+		paData:  append(pa, paData{Type: paPACRequest, Value: pacReq}),
+		cname:   &cname,
+		realm:   c.Realm,
+		sname:   principalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", c.Realm}},
+		nonce:   nonce(),
+		etypes:  etypes,
+	}
+	return c.kdcExchange(ctx, req, msgASRep)
+}
+
+// kdcExchange sends a request and decodes the reply, which must be of
+// type want or a KRB-ERROR
+func (c *Client) kdcExchange(ctx context.Context, req *kdcRequest, want int) (*kdcRep, error) {
+	data, err := req.marshal()
+	if err != nil {
+		return nil, fmt.Errorf("encoding request: %w", err)
+	}
+	reply, err := c.send(ctx, data)
+	if err != nil {
+		return nil, err
+	}
+
+	switch applicationTag(reply) {
+	case want:
+		var rep kdcRep
+		if err := unmarshalApplication(reply, want, &rep); err != nil {
+			return nil, fmt.Errorf("parsing KDC reply: %w", err)
+		}
+		return &rep, nil
+	case msgKRBError:
+		var e krbError
+		if err := unmarshalApplication(reply, msgKRBError, &e); err != nil {
+			return nil, fmt.Errorf("parsing KDC error: %w", err)
+		}
+		return nil, &Error{Code: e.ErrorCode, Text: e.EText, eData: e.EData}
+	}
+	return nil, fmt.Errorf("unexpected KDC reply")
+}
+
+// send sends a message to the KDC over TCP and returns the reply
+func (c *Client) send(ctx context.Context, msg []byte) ([]byte, error) {
+	addr := c.KDC
+	if _, _, err := net.SplitHostPort(addr); err != nil {
+		addr = net.JoinHostPort(addr, fmt.Sprint(DefaultPort))
+	}
+	if c.Timeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, c.Timeout)
+		defer cancel()
+	}
+
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", addr)
+	if err != nil {
+		return nil, fmt.Errorf("connecting to KDC %s: %w", addr, err)
+	}
+	defer conn.Close()
+	if deadline, ok := ctx.Deadline(); ok {
+		conn.SetDeadline(deadline)
+	}
+
+	// Messages over TCP are prefixed with their length
+	if _, err := conn.Write(binary.BigEndian.AppendUint32(nil, uint32(len(msg)))); err != nil {
+		return nil, fmt.Errorf("sending to KDC %s: %w", addr, err)
+	}
+	if _, err := conn.Write(msg); err != nil {
+		return nil, fmt.Errorf("sending to KDC %s: %w", addr, err)
+	}
+
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return nil, fmt.Errorf("reading from KDC %s: %w", addr, err)
+	}
+	n := binary.BigEndian.Uint32(size[:])
+	if n > maxMessage {
+		return nil, fmt.Errorf("KDC reply of %d bytes is too large", n)
+	}
+	reply := make([]byte, n)
+	if _, err := io.ReadFull(conn, reply); err != nil {
+		return nil, fmt.Errorf("reading from KDC %s: %w", addr, err)
+	}
+	return reply, nil
+}
+
+// decryptRepPart decrypts the encrypted part of a KDC reply and returns
+// its session key
+func decryptRepPart(k key, usage uint32, enc encryptedData) (key, error) {
+	if enc.EType != k.etype {
+		return key{}, fmt.Errorf("reply is encrypted with %s, not %s", ETypeName(enc.EType), ETypeName(k.etype))
+	}
+	plain, err := k.decrypt(usage, enc.Cipher)
+	if err != nil {
+		return key{}, err
+	}
+
+	// Windows KDCs may tag the part of an AS-REP as EncTGSRepPart
+	tag := applicationTag(plain)
+	if tag != tagEncASRepPart && tag != tagEncTGSRepPart {
+		return key{}, fmt.Errorf("unexpected encrypted part")
+	}
+	var part encKDCRepPart
+	if _, err := asn1.UnmarshalWithParams(plain, &part, fmt.Sprintf("application,explicit,tag:%d", tag)); err != nil {
+		return key{}, fmt.Errorf("parsing encrypted part: %w", err)
+	}
+	if !supportedEType(part.Key.KeyType) {
+		return key{}, fmt.Errorf("unsupported session key type %s", ETypeName(part.Key.KeyType))
+	}
+	return key{etype: part.Key.KeyType, value: part.Key.KeyValue}, nil
+}
+
+// eTypeInfo returns the PA-ETYPE-INFO2 entries of padata
+func eTypeInfo(padata []paData) []eTypeInfo2Entry {
+	for _, pa := range padata {
+		if pa.Type == paETypeInfo2 {
+			var entries []eTypeInfo2Entry
+			if _, err := asn1.Unmarshal(pa.Value, &entries); err == nil {
+				return entries
+			}
+		}
+	}
+	return nil
+}
+
+// chooseEType returns the first entry of a supported encryption type
+func chooseEType(entries []eTypeInfo2Entry) (eTypeInfo2Entry, bool) {
+	i := slices.IndexFunc(entries, func(e eTypeInfo2Entry) bool { return supportedEType(e.EType) })
+	if i < 0 {
+		return eTypeInfo2Entry{}, false
+	}
+	return entries[i], true
+}
+
+// nonce returns a random request nonce
+func nonce() uint32 {
+	var b [4]byte
+	rand.Read(b[:])
+	return binary.BigEndian.Uint32(b[:]) & 0x7fffffff
+}
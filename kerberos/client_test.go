@@ -0,0 +1,371 @@
+package kerberos
+
+import (
+	"bytes"
+	"context"
+	"encoding/asn1"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"slices"
+	"sync"
+	"testing"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+const (
+	testRealm    = "CORP.LOCAL"
+	testUser     = "jdoe"
+	testPassword = "Summer2024!"
+	testSalt     = "CORP.LOCALjdoe"
+	testSPN      = "MSSQLSvc/sql01.corp.local:1433"
+)
+
+// testKDC answers AS-REQs for testUser, who requires pre-authentication
+// with an AES256 key, and for "nopreauth", who does not. It answers
+// TGS-REQs presenting the TGT it issued with an RC4 ticket for testSPN.
+type testKDC struct {
+	t          *testing.T
+	userKey    key
+	sessionKey key
+	tgt        []byte
+
+	mu       sync.Mutex
+	requests []int // Message types received
+}
+
+// received returns the message types received so far
+func (k *testKDC) received() []int {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+	return slices.Clone(k.requests)
+}
+
+// startKDC serves a testKDC on localhost and returns it and its address
+func startKDC(t *testing.T) (*testKDC, string) {
+	t.Helper()
+	userKey, err := stringToKey(ETypeAES256, testPassword, testSalt, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	kdc := &testKDC{
+		t:          t,
+		userKey:    userKey,
+		sessionKey: key{etype: ETypeAES128, value: bytes.Repeat([]byte{0x5a}, 16)},
+		tgt:        buildTicket(testRealm, principalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", testRealm}}, ETypeAES256, []byte("tgt enc-part")),
+	}
+
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+	go func() {
+		for {
+			conn, err := l.Accept()
+			if err != nil {
+				return
+			}
+			kdc.serve(conn)
+		}
+	}()
+	return kdc, l.Addr().String()
+}
+
+// serve answers one length-prefixed request on conn
+func (k *testKDC) serve(conn net.Conn) {
+	defer conn.Close()
+	var size [4]byte
+	if _, err := io.ReadFull(conn, size[:]); err != nil {
+		return
+	}
+	req := make([]byte, binary.BigEndian.Uint32(size[:]))
+	if _, err := io.ReadFull(conn, req); err != nil {
+		return
+	}
+	reply := k.handle(req)
+	conn.Write(binary.BigEndian.AppendUint32(nil, uint32(len(reply))))
+	conn.Write(reply)
+}
+
+func (k *testKDC) handle(data []byte) []byte {
+	tag := applicationTag(data)
+	k.mu.Lock()
+	k.requests = append(k.requests, tag)
+	k.mu.Unlock()
+	var req testKDCReq
+	if err := unmarshalApplication(data, tag, &req); err != nil {
+		k.t.Errorf("KDC cannot parse request: %v", err)
+		return buildKRBError(60, nil)
+	}
+	if tag == msgTGSReq {
+		return k.tgsReply(req)
+	}
+
+	switch req.Body.CName.String() {
+	case "nopreauth":
+		return buildKDCRep(msgASRep, req.Body.CName, k.tgt, ETypeRC4, bytes.Repeat([]byte{0xcd}, 64))
+	case testUser:
+		// Pre-authenticates below
+	default:
+		return buildKRBError(ErrClientUnknown, nil)
+	}
+
+	ts := findPAData(req.PAData, paEncTimestamp)
+	if ts == nil {
+		info := buildSequence(func(b *cryptobyte.Builder) {
+			b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				addInt(b, 0, int64(ETypeAES256))
+				addString(b, 1, testSalt)
+			})
+		})
+		return buildKRBError(ErrPreauthRequired, buildSequence(func(b *cryptobyte.Builder) {
+			b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				addInt(b, 1, paETypeInfo2)
+				addOctets(b, 2, info)
+			})
+		}))
+	}
+
+	var enc encryptedData
+	if _, err := asn1.Unmarshal(ts, &enc); err != nil {
+		k.t.Errorf("KDC cannot parse PA-ENC-TIMESTAMP: %v", err)
+		return buildKRBError(ErrPreauthFailed, nil)
+	}
+	if _, err := k.userKey.decrypt(usageASReqTimestamp, enc.Cipher); err != nil {
+		return buildKRBError(ErrPreauthFailed, nil)
+	}
+	part := buildEncRepPart(tagEncASRepPart, k.sessionKey, req.Body.Nonce)
+	cipher, err := k.userKey.encrypt(usageASRepEncPart, part)
+	if err != nil {
+		k.t.Error(err)
+		return buildKRBError(60, nil)
+	}
+	return buildKDCRep(msgASRep, req.Body.CName, k.tgt, k.userKey.etype, cipher)
+}
+
+// tgsReply checks the AP-REQ of a TGS-REQ and issues a service ticket
+func (k *testKDC) tgsReply(req testKDCReq) []byte {
+	var ap testAPReq
+	if err := unmarshalApplication(findPAData(req.PAData, paTGSReq), msgAPReq, &ap); err != nil {
+		k.t.Errorf("KDC cannot parse AP-REQ: %v", err)
+		return buildKRBError(60, nil)
+	}
+	if !bytes.Equal(ap.Ticket.Bytes, k.tgt) {
+		k.t.Error("AP-REQ does not present the TGT")
+	}
+	plain, err := k.sessionKey.decrypt(usageTGSReqAuthenticator, ap.Authenticator.Cipher)
+	if err != nil {
+		k.t.Errorf("decrypting authenticator: %v", err)
+		return buildKRBError(ErrPreauthFailed, nil)
+	}
+	if applicationTag(plain) != tagAuthenticator {
+		k.t.Error("authenticator is not an Authenticator")
+	}
+	if req.Body.SName.String() != testSPN {
+		return buildKRBError(ErrServerUnknown, nil)
+	}
+
+	service := buildTicket(testRealm, req.Body.SName, req.Body.ETypes[0], bytes.Repeat([]byte{0xab}, 64))
+	part := buildEncRepPart(tagEncTGSRepPart, key{etype: ETypeRC4, value: make([]byte, 16)}, req.Body.Nonce)
+	cipher, err := k.sessionKey.encrypt(usageTGSRepEncPart, part)
+	if err != nil {
+		k.t.Error(err)
+		return buildKRBError(60, nil)
+	}
+	cname := principalName{NameType: NameTypePrincipal, NameString: []string{testUser}}
+	return buildKDCRep(msgTGSRep, cname, service, k.sessionKey.etype, cipher)
+}
+
+// testAPReq decodes an AP-REQ
+type testAPReq struct {
+	PVNO          int            `asn1:"explicit,tag:0"`
+	MsgType       int            `asn1:"explicit,tag:1"`
+	Options       asn1.BitString `asn1:"explicit,tag:2"`
+	Ticket        asn1.RawValue  `asn1:"explicit,tag:3"`
+	Authenticator encryptedData  `asn1:"explicit,tag:4"`
+}
+
+func findPAData(padata []paData, typ int32) []byte {
+	for _, pa := range padata {
+		if pa.Type == typ {
+			return pa.Value
+		}
+	}
+	return nil
+}
+
+func buildSequence(f cryptobyte.BuilderContinuation) []byte {
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, f)
+	return b.BytesOrPanic()
+}
+
+func buildTicket(realm string, sname principalName, etype int32, cipher []byte) []byte {
+	var b cryptobyte.Builder
+	application(&b, tagTicket, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 0, 5)
+			addString(b, 1, realm)
+			addPrincipal(b, 2, sname)
+			explicit(b, 3, func(b *cryptobyte.Builder) {
+				addEncryptedData(b, etype, cipher)
+			})
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+func buildKDCRep(msgType int, cname principalName, tkt []byte, etype int32, cipher []byte) []byte {
+	var b cryptobyte.Builder
+	application(&b, msgType, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 0, 5)
+			addInt(b, 1, int64(msgType))
+			addString(b, 3, testRealm)
+			addPrincipal(b, 4, cname)
+			explicit(b, 5, func(b *cryptobyte.Builder) {
+				b.AddBytes(tkt)
+			})
+			explicit(b, 6, func(b *cryptobyte.Builder) {
+				addEncryptedData(b, etype, cipher)
+			})
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+func buildEncRepPart(tag int, k key, nonce int64) []byte {
+	var b cryptobyte.Builder
+	application(&b, tag, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			explicit(b, 0, func(b *cryptobyte.Builder) {
+				b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					addInt(b, 0, int64(k.etype))
+					addOctets(b, 1, k.value)
+				})
+			})
+			explicit(b, 1, func(b *cryptobyte.Builder) {
+				b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						addInt(b, 0, 0)
+						addTime(b, 1, time.Now())
+					})
+				})
+			})
+			addInt(b, 2, nonce)
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+func buildKRBError(code int32, edata []byte) []byte {
+	var b cryptobyte.Builder
+	application(&b, msgKRBError, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 0, 5)
+			addInt(b, 1, msgKRBError)
+			addTime(b, 4, time.Now())
+			addInt(b, 5, 0)
+			addInt(b, 6, int64(code))
+			addString(b, 9, testRealm)
+			addPrincipal(b, 10, principalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", testRealm}})
+			if edata != nil {
+				addOctets(b, 12, edata)
+			}
+		})
+	})
+	return b.BytesOrPanic()
+}
+
+func TestLoginAndServiceTicket(t *testing.T) {
+	kdc, addr := startKDC(t)
+	c := NewClient(addr, "corp.local", testUser, testPassword)
+	c.Timeout = 5 * time.Second
+	ctx := context.Background()
+
+	if _, err := c.ServiceTicket(ctx, testSPN, []int32{ETypeRC4}); err == nil {
+		t.Error("ServiceTicket without a TGT succeeded")
+	}
+	if err := c.Login(ctx); err != nil {
+		t.Fatal(err)
+	}
+	// The first AS-REQ learns the salt, the second pre-authenticates
+	if got := kdc.received(); !slices.Equal(got, []int{msgASReq, msgASReq}) {
+		t.Errorf("KDC received %v, want two AS-REQs", got)
+	}
+	if !bytes.Equal(c.tgt.ticket, kdc.tgt) || c.tgt.sessionKey.etype != ETypeAES128 || !bytes.Equal(c.tgt.sessionKey.value, kdc.sessionKey.value) {
+		t.Fatalf("TGT = %+v", c.tgt)
+	}
+
+	tkt, err := c.ServiceTicket(ctx, testSPN, []int32{ETypeRC4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if tkt.Realm != testRealm || tkt.SPN != testSPN || tkt.EType != ETypeRC4 || len(tkt.Cipher) != 64 {
+		t.Errorf("ticket = %+v", tkt)
+	}
+
+	_, err = c.ServiceTicket(ctx, "HTTP/missing", []int32{ETypeRC4})
+	var kerr *Error
+	if !errors.As(err, &kerr) || kerr.Code != ErrServerUnknown {
+		t.Errorf("unknown SPN: err = %v, want code %d", err, ErrServerUnknown)
+	}
+}
+
+func TestLoginWrongPassword(t *testing.T) {
+	_, addr := startKDC(t)
+	c := NewClient(addr, testRealm, testUser, "Winter2024!")
+	err := c.Login(context.Background())
+	var kerr *Error
+	if !errors.As(err, &kerr) || kerr.Code != ErrPreauthFailed {
+		t.Errorf("err = %v, want code %d", err, ErrPreauthFailed)
+	}
+	if c.tgt != nil {
+		t.Error("a TGT was stored")
+	}
+
+	if err := NewClient(addr, testRealm, testUser, "").Login(context.Background()); err == nil {
+		t.Error("Login without a password succeeded")
+	}
+}
+
+func TestASREP(t *testing.T) {
+	_, addr := startKDC(t)
+	c := NewClient(addr, testRealm, "", "")
+
+	asrep, err := c.ASREP(context.Background(), "nopreauth", []int32{ETypeRC4})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if asrep.User != "nopreauth" || asrep.Realm != testRealm || asrep.EType != ETypeRC4 || len(asrep.Cipher) != 64 {
+		t.Errorf("AS-REP = %+v", asrep)
+	}
+
+	for user, code := range map[string]int32{testUser: ErrPreauthRequired, "ghost": ErrClientUnknown} {
+		_, err := c.ASREP(context.Background(), user, []int32{ETypeRC4})
+		var kerr *Error
+		if !errors.As(err, &kerr) || kerr.Code != code {
+			t.Errorf("%s: err = %v, want code %d", user, err, code)
+		}
+	}
+}
+
+func TestKDCUnreachable(t *testing.T) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := l.Addr().String()
+	l.Close()
+
+	c := NewClient(addr, testRealm, testUser, testPassword)
+	c.Timeout = time.Second
+	if err := c.Login(context.Background()); err == nil {
+		t.Error("Login succeeded without a KDC")
+	}
+}
@@ -0,0 +1,380 @@
+package kerberos
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/hmac"
+	"crypto/md5"
+	"crypto/pbkdf2"
+	"crypto/rand"
+	"crypto/rc4"
+	"crypto/sha1"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"hash"
+	"unicode/utf16"
+
+	"golang.org/x/crypto/md4"
+)
+
+// Encryption types (RFC 3962, RFC 4757)
+const (
+	ETypeAES128 int32 = 17 // aes128-cts-hmac-sha1-96
+	ETypeAES256 int32 = 18 // aes256-cts-hmac-sha1-96
+	ETypeRC4    int32 = 23 // rc4-hmac
+)
+
+// Key usage numbers (RFC 4120 section 7.5.1)
+const (
+	usageASReqTimestamp      = 1
+	usageTicket              = 2
+	usageASRepEncPart        = 3
+	usageTGSReqAuthenticator = 7
+	usageTGSRepEncPart       = 8
+)
+
+// defaultIterations is the PBKDF2 iteration count of the AES string-to-key
+const defaultIterations = 4096
+
+// errIntegrity means a message did not decrypt, usually a wrong password
+var errIntegrity = errors.New("integrity check failed (wrong password or key)")
+
+// ETypeName returns the name of an encryption type
+func ETypeName(etype int32) string {
+	switch etype {
+	case ETypeAES128:
+		return "aes128-cts-hmac-sha1-96"
+	case ETypeAES256:
+		return "aes256-cts-hmac-sha1-96"
+	case ETypeRC4:
+		return "rc4-hmac"
+	}
+	return fmt.Sprintf("etype %d", etype)
+}
+
+// supportedEType reports whether keys of etype can be derived and used
+func supportedEType(etype int32) bool {
+	return etype == ETypeAES128 || etype == ETypeAES256 || etype == ETypeRC4
+}
+
+// key is an encryption key of one encryption type
+type key struct {
+	etype int32
+	value []byte
+}
+
+// stringToKey derives the key of a password. Salt and iterations only
+// apply to AES; an iteration count of 0 means the default.
+func stringToKey(etype int32, password, salt string, iterations int) (key, error) {
+	if iterations == 0 {
+		iterations = defaultIterations
+	}
+	switch etype {
+	case ETypeRC4:
+		h := md4.New()
+		h.Write(utf16le(password))
+		return key{etype, h.Sum(nil)}, nil
+	case ETypeAES128, ETypeAES256:
+		size := 16
+		if etype == ETypeAES256 {
+			size = 32
+		}
+		tkey, err := pbkdf2.Key(sha1.New, password, []byte(salt), iterations, size)
+		if err != nil {
+			return key{}, err
+		}
+		value, err := deriveKey(tkey, []byte("kerberos"))
+		if err != nil {
+			return key{}, err
+		}
+		return key{etype, value}, nil
+	}
+	return key{}, fmt.Errorf("unsupported encryption type %s", ETypeName(etype))
+}
+
+// encrypt encrypts plain for a key usage, with a random confounder
+func (k key) encrypt(usage uint32, plain []byte) ([]byte, error) {
+	switch k.etype {
+	case ETypeRC4:
+		return rc4Encrypt(k.value, usage, plain)
+	case ETypeAES128, ETypeAES256:
+		return aesEncrypt(k.value, usage, plain)
+	}
+	return nil, fmt.Errorf("unsupported encryption type %s", ETypeName(k.etype))
+}
+
+// decrypt decrypts and verifies a message encrypted for a key usage
+func (k key) decrypt(usage uint32, ciphertext []byte) ([]byte, error) {
+	switch k.etype {
+	case ETypeRC4:
+		return rc4Decrypt(k.value, usage, ciphertext)
+	case ETypeAES128, ETypeAES256:
+		return aesDecrypt(k.value, usage, ciphertext)
+	}
+	return nil, fmt.Errorf("unsupported encryption type %s", ETypeName(k.etype))
+}
+
+// utf16le encodes s as UTF-16 little-endian, as the NT hash expects
+func utf16le(s string) []byte {
+	u := utf16.Encode([]rune(s))
+	b := make([]byte, 2*len(u))
+	for i, c := range u {
+		binary.LittleEndian.PutUint16(b[2*i:], c)
+	}
+	return b
+}
+
+func hmacSum(h func() hash.Hash, key []byte, data ...[]byte) []byte {
+	mac := hmac.New(h, key)
+	for _, d := range data {
+		mac.Write(d)
+	}
+	return mac.Sum(nil)
+}
+
+func random(n int) ([]byte, error) {
+	b := make([]byte, n)
+	_, err := rand.Read(b)
+	return b, err
+}
+
+// rc4Usage maps a key usage to the message type RFC 4757 hashes in
+func rc4Usage(usage uint32) []byte {
+	switch usage {
+	case 3:
+		usage = 8
+	case 23:
+		usage = 13
+	}
+	return binary.LittleEndian.AppendUint32(nil, usage)
+}
+
+// rc4Encrypt implements RC4-HMAC encryption (RFC 4757)
+func rc4Encrypt(k []byte, usage uint32, plain []byte) ([]byte, error) {
+	confounder, err := random(8)
+	if err != nil {
+		return nil, err
+	}
+	data := append(confounder, plain...)
+	k1 := hmacSum(md5.New, k, rc4Usage(usage))
+	checksum := hmacSum(md5.New, k1, data)
+	c, err := rc4.NewCipher(hmacSum(md5.New, k1, checksum))
+	if err != nil {
+		return nil, err
+	}
+	c.XORKeyStream(data, data)
+	return append(checksum, data...), nil
+}
+
+// rc4Decrypt implements RC4-HMAC decryption (RFC 4757)
+func rc4Decrypt(k []byte, usage uint32, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < md5.Size+8 {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	checksum := ciphertext[:md5.Size]
+	k1 := hmacSum(md5.New, k, rc4Usage(usage))
+	c, err := rc4.NewCipher(hmacSum(md5.New, k1, checksum))
+	if err != nil {
+		return nil, err
+	}
+	data := make([]byte, len(ciphertext)-md5.Size)
+	c.XORKeyStream(data, ciphertext[md5.Size:])
+	if !hmac.Equal(hmacSum(md5.New, k1, data), checksum) {
+		return nil, errIntegrity
+	}
+	return data[8:], nil
+}
+
+// usageKey derives the encryption (0xAA) or integrity (0x55) key of a
+// key usage from an AES base key
+func usageKey(base []byte, usage uint32, kind byte) ([]byte, error) {
+	return deriveKey(base, append(binary.BigEndian.AppendUint32(nil, usage), kind))
+}
+
+// aesEncrypt implements aes*-cts-hmac-sha1-96 encryption (RFC 3962)
+func aesEncrypt(k []byte, usage uint32, plain []byte) ([]byte, error) {
+	ke, err := usageKey(k, usage, 0xAA)
+	if err != nil {
+		return nil, err
+	}
+	ki, err := usageKey(k, usage, 0x55)
+	if err != nil {
+		return nil, err
+	}
+	confounder, err := random(aes.BlockSize)
+	if err != nil {
+		return nil, err
+	}
+	data := append(confounder, plain...)
+	out, err := ctsEncrypt(ke, data)
+	if err != nil {
+		return nil, err
+	}
+	return append(out, hmacSum(sha1.New, ki, data)[:12]...), nil
+}
+
+// aesDecrypt implements aes*-cts-hmac-sha1-96 decryption (RFC 3962)
+func aesDecrypt(k []byte, usage uint32, ciphertext []byte) ([]byte, error) {
+	if len(ciphertext) < aes.BlockSize+12 {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+	ke, err := usageKey(k, usage, 0xAA)
+	if err != nil {
+		return nil, err
+	}
+	ki, err := usageKey(k, usage, 0x55)
+	if err != nil {
+		return nil, err
+	}
+	n := len(ciphertext) - 12
+	data, err := ctsDecrypt(ke, ciphertext[:n])
+	if err != nil {
+		return nil, err
+	}
+	if !hmac.Equal(hmacSum(sha1.New, ki, data)[:12], ciphertext[n:]) {
+		return nil, errIntegrity
+	}
+	return data[aes.BlockSize:], nil
+}
+
+// deriveKey implements DK(key, constant) of RFC 3961 for AES, where
+// random-to-key is the identity
+func deriveKey(k, constant []byte) ([]byte, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	in := nfold(constant, aes.BlockSize)
+	out := make([]byte, 0, len(k)+aes.BlockSize)
+	for len(out) < len(k) {
+		next := make([]byte, aes.BlockSize)
+		block.Encrypt(next, in)
+		out = append(out, next...)
+		in = next
+	}
+	return out[:len(k)], nil
+}
+
+// nfold stretches or folds in to size bytes (RFC 3961 section 5.1)
+func nfold(in []byte, size int) []byte {
+	inBits, outBits := len(in)*8, size*8
+	lcm := inBits / gcd(inBits, outBits) * outBits
+
+	buf := make([]byte, 0, lcm/8)
+	for i := 0; len(buf) < lcm/8; i++ {
+		buf = append(buf, rotateRight(in, 13*i)...)
+	}
+
+	out := make([]byte, size)
+	for i := 0; i < len(buf); i += size {
+		onesComplementAdd(out, buf[i:i+size])
+	}
+	return out
+}
+
+// rotateRight rotates the bits of b right by n
+func rotateRight(b []byte, n int) []byte {
+	total := len(b) * 8
+	out := make([]byte, len(b))
+	for i := range total {
+		src := ((i-n)%total + total) % total
+		if b[src/8]&(0x80>>(src%8)) != 0 {
+			out[i/8] |= 0x80 >> (i % 8)
+		}
+	}
+	return out
+}
+
+// onesComplementAdd adds b to a in ones' complement arithmetic
+func onesComplementAdd(a, b []byte) {
+	var carry uint
+	for i := len(a) - 1; i >= 0; i-- {
+		sum := uint(a[i]) + uint(b[i]) + carry
+		a[i], carry = byte(sum), sum>>8
+	}
+	for carry != 0 {
+		for i := len(a) - 1; i >= 0 && carry != 0; i-- {
+			sum := uint(a[i]) + carry
+			a[i], carry = byte(sum), sum>>8
+		}
+	}
+}
+
+func gcd(a, b int) int {
+	for b != 0 {
+		a, b = b, a%b
+	}
+	return a
+}
+
+// ctsEncrypt encrypts with AES in CBC ciphertext stealing mode (CS3) and
+// a zero IV
+func ctsEncrypt(k, plain []byte) ([]byte, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	bs := aes.BlockSize
+	if len(plain) < bs {
+		return nil, fmt.Errorf("plaintext shorter than one block")
+	}
+	if len(plain) == bs {
+		out := make([]byte, bs)
+		block.Encrypt(out, plain)
+		return out, nil
+	}
+
+	padded := make([]byte, (len(plain)+bs-1)/bs*bs)
+	copy(padded, plain)
+	cipher.NewCBCEncrypter(block, make([]byte, bs)).CryptBlocks(padded, padded)
+
+	// Swap the last two blocks and drop the padding
+	n := len(padded)
+	out := make([]byte, 0, n)
+	out = append(out, padded[:n-2*bs]...)
+	out = append(out, padded[n-bs:]...)
+	out = append(out, padded[n-2*bs:n-bs]...)
+	return out[:len(plain)], nil
+}
+
+// ctsDecrypt reverses ctsEncrypt
+func ctsDecrypt(k, ciphertext []byte) ([]byte, error) {
+	block, err := aes.NewCipher(k)
+	if err != nil {
+		return nil, err
+	}
+	bs := aes.BlockSize
+	if len(ciphertext) < bs {
+		return nil, fmt.Errorf("ciphertext shorter than one block")
+	}
+	if len(ciphertext) == bs {
+		out := make([]byte, bs)
+		block.Decrypt(out, ciphertext)
+		return out, nil
+	}
+
+	tail := len(ciphertext) % bs
+	if tail == 0 {
+		tail = bs
+	}
+	prefix := len(ciphertext) - bs - tail
+
+	// The full block before the partial one encrypts the padded last block
+	last := make([]byte, bs)
+	block.Decrypt(last, ciphertext[prefix:prefix+bs])
+
+	// The padding was zero, so the decrypted block holds the stolen bytes
+	prev := make([]byte, 0, bs)
+	prev = append(prev, ciphertext[prefix+bs:]...)
+	prev = append(prev, last[tail:]...)
+	for i := range tail {
+		last[i] ^= prev[i]
+	}
+
+	out := make([]byte, 0, len(ciphertext))
+	out = append(out, ciphertext[:prefix]...)
+	out = append(out, prev...)
+	cipher.NewCBCDecrypter(block, make([]byte, bs)).CryptBlocks(out, out)
+	return append(out, last[:tail]...), nil
+}
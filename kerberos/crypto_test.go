@@ -0,0 +1,117 @@
+package kerberos
+
+import (
+	"bytes"
+	"encoding/hex"
+	"testing"
+)
+
+func unhex(t *testing.T, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(s)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return b
+}
+
+// TestNFold checks the n-fold vectors of RFC 3961 appendix A.1
+func TestNFold(t *testing.T) {
+	tests := []struct {
+		in   string
+		bits int
+		want string
+	}{
+		{"012345", 64, "be072631276b1955"},
+		{"password", 56, "78a07b6caf85fa"},
+		{"Rough Consensus, and Running Code", 64, "bb6ed30870b7f0e0"},
+		{"password", 168, "59e4a8ca7c0385c3c37b3f6d2000247cb6e6bd5b3e"},
+		{"kerberos", 64, "6b65726265726f73"},
+		{"kerberos", 128, "6b65726265726f737b9b5b2b93132b93"},
+	}
+	for _, tt := range tests {
+		if got := hex.EncodeToString(nfold([]byte(tt.in), tt.bits/8)); got != tt.want {
+			t.Errorf("%d-fold(%q) = %s, want %s", tt.bits, tt.in, got, tt.want)
+		}
+	}
+}
+
+// TestStringToKey checks the AES vectors of RFC 3962 appendix B and the
+// RC4 key, which is the NT hash
+func TestStringToKey(t *testing.T) {
+	tests := []struct {
+		etype      int32
+		password   string
+		salt       string
+		iterations int
+		want       string
+	}{
+		{ETypeAES128, "password", "ATHENA.MIT.EDUraeburn", 1, "42263c6e89f4fc28b8df68ee09799f15"},
+		{ETypeAES256, "password", "ATHENA.MIT.EDUraeburn", 1, "fe697b52bc0d3ce14432ba036a92e65bbb52280990a2fa27883998d72af30161"},
+		{ETypeAES128, "password", "ATHENA.MIT.EDUraeburn", 1200, "4c01cd46d632d01e6dbe230a01ed642a"},
+		{ETypeAES256, "password", "ATHENA.MIT.EDUraeburn", 1200, "55a6ac740ad17b4846941051e1e8b0a7548d93b0ab30a8bc3ff16280382b8c2a"},
+		{ETypeRC4, "password", "", 0, "8846f7eaee8fb117ad06bdd830b7586c"},
+	}
+	for _, tt := range tests {
+		k, err := stringToKey(tt.etype, tt.password, tt.salt, tt.iterations)
+		if err != nil {
+			t.Fatalf("%s: %v", ETypeName(tt.etype), err)
+		}
+		if got := hex.EncodeToString(k.value); got != tt.want {
+			t.Errorf("%s key with %d iterations = %s, want %s", ETypeName(tt.etype), tt.iterations, got, tt.want)
+		}
+	}
+}
+
+// TestCTS checks the AES-CTS vectors of RFC 3962 appendix B
+func TestCTS(t *testing.T) {
+	k := []byte("chicken teriyaki")
+	tests := []struct{ plain, cipher string }{
+		{"4920776f756c64206c696b652074686520", "c6353568f2bf8cb4d8a580362da7ff7f97"},
+		{"4920776f756c64206c696b65207468652047656e6572616c20476175277320", "fc00783e0efdb2c1d445d4c8eff7ed2297687268d6ecccc0c07b25e25ecfe5"},
+		{"4920776f756c64206c696b65207468652047656e6572616c2047617527732043", "39312523a78662d5be7fcbcc98ebf5a897687268d6ecccc0c07b25e25ecfe584"},
+	}
+	for _, tt := range tests {
+		plain, want := unhex(t, tt.plain), unhex(t, tt.cipher)
+		got, err := ctsEncrypt(k, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(got, want) {
+			t.Errorf("encrypt %s = %x, want %s", tt.plain, got, tt.cipher)
+		}
+		back, err := ctsDecrypt(k, want)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(back, plain) {
+			t.Errorf("decrypt %s = %x, want %s", tt.cipher, back, tt.plain)
+		}
+	}
+}
+
+// TestEncryptRoundTrip checks every encryption type decrypts its own
+// output, and rejects the wrong key usage
+func TestEncryptRoundTrip(t *testing.T) {
+	plain := []byte("an encoded EncASRepPart of some length")
+	for _, etype := range []int32{ETypeRC4, ETypeAES128, ETypeAES256} {
+		k, err := stringToKey(etype, "Summer2024!", "CORP.LOCALsvc_sql", 0)
+		if err != nil {
+			t.Fatal(err)
+		}
+		c, err := k.encrypt(usageASRepEncPart, plain)
+		if err != nil {
+			t.Fatal(err)
+		}
+		got, err := k.decrypt(usageASRepEncPart, c)
+		if err != nil {
+			t.Fatalf("%s: %v", ETypeName(etype), err)
+		}
+		if !bytes.Equal(got, plain) {
+			t.Errorf("%s: decrypted %q, want %q", ETypeName(etype), got, plain)
+		}
+		if _, err := k.decrypt(usageTicket, c); err == nil {
+			t.Errorf("%s: decrypting with the wrong usage succeeded", ETypeName(etype))
+		}
+	}
+}
@@ -0,0 +1,66 @@
+package kerberos
+
+import (
+	"encoding/asn1"
+	"fmt"
+)
+
+// KDC error codes (RFC 4120 section 7.5.9)
+const (
+	ErrClientUnknown   int32 = 6  // KDC_ERR_C_PRINCIPAL_UNKNOWN
+	ErrServerUnknown   int32 = 7  // KDC_ERR_S_PRINCIPAL_UNKNOWN
+	ErrServerNotUnique int32 = 8  // KDC_ERR_PRINCIPAL_NOT_UNIQUE
+	ErrETypeNotSupp    int32 = 14 // KDC_ERR_ETYPE_NOSUPP
+	ErrClientRevoked   int32 = 18 // KDC_ERR_CLIENT_REVOKED
+	ErrKeyExpired      int32 = 23 // KDC_ERR_KEY_EXPIRED
+	ErrPreauthFailed   int32 = 24 // KDC_ERR_PREAUTH_FAILED
+	ErrPreauthRequired int32 = 25 // KDC_ERR_PREAUTH_REQUIRED
+	ErrTicketExpired   int32 = 32 // KRB_AP_ERR_TKT_EXPIRED
+	ErrSkew            int32 = 37 // KRB_AP_ERR_SKEW
+	ErrWrongRealm      int32 = 68 // KDC_ERR_WRONG_REALM
+)
+
+// errorNames describes the KDC errors roasting commonly runs into
+var errorNames = map[int32]string{
+	ErrClientUnknown:   "client not found in Kerberos database",
+	ErrServerUnknown:   "server not found in Kerberos database",
+	ErrServerNotUnique: "principal is not unique (duplicate SPN)",
+	ErrETypeNotSupp:    "encryption type not supported by the account",
+	ErrClientRevoked:   "client credentials revoked (disabled or locked out)",
+	ErrKeyExpired:      "password has expired",
+	ErrPreauthFailed:   "pre-authentication failed (wrong password)",
+	ErrPreauthRequired: "pre-authentication required",
+	ErrTicketExpired:   "ticket expired",
+	ErrSkew:            "clock skew too great",
+	ErrWrongRealm:      "wrong realm",
+}
+
+// Error is a KRB-ERROR returned by the KDC
+type Error struct {
+	Code int32  // Error code
+	Text string // Optional text from the KDC
+
+	eData []byte
+}
+
+func (e *Error) Error() string {
+	msg := errorNames[e.Code]
+	if msg == "" {
+		msg = "KDC error"
+	}
+	msg = fmt.Sprintf("%s (code %d)", msg, e.Code)
+	if e.Text != "" {
+		msg += ": " + e.Text
+	}
+	return msg
+}
+
+// eTypeInfo returns the PA-ETYPE-INFO2 entries of the error data, sent
+// with ErrPreauthRequired
+func (e *Error) eTypeInfo() []eTypeInfo2Entry {
+	var padata []paData
+	if _, err := asn1.Unmarshal(e.eData, &padata); err != nil {
+		return nil
+	}
+	return eTypeInfo(padata)
+}
@@ -0,0 +1,65 @@
+package kerberos
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+)
+
+// TGSHash formats a service ticket of the account user as a $krb5tgs$
+// hash, cracked by hashcat modes 13100 (RC4), 19600 (AES128) and 19700
+// (AES256) and by john's krb5tgs formats
+func TGSHash(user string, t *Ticket) (string, error) {
+	if err := checkCipher(t.EType, t.Cipher); err != nil {
+		return "", fmt.Errorf("ticket for %s: %w", t.SPN, err)
+	}
+	// Colons separate fields in john and hashcat potfiles
+	spn := strings.ReplaceAll(t.SPN, ":", "~")
+	if t.EType == ETypeRC4 {
+		return fmt.Sprintf("$krb5tgs$%d$*%s$%s$%s*$%s$%s", t.EType, user, t.Realm, spn,
+			hex.EncodeToString(t.Cipher[:16]), hex.EncodeToString(t.Cipher[16:])), nil
+	}
+	n := len(t.Cipher) - 12
+	return fmt.Sprintf("$krb5tgs$%d$%s$%s$*%s*$%s$%s", t.EType, user, t.Realm, spn,
+		hex.EncodeToString(t.Cipher[n:]), hex.EncodeToString(t.Cipher[:n])), nil
+}
+
+// ASREPHash formats an AS-REP as a $krb5asrep$ hash, cracked by hashcat
+// modes 18200 (RC4), 32100 (AES128) and 32200 (AES256) and by john's
+// krb5asrep format
+func ASREPHash(a *ASREP) (string, error) {
+	if err := checkCipher(a.EType, a.Cipher); err != nil {
+		return "", fmt.Errorf("AS-REP for %s: %w", a.User, err)
+	}
+	if a.EType == ETypeRC4 {
+		return fmt.Sprintf("$krb5asrep$%d$%s@%s:%s$%s", a.EType, a.User, a.Realm,
+			hex.EncodeToString(a.Cipher[:16]), hex.EncodeToString(a.Cipher[16:])), nil
+	}
+	n := len(a.Cipher) - 12
+	return fmt.Sprintf("$krb5asrep$%d$%s$%s$%s$%s", a.EType, a.User, a.Realm,
+		hex.EncodeToString(a.Cipher[n:]), hex.EncodeToString(a.Cipher[:n])), nil
+}
+
+// checkCipher returns an error if cipher is too short for the checksum a
+// hash of etype is split at: the leading 16 bytes for RC4, the trailing
+// 12 bytes for AES
+func checkCipher(etype int32, cipher []byte) error {
+	checksum := 12
+	if etype == ETypeRC4 {
+		checksum = 16
+	}
+	if len(cipher) < checksum {
+		return fmt.Errorf("%s cipher of %d bytes is shorter than its %d byte checksum", ETypeName(etype), len(cipher), checksum)
+	}
+	return nil
+}
+
+// JohnHash converts a hash of TGSHash or ASREPHash to the form john
+// expects. john's krb5asrep format takes RC4 hashes without the
+// encryption type; the other hashes are the same for both crackers.
+func JohnHash(hash string) string {
+	if rest, ok := strings.CutPrefix(hash, "$krb5asrep$23$"); ok {
+		return "$krb5asrep$" + rest
+	}
+	return hash
+}
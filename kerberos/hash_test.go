@@ -0,0 +1,84 @@
+package kerberos
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+)
+
+func TestTGSHash(t *testing.T) {
+	cipher := bytes.Repeat([]byte{0xab}, 40)
+	rc4, _ := TGSHash("svc_sql", &Ticket{Realm: "CORP.LOCAL", SPN: "MSSQLSvc/sql01.corp.local:1433", EType: ETypeRC4, Cipher: cipher})
+	want := "$krb5tgs$23$*svc_sql$CORP.LOCAL$MSSQLSvc/sql01.corp.local~1433*$" + hex.EncodeToString(cipher[:16]) + "$" + hex.EncodeToString(cipher[16:])
+	if rc4 != want {
+		t.Errorf("RC4 hash = %s, want %s", rc4, want)
+	}
+
+	aes, _ := TGSHash("svc_web", &Ticket{Realm: "CORP.LOCAL", SPN: "HTTP/web01", EType: ETypeAES256, Cipher: cipher})
+	want = "$krb5tgs$18$svc_web$CORP.LOCAL$*HTTP/web01*$" + hex.EncodeToString(cipher[28:]) + "$" + hex.EncodeToString(cipher[:28])
+	if aes != want {
+		t.Errorf("AES hash = %s, want %s", aes, want)
+	}
+}
+
+func TestASREPHash(t *testing.T) {
+	cipher := bytes.Repeat([]byte{0xcd}, 40)
+	rc4, _ := ASREPHash(&ASREP{User: "jdoe", Realm: "CORP.LOCAL", EType: ETypeRC4, Cipher: cipher})
+	want := "$krb5asrep$23$jdoe@CORP.LOCAL:" + hex.EncodeToString(cipher[:16]) + "$" + hex.EncodeToString(cipher[16:])
+	if rc4 != want {
+		t.Errorf("RC4 hash = %s, want %s", rc4, want)
+	}
+
+	aes, _ := ASREPHash(&ASREP{User: "jdoe", Realm: "CORP.LOCAL", EType: ETypeAES128, Cipher: cipher})
+	want = "$krb5asrep$17$jdoe$CORP.LOCAL$" + hex.EncodeToString(cipher[28:]) + "$" + hex.EncodeToString(cipher[:28])
+	if aes != want {
+		t.Errorf("AES hash = %s, want %s", aes, want)
+	}
+}
+
+func TestHashShortCipher(t *testing.T) {
+	tests := []struct {
+		name  string
+		etype int32
+		size  int
+		ok    bool
+	}{
+		{"RC4 empty", ETypeRC4, 0, false},
+		{"RC4 short", ETypeRC4, 15, false},
+		{"RC4 checksum only", ETypeRC4, 16, true},
+		{"AES empty", ETypeAES256, 0, false},
+		{"AES short", ETypeAES128, 11, false},
+		{"AES checksum only", ETypeAES256, 12, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cipher := bytes.Repeat([]byte{0xef}, tt.size)
+			tgs, err := TGSHash("svc_sql", &Ticket{Realm: "CORP.LOCAL", SPN: "MSSQLSvc/sql01", EType: tt.etype, Cipher: cipher})
+			if (err == nil) != tt.ok || (tgs == "") == tt.ok {
+				t.Errorf("TGSHash = %q, %v", tgs, err)
+			}
+			asrep, err := ASREPHash(&ASREP{User: "jdoe", Realm: "CORP.LOCAL", EType: tt.etype, Cipher: cipher})
+			if (err == nil) != tt.ok || (asrep == "") == tt.ok {
+				t.Errorf("ASREPHash = %q, %v", asrep, err)
+			}
+			if err != nil && !strings.Contains(err.Error(), "jdoe") {
+				t.Errorf("ASREPHash error %q does not name the account", err)
+			}
+		})
+	}
+}
+
+func TestJohnHash(t *testing.T) {
+	cipher := bytes.Repeat([]byte{0xcd}, 40)
+	rc4, _ := ASREPHash(&ASREP{User: "jdoe", Realm: "CORP.LOCAL", EType: ETypeRC4, Cipher: cipher})
+	want := "$krb5asrep$jdoe@CORP.LOCAL:" + hex.EncodeToString(cipher[:16]) + "$" + hex.EncodeToString(cipher[16:])
+	if got := JohnHash(rc4); got != want {
+		t.Errorf("JohnHash(RC4 AS-REP) = %s, want %s", got, want)
+	}
+
+	tgs, _ := TGSHash("svc_sql", &Ticket{Realm: "CORP.LOCAL", SPN: "MSSQLSvc/sql01", EType: ETypeRC4, Cipher: cipher})
+	if got := JohnHash(tgs); got != tgs {
+		t.Errorf("JohnHash(TGS) = %s, want it unchanged", got)
+	}
+}
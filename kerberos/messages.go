@@ -0,0 +1,336 @@
+package kerberos
+
+import (
+	"encoding/asn1"
+	"fmt"
+	"strings"
+	"time"
+
+	"golang.org/x/crypto/cryptobyte"
+	cbasn1 "golang.org/x/crypto/cryptobyte/asn1"
+)
+
+// Message types, which are also the APPLICATION tags of the messages
+const (
+	msgASReq    = 10
+	msgASRep    = 11
+	msgTGSReq   = 12
+	msgTGSRep   = 13
+	msgAPReq    = 14
+	msgKRBError = 30
+
+	tagTicket        = 1
+	tagAuthenticator = 2
+	tagEncASRepPart  = 25
+	tagEncTGSRepPart = 26
+)
+
+// Principal name types
+const (
+	NameTypePrincipal = 1 // NT-PRINCIPAL, a user or computer account
+	NameTypeSrvInst   = 2 // NT-SRV-INST, a service and its instances
+)
+
+// Pre-authentication data types
+const (
+	paTGSReq       = 1
+	paEncTimestamp = 2
+	paETypeInfo2   = 19
+	paPACRequest   = 128
+)
+
+// kdcOptions requests a forwardable, renewable and canonicalized ticket,
+// like the Windows client does
+var kdcOptions = []byte{0x40, 0x81, 0x00, 0x10}
+
+// farFuture is the "till" time of ticket requests; the KDC caps it to
+// the policy lifetime
+var farFuture = time.Date(2037, 9, 13, 2, 48, 5, 0, time.UTC)
+
+// tagGeneralString is the universal tag of KerberosString
+const tagGeneralString = cbasn1.Tag(27)
+
+// principalName is the PrincipalName of a client or service
+type principalName struct {
+	NameType   int32    `asn1:"explicit,tag:0"`
+	NameString []string `asn1:"explicit,tag:1"`
+}
+
+// String returns the principal as "component/component"
+func (p principalName) String() string {
+	return strings.Join(p.NameString, "/")
+}
+
+// encryptedData is a message part encrypted under a key
+type encryptedData struct {
+	EType  int32  `asn1:"explicit,tag:0"`
+	KVNO   int    `asn1:"optional,explicit,tag:1"`
+	Cipher []byte `asn1:"explicit,tag:2"`
+}
+
+// encryptionKey is a key sent by the KDC, such as a session key
+type encryptionKey struct {
+	KeyType  int32  `asn1:"explicit,tag:0"`
+	KeyValue []byte `asn1:"explicit,tag:1"`
+}
+
+// paData is one PA-DATA element
+type paData struct {
+	Type  int32  `asn1:"explicit,tag:1"`
+	Value []byte `asn1:"explicit,tag:2"`
+}
+
+// eTypeInfo2Entry names an encryption type of the client key and its salt
+type eTypeInfo2Entry struct {
+	EType     int32  `asn1:"explicit,tag:0"`
+	Salt      string `asn1:"optional,explicit,tag:1"`
+	S2KParams []byte `asn1:"optional,explicit,tag:2"`
+}
+
+// kdcRep is an AS-REP or TGS-REP
+type kdcRep struct {
+	PVNO    int           `asn1:"explicit,tag:0"`
+	MsgType int           `asn1:"explicit,tag:1"`
+	PAData  []paData      `asn1:"optional,explicit,tag:2"`
+	CRealm  string        `asn1:"explicit,tag:3"`
+	CName   principalName `asn1:"explicit,tag:4"`
+	Ticket  asn1.RawValue `asn1:"explicit,tag:5"` // Bytes hold the encoded Ticket
+	EncPart encryptedData `asn1:"explicit,tag:6"`
+}
+
+// ticket is a Ticket; its encrypted part is under the service key
+type ticket struct {
+	TktVNO  int           `asn1:"explicit,tag:0"`
+	Realm   string        `asn1:"explicit,tag:1"`
+	SName   principalName `asn1:"explicit,tag:2"`
+	EncPart encryptedData `asn1:"explicit,tag:3"`
+}
+
+// encKDCRepPart is the start of EncASRepPart and EncTGSRepPart
+type encKDCRepPart struct {
+	Key     encryptionKey `asn1:"explicit,tag:0"`
+	LastReq asn1.RawValue `asn1:"explicit,tag:1"`
+	Nonce   int64         `asn1:"explicit,tag:2"`
+}
+
+// krbError is a KRB-ERROR
+type krbError struct {
+	PVNO      int           `asn1:"explicit,tag:0"`
+	MsgType   int           `asn1:"explicit,tag:1"`
+	CTime     time.Time     `asn1:"generalized,optional,explicit,tag:2"`
+	CUSec     int           `asn1:"optional,explicit,tag:3"`
+	STime     time.Time     `asn1:"generalized,explicit,tag:4"`
+	SUSec     int           `asn1:"explicit,tag:5"`
+	ErrorCode int32         `asn1:"explicit,tag:6"`
+	CRealm    string        `asn1:"optional,explicit,tag:7"`
+	CName     principalName `asn1:"optional,explicit,tag:8"`
+	Realm     string        `asn1:"explicit,tag:9"`
+	SName     principalName `asn1:"explicit,tag:10"`
+	EText     string        `asn1:"optional,explicit,tag:11"`
+	EData     []byte        `asn1:"optional,explicit,tag:12"`
+}
+
+// kdcRequest is the content of an AS-REQ or TGS-REQ
+type kdcRequest struct {
+	msgType int
+	paData  []paData
+	cname   *principalName // Client, AS-REQ only
+	realm   string
+	sname   principalName
+	nonce   uint32
+	etypes  []int32
+}
+
+// marshal encodes the request
+func (r *kdcRequest) marshal() ([]byte, error) {
+	var b cryptobyte.Builder
+	application(&b, r.msgType, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 1, 5)
+			addInt(b, 2, int64(r.msgType))
+			if len(r.paData) > 0 {
+				explicit(b, 3, func(b *cryptobyte.Builder) {
+					b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+						for _, pa := range r.paData {
+							b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+								addInt(b, 1, int64(pa.Type))
+								addOctets(b, 2, pa.Value)
+							})
+						}
+					})
+				})
+			}
+			explicit(b, 4, r.marshalBody)
+		})
+	})
+	return b.Bytes()
+}
+
+// marshalBody adds the KDC-REQ-BODY of the request
+func (r *kdcRequest) marshalBody(b *cryptobyte.Builder) {
+	b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		explicit(b, 0, func(b *cryptobyte.Builder) {
+			b.AddASN1BitString(kdcOptions)
+		})
+		if r.cname != nil {
+			addPrincipal(b, 1, *r.cname)
+		}
+		addString(b, 2, r.realm)
+		addPrincipal(b, 3, r.sname)
+		addTime(b, 5, farFuture)
+		addInt(b, 7, int64(r.nonce))
+		explicit(b, 8, func(b *cryptobyte.Builder) {
+			b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+				for _, etype := range r.etypes {
+					b.AddASN1Int64(int64(etype))
+				}
+			})
+		})
+	})
+}
+
+// marshalEncryptedData encodes an EncryptedData
+func marshalEncryptedData(etype int32, cipher []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	addEncryptedData(&b, etype, cipher)
+	return b.Bytes()
+}
+
+// marshalTimestamp encodes the PA-ENC-TS-ENC of now
+func marshalTimestamp(now time.Time) ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addTime(b, 0, now)
+		addInt(b, 1, int64(now.Nanosecond()/1000))
+	})
+	return b.Bytes()
+}
+
+// marshalPACRequest encodes a KERB-PA-PAC-REQUEST asking for a PAC
+func marshalPACRequest() ([]byte, error) {
+	var b cryptobyte.Builder
+	b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		explicit(b, 0, func(b *cryptobyte.Builder) {
+			b.AddASN1Boolean(true)
+		})
+	})
+	return b.Bytes()
+}
+
+// marshalAuthenticator encodes the Authenticator of an AP-REQ
+func marshalAuthenticator(realm string, cname principalName, now time.Time) ([]byte, error) {
+	var b cryptobyte.Builder
+	application(&b, tagAuthenticator, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 0, 5)
+			addString(b, 1, realm)
+			addPrincipal(b, 2, cname)
+			addInt(b, 4, int64(now.Nanosecond()/1000))
+			addTime(b, 5, now)
+		})
+	})
+	return b.Bytes()
+}
+
+// marshalAPReq encodes an AP-REQ presenting ticket with an encrypted
+// authenticator
+func marshalAPReq(ticket []byte, etype int32, authenticator []byte) ([]byte, error) {
+	var b cryptobyte.Builder
+	application(&b, msgAPReq, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 0, 5)
+			addInt(b, 1, msgAPReq)
+			explicit(b, 2, func(b *cryptobyte.Builder) {
+				b.AddASN1BitString(make([]byte, 4))
+			})
+			explicit(b, 3, func(b *cryptobyte.Builder) {
+				b.AddBytes(ticket)
+			})
+			explicit(b, 4, func(b *cryptobyte.Builder) {
+				addEncryptedData(b, etype, authenticator)
+			})
+		})
+	})
+	return b.Bytes()
+}
+
+// application adds a constructed [APPLICATION tag] element
+func application(b *cryptobyte.Builder, tag int, f cryptobyte.BuilderContinuation) {
+	b.AddASN1(cbasn1.Tag(0x40|tag).Constructed(), f)
+}
+
+// explicit adds an explicitly tagged [tag] element
+func explicit(b *cryptobyte.Builder, tag int, f cryptobyte.BuilderContinuation) {
+	b.AddASN1(cbasn1.Tag(tag).ContextSpecific().Constructed(), f)
+}
+
+func addInt(b *cryptobyte.Builder, tag int, v int64) {
+	explicit(b, tag, func(b *cryptobyte.Builder) {
+		b.AddASN1Int64(v)
+	})
+}
+
+func addOctets(b *cryptobyte.Builder, tag int, v []byte) {
+	explicit(b, tag, func(b *cryptobyte.Builder) {
+		b.AddASN1OctetString(v)
+	})
+}
+
+func addString(b *cryptobyte.Builder, tag int, s string) {
+	explicit(b, tag, func(b *cryptobyte.Builder) {
+		b.AddASN1(tagGeneralString, func(b *cryptobyte.Builder) {
+			b.AddBytes([]byte(s))
+		})
+	})
+}
+
+// addTime adds a KerberosTime, a GeneralizedTime in UTC without fractions
+func addTime(b *cryptobyte.Builder, tag int, t time.Time) {
+	explicit(b, tag, func(b *cryptobyte.Builder) {
+		b.AddASN1GeneralizedTime(t.UTC().Truncate(time.Second))
+	})
+}
+
+func addEncryptedData(b *cryptobyte.Builder, etype int32, cipher []byte) {
+	b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+		addInt(b, 0, int64(etype))
+		addOctets(b, 2, cipher)
+	})
+}
+
+func addPrincipal(b *cryptobyte.Builder, tag int, p principalName) {
+	explicit(b, tag, func(b *cryptobyte.Builder) {
+		b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+			addInt(b, 0, int64(p.NameType))
+			explicit(b, 1, func(b *cryptobyte.Builder) {
+				b.AddASN1(cbasn1.SEQUENCE, func(b *cryptobyte.Builder) {
+					for _, s := range p.NameString {
+						b.AddASN1(tagGeneralString, func(b *cryptobyte.Builder) {
+							b.AddBytes([]byte(s))
+						})
+					}
+				})
+			})
+		})
+	})
+}
+
+// unmarshalApplication decodes an [APPLICATION tag] message into v
+func unmarshalApplication(data []byte, tag int, v any) error {
+	rest, err := asn1.UnmarshalWithParams(data, v, fmt.Sprintf("application,explicit,tag:%d", tag))
+	if err != nil {
+		return err
+	}
+	if len(rest) > 0 {
+		return fmt.Errorf("trailing data after message")
+	}
+	return nil
+}
+
+// applicationTag returns the APPLICATION tag of a message, or -1
+func applicationTag(data []byte) int {
+	if len(data) == 0 || data[0]&0xE0 != 0x60 {
+		return -1
+	}
+	return int(data[0] & 0x1F)
+}
@@ -0,0 +1,303 @@
+package kerberos
+
+import (
+	"bytes"
+	"encoding/asn1"
+	"strings"
+	"testing"
+	"time"
+)
+
+// Reference encodings of the MIT Kerberos ASN.1 test suite
+// (src/tests/asn.1/reference_encode.out), for client hftsai/extra and
+// service hftsai/extra in ATHENA.MIT.EDU, with the ciphertext
+// "krbASN.1 test message" under etype 0 and kvno 5
+const (
+	mitASRep = "6b81ea3081e7a003020105a10302010ba22630243010a10302010da209040770" +
+		"612d646174613010a10302010da209040770612d64617461a3101b0e41544845" +
+		"4e412e4d49542e454455a41a3018a003020101a111300f1b066866747361691b" +
+		"056578747261a55e615c305aa003020105a1101b0e415448454e412e4d49542e" +
+		"454455a21a3018a003020101a111300f1b066866747361691b056578747261a3" +
+		"253023a003020100a103020105a21704156b726241534e2e312074657374206d" +
+		"657373616765a6253023a003020100a103020105a21704156b726241534e2e31" +
+		"2074657374206d657373616765"
+	mitTGSRep = "6d81ea3081e7a003020105a10302010da22630243010a10302010da209040770" +
+		"612d646174613010a10302010da209040770612d64617461a3101b0e41544845" +
+		"4e412e4d49542e454455a41a3018a003020101a111300f1b066866747361691b" +
+		"056578747261a55e615c305aa003020105a1101b0e415448454e412e4d49542e" +
+		"454455a21a3018a003020101a111300f1b066866747361691b056578747261a3" +
+		"253023a003020100a103020105a21704156b726241534e2e312074657374206d" +
+		"657373616765a6253023a003020100a103020105a21704156b726241534e2e31" +
+		"2074657374206d657373616765"
+	mitKRBError = "7e81ba3081b7a003020105a10302011ea211180f313939343036313030363033" +
+		"31375aa305020301e240a411180f31393934303631303036303331375aa50502" +
+		"0301e240a60302013ca7101b0e415448454e412e4d49542e454455a81a3018a0" +
+		"03020101a111300f1b066866747361691b056578747261a9101b0e415448454e" +
+		"412e4d49542e454455aa1a3018a003020101a111300f1b066866747361691b05" +
+		"6578747261ab0a1b086b72623564617461ac0a04086b72623564617461"
+	mitKRBErrorNoOptionals = "7e60305ea003020105a10302011ea305020301e240a411180f31393934303631" +
+		"303036303331375aa505020301e240a60302013ca9101b0e415448454e412e4d" +
+		"49542e454455aa1a3018a003020101a111300f1b066866747361691b05657874" +
+		"7261"
+	mitETypeInfo2 = "3051301ea003020100a10d1b0b4d6f72746f6e2773202330a208040673326b3a" +
+		"2030300fa003020101a208040673326b3a2031301ea003020102a10d1b0b4d6f" +
+		"72746f6e2773202332a208040673326b3a2032"
+)
+
+// capturedASRep is an AS-REP of an MIT KDC for testuser1@TEST.GOKRB5,
+// whose password is "passwordvalue", encrypted with aes256-cts-hmac-sha1-96
+// (from the gokrb5 test suite)
+const capturedASRep = "6b8202f3308202efa003020105a10302010ba22e302c302aa103020113a22304" +
+	"21301f301da003020112a1161b14544553542e474f4b52423574657374757365" +
+	"7231a30d1b0b544553542e474f4b524235a4163014a003020101a10d300b1b09" +
+	"746573747573657231a582015a6182015630820152a003020105a10d1b0b5445" +
+	"53542e474f4b524235a220301ea003020102a11730151b066b72627467741b0b" +
+	"544553542e474f4b524235a382011830820114a003020112a103020101a28201" +
+	"060482010237e486e32cd18ab1ac9f8d42e93f8babd7b3497084cc5599f18ec6" +
+	"1961c6d5242d350354d99d67a7604c451116188d16cb719e84377212eac27434" +
+	"40e8c504ef69c755e489cc6b65f935dd032bfc076f9b2c56d816197845b8fe85" +
+	"7d738bc59712787631a50e86833d1b0e4732c8712c856417a6a257758e7d01d3" +
+	"182adb3233f0dde65d228c240ed26aa1af69f8d765dc0bc69096fdb037a75af2" +
+	"20fea176839528d44b70f7dabfaa2ea506de1296f847176a60c501fd8cef8e0a" +
+	"51399bb6d5f753962d96292e93ffe344c6630db912931d46d88c0279f00719e2" +
+	"2d0efcfd4ee33a702d0b660c1f13970a9beec12c0c8af3dda68bd81ac1fe3f12" +
+	"6d2a24ebb445c5a682012c30820128a003020112a282011f0482011bb149cc16" +
+	"018072c4c18788d95a33aba540e52c11b54a93e67e788d05de75d8f3d4aa1afa" +
+	"fbbfa6fde3eb40e5aa1890644cea2607efd5213a3fd00345b02eeb9ae1b589f3" +
+	"6c74c689cd4ec1239dfe61e42ba6afa33f6240e3cfab291e4abb465d273302db" +
+	"f7dbd148a299a9369044dd03377c1687e7dd36aa66501284a4ca50c0a7b08f4f" +
+	"87aecfa23b0dd0b11490e3ad330906dab715de81fc52f120d09c39990b8b5330" +
+	"d4601cc396b2ed258834329c4cc02c563a12de3ef9bf11e946258bc2ab5257f4" +
+	"caa4d443a7daf0fc25f6f531c2fcba88af8ca55c85300997cd05abbea52811fe" +
+	"2d038ba8f62fc8e3bc71ce04362d356ea2e1df8ac55c784c53cfb07817d48e39" +
+	"fe99fc8788040d98209c79dcf044d97e80de9f47824646"
+
+const (
+	mitRealm  = "ATHENA.MIT.EDU"
+	mitCipher = "krbASN.1 test message"
+)
+
+// mitPrincipal is the client and service of the MIT reference encodings
+var mitPrincipal = principalName{NameType: NameTypePrincipal, NameString: []string{"hftsai", "extra"}}
+
+func equalPrincipal(a, b principalName) bool {
+	return a.NameType == b.NameType && a.String() == b.String()
+}
+
+// checkMITRep checks a KDC-REP of the reference encodings
+func checkMITRep(t *testing.T, rep kdcRep, msgType int) {
+	t.Helper()
+	if rep.PVNO != 5 || rep.MsgType != msgType {
+		t.Errorf("pvno %d, msg-type %d; want 5, %d", rep.PVNO, rep.MsgType, msgType)
+	}
+	if len(rep.PAData) != 2 || rep.PAData[0].Type != 13 || string(rep.PAData[1].Value) != "pa-data" {
+		t.Errorf("padata = %+v", rep.PAData)
+	}
+	if rep.CRealm != mitRealm || !equalPrincipal(rep.CName, mitPrincipal) {
+		t.Errorf("client = %s@%s", rep.CName, rep.CRealm)
+	}
+	if rep.EncPart.EType != 0 || rep.EncPart.KVNO != 5 || string(rep.EncPart.Cipher) != mitCipher {
+		t.Errorf("enc-part = %+v", rep.EncPart)
+	}
+
+	// The ticket is kept encoded, to be sent back in an AP-REQ
+	if applicationTag(rep.Ticket.Bytes) != tagTicket {
+		t.Fatalf("ticket is not an encoded Ticket: % x", rep.Ticket.Bytes[:4])
+	}
+	var tkt ticket
+	if err := unmarshalApplication(rep.Ticket.Bytes, tagTicket, &tkt); err != nil {
+		t.Fatalf("parsing ticket: %v", err)
+	}
+	if tkt.TktVNO != 5 || tkt.Realm != mitRealm || !equalPrincipal(tkt.SName, mitPrincipal) || string(tkt.EncPart.Cipher) != mitCipher {
+		t.Errorf("ticket = %+v", tkt)
+	}
+}
+
+func TestUnmarshalASRep(t *testing.T) {
+	data := unhex(t, mitASRep)
+	if tag := applicationTag(data); tag != msgASRep {
+		t.Fatalf("applicationTag = %d, want %d", tag, msgASRep)
+	}
+	var rep kdcRep
+	if err := unmarshalApplication(data, msgASRep, &rep); err != nil {
+		t.Fatal(err)
+	}
+	checkMITRep(t, rep, msgASRep)
+}
+
+func TestUnmarshalTGSRep(t *testing.T) {
+	data := unhex(t, mitTGSRep)
+	if tag := applicationTag(data); tag != msgTGSRep {
+		t.Fatalf("applicationTag = %d, want %d", tag, msgTGSRep)
+	}
+	var rep kdcRep
+	if err := unmarshalApplication(data, msgTGSRep, &rep); err != nil {
+		t.Fatal(err)
+	}
+	checkMITRep(t, rep, msgTGSRep)
+}
+
+func TestUnmarshalKRBError(t *testing.T) {
+	stime := time.Date(1994, 6, 10, 6, 3, 17, 0, time.UTC)
+
+	var e krbError
+	if err := unmarshalApplication(unhex(t, mitKRBError), msgKRBError, &e); err != nil {
+		t.Fatal(err)
+	}
+	if e.ErrorCode != 60 || !e.STime.Equal(stime) || e.SUSec != 123456 || !e.CTime.Equal(stime) {
+		t.Errorf("error %d at %s.%d, ctime %s", e.ErrorCode, e.STime, e.SUSec, e.CTime)
+	}
+	if e.CRealm != mitRealm || !equalPrincipal(e.CName, mitPrincipal) || e.Realm != mitRealm || !equalPrincipal(e.SName, mitPrincipal) {
+		t.Errorf("client %s@%s, service %s@%s", e.CName, e.CRealm, e.SName, e.Realm)
+	}
+	if e.EText != "krb5data" || string(e.EData) != "krb5data" {
+		t.Errorf("e-text %q, e-data %q", e.EText, e.EData)
+	}
+
+	// Without the optional client, times, text and data
+	var bare krbError
+	if err := unmarshalApplication(unhex(t, mitKRBErrorNoOptionals), msgKRBError, &bare); err != nil {
+		t.Fatal(err)
+	}
+	if bare.ErrorCode != 60 || bare.CRealm != "" || !bare.CTime.IsZero() || bare.EText != "" || bare.EData != nil {
+		t.Errorf("error without optionals = %+v", bare)
+	}
+}
+
+func TestUnmarshalApplicationRejects(t *testing.T) {
+	asRep := unhex(t, mitASRep)
+	var rep kdcRep
+	if err := unmarshalApplication(asRep, msgTGSRep, &rep); err == nil {
+		t.Error("an AS-REP parsed as a TGS-REP")
+	}
+	if err := unmarshalApplication(append(bytes.Clone(asRep), 0), msgASRep, &rep); err == nil || !strings.Contains(err.Error(), "trailing") {
+		t.Errorf("trailing data: err = %v", err)
+	}
+	if err := unmarshalApplication(asRep[:len(asRep)-10], msgASRep, &rep); err == nil {
+		t.Error("a truncated AS-REP parsed")
+	}
+	if tag := applicationTag([]byte{0x30, 0x00}); tag != -1 {
+		t.Errorf("applicationTag(SEQUENCE) = %d, want -1", tag)
+	}
+	if tag := applicationTag(nil); tag != -1 {
+		t.Errorf("applicationTag(nil) = %d, want -1", tag)
+	}
+}
+
+func TestETypeInfo(t *testing.T) {
+	padata := []paData{{Type: paPACRequest, Value: []byte{0x30, 0x00}}, {Type: paETypeInfo2, Value: unhex(t, mitETypeInfo2)}}
+	entries := eTypeInfo(padata)
+	if len(entries) != 3 {
+		t.Fatalf("got %d entries, want 3", len(entries))
+	}
+	want := []eTypeInfo2Entry{
+		{EType: 0, Salt: "Morton's #0", S2KParams: []byte("s2k: 0")},
+		{EType: 1, S2KParams: []byte("s2k: 1")},
+		{EType: 2, Salt: "Morton's #2", S2KParams: []byte("s2k: 2")},
+	}
+	for i, e := range entries {
+		if e.EType != want[i].EType || e.Salt != want[i].Salt || !bytes.Equal(e.S2KParams, want[i].S2KParams) {
+			t.Errorf("entry %d = %+v, want %+v", i, e, want[i])
+		}
+	}
+	if _, ok := chooseEType(entries); ok {
+		t.Error("chose one of the unsupported etypes 0, 1 and 2")
+	}
+	if e, ok := chooseEType(append(entries, eTypeInfo2Entry{EType: ETypeRC4}, eTypeInfo2Entry{EType: ETypeAES256})); !ok || e.EType != ETypeRC4 {
+		t.Errorf("chooseEType = %d, %t; want the first supported, rc4-hmac", e.EType, ok)
+	}
+}
+
+// TestDecryptCapturedASRep derives the key of a real AS-REP from the
+// salt it announces and decrypts its session key
+func TestDecryptCapturedASRep(t *testing.T) {
+	var rep kdcRep
+	if err := unmarshalApplication(unhex(t, capturedASRep), msgASRep, &rep); err != nil {
+		t.Fatal(err)
+	}
+	if rep.CRealm != "TEST.GOKRB5" || rep.CName.String() != "testuser1" || rep.EncPart.EType != ETypeAES256 {
+		t.Fatalf("AS-REP for %s@%s with %s", rep.CName, rep.CRealm, ETypeName(rep.EncPart.EType))
+	}
+	var tkt ticket
+	if err := unmarshalApplication(rep.Ticket.Bytes, tagTicket, &tkt); err != nil {
+		t.Fatal(err)
+	}
+	if tkt.SName.String() != "krbtgt/TEST.GOKRB5" || tkt.EncPart.KVNO != 1 {
+		t.Errorf("ticket for %s, kvno %d", tkt.SName, tkt.EncPart.KVNO)
+	}
+
+	entry, ok := chooseEType(eTypeInfo(rep.PAData))
+	if !ok || entry.EType != ETypeAES256 || entry.Salt != "TEST.GOKRB5testuser1" {
+		t.Fatalf("etype-info2 = %+v", entry)
+	}
+	k, err := stringToKey(entry.EType, "passwordvalue", entry.Salt, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	sessionKey, err := decryptRepPart(k, usageASRepEncPart, rep.EncPart)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if sessionKey.etype != ETypeAES256 || len(sessionKey.value) != 32 {
+		t.Errorf("session key %s of %d bytes", ETypeName(sessionKey.etype), len(sessionKey.value))
+	}
+
+	wrong, _ := stringToKey(entry.EType, "wrongpassword", entry.Salt, 0)
+	if _, err := decryptRepPart(wrong, usageASRepEncPart, rep.EncPart); err == nil {
+		t.Error("decrypted with the wrong password")
+	}
+	rc4, _ := stringToKey(ETypeRC4, "passwordvalue", "", 0)
+	if _, err := decryptRepPart(rc4, usageASRepEncPart, rep.EncPart); err == nil {
+		t.Error("decrypted an AES part with an RC4 key")
+	}
+}
+
+func TestKDCRequestMarshal(t *testing.T) {
+	req := &kdcRequest{
+		msgType: msgASReq,
+		paData:  []paData{{Type: paPACRequest, Value: []byte{0x30, 0x00}}},
+		cname:   &principalName{NameType: NameTypePrincipal, NameString: []string{"jdoe"}},
+		realm:   "CORP.LOCAL",
+		sname:   principalName{NameType: NameTypeSrvInst, NameString: []string{"krbtgt", "CORP.LOCAL"}},
+		nonce:   12345,
+		etypes:  []int32{ETypeAES256, ETypeRC4},
+	}
+	data, err := req.marshal()
+	if err != nil {
+		t.Fatal(err)
+	}
+	var got testKDCReq
+	if err := unmarshalApplication(data, msgASReq, &got); err != nil {
+		t.Fatal(err)
+	}
+	b := got.Body
+	if got.PVNO != 5 || got.MsgType != msgASReq || len(got.PAData) != 1 || got.PAData[0].Type != paPACRequest {
+		t.Errorf("request header = %+v", got)
+	}
+	if b.CName.String() != "jdoe" || b.Realm != "CORP.LOCAL" || b.SName.String() != "krbtgt/CORP.LOCAL" ||
+		b.Nonce != 12345 || len(b.ETypes) != 2 || b.ETypes[0] != ETypeAES256 || !b.Till.Equal(farFuture) {
+		t.Errorf("request body = %+v", b)
+	}
+	if !bytes.Equal(b.Options.Bytes, kdcOptions) {
+		t.Errorf("kdc-options = % x, want % x", b.Options.Bytes, kdcOptions)
+	}
+}
+
+// testKDCReq decodes a KDC-REQ, as a KDC does
+type testKDCReq struct {
+	PVNO    int            `asn1:"explicit,tag:1"`
+	MsgType int            `asn1:"explicit,tag:2"`
+	PAData  []paData       `asn1:"optional,explicit,tag:3"`
+	Body    testKDCReqBody `asn1:"explicit,tag:4"`
+}
+
+// testKDCReqBody decodes the KDC-REQ-BODY fields requests send
+type testKDCReqBody struct {
+	Options asn1.BitString `asn1:"explicit,tag:0"`
+	CName   principalName  `asn1:"optional,explicit,tag:1"`
+	Realm   string         `asn1:"explicit,tag:2"`
+	SName   principalName  `asn1:"optional,explicit,tag:3"`
+	Till    time.Time      `asn1:"generalized,explicit,tag:5"`
+	Nonce   int64          `asn1:"explicit,tag:7"`
+	ETypes  []int32        `asn1:"explicit,tag:8"`
+}
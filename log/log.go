@@ -1,6 +1,7 @@
 package log
 
 import (
+	"adgo/tracing"
 	"context"
 	"fmt"
 	"os"
@@ -10,11 +11,20 @@ import (
 	"go.uber.org/zap/zapcore"
 )
 
+// Log formats
+const (
+	FormatConsole = "console" // Colored text on stderr (default)
+	FormatJSON    = "json"    // One JSON object per line
+)
+
 var (
 	sugar  *zap.SugaredLogger
 	level  zap.AtomicLevel
 	once   sync.Once
 	inited bool
+
+	format  = FormatConsole
+	logFile *rotatingFile // Set by SetFile
 )
 
 func init() {
@@ -30,28 +40,108 @@ func initLogger() {
 		return
 	}
 
-	consoleEncoder := zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
+	core := zapcore.NewCore(
+		newEncoder(true),
+		zapcore.Lock(os.Stderr),
+		level,
+	)
+	if logFile != nil {
+		core = zapcore.NewTee(core, zapcore.NewCore(newEncoder(false), logFile, level))
+	}
+
+	sugar = zap.New(newRedactCore(core)).Sugar()
+}
+
+// newEncoder returns the encoder of the current format. Console output is
+// colored only on stderr.
+func newEncoder(color bool) zapcore.Encoder {
+	if format == FormatJSON {
+		return zapcore.NewJSONEncoder(zapcore.EncoderConfig{
+			LevelKey:       "level",
+			NameKey:        "logger",
+			MessageKey:     "msg",
+			TimeKey:        "time",
+			LineEnding:     zapcore.DefaultLineEnding,
+			EncodeLevel:    zapcore.LowercaseLevelEncoder,
+			EncodeTime:     zapcore.RFC3339NanoTimeEncoder,
+			EncodeDuration: zapcore.StringDurationEncoder,
+		})
+	}
+
+	encodeLevel := zapcore.CapitalLevelEncoder
+	if color {
+		encodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	return zapcore.NewConsoleEncoder(zapcore.EncoderConfig{
 		LevelKey:       "level",
 		NameKey:        "logger",
 		MessageKey:     "msg",
 		TimeKey:        "time",
 		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.CapitalColorLevelEncoder,
+		EncodeLevel:    encodeLevel,
 		EncodeTime:     zapcore.TimeEncoderOfLayout("2006-01-02 15:04:05"),
 		EncodeDuration: zapcore.SecondsDurationEncoder,
 	})
+}
 
-	core := zapcore.NewCore(
-		consoleEncoder,
-		zapcore.Lock(os.Stderr),
-		level,
-	)
+// ValidateFormat checks that f is a log format
+func ValidateFormat(f string) error {
+	switch f {
+	case FormatConsole, FormatJSON:
+		return nil
+	default:
+		return fmt.Errorf("invalid log format: %s (use %s or %s)", f, FormatConsole, FormatJSON)
+	}
+}
+
+// SetFormat sets the log format (console or json) of stderr and the log file
+func SetFormat(f string) error {
+	if err := ValidateFormat(f); err != nil {
+		return err
+	}
+	format = f
+	sugar = nil // Rebuilt on next use
+	return nil
+}
+
+// SetFile also writes logs to path, in addition to stderr. The file is
+// rotated once it would exceed maxSizeMB megabytes (0 never rotates),
+// keeping maxBackups older files as path.1, path.2, ...
+func SetFile(path string, maxSizeMB, maxBackups int) error {
+	f, err := openRotatingFile(path, maxSizeMB, maxBackups)
+	if err != nil {
+		return err
+	}
+	if logFile != nil {
+		logFile.Close()
+	}
+	logFile = f
+	sugar = nil // Rebuilt on next use
+	return nil
+}
 
-	sugar = zap.New(core).Sugar()
+// Close flushes and closes the log file, if any. Later logs go to stderr only.
+func Close() error {
+	if logFile == nil {
+		return nil
+	}
+	Sync()
+	err := logFile.Close()
+	logFile = nil
+	sugar = nil
+	return err
+}
+
+// ValidateLevel checks that l is a log level
+func ValidateLevel(l string) error {
+	switch l {
+	case "debug", "info", "warn", "error", "fatal", "panic":
+		return nil
+	}
+	return fmt.Errorf("invalid log level: %s", l)
 }
 
 // SetLevel sets the minimum log level (debug, info, warn, error, fatal, panic)
-// SetLevel sets minimum log level (debug, info, warn, error, fatal, panic)
 // Returns an error if the level is invalid
 func SetLevel(l string) error {
 	switch l {
@@ -76,32 +166,32 @@ func SetLevel(l string) error {
 func Info(args ...any)                       { initLogger(); sugar.Info(args...) }
 func Infoln(args ...any)                     { initLogger(); sugar.Infoln(args...) }
 func Infof(format string, args ...any)       { initLogger(); sugar.Infof(format, args...) }
-func Infow(msg string, keysAndValues ...any) { sugar.Infow(msg, keysAndValues...) }
+func Infow(msg string, keysAndValues ...any) { initLogger(); sugar.Infow(msg, keysAndValues...) }
 
 func Debug(args ...any)                       { initLogger(); sugar.Debug(args...) }
 func Debugln(args ...any)                     { initLogger(); sugar.Debugln(args...) }
 func Debugf(format string, args ...any)       { initLogger(); sugar.Debugf(format, args...) }
-func Debugw(msg string, keysAndValues ...any) { sugar.Debugw(msg, keysAndValues...) }
+func Debugw(msg string, keysAndValues ...any) { initLogger(); sugar.Debugw(msg, keysAndValues...) }
 
 func Warn(args ...any)                       { initLogger(); sugar.Warn(args...) }
 func Warnln(args ...any)                     { initLogger(); sugar.Warnln(args...) }
 func Warnf(format string, args ...any)       { initLogger(); sugar.Warnf(format, args...) }
-func Warnw(msg string, keysAndValues ...any) { sugar.Warnw(msg, keysAndValues...) }
+func Warnw(msg string, keysAndValues ...any) { initLogger(); sugar.Warnw(msg, keysAndValues...) }
 
 func Error(args ...any)                       { initLogger(); sugar.Error(args...) }
 func Errorln(args ...any)                     { initLogger(); sugar.Errorln(args...) }
 func Errorf(format string, args ...any)       { initLogger(); sugar.Errorf(format, args...) }
-func Errorw(msg string, keysAndValues ...any) { sugar.Errorw(msg, keysAndValues...) }
+func Errorw(msg string, keysAndValues ...any) { initLogger(); sugar.Errorw(msg, keysAndValues...) }
 
 func Fatal(args ...any)                       { initLogger(); sugar.Fatal(args...) }
 func Fatalln(args ...any)                     { initLogger(); sugar.Fatalln(args...) }
 func Fatalf(format string, args ...any)       { initLogger(); sugar.Fatalf(format, args...) }
-func Fatalw(msg string, keysAndValues ...any) { sugar.Fatalw(msg, keysAndValues...) }
+func Fatalw(msg string, keysAndValues ...any) { initLogger(); sugar.Fatalw(msg, keysAndValues...) }
 
 func Panic(args ...any)                       { initLogger(); sugar.Panic(args...) }
 func Panicln(args ...any)                     { initLogger(); sugar.Panicln(args...) }
 func Panicf(format string, args ...any)       { initLogger(); sugar.Panicf(format, args...) }
-func Panicw(msg string, keysAndValues ...any) { sugar.Panicw(msg, keysAndValues...) }
+func Panicw(msg string, keysAndValues ...any) { initLogger(); sugar.Panicw(msg, keysAndValues...) }
 
 // Sync flushes the log buffer and returns any error
 func Sync() error {
@@ -120,6 +210,7 @@ type ContextLogger struct {
 // WithContext creates a new logger with context for request tracing
 // The context can contain trace_id, span_id, or other debugging information
 func WithContext(ctx context.Context) *ContextLogger {
+	initLogger()
 	return &ContextLogger{
 		SugaredLogger: sugar,
 		ctx:           ctx,
@@ -134,17 +225,26 @@ func (l *ContextLogger) With(args ...any) *ContextLogger {
 	}
 }
 
+// traceID returns the trace ID of the span in the context, or the
+// "trace_id" value set by the caller
+func (l *ContextLogger) traceID() any {
+	if id := tracing.TraceID(l.ctx); id != "" {
+		return id
+	}
+	return l.ctx.Value("trace_id")
+}
+
 // ErrorWithOp logs an error with operation context
 func (l *ContextLogger) ErrorWithOp(op string, err error) {
 	initLogger()
 	l.SugaredLogger.Errorw("operation failed",
 		"operation", op,
 		"error", err,
-		"trace_id", l.ctx.Value("trace_id"),
+		"trace_id", l.traceID(),
 	)
 }
 
 // InfoWithKey logs info with a specific key
 func (l *ContextLogger) InfoWithKey(key string, value any) {
-	l.SugaredLogger.Infow("info", key, value, "trace_id", l.ctx.Value("trace_id"))
+	l.SugaredLogger.Infow("info", key, value, "trace_id", l.traceID())
 }
@@ -0,0 +1,80 @@
+package log
+
+import (
+	"adgo/redact"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// redactCore masks secrets in messages and fields before they reach the
+// wrapped core: values of secret keys, registered secrets such as the bind
+// password and values after a password keyword or LAPS attribute name
+type redactCore struct {
+	zapcore.Core
+}
+
+// newRedactCore wraps core so nothing it writes contains a known secret
+func newRedactCore(core zapcore.Core) zapcore.Core {
+	return &redactCore{Core: core}
+}
+
+func (c *redactCore) With(fields []zapcore.Field) zapcore.Core {
+	return &redactCore{Core: c.Core.With(redactFields(fields))}
+}
+
+func (c *redactCore) Check(e zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(e.Level) {
+		return ce.AddCore(e, c)
+	}
+	return ce
+}
+
+func (c *redactCore) Write(e zapcore.Entry, fields []zapcore.Field) error {
+	e.Message = redact.Text(e.Message)
+	return c.Core.Write(e, redactFields(fields))
+}
+
+// redactFields returns fields with secret values masked
+func redactFields(fields []zapcore.Field) []zapcore.Field {
+	out := make([]zapcore.Field, len(fields))
+	for i, f := range fields {
+		out[i] = redactField(f)
+	}
+	return out
+}
+
+// redactField masks one field. Text-like values are passed through
+// redact.Text; numbers, booleans and durations are left alone.
+func redactField(f zapcore.Field) zapcore.Field {
+	if redact.IsSecretKey(f.Key) {
+		return zap.String(f.Key, redact.Mask)
+	}
+	switch f.Type {
+	case zapcore.StringType:
+		return zap.String(f.Key, redact.Text(f.String))
+	case zapcore.ErrorType:
+		if err, ok := f.Interface.(error); ok {
+			return zap.String(f.Key, redact.Text(err.Error()))
+		}
+	case zapcore.StringerType, zapcore.ReflectType, zapcore.ByteStringType, zapcore.BinaryType:
+		return zap.String(f.Key, redact.Text(fieldText(f)))
+	}
+	return f
+}
+
+// fieldText returns the text of a stringer, reflected or byte field
+func fieldText(f zapcore.Field) string {
+	switch f.Type {
+	case zapcore.StringerType:
+		if s, ok := f.Interface.(fmt.Stringer); ok {
+			return s.String()
+		}
+	case zapcore.ByteStringType, zapcore.BinaryType:
+		if b, ok := f.Interface.([]byte); ok {
+			return string(b)
+		}
+	}
+	return fmt.Sprint(f.Interface)
+}
@@ -0,0 +1,95 @@
+package log
+
+import (
+	"fmt"
+	"os"
+	"sync"
+)
+
+// rotatingFile is a log file that is renamed to path.1 once it would grow
+// beyond maxSize bytes. Older files move up to path.maxBackups and the
+// oldest is removed.
+type rotatingFile struct {
+	path       string
+	maxSize    int64 // 0 disables rotation
+	maxBackups int
+
+	mu   sync.Mutex
+	file *os.File
+	size int64
+}
+
+// openRotatingFile opens path for appending
+func openRotatingFile(path string, maxSizeMB, maxBackups int) (*rotatingFile, error) {
+	r := &rotatingFile{path: path, maxSize: int64(maxSizeMB) << 20, maxBackups: maxBackups}
+	if err := r.open(); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// open opens the current file; mu must be held or r not yet shared
+func (r *rotatingFile) open() error {
+	f, err := os.OpenFile(r.path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	info, err := f.Stat()
+	if err != nil {
+		f.Close()
+		return fmt.Errorf("opening log file: %w", err)
+	}
+	r.file, r.size = f, info.Size()
+	return nil
+}
+
+// rotate moves the current file to path.1, shifting older backups; mu must be held
+func (r *rotatingFile) rotate() error {
+	if err := r.file.Close(); err != nil {
+		return err
+	}
+	if r.maxBackups <= 0 {
+		if err := os.Remove(r.path); err != nil && !os.IsNotExist(err) {
+			return err
+		}
+		return r.open()
+	}
+
+	os.Remove(fmt.Sprintf("%s.%d", r.path, r.maxBackups))
+	for i := r.maxBackups - 1; i >= 1; i-- {
+		os.Rename(fmt.Sprintf("%s.%d", r.path, i), fmt.Sprintf("%s.%d", r.path, i+1))
+	}
+	if err := os.Rename(r.path, r.path+".1"); err != nil {
+		return err
+	}
+	return r.open()
+}
+
+// Write appends p, rotating first if p would exceed the size limit
+func (r *rotatingFile) Write(p []byte) (int, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if r.maxSize > 0 && r.size > 0 && r.size+int64(len(p)) > r.maxSize {
+		if err := r.rotate(); err != nil {
+			return 0, fmt.Errorf("rotating log file: %w", err)
+		}
+	}
+	n, err := r.file.Write(p)
+	r.size += int64(n)
+	return n, err
+}
+
+// Sync flushes the current file to disk
+func (r *rotatingFile) Sync() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Sync()
+}
+
+// Close closes the current file
+func (r *rotatingFile) Close() error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.file.Close()
+}
@@ -2,13 +2,11 @@ package main
 
 import (
 	"adgo/cmd"
-	"fmt"
 	"os"
 )
 
 func main() {
 	if err := cmd.Execute(); err != nil {
-		fmt.Fprintf(os.Stderr, "Error: %v\n", err)
-		os.Exit(1)
+		os.Exit(cmd.ExitCode(err))
 	}
 }
@@ -0,0 +1,104 @@
+package metrics
+
+import (
+	"adgo/connect"
+	"context"
+	"sync"
+	"time"
+)
+
+// durationBuckets are the upper bounds of the search duration histogram, in seconds
+var durationBuckets = []float64{0.05, 0.1, 0.25, 0.5, 1, 2.5, 5, 10, 30, 60, 300}
+
+// LDAP holds the metrics of the LDAP activity of adgo
+type LDAP struct {
+	Registry *Registry
+
+	searches       *Counter
+	searchErrors   *Counter
+	entries        *Counter
+	pages          *Counter
+	retries        *Counter
+	searchDuration *Histogram
+
+	mu    sync.Mutex
+	pools []*connect.ConnPool
+}
+
+// NewLDAP registers the LDAP metrics in a new registry
+func NewLDAP() *LDAP {
+	r := NewRegistry()
+	m := &LDAP{
+		Registry:       r,
+		searches:       r.Counter("adgo_searches_total", "Searches executed."),
+		searchErrors:   r.Counter("adgo_search_errors_total", "Searches that failed."),
+		entries:        r.Counter("adgo_entries_total", "Entries returned by searches."),
+		pages:          r.Counter("adgo_pages_total", "Result pages fetched from the server."),
+		retries:        r.Counter("adgo_retries_total", "Connection attempts retried."),
+		searchDuration: r.Histogram("adgo_search_duration_seconds", "Duration of searches.", durationBuckets),
+	}
+	r.GaugeFunc("adgo_pool_connections", "Open connections of the connection pools.", func() float64 {
+		return float64(m.poolSum((*connect.ConnPool).Count))
+	})
+	r.GaugeFunc("adgo_pool_idle_connections", "Idle connections waiting in the connection pools.", func() float64 {
+		return float64(m.poolSum((*connect.ConnPool).Size))
+	})
+	return m
+}
+
+// TrackPool adds the connections of pool to the pool gauges
+func (m *LDAP) TrackPool(pool *connect.ConnPool) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.pools = append(m.pools, pool)
+}
+
+// poolSum adds up fn over the tracked pools
+func (m *LDAP) poolSum(fn func(*connect.ConnPool) int) int {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	total := 0
+	for _, p := range m.pools {
+		total += fn(p)
+	}
+	return total
+}
+
+// Retry counts a retried connection attempt. It has the signature of a
+// connect.RetryHook.
+func (m *LDAP) Retry(attempt int, err error) {
+	m.retries.Inc()
+}
+
+// Middleware returns a connect.Middleware counting the searches of a client
+func (m *LDAP) Middleware() connect.Middleware {
+	return &middleware{m: m}
+}
+
+// middleware feeds the LDAP metrics from the searches of a client
+type middleware struct {
+	connect.BaseMiddleware
+	m *LDAP
+}
+
+// startKey carries the start time of a search
+type startKey struct{}
+
+func (mw *middleware) OnSearchStart(ctx context.Context, filter string, attributes []string) (context.Context, error) {
+	mw.m.searches.Inc()
+	return context.WithValue(ctx, startKey{}, time.Now()), nil
+}
+
+func (mw *middleware) OnPage(ctx context.Context, entries int) {
+	mw.m.pages.Inc()
+}
+
+func (mw *middleware) OnSearchEnd(ctx context.Context, filter string, entries int, err error) {
+	mw.m.entries.Add(uint64(entries))
+	if err != nil {
+		mw.m.searchErrors.Inc()
+	}
+	if start, ok := ctx.Value(startKey{}).(time.Time); ok {
+		mw.m.searchDuration.Observe(time.Since(start).Seconds())
+	}
+}
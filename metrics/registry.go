@@ -0,0 +1,187 @@
+// Package metrics keeps counters, gauges and histograms and exposes them
+// in the Prometheus text format, without depending on a Prometheus client.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"sync"
+	"sync/atomic"
+)
+
+// metric is anything a Registry can expose
+type metric interface {
+	name() string
+	write(w *bufio.Writer)
+}
+
+// Registry holds metrics and writes them in the Prometheus text format.
+// It is safe for concurrent use.
+type Registry struct {
+	mu      sync.Mutex
+	metrics []metric
+}
+
+// NewRegistry returns an empty registry
+func NewRegistry() *Registry {
+	return &Registry{}
+}
+
+// add registers m, keeping the metrics sorted by name
+func (r *Registry) add(m metric) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.metrics = append(r.metrics, m)
+	sort.SliceStable(r.metrics, func(i, j int) bool { return r.metrics[i].name() < r.metrics[j].name() })
+}
+
+// Counter registers and returns a counter
+func (r *Registry) Counter(name, help string) *Counter {
+	c := &Counter{desc: desc{n: name, help: help}}
+	r.add(c)
+	return c
+}
+
+// GaugeFunc registers a gauge whose value is read from fn when scraped
+func (r *Registry) GaugeFunc(name, help string, fn func() float64) {
+	r.add(&gaugeFunc{desc: desc{n: name, help: help}, fn: fn})
+}
+
+// Histogram registers and returns a histogram with the given upper bounds
+func (r *Registry) Histogram(name, help string, buckets []float64) *Histogram {
+	sorted := append([]float64(nil), buckets...)
+	sort.Float64s(sorted)
+	h := &Histogram{desc: desc{n: name, help: help}, buckets: sorted, counts: make([]uint64, len(sorted))}
+	r.add(h)
+	return h
+}
+
+// WriteTo writes every metric in the Prometheus text format
+func (r *Registry) WriteTo(w io.Writer) (int64, error) {
+	r.mu.Lock()
+	metrics := append([]metric(nil), r.metrics...)
+	r.mu.Unlock()
+
+	cw := &countingWriter{w: w}
+	bw := bufio.NewWriter(cw)
+	for _, m := range metrics {
+		m.write(bw)
+	}
+	err := bw.Flush()
+	return cw.n, err
+}
+
+// ServeHTTP answers a scrape
+func (r *Registry) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_, _ = r.WriteTo(w)
+}
+
+// desc is the name and help text of a metric
+type desc struct {
+	n    string
+	help string
+}
+
+func (d desc) name() string { return d.n }
+
+// header writes the HELP and TYPE lines
+func (d desc) header(w *bufio.Writer, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n# TYPE %s %s\n", d.n, d.help, d.n, typ)
+}
+
+// Counter is a value that only goes up
+type Counter struct {
+	desc
+	v atomic.Uint64
+}
+
+// Inc adds one
+func (c *Counter) Inc() { c.v.Add(1) }
+
+// Add adds n
+func (c *Counter) Add(n uint64) { c.v.Add(n) }
+
+// Value returns the current count
+func (c *Counter) Value() uint64 { return c.v.Load() }
+
+func (c *Counter) write(w *bufio.Writer) {
+	c.header(w, "counter")
+	fmt.Fprintf(w, "%s %d\n", c.n, c.v.Load())
+}
+
+// gaugeFunc is a gauge read on every scrape
+type gaugeFunc struct {
+	desc
+	fn func() float64
+}
+
+func (g *gaugeFunc) write(w *bufio.Writer) {
+	g.header(w, "gauge")
+	fmt.Fprintf(w, "%s %s\n", g.n, formatFloat(g.fn()))
+}
+
+// Histogram counts observations in cumulative buckets
+type Histogram struct {
+	desc
+	buckets []float64
+
+	mu     sync.Mutex
+	counts []uint64 // Observations per bucket, not cumulative
+	count  uint64
+	sum    float64
+}
+
+// Observe records one value
+func (h *Histogram) Observe(v float64) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		h.counts[i]++
+	}
+	h.count++
+	h.sum += v
+}
+
+func (h *Histogram) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	h.header(w, "histogram")
+	var cumulative uint64
+	for i, le := range h.buckets {
+		cumulative += h.counts[i]
+		fmt.Fprintf(w, "%s_bucket{le=\"%s\"} %d\n", h.n, formatFloat(le), cumulative)
+	}
+	fmt.Fprintf(w, "%s_bucket{le=\"+Inf\"} %d\n", h.n, h.count)
+	fmt.Fprintf(w, "%s_sum %s\n", h.n, formatFloat(h.sum))
+	fmt.Fprintf(w, "%s_count %d\n", h.n, h.count)
+}
+
+// formatFloat formats v as Prometheus expects
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+// countingWriter counts the bytes written for WriteTo
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
@@ -1,10 +1,11 @@
 package output
 
 import (
+	"adgo/analyze"
 	"encoding/json"
 	"fmt"
-	"os"
 	"slices"
+	"strings"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
@@ -72,10 +73,10 @@ type bloodHoundComputerProps struct {
 
 // bloodHoundGroup represents a BloodHound group object
 type bloodHoundGroup struct {
-	Properties bloodHoundGroupProps `json:"Properties"`
-	ObjectID   string               `json:"ObjectIdentifier"`
-	ACLs       []bloodHoundACL      `json:"Aces,omitempty"`
-	Members    []string             `json:"Members,omitempty"`
+	Properties bloodHoundGroupProps       `json:"Properties"`
+	ObjectID   string                     `json:"ObjectIdentifier"`
+	ACLs       []bloodHoundACL            `json:"Aces,omitempty"`
+	Members    []bloodHoundTypedPrincipal `json:"Members,omitempty"`
 }
 
 // bloodHoundGroupProps represents group properties for BloodHound
@@ -88,6 +89,12 @@ type bloodHoundGroupProps struct {
 	WhenCreated string `json:"whencreated,omitempty"`
 }
 
+// bloodHoundTypedPrincipal references another object by identifier and kind
+type bloodHoundTypedPrincipal struct {
+	ObjectIdentifier string `json:"ObjectIdentifier"`
+	ObjectType       string `json:"ObjectType"`
+}
+
 // bloodHoundACL represents an Access Control Entry in BloodHound format
 type bloodHoundACL struct {
 	PrincipalName string `json:"PrincipalName"`
@@ -124,8 +131,11 @@ func (p *bloodHoundPrinter) Print(entries []*ldap.Entry) error {
 	// Auto-detect object type from entries
 	objectType := p.autoDetectObjectType(entries)
 
+	// Index collected principals so members and ACE trustees resolve to SIDs
+	idx := newBloodHoundIndex(entries)
+
 	for _, entry := range entries {
-		bhObj := p.convertToBloodHound(entry, objectType)
+		bhObj := p.convertToBloodHound(entry, objectType, idx)
 		if bhObj != nil {
 			bhData = append(bhData, bhObj)
 		}
@@ -133,12 +143,7 @@ func (p *bloodHoundPrinter) Print(entries []*ldap.Entry) error {
 
 	// Create complete output structure
 	output := bloodHoundOutput{
-		Meta: bloodHoundMetadata{
-			Type:           objectType,
-			Version:        bloodHoundVersion,
-			Count:          len(bhData),
-			CollectionTime: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
-		},
+		Meta: newBloodHoundMetadata(objectType, len(bhData)),
 		Data: bhData,
 	}
 
@@ -149,12 +154,14 @@ func (p *bloodHoundPrinter) Print(entries []*ldap.Entry) error {
 	}
 
 	// Write output
-	if p.cfg.Path != "" {
-		return os.WriteFile(p.cfg.Path, data, 0644)
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
 	}
+	defer closeFn()
 
-	fmt.Println(string(data))
-	return nil
+	_, err = fmt.Fprintln(w, string(data))
+	return err
 }
 
 // autoDetectObjectType detects the primary object type from entries
@@ -162,35 +169,45 @@ func (p *bloodHoundPrinter) autoDetectObjectType(entries []*ldap.Entry) string {
 	typeCount := map[string]int{"users": 0, "computers": 0, "groups": 0}
 
 	for _, entry := range entries {
-		// Prioritize using objectClass
-		objectClasses := getAttributeValues(entry, "objectClass")
-		if len(objectClasses) > 0 {
-			// objectClass has multiple values, check by priority
-			// Priority: computer > user > group
-			// Most specific class should be last: "top", "person", "organizationalPerson", "user", "computer"
-			if slices.Contains(objectClasses, "computer") {
-				typeCount["computers"]++
-			} else if slices.Contains(objectClasses, "user") {
-				typeCount["users"]++
-			} else if slices.Contains(objectClasses, "group") {
-				typeCount["groups"]++
-			}
-		} else {
-			// Fallback: detect type from DN when objectClass is missing
-			detectedType := detectTypeFromDN(entry.DN)
-			switch detectedType {
-			case "DC":
-				typeCount["computers"]++
-			case "USER":
-				typeCount["users"]++
-			case "COMPUTER":
-				typeCount["computers"]++
-			case "GROUP":
-				typeCount["groups"]++
-			}
+		countObjectType(typeCount, entry)
+	}
+
+	return mostCommonObjectType(typeCount)
+}
+
+// countObjectType adds an entry to the per-type counts used by autoDetectObjectType
+func countObjectType(typeCount map[string]int, entry *ldap.Entry) {
+	// Prioritize using objectClass
+	objectClasses := getAttributeValues(entry, "objectClass")
+	if len(objectClasses) > 0 {
+		// objectClass has multiple values, check by priority
+		// Priority: computer > user > group
+		// Most specific class should be last: "top", "person", "organizationalPerson", "user", "computer"
+		if slices.Contains(objectClasses, "computer") {
+			typeCount["computers"]++
+		} else if slices.Contains(objectClasses, "user") {
+			typeCount["users"]++
+		} else if slices.Contains(objectClasses, "group") {
+			typeCount["groups"]++
+		}
+	} else {
+		// Fallback: detect type from DN when objectClass is missing
+		detectedType := detectTypeFromDN(entry.DN)
+		switch detectedType {
+		case "DC":
+			typeCount["computers"]++
+		case "USER":
+			typeCount["users"]++
+		case "COMPUTER":
+			typeCount["computers"]++
+		case "GROUP":
+			typeCount["groups"]++
 		}
 	}
+}
 
+// mostCommonObjectType returns the type with the highest count, defaulting to users
+func mostCommonObjectType(typeCount map[string]int) string {
 	// Return the type with highest count
 	maxCount := 0
 	detectedType := "users"
@@ -243,39 +260,83 @@ func detectTypeFromDN(dn string) string {
 	}
 }
 
-// StreamPrint streams entries in BloodHound JSON format
+// newBloodHoundMetadata returns the meta section for count objects of objectType
+func newBloodHoundMetadata(objectType string, count int) bloodHoundMetadata {
+	return bloodHoundMetadata{
+		Type:           objectType,
+		Version:        bloodHoundVersion,
+		Count:          count,
+		CollectionTime: time.Now().UTC().Format("2006-01-02T15:04:05Z"),
+	}
+}
+
+// StreamPrint streams entries in BloodHound JSON format.
+// Members and ACE trustees resolve against the whole result set, so entries
+// are spooled to a temporary file while the SID index is built, then
+// converted and written one at a time with the meta section last.
 func (p *bloodHoundPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
-	// Collect all entries first (BloodHound JSON needs metadata)
-	var entries []*ldap.Entry
+	spool, err := newEntrySpool()
+	if err != nil {
+		return err
+	}
+	defer spool.close()
+
+	idx := newBloodHoundIndex(nil)
+	typeCount := map[string]int{"users": 0, "computers": 0, "groups": 0}
 	for entry := range entriesChan {
-		entries = append(entries, entry)
+		if entry == nil {
+			continue
+		}
+		idx.add(entry)
+		countObjectType(typeCount, entry)
+		if err := spool.add(entry); err != nil {
+			return err
+		}
+	}
+	objectType := mostCommonObjectType(typeCount)
+
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	data := newJSONArrayWriter(w, "{\n  \"data\": [\n")
+	err = spool.each(func(entry *ldap.Entry) error {
+		if bhObj := p.convertToBloodHound(entry, objectType, idx); bhObj != nil {
+			return data.add(bhObj)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
 	}
 
-	return p.Print(entries)
+	return data.close("\n  ],\n  \"meta\": ", newBloodHoundMetadata(objectType, data.count), "\n}\n")
 }
 
 // convertToBloodHound converts an LDAP entry to BloodHound format
-func (p *bloodHoundPrinter) convertToBloodHound(entry *ldap.Entry, objectType string) map[string]any {
+func (p *bloodHoundPrinter) convertToBloodHound(entry *ldap.Entry, objectType string, idx *bloodHoundIndex) map[string]any {
 	// objectClass is optional - use objectType parameter for conversion
 	// This allows processing entries even when objectClass attribute is missing
 	switch objectType {
 	case "users":
-		return p.convertUser(entry)
+		return p.convertUser(entry, idx)
 	case "computers":
-		return p.convertComputer(entry)
+		return p.convertComputer(entry, idx)
 	case "groups":
-		return p.convertGroup(entry)
+		return p.convertGroup(entry, idx)
 	default:
 		return p.convertGeneric(entry)
 	}
 }
 
 // convertUser converts LDAP entry to BloodHound user format
-func (p *bloodHoundPrinter) convertUser(entry *ldap.Entry) map[string]any {
+func (p *bloodHoundPrinter) convertUser(entry *ldap.Entry, idx *bloodHoundIndex) map[string]any {
 	domain := extractDomain(entry.DN)
 
 	user := bloodHoundUser{
-		ObjectID: entry.DN,
+		ObjectID: bloodHoundObjectID(entry),
 		Properties: bloodHoundUserProps{
 			Name:                  getAttributeValue(entry, "sAMAccountName"),
 			Domain:                domain,
@@ -285,62 +346,72 @@ func (p *bloodHoundPrinter) convertUser(entry *ldap.Entry) map[string]any {
 			AdminCount:            getIntAttribute(entry, "adminCount"),
 			DontReqPreAuth:        getBoolAttribute(entry, "userAccountControl", "dontReqPreauth"),
 			Delegatable:           getBoolAttribute(entry, "userAccountControl", "trustedToAuthForDelegation"),
-			SID:                   getAttributeValue(entry, "objectSID"),
+			SID:                   entrySID(entry),
 			WhenCreated:           getAttributeValue(entry, "whenCreated"),
 		},
+		ACLs: bloodHoundACLs(entry, "User", idx.types),
 	}
 
 	// Convert to map
 	return map[string]any{
 		"Properties":       user.Properties,
 		"ObjectIdentifier": user.ObjectID,
+		"PrimaryGroupSID":  primaryGroupSID(entry, domainSIDFromSID(user.Properties.SID)),
+		"Aces":             user.ACLs,
 	}
 }
 
 // convertComputer converts LDAP entry to BloodHound computer format
-func (p *bloodHoundPrinter) convertComputer(entry *ldap.Entry) map[string]any {
+func (p *bloodHoundPrinter) convertComputer(entry *ldap.Entry, idx *bloodHoundIndex) map[string]any {
 	domain := extractDomain(entry.DN)
 
 	computer := bloodHoundComputer{
-		ObjectID: entry.DN,
+		ObjectID: bloodHoundObjectID(entry),
 		Properties: bloodHoundComputerProps{
 			Name:            getAttributeValue(entry, "sAMAccountName"),
 			Domain:          domain,
 			Enabled:         isEnabled(entry),
 			OperatingSystem: getAttributeValue(entry, "operatingSystem"),
 			OSVersion:       getAttributeValue(entry, "operatingSystemVersion"),
-			SID:             getAttributeValue(entry, "objectSID"),
+			SID:             entrySID(entry),
 			WhenCreated:     getAttributeValue(entry, "whenCreated"),
 		},
+		ACLs: bloodHoundACLs(entry, "Computer", idx.types),
 	}
 
 	return map[string]any{
 		"Properties":       computer.Properties,
 		"ObjectIdentifier": computer.ObjectID,
+		"PrimaryGroupSID":  primaryGroupSID(entry, domainSIDFromSID(computer.Properties.SID)),
+		"Aces":             computer.ACLs,
 	}
 }
 
 // convertGroup converts LDAP entry to BloodHound group format
-func (p *bloodHoundPrinter) convertGroup(entry *ldap.Entry) map[string]any {
+func (p *bloodHoundPrinter) convertGroup(entry *ldap.Entry, idx *bloodHoundIndex) map[string]any {
 	domain := extractDomain(entry.DN)
+	sid := entrySID(entry)
+	members := idx.members(entry, sid)
 
 	group := bloodHoundGroup{
-		ObjectID: entry.DN,
+		ObjectID: bloodHoundObjectID(entry),
 		Properties: bloodHoundGroupProps{
 			Name:        getAttributeValue(entry, "sAMAccountName"),
 			Domain:      domain,
 			Enabled:     true, // Groups don't have disabled state
-			MemberCount: len(getAttributeValues(entry, "member")),
-			SID:         getAttributeValue(entry, "objectSID"),
+			MemberCount: len(members),
+			SID:         sid,
 			WhenCreated: getAttributeValue(entry, "whenCreated"),
 		},
-		Members: getAttributeValues(entry, "member"),
+		Members: members,
+		ACLs:    bloodHoundACLs(entry, "Group", idx.types),
 	}
 
 	return map[string]any{
 		"Properties":       group.Properties,
 		"ObjectIdentifier": group.ObjectID,
 		"Members":          group.Members,
+		"Aces":             group.ACLs,
 	}
 }
 
@@ -369,6 +440,137 @@ func (p *bloodHoundPrinter) convertGeneric(entry *ldap.Entry) map[string]any {
 
 // Helper functions
 
+// bloodHoundIndex resolves references between the entries of one result set
+type bloodHoundIndex struct {
+	sidByDN        map[string]string   // lowercased DN -> SID
+	types          map[string]string   // SID -> ObjectType
+	primaryMembers map[string][]string // group SID -> SIDs of accounts with it as primary group
+}
+
+// newBloodHoundIndex indexes the SIDs, types and primary groups of entries
+func newBloodHoundIndex(entries []*ldap.Entry) *bloodHoundIndex {
+	idx := &bloodHoundIndex{
+		sidByDN:        make(map[string]string, len(entries)),
+		types:          make(map[string]string, len(entries)),
+		primaryMembers: make(map[string][]string),
+	}
+
+	for _, entry := range entries {
+		idx.add(entry)
+	}
+
+	return idx
+}
+
+// add indexes one entry
+func (idx *bloodHoundIndex) add(entry *ldap.Entry) {
+	sid := entrySID(entry)
+	if sid == "" {
+		return
+	}
+	idx.sidByDN[strings.ToLower(entry.DN)] = sid
+	idx.types[sid] = bloodHoundCEObjectType(bloodHoundCEKind(entry))
+
+	// Primary group membership is implicit: AD omits it from the group's member attribute
+	if group := primaryGroupSID(entry, domainSIDFromSID(sid)); group != "" {
+		idx.primaryMembers[group] = append(idx.primaryMembers[group], sid)
+	}
+}
+
+// members resolves a group's members to SID references. Extended DN values
+// carry the SID directly, plain DNs are looked up among the indexed entries,
+// and accounts using the group as their primary group are added. Members
+// whose SID cannot be determined are skipped.
+func (idx *bloodHoundIndex) members(entry *ldap.Entry, groupSID string) []bloodHoundTypedPrincipal {
+	members := []bloodHoundTypedPrincipal{}
+	seen := make(map[string]bool)
+	add := func(sid string) {
+		if sid == "" || seen[sid] {
+			return
+		}
+		seen[sid] = true
+		members = append(members, bloodHoundTypedPrincipal{
+			ObjectIdentifier: sid,
+			ObjectType:       principalType(sid, idx.types),
+		})
+	}
+
+	for _, value := range entry.GetAttributeValues(analyze.AttrMember) {
+		dn, _, sid := analyze.ParseExtendedDN(value)
+		if sid == "" {
+			sid = idx.sidByDN[strings.ToLower(dn)]
+		}
+		add(sid)
+	}
+
+	if groupSID != "" {
+		for _, sid := range idx.primaryMembers[groupSID] {
+			add(sid)
+		}
+	}
+
+	return members
+}
+
+// bloodHoundObjectID returns the SID of an entry, falling back to its DN
+func bloodHoundObjectID(entry *ldap.Entry) string {
+	if sid := entrySID(entry); sid != "" {
+		return sid
+	}
+	return entry.DN
+}
+
+// entryACLEdges extracts the BloodHound ACL edges from an entry's nTSecurityDescriptor.
+// Returns false if the entry has no readable security descriptor.
+func entryACLEdges(entry *ldap.Entry, objectType string) (analyze.ACLEdges, bool) {
+	raw := entry.GetEqualFoldRawAttributeValues(analyze.AttrNTSecurityDescriptor)
+	if len(raw) == 0 {
+		return analyze.ACLEdges{}, false
+	}
+	edges, err := analyze.ExtractEdges(raw[0], objectType)
+	if err != nil {
+		return analyze.ACLEdges{}, false
+	}
+	return edges, true
+}
+
+// bloodHoundACLs maps the ACL edges of an entry to BloodHound ACEs
+func bloodHoundACLs(entry *ldap.Entry, objectType string, principals map[string]string) []bloodHoundACL {
+	acl, ok := entryACLEdges(entry, objectType)
+	if !ok {
+		return []bloodHoundACL{}
+	}
+
+	aces := make([]bloodHoundACL, 0, len(acl.Edges))
+	for _, edge := range acl.Edges {
+		aces = append(aces, bloodHoundACL{
+			PrincipalName: edge.PrincipalSID,
+			PrincipalType: principalType(edge.PrincipalSID, principals),
+			RightName:     edge.RightName,
+			IsInherited:   edge.IsInherited,
+		})
+	}
+	return aces
+}
+
+// principalType returns the BloodHound type of an ACE principal, looking it up
+// in the collected objects first. Builtin and well-known group SIDs that were
+// not collected are reported as groups, anything else as "Base".
+func principalType(sid string, known map[string]string) string {
+	if t, ok := known[sid]; ok {
+		return t
+	}
+	switch {
+	case strings.Contains(sid, "S-1-5-32-"),
+		strings.HasSuffix(sid, "S-1-1-0"),
+		strings.HasSuffix(sid, "S-1-5-11"),
+		strings.HasSuffix(sid, "S-1-5-9"):
+		return "Group"
+	default:
+		return "Base"
+	}
+}
+
 // getAttributeValue safely gets a single attribute value
 func getAttributeValue(entry *ldap.Entry, name string) string {
 	attr := entry.GetAttributeValues(name)
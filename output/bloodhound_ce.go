@@ -0,0 +1,1051 @@
+package output
+
+import (
+	"adgo/analyze"
+	"archive/zip"
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+const (
+	// BloodHound CE ingestion schema version (SharpHound CE v2 layout)
+	bloodHoundCEVersion = 6
+
+	// Collector name reported in the meta block
+	bloodHoundCECollector = "adgo"
+
+	// SharpHound collection method flags describing what this output contains
+	bloodHoundCEMethodGroup       = 1 << 0
+	bloodHoundCEMethodTrusts      = 1 << 5
+	bloodHoundCEMethodACL         = 1 << 6
+	bloodHoundCEMethodContainer   = 1 << 7
+	bloodHoundCEMethodObjectProps = 1 << 9
+
+	// Unix timestamp used by BloodHound CE for "never"
+	bloodHoundCENever int64 = -1
+)
+
+// BloodHound CE object kinds as used in meta.type and ObjectType fields
+const (
+	bhKindUsers     = "users"
+	bhKindComputers = "computers"
+	bhKindGroups    = "groups"
+	bhKindDomains   = "domains"
+	bhKindGPOs      = "gpos"
+	bhKindOUs       = "ous"
+	bhKindContainer = "containers"
+
+	// Trusts are not a file kind; they are attached to their domain object
+	bhKindTrusts = "trusts"
+)
+
+// bloodHoundCEFileKinds lists the file kinds in the order SharpHound writes them
+var bloodHoundCEFileKinds = []string{
+	bhKindUsers, bhKindComputers, bhKindGroups, bhKindDomains, bhKindGPOs, bhKindOUs, bhKindContainer,
+}
+
+// bloodHoundCEObjectTypes maps file kinds to the ObjectType name used in references
+var bloodHoundCEObjectTypes = map[string]string{
+	bhKindUsers:     "User",
+	bhKindComputers: "Computer",
+	bhKindGroups:    "Group",
+	bhKindDomains:   "Domain",
+	bhKindGPOs:      "GPO",
+	bhKindOUs:       "OU",
+	bhKindContainer: "Container",
+}
+
+// bloodHoundCEMeta represents the meta section of a BloodHound CE file
+type bloodHoundCEMeta struct {
+	Methods          int    `json:"methods"`
+	Type             string `json:"type"`
+	Count            int    `json:"count"`
+	Version          int    `json:"version"`
+	CollectorVersion string `json:"collectorversion"`
+}
+
+// bloodHoundCEOutput represents the complete BloodHound CE JSON structure
+type bloodHoundCEOutput struct {
+	Data []any            `json:"data"`
+	Meta bloodHoundCEMeta `json:"meta"`
+}
+
+// bloodHoundCEAce represents an ACL edge in BloodHound CE format
+type bloodHoundCEAce struct {
+	PrincipalSID  string `json:"PrincipalSID"`
+	PrincipalType string `json:"PrincipalType"`
+	RightName     string `json:"RightName"`
+	IsInherited   bool   `json:"IsInherited"`
+}
+
+// bloodHoundCEBase holds the fields shared by every BloodHound CE object
+type bloodHoundCEBase struct {
+	ObjectIdentifier string                    `json:"ObjectIdentifier"`
+	Aces             []bloodHoundCEAce         `json:"Aces"`
+	IsDeleted        bool                      `json:"IsDeleted"`
+	IsACLProtected   bool                      `json:"IsACLProtected"`
+	ContainedBy      *bloodHoundTypedPrincipal `json:"ContainedBy"`
+}
+
+// bloodHoundCEUser represents a BloodHound CE user object
+type bloodHoundCEUser struct {
+	bloodHoundCEBase
+	Properties        bloodHoundCEUserProps      `json:"Properties"`
+	PrimaryGroupSID   string                     `json:"PrimaryGroupSID,omitempty"`
+	AllowedToDelegate []bloodHoundTypedPrincipal `json:"AllowedToDelegate"`
+	HasSIDHistory     []bloodHoundTypedPrincipal `json:"HasSIDHistory"`
+	SPNTargets        []any                      `json:"SPNTargets"`
+}
+
+// bloodHoundCEUserProps represents user properties for BloodHound CE
+type bloodHoundCEUserProps struct {
+	Name                    string   `json:"name"`
+	Domain                  string   `json:"domain"`
+	DomainSID               string   `json:"domainsid"`
+	DistinguishedName       string   `json:"distinguishedname"`
+	SAMAccountName          string   `json:"samaccountname"`
+	Enabled                 bool     `json:"enabled"`
+	AdminCount              bool     `json:"admincount"`
+	Sensitive               bool     `json:"sensitive"`
+	DontReqPreAuth          bool     `json:"dontreqpreauth"`
+	PasswordNotReqd         bool     `json:"passwordnotreqd"`
+	UnconstrainedDelegation bool     `json:"unconstraineddelegation"`
+	PwdNeverExpires         bool     `json:"pwdneverexpires"`
+	TrustedToAuth           bool     `json:"trustedtoauth"`
+	HasSPN                  bool     `json:"hasspn"`
+	ServicePrincipalNames   []string `json:"serviceprincipalnames"`
+	AllowedToDelegate       []string `json:"allowedtodelegate,omitempty"`
+	LastLogon               int64    `json:"lastlogon"`
+	LastLogonTimestamp      int64    `json:"lastlogontimestamp"`
+	PwdLastSet              int64    `json:"pwdlastset"`
+	WhenCreated             int64    `json:"whencreated"`
+	DisplayName             string   `json:"displayname,omitempty"`
+	Description             string   `json:"description,omitempty"`
+	Email                   string   `json:"email,omitempty"`
+	SIDHistory              []string `json:"sidhistory"`
+}
+
+// bloodHoundCEComputer represents a BloodHound CE computer object
+type bloodHoundCEComputer struct {
+	bloodHoundCEBase
+	Properties        bloodHoundCEComputerProps  `json:"Properties"`
+	PrimaryGroupSID   string                     `json:"PrimaryGroupSID,omitempty"`
+	AllowedToDelegate []bloodHoundTypedPrincipal `json:"AllowedToDelegate"`
+	AllowedToAct      []bloodHoundTypedPrincipal `json:"AllowedToAct"`
+	HasSIDHistory     []bloodHoundTypedPrincipal `json:"HasSIDHistory"`
+	DomainSID         string                     `json:"DomainSID"`
+	IsDC              bool                       `json:"IsDC"`
+}
+
+// bloodHoundCEComputerProps represents computer properties for BloodHound CE
+type bloodHoundCEComputerProps struct {
+	Name                    string   `json:"name"`
+	Domain                  string   `json:"domain"`
+	DomainSID               string   `json:"domainsid"`
+	DistinguishedName       string   `json:"distinguishedname"`
+	SAMAccountName          string   `json:"samaccountname"`
+	Enabled                 bool     `json:"enabled"`
+	UnconstrainedDelegation bool     `json:"unconstraineddelegation"`
+	TrustedToAuth           bool     `json:"trustedtoauth"`
+	IsDC                    bool     `json:"isdc"`
+	HasLAPS                 bool     `json:"haslaps"`
+	OperatingSystem         string   `json:"operatingsystem,omitempty"`
+	ServicePrincipalNames   []string `json:"serviceprincipalnames"`
+	AllowedToDelegate       []string `json:"allowedtodelegate,omitempty"`
+	LastLogon               int64    `json:"lastlogon"`
+	LastLogonTimestamp      int64    `json:"lastlogontimestamp"`
+	PwdLastSet              int64    `json:"pwdlastset"`
+	WhenCreated             int64    `json:"whencreated"`
+	Description             string   `json:"description,omitempty"`
+	SIDHistory              []string `json:"sidhistory"`
+}
+
+// bloodHoundCEGroup represents a BloodHound CE group object
+type bloodHoundCEGroup struct {
+	bloodHoundCEBase
+	Properties bloodHoundCEGroupProps     `json:"Properties"`
+	Members    []bloodHoundTypedPrincipal `json:"Members"`
+}
+
+// bloodHoundCEGroupProps represents group properties for BloodHound CE
+type bloodHoundCEGroupProps struct {
+	Name              string `json:"name"`
+	Domain            string `json:"domain"`
+	DomainSID         string `json:"domainsid"`
+	DistinguishedName string `json:"distinguishedname"`
+	SAMAccountName    string `json:"samaccountname"`
+	AdminCount        bool   `json:"admincount"`
+	WhenCreated       int64  `json:"whencreated"`
+	Description       string `json:"description,omitempty"`
+}
+
+// bloodHoundCEDomain represents a BloodHound CE domain object
+type bloodHoundCEDomain struct {
+	bloodHoundCEBase
+	Properties   bloodHoundCEDomainProps    `json:"Properties"`
+	Trusts       []bloodHoundCETrust        `json:"Trusts"`
+	Links        []bloodHoundCELink         `json:"Links"`
+	ChildObjects []bloodHoundTypedPrincipal `json:"ChildObjects"`
+	GPOChanges   bloodHoundCEGPOChanges     `json:"GPOChanges"`
+}
+
+// bloodHoundCEDomainProps represents domain properties for BloodHound CE
+type bloodHoundCEDomainProps struct {
+	Name              string `json:"name"`
+	Domain            string `json:"domain"`
+	DomainSID         string `json:"domainsid"`
+	DistinguishedName string `json:"distinguishedname"`
+	FunctionalLevel   string `json:"functionallevel,omitempty"`
+	WhenCreated       int64  `json:"whencreated"`
+	Description       string `json:"description,omitempty"`
+}
+
+// bloodHoundCEGPOChanges lists principals affected by GPO-based local group changes
+type bloodHoundCEGPOChanges struct {
+	LocalAdmins        []bloodHoundTypedPrincipal `json:"LocalAdmins"`
+	RemoteDesktopUsers []bloodHoundTypedPrincipal `json:"RemoteDesktopUsers"`
+	DcomUsers          []bloodHoundTypedPrincipal `json:"DcomUsers"`
+	PSRemoteUsers      []bloodHoundTypedPrincipal `json:"PSRemoteUsers"`
+	AffectedComputers  []bloodHoundTypedPrincipal `json:"AffectedComputers"`
+}
+
+// bloodHoundCEGPO represents a BloodHound CE GPO object
+type bloodHoundCEGPO struct {
+	bloodHoundCEBase
+	Properties bloodHoundCEGPOProps `json:"Properties"`
+}
+
+// bloodHoundCEGPOProps represents GPO properties for BloodHound CE
+type bloodHoundCEGPOProps struct {
+	Name              string `json:"name"`
+	Domain            string `json:"domain"`
+	DomainSID         string `json:"domainsid"`
+	DistinguishedName string `json:"distinguishedname"`
+	GPCPath           string `json:"gpcpath"`
+	WhenCreated       int64  `json:"whencreated"`
+	Description       string `json:"description,omitempty"`
+}
+
+// bloodHoundCEOU represents a BloodHound CE organizational unit object
+type bloodHoundCEOU struct {
+	bloodHoundCEBase
+	Properties   bloodHoundCEOUProps        `json:"Properties"`
+	Links        []bloodHoundCELink         `json:"Links"`
+	ChildObjects []bloodHoundTypedPrincipal `json:"ChildObjects"`
+	GPOChanges   bloodHoundCEGPOChanges     `json:"GPOChanges"`
+}
+
+// bloodHoundCEOUProps represents organizational unit properties for BloodHound CE
+type bloodHoundCEOUProps struct {
+	Name              string `json:"name"`
+	Domain            string `json:"domain"`
+	DomainSID         string `json:"domainsid"`
+	DistinguishedName string `json:"distinguishedname"`
+	BlocksInheritance bool   `json:"blocksinheritance"`
+	WhenCreated       int64  `json:"whencreated"`
+	Description       string `json:"description,omitempty"`
+}
+
+// bloodHoundCEContainer represents a BloodHound CE container object
+type bloodHoundCEContainer struct {
+	bloodHoundCEBase
+	Properties   bloodHoundCEContainerProps `json:"Properties"`
+	ChildObjects []bloodHoundTypedPrincipal `json:"ChildObjects"`
+}
+
+// bloodHoundCEContainerProps represents container properties for BloodHound CE
+type bloodHoundCEContainerProps struct {
+	Name              string `json:"name"`
+	Domain            string `json:"domain"`
+	DomainSID         string `json:"domainsid"`
+	DistinguishedName string `json:"distinguishedname"`
+	WhenCreated       int64  `json:"whencreated"`
+	Description       string `json:"description,omitempty"`
+}
+
+// bloodHoundCELink represents a GPO link on a domain or OU
+type bloodHoundCELink struct {
+	IsEnforced bool   `json:"IsEnforced"`
+	GUID       string `json:"GUID"`
+}
+
+// bloodHoundCETrust represents a domain trust in BloodHound CE format
+type bloodHoundCETrust struct {
+	TargetDomainSid     string `json:"TargetDomainSid"`
+	TargetDomainName    string `json:"TargetDomainName"`
+	IsTransitive        bool   `json:"IsTransitive"`
+	SidFilteringEnabled bool   `json:"SidFilteringEnabled"`
+	TrustDirection      string `json:"TrustDirection"`
+	TrustType           string `json:"TrustType"`
+}
+
+// bloodHoundCEPrinter outputs the BloodHound CE ingestion schema.
+// Objects are identified by SID (or GUID for GPOs, OUs and containers)
+// rather than DN as required by CE imports.
+type bloodHoundCEPrinter struct {
+	cfg PrinterConfig
+}
+
+// newBloodHoundCEPrinter creates a new BloodHound CE format printer
+func newBloodHoundCEPrinter(cfg PrinterConfig) Printer {
+	return &bloodHoundCEPrinter{cfg: cfg}
+}
+
+// Print outputs entries in BloodHound CE JSON format
+func (p *bloodHoundCEPrinter) Print(entries []*ldap.Entry) error {
+	graph := newBloodHoundCEGraph(entries)
+	output := graph.file(detectBloodHoundCEKind(entries), entries)
+
+	b, err := json.Marshal(output)
+	if err != nil {
+		return fmt.Errorf("marshaling BloodHound CE JSON: %w", err)
+	}
+
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	_, err = fmt.Fprintln(w, string(b))
+	return err
+}
+
+// StreamPrint outputs entries in BloodHound CE JSON format.
+// BloodHound CE needs SID lookups across the whole result set, so entries
+// are spooled to a temporary file while the graph is indexed, then
+// converted and written one at a time with the meta section last.
+func (p *bloodHoundCEPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	spool, err := newEntrySpool()
+	if err != nil {
+		return err
+	}
+	defer spool.close()
+
+	graph := newBloodHoundCEGraph(nil)
+	counts := make(map[string]int)
+	for entry := range entriesChan {
+		if entry == nil {
+			continue
+		}
+		graph.add(entry)
+		counts[bloodHoundCEKind(entry)]++
+		if err := spool.add(entry); err != nil {
+			return err
+		}
+	}
+	kind := mostCommonBloodHoundCEKind(counts)
+
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	data := newJSONArrayWriter(w, "{\"data\":[\n")
+	err = spool.each(func(entry *ldap.Entry) error {
+		if bloodHoundCEKind(entry) != kind {
+			return nil
+		}
+		if obj := graph.convert(entry, kind); obj != nil {
+			return data.add(obj)
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	return data.close("\n],\"meta\":", newBloodHoundCEMeta(kind, data.count), "}\n")
+}
+
+// WriteBloodHoundCEArchive converts entries of any object type into the
+// BloodHound CE multi-file layout and writes it as a zip archive at path.
+// Each file kind is stored as <prefix>_<kind>.json; references between
+// objects (members, containers, GPO links, trusts) are resolved across
+// the whole set of entries.
+func WriteBloodHoundCEArchive(path, prefix string, entries []*ldap.Entry) error {
+	// Collections can overlap (e.g. containers include GPOs), keep the first copy
+	seen := make(map[string]bool, len(entries))
+	unique := make([]*ldap.Entry, 0, len(entries))
+	for _, entry := range entries {
+		if entry == nil || seen[strings.ToLower(entry.DN)] {
+			continue
+		}
+		seen[strings.ToLower(entry.DN)] = true
+		unique = append(unique, entry)
+	}
+
+	byKind := make(map[string][]*ldap.Entry)
+	for _, entry := range unique {
+		kind := bloodHoundCEKind(entry)
+		byKind[kind] = append(byKind[kind], entry)
+	}
+
+	f, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("creating archive: %w", err)
+	}
+	defer f.Close()
+
+	graph := newBloodHoundCEGraph(unique)
+	zw := zip.NewWriter(f)
+	for _, kind := range bloodHoundCEFileKinds {
+		w, err := zw.Create(fmt.Sprintf("%s_%s.json", prefix, kind))
+		if err != nil {
+			return fmt.Errorf("adding %s to archive: %w", kind, err)
+		}
+		if err := json.NewEncoder(w).Encode(graph.file(kind, byKind[kind])); err != nil {
+			return fmt.Errorf("writing %s to archive: %w", kind, err)
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("closing archive: %w", err)
+	}
+	return f.Close()
+}
+
+// detectBloodHoundCEKind returns the most common file kind among entries
+func detectBloodHoundCEKind(entries []*ldap.Entry) string {
+	counts := make(map[string]int)
+	for _, entry := range entries {
+		counts[bloodHoundCEKind(entry)]++
+	}
+	return mostCommonBloodHoundCEKind(counts)
+}
+
+// mostCommonBloodHoundCEKind returns the file kind with the highest count, defaulting to users
+func mostCommonBloodHoundCEKind(counts map[string]int) string {
+	detected := bhKindUsers
+	maxCount := 0
+	for _, kind := range bloodHoundCEFileKinds {
+		if counts[kind] > maxCount {
+			maxCount = counts[kind]
+			detected = kind
+		}
+	}
+	return detected
+}
+
+// bloodHoundCEGraph indexes a result set so objects can reference each
+// other by identifier instead of DN.
+type bloodHoundCEGraph struct {
+	nodes      map[string]bloodHoundTypedPrincipal   // lowercased DN -> reference
+	index      *bloodHoundIndex                      // SID lookups shared with the v4 printer
+	children   map[string][]bloodHoundTypedPrincipal // lowercased parent DN -> child references
+	domainSIDs map[string]string                     // lowercased domain DN -> domain SID
+	trusts     map[string][]bloodHoundCETrust        // lowercased domain DN -> trusts
+}
+
+// newBloodHoundCEGraph builds the reference index for a result set
+func newBloodHoundCEGraph(entries []*ldap.Entry) *bloodHoundCEGraph {
+	g := &bloodHoundCEGraph{
+		nodes:      make(map[string]bloodHoundTypedPrincipal, len(entries)),
+		index:      newBloodHoundIndex(entries),
+		children:   make(map[string][]bloodHoundTypedPrincipal),
+		domainSIDs: make(map[string]string),
+		trusts:     make(map[string][]bloodHoundCETrust),
+	}
+
+	for _, entry := range entries {
+		g.indexEntry(entry)
+	}
+
+	return g
+}
+
+// add indexes one more entry
+func (g *bloodHoundCEGraph) add(entry *ldap.Entry) {
+	g.index.add(entry)
+	g.indexEntry(entry)
+}
+
+// indexEntry records the node, parent and trust references of an entry
+func (g *bloodHoundCEGraph) indexEntry(entry *ldap.Entry) {
+	kind := bloodHoundCEKind(entry)
+	switch kind {
+	case "":
+		return
+	case bhKindTrusts:
+		domainDN := strings.ToLower(domainDNOf(entry.DN))
+		g.trusts[domainDN] = append(g.trusts[domainDN], convertTrust(entry))
+		return
+	case bhKindDomains:
+		g.domainSIDs[strings.ToLower(entry.DN)] = entrySID(entry)
+	}
+
+	id := bloodHoundCEIdentifier(entry, kind)
+	if id == "" {
+		return
+	}
+	ref := bloodHoundTypedPrincipal{ObjectIdentifier: id, ObjectType: bloodHoundCEObjectType(kind)}
+	g.nodes[strings.ToLower(entry.DN)] = ref
+	if kind != bhKindDomains {
+		parent := strings.ToLower(analyze.ParentDN(entry.DN))
+		g.children[parent] = append(g.children[parent], ref)
+	}
+}
+
+// file converts the entries of one kind into a BloodHound CE file
+func (g *bloodHoundCEGraph) file(kind string, entries []*ldap.Entry) bloodHoundCEOutput {
+	data := make([]any, 0, len(entries))
+	for _, entry := range entries {
+		if bloodHoundCEKind(entry) != kind {
+			continue
+		}
+		if obj := g.convert(entry, kind); obj != nil {
+			data = append(data, obj)
+		}
+	}
+
+	return bloodHoundCEOutput{
+		Data: data,
+		Meta: newBloodHoundCEMeta(kind, len(data)),
+	}
+}
+
+// newBloodHoundCEMeta returns the meta section for a file of count objects of kind
+func newBloodHoundCEMeta(kind string, count int) bloodHoundCEMeta {
+	return bloodHoundCEMeta{
+		Methods: bloodHoundCEMethodGroup | bloodHoundCEMethodTrusts | bloodHoundCEMethodACL |
+			bloodHoundCEMethodContainer | bloodHoundCEMethodObjectProps,
+		Type:             kind,
+		Count:            count,
+		Version:          bloodHoundCEVersion,
+		CollectorVersion: bloodHoundCECollector,
+	}
+}
+
+// convert converts an LDAP entry to the BloodHound CE object for its kind
+func (g *bloodHoundCEGraph) convert(entry *ldap.Entry, kind string) any {
+	id := bloodHoundCEIdentifier(entry, kind)
+	if id == "" {
+		// CE imports key every node by SID or GUID; objects without one cannot be linked
+		return nil
+	}
+
+	switch kind {
+	case bhKindUsers:
+		return g.convertUser(entry, id)
+	case bhKindComputers:
+		return g.convertComputer(entry, id)
+	case bhKindGroups:
+		return g.convertGroup(entry, id)
+	case bhKindDomains:
+		return g.convertDomain(entry, id)
+	case bhKindGPOs:
+		return g.convertGPO(entry, id)
+	case bhKindOUs:
+		return g.convertOU(entry, id)
+	case bhKindContainer:
+		return g.convertContainer(entry, id)
+	default:
+		return nil
+	}
+}
+
+// convertUser converts an LDAP entry to a BloodHound CE user
+func (g *bloodHoundCEGraph) convertUser(entry *ldap.Entry, sid string) bloodHoundCEUser {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+	domainSID := domainSIDFromSID(sid)
+	uac := uacValue(entry)
+	sam := getAttributeValue(entry, analyze.AttrSAMAccountName)
+	spns := getAttributeValues(entry, analyze.AttrServicePrincipalName)
+
+	return bloodHoundCEUser{
+		bloodHoundCEBase: g.newBase(entry, sid, bhKindUsers),
+		Properties: bloodHoundCEUserProps{
+			Name:                    strings.ToUpper(sam + "@" + domain),
+			Domain:                  domain,
+			DomainSID:               domainSID,
+			DistinguishedName:       strings.ToUpper(entry.DN),
+			SAMAccountName:          sam,
+			Enabled:                 uac&analyze.UF_ACCOUNTDISABLE == 0,
+			AdminCount:              getIntAttribute(entry, analyze.AttrAdminCount) == 1,
+			Sensitive:               uac&analyze.UF_NOT_DELEGATED != 0,
+			DontReqPreAuth:          uac&analyze.UF_DONT_REQUIRE_PREAUTH != 0,
+			PasswordNotReqd:         uac&analyze.UF_PASSWD_NOTREQD != 0,
+			UnconstrainedDelegation: uac&analyze.UF_TRUSTED_FOR_DELEGATION != 0,
+			PwdNeverExpires:         uac&analyze.UF_DONT_EXPIRE_PASSWORD != 0,
+			TrustedToAuth:           uac&analyze.UF_TRUSTED_TO_AUTH_FOR_DELEGATION != 0,
+			HasSPN:                  len(spns) > 0,
+			ServicePrincipalNames:   spns,
+			AllowedToDelegate:       entry.GetAttributeValues(analyze.AttrMSDSAllowedToDelegateTo),
+			LastLogon:               fileTimeToUnix(getAttributeValue(entry, analyze.AttrLastLogon)),
+			LastLogonTimestamp:      fileTimeToUnix(getAttributeValue(entry, analyze.AttrLastLogonTimestamp)),
+			PwdLastSet:              fileTimeToUnix(getAttributeValue(entry, analyze.AttrPwdLastSet)),
+			WhenCreated:             generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			DisplayName:             getAttributeValue(entry, analyze.AttrDisplayName),
+			Description:             getAttributeValue(entry, analyze.AttrDescription),
+			Email:                   getAttributeValue(entry, "mail"),
+			SIDHistory:              entrySIDHistory(entry),
+		},
+		PrimaryGroupSID:   primaryGroupSID(entry, domainSID),
+		AllowedToDelegate: []bloodHoundTypedPrincipal{},
+		HasSIDHistory:     typedPrincipals(entrySIDHistory(entry), "Base"),
+		SPNTargets:        []any{},
+	}
+}
+
+// convertComputer converts an LDAP entry to a BloodHound CE computer
+func (g *bloodHoundCEGraph) convertComputer(entry *ldap.Entry, sid string) bloodHoundCEComputer {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+	domainSID := domainSIDFromSID(sid)
+	uac := uacValue(entry)
+	sam := getAttributeValue(entry, analyze.AttrSAMAccountName)
+	isDC := uac&analyze.UF_SERVER_TRUST_ACCOUNT != 0
+
+	name := getAttributeValue(entry, analyze.AttrDNSHostName)
+	if name == "" {
+		name = strings.TrimSuffix(sam, "$") + "." + domain
+	}
+
+	var rbcd []string
+	if raw := entry.GetRawAttributeValue(analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity); len(raw) > 0 {
+		rbcd, _ = analyze.ParseRBCDBinary(raw)
+	}
+
+	return bloodHoundCEComputer{
+		bloodHoundCEBase: g.newBase(entry, sid, bhKindComputers),
+		Properties: bloodHoundCEComputerProps{
+			Name:                    strings.ToUpper(name),
+			Domain:                  domain,
+			DomainSID:               domainSID,
+			DistinguishedName:       strings.ToUpper(entry.DN),
+			SAMAccountName:          sam,
+			Enabled:                 uac&analyze.UF_ACCOUNTDISABLE == 0,
+			UnconstrainedDelegation: uac&analyze.UF_TRUSTED_FOR_DELEGATION != 0,
+			TrustedToAuth:           uac&analyze.UF_TRUSTED_TO_AUTH_FOR_DELEGATION != 0,
+			IsDC:                    isDC,
+			HasLAPS:                 hasLAPS(entry),
+			OperatingSystem:         getAttributeValue(entry, analyze.AttrOperatingSystem),
+			ServicePrincipalNames:   getAttributeValues(entry, analyze.AttrServicePrincipalName),
+			AllowedToDelegate:       entry.GetAttributeValues(analyze.AttrMSDSAllowedToDelegateTo),
+			LastLogon:               fileTimeToUnix(getAttributeValue(entry, analyze.AttrLastLogon)),
+			LastLogonTimestamp:      fileTimeToUnix(getAttributeValue(entry, analyze.AttrLastLogonTimestamp)),
+			PwdLastSet:              fileTimeToUnix(getAttributeValue(entry, analyze.AttrPwdLastSet)),
+			WhenCreated:             generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			Description:             getAttributeValue(entry, analyze.AttrDescription),
+			SIDHistory:              entrySIDHistory(entry),
+		},
+		PrimaryGroupSID:   primaryGroupSID(entry, domainSID),
+		AllowedToDelegate: []bloodHoundTypedPrincipal{},
+		AllowedToAct:      typedPrincipals(rbcd, "Base"),
+		HasSIDHistory:     typedPrincipals(entrySIDHistory(entry), "Base"),
+		DomainSID:         domainSID,
+		IsDC:              isDC,
+	}
+}
+
+// convertGroup converts an LDAP entry to a BloodHound CE group
+func (g *bloodHoundCEGraph) convertGroup(entry *ldap.Entry, sid string) bloodHoundCEGroup {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+	sam := getAttributeValue(entry, analyze.AttrSAMAccountName)
+
+	members := g.index.members(entry, entrySID(entry))
+	for i := range members {
+		members[i].ObjectIdentifier = qualifySID(members[i].ObjectIdentifier, domain)
+	}
+
+	return bloodHoundCEGroup{
+		bloodHoundCEBase: g.newBase(entry, sid, bhKindGroups),
+		Properties: bloodHoundCEGroupProps{
+			Name:              strings.ToUpper(sam + "@" + domain),
+			Domain:            domain,
+			DomainSID:         domainSIDFromSID(sid),
+			DistinguishedName: strings.ToUpper(entry.DN),
+			SAMAccountName:    sam,
+			AdminCount:        getIntAttribute(entry, analyze.AttrAdminCount) == 1,
+			WhenCreated:       generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			Description:       getAttributeValue(entry, analyze.AttrDescription),
+		},
+		Members: members,
+	}
+}
+
+// convertDomain converts an LDAP entry to a BloodHound CE domain
+func (g *bloodHoundCEGraph) convertDomain(entry *ldap.Entry, sid string) bloodHoundCEDomain {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+
+	trusts := g.trusts[strings.ToLower(entry.DN)]
+	if trusts == nil {
+		trusts = []bloodHoundCETrust{}
+	}
+
+	return bloodHoundCEDomain{
+		bloodHoundCEBase: g.newBase(entry, sid, bhKindDomains),
+		Properties: bloodHoundCEDomainProps{
+			Name:              domain,
+			Domain:            domain,
+			DomainSID:         sid,
+			DistinguishedName: strings.ToUpper(entry.DN),
+			FunctionalLevel:   analyze.FunctionalLevel(getAttributeValue(entry, analyze.AttrMSDSBehaviorVersion)),
+			WhenCreated:       generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			Description:       getAttributeValue(entry, analyze.AttrDescription),
+		},
+		Trusts:       trusts,
+		Links:        g.links(entry),
+		ChildObjects: g.childObjects(entry),
+		GPOChanges:   newBloodHoundCEGPOChanges(),
+	}
+}
+
+// convertGPO converts an LDAP entry to a BloodHound CE GPO
+func (g *bloodHoundCEGraph) convertGPO(entry *ldap.Entry, guid string) bloodHoundCEGPO {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+
+	return bloodHoundCEGPO{
+		bloodHoundCEBase: g.newBase(entry, guid, bhKindGPOs),
+		Properties: bloodHoundCEGPOProps{
+			Name:              strings.ToUpper(getAttributeValue(entry, analyze.AttrDisplayName) + "@" + domain),
+			Domain:            domain,
+			DomainSID:         g.domainSID(entry.DN),
+			DistinguishedName: strings.ToUpper(entry.DN),
+			GPCPath:           strings.ToUpper(getAttributeValue(entry, analyze.AttrGPCFileSysPath)),
+			WhenCreated:       generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			Description:       getAttributeValue(entry, analyze.AttrDescription),
+		},
+	}
+}
+
+// convertOU converts an LDAP entry to a BloodHound CE organizational unit
+func (g *bloodHoundCEGraph) convertOU(entry *ldap.Entry, guid string) bloodHoundCEOU {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+
+	return bloodHoundCEOU{
+		bloodHoundCEBase: g.newBase(entry, guid, bhKindOUs),
+		Properties: bloodHoundCEOUProps{
+			Name:              strings.ToUpper(entryName(entry) + "@" + domain),
+			Domain:            domain,
+			DomainSID:         g.domainSID(entry.DN),
+			DistinguishedName: strings.ToUpper(entry.DN),
+			BlocksInheritance: getIntAttribute(entry, analyze.AttrGPOptions) == 1,
+			WhenCreated:       generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			Description:       getAttributeValue(entry, analyze.AttrDescription),
+		},
+		Links:        g.links(entry),
+		ChildObjects: g.childObjects(entry),
+		GPOChanges:   newBloodHoundCEGPOChanges(),
+	}
+}
+
+// convertContainer converts an LDAP entry to a BloodHound CE container
+func (g *bloodHoundCEGraph) convertContainer(entry *ldap.Entry, guid string) bloodHoundCEContainer {
+	domain := strings.ToUpper(extractDomain(entry.DN))
+
+	return bloodHoundCEContainer{
+		bloodHoundCEBase: g.newBase(entry, guid, bhKindContainer),
+		Properties: bloodHoundCEContainerProps{
+			Name:              strings.ToUpper(entryName(entry) + "@" + domain),
+			Domain:            domain,
+			DomainSID:         g.domainSID(entry.DN),
+			DistinguishedName: strings.ToUpper(entry.DN),
+			WhenCreated:       generalizedTimeToUnix(getAttributeValue(entry, analyze.AttrWhenCreated)),
+			Description:       getAttributeValue(entry, analyze.AttrDescription),
+		},
+		ChildObjects: g.childObjects(entry),
+	}
+}
+
+// newBase creates the shared object fields, resolving the parent container
+// and the ACL edges of the entry
+func (g *bloodHoundCEGraph) newBase(entry *ldap.Entry, id, kind string) bloodHoundCEBase {
+	base := bloodHoundCEBase{
+		ObjectIdentifier: id,
+		Aces:             []bloodHoundCEAce{},
+	}
+	if parent, ok := g.nodes[strings.ToLower(analyze.ParentDN(entry.DN))]; ok {
+		base.ContainedBy = &parent
+	}
+
+	acl, ok := entryACLEdges(entry, bloodHoundCEObjectType(kind))
+	if !ok {
+		return base
+	}
+	base.IsACLProtected = acl.Protected
+
+	domain := strings.ToUpper(extractDomain(entry.DN))
+	for _, edge := range acl.Edges {
+		base.Aces = append(base.Aces, bloodHoundCEAce{
+			PrincipalSID:  qualifySID(edge.PrincipalSID, domain),
+			PrincipalType: principalType(edge.PrincipalSID, g.index.types),
+			RightName:     edge.RightName,
+			IsInherited:   edge.IsInherited,
+		})
+	}
+	return base
+}
+
+// childObjects returns references to the objects directly below an entry
+func (g *bloodHoundCEGraph) childObjects(entry *ldap.Entry) []bloodHoundTypedPrincipal {
+	children := g.children[strings.ToLower(entry.DN)]
+	if children == nil {
+		return []bloodHoundTypedPrincipal{}
+	}
+	return children
+}
+
+// links resolves the gPLink attribute of a domain or OU to GPO GUIDs.
+// Disabled links and GPOs missing from the result set are skipped.
+func (g *bloodHoundCEGraph) links(entry *ldap.Entry) []bloodHoundCELink {
+	links := []bloodHoundCELink{}
+	for _, l := range analyze.ParseGPLink(getAttributeValue(entry, analyze.AttrGPLink)) {
+		if !l.Enabled {
+			continue
+		}
+		gpo, ok := g.nodes[strings.ToLower(l.DN)]
+		if !ok {
+			continue
+		}
+		links = append(links, bloodHoundCELink{
+			IsEnforced: l.Enforced,
+			GUID:       gpo.ObjectIdentifier,
+		})
+	}
+	return links
+}
+
+// domainSID returns the SID of the domain containing dn, if it was collected
+func (g *bloodHoundCEGraph) domainSID(dn string) string {
+	return g.domainSIDs[strings.ToLower(domainDNOf(dn))]
+}
+
+// newBloodHoundCEGPOChanges returns an empty GPOChanges block
+func newBloodHoundCEGPOChanges() bloodHoundCEGPOChanges {
+	return bloodHoundCEGPOChanges{
+		LocalAdmins:        []bloodHoundTypedPrincipal{},
+		RemoteDesktopUsers: []bloodHoundTypedPrincipal{},
+		DcomUsers:          []bloodHoundTypedPrincipal{},
+		PSRemoteUsers:      []bloodHoundTypedPrincipal{},
+		AffectedComputers:  []bloodHoundTypedPrincipal{},
+	}
+}
+
+// convertTrust converts a trustedDomain entry to a BloodHound CE trust
+func convertTrust(entry *ldap.Entry) bloodHoundCETrust {
+	attributes := getIntAttribute(entry, analyze.AttrTrustAttributes)
+	trustType := analyze.TrustTypeName(attributes)
+
+	var targetSID string
+	if raw := entry.GetRawAttributeValue(analyze.AttrSecurityIdentifier); len(raw) > 0 {
+		targetSID, _ = analyze.ParseObjectSID(raw)
+	}
+
+	targetName := getAttributeValue(entry, analyze.AttrTrustPartner)
+	if targetName == "" {
+		targetName = getAttributeValue(entry, analyze.AttrName)
+	}
+
+	return bloodHoundCETrust{
+		TargetDomainSid:  targetSID,
+		TargetDomainName: strings.ToUpper(targetName),
+		IsTransitive:     attributes&analyze.TRUST_ATTRIBUTE_NON_TRANSITIVE == 0,
+		// Forest trusts always filter SIDs; other trusts only when quarantined
+		SidFilteringEnabled: trustType == "Forest" || attributes&analyze.TRUST_ATTRIBUTE_QUARANTINED_DOMAIN != 0,
+		TrustDirection:      analyze.TrustDirectionName(getIntAttribute(entry, analyze.AttrTrustDirection)),
+		TrustType:           trustType,
+	}
+}
+
+// bloodHoundCEKind determines the BloodHound CE kind of an entry from its objectClass
+func bloodHoundCEKind(entry *ldap.Entry) string {
+	classes := entry.GetAttributeValues(analyze.AttrObjectClass)
+	switch {
+	case slices.Contains(classes, "computer"):
+		return bhKindComputers
+	case slices.Contains(classes, "user"):
+		return bhKindUsers
+	case slices.Contains(classes, "group"):
+		return bhKindGroups
+	case slices.Contains(classes, "groupPolicyContainer"):
+		return bhKindGPOs
+	case slices.Contains(classes, "organizationalUnit"):
+		return bhKindOUs
+	case slices.Contains(classes, "domainDNS"), slices.Contains(classes, "domain"):
+		return bhKindDomains
+	case slices.Contains(classes, "trustedDomain"):
+		return bhKindTrusts
+	case slices.Contains(classes, "container"):
+		return bhKindContainer
+	}
+
+	if len(classes) > 0 {
+		return ""
+	}
+
+	// Fallback: detect type from DN when objectClass is missing
+	switch detectTypeFromDN(entry.DN) {
+	case "USER":
+		return bhKindUsers
+	case "GROUP":
+		return bhKindGroups
+	default:
+		return bhKindComputers
+	}
+}
+
+// bloodHoundCEIdentifier returns the ObjectIdentifier of an entry:
+// the SID for security principals and domains, the GUID for everything else.
+func bloodHoundCEIdentifier(entry *ldap.Entry, kind string) string {
+	switch kind {
+	case bhKindGPOs, bhKindOUs, bhKindContainer:
+		return entryGUID(entry)
+	default:
+		sid := entrySID(entry)
+		if sid == "" {
+			return ""
+		}
+		return qualifySID(sid, strings.ToUpper(extractDomain(entry.DN)))
+	}
+}
+
+// qualifySID prefixes well-known SIDs with the domain name, as BloodHound CE
+// does, so that builtin principals of different domains stay distinct
+func qualifySID(sid, domain string) string {
+	if strings.HasPrefix(sid, "S-1-5-21-") {
+		return sid
+	}
+	return domain + "-" + sid
+}
+
+// bloodHoundCEObjectType returns the ObjectType name for a file kind
+func bloodHoundCEObjectType(kind string) string {
+	if t, ok := bloodHoundCEObjectTypes[kind]; ok {
+		return t
+	}
+	return "Base"
+}
+
+// typedPrincipals wraps SIDs as typed principal references
+func typedPrincipals(sids []string, objectType string) []bloodHoundTypedPrincipal {
+	refs := make([]bloodHoundTypedPrincipal, 0, len(sids))
+	for _, sid := range sids {
+		refs = append(refs, bloodHoundTypedPrincipal{ObjectIdentifier: sid, ObjectType: objectType})
+	}
+	return refs
+}
+
+// entrySID returns the string form of an entry's objectSid, or "" if absent
+func entrySID(entry *ldap.Entry) string {
+	raw := entry.GetEqualFoldRawAttributeValues(analyze.AttrObjectSID)
+	if len(raw) == 0 {
+		return ""
+	}
+	sid, err := analyze.ParseObjectSID(raw[0])
+	if err != nil {
+		return ""
+	}
+	return sid
+}
+
+// entryGUID returns an entry's objectGUID in BloodHound form
+// (uppercase, without braces), or "" if absent
+func entryGUID(entry *ldap.Entry) string {
+	raw := entry.GetEqualFoldRawAttributeValues(analyze.AttrObjectGUID)
+	if len(raw) == 0 {
+		return ""
+	}
+	guid, err := analyze.ParseObjectGUID(raw[0])
+	if err != nil {
+		return ""
+	}
+	return strings.ToUpper(strings.Trim(guid, "{}"))
+}
+
+// entryName returns the name attribute of an entry, falling back to its RDN value
+func entryName(entry *ldap.Entry) string {
+	if name := getAttributeValue(entry, analyze.AttrName); name != "" {
+		return name
+	}
+	rdn := strings.SplitN(entry.DN, ",", 2)[0]
+	if i := strings.Index(rdn, "="); i >= 0 {
+		return rdn[i+1:]
+	}
+	return rdn
+}
+
+// entrySIDHistory returns the string form of every sIDHistory value
+func entrySIDHistory(entry *ldap.Entry) []string {
+	sids := []string{}
+	for _, raw := range entry.GetRawAttributeValues(analyze.AttrSIDHistory) {
+		if sid, err := analyze.ParseObjectSID(raw); err == nil {
+			sids = append(sids, sid)
+		}
+	}
+	return sids
+}
+
+// domainDNOf returns the DC= suffix of a DN
+func domainDNOf(dn string) string {
+	lower := strings.ToLower(dn)
+	if strings.HasPrefix(lower, "dc=") {
+		return dn
+	}
+	if i := strings.Index(lower, ",dc="); i >= 0 {
+		return dn[i+1:]
+	}
+	return ""
+}
+
+// domainSIDFromSID strips the RID from a domain account SID
+func domainSIDFromSID(sid string) string {
+	if !strings.HasPrefix(sid, "S-1-5-21-") {
+		return ""
+	}
+	if strings.Count(sid, "-") == 6 {
+		// Already a domain SID (S-1-5-21-a-b-c)
+		return sid
+	}
+	return sid[:strings.LastIndex(sid, "-")]
+}
+
+// primaryGroupSID builds the primary group SID from the domain SID and primaryGroupID
+func primaryGroupSID(entry *ldap.Entry, domainSID string) string {
+	rid := getAttributeValue(entry, analyze.AttrPrimaryGroupID)
+	if rid == "" || domainSID == "" {
+		return ""
+	}
+	return domainSID + "-" + rid
+}
+
+// uacValue returns the numeric userAccountControl value of an entry
+func uacValue(entry *ldap.Entry) uint32 {
+	v, err := strconv.ParseUint(getAttributeValue(entry, analyze.AttrUserAccountControl), 10, 32)
+	if err != nil {
+		return 0
+	}
+	return uint32(v)
+}
+
+// hasLAPS reports whether a computer has a legacy or Windows LAPS expiration time set
+func hasLAPS(entry *ldap.Entry) bool {
+	return getAttributeValue(entry, "ms-Mcs-AdmPwdExpirationTime") != "" ||
+		getAttributeValue(entry, "msLAPS-PasswordExpirationTime") != ""
+}
+
+// fileTimeToUnix converts a Windows FileTime string to Unix seconds.
+// Returns bloodHoundCENever for empty, zero, or "never" values.
+func fileTimeToUnix(value string) int64 {
+	ft, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || ft <= 0 || ft == 9223372036854775807 {
+		return bloodHoundCENever
+	}
+	return (ft - 116444736000000000) / 10000000
+}
+
+// generalizedTimeToUnix converts an LDAP generalized time string to Unix seconds
+func generalizedTimeToUnix(value string) int64 {
+	t, err := time.Parse("20060102150405.0Z", value)
+	if err != nil {
+		return 0
+	}
+	return t.Unix()
+}
@@ -23,15 +23,7 @@ type colorFunctions struct {
 // initColors initializes color functions based on terminal support
 func initColors() colorFunctions {
 	if color.NoColor {
-		return colorFunctions{
-			Red:    fmt.Sprint,
-			Green:  fmt.Sprint,
-			Yellow: fmt.Sprint,
-			Blue:   fmt.Sprint,
-			Cyan:   fmt.Sprint,
-			Bold:   fmt.Sprint,
-			Dim:    fmt.Sprint,
-		}
+		return plainColors()
 	}
 
 	return colorFunctions{
@@ -45,6 +37,28 @@ func initColors() colorFunctions {
 	}
 }
 
+// outputColors returns plain color functions when writing to a file or
+// compressing, so reports do not contain terminal escape codes
+func outputColors(cfg PrinterConfig) colorFunctions {
+	if cfg.Path != "" || cfg.Compress != "" {
+		return plainColors()
+	}
+	return initColors()
+}
+
+// plainColors returns color functions that leave text unchanged
+func plainColors() colorFunctions {
+	return colorFunctions{
+		Red:    fmt.Sprint,
+		Green:  fmt.Sprint,
+		Yellow: fmt.Sprint,
+		Blue:   fmt.Sprint,
+		Cyan:   fmt.Sprint,
+		Bold:   fmt.Sprint,
+		Dim:    fmt.Sprint,
+	}
+}
+
 // objectType determines the AD object type from its distinguished name
 func objectType(dn string) string {
 	switch {
@@ -211,6 +225,26 @@ func scoreTarget(entry *ldap.Entry) int {
 	return score
 }
 
+// ScoreEntry returns the value score adgo sorts results by, highest first
+func ScoreEntry(entry *ldap.Entry) int {
+	return scoreTarget(entry)
+}
+
+// IsHighValue reports whether an entry is a high-value target: an admin
+// account, a domain controller or an account with a sensitive SPN
+func IsHighValue(entry *ldap.Entry) bool {
+	return isHighValueTarget(entry)
+}
+
+// orderEntries returns entries in display order: by value score, unless an
+// explicit --sort-by order has already been applied by the sort printer
+func orderEntries(cfg PrinterConfig, entries []*ldap.Entry) []*ldap.Entry {
+	if cfg.SortBy != "" {
+		return entries
+	}
+	return sortByValue(entries)
+}
+
 // sortByValue sorts entries by their value score (highest first)
 func sortByValue(entries []*ldap.Entry) []*ldap.Entry {
 	sorted := make([]*ldap.Entry, len(entries))
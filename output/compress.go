@@ -0,0 +1,79 @@
+package output
+
+import (
+	"compress/gzip"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// Supported output compression methods
+const (
+	CompressionGzip = "gzip"
+	CompressionZstd = "zstd"
+)
+
+// compressionExtensions maps compression methods to their file extensions
+var compressionExtensions = map[string]string{
+	CompressionGzip: ".gz",
+	CompressionZstd: ".zst",
+}
+
+// ParseCompression normalizes a --compress value.
+// Returns an error if the method is not supported.
+func ParseCompression(method string) (string, error) {
+	switch strings.ToLower(method) {
+	case "":
+		return "", nil
+	case "gzip", "gz":
+		return CompressionGzip, nil
+	case "zstd", "zst":
+		return CompressionZstd, nil
+	default:
+		return "", fmt.Errorf("unsupported compression: %s (must be gzip or zstd)", method)
+	}
+}
+
+// CompressionExtension returns the file extension for a compression method
+func CompressionExtension(method string) string {
+	return compressionExtensions[method]
+}
+
+// compressionFor returns the compression applied to cfg's output: the
+// explicit method if set, otherwise one inferred from the Path extension.
+func compressionFor(cfg PrinterConfig) string {
+	if cfg.Compress != "" {
+		return cfg.Compress
+	}
+	for method, ext := range compressionExtensions {
+		if strings.HasSuffix(strings.ToLower(cfg.Path), ext) {
+			return method
+		}
+	}
+	return ""
+}
+
+// compressWriter wraps w in a compressor for method.
+// The returned close function flushes the compressor; it does not close w.
+func compressWriter(w io.Writer, method string) (io.Writer, func() error, error) {
+	switch method {
+	case CompressionGzip:
+		gz := gzip.NewWriter(w)
+		return gz, gz.Close, nil
+	case CompressionZstd:
+		return zstdWriter(w)
+	default:
+		return w, func() error { return nil }, nil
+	}
+}
+
+// zstdWriter compresses with the default zstd level
+func zstdWriter(w io.Writer) (io.Writer, func() error, error) {
+	zw, err := zstd.NewWriter(w)
+	if err != nil {
+		return nil, nil, fmt.Errorf("starting zstd: %w", err)
+	}
+	return zw, zw.Close, nil
+}
@@ -3,18 +3,48 @@ package output
 import (
 	"encoding/csv"
 	"fmt"
+	"io"
 	"os"
 	"sort"
+	"strings"
+	"unicode/utf8"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
+// utf8BOM marks CSV output as UTF-8 for Excel
+const utf8BOM = "\ufeff"
+
+// csvRowWriter writes CSV records; implemented by csv.Writer and quotingWriter
+type csvRowWriter interface {
+	Write(record []string) error
+	Flush()
+	Error() error
+}
+
 // csvPrinter outputs LDAP entries in CSV format.
 // It supports both batch (wide format) and streaming (long format) output.
 type csvPrinter struct {
 	cfg PrinterConfig
 }
 
+// ParseCSVDelimiter normalizes a --csv-delimiter value. "tab" and "\t" select
+// a tab; any other value must be a single character.
+// Returns an error if the delimiter is not usable.
+func ParseCSVDelimiter(s string) (rune, error) {
+	switch strings.ToLower(s) {
+	case "":
+		return ',', nil
+	case "tab", `\t`:
+		return '\t', nil
+	}
+	r, size := utf8.DecodeRuneInString(s)
+	if size != len(s) || r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		return 0, fmt.Errorf("invalid CSV delimiter: %q (must be a single character other than a quote or newline)", s)
+	}
+	return r, nil
+}
+
 // newCSVPrinter creates a new CSV printer instance.
 func newCSVPrinter(cfg PrinterConfig) Printer {
 	return &csvPrinter{cfg: cfg}
@@ -52,7 +82,19 @@ func (p *csvPrinter) Print(entries []*ldap.Entry) error {
 
 // StreamPrint outputs LDAP entries in CSV long format as they arrive (one row per attribute).
 // Each row contains: DN, attribute name, attribute value.
+// With CSVWide, entries are collected and written in wide format instead,
+// since the columns are only known once all entries have arrived.
 func (p *csvPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	if p.cfg.CSVWide {
+		var entries []*ldap.Entry
+		for entry := range entriesChan {
+			if entry != nil {
+				entries = append(entries, entry)
+			}
+		}
+		return p.Print(entries)
+	}
+
 	writer, closeFn, err := p.createWriter()
 	if err != nil {
 		return err
@@ -113,7 +155,7 @@ func (p *csvPrinter) buildRow(entry *ldap.Entry, header []string) []string {
 
 // writeEntry writes an LDAP entry to CSV in long format (one row per attribute).
 // Each row contains: DN, attribute name, attribute value.
-func (p *csvPrinter) writeEntry(writer *csv.Writer, entry *ldap.Entry) error {
+func (p *csvPrinter) writeEntry(writer csvRowWriter, entry *ldap.Entry) error {
 	attrs := formatEntryAttributes(entry)
 
 	// Extract and sort attribute names for consistent output
@@ -133,33 +175,92 @@ func (p *csvPrinter) writeEntry(writer *csv.Writer, entry *ldap.Entry) error {
 	return nil
 }
 
-// createWriter creates a CSV writer and a cleanup function.
+// createWriter creates a CSV writer for the configured dialect and a cleanup function.
 // If Path is empty, writes to stdout. Otherwise, creates/overwrites the specified file.
 // The returned cleanup function flushes and closes the writer/file.
-func (p *csvPrinter) createWriter() (*csv.Writer, func(), error) {
-	if p.cfg.Path == "" {
-		writer := csv.NewWriter(os.Stdout)
-		return writer, func() {
-			writer.Flush()
-			if err := writer.Error(); err != nil {
-				fmt.Fprintf(os.Stderr, "Error flushing CSV writer: %v\n", err)
-			}
-		}, nil
+func (p *csvPrinter) createWriter() (csvRowWriter, func(), error) {
+	delimiter, err := ParseCSVDelimiter(p.cfg.CSVDelimiter)
+	if err != nil {
+		return nil, nil, err
 	}
 
-	file, err := os.Create(p.cfg.Path)
+	w, closeFn, err := createOutput(p.cfg)
 	if err != nil {
-		return nil, nil, fmt.Errorf("failed to create CSV file: %w", err)
+		return nil, nil, err
 	}
 
-	writer := csv.NewWriter(file)
+	if p.cfg.CSVBOM {
+		if _, err := io.WriteString(w, utf8BOM); err != nil {
+			closeFn()
+			return nil, nil, fmt.Errorf("failed to write BOM: %w", err)
+		}
+	}
+
+	var writer csvRowWriter
+	if p.cfg.CSVQuoteAll {
+		writer = newQuotingWriter(w, delimiter, p.cfg.CSVCRLF)
+	} else {
+		cw := csv.NewWriter(w)
+		cw.Comma = delimiter
+		cw.UseCRLF = p.cfg.CSVCRLF
+		writer = cw
+	}
 	return writer, func() {
 		writer.Flush()
 		if err := writer.Error(); err != nil {
 			fmt.Fprintf(os.Stderr, "Error flushing CSV writer: %v\n", err)
 		}
-		if err := file.Close(); err != nil {
-			fmt.Fprintf(os.Stderr, "Error closing CSV file: %v\n", err)
-		}
+		closeFn()
 	}, nil
 }
+
+// quotingWriter writes CSV records with every field quoted, which
+// encoding/csv does not support. Quoting everything keeps Excel from
+// reinterpreting values such as SIDs, GUIDs or leading zeros.
+type quotingWriter struct {
+	buf       *strings.Builder
+	out       io.Writer
+	delimiter string
+	newline   string
+	err       error
+}
+
+// newQuotingWriter creates a writer that quotes all fields
+func newQuotingWriter(w io.Writer, delimiter rune, crlf bool) *quotingWriter {
+	newline := "\n"
+	if crlf {
+		newline = "\r\n"
+	}
+	return &quotingWriter{buf: &strings.Builder{}, out: w, delimiter: string(delimiter), newline: newline}
+}
+
+// Write buffers one record; it is written on Flush.
+func (q *quotingWriter) Write(record []string) error {
+	if q.err != nil {
+		return q.err
+	}
+	for i, field := range record {
+		if i > 0 {
+			q.buf.WriteString(q.delimiter)
+		}
+		q.buf.WriteString(`"`)
+		q.buf.WriteString(strings.ReplaceAll(field, `"`, `""`))
+		q.buf.WriteString(`"`)
+	}
+	q.buf.WriteString(q.newline)
+	return nil
+}
+
+// Flush writes buffered records to the underlying writer.
+func (q *quotingWriter) Flush() {
+	if q.err != nil || q.buf.Len() == 0 {
+		return
+	}
+	_, q.err = io.WriteString(q.out, q.buf.String())
+	q.buf.Reset()
+}
+
+// Error reports any error from a previous Write or Flush.
+func (q *quotingWriter) Error() error {
+	return q.err
+}
@@ -0,0 +1,96 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// dotNodeStyles maps node kinds to Graphviz node attributes
+var dotNodeStyles = map[string]string{
+	nodeUser:     `shape=ellipse, fillcolor="#dbeafe"`,
+	nodeComputer: `shape=box, fillcolor="#dcfce7"`,
+	nodeGroup:    `shape=box, style="rounded,filled", fillcolor="#fef9c3"`,
+	nodeDomain:   `shape=doubleoctagon, fillcolor="#ede9fe"`,
+	nodeOther:    `shape=note, fillcolor="#f3f4f6"`,
+}
+
+// dotPrinter outputs group membership, delegation and trust relationships
+// as a Graphviz digraph, e.g. for `dot -Tpng -o graph.png`.
+type dotPrinter struct {
+	cfg PrinterConfig
+}
+
+// newDOTPrinter creates a new DOT printer instance.
+func newDOTPrinter(cfg PrinterConfig) Printer {
+	return &dotPrinter{cfg: cfg}
+}
+
+// Print writes the relationship graph of the entries.
+func (p *dotPrinter) Print(entries []*ldap.Entry) error {
+	g := newRelationGraph()
+	for _, e := range entries {
+		if e != nil {
+			g.add(e)
+		}
+	}
+	return p.write(g)
+}
+
+// StreamPrint builds the graph as entries arrive and writes it at the end.
+func (p *dotPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	g := newRelationGraph()
+	for e := range entriesChan {
+		if e != nil {
+			g.add(e)
+		}
+	}
+	return p.write(g)
+}
+
+// write renders the graph
+func (p *dotPrinter) write(g *relationGraph) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	g.resolve()
+	writeDOT(w, g)
+	return nil
+}
+
+// writeDOT renders g in the Graphviz DOT language
+func writeDOT(w io.Writer, g *relationGraph) {
+	fmt.Fprintln(w, "digraph adgo {")
+	fmt.Fprintln(w, "  rankdir=LR;")
+	fmt.Fprintln(w, `  node [style=filled, fontname="Helvetica", fontsize=10];`)
+	fmt.Fprintln(w, `  edge [fontname="Helvetica", fontsize=8];`)
+
+	for _, id := range g.order {
+		n := g.nodes[id]
+		style := dotNodeStyles[n.Kind]
+		if n.Unconstrained {
+			style += `, color="#dc2626", penwidth=2`
+		}
+		fmt.Fprintf(w, "  %s [label=%s, %s];\n", dotQuote(n.ID), dotQuote(n.Label), style)
+	}
+
+	for _, e := range g.edges {
+		attrs := "label=" + dotQuote(e.Label)
+		if e.Both {
+			attrs += ", dir=both"
+		}
+		fmt.Fprintf(w, "  %s -> %s [%s];\n", dotQuote(e.From), dotQuote(e.To), attrs)
+	}
+
+	fmt.Fprintln(w, "}")
+}
+
+// dotQuote returns s as a quoted DOT identifier
+func dotQuote(s string) string {
+	return `"` + strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`).Replace(s) + `"`
+}
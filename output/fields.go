@@ -0,0 +1,71 @@
+package output
+
+import (
+	"slices"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// fieldsPrinter trims entry attributes to an include list and/or drops an
+// exclude list before handing entries to the wrapped printer.
+type fieldsPrinter struct {
+	next    Printer
+	include []string
+	exclude []string
+}
+
+// newFieldsPrinter wraps next so it only sees the selected attributes
+func newFieldsPrinter(next Printer, include, exclude []string) Printer {
+	return &fieldsPrinter{next: next, include: include, exclude: exclude}
+}
+
+// keep reports whether an attribute passes the include and exclude lists
+func (p *fieldsPrinter) keep(name string) bool {
+	match := func(f string) bool { return strings.EqualFold(f, name) }
+	if len(p.include) > 0 && !slices.ContainsFunc(p.include, match) {
+		return false
+	}
+	return !slices.ContainsFunc(p.exclude, match)
+}
+
+// trim returns a copy of the entry with only the kept attributes
+func (p *fieldsPrinter) trim(e *ldap.Entry) *ldap.Entry {
+	out := &ldap.Entry{DN: e.DN}
+	for _, attr := range e.Attributes {
+		if p.keep(attr.Name) {
+			out.Attributes = append(out.Attributes, attr)
+		}
+	}
+	return out
+}
+
+// Print trims entries and prints them.
+func (p *fieldsPrinter) Print(entries []*ldap.Entry) error {
+	trimmed := make([]*ldap.Entry, 0, len(entries))
+	for _, e := range entries {
+		if e != nil {
+			trimmed = append(trimmed, p.trim(e))
+		}
+	}
+	return p.next.Print(trimmed)
+}
+
+// StreamPrint trims entries as they arrive and streams them.
+func (p *fieldsPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	trimmed := make(chan *ldap.Entry)
+	go func() {
+		defer close(trimmed)
+		for e := range entriesChan {
+			if e != nil {
+				trimmed <- p.trim(e)
+			}
+		}
+	}()
+
+	err := p.next.StreamPrint(trimmed)
+	// Drain so the search is not blocked if the printer stopped early
+	for range trimmed {
+	}
+	return err
+}
@@ -0,0 +1,297 @@
+package output
+
+import (
+	"adgo/analyze"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Graph node kinds
+const (
+	nodeUser     = "user"
+	nodeComputer = "computer"
+	nodeGroup    = "group"
+	nodeDomain   = "domain"
+	nodeOther    = "other"
+)
+
+// Graph edge labels
+const (
+	edgeMemberOf          = "MemberOf"
+	edgeAllowedToDelegate = "AllowedToDelegate"
+	edgeAllowedToAct      = "AllowedToAct"
+	edgeTrusts            = "Trusts"
+)
+
+// graphNode is an object in a relationship graph
+type graphNode struct {
+	ID            string // Stable identifier (lowercased DN, "domain:", "host:" or "sid:" key)
+	Label         string // Display name
+	Kind          string // One of the node kinds
+	Unconstrained bool   // Trusted for unconstrained delegation
+}
+
+// graphEdge is a directed relationship between two nodes
+type graphEdge struct {
+	From  string
+	To    string
+	Label string
+	Both  bool // Relationship applies in both directions (bidirectional trusts)
+}
+
+// relationGraph collects group membership, delegation and trust
+// relationships from LDAP entries for the diagram formats
+type relationGraph struct {
+	nodes map[string]*graphNode
+	order []string // Node IDs in insertion order, for stable output
+	edges []graphEdge
+	seen  map[graphEdge]bool
+	sids  map[string]string // objectSid -> node ID
+	hosts map[string]string // lowercased dNSHostName (and short name) -> node ID
+}
+
+// graphAttributes are the attributes the diagram formats read
+var graphAttributes = []string{
+	analyze.AttrObjectClass,
+	analyze.AttrObjectSID,
+	analyze.AttrSAMAccountName,
+	analyze.AttrDNSHostName,
+	analyze.AttrUserAccountControl,
+	analyze.AttrMember,
+	analyze.AttrMemberOf,
+	analyze.AttrMSDSAllowedToDelegateTo,
+	analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity,
+	analyze.AttrTrustPartner,
+	analyze.AttrTrustDirection,
+}
+
+// newRelationGraph creates an empty graph
+func newRelationGraph() *relationGraph {
+	return &relationGraph{
+		nodes: make(map[string]*graphNode),
+		seen:  make(map[graphEdge]bool),
+		sids:  make(map[string]string),
+		hosts: make(map[string]string),
+	}
+}
+
+// add records an entry and its relationships
+func (g *relationGraph) add(e *ldap.Entry) {
+	classes := e.GetEqualFoldAttributeValues(analyze.AttrObjectClass)
+
+	// Trusted domain objects describe a domain-to-domain edge, not a node of their own
+	if partner := e.GetEqualFoldAttributeValue(analyze.AttrTrustPartner); partner != "" {
+		g.addTrust(e, partner)
+		return
+	}
+
+	id := strings.ToLower(e.DN)
+	node := g.node(id, entryLabel(e), entryKind(e.DN, classes))
+	if raw := e.GetEqualFoldRawAttributeValue(analyze.AttrObjectSID); len(raw) > 0 {
+		if sid, err := analyze.ParseObjectSID(raw); err == nil {
+			g.sids[sid] = id
+		}
+	}
+	if host := strings.ToLower(e.GetEqualFoldAttributeValue(analyze.AttrDNSHostName)); host != "" {
+		g.hosts[host] = id
+		// SPNs often name the host without its domain
+		if short, _, ok := strings.Cut(host, "."); ok {
+			if _, exists := g.hosts[short]; !exists {
+				g.hosts[short] = id
+			}
+		}
+	}
+	if uac, err := strconv.ParseUint(e.GetEqualFoldAttributeValue(analyze.AttrUserAccountControl), 10, 32); err == nil {
+		node.Unconstrained = uac&analyze.UF_TRUSTED_FOR_DELEGATION != 0
+	}
+
+	for _, member := range e.GetEqualFoldAttributeValues(analyze.AttrMember) {
+		memberDN, _, _ := analyze.ParseExtendedDN(member)
+		memberID := strings.ToLower(memberDN)
+		g.node(memberID, dnLabel(memberDN), entryKind(memberDN, nil))
+		g.edge(memberID, id, edgeMemberOf, false)
+	}
+	for _, group := range e.GetEqualFoldAttributeValues(analyze.AttrMemberOf) {
+		groupDN, _, _ := analyze.ParseExtendedDN(group)
+		groupID := strings.ToLower(groupDN)
+		g.node(groupID, dnLabel(groupDN), nodeGroup)
+		g.edge(id, groupID, edgeMemberOf, false)
+	}
+
+	for _, spn := range e.GetEqualFoldAttributeValues(analyze.AttrMSDSAllowedToDelegateTo) {
+		host := spnHost(spn)
+		if host == "" {
+			continue
+		}
+		hostID := "host:" + strings.ToLower(host)
+		g.node(hostID, host, nodeComputer)
+		g.edge(id, hostID, edgeAllowedToDelegate, false)
+	}
+
+	if raw := e.GetEqualFoldRawAttributeValue(analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity); len(raw) > 0 {
+		sids, _ := analyze.ParseRBCDBinary(raw)
+		for _, sid := range sids {
+			sidID := "sid:" + sid
+			g.node(sidID, sid, nodeOther)
+			g.edge(sidID, id, edgeAllowedToAct, false)
+		}
+	}
+}
+
+// addTrust records the trust described by a trustedDomain entry
+func (g *relationGraph) addTrust(e *ldap.Entry, partner string) {
+	local := dnDomain(e.DN)
+	localID := "domain:" + strings.ToLower(local)
+	partnerID := "domain:" + strings.ToLower(partner)
+	g.node(localID, local, nodeDomain)
+	g.node(partnerID, partner, nodeDomain)
+
+	direction, _ := strconv.Atoi(e.GetEqualFoldAttributeValue(analyze.AttrTrustDirection))
+	switch direction {
+	case analyze.TRUST_DIRECTION_INBOUND:
+		g.edge(partnerID, localID, edgeTrusts, false)
+	case analyze.TRUST_DIRECTION_OUTBOUND:
+		g.edge(localID, partnerID, edgeTrusts, false)
+	case analyze.TRUST_DIRECTION_BIDIRECTIONAL:
+		g.edge(localID, partnerID, edgeTrusts, true)
+	}
+}
+
+// node returns the node with id, creating it if needed. Placeholder nodes
+// created from references are upgraded when the entry itself is added.
+func (g *relationGraph) node(id, label, kind string) *graphNode {
+	if n, ok := g.nodes[id]; ok {
+		if label != "" && n.Label != label && kind != nodeOther {
+			n.Label = label
+		}
+		if n.Kind == nodeOther {
+			n.Kind = kind
+		}
+		return n
+	}
+	n := &graphNode{ID: id, Label: label, Kind: kind}
+	g.nodes[id] = n
+	g.order = append(g.order, id)
+	return n
+}
+
+// edge records a relationship once
+func (g *relationGraph) edge(from, to, label string, both bool) {
+	e := graphEdge{From: from, To: to, Label: label, Both: both}
+	if g.seen[e] {
+		return
+	}
+	g.seen[e] = true
+	g.edges = append(g.edges, e)
+}
+
+// resolve merges placeholder nodes into the entries they refer to:
+// "sid:" nodes by objectSid and "host:" nodes by dNSHostName.
+// Call once after all entries have been added.
+func (g *relationGraph) resolve() {
+	target := func(id string) string {
+		switch {
+		case strings.HasPrefix(id, "sid:"):
+			if resolved, ok := g.sids[strings.TrimPrefix(id, "sid:")]; ok {
+				return resolved
+			}
+		case strings.HasPrefix(id, "host:"):
+			if resolved, ok := g.hosts[strings.TrimPrefix(id, "host:")]; ok {
+				return resolved
+			}
+		}
+		return id
+	}
+
+	edges := g.edges
+	g.edges = nil
+	g.seen = make(map[graphEdge]bool)
+	for _, e := range edges {
+		g.edge(target(e.From), target(e.To), e.Label, e.Both)
+	}
+
+	g.order = slices.DeleteFunc(g.order, func(id string) bool {
+		if target(id) != id {
+			delete(g.nodes, id)
+			return true
+		}
+		return false
+	})
+}
+
+// entryKind classifies an object from its classes, falling back to its DN
+func entryKind(dn string, classes []string) string {
+	hasClass := func(class string) bool {
+		return slices.ContainsFunc(classes, func(c string) bool { return strings.EqualFold(c, class) })
+	}
+	switch {
+	case hasClass("computer"):
+		return nodeComputer
+	case hasClass("group"):
+		return nodeGroup
+	case hasClass("user"):
+		return nodeUser
+	case hasClass("domainDNS"):
+		return nodeDomain
+	case len(classes) > 0:
+		return nodeOther
+	}
+
+	switch objectType(dn) {
+	case "USER":
+		return nodeUser
+	case "COMPUTER", "DC":
+		return nodeComputer
+	case "GROUP":
+		return nodeGroup
+	default:
+		return nodeOther
+	}
+}
+
+// entryLabel returns the display name of an entry
+func entryLabel(e *ldap.Entry) string {
+	if name := e.GetEqualFoldAttributeValue(analyze.AttrSAMAccountName); name != "" {
+		return name
+	}
+	return dnLabel(e.DN)
+}
+
+// dnLabel returns the value of the first RDN, e.g. "Domain Admins"
+func dnLabel(dn string) string {
+	if parsed, err := ldap.ParseDN(dn); err == nil && len(parsed.RDNs) > 0 && len(parsed.RDNs[0].Attributes) > 0 {
+		return parsed.RDNs[0].Attributes[0].Value
+	}
+	return dn
+}
+
+// dnDomain returns the DNS domain name of a DN, e.g. "example.com"
+func dnDomain(dn string) string {
+	parsed, err := ldap.ParseDN(dn)
+	if err != nil {
+		return dn
+	}
+	var parts []string
+	for _, rdn := range parsed.RDNs {
+		for _, attr := range rdn.Attributes {
+			if strings.EqualFold(attr.Type, "DC") {
+				parts = append(parts, attr.Value)
+			}
+		}
+	}
+	return strings.Join(parts, ".")
+}
+
+// spnHost returns the host of an SPN such as "cifs/srv01.example.com:445"
+func spnHost(spn string) string {
+	_, rest, ok := strings.Cut(spn, "/")
+	if !ok {
+		return ""
+	}
+	host, _, _ := strings.Cut(rest, "/")
+	host, _, _ = strings.Cut(host, ":")
+	return host
+}
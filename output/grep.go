@@ -0,0 +1,98 @@
+package output
+
+import (
+	"adgo/analyze"
+	"fmt"
+	"io"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// grepValueReplacer keeps each value on a single tab-separated line
+var grepValueReplacer = strings.NewReplacer("\t", " ", "\r", " ", "\n", " ")
+
+// grepPrinter outputs LDAP entries in a grepable format modeled on nmap -oG.
+// Each entry is one line: "DN: <dn>" followed by tab-separated key=value
+// pairs, so results can be sliced with grep, awk and cut.
+type grepPrinter struct {
+	cfg PrinterConfig
+	w   io.Writer // Destination set by Print/StreamPrint
+}
+
+// newGrepPrinter creates a new grepable printer instance.
+func newGrepPrinter(cfg PrinterConfig) Printer {
+	return &grepPrinter{cfg: cfg}
+}
+
+// Print writes each LDAP entry as a single grepable line.
+func (p *grepPrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	p.w = w
+
+	p.printHeader()
+	for _, e := range entries {
+		p.printEntry(e)
+	}
+	p.printFooter(len(entries))
+	return nil
+}
+
+// StreamPrint writes each LDAP entry as a grepable line as soon as it arrives.
+func (p *grepPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	p.w = w
+
+	p.printHeader()
+	count := 0
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		p.printEntry(e)
+		count++
+	}
+	p.printFooter(count)
+	return nil
+}
+
+// printHeader writes the leading comment line
+func (p *grepPrinter) printHeader() {
+	fmt.Fprintf(p.w, "# adgo grepable output started at %s\n", time.Now().In(analyze.TimeLocation()).Format(time.RFC3339))
+}
+
+// printFooter writes the trailing comment line with the entry count
+func (p *grepPrinter) printFooter(count int) {
+	fmt.Fprintf(p.w, "# adgo done at %s -- %d entries\n", time.Now().In(analyze.TimeLocation()).Format(time.RFC3339), count)
+}
+
+// printEntry writes one entry with its attributes sorted by name
+func (p *grepPrinter) printEntry(e *ldap.Entry) {
+	attrs := formatEntryAttributes(e)
+	names := make([]string, 0, len(attrs))
+	for name := range attrs {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString("DN: ")
+	b.WriteString(grepValueReplacer.Replace(e.DN))
+	for _, name := range names {
+		b.WriteByte('\t')
+		b.WriteString(name)
+		b.WriteByte('=')
+		b.WriteString(grepValueReplacer.Replace(attrs[name]))
+	}
+	fmt.Fprintln(p.w, b.String())
+}
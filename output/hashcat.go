@@ -0,0 +1,85 @@
+package output
+
+import (
+	"adgo/analyze"
+	"adgo/kerberos"
+	"fmt"
+	"io"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// hashcatPrinter outputs the kerberoast and asrep query results as a
+// target list for the roast tools: one sAMAccountName per line, without
+// headers, as hashcat and john reject comment lines. The list feeds
+// "roast asrep --users-file" and the usersfile options of other tools.
+// john is not a query format: without hashes its list would be the same.
+type hashcatPrinter struct {
+	cfg PrinterConfig
+}
+
+// newHashcatPrinter creates a new hashcat target list printer.
+func newHashcatPrinter(cfg PrinterConfig) Printer {
+	return &hashcatPrinter{cfg: cfg}
+}
+
+// Print writes the account name of each entry.
+func (p *hashcatPrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	for _, e := range entries {
+		if err := printTarget(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamPrint writes the account name of each entry as soon as it arrives.
+func (p *hashcatPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		if err := printTarget(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// printTarget writes the sAMAccountName of an entry, skipping entries
+// without one as no ticket can be requested for them
+func printTarget(w io.Writer, e *ldap.Entry) error {
+	name := e.GetEqualFoldAttributeValue(analyze.AttrSAMAccountName)
+	if name == "" {
+		return nil
+	}
+	_, err := fmt.Fprintln(w, name)
+	return err
+}
+
+// IsHashFormat reports whether format is the hashcat target list
+func IsHashFormat(format string) bool {
+	return format == analyze.OutputFormatHashcat
+}
+
+// FormatHash returns a hash in hashcat format as a line of a roast output
+// format, hashcat or john; john expects RC4 AS-REP hashes without the
+// encryption type
+func FormatHash(format, hash string) string {
+	if format == analyze.OutputFormatJohn {
+		return kerberos.JohnHash(hash)
+	}
+	return hash
+}
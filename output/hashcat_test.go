@@ -0,0 +1,50 @@
+package output
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// roastTargets are query results, one of them without a sAMAccountName
+var roastTargets = []*ldap.Entry{
+	ldap.NewEntry("CN=svc_sql,CN=Users,DC=example,DC=com", map[string][]string{"sAMAccountName": {"svc_sql"}}),
+	ldap.NewEntry("CN=Orphan,CN=Users,DC=example,DC=com", map[string][]string{"cn": {"Orphan"}}),
+	ldap.NewEntry("CN=jdoe,CN=Users,DC=example,DC=com", map[string][]string{"samaccountname": {"jdoe"}}),
+}
+
+func TestHashcatPrinter(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "users.txt")
+	if err := newHashcatPrinter(PrinterConfig{Path: path}).Print(roastTargets); err != nil {
+		t.Fatalf("Print: %v", err)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "svc_sql\njdoe\n"; string(data) != want {
+		t.Errorf("target list = %q, want %q", data, want)
+	}
+}
+
+func TestPrintTargetError(t *testing.T) {
+	if err := printTarget(&failingWriter{n: 3}, roastTargets[0]); !errors.Is(err, errWriteFailed) {
+		t.Errorf("printTarget = %v, want %v", err, errWriteFailed)
+	}
+	if err := printTarget(&failingWriter{}, roastTargets[1]); err != nil {
+		t.Errorf("printTarget of an entry without a name = %v", err)
+	}
+}
+
+func TestFormatHash(t *testing.T) {
+	const asrep = "$krb5asrep$23$jdoe@CORP.LOCAL:0011$2233"
+	if got := FormatHash("hashcat", asrep); got != asrep {
+		t.Errorf("FormatHash(hashcat) = %s", got)
+	}
+	if got, want := FormatHash("john", asrep), "$krb5asrep$jdoe@CORP.LOCAL:0011$2233"; got != want {
+		t.Errorf("FormatHash(john) = %s, want %s", got, want)
+	}
+}
@@ -0,0 +1,230 @@
+package output
+
+import (
+	"adgo/analyze"
+	"fmt"
+	"html/template"
+	"sort"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Severity thresholds applied to scoreTarget values
+const (
+	severityCriticalScore = 50
+	severityHighScore     = 30
+	severityMediumScore   = 15
+)
+
+// htmlPrinter outputs a single self-contained HTML report.
+// CSS and JavaScript are embedded so the file can be shared as-is.
+type htmlPrinter struct {
+	cfg PrinterConfig
+}
+
+// newHTMLPrinter creates a new HTML report printer instance.
+func newHTMLPrinter(cfg PrinterConfig) Printer {
+	return &htmlPrinter{cfg: cfg}
+}
+
+// htmlReport is the data passed to the report template.
+type htmlReport struct {
+	Title     string
+	Generated string
+	Stats     Statistics
+	Severity  []htmlSeverity
+	Entries   []htmlEntry
+}
+
+// htmlSeverity is the number of entries carrying a severity label.
+type htmlSeverity struct {
+	Label string
+	Count int
+}
+
+// severityLabels lists severity labels from most to least severe
+var severityLabels = []string{"critical", "high", "medium", "low", "info"}
+
+// htmlEntry is a single LDAP entry as rendered in the report.
+type htmlEntry struct {
+	DN         string
+	Name       string
+	Type       string
+	Score      int
+	Severity   string
+	Attributes []htmlAttribute
+}
+
+// htmlAttribute is a formatted attribute name/value pair.
+type htmlAttribute struct {
+	Name  string
+	Value string
+}
+
+// Print writes the HTML report for the given entries.
+func (p *htmlPrinter) Print(entries []*ldap.Entry) error {
+	report := htmlReport{
+		Title:     reportTitle,
+		Generated: time.Now().In(analyze.TimeLocation()).Format(time.RFC3339),
+		Stats:     collectStats(entries),
+	}
+	counts := make(map[string]int)
+
+	for _, e := range orderEntries(p.cfg, entries) {
+		attrs := formatEntryAttributes(e)
+		keys := make([]string, 0, len(attrs))
+		for k := range attrs {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+
+		entry := htmlEntry{
+			DN:    e.DN,
+			Name:  attrs["sAMAccountName"],
+			Type:  objectType(e.DN),
+			Score: scoreTarget(e),
+		}
+		if entry.Name == "" {
+			entry.Name = attrs["name"]
+		}
+		entry.Severity = severityLabel(entry.Score)
+		for _, k := range keys {
+			entry.Attributes = append(entry.Attributes, htmlAttribute{Name: k, Value: attrs[k]})
+		}
+
+		counts[entry.Severity]++
+		report.Entries = append(report.Entries, entry)
+	}
+
+	for _, label := range severityLabels {
+		report.Severity = append(report.Severity, htmlSeverity{Label: label, Count: counts[label]})
+	}
+
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if err := htmlTemplate.Execute(w, report); err != nil {
+		return fmt.Errorf("rendering HTML report: %w", err)
+	}
+	return nil
+}
+
+// StreamPrint collects entries and writes the HTML report.
+// The report needs all entries for statistics and sorting.
+func (p *htmlPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	var entries []*ldap.Entry
+	for entry := range entriesChan {
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return p.Print(entries)
+}
+
+// severityLabel maps a target score to a severity badge label.
+func severityLabel(score int) string {
+	switch {
+	case score >= severityCriticalScore:
+		return "critical"
+	case score >= severityHighScore:
+		return "high"
+	case score >= severityMediumScore:
+		return "medium"
+	case score > 0:
+		return "low"
+	default:
+		return "info"
+	}
+}
+
+// htmlTemplate renders the report. html/template escapes all LDAP values.
+var htmlTemplate = template.Must(template.New("report").Parse(`<!DOCTYPE html>
+<html lang="en">
+<head>
+<meta charset="utf-8">
+<title>{{.Title}}</title>
+<style>
+body{font-family:-apple-system,Segoe UI,Helvetica,Arial,sans-serif;margin:2em;color:#222;background:#fafafa}
+h1{margin-bottom:0}
+.meta{color:#777;margin-top:.2em}
+.stats{display:flex;flex-wrap:wrap;gap:1em;margin:1.5em 0}
+.stat{background:#fff;border:1px solid #ddd;border-radius:6px;padding:.8em 1.2em;min-width:8em}
+.stat b{display:block;font-size:1.6em}
+table{border-collapse:collapse;width:100%;background:#fff}
+th,td{border:1px solid #ddd;padding:.4em .6em;text-align:left;vertical-align:top}
+th{background:#f0f0f0;cursor:pointer;user-select:none}
+th.asc:after{content:" \25B2"}
+th.desc:after{content:" \25BC"}
+td.dn{font-family:monospace;font-size:.9em;word-break:break-all}
+.badge{display:inline-block;padding:.1em .6em;border-radius:1em;color:#fff;font-size:.85em;text-transform:uppercase}
+.critical{background:#b71c1c}.high{background:#e65100}.medium{background:#f9a825}.low{background:#1565c0}.info{background:#757575}
+details table{margin:.4em 0 .8em}
+details td:first-child{white-space:nowrap;font-weight:bold}
+#filter{padding:.4em;width:20em;margin-bottom:.8em}
+</style>
+</head>
+<body>
+<h1>{{.Title}}</h1>
+<p class="meta">Generated {{.Generated}}</p>
+
+<h2>Statistics</h2>
+<div class="stats">
+<div class="stat"><b>{{.Stats.Total}}</b>Total</div>
+<div class="stat"><b>{{.Stats.Admins}}</b>Admins</div>
+<div class="stat"><b>{{.Stats.SPN}}</b>SPN Accounts</div>
+<div class="stat"><b>{{.Stats.ASRep}}</b>AS-REP Roastable</div>
+<div class="stat"><b>{{.Stats.DCs}}</b>Domain Controllers</div>
+<div class="stat"><b>{{.Stats.Enabled}}</b>Enabled</div>
+<div class="stat"><b>{{.Stats.Disabled}}</b>Disabled</div>
+</div>
+<div class="stats">
+{{range .Severity}}<div class="stat"><b>{{.Count}}</b><span class="badge {{.Label}}">{{.Label}}</span></div>
+{{end}}</div>
+
+<h2>Entries</h2>
+<input id="filter" type="search" placeholder="Filter entries...">
+<table id="entries">
+<thead><tr><th data-type="num">Score</th><th>Severity</th><th>Type</th><th>Name</th><th>DN</th></tr></thead>
+<tbody>
+{{range .Entries}}<tr>
+<td>{{.Score}}</td>
+<td><span class="badge {{.Severity}}">{{.Severity}}</span></td>
+<td>{{.Type}}</td>
+<td>{{.Name}}</td>
+<td class="dn"><details><summary>{{.DN}}</summary><table>{{range .Attributes}}<tr><td>{{.Name}}</td><td>{{.Value}}</td></tr>{{end}}</table></details></td>
+</tr>
+{{end}}</tbody>
+</table>
+
+<script>
+(function(){
+  var table=document.getElementById("entries"),body=table.tBodies[0];
+  Array.prototype.forEach.call(table.tHead.rows[0].cells,function(th,col){
+    th.addEventListener("click",function(){
+      var asc=!th.classList.contains("asc"),num=th.dataset.type==="num";
+      Array.prototype.forEach.call(th.parentNode.cells,function(c){c.classList.remove("asc","desc")});
+      th.classList.add(asc?"asc":"desc");
+      var rows=Array.prototype.slice.call(body.rows);
+      rows.sort(function(a,b){
+        var x=a.cells[col].textContent.trim(),y=b.cells[col].textContent.trim();
+        var r=num?(parseFloat(x)-parseFloat(y)):x.localeCompare(y);
+        return asc?r:-r;
+      });
+      rows.forEach(function(r){body.appendChild(r)});
+    });
+  });
+  document.getElementById("filter").addEventListener("input",function(e){
+    var q=e.target.value.toLowerCase();
+    Array.prototype.forEach.call(body.rows,function(r){
+      r.style.display=r.textContent.toLowerCase().indexOf(q)>=0?"":"none";
+    });
+  });
+})();
+</script>
+</body>
+</html>
+`))
@@ -1,10 +1,11 @@
 package output
 
 import (
+	"adgo/analyze"
 	"bufio"
+	"encoding/base64"
 	"encoding/json"
 	"fmt"
-	"os"
 	"time"
 
 	"github.com/go-ldap/ldap/v3"
@@ -29,13 +30,42 @@ type jsonMeta struct {
 
 // jsonSummary contains summary statistics about the output.
 type jsonSummary struct {
-	Count int `json:"count"` // Number of entries output
+	Count   int    `json:"count"`             // Number of entries output
+	Partial bool   `json:"partial,omitempty"` // The search failed mid-stream; data is incomplete
+	Error   string `json:"error,omitempty"`   // Why the search failed
 }
 
 // jsonEntry represents a single LDAP entry in JSON format.
 type jsonEntry struct {
-	DN         string            `json:"dn"`         // Distinguished Name of the entry
-	Attributes map[string]string `json:"attributes"` // Formatted attributes as key-value pairs
+	DN         string              `json:"dn"`            // Distinguished Name of the entry
+	Attributes map[string]string   `json:"attributes"`    // Formatted attributes as key-value pairs
+	Raw        map[string][]string `json:"raw,omitempty"` // Base64 values of binary attributes, with PrinterConfig.JSONRaw
+}
+
+// newJSONEntry converts an LDAP entry for the json and jsonl formats. With
+// raw, binary attributes also keep their values as returned by the server,
+// base64-encoded, so they can be parsed again: known binary attributes
+// such as objectSid and nTSecurityDescriptor, and any other attribute with
+// a value that is not valid UTF-8.
+func newJSONEntry(e *ldap.Entry, raw bool) jsonEntry {
+	je := jsonEntry{DN: e.DN, Attributes: formatEntryAttributes(e)}
+	if !raw {
+		return je
+	}
+	for _, attr := range e.Attributes {
+		if !analyze.IsBinaryAttribute(attr.Name) && isTextValues(attr.ByteValues) {
+			continue
+		}
+		if je.Raw == nil {
+			je.Raw = make(map[string][]string)
+		}
+		values := make([]string, len(attr.ByteValues))
+		for i, v := range attr.ByteValues {
+			values[i] = base64.StdEncoding.EncodeToString(v)
+		}
+		je.Raw[attr.Name] = values
+	}
+	return je
 }
 
 // Print outputs LDAP entries in JSON format with metadata and summary.
@@ -43,10 +73,7 @@ type jsonEntry struct {
 func (p *jsonPrinter) Print(entries []*ldap.Entry) error {
 	data := make([]jsonEntry, 0, len(entries))
 	for _, e := range entries {
-		data = append(data, jsonEntry{
-			DN:         e.DN,
-			Attributes: p.toMap(e),
-		})
+		data = append(data, newJSONEntry(e, p.cfg.JSONRaw))
 	}
 
 	output := struct {
@@ -56,26 +83,38 @@ func (p *jsonPrinter) Print(entries []*ldap.Entry) error {
 	}{
 		Meta: jsonMeta{
 			Version:   "1.0",
-			Timestamp: time.Now().Format(time.RFC3339),
+			Timestamp: time.Now().In(analyze.TimeLocation()).Format(time.RFC3339),
 		},
 		Data:    data,
 		Summary: jsonSummary{Count: len(entries)},
 	}
 
-	enc := json.NewEncoder(os.Stdout)
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	enc := json.NewEncoder(w)
 	enc.SetIndent("", "  ")
 	return enc.Encode(output)
 }
 
-// StreamPrint writes LDAP entries to stdout in JSON format as they arrive.
+// StreamPrint writes LDAP entries in JSON format as they arrive.
 // It outputs a streaming JSON structure with metadata, entries array, and summary.
 func (p *jsonPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
-	w := bufio.NewWriter(os.Stdout)
+	out, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	w := bufio.NewWriter(out)
 	defer w.Flush()
 
 	m := jsonMeta{
 		Version:   "1.0",
-		Timestamp: time.Now().Format(time.RFC3339),
+		Timestamp: time.Now().In(analyze.TimeLocation()).Format(time.RFC3339),
 	}
 
 	if _, err := w.WriteString("{\n  \"meta\": "); err != nil {
@@ -105,18 +144,25 @@ func (p *jsonPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
 		if _, err := w.WriteString("    "); err != nil {
 			return err
 		}
-		if err := p.write(w, jsonEntry{
-			DN:         e.DN,
-			Attributes: p.toMap(e),
-		}); err != nil {
+		if err := p.write(w, newJSONEntry(e, p.cfg.JSONRaw)); err != nil {
 			return err
 		}
 	}
 
+	// The document is closed even if the search failed, with the summary
+	// marking the data as partial
+	summary := jsonSummary{Count: count}
+	if p.cfg.StreamErr != nil {
+		if err := p.cfg.StreamErr(); err != nil {
+			summary.Partial = true
+			summary.Error = err.Error()
+		}
+	}
+
 	if _, err := w.WriteString("\n  ],\n  \"summary\": "); err != nil {
 		return err
 	}
-	if err := p.write(w, jsonSummary{Count: count}); err != nil {
+	if err := p.write(w, summary); err != nil {
 		return err
 	}
 	if _, err := w.WriteString("\n}\n"); err != nil {
@@ -125,12 +171,6 @@ func (p *jsonPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
 	return w.Flush()
 }
 
-// toMap converts an LDAP entry to a map of formatted attributes.
-// It uses the shared formatEntryAttributes function for consistency.
-func (p *jsonPrinter) toMap(e *ldap.Entry) map[string]string {
-	return formatEntryAttributes(e)
-}
-
 // write marshals a value to JSON and writes it to the buffer.
 // Returns an error if marshaling or writing fails.
 func (p *jsonPrinter) write(w *bufio.Writer, v interface{}) error {
@@ -0,0 +1,61 @@
+package output
+
+import (
+	"encoding/json"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// jsonlPrinter outputs LDAP entries as JSON Lines (NDJSON).
+// Each entry is written as one self-contained JSON object per line,
+// so output can be piped into stream processors without buffering.
+type jsonlPrinter struct {
+	cfg PrinterConfig
+}
+
+// newJSONLPrinter creates a new JSON Lines printer instance.
+func newJSONLPrinter(cfg PrinterConfig) Printer {
+	return &jsonlPrinter{cfg: cfg}
+}
+
+// Print writes each LDAP entry as a single JSON line.
+func (p *jsonlPrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	enc := json.NewEncoder(w)
+	for _, e := range entries {
+		if err := p.encode(enc, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamPrint writes each LDAP entry as a JSON line as soon as it arrives.
+func (p *jsonlPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	enc := json.NewEncoder(w)
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		if err := p.encode(enc, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// encode writes one entry; json.Encoder terminates each value with a newline.
+func (p *jsonlPrinter) encode(enc *json.Encoder, e *ldap.Entry) error {
+	return enc.Encode(newJSONEntry(e, p.cfg.JSONRaw))
+}
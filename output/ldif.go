@@ -0,0 +1,125 @@
+package output
+
+import (
+	"encoding/base64"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ldifLineWidth is the column at which LDIF lines are folded (RFC 2849)
+const ldifLineWidth = 76
+
+// ldifPrinter outputs LDAP entries as LDIF content records (RFC 2849).
+// Values that are not safe strings are base64-encoded, so binary
+// attributes survive a round trip through ReadEntries.
+type ldifPrinter struct {
+	cfg PrinterConfig
+}
+
+// newLDIFPrinter creates a new LDIF printer instance.
+func newLDIFPrinter(cfg PrinterConfig) Printer {
+	return &ldifPrinter{cfg: cfg}
+}
+
+// Print writes each LDAP entry as an LDIF record.
+func (p *ldifPrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if _, err := fmt.Fprintln(w, "version: 1"); err != nil {
+		return err
+	}
+	for _, e := range entries {
+		if err := writeLDIFEntry(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamPrint writes each LDAP entry as an LDIF record as soon as it arrives.
+func (p *ldifPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if _, err := fmt.Fprintln(w, "version: 1"); err != nil {
+		return err
+	}
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		if err := writeLDIFEntry(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeLDIFEntry writes one record, preceded by the blank separator line,
+// with attributes sorted by name
+func writeLDIFEntry(w io.Writer, e *ldap.Entry) error {
+	if _, err := fmt.Fprintln(w); err != nil {
+		return err
+	}
+	if err := writeLDIFLine(w, "dn", []byte(e.DN)); err != nil {
+		return err
+	}
+
+	attrs := make([]*ldap.EntryAttribute, len(e.Attributes))
+	copy(attrs, e.Attributes)
+	sort.SliceStable(attrs, func(i, j int) bool { return attrs[i].Name < attrs[j].Name })
+	for _, attr := range attrs {
+		for _, v := range attr.ByteValues {
+			if err := writeLDIFLine(w, attr.Name, v); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// writeLDIFLine writes "name: value", or "name:: base64" for values that
+// are not safe strings, folded at ldifLineWidth
+func writeLDIFLine(w io.Writer, name string, value []byte) error {
+	line := name + ": " + string(value)
+	if !isLDIFSafe(value) {
+		line = name + ":: " + base64.StdEncoding.EncodeToString(value)
+	}
+
+	for len(line) > ldifLineWidth {
+		if _, err := fmt.Fprintln(w, line[:ldifLineWidth]); err != nil {
+			return err
+		}
+		line = " " + line[ldifLineWidth:]
+	}
+	_, err := fmt.Fprintln(w, line)
+	return err
+}
+
+// isLDIFSafe reports whether v is a SAFE-STRING of RFC 2849: printable
+// ASCII without NUL, CR or LF, not starting with a space, colon or '<'
+// and not ending with a space
+func isLDIFSafe(v []byte) bool {
+	if len(v) == 0 {
+		return true
+	}
+	if v[0] == ' ' || v[0] == ':' || v[0] == '<' || v[len(v)-1] == ' ' {
+		return false
+	}
+	for _, b := range v {
+		if b == 0 || b == '\r' || b == '\n' || b > 0x7f {
+			return false
+		}
+	}
+	return true
+}
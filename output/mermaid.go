@@ -0,0 +1,103 @@
+package output
+
+import (
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// mermaidNodeShapes maps node kinds to Mermaid flowchart shape delimiters
+var mermaidNodeShapes = map[string][2]string{
+	nodeUser:     {"([", "])"},
+	nodeComputer: {"[", "]"},
+	nodeGroup:    {"{{", "}}"},
+	nodeDomain:   {"[[", "]]"},
+	nodeOther:    {"[/", "/]"},
+}
+
+// mermaidPrinter outputs group membership, delegation and trust relationships
+// as a Mermaid flowchart that can be pasted into Markdown reports and wikis.
+type mermaidPrinter struct {
+	cfg PrinterConfig
+}
+
+// newMermaidPrinter creates a new Mermaid printer instance.
+func newMermaidPrinter(cfg PrinterConfig) Printer {
+	return &mermaidPrinter{cfg: cfg}
+}
+
+// Print writes the relationship graph of the entries.
+func (p *mermaidPrinter) Print(entries []*ldap.Entry) error {
+	g := newRelationGraph()
+	for _, e := range entries {
+		if e != nil {
+			g.add(e)
+		}
+	}
+	return p.write(g)
+}
+
+// StreamPrint builds the graph as entries arrive and writes it at the end.
+func (p *mermaidPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	g := newRelationGraph()
+	for e := range entriesChan {
+		if e != nil {
+			g.add(e)
+		}
+	}
+	return p.write(g)
+}
+
+// write renders the graph
+func (p *mermaidPrinter) write(g *relationGraph) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	g.resolve()
+	writeMermaid(w, g)
+	return nil
+}
+
+// writeMermaid renders g as a Mermaid flowchart. Mermaid IDs cannot contain
+// DN punctuation, so nodes are numbered in output order.
+func writeMermaid(w io.Writer, g *relationGraph) {
+	ids := make(map[string]string, len(g.order))
+
+	fmt.Fprintln(w, "flowchart LR")
+	for i, id := range g.order {
+		n := g.nodes[id]
+		ids[id] = fmt.Sprintf("n%d", i)
+		shape := mermaidNodeShapes[n.Kind]
+		fmt.Fprintf(w, "    %s%s%s%s\n", ids[id], shape[0], mermaidQuote(n.Label), shape[1])
+	}
+
+	for _, e := range g.edges {
+		arrow := "-->"
+		if e.Both {
+			arrow = "<-->"
+		}
+		fmt.Fprintf(w, "    %s %s|%s| %s\n", ids[e.From], arrow, e.Label, ids[e.To])
+	}
+
+	var unconstrained []string
+	for _, id := range g.order {
+		if g.nodes[id].Unconstrained {
+			unconstrained = append(unconstrained, ids[id])
+		}
+	}
+	if len(unconstrained) > 0 {
+		fmt.Fprintln(w, "    classDef unconstrained stroke:#dc2626,stroke-width:3px")
+		fmt.Fprintf(w, "    class %s unconstrained\n", strings.Join(unconstrained, ","))
+	}
+}
+
+// mermaidQuote returns s as a quoted Mermaid label. Double quotes cannot be
+// escaped with a backslash, so they are written as an HTML entity.
+func mermaidQuote(s string) string {
+	return `"` + strings.NewReplacer(`"`, "#quot;", "\n", " ").Replace(s) + `"`
+}
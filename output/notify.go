@@ -0,0 +1,222 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// notifyMaxFindings caps the findings included in a notification
+const notifyMaxFindings = 20
+
+// notifyPrinter passes entries through to the next printer and POSTs a
+// summary to a webhook once output is complete. With a minimum score, only
+// entries scoring at or above it are reported, and nothing is sent when
+// there are none.
+type notifyPrinter struct {
+	next     Printer
+	url      string
+	minScore int
+	query    string
+	client   *http.Client
+}
+
+// notifyFinding is a single high-value entry in a notification
+type notifyFinding struct {
+	DN       string `json:"dn"`
+	Name     string `json:"name"`
+	Type     string `json:"type"`
+	Score    int    `json:"score"`
+	Severity string `json:"severity"`
+}
+
+// notifyPayload is the JSON body sent to generic webhooks. Slack and Teams
+// only receive Text.
+type notifyPayload struct {
+	Text     string          `json:"text"`
+	Tool     string          `json:"tool"`
+	Query    string          `json:"query,omitempty"`
+	Time     time.Time       `json:"time"`
+	Total    int             `json:"total"`
+	MinScore int             `json:"minScore,omitempty"`
+	Findings []notifyFinding `json:"findings,omitempty"`
+}
+
+// newNotifyPrinter wraps next so a webhook is notified after it finishes
+func newNotifyPrinter(next Printer, url string, minScore int, query string) Printer {
+	return &notifyPrinter{
+		next:     next,
+		url:      url,
+		minScore: minScore,
+		query:    query,
+		client:   &http.Client{Timeout: sinkTimeout},
+	}
+}
+
+// Print prints the entries, then sends the notification.
+func (p *notifyPrinter) Print(entries []*ldap.Entry) error {
+	if err := p.next.Print(entries); err != nil {
+		return err
+	}
+
+	var findings []notifyFinding
+	for _, e := range entries {
+		if f, ok := p.finding(e); ok {
+			findings = append(findings, f)
+		}
+	}
+	p.notify(len(entries), findings)
+	return nil
+}
+
+// StreamPrint streams the entries to the next printer, keeping only the
+// findings, then sends the notification.
+func (p *notifyPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	ch := make(chan *ldap.Entry)
+	total := 0
+	var findings []notifyFinding
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		defer close(ch)
+		for e := range entriesChan {
+			if e == nil {
+				continue
+			}
+			total++
+			if f, ok := p.finding(e); ok {
+				findings = append(findings, f)
+			}
+			ch <- e
+		}
+	}()
+
+	err := p.next.StreamPrint(ch)
+	// Drain in case the next printer stopped early
+	for range ch {
+	}
+	<-done
+	if err != nil {
+		return err
+	}
+
+	p.notify(total, findings)
+	return nil
+}
+
+// finding returns the entry as a finding if it meets the minimum score
+func (p *notifyPrinter) finding(e *ldap.Entry) (notifyFinding, bool) {
+	if p.minScore <= 0 {
+		return notifyFinding{}, false
+	}
+	score := scoreTarget(e)
+	if score < p.minScore {
+		return notifyFinding{}, false
+	}
+
+	name := e.GetAttributeValue("sAMAccountName")
+	if name == "" {
+		name = e.GetAttributeValue("name")
+	}
+	return notifyFinding{
+		DN:       e.DN,
+		Name:     name,
+		Type:     objectType(e.DN),
+		Score:    score,
+		Severity: severityLabel(score),
+	}, true
+}
+
+// notify sends the summary. Failures are reported on stderr but do not fail
+// the command, since the results have already been written.
+func (p *notifyPrinter) notify(total int, findings []notifyFinding) {
+	if p.minScore > 0 && len(findings) == 0 {
+		return
+	}
+	if err := p.send(total, findings); err != nil {
+		fmt.Fprintf(os.Stderr, "Error sending webhook notification: %v\n", err)
+	}
+}
+
+// send builds the payload for the webhook type and POSTs it
+func (p *notifyPrinter) send(total int, findings []notifyFinding) error {
+	sort.SliceStable(findings, func(i, j int) bool {
+		return findings[i].Score > findings[j].Score
+	})
+	matched := len(findings)
+	if len(findings) > notifyMaxFindings {
+		findings = findings[:notifyMaxFindings]
+	}
+
+	payload := notifyPayload{
+		Text:     notifyText(p.query, total, matched, p.minScore, findings),
+		Tool:     sinkSource,
+		Query:    p.query,
+		Time:     time.Now().UTC(),
+		Total:    total,
+		MinScore: p.minScore,
+		Findings: findings,
+	}
+
+	var body any = payload
+	if isChatWebhook(p.url) {
+		body = map[string]string{"text": payload.Text}
+	}
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+	enc.SetEscapeHTML(false)
+	if err := enc.Encode(body); err != nil {
+		return err
+	}
+
+	resp, err := p.client.Post(p.url, "application/json", &buf)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		respBody, _ := io.ReadAll(io.LimitReader(resp.Body, 4096))
+		return fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(respBody)))
+	}
+	return nil
+}
+
+// notifyText renders the human-readable summary used as the message text
+func notifyText(query string, total, matched, minScore int, findings []notifyFinding) string {
+	if query == "" {
+		query = "query"
+	}
+
+	var sb strings.Builder
+	fmt.Fprintf(&sb, "adgo %s: %d entries", query, total)
+	if minScore > 0 {
+		fmt.Fprintf(&sb, ", %d with score >= %d", matched, minScore)
+	}
+	for _, f := range findings {
+		fmt.Fprintf(&sb, "\n[%s] %s (%s, score %d)", strings.ToUpper(f.Severity), f.Name, f.Type, f.Score)
+	}
+	if matched > len(findings) {
+		fmt.Fprintf(&sb, "\n... and %d more", matched-len(findings))
+	}
+	return sb.String()
+}
+
+// isChatWebhook reports whether url is a Slack or Microsoft Teams webhook,
+// which expect a {"text": ...} message rather than the full payload
+func isChatWebhook(url string) bool {
+	for _, host := range []string{"hooks.slack.com", "webhook.office.com", ".logic.azure.com", "outlook.office.com"} {
+		if strings.Contains(url, host) {
+			return true
+		}
+	}
+	return false
+}
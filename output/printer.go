@@ -3,14 +3,52 @@ package output
 import (
 	"adgo/analyze"
 	"fmt"
+	"io"
+	"os"
 
 	"github.com/go-ldap/ldap/v3"
 )
 
 // PrinterConfig defines configuration options for output printers.
 type PrinterConfig struct {
-	Format string // Output format: "text", "json", or "csv"
-	Path   string // Optional file path. If empty, writes to stdout
+	Format        string   // Output format (see NewPrinter)
+	Path          string   // Optional file path. If empty, writes to stdout
+	TemplateFile  string   // Template file executed per entry by the "template" format
+	Where         string   // Optional client-side filter expression (see whereExpr)
+	Fields        []string // Attributes to keep; empty keeps all
+	ExcludeFields []string // Attributes to drop
+	Compress      string   // Compression method (gzip or zstd); inferred from Path if empty
+	Query         string   // Name of the query that produced the entries (sink metadata)
+	SinkURL       string   // Splunk HEC or Elasticsearch endpoint for the sink formats
+	SinkToken     string   // HEC token or Elasticsearch API key
+	SinkIndex     string   // Target index; Elasticsearch defaults to "adgo"
+	NotifyURL     string   // Webhook notified with a summary after output completes
+	NotifyMin     int      // Only notify about entries scoring at least this much; 0 sends a summary
+	SortBy        string   // Attribute to sort by ("dn" and "score" are also accepted); empty keeps the format's order
+	SortDesc      bool     // Sort in descending order
+	Limit         int      // Maximum number of entries to output; 0 is unlimited
+	Summary       bool     // Print only the statistics block instead of entries
+	Count         bool     // Print only the number of entries
+	GroupBy       string   // Attribute the "stats" format aggregates by ("ou", "type", "service" and "servicemap" are also accepted)
+	Redact        bool     // Mask passwords and password-like text before output
+	Quiet         bool     // Leave out the header and summary of text output
+	CSVDelimiter  string   // CSV field delimiter ("," if empty, "tab" for a tab)
+	CSVQuoteAll   bool     // Quote every CSV field
+	CSVCRLF       bool     // End CSV lines with CRLF
+	CSVBOM        bool     // Start CSV output with a UTF-8 byte order mark
+	CSVWide       bool     // Write CSV in wide format (one row per entry) when streaming
+	JSONRaw       bool     // Add base64 raw values of binary attributes to json and jsonl output
+
+	// FileMode is the permissions of a created Path file; 0666 before the
+	// umask if zero. Files holding secrets use 0600.
+	FileMode os.FileMode
+
+	// PostProcess steps, e.g. from plugins, run on every entry before Where
+	PostProcess []ProcessFunc
+
+	// StreamErr reports why the entry stream ended, or nil if it completed.
+	// It may only be called once the stream is closed.
+	StreamErr func() error
 }
 
 // Printer defines the interface for output formatters.
@@ -25,25 +63,161 @@ type Printer interface {
 //
 // Supported formats:
 //   - "text": Human-readable card-based output with color
+//   - "table": Compact aligned table of key attributes, one row per entry
 //   - "json": Structured JSON output with metadata
+//   - "jsonl" or "ndjson": One JSON object per line, streamed as entries arrive
+//   - "raw": JSON Lines with unformatted values and base64 binaries, readable by ReadEntries
+//   - "ldif": LDIF content records, readable by ReadEntries
+//   - "grep": One tab-separated line per entry with key=value pairs, like nmap -oG
 //   - "csv": Comma-separated values for spreadsheet compatibility
+//   - "xlsx": Excel workbook with one worksheet per object type
+//   - "html": Self-contained HTML report with statistics and severity badges
+//   - "template": Entries rendered through a user-supplied text/template file
 //   - "bloodhound" or "bh": BloodHound JSON format for analysis
+//   - "bloodhound-ce" or "bhce": BloodHound Community Edition ingestion format
+//   - "stats": Count and percentage of entries per value of GroupBy
+//   - "dot": Graphviz digraph of group membership, delegation and trust relationships
+//   - "mermaid": Mermaid flowchart of the same relationships, for Markdown reports
+//   - "splunk": Events POSTed to a Splunk HTTP Event Collector at SinkURL
+//   - "elastic": Documents POSTed to the Elasticsearch bulk API at SinkURL
+//   - "hashcat": sAMAccountNames, one per line, as a roast target list
+//
+// When Where is set, the printer only receives entries matching the expression.
+// Fields and ExcludeFields trim attributes after filtering; they are ignored by
+// the BloodHound and diagram formats, which need every attribute to build the graph,
+// and by the hashcat format, which only prints the account name.
+// Count and Summary replace per-entry output with the number of entries or
+// the statistics block (JSON for the json and jsonl formats).
+// SortBy and Limit order and truncate the filtered entries for every format;
+// a limit without SortBy keeps the highest-scoring entries.
+// Redact masks secrets for every printer, including sinks and notifications.
+// When NotifyURL is set, a webhook receives a summary of the filtered entries
+// after output completes. PostProcess steps see entries before everything else.
 func NewPrinter(cfg PrinterConfig) (Printer, error) {
+	var printer Printer
+	var err error
+	switch {
+	case cfg.Count:
+		printer = newSummaryPrinter(cfg, true)
+	case cfg.Summary:
+		printer = newSummaryPrinter(cfg, false)
+	default:
+		printer, err = newFormatPrinter(cfg)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if (len(cfg.Fields) > 0 || len(cfg.ExcludeFields) > 0) && !IsBloodHoundFormat(cfg.Format) && !isGraphFormat(cfg.Format) && !IsHashFormat(cfg.Format) {
+		printer = newFieldsPrinter(printer, cfg.Fields, cfg.ExcludeFields)
+	}
+
+	if cfg.NotifyURL != "" {
+		printer = newNotifyPrinter(printer, cfg.NotifyURL, cfg.NotifyMin, cfg.Query)
+	}
+
+	if cfg.SortBy != "" || cfg.Limit > 0 {
+		printer = newSortPrinter(printer, cfg.SortBy, cfg.SortDesc, cfg.Limit)
+	}
+
+	if cfg.Redact {
+		printer = newRedactPrinter(printer)
+	}
+
+	if cfg.Where != "" {
+		if printer, err = newWherePrinter(printer, cfg.Where); err != nil {
+			return nil, err
+		}
+	}
+
+	if len(cfg.PostProcess) > 0 {
+		printer = newProcessPrinter(printer, cfg.PostProcess)
+	}
+	return printer, nil
+}
+
+// newFormatPrinter creates the printer for cfg.Format
+func newFormatPrinter(cfg PrinterConfig) (Printer, error) {
 	switch cfg.Format {
 	case "text", "card":
 		return newTextPrinter(cfg), nil
+	case "table":
+		return newTablePrinter(cfg), nil
 	case "json":
 		return newJSONPrinter(cfg), nil
+	case "jsonl", "ndjson":
+		return newJSONLPrinter(cfg), nil
+	case "raw":
+		return newRawPrinter(cfg), nil
+	case "ldif":
+		return newLDIFPrinter(cfg), nil
+	case "grep":
+		return newGrepPrinter(cfg), nil
 	case "csv":
 		return newCSVPrinter(cfg), nil
+	case "xlsx":
+		return newXLSXPrinter(cfg), nil
+	case "html":
+		return newHTMLPrinter(cfg), nil
+	case "template":
+		return newTemplatePrinter(cfg)
 	case "bloodhound", "bh":
 		// Default to users object type if not specified
 		return newBloodHoundPrinter(cfg, "users"), nil
+	case "bloodhound-ce", "bhce":
+		return newBloodHoundCEPrinter(cfg), nil
+	case "stats":
+		return newStatsPrinter(cfg), nil
+	case "dot":
+		return newDOTPrinter(cfg), nil
+	case "mermaid":
+		return newMermaidPrinter(cfg), nil
+	case sinkSplunk, sinkElastic:
+		return newSinkPrinter(cfg, cfg.Format)
+	case analyze.OutputFormatHashcat:
+		return newHashcatPrinter(cfg), nil
+	case analyze.OutputFormatJohn:
+		return nil, fmt.Errorf("john is an output format of the roast commands; use hashcat for a target list")
 	default:
 		return nil, fmt.Errorf("unsupported output format: %s", cfg.Format)
 	}
 }
 
+// RequiredAttributes returns the attributes a format needs in addition to
+// those requested by a query, e.g. security descriptors for BloodHound ACL edges.
+func RequiredAttributes(format string) []string {
+	if isGraphFormat(format) {
+		return graphAttributes
+	}
+	if IsHashFormat(format) {
+		return []string{analyze.AttrSAMAccountName}
+	}
+	if !IsBloodHoundFormat(format) {
+		return nil
+	}
+	return []string{
+		analyze.AttrObjectClass,
+		analyze.AttrObjectSID,
+		analyze.AttrPrimaryGroupID,
+		analyze.AttrNTSecurityDescriptor,
+	}
+}
+
+// IsBloodHoundFormat reports whether format is one of the BloodHound formats
+func IsBloodHoundFormat(format string) bool {
+	switch format {
+	case "bloodhound", "bh", "bloodhound-ce", "bhce":
+		return true
+	default:
+		return false
+	}
+}
+
+// isGraphFormat reports whether format renders a relationship diagram
+func isGraphFormat(format string) bool {
+	return format == "dot" || format == "mermaid"
+}
+
 // formatEntryAttributes converts LDAP entry attributes to a map of attribute names to formatted values.
 // It uses the analyze package to format each attribute appropriately.
 // Empty or invalid attributes are omitted from the result.
@@ -56,3 +230,69 @@ func formatEntryAttributes(e *ldap.Entry) map[string]string {
 	}
 	return attrs
 }
+
+// FileExtension returns the file extension used for a format's output files
+func FileExtension(format string) string {
+	switch format {
+	case "json", "bloodhound", "bh", "bloodhound-ce", "bhce":
+		return "json"
+	case "jsonl", "ndjson", "raw":
+		return "jsonl"
+	case "csv", "xlsx", "html", "dot", "ldif":
+		return format
+	case "mermaid":
+		return "mmd"
+	default:
+		return "txt"
+	}
+}
+
+// createOutput returns the destination for printer output and a cleanup function.
+// If Path is empty, output goes to stdout. Otherwise, the file at Path is created
+// or overwritten and closed by the cleanup function. Output is compressed when
+// Compress is set or Path ends in .gz or .zst.
+func createOutput(cfg PrinterConfig) (io.Writer, func(), error) {
+	w, closeOutput, err := CreateOutput(cfg)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, func() {
+		if err := closeOutput(); err != nil {
+			fmt.Fprintf(os.Stderr, "Error closing output file: %v\n", err)
+		}
+	}, nil
+}
+
+// CreateOutput is createOutput for commands writing their own reports: the
+// returned function finishes the compressor and closes the file, and
+// returns the first error so a truncated report is not missed. The file is
+// created with FileMode.
+func CreateOutput(cfg PrinterConfig) (io.Writer, func() error, error) {
+	var w io.Writer = os.Stdout
+	closeFile := func() error { return nil }
+	if cfg.Path != "" {
+		mode := cfg.FileMode
+		if mode == 0 {
+			mode = 0666
+		}
+		file, err := os.OpenFile(cfg.Path, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, mode)
+		if err != nil {
+			return nil, nil, fmt.Errorf("failed to create output file: %w", err)
+		}
+		w = file
+		closeFile = file.Close
+	}
+
+	cw, closeCompressor, err := compressWriter(w, compressionFor(cfg))
+	if err != nil {
+		closeFile()
+		return nil, nil, err
+	}
+	return cw, func() error {
+		err := closeCompressor()
+		if cerr := closeFile(); err == nil {
+			err = cerr
+		}
+		return err
+	}, nil
+}
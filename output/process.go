@@ -0,0 +1,81 @@
+package output
+
+import (
+	"github.com/go-ldap/ldap/v3"
+)
+
+// ProcessFunc is a post-processing step. It returns the entry to print,
+// which may be modified, or nil to drop it.
+type ProcessFunc func(e *ldap.Entry) (*ldap.Entry, error)
+
+// processPrinter runs entries through post-processing steps before they
+// reach the next printer
+type processPrinter struct {
+	next  Printer
+	steps []ProcessFunc
+}
+
+// newProcessPrinter wraps next so it receives processed entries
+func newProcessPrinter(next Printer, steps []ProcessFunc) Printer {
+	return &processPrinter{next: next, steps: steps}
+}
+
+// process runs e through every step, stopping when one drops it
+func (p *processPrinter) process(e *ldap.Entry) (*ldap.Entry, error) {
+	for _, step := range p.steps {
+		var err error
+		if e, err = step(e); err != nil || e == nil {
+			return nil, err
+		}
+	}
+	return e, nil
+}
+
+// Print processes and prints the entries.
+func (p *processPrinter) Print(entries []*ldap.Entry) error {
+	processed := make([]*ldap.Entry, 0, len(entries))
+	for _, e := range entries {
+		if e == nil {
+			continue
+		}
+		out, err := p.process(e)
+		if err != nil {
+			return err
+		}
+		if out != nil {
+			processed = append(processed, out)
+		}
+	}
+	return p.next.Print(processed)
+}
+
+// StreamPrint processes entries as they arrive. The first step error ends
+// the stream and is returned.
+func (p *processPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	processed := make(chan *ldap.Entry)
+	var stepErr error
+	go func() {
+		defer close(processed)
+		for e := range entriesChan {
+			if e == nil || stepErr != nil {
+				continue
+			}
+			out, err := p.process(e)
+			if err != nil {
+				stepErr = err
+				continue
+			}
+			if out != nil {
+				processed <- out
+			}
+		}
+	}()
+
+	err := p.next.StreamPrint(processed)
+	for range processed {
+	}
+	if stepErr != nil {
+		return stepErr
+	}
+	return err
+}
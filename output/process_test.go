@@ -0,0 +1,103 @@
+package output
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// collectPrinter records the DNs of the entries it is given
+type collectPrinter struct {
+	dns []string
+}
+
+func (c *collectPrinter) Print(entries []*ldap.Entry) error {
+	for _, e := range entries {
+		c.dns = append(c.dns, e.DN)
+	}
+	return nil
+}
+
+func (c *collectPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	for e := range entriesChan {
+		c.dns = append(c.dns, e.DN)
+	}
+	return nil
+}
+
+// processEntries are the entries every process test runs through the steps
+func processEntries() []*ldap.Entry {
+	return []*ldap.Entry{
+		ldap.NewEntry("CN=alice", nil),
+		ldap.NewEntry("CN=bob", nil),
+		nil,
+		ldap.NewEntry("CN=carol", nil),
+	}
+}
+
+// rename moves entries into OU=Staff, dropBob drops bob and failCarol
+// fails on carol
+var (
+	rename = func(e *ldap.Entry) (*ldap.Entry, error) {
+		return ldap.NewEntry(e.DN+",OU=Staff", nil), nil
+	}
+	dropBob = func(e *ldap.Entry) (*ldap.Entry, error) {
+		if e.DN == "CN=bob" {
+			return nil, nil
+		}
+		return e, nil
+	}
+	errCarol  = errors.New("carol is not allowed")
+	failCarol = func(e *ldap.Entry) (*ldap.Entry, error) {
+		if strings.HasPrefix(e.DN, "CN=carol") {
+			return nil, errCarol
+		}
+		return e, nil
+	}
+)
+
+func TestProcessPrinter(t *testing.T) {
+	tests := []struct {
+		name    string
+		steps   []ProcessFunc
+		want    []string
+		wantErr error
+	}{
+		{"no steps", nil, []string{"CN=alice", "CN=bob", "CN=carol"}, nil},
+		{"modify", []ProcessFunc{rename}, []string{"CN=alice,OU=Staff", "CN=bob,OU=Staff", "CN=carol,OU=Staff"}, nil},
+		{"drop", []ProcessFunc{dropBob, rename}, []string{"CN=alice,OU=Staff", "CN=carol,OU=Staff"}, nil},
+		{"steps run in order", []ProcessFunc{rename, dropBob}, []string{"CN=alice,OU=Staff", "CN=bob,OU=Staff", "CN=carol,OU=Staff"}, nil},
+		{"error", []ProcessFunc{rename, failCarol}, nil, errCarol},
+	}
+	for _, tt := range tests {
+		// Print
+		next := &collectPrinter{}
+		err := newProcessPrinter(next, tt.steps).Print(processEntries())
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: Print error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if tt.wantErr == nil && !slices.Equal(next.dns, tt.want) {
+			t.Errorf("%s: Print passed on %v, want %v", tt.name, next.dns, tt.want)
+		}
+
+		// StreamPrint
+		next = &collectPrinter{}
+		entries := make(chan *ldap.Entry)
+		go func() {
+			defer close(entries)
+			for _, e := range processEntries() {
+				entries <- e
+			}
+		}()
+		err = newProcessPrinter(next, tt.steps).StreamPrint(entries)
+		if !errors.Is(err, tt.wantErr) {
+			t.Errorf("%s: StreamPrint error = %v, want %v", tt.name, err, tt.wantErr)
+		}
+		if tt.wantErr == nil && !slices.Equal(next.dns, tt.want) {
+			t.Errorf("%s: StreamPrint passed on %v, want %v", tt.name, next.dns, tt.want)
+		}
+	}
+}
@@ -0,0 +1,139 @@
+package output
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// rawPrinter outputs LDAP entries as JSON Lines with unformatted values.
+// Unlike jsonl, values are written as returned by the server, with binary
+// values base64-encoded under "binary", so the output can be re-read by
+// ReadEntries and converted to any other format offline.
+type rawPrinter struct {
+	cfg PrinterConfig
+}
+
+// newRawPrinter creates a new raw JSON Lines printer instance.
+func newRawPrinter(cfg PrinterConfig) Printer {
+	return &rawPrinter{cfg: cfg}
+}
+
+// RawEntry represents a single LDAP entry with its unformatted values.
+// It is the line format of the raw output and of entries sent to plugins.
+type RawEntry struct {
+	DN         string              `json:"dn"`               // Distinguished Name of the entry
+	Attributes map[string][]string `json:"attributes"`       // Text values by attribute name
+	Binary     map[string][]string `json:"binary,omitempty"` // Base64 values of attributes that are not valid UTF-8
+}
+
+// Print writes each LDAP entry as a single raw JSON line.
+func (p *rawPrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for _, e := range entries {
+		if err := enc.Encode(NewRawEntry(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamPrint writes each LDAP entry as a raw JSON line as soon as it arrives.
+func (p *rawPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	enc := json.NewEncoder(w)
+	enc.SetEscapeHTML(false)
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		if err := enc.Encode(NewRawEntry(e)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// NewRawEntry splits the values of e into text and base64-encoded binary
+// attributes. An attribute is binary if any of its values is not valid UTF-8.
+func NewRawEntry(e *ldap.Entry) RawEntry {
+	r := RawEntry{DN: e.DN, Attributes: make(map[string][]string, len(e.Attributes))}
+	for _, attr := range e.Attributes {
+		if isTextValues(attr.ByteValues) {
+			r.Attributes[attr.Name] = attr.Values
+			continue
+		}
+		if r.Binary == nil {
+			r.Binary = make(map[string][]string)
+		}
+		values := make([]string, len(attr.ByteValues))
+		for i, raw := range attr.ByteValues {
+			values[i] = base64.StdEncoding.EncodeToString(raw)
+		}
+		r.Binary[attr.Name] = values
+	}
+	return r
+}
+
+// Entry converts a raw entry back into an LDAP entry, with attributes
+// sorted by name so the result does not depend on map order
+func (r RawEntry) Entry() (*ldap.Entry, error) {
+	names := make([]string, 0, len(r.Attributes)+len(r.Binary))
+	for name := range r.Attributes {
+		names = append(names, name)
+	}
+	for name := range r.Binary {
+		if _, ok := r.Attributes[name]; !ok {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	e := &ldap.Entry{DN: r.DN}
+	for _, name := range names {
+		if values, ok := r.Attributes[name]; ok {
+			e.Attributes = append(e.Attributes, ldap.NewEntryAttribute(name, values))
+		}
+		values, ok := r.Binary[name]
+		if !ok {
+			continue
+		}
+		attr := &ldap.EntryAttribute{Name: name}
+		for _, v := range values {
+			raw, err := base64.StdEncoding.DecodeString(v)
+			if err != nil {
+				return nil, fmt.Errorf("attribute %s: %w", name, err)
+			}
+			attr.Values = append(attr.Values, string(raw))
+			attr.ByteValues = append(attr.ByteValues, raw)
+		}
+		e.Attributes = append(e.Attributes, attr)
+	}
+	return e, nil
+}
+
+// isTextValues reports whether every value is valid UTF-8
+func isTextValues(values [][]byte) bool {
+	for _, v := range values {
+		if !utf8.Valid(v) {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,199 @@
+package output
+
+import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"github.com/klauspost/compress/zstd"
+)
+
+// maxInputLine bounds a single line of raw JSONL or LDIF input
+const maxInputLine = 64 << 20
+
+// Magic numbers of the compressed inputs ReadEntries accepts
+var (
+	gzipMagic = []byte{0x1f, 0x8b}
+	zstdMagic = []byte{0x28, 0xb5, 0x2f, 0xfd}
+)
+
+// ReadEntries parses entries saved with the raw or ldif format, detected
+// from the content, so they can be printed again in another format.
+// Gzip and zstd compressed input is decompressed first.
+func ReadEntries(r io.Reader) ([]*ldap.Entry, error) {
+	br := bufio.NewReader(r)
+	magic, _ := br.Peek(len(zstdMagic))
+	switch {
+	case bytes.HasPrefix(magic, gzipMagic):
+		gz, err := gzip.NewReader(br)
+		if err != nil {
+			return nil, fmt.Errorf("reading gzip input: %w", err)
+		}
+		defer gz.Close()
+		return ReadEntries(gz)
+	case bytes.HasPrefix(magic, zstdMagic):
+		return readZstd(br)
+	}
+
+	// Skip leading blank lines to see which format follows
+	for {
+		b, err := br.Peek(1)
+		if err != nil {
+			return nil, nil // Empty input
+		}
+		if b[0] != '\n' && b[0] != '\r' && b[0] != ' ' && b[0] != '\t' {
+			break
+		}
+		br.ReadByte()
+	}
+	if b, _ := br.Peek(1); b[0] == '{' {
+		return readRawJSONL(br)
+	}
+	return readLDIF(br)
+}
+
+// readZstd decompresses r and parses the entries it holds
+func readZstd(r io.Reader) ([]*ldap.Entry, error) {
+	zr, err := zstd.NewReader(r)
+	if err != nil {
+		return nil, fmt.Errorf("reading zstd input: %w", err)
+	}
+	defer zr.Close()
+	return ReadEntries(zr)
+}
+
+// readRawJSONL parses the output of the raw format, one entry per line
+func readRawJSONL(r io.Reader) ([]*ldap.Entry, error) {
+	var entries []*ldap.Entry
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxInputLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		if len(bytes.TrimSpace(scanner.Bytes())) == 0 {
+			continue
+		}
+		var raw RawEntry
+		if err := json.Unmarshal(scanner.Bytes(), &raw); err != nil {
+			return nil, fmt.Errorf("line %d: %w (only -o raw output keeps the original values)", line, err)
+		}
+		e, err := raw.Entry()
+		if err != nil {
+			return nil, fmt.Errorf("line %d: %w", line, err)
+		}
+		entries = append(entries, e)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+	return entries, nil
+}
+
+// readLDIF parses LDIF content records (RFC 2849). Change records and
+// URL values are not supported.
+func readLDIF(r io.Reader) ([]*ldap.Entry, error) {
+	var entries []*ldap.Entry
+	var current *ldap.Entry
+	var pending string // Logical line being unfolded
+	pendingLine := 0
+
+	flushLine := func() error {
+		defer func() { pending = "" }()
+		if pending == "" || strings.HasPrefix(pending, "#") {
+			return nil
+		}
+		name, value, err := parseLDIFLine(pending)
+		if err != nil {
+			return fmt.Errorf("line %d: %w", pendingLine, err)
+		}
+		switch {
+		case strings.EqualFold(name, "version") && current == nil:
+		case strings.EqualFold(name, "dn"):
+			if current != nil {
+				return fmt.Errorf("line %d: dn inside a record", pendingLine)
+			}
+			current = &ldap.Entry{DN: string(value)}
+			entries = append(entries, current)
+		case strings.EqualFold(name, "changetype"):
+			return fmt.Errorf("line %d: change records are not supported", pendingLine)
+		case current == nil:
+			return fmt.Errorf("line %d: attribute %s before dn", pendingLine, name)
+		default:
+			addLDIFValue(current, name, value)
+		}
+		return nil
+	}
+
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(nil, maxInputLine)
+	line := 0
+	for scanner.Scan() {
+		line++
+		text := strings.TrimSuffix(scanner.Text(), "\r")
+		switch {
+		case strings.HasPrefix(text, " "):
+			pending += text[1:]
+			continue
+		case text == "":
+			if err := flushLine(); err != nil {
+				return nil, err
+			}
+			current = nil
+			continue
+		}
+		if err := flushLine(); err != nil {
+			return nil, err
+		}
+		pending, pendingLine = text, line
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("reading input: %w", err)
+	}
+	if err := flushLine(); err != nil {
+		return nil, err
+	}
+	return entries, nil
+}
+
+// parseLDIFLine splits "name: value" or "name:: base64" into the name and
+// the decoded value
+func parseLDIFLine(line string) (string, []byte, error) {
+	name, value, ok := strings.Cut(line, ":")
+	if !ok || name == "" {
+		return "", nil, fmt.Errorf("missing attribute name")
+	}
+	switch {
+	case strings.HasPrefix(value, ":"):
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimSpace(value[1:]))
+		if err != nil {
+			return "", nil, fmt.Errorf("attribute %s: %w", name, err)
+		}
+		return name, raw, nil
+	case strings.HasPrefix(value, "<"):
+		return "", nil, fmt.Errorf("attribute %s: URL values are not supported", name)
+	default:
+		return name, []byte(strings.TrimLeft(value, " ")), nil
+	}
+}
+
+// addLDIFValue appends a value to the attribute of e with the given name
+func addLDIFValue(e *ldap.Entry, name string, value []byte) {
+	for _, attr := range e.Attributes {
+		if strings.EqualFold(attr.Name, name) {
+			attr.Values = append(attr.Values, string(value))
+			attr.ByteValues = append(attr.ByteValues, value)
+			return
+		}
+	}
+	e.Attributes = append(e.Attributes, &ldap.EntryAttribute{
+		Name:       name,
+		Values:     []string{string(value)},
+		ByteValues: [][]byte{value},
+	})
+}
@@ -0,0 +1,136 @@
+package output
+
+import (
+	"bytes"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// roundTripEntries covers multi-valued, binary, non-ASCII, folded and
+// empty values; attributes are out of order on purpose
+func roundTripEntries() []*ldap.Entry {
+	sid := []byte{0x01, 0x05, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x15, 0x00, 0x00, 0x00, 0xff, 0x0a}
+	return []*ldap.Entry{
+		{
+			DN: "CN=Zoë Keller,OU=Staff,DC=example,DC=com",
+			Attributes: []*ldap.EntryAttribute{
+				ldap.NewEntryAttribute("sAMAccountName", []string{"zkeller"}),
+				ldap.NewEntryAttribute("memberOf", []string{
+					"CN=Domain Users,CN=Users,DC=example,DC=com",
+					"CN=VPN Users With A Very Long Group Name For Folding,OU=Groups,DC=example,DC=com",
+				}),
+				{Name: "objectSid", Values: []string{string(sid)}, ByteValues: [][]byte{sid}},
+				ldap.NewEntryAttribute("description", []string{" leading space", ":colon", "Zoë"}),
+				ldap.NewEntryAttribute("info", []string{""}),
+			},
+		},
+		{
+			DN:         "CN=WS01,OU=Workstations,DC=example,DC=com",
+			Attributes: []*ldap.EntryAttribute{ldap.NewEntryAttribute("dNSHostName", []string{"ws01.example.com"})},
+		},
+	}
+}
+
+// sortedEntryValues lists the attributes of each entry in the order they
+// are written, by name
+func sortedEntryValues(entries []*ldap.Entry) []map[string][][]byte {
+	var out []map[string][][]byte
+	for _, e := range entries {
+		m := map[string][][]byte{"dn": {[]byte(e.DN)}}
+		for _, attr := range e.Attributes {
+			m[attr.Name] = attr.ByteValues
+		}
+		out = append(out, m)
+	}
+	return out
+}
+
+// attributeNames returns the attribute names of e in order
+func attributeNames(e *ldap.Entry) []string {
+	names := make([]string, len(e.Attributes))
+	for i, attr := range e.Attributes {
+		names[i] = attr.Name
+	}
+	return names
+}
+
+func TestReadEntriesRoundTrip(t *testing.T) {
+	want := roundTripEntries()
+	wantNames := [][]string{
+		{"description", "info", "memberOf", "objectSid", "sAMAccountName"},
+		{"dNSHostName"},
+	}
+
+	for _, tt := range []struct {
+		file       string
+		newPrinter func(PrinterConfig) Printer
+	}{
+		{"entries.jsonl", newRawPrinter},
+		{"entries.ldif", newLDIFPrinter},
+		{"entries.jsonl.gz", newRawPrinter},
+		{"entries.ldif.zst", newLDIFPrinter},
+	} {
+		t.Run(tt.file, func(t *testing.T) {
+			path := filepath.Join(t.TempDir(), tt.file)
+			if err := tt.newPrinter(PrinterConfig{Path: path}).Print(want); err != nil {
+				t.Fatalf("Print: %v", err)
+			}
+
+			f, err := os.Open(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			defer f.Close()
+			got, err := ReadEntries(f)
+			if err != nil {
+				t.Fatalf("ReadEntries: %v", err)
+			}
+			if len(got) != len(want) {
+				t.Fatalf("ReadEntries returned %d entries, want %d", len(got), len(want))
+			}
+			if !reflect.DeepEqual(sortedEntryValues(got), sortedEntryValues(want)) {
+				t.Errorf("read back %v\nwant %v", sortedEntryValues(got), sortedEntryValues(want))
+			}
+			for i, e := range got {
+				if names := attributeNames(e); !reflect.DeepEqual(names, wantNames[i]) {
+					t.Errorf("entry %d attributes = %v, want %v", i, names, wantNames[i])
+				}
+			}
+		})
+	}
+}
+
+// failingWriter fails every write after the first n bytes
+type failingWriter struct{ n int }
+
+var errWriteFailed = errors.New("disk full")
+
+func (w *failingWriter) Write(p []byte) (int, error) {
+	if len(p) > w.n {
+		n := w.n
+		w.n = 0
+		return n, errWriteFailed
+	}
+	w.n -= len(p)
+	return len(p), nil
+}
+
+func TestWriteLDIFEntryError(t *testing.T) {
+	e := roundTripEntries()[0]
+	var full bytes.Buffer
+	if err := writeLDIFEntry(&full, e); err != nil {
+		t.Fatal(err)
+	}
+
+	// Fail at every point of the record, including inside folded lines
+	for n := 0; n < full.Len(); n++ {
+		if err := writeLDIFEntry(&failingWriter{n: n}, e); !errors.Is(err, errWriteFailed) {
+			t.Fatalf("writeLDIFEntry failing after %d bytes = %v, want %v", n, err, errWriteFailed)
+		}
+	}
+}
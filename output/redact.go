@@ -0,0 +1,94 @@
+package output
+
+import (
+	"adgo/redact"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Redacted replaces secret values in shareable output
+const Redacted = redact.Mask
+
+// redactFreeTextAttributes are free-text attributes where admins commonly
+// leave passwords, e.g. "Temp password: Summer2024!" (lowercase)
+var redactFreeTextAttributes = map[string]bool{
+	"description": true,
+	"info":        true,
+	"comment":     true,
+	"displayname": true,
+}
+
+// redactPrinter masks secrets before entries reach the next printer, so
+// output can be shared without leaking live credentials.
+type redactPrinter struct {
+	next Printer
+}
+
+// newRedactPrinter wraps next so it receives redacted entries
+func newRedactPrinter(next Printer) Printer {
+	return &redactPrinter{next: next}
+}
+
+// Print redacts and prints the entries.
+func (p *redactPrinter) Print(entries []*ldap.Entry) error {
+	redacted := make([]*ldap.Entry, 0, len(entries))
+	for _, e := range entries {
+		if e != nil {
+			redacted = append(redacted, redactEntry(e))
+		}
+	}
+	return p.next.Print(redacted)
+}
+
+// StreamPrint redacts entries as they arrive.
+func (p *redactPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	redacted := make(chan *ldap.Entry)
+	go func() {
+		defer close(redacted)
+		for e := range entriesChan {
+			if e != nil {
+				redacted <- redactEntry(e)
+			}
+		}
+	}()
+
+	err := p.next.StreamPrint(redacted)
+	for range redacted {
+	}
+	return err
+}
+
+// redactEntry returns a copy of e with secret values masked
+func redactEntry(e *ldap.Entry) *ldap.Entry {
+	attrs := make([]*ldap.EntryAttribute, 0, len(e.Attributes))
+	for _, attr := range e.Attributes {
+		name := strings.ToLower(attr.Name)
+		switch {
+		case redact.IsSecretAttribute(name):
+			attrs = append(attrs, maskAttribute(attr, func(string) string { return Redacted }))
+		case redactFreeTextAttributes[name]:
+			attrs = append(attrs, maskAttribute(attr, RedactText))
+		default:
+			attrs = append(attrs, attr)
+		}
+	}
+	return &ldap.Entry{DN: e.DN, Attributes: attrs}
+}
+
+// maskAttribute returns a copy of attr with each value passed through mask
+func maskAttribute(attr *ldap.EntryAttribute, mask func(string) string) *ldap.EntryAttribute {
+	masked := &ldap.EntryAttribute{Name: attr.Name}
+	for _, v := range attr.Values {
+		m := mask(v)
+		masked.Values = append(masked.Values, m)
+		masked.ByteValues = append(masked.ByteValues, []byte(m))
+	}
+	return masked
+}
+
+// RedactText masks values that follow a password keyword in free text,
+// e.g. "Temp password: Summer2024!" becomes "Temp password: ********".
+func RedactText(s string) string {
+	return redact.Text(s)
+}
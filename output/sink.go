@@ -0,0 +1,274 @@
+package output
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Sink tuning
+const (
+	sinkBatchSize    = 500              // Events per HTTP request
+	sinkMaxAttempts  = 3                // Attempts per batch
+	sinkInitialDelay = time.Second      // Delay before the first retry, doubled per attempt
+	sinkTimeout      = 30 * time.Second // Per-request timeout
+	sinkSource       = "adgo"           // Splunk source and default Elasticsearch index
+)
+
+// Sink kinds
+const (
+	sinkSplunk  = "splunk"
+	sinkElastic = "elastic"
+)
+
+// sinkPrinter POSTs entries to a SIEM instead of writing them locally.
+// "splunk" sends events to a Splunk HTTP Event Collector; "elastic" sends
+// documents to the Elasticsearch bulk API. Entries are batched and each
+// batch is retried on network errors, 429 and 5xx responses.
+type sinkPrinter struct {
+	cfg       PrinterConfig
+	kind      string
+	url       string
+	client    *http.Client
+	collected time.Time
+}
+
+// sinkEvent is the document sent for each entry
+type sinkEvent struct {
+	DN          string            `json:"dn"`
+	Attributes  map[string]string `json:"attributes"`
+	Query       string            `json:"query,omitempty"`
+	Tool        string            `json:"tool"`
+	CollectedAt time.Time         `json:"collectedAt"`
+}
+
+// newSinkPrinter creates a printer that sends entries to cfg.SinkURL
+func newSinkPrinter(cfg PrinterConfig, kind string) (Printer, error) {
+	if cfg.SinkURL == "" {
+		return nil, fmt.Errorf("%s output requires --sink-url", kind)
+	}
+	return &sinkPrinter{
+		cfg:       cfg,
+		kind:      kind,
+		url:       sinkEndpoint(kind, cfg.SinkURL),
+		client:    &http.Client{Timeout: sinkTimeout},
+		collected: time.Now().UTC(),
+	}, nil
+}
+
+// sinkEndpoint appends the API path when url only names the server
+func sinkEndpoint(kind, url string) string {
+	url = strings.TrimRight(url, "/")
+	switch kind {
+	case sinkSplunk:
+		if !strings.Contains(url, "/services/collector") {
+			return url + "/services/collector/event"
+		}
+	case sinkElastic:
+		if !strings.HasSuffix(url, "/_bulk") {
+			return url + "/_bulk"
+		}
+	}
+	return url
+}
+
+// Print sends all entries in batches.
+func (p *sinkPrinter) Print(entries []*ldap.Entry) error {
+	ch := make(chan *ldap.Entry)
+	go func() {
+		defer close(ch)
+		for _, e := range entries {
+			ch <- e
+		}
+	}()
+	return p.StreamPrint(ch)
+}
+
+// StreamPrint sends entries as they arrive, one batch at a time.
+func (p *sinkPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	batch := make([]*ldap.Entry, 0, sinkBatchSize)
+	sent := 0
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		batch = append(batch, e)
+		if len(batch) == sinkBatchSize {
+			if err := p.send(batch); err != nil {
+				// Drain so the producer is not blocked
+				for range entriesChan {
+				}
+				return err
+			}
+			sent += len(batch)
+			batch = batch[:0]
+		}
+	}
+	if len(batch) > 0 {
+		if err := p.send(batch); err != nil {
+			return err
+		}
+		sent += len(batch)
+	}
+
+	fmt.Fprintf(os.Stderr, "Sent %d entries to %s\n", sent, p.url)
+	return nil
+}
+
+// send encodes a batch and POSTs it with retries
+func (p *sinkPrinter) send(batch []*ldap.Entry) error {
+	body, err := p.encode(batch)
+	if err != nil {
+		return err
+	}
+
+	var lastErr error
+	delay := sinkInitialDelay
+	for attempt := 1; attempt <= sinkMaxAttempts; attempt++ {
+		if attempt > 1 {
+			time.Sleep(delay)
+			delay *= 2
+		}
+
+		retry, err := p.post(body)
+		if err == nil {
+			return nil
+		}
+		lastErr = err
+		if !retry {
+			break
+		}
+	}
+	return fmt.Errorf("sending to %s: %w", p.kind, lastErr)
+}
+
+// post performs one request and reports whether a failure is worth retrying
+func (p *sinkPrinter) post(body []byte) (bool, error) {
+	req, err := http.NewRequest(http.MethodPost, p.url, bytes.NewReader(body))
+	if err != nil {
+		return false, err
+	}
+
+	switch p.kind {
+	case sinkSplunk:
+		req.Header.Set("Content-Type", "application/json")
+		if p.cfg.SinkToken != "" {
+			req.Header.Set("Authorization", "Splunk "+p.cfg.SinkToken)
+		}
+	case sinkElastic:
+		req.Header.Set("Content-Type", "application/x-ndjson")
+		if p.cfg.SinkToken != "" {
+			req.Header.Set("Authorization", "ApiKey "+p.cfg.SinkToken)
+		}
+	}
+
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		// Error responses are only read for the message
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 64*1024))
+		retry := resp.StatusCode == http.StatusTooManyRequests || resp.StatusCode >= 500
+		return retry, fmt.Errorf("HTTP %d: %s", resp.StatusCode, strings.TrimSpace(string(msg)))
+	}
+
+	if p.kind == sinkElastic {
+		return false, checkBulkResponse(resp.Body)
+	}
+	return false, nil
+}
+
+// bulkResponse is the part of an Elasticsearch bulk API response that
+// reports failed documents
+type bulkResponse struct {
+	Errors bool `json:"errors"`
+	Items  []map[string]struct {
+		Status int `json:"status"`
+		Error  *struct {
+			Type   string `json:"type"`
+			Reason string `json:"reason"`
+		} `json:"error"`
+	} `json:"items"`
+}
+
+// checkBulkResponse decodes a whole bulk API response, which is 200 even
+// when individual documents fail, and returns an error counting the failed
+// documents. A response that cannot be decoded is an error too.
+func checkBulkResponse(r io.Reader) error {
+	var result bulkResponse
+	if err := json.NewDecoder(r).Decode(&result); err != nil {
+		return fmt.Errorf("decoding bulk response: %w", err)
+	}
+	if !result.Errors {
+		return nil
+	}
+
+	failed := 0
+	first := "unknown error"
+	for _, item := range result.Items {
+		for _, action := range item {
+			if action.Error == nil {
+				continue
+			}
+			if failed == 0 {
+				first = fmt.Sprintf("HTTP %d %s: %s", action.Status, action.Error.Type, action.Error.Reason)
+			}
+			failed++
+		}
+	}
+	return fmt.Errorf("bulk request failed for %d of %d documents, first: %s", failed, len(result.Items), first)
+}
+
+// encode builds the request body for a batch: concatenated HEC events for
+// Splunk, or action/document line pairs for the Elasticsearch bulk API.
+func (p *sinkPrinter) encode(batch []*ldap.Entry) ([]byte, error) {
+	var buf bytes.Buffer
+	enc := json.NewEncoder(&buf)
+
+	for _, e := range batch {
+		event := sinkEvent{
+			DN:          e.DN,
+			Attributes:  formatEntryAttributes(e),
+			Query:       p.cfg.Query,
+			Tool:        sinkSource,
+			CollectedAt: p.collected,
+		}
+
+		var err error
+		switch p.kind {
+		case sinkSplunk:
+			hec := map[string]any{
+				"time":       p.collected.Unix(),
+				"source":     sinkSource,
+				"sourcetype": sinkSource + ":ldap",
+				"event":      event,
+			}
+			if p.cfg.SinkIndex != "" {
+				hec["index"] = p.cfg.SinkIndex
+			}
+			err = enc.Encode(hec)
+		case sinkElastic:
+			index := p.cfg.SinkIndex
+			if index == "" {
+				index = sinkSource
+			}
+			if err = enc.Encode(map[string]any{"index": map[string]string{"_index": index}}); err == nil {
+				err = enc.Encode(event)
+			}
+		}
+		if err != nil {
+			return nil, fmt.Errorf("encoding event: %w", err)
+		}
+	}
+	return buf.Bytes(), nil
+}
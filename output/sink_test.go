@@ -0,0 +1,71 @@
+package output
+
+import (
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+// bulkItems renders n successful bulk items followed by the failed ones
+func bulkItems(n int, failed ...string) string {
+	items := make([]string, 0, n+len(failed))
+	for i := 0; i < n; i++ {
+		items = append(items, fmt.Sprintf(`{"index":{"_index":"adgo","_id":"%d","status":201}}`, i))
+	}
+	for _, reason := range failed {
+		items = append(items, `{"index":{"_index":"adgo","status":400,`+
+			`"error":{"type":"mapper_parsing_exception","reason":"`+reason+`"}}}`)
+	}
+	return strings.Join(items, ",")
+}
+
+func TestSinkPost(t *testing.T) {
+	tests := []struct {
+		name      string
+		kind      string
+		status    int
+		body      string
+		wantErr   string
+		wantRetry bool
+	}{
+		{"elastic success", sinkElastic, http.StatusOK, `{"took":3,"errors":false,"items":[` + bulkItems(2) + `]}`, "", false},
+		{"elastic item errors", sinkElastic, http.StatusOK, `{"errors":true,"items":[` + bulkItems(1, "bad date") + `]}`,
+			"failed for 1 of 2 documents, first: HTTP 400 mapper_parsing_exception: bad date", false},
+		// The failed item comes well past the first 64KiB of the response
+		{"elastic large response", sinkElastic, http.StatusOK, `{"errors":true,"items":[` + bulkItems(2000, "bad date", "bad int") + `]}`,
+			"failed for 2 of 2002 documents", false},
+		{"elastic truncated response", sinkElastic, http.StatusOK, `{"errors":false,"items":[` + bulkItems(2), "decoding bulk response", false},
+		{"elastic not json", sinkElastic, http.StatusOK, `<html>proxy login</html>`, "decoding bulk response", false},
+		{"splunk success", sinkSplunk, http.StatusOK, `{"text":"Success","code":0}`, "", false},
+		{"throttled", sinkElastic, http.StatusTooManyRequests, "slow down", "HTTP 429: slow down", true},
+		{"server error", sinkSplunk, http.StatusServiceUnavailable, "", "HTTP 503", true},
+		{"rejected", sinkSplunk, http.StatusForbidden, `{"text":"Invalid token","code":4}`, "HTTP 403", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				fmt.Fprint(w, tt.body)
+			}))
+			defer server.Close()
+
+			p, err := newSinkPrinter(PrinterConfig{SinkURL: server.URL}, tt.kind)
+			if err != nil {
+				t.Fatal(err)
+			}
+			retry, err := p.(*sinkPrinter).post([]byte("{}\n"))
+			if retry != tt.wantRetry {
+				t.Errorf("retry = %v, want %v", retry, tt.wantRetry)
+			}
+			switch {
+			case tt.wantErr == "" && err != nil:
+				t.Errorf("post: %v", err)
+			case tt.wantErr != "" && (err == nil || !strings.Contains(err.Error(), tt.wantErr)):
+				t.Errorf("post error = %v, want %q", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,163 @@
+package output
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Pseudo-attributes accepted by --sort-by
+const (
+	sortKeyDN    = "dn"    // Distinguished name
+	sortKeyScore = "score" // Target value score (the default text/HTML order)
+)
+
+// sortPrinter orders and truncates entries before passing them on.
+// Entries are buffered, sorted by an attribute (or by value score when no
+// attribute is given) and cut to the limit, then streamed to the next printer.
+type sortPrinter struct {
+	next  Printer
+	by    string
+	desc  bool
+	limit int
+}
+
+// newSortPrinter wraps next so it receives sorted, limited entries
+func newSortPrinter(next Printer, by string, desc bool, limit int) Printer {
+	return &sortPrinter{next: next, by: by, desc: desc, limit: limit}
+}
+
+// SortAttributes returns the attribute --sort-by needs from the server, if any
+func SortAttributes(by string) []string {
+	switch strings.ToLower(by) {
+	case "", sortKeyDN, sortKeyScore:
+		return nil
+	default:
+		return []string{by}
+	}
+}
+
+// Print sorts and limits the entries, then prints them.
+func (p *sortPrinter) Print(entries []*ldap.Entry) error {
+	return p.next.Print(p.apply(entries))
+}
+
+// StreamPrint collects all entries, then streams them in order.
+func (p *sortPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	var entries []*ldap.Entry
+	for e := range entriesChan {
+		if e != nil {
+			entries = append(entries, e)
+		}
+	}
+
+	sorted := make(chan *ldap.Entry)
+	go func() {
+		defer close(sorted)
+		for _, e := range p.apply(entries) {
+			sorted <- e
+		}
+	}()
+
+	err := p.next.StreamPrint(sorted)
+	for range sorted {
+	}
+	return err
+}
+
+// apply returns the entries in order, cut to the limit
+func (p *sortPrinter) apply(entries []*ldap.Entry) []*ldap.Entry {
+	if p.by == "" {
+		entries = sortByValue(entries)
+	} else {
+		entries = sortByAttribute(entries, p.by, p.desc)
+	}
+	if p.limit > 0 && len(entries) > p.limit {
+		entries = entries[:p.limit]
+	}
+	return entries
+}
+
+// sortKey is the precomputed sort value of an entry
+type sortKey struct {
+	entry   *ldap.Entry
+	missing bool
+	numeric bool
+	number  float64
+	text    string
+}
+
+// sortByAttribute sorts entries by an attribute. Raw values that are numbers
+// (including FILETIME and integer flags) compare numerically, so e.g.
+// pwdLastSet sorts chronologically; other values compare by their formatted
+// text, case-insensitively. Entries without the attribute always sort last.
+func sortByAttribute(entries []*ldap.Entry, by string, desc bool) []*ldap.Entry {
+	keys := make([]sortKey, len(entries))
+	for i, e := range entries {
+		keys[i] = newSortKey(e, by)
+	}
+
+	sort.SliceStable(keys, func(i, j int) bool {
+		a, b := keys[i], keys[j]
+		if a.missing || b.missing {
+			return !a.missing && b.missing
+		}
+		if a.numeric && b.numeric {
+			if desc {
+				return a.number > b.number
+			}
+			return a.number < b.number
+		}
+		if desc {
+			return a.text > b.text
+		}
+		return a.text < b.text
+	})
+
+	sorted := make([]*ldap.Entry, len(keys))
+	for i, k := range keys {
+		sorted[i] = k.entry
+	}
+	return sorted
+}
+
+// newSortKey computes the sort value of an entry for attribute by
+func newSortKey(e *ldap.Entry, by string) sortKey {
+	k := sortKey{entry: e}
+	switch strings.ToLower(by) {
+	case sortKeyDN:
+		k.text = strings.ToLower(e.DN)
+		return k
+	case sortKeyScore:
+		k.numeric = true
+		k.number = float64(scoreTarget(e))
+		return k
+	}
+
+	raw := e.GetEqualFoldAttributeValue(by)
+	if raw == "" {
+		k.missing = true
+		return k
+	}
+	if n, err := strconv.ParseFloat(raw, 64); err == nil {
+		k.numeric = true
+		k.number = n
+	}
+	k.text = strings.ToLower(formatEntryAttributes(e)[attributeName(e, by)])
+	if k.text == "" {
+		k.text = strings.ToLower(raw)
+	}
+	return k
+}
+
+// attributeName returns the entry's spelling of an attribute name
+func attributeName(e *ldap.Entry, name string) string {
+	for _, attr := range e.Attributes {
+		if strings.EqualFold(attr.Name, name) {
+			return attr.Name
+		}
+	}
+	return name
+}
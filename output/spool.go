@@ -0,0 +1,120 @@
+package output
+
+import (
+	"bufio"
+	"encoding/gob"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// entrySpool buffers entries in a temporary file so that formats needing a
+// second pass over the result set (e.g. to resolve SIDs) do not have to hold
+// every entry in memory.
+type entrySpool struct {
+	f   *os.File
+	w   *bufio.Writer
+	enc *gob.Encoder
+}
+
+// newEntrySpool creates a spool backed by a new temporary file.
+// Call close to remove the file.
+func newEntrySpool() (*entrySpool, error) {
+	f, err := os.CreateTemp("", "adgo-spool-*")
+	if err != nil {
+		return nil, fmt.Errorf("creating spool file: %w", err)
+	}
+	w := bufio.NewWriter(f)
+	return &entrySpool{f: f, w: w, enc: gob.NewEncoder(w)}, nil
+}
+
+// add appends an entry to the spool
+func (s *entrySpool) add(e *ldap.Entry) error {
+	if err := s.enc.Encode(e); err != nil {
+		return fmt.Errorf("writing spool file: %w", err)
+	}
+	return nil
+}
+
+// each reads the spooled entries back in order and calls fn for each
+func (s *entrySpool) each(fn func(*ldap.Entry) error) error {
+	if err := s.w.Flush(); err != nil {
+		return fmt.Errorf("writing spool file: %w", err)
+	}
+	if _, err := s.f.Seek(0, io.SeekStart); err != nil {
+		return fmt.Errorf("reading spool file: %w", err)
+	}
+
+	dec := gob.NewDecoder(bufio.NewReader(s.f))
+	for {
+		var e ldap.Entry
+		if err := dec.Decode(&e); err != nil {
+			if errors.Is(err, io.EOF) {
+				return nil
+			}
+			return fmt.Errorf("reading spool file: %w", err)
+		}
+		if err := fn(&e); err != nil {
+			return err
+		}
+	}
+}
+
+// close closes and removes the spool file
+func (s *entrySpool) close() {
+	s.f.Close()
+	os.Remove(s.f.Name())
+}
+
+// jsonArrayWriter writes a JSON document whose array elements are marshaled
+// one at a time, so the trailer (e.g. meta with the final count) can follow
+// the data without the elements being held in memory.
+type jsonArrayWriter struct {
+	w     *bufio.Writer
+	count int
+	err   error
+}
+
+// newJSONArrayWriter starts a document with prefix, which must open the array
+func newJSONArrayWriter(w io.Writer, prefix string) *jsonArrayWriter {
+	a := &jsonArrayWriter{w: bufio.NewWriter(w)}
+	_, a.err = a.w.WriteString(prefix)
+	return a
+}
+
+// add writes one array element
+func (a *jsonArrayWriter) add(v any) error {
+	if a.err != nil {
+		return a.err
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	if a.count > 0 {
+		a.w.WriteString(",\n")
+	}
+	a.w.WriteString("    ")
+	_, a.err = a.w.Write(b)
+	a.count++
+	return a.err
+}
+
+// close closes the array with sep, writes trailer as JSON, then suffix
+func (a *jsonArrayWriter) close(sep string, trailer any, suffix string) error {
+	if a.err != nil {
+		return a.err
+	}
+	b, err := json.Marshal(trailer)
+	if err != nil {
+		return fmt.Errorf("failed to marshal JSON: %w", err)
+	}
+	a.w.WriteString(sep)
+	a.w.Write(b)
+	a.w.WriteString(suffix)
+	return a.w.Flush()
+}
@@ -0,0 +1,261 @@
+package output
+
+import (
+	"adgo/analyze"
+	"fmt"
+	"io"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Pseudo-attributes accepted by --group-by
+const (
+	groupKeyOU         = "ou"         // Parent container of the entry
+	groupKeyType       = "type"       // Object type derived from the DN (default)
+	groupKeyService    = "service"    // Service identified by each SPN, e.g. MSSQL
+	groupKeyServiceMap = "servicemap" // Service and host[:port] of each SPN
+	groupKeyPwdAge     = "pwdage"     // Password age bucket per account kind
+)
+
+// Stats layout
+const (
+	statsMaxValueWidth = 60 // Values wider than this are truncated
+	statsBarWidth      = 30 // Width of the percentage bar
+	statsNoValue       = "(none)"
+)
+
+// statsPrinter aggregates entries by an attribute and prints the count and
+// percentage of each value, e.g. the operating system distribution of
+// computers. Multi-valued attributes count every value, so percentages are
+// relative to the number of entries and may add up to more than 100.
+type statsPrinter struct {
+	cfg    PrinterConfig
+	by     string
+	colors colorFunctions
+	now    time.Time                 // Reference time of password ages
+	ages   map[string]map[string]int // Account kind to password age bucket counts, for pwdage
+}
+
+// statsRow is the count for one value
+type statsRow struct {
+	Value string
+	Count int
+}
+
+// newStatsPrinter creates a new aggregation printer instance.
+func newStatsPrinter(cfg PrinterConfig) Printer {
+	by := cfg.GroupBy
+	if by == "" {
+		by = groupKeyType
+	}
+	return &statsPrinter{cfg: cfg, by: by, colors: outputColors(cfg), now: time.Now(), ages: make(map[string]map[string]int)}
+}
+
+// GroupByAttributes returns the attribute --group-by needs from the server, if any
+func GroupByAttributes(by string) []string {
+	switch strings.ToLower(by) {
+	case "", groupKeyOU, groupKeyType:
+		return nil
+	case groupKeyService, groupKeyServiceMap:
+		return []string{analyze.AttrServicePrincipalName}
+	case groupKeyPwdAge:
+		return analyze.PasswordAgeAttributes
+	default:
+		return []string{by}
+	}
+}
+
+// Print aggregates and prints the entries.
+func (p *statsPrinter) Print(entries []*ldap.Entry) error {
+	counts := make(map[string]int)
+	total := 0
+	for _, e := range entries {
+		if e != nil {
+			p.add(counts, e)
+			total++
+		}
+	}
+	return p.write(counts, total)
+}
+
+// StreamPrint aggregates entries as they arrive without keeping them.
+func (p *statsPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	counts := make(map[string]int)
+	total := 0
+	for e := range entriesChan {
+		if e != nil {
+			p.add(counts, e)
+			total++
+		}
+	}
+	return p.write(counts, total)
+}
+
+// add counts the group values of one entry
+func (p *statsPrinter) add(counts map[string]int, e *ldap.Entry) {
+	if strings.EqualFold(p.by, groupKeyPwdAge) {
+		kind := analyze.AccountKind(e)
+		if p.ages[kind] == nil {
+			p.ages[kind] = make(map[string]int)
+		}
+		p.ages[kind][analyze.PasswordAgeBucket(e.GetEqualFoldAttributeValue(analyze.AttrPwdLastSet), p.now)]++
+		return
+	}
+	values := p.values(e)
+	if len(values) == 0 {
+		counts[statsNoValue]++
+		return
+	}
+	for _, v := range values {
+		counts[v]++
+	}
+}
+
+// values returns the values an entry is grouped under
+func (p *statsPrinter) values(e *ldap.Entry) []string {
+	switch strings.ToLower(p.by) {
+	case groupKeyType:
+		return []string{objectType(e.DN)}
+	case groupKeyOU:
+		if _, parent, ok := strings.Cut(e.DN, ","); ok {
+			return []string{parent}
+		}
+		return nil
+	case groupKeyService, groupKeyServiceMap:
+		return spnGroups(e, strings.ToLower(p.by) == groupKeyServiceMap)
+	}
+
+	raw := e.GetEqualFoldAttributeValues(p.by)
+	if len(raw) == 1 {
+		// Formatted values group more meaningfully (e.g. UAC flag names, dates)
+		if v := formatEntryAttributes(e)[attributeName(e, p.by)]; v != "" {
+			return []string{v}
+		}
+	}
+	var values []string
+	for _, v := range raw {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
+
+// spnGroups returns the distinct services of the SPNs of an entry, with
+// the host and port if withEndpoint is set, so an account registering a
+// service under several names counts once per service or endpoint
+func spnGroups(e *ldap.Entry, withEndpoint bool) []string {
+	var values []string
+	for _, v := range e.GetEqualFoldAttributeValues(analyze.AttrServicePrincipalName) {
+		s, ok := analyze.ParseSPN(v)
+		if !ok {
+			continue
+		}
+		value := s.Class
+		if withEndpoint {
+			value = s.Class + " on " + strings.ToLower(s.Endpoint())
+		}
+		if !slices.Contains(values, value) {
+			values = append(values, value)
+		}
+	}
+	return values
+}
+
+// write prints the table of counts, most common first
+func (p *statsPrinter) write(counts map[string]int, total int) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if total == 0 {
+		fmt.Fprintln(w, msgNoEntries)
+		return nil
+	}
+	if strings.EqualFold(p.by, groupKeyPwdAge) {
+		p.writePasswordAges(w, total)
+		return nil
+	}
+
+	rows := make([]statsRow, 0, len(counts))
+	width := len(p.by)
+	for v, c := range counts {
+		rows = append(rows, statsRow{Value: v, Count: c})
+		width = max(width, utf8.RuneCountInString(v))
+	}
+	width = min(width, statsMaxValueWidth)
+	sort.Slice(rows, func(i, j int) bool {
+		if rows[i].Count != rows[j].Count {
+			return rows[i].Count > rows[j].Count
+		}
+		return rows[i].Value < rows[j].Value
+	})
+
+	p.writeRows(w, rows, total, width)
+	return nil
+}
+
+// writeRows prints the aligned table
+func (p *statsPrinter) writeRows(w io.Writer, rows []statsRow, total, width int) {
+	fmt.Fprintf(w, "%s  %7s  %7s\n", p.colors.Bold(tableCell(p.by, width)), "COUNT", "PERCENT")
+	fmt.Fprintln(w, p.colors.Dim(strings.Repeat(tableSeparator, width+18+statsBarWidth)))
+	for _, r := range rows {
+		pct := float64(r.Count) * 100 / float64(total)
+		bar := strings.Repeat("#", int(pct*statsBarWidth/100+0.5))
+		fmt.Fprintf(w, "%s  %7d  %6.1f%%  %s\n", tableCell(r.Value, width), r.Count, pct, p.colors.Cyan(bar))
+	}
+	fmt.Fprintln(w, p.colors.Dim(strings.Repeat(tableSeparator, width+18+statsBarWidth)))
+	fmt.Fprintf(w, "%d entries, %d distinct values\n", total, len(rows))
+}
+
+// pwdAgeKinds lists the account kinds of the password age table in
+// display order
+var pwdAgeKinds = []string{
+	analyze.AccountKindUser,
+	analyze.AccountKindServiceAccount,
+	analyze.AccountKindComputer,
+	analyze.AccountKindManaged,
+	analyze.AccountKindOther,
+}
+
+// writePasswordAges prints the password age buckets of each account kind,
+// followed by the share of passwords older than three years
+func (p *statsPrinter) writePasswordAges(w io.Writer, total int) {
+	const kindWidth, cellWidth = 23, 9
+	header := tableCell("ACCOUNT KIND", kindWidth)
+	for _, b := range analyze.PasswordAgeBuckets {
+		header += fmt.Sprintf("  %*s", cellWidth, b)
+	}
+	header += fmt.Sprintf("  %*s", cellWidth, "TOTAL")
+	width := kindWidth + (len(analyze.PasswordAgeBuckets)+1)*(cellWidth+2)
+
+	fmt.Fprintln(w, p.colors.Bold(header))
+	fmt.Fprintln(w, p.colors.Dim(strings.Repeat(tableSeparator, width)))
+	old := 0
+	for _, kind := range pwdAgeKinds {
+		counts := p.ages[kind]
+		if counts == nil {
+			continue
+		}
+		line, sum := tableCell(kind, kindWidth), 0
+		for _, b := range analyze.PasswordAgeBuckets {
+			cell := fmt.Sprintf("%*d", cellWidth, counts[b])
+			if b == analyze.PasswordAgeOver3Years && counts[b] > 0 {
+				cell = p.colors.Red(cell)
+			}
+			line += "  " + cell
+			sum += counts[b]
+		}
+		fmt.Fprintf(w, "%s  %*d\n", line, cellWidth, sum)
+		old += counts[analyze.PasswordAgeOver3Years]
+	}
+	fmt.Fprintln(w, p.colors.Dim(strings.Repeat(tableSeparator, width)))
+	fmt.Fprintf(w, "%d entries, %d (%.1f%%) with passwords older than 3 years\n", total, old, float64(old)*100/float64(total))
+}
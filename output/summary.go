@@ -0,0 +1,79 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// summaryPrinter suppresses per-entry output and prints only the entry
+// count (--count) or the statistics block (--summary). JSON formats get
+// the statistics as a JSON object for scripting.
+type summaryPrinter struct {
+	cfg       PrinterConfig
+	countOnly bool
+}
+
+// newSummaryPrinter creates a printer for --summary, or --count if countOnly
+func newSummaryPrinter(cfg PrinterConfig, countOnly bool) Printer {
+	return &summaryPrinter{cfg: cfg, countOnly: countOnly}
+}
+
+// Print prints the count or statistics of the entries.
+func (p *summaryPrinter) Print(entries []*ldap.Entry) error {
+	if p.countOnly {
+		return p.write(Statistics{Total: len(entries)})
+	}
+	return p.write(collectStats(entries))
+}
+
+// StreamPrint counts entries as they arrive without keeping them.
+func (p *summaryPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	var stats Statistics
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		if p.countOnly {
+			stats.Total++
+			continue
+		}
+		addStats(&stats, collectStats([]*ldap.Entry{e}))
+	}
+	return p.write(stats)
+}
+
+// write prints the result in the configured format
+func (p *summaryPrinter) write(stats Statistics) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if p.countOnly {
+		fmt.Fprintln(w, stats.Total)
+		return nil
+	}
+
+	switch p.cfg.Format {
+	case "json", "jsonl", "ndjson":
+		return json.NewEncoder(w).Encode(stats)
+	default:
+		tp := &textPrinter{cfg: p.cfg, colors: outputColors(p.cfg), w: w}
+		tp.printSummary(stats)
+		return nil
+	}
+}
+
+// addStats adds the counts in o to s
+func addStats(s *Statistics, o Statistics) {
+	s.Total += o.Total
+	s.Admins += o.Admins
+	s.SPN += o.SPN
+	s.ASRep += o.ASRep
+	s.DCs += o.DCs
+	s.Enabled += o.Enabled
+	s.Disabled += o.Disabled
+}
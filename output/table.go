@@ -0,0 +1,216 @@
+package output
+
+import (
+	"adgo/analyze"
+	"fmt"
+	"os"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+	"golang.org/x/term"
+)
+
+// Table output formatting constants
+const (
+	tableColumnGap      = 2  // Spaces between columns
+	tableMaxColumnWidth = 40 // Widest a column may grow before values are truncated
+	tableMinColumnWidth = 6  // Narrowest column worth showing
+)
+
+// tableColumns are the key attributes shown by the table format, in column order.
+// Only columns with a value on at least one entry are printed; --fields
+// replaces this list.
+var tableColumns = []string{
+	analyze.AttrSAMAccountName,
+	analyze.AttrDisplayName,
+	analyze.AttrDNSHostName,
+	analyze.AttrOperatingSystem,
+	"mail",
+	analyze.AttrAdminCount,
+	analyze.AttrUserAccountControl,
+	analyze.AttrPwdLastSet,
+	analyze.AttrLastLogonTimestamp,
+	analyze.AttrDescription,
+}
+
+// tablePrinter outputs LDAP entries as an aligned table with one row per entry.
+// It is a compact alternative to the card format for long result sets.
+type tablePrinter struct {
+	cfg    PrinterConfig
+	colors colorFunctions
+}
+
+// newTablePrinter creates a new table printer instance.
+func newTablePrinter(cfg PrinterConfig) Printer {
+	return &tablePrinter{
+		cfg:    cfg,
+		colors: outputColors(cfg),
+	}
+}
+
+// Print outputs LDAP entries as a table sized to the terminal width,
+// or to maxLineWidth when writing to a file.
+func (p *tablePrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	if len(entries) == 0 {
+		fmt.Fprintln(w, msgNoEntries)
+		return nil
+	}
+
+	rows := make([]map[string]string, len(entries))
+	for i, e := range entries {
+		rows[i] = formatEntryAttributes(e)
+		rows[i]["DN"] = e.DN
+	}
+
+	columns := tableSelectColumns(rows)
+	if len(p.cfg.Fields) > 0 {
+		columns = tableFieldColumns(p.cfg.Fields, rows)
+	}
+	width := maxLineWidth
+	if p.cfg.Path == "" {
+		width = terminalWidth()
+	}
+	widths := tableFitWidths(columns, rows, width)
+
+	header := make([]string, len(columns))
+	for i, col := range columns {
+		header[i] = tableCell(col, widths[i])
+	}
+	fmt.Fprintln(w, p.colors.Bold(strings.TrimRight(strings.Join(header, strings.Repeat(" ", tableColumnGap)), " ")))
+
+	for _, row := range rows {
+		cells := make([]string, len(columns))
+		for i, col := range columns {
+			cells[i] = tableCell(row[col], widths[i])
+		}
+		fmt.Fprintln(w, strings.TrimRight(strings.Join(cells, strings.Repeat(" ", tableColumnGap)), " "))
+	}
+
+	fmt.Fprintln(w, p.colors.Dim(fmt.Sprintf("%d entries", len(entries))))
+	return nil
+}
+
+// StreamPrint collects entries and prints the table.
+// Column widths depend on every row, so output waits for the stream to end.
+func (p *tablePrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	var entries []*ldap.Entry
+	for entry := range entriesChan {
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return p.Print(entries)
+}
+
+// tableSelectColumns returns the key attributes present in the rows.
+// The name column falls back to name, then DN, when sAMAccountName is absent.
+func tableSelectColumns(rows []map[string]string) []string {
+	present := func(col string) bool {
+		for _, row := range rows {
+			if row[col] != "" {
+				return true
+			}
+		}
+		return false
+	}
+
+	var columns []string
+	for _, col := range tableColumns {
+		if present(col) {
+			columns = append(columns, col)
+		}
+	}
+
+	if !present(analyze.AttrSAMAccountName) {
+		if present(analyze.AttrName) {
+			columns = append([]string{analyze.AttrName}, columns...)
+		} else {
+			columns = append([]string{"DN"}, columns...)
+		}
+	}
+	return columns
+}
+
+// tableFieldColumns uses the requested --fields as columns, in order.
+// Field names are matched to the attribute names returned by the server
+// case-insensitively so the cells line up.
+func tableFieldColumns(fields []string, rows []map[string]string) []string {
+	columns := make([]string, len(fields))
+	for i, field := range fields {
+		columns[i] = field
+		for _, row := range rows {
+			for name := range row {
+				if strings.EqualFold(name, field) {
+					columns[i] = name
+				}
+			}
+		}
+	}
+	return columns
+}
+
+// tableFitWidths computes column widths that fit within maxWidth.
+// Columns are capped at tableMaxColumnWidth; trailing columns that no longer
+// fit are dropped by giving them a width of zero.
+func tableFitWidths(columns []string, rows []map[string]string, maxWidth int) []int {
+	widths := make([]int, len(columns))
+	for i, col := range columns {
+		widths[i] = utf8.RuneCountInString(col)
+		for _, row := range rows {
+			widths[i] = max(widths[i], utf8.RuneCountInString(row[col]))
+		}
+		widths[i] = min(widths[i], tableMaxColumnWidth)
+	}
+
+	used := 0
+	for i := range widths {
+		remaining := maxWidth - used
+		if i > 0 {
+			remaining -= tableColumnGap
+		}
+		if remaining < tableMinColumnWidth {
+			clear(widths[i:])
+			break
+		}
+		widths[i] = min(widths[i], remaining)
+		used += widths[i]
+		if i > 0 {
+			used += tableColumnGap
+		}
+	}
+	return widths
+}
+
+// tableCell pads or truncates a value to exactly width runes.
+// A width of zero marks a dropped column and yields an empty cell.
+func tableCell(value string, width int) string {
+	if width == 0 {
+		return ""
+	}
+	value = strings.ReplaceAll(value, "\n", " ")
+	n := utf8.RuneCountInString(value)
+	if n > width {
+		runes := []rune(value)
+		if width > 3 {
+			return string(runes[:width-3]) + "..."
+		}
+		return string(runes[:width])
+	}
+	return value + strings.Repeat(" ", width-n)
+}
+
+// terminalWidth returns the width of the terminal on stdout,
+// or maxLineWidth when stdout is not a terminal.
+func terminalWidth() int {
+	if width, _, err := term.GetSize(int(os.Stdout.Fd())); err == nil && width > 0 {
+		return width
+	}
+	return maxLineWidth
+}
@@ -0,0 +1,128 @@
+package output
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"text/template"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// templatePrinter outputs LDAP entries through a user-supplied text/template.
+// The template is executed once per entry; it controls its own line endings.
+type templatePrinter struct {
+	cfg  PrinterConfig
+	tmpl *template.Template
+}
+
+// templateEntry is the data passed to the template for each entry.
+type templateEntry struct {
+	DN         string            // Distinguished name
+	Attributes map[string]string // Formatted attribute values by name
+	Entry      *ldap.Entry       // Raw LDAP entry, for the helper funcs
+}
+
+// templateFuncs are the helper functions available to output templates
+var templateFuncs = template.FuncMap{
+	// attr returns the formatted value of an attribute (e.g., {{attr . "pwdLastSet"}})
+	"attr": func(e templateEntry, name string) string {
+		return e.Attributes[name]
+	},
+	// values returns the raw string values of a multi-valued attribute
+	"values": func(e templateEntry, name string) []string {
+		return e.Entry.GetEqualFoldAttributeValues(name)
+	},
+	// has reports whether the entry has a non-empty attribute
+	"has": func(e templateEntry, name string) bool {
+		return e.Attributes[name] != ""
+	},
+	"type":    func(e templateEntry) string { return objectType(e.DN) },
+	"score":   func(e templateEntry) int { return scoreTarget(e.Entry) },
+	"enabled": func(e templateEntry) bool { return isEnabled(e.Entry) },
+	"default": func(fallback, value string) string {
+		if value == "" {
+			return fallback
+		}
+		return value
+	},
+	"join":  func(sep string, values []string) string { return strings.Join(values, sep) },
+	"upper": strings.ToUpper,
+	"lower": strings.ToLower,
+	"trim":  strings.TrimSpace,
+	"quote": func(s string) string { return fmt.Sprintf("%q", s) },
+	"json": func(v any) (string, error) {
+		b, err := json.Marshal(v)
+		return string(b), err
+	},
+}
+
+// newTemplatePrinter parses the template file and creates a new template printer.
+func newTemplatePrinter(cfg PrinterConfig) (Printer, error) {
+	if cfg.TemplateFile == "" {
+		return nil, fmt.Errorf("template format requires --template-file")
+	}
+
+	content, err := os.ReadFile(cfg.TemplateFile)
+	if err != nil {
+		return nil, fmt.Errorf("reading template file: %w", err)
+	}
+
+	tmpl, err := template.New(filepath.Base(cfg.TemplateFile)).Funcs(templateFuncs).Parse(string(content))
+	if err != nil {
+		return nil, fmt.Errorf("parsing template file: %w", err)
+	}
+
+	return &templatePrinter{cfg: cfg, tmpl: tmpl}, nil
+}
+
+// Print executes the template for each entry.
+func (p *templatePrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	for _, e := range entries {
+		if err := p.execute(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// StreamPrint executes the template for each entry as it arrives.
+func (p *templatePrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	for e := range entriesChan {
+		if e == nil {
+			continue
+		}
+		if err := p.execute(w, e); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// execute renders a single entry
+func (p *templatePrinter) execute(w io.Writer, e *ldap.Entry) error {
+	data := templateEntry{
+		DN:         e.DN,
+		Attributes: formatEntryAttributes(e),
+		Entry:      e,
+	}
+	if err := p.tmpl.Execute(w, data); err != nil {
+		return fmt.Errorf("executing template for %s: %w", e.DN, err)
+	}
+	return nil
+}
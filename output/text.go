@@ -2,6 +2,7 @@ package output
 
 import (
 	"fmt"
+	"io"
 	"sort"
 	"strconv"
 	"strings"
@@ -29,32 +30,40 @@ const (
 
 // Statistics holds summary statistics about entries.
 type Statistics struct {
-	Total    int
-	Admins   int
-	SPN      int
-	ASRep    int
-	DCs      int
-	Enabled  int
-	Disabled int
+	Total    int `json:"total"`
+	Admins   int `json:"admins"`
+	SPN      int `json:"spn"`
+	ASRep    int `json:"asrep"`
+	DCs      int `json:"dcs"`
+	Enabled  int `json:"enabled"`
+	Disabled int `json:"disabled"`
 }
 
 type textPrinter struct {
 	cfg    PrinterConfig
 	colors colorFunctions
+	w      io.Writer // Destination set by Print/StreamPrint
 }
 
 func newTextPrinter(cfg PrinterConfig) Printer {
 	return &textPrinter{
 		cfg:    cfg,
-		colors: initColors(),
+		colors: outputColors(cfg),
 	}
 }
 
 // Print outputs LDAP entries in card-based text format.
 // Each entry is displayed as a separate card with attributes.
 func (p *textPrinter) Print(entries []*ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	p.w = w
+
 	if len(entries) == 0 {
-		fmt.Println(msgNoEntries)
+		fmt.Fprintln(p.w, msgNoEntries)
 		return nil
 	}
 	return p.printCards(entries)
@@ -62,6 +71,13 @@ func (p *textPrinter) Print(entries []*ldap.Entry) error {
 
 // StreamPrint outputs LDAP entries in card-based text format as they arrive.
 func (p *textPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+	p.w = w
+
 	return p.streamCards(entriesChan)
 }
 
@@ -73,7 +89,7 @@ func (p *textPrinter) printCards(entries []*ldap.Entry) error {
 	stats := collectStats(entries)
 
 	// Sort entries by value (high-value targets first)
-	sortedEntries := sortByValue(entries)
+	sortedEntries := orderEntries(p.cfg, entries)
 
 	// Print cards
 	for _, entry := range sortedEntries {
@@ -99,7 +115,7 @@ func (p *textPrinter) streamCards(entriesChan <-chan *ldap.Entry) error {
 
 	// Collect statistics and sort
 	stats := collectStats(entries)
-	sortedEntries := sortByValue(entries)
+	sortedEntries := orderEntries(p.cfg, entries)
 
 	// Print cards
 	for _, entry := range sortedEntries {
@@ -115,13 +131,13 @@ func (p *textPrinter) card(entry *ldap.Entry) {
 	objType := objectType(entry.DN)
 
 	sep := strings.Repeat("-", cardSeparatorWidth)
-	fmt.Printf("%s\n%s\n%s\n", sep, p.colors.Bold(fmt.Sprintf("[%s] %s", objType, entry.DN)), sep)
+	fmt.Fprintf(p.w, "%s\n%s\n%s\n", sep, p.colors.Bold(fmt.Sprintf("[%s] %s", objType, entry.DN)), sep)
 
 	keys, maxLen := p.sortKeys(attrs)
 	for _, k := range keys {
 		p.attr(k, attrs[k], maxLen)
 	}
-	fmt.Println()
+	fmt.Fprintln(p.w)
 }
 
 // toMap converts an LDAP entry to a map of formatted attributes.
@@ -163,7 +179,7 @@ func (p *textPrinter) attr(name, val string, maxKeyLen int) {
 	if len(valStr) > maxLineWidth {
 		valStr = valStr[:truncateLength] + "..."
 	}
-	fmt.Printf("%s%s : %s\n", keyStr, padding, valStr)
+	fmt.Fprintf(p.w, "%s%s : %s\n", keyStr, padding, valStr)
 }
 
 // colorize applies color formatting to attribute values based on sensitivity and type.
@@ -196,9 +212,9 @@ func (p *textPrinter) printMultiline(keyStr, padding, val string, indentLen int)
 	indent := strings.Repeat(" ", indentLen)
 	for i, part := range wrap(val, maxLineWidth) {
 		if i == 0 {
-			fmt.Printf("%s%s : %s\n", keyStr, padding, part)
+			fmt.Fprintf(p.w, "%s%s : %s\n", keyStr, padding, part)
 		} else {
-			fmt.Printf("%s%s\n", indent, part)
+			fmt.Fprintf(p.w, "%s%s\n", indent, part)
 		}
 	}
 }
@@ -227,36 +243,42 @@ func wrap(s string, width int) []string {
 
 // header prints the report header with the specified title.
 func (p *textPrinter) header(title string) {
-	fmt.Printf("\n  %s\n\n", p.colors.Cyan(fmt.Sprintf("%s  |  %s", reportTitle, title)))
+	if p.cfg.Quiet {
+		return
+	}
+	fmt.Fprintf(p.w, "\n  %s\n\n", p.colors.Cyan(fmt.Sprintf("%s  |  %s", reportTitle, title)))
 }
 
 // footer prints the report footer with entry count.
 func (p *textPrinter) footer(count int) {
-	fmt.Printf("Total Entries: %s\n", p.colors.Green(strconv.Itoa(count)))
+	fmt.Fprintf(p.w, "Total Entries: %s\n", p.colors.Green(strconv.Itoa(count)))
 }
 
 // printSummary prints the statistics summary at the end of card output.
 func (p *textPrinter) printSummary(stats Statistics) {
-	fmt.Printf("\n%s\n", p.colors.Dim(strings.Repeat(tableSeparator, 80)))
-	fmt.Printf("%s\n", p.colors.Bold("Summary:"))
+	if p.cfg.Quiet {
+		return
+	}
+	fmt.Fprintf(p.w, "\n%s\n", p.colors.Dim(strings.Repeat(tableSeparator, 80)))
+	fmt.Fprintf(p.w, "%s\n", p.colors.Bold("Summary:"))
 
 	if stats.Admins > 0 {
-		fmt.Printf("  [%s] Admins: %s\n", p.colors.Red("!"), p.colors.Red(strconv.Itoa(stats.Admins)))
+		fmt.Fprintf(p.w, "  [%s] Admins: %s\n", p.colors.Red("!"), p.colors.Red(strconv.Itoa(stats.Admins)))
 	}
 	if stats.SPN > 0 {
-		fmt.Printf("  [*] SPN Accounts: %s (Kerberoast targets)\n", p.colors.Green(strconv.Itoa(stats.SPN)))
+		fmt.Fprintf(p.w, "  [*] SPN Accounts: %s (Kerberoast targets)\n", p.colors.Green(strconv.Itoa(stats.SPN)))
 	}
 	if stats.ASRep > 0 {
-		fmt.Printf("  [*] AS-REP Roastable: %s\n", p.colors.Yellow(strconv.Itoa(stats.ASRep)))
+		fmt.Fprintf(p.w, "  [*] AS-REP Roastable: %s\n", p.colors.Yellow(strconv.Itoa(stats.ASRep)))
 	}
 	if stats.DCs > 0 {
-		fmt.Printf("  [*] Domain Controllers: %s\n", p.colors.Yellow(strconv.Itoa(stats.DCs)))
+		fmt.Fprintf(p.w, "  [*] Domain Controllers: %s\n", p.colors.Yellow(strconv.Itoa(stats.DCs)))
 	}
 
-	fmt.Printf("  Total: %s | Enabled: %s | Disabled: %s\n",
+	fmt.Fprintf(p.w, "  Total: %s | Enabled: %s | Disabled: %s\n",
 		p.colors.Green(strconv.Itoa(stats.Total)),
 		p.colors.Green(strconv.Itoa(stats.Enabled)),
 		p.colors.Yellow(strconv.Itoa(stats.Disabled)),
 	)
-	fmt.Printf("%s\n\n", p.colors.Dim(strings.Repeat(tableSeparator, 80)))
+	fmt.Fprintf(p.w, "%s\n\n", p.colors.Dim(strings.Repeat(tableSeparator, 80)))
 }
@@ -0,0 +1,395 @@
+package output
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"unicode"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// whereExpr is a parsed --where expression evaluated against a single entry.
+//
+// Grammar:
+//
+//	expr       = and { "||" and }
+//	and        = unary { "&&" unary }
+//	unary      = "!" unary | "(" expr ")" | comparison
+//	comparison = attribute [ operator value ]
+//	operator   = "==" | "!=" | "<" | "<=" | ">" | ">=" | "contains" | "startswith" | "endswith" | "matches"
+//
+// A bare attribute is true when the attribute is present. Values are quoted
+// strings or bare words. Comparisons are case-insensitive and numeric when
+// both sides are numbers.
+type whereExpr interface {
+	eval(attrs map[string]string, entry *ldap.Entry) bool
+}
+
+// whereBinary combines two expressions with && or ||
+type whereBinary struct {
+	op          string
+	left, right whereExpr
+}
+
+func (b whereBinary) eval(attrs map[string]string, entry *ldap.Entry) bool {
+	if b.op == "&&" {
+		return b.left.eval(attrs, entry) && b.right.eval(attrs, entry)
+	}
+	return b.left.eval(attrs, entry) || b.right.eval(attrs, entry)
+}
+
+// whereNot negates an expression
+type whereNot struct {
+	expr whereExpr
+}
+
+func (n whereNot) eval(attrs map[string]string, entry *ldap.Entry) bool {
+	return !n.expr.eval(attrs, entry)
+}
+
+// whereComparison compares an attribute with a literal value
+type whereComparison struct {
+	attr  string
+	op    string // Empty for a presence test
+	value string
+	re    *regexp.Regexp
+}
+
+// eval tests the formatted attribute value and each raw value; the comparison
+// holds if any of them matches, so "userAccountControl==512" works even though
+// the formatted value also lists flag names. "!=" holds if none of them equals.
+func (c whereComparison) eval(attrs map[string]string, entry *ldap.Entry) bool {
+	candidates := entry.GetEqualFoldAttributeValues(c.attr)
+	for name, v := range attrs {
+		if strings.EqualFold(name, c.attr) {
+			candidates = append(candidates, v)
+		}
+	}
+
+	if c.op == "" {
+		return len(candidates) > 0
+	}
+	if c.op == "!=" {
+		for _, v := range candidates {
+			if compareWhere(v, "==", c.value, nil) {
+				return false
+			}
+		}
+		return true
+	}
+	for _, v := range candidates {
+		if compareWhere(v, c.op, c.value, c.re) {
+			return true
+		}
+	}
+	return false
+}
+
+// compareWhere applies a single operator to an attribute value
+func compareWhere(actual, op, expected string, re *regexp.Regexp) bool {
+	a, b := strings.ToLower(actual), strings.ToLower(expected)
+	switch op {
+	case "contains":
+		return strings.Contains(a, b)
+	case "startswith":
+		return strings.HasPrefix(a, b)
+	case "endswith":
+		return strings.HasSuffix(a, b)
+	case "matches":
+		return re.MatchString(actual)
+	}
+
+	x, errX := strconv.ParseFloat(strings.TrimSpace(actual), 64)
+	y, errY := strconv.ParseFloat(expected, 64)
+	numeric := errX == nil && errY == nil
+	cmp := strings.Compare(a, b)
+	if numeric {
+		switch {
+		case x < y:
+			cmp = -1
+		case x > y:
+			cmp = 1
+		default:
+			cmp = 0
+		}
+	}
+
+	switch op {
+	case "==":
+		return cmp == 0
+	case "<":
+		return cmp < 0
+	case "<=":
+		return cmp <= 0
+	case ">":
+		return cmp > 0
+	case ">=":
+		return cmp >= 0
+	}
+	return false
+}
+
+// whereToken is a lexical token of a --where expression
+type whereToken struct {
+	text   string
+	quoted bool
+}
+
+// whereParser is a recursive descent parser over the token list
+type whereParser struct {
+	tokens []whereToken
+	pos    int
+}
+
+// parseWhere parses a --where expression
+func parseWhere(input string) (whereExpr, error) {
+	tokens, err := tokenizeWhere(input)
+	if err != nil {
+		return nil, err
+	}
+	if len(tokens) == 0 {
+		return nil, fmt.Errorf("empty --where expression")
+	}
+
+	p := &whereParser{tokens: tokens}
+	expr, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if p.pos < len(p.tokens) {
+		return nil, fmt.Errorf("unexpected %q in --where expression", p.tokens[p.pos].text)
+	}
+	return expr, nil
+}
+
+// tokenizeWhere splits an expression into operators, parentheses, quoted strings and words
+func tokenizeWhere(input string) ([]whereToken, error) {
+	var tokens []whereToken
+	runes := []rune(input)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(' || r == ')':
+			tokens = append(tokens, whereToken{text: string(r)})
+			i++
+		case r == '"' || r == '\'':
+			j := i + 1
+			var b strings.Builder
+			for ; j < len(runes) && runes[j] != r; j++ {
+				if runes[j] == '\\' && j+1 < len(runes) {
+					j++
+				}
+				b.WriteRune(runes[j])
+			}
+			if j >= len(runes) {
+				return nil, fmt.Errorf("unterminated string in --where expression")
+			}
+			tokens = append(tokens, whereToken{text: b.String(), quoted: true})
+			i = j + 1
+		case strings.ContainsRune("=!<>&|", r):
+			j := i + 1
+			for j < len(runes) && strings.ContainsRune("=<>&|", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, whereToken{text: string(runes[i:j])})
+			i = j
+		default:
+			j := i
+			for j < len(runes) && !unicode.IsSpace(runes[j]) && !strings.ContainsRune("()\"'=!<>&|", runes[j]) {
+				j++
+			}
+			tokens = append(tokens, whereToken{text: string(runes[i:j])})
+			i = j
+		}
+	}
+	return tokens, nil
+}
+
+// peek returns the current operator or keyword, or "" at the end of input
+func (p *whereParser) peek() string {
+	if p.pos >= len(p.tokens) || p.tokens[p.pos].quoted {
+		return ""
+	}
+	return p.tokens[p.pos].text
+}
+
+func (p *whereParser) parseOr() (whereExpr, error) {
+	left, err := p.parseAnd()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "||" {
+		p.pos++
+		right, err := p.parseAnd()
+		if err != nil {
+			return nil, err
+		}
+		left = whereBinary{op: "||", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseAnd() (whereExpr, error) {
+	left, err := p.parseUnary()
+	if err != nil {
+		return nil, err
+	}
+	for p.peek() == "&&" {
+		p.pos++
+		right, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		left = whereBinary{op: "&&", left: left, right: right}
+	}
+	return left, nil
+}
+
+func (p *whereParser) parseUnary() (whereExpr, error) {
+	switch p.peek() {
+	case "!":
+		p.pos++
+		expr, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		return whereNot{expr: expr}, nil
+	case "(":
+		p.pos++
+		expr, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if p.peek() != ")" {
+			return nil, fmt.Errorf("missing ) in --where expression")
+		}
+		p.pos++
+		return expr, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *whereParser) parseComparison() (whereExpr, error) {
+	switch tok := p.peek(); {
+	case p.pos >= len(p.tokens), p.tokens[p.pos].quoted, isWhereOperator(tok), strings.ContainsAny(tok, "()!&|"):
+		return nil, fmt.Errorf("expected attribute name in --where expression")
+	}
+
+	c := whereComparison{attr: p.tokens[p.pos].text}
+	p.pos++
+
+	op := strings.ToLower(p.peek())
+	if !isWhereOperator(op) {
+		return c, nil
+	}
+	p.pos++
+
+	if p.pos >= len(p.tokens) {
+		return nil, fmt.Errorf("missing value after %s in --where expression", op)
+	}
+	c.op = op
+	c.value = p.tokens[p.pos].text
+	p.pos++
+
+	if op == "matches" {
+		re, err := regexp.Compile("(?i)" + c.value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression in --where expression: %w", err)
+		}
+		c.re = re
+	}
+	return c, nil
+}
+
+// WhereAttributes returns the attributes referenced by a --where expression,
+// so they can be requested from the server. Invalid expressions yield nil;
+// NewPrinter reports the parse error.
+func WhereAttributes(where string) []string {
+	if where == "" {
+		return nil
+	}
+	expr, err := parseWhere(where)
+	if err != nil {
+		return nil
+	}
+
+	var attrs []string
+	var walk func(whereExpr)
+	walk = func(e whereExpr) {
+		switch e := e.(type) {
+		case whereBinary:
+			walk(e.left)
+			walk(e.right)
+		case whereNot:
+			walk(e.expr)
+		case whereComparison:
+			attrs = append(attrs, e.attr)
+		}
+	}
+	walk(expr)
+	return attrs
+}
+
+// isWhereOperator reports whether s is a comparison operator
+func isWhereOperator(s string) bool {
+	switch strings.ToLower(s) {
+	case "==", "!=", "<", "<=", ">", ">=", "contains", "startswith", "endswith", "matches":
+		return true
+	}
+	return false
+}
+
+// wherePrinter drops entries that do not match an expression before
+// handing them to the wrapped printer.
+type wherePrinter struct {
+	next Printer
+	expr whereExpr
+}
+
+// newWherePrinter wraps next so it only receives entries matching where
+func newWherePrinter(next Printer, where string) (Printer, error) {
+	expr, err := parseWhere(where)
+	if err != nil {
+		return nil, err
+	}
+	return &wherePrinter{next: next, expr: expr}, nil
+}
+
+// match evaluates the expression against an entry
+func (p *wherePrinter) match(e *ldap.Entry) bool {
+	return p.expr.eval(formatEntryAttributes(e), e)
+}
+
+// Print filters entries and prints the matching ones.
+func (p *wherePrinter) Print(entries []*ldap.Entry) error {
+	var matched []*ldap.Entry
+	for _, e := range entries {
+		if e != nil && p.match(e) {
+			matched = append(matched, e)
+		}
+	}
+	return p.next.Print(matched)
+}
+
+// StreamPrint filters entries as they arrive and streams the matching ones.
+func (p *wherePrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	filtered := make(chan *ldap.Entry)
+	go func() {
+		defer close(filtered)
+		for e := range entriesChan {
+			if e != nil && p.match(e) {
+				filtered <- e
+			}
+		}
+	}()
+
+	err := p.next.StreamPrint(filtered)
+	// Drain so the search is not blocked if the printer stopped early
+	for range filtered {
+	}
+	return err
+}
@@ -0,0 +1,121 @@
+package output
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// whereEntry is the account every --where test case is evaluated against
+var whereEntry = ldap.NewEntry("CN=svc_sql,CN=Users,DC=example,DC=com", map[string][]string{
+	"sAMAccountName":       {"svc_sql"},
+	"description":          {`SQL "prod" service`},
+	"department":           {"IT Ops"},
+	"adminCount":           {"1"},
+	"logonCount":           {"42"},
+	"servicePrincipalName": {"MSSQLSvc/db01:1433", "MSSQLSvc/db01.example.com:1433"},
+})
+
+func TestWhere(t *testing.T) {
+	tests := []struct {
+		name  string
+		where string
+		want  bool
+	}{
+		// Presence
+		{"present", "servicePrincipalName", true},
+		{"absent", "mail", false},
+		{"not absent", "!mail", true},
+		{"attribute name case", "SAMACCOUNTNAME==svc_sql", true},
+
+		// Comparison operators
+		{"equal", "sAMAccountName==svc_sql", true},
+		{"equal ignores case", "sAMAccountName==SVC_SQL", true},
+		{"not equal", "sAMAccountName!=krbtgt", true},
+		{"not equal any value", "servicePrincipalName!=MSSQLSvc/db01:1433", false},
+		{"missing not equal", "mail!=x", true},
+		{"missing equal", "mail==x", false},
+		{"numeric greater", "logonCount>9", true},
+		{"numeric less", "logonCount<100", true},
+		{"numeric less or equal", "logonCount<=42", true},
+		{"numeric greater or equal", "logonCount>=43", false},
+		{"numeric equal", "logonCount==42.0", true},
+		{"string less", "sAMAccountName<svc_tst", true},
+		{"contains", "department contains \"it op\"", true},
+		{"startswith", "sAMAccountName startswith svc_", true},
+		{"endswith any value", "servicePrincipalName endswith .example.com:1433", true},
+		{"matches", "sAMAccountName matches '^svc_[a-z]+$'", true},
+		{"matches ignores case", "sAMAccountName MATCHES ^SVC", true},
+		{"matches fails", "sAMAccountName matches ^adm", false},
+
+		// Quoting
+		{"double quoted", "department==\"IT Ops\"", true},
+		{"single quoted", "description=='SQL \"prod\" service'", true},
+		{"escaped quote", `description=="SQL \"prod\" service"`, true},
+		{"quoted operators", "department!=\"a && b || c\"", true},
+
+		// Precedence
+		{"and binds tighter than or", "adminCount==1 || adminCount==0 && logonCount>100", true},
+		{"parentheses", "(adminCount==1 || adminCount==0) && logonCount>100", false},
+		{"not binds tighter than and", "!adminCount==0 && logonCount==42", true},
+		{"not parenthesised", "!(adminCount==1 && logonCount==42)", false},
+		{"double not", "!!adminCount", true},
+		{"no spaces", "logonCount>=42&&adminCount==1||mail", true},
+	}
+	for _, tt := range tests {
+		expr, err := parseWhere(tt.where)
+		if err != nil {
+			t.Errorf("%s: parseWhere(%q): %v", tt.name, tt.where, err)
+			continue
+		}
+		if got := expr.eval(nil, whereEntry); got != tt.want {
+			t.Errorf("%s: %q = %v, want %v", tt.name, tt.where, got, tt.want)
+		}
+	}
+}
+
+func TestWhereMalformed(t *testing.T) {
+	tests := []struct {
+		where string
+		want  string
+	}{
+		{"", "empty"},
+		{"   ", "empty"},
+		{"adminCount==", "missing value"},
+		{"(adminCount==1", "missing )"},
+		{"adminCount==1)", "unexpected \")\""},
+		{"adminCount = 1", "unexpected \"=\""},
+		{"adminCount==1 logonCount==2", "unexpected \"logonCount\""},
+		{"adminCount & logonCount", "unexpected \"&\""},
+		{"&& adminCount", "expected attribute"},
+		{"adminCount==1 &&", "expected attribute"},
+		{"!", "expected attribute"},
+		{"==1", "expected attribute"},
+		{"\"adminCount\"==1", "expected attribute"},
+		{"description==\"SQL", "unterminated string"},
+		{"sAMAccountName matches \"[\"", "invalid regular expression"},
+	}
+	for _, tt := range tests {
+		_, err := parseWhere(tt.where)
+		if err == nil {
+			t.Errorf("parseWhere(%q) accepted a malformed expression", tt.where)
+			continue
+		}
+		if !strings.Contains(err.Error(), tt.want) {
+			t.Errorf("parseWhere(%q) error = %q, want it to contain %q", tt.where, err, tt.want)
+		}
+	}
+}
+
+func TestWhereAttributes(t *testing.T) {
+	got := WhereAttributes("adminCount==1 && !(mail || logonCount>5)")
+	want := []string{"adminCount", "mail", "logonCount"}
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("WhereAttributes = %v, want %v", got, want)
+	}
+	if got := WhereAttributes("adminCount=="); got != nil {
+		t.Errorf("WhereAttributes of an invalid expression = %v, want nil", got)
+	}
+}
@@ -0,0 +1,242 @@
+package output
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// Limits enforced by Excel when opening a workbook
+const (
+	xlsxMaxSheetName  = 31    // Longest worksheet name
+	xlsxMaxCellLength = 32767 // Longest cell text
+)
+
+// xlsxPrinter outputs LDAP entries as an Excel workbook.
+// Entries are split into one worksheet per object type; each sheet has a
+// frozen header row and an auto-filter over its columns.
+type xlsxPrinter struct {
+	cfg PrinterConfig
+}
+
+// newXLSXPrinter creates a new XLSX printer instance.
+func newXLSXPrinter(cfg PrinterConfig) Printer {
+	return &xlsxPrinter{cfg: cfg}
+}
+
+// xlsxSheet is a worksheet with its header and rows.
+type xlsxSheet struct {
+	Name string
+	Rows [][]string
+}
+
+// Print writes all entries to an XLSX workbook.
+// If Path is empty, the workbook is written to stdout.
+func (p *xlsxPrinter) Print(entries []*ldap.Entry) error {
+	sheets := p.buildSheets(entries)
+
+	w, closeFn, err := createOutput(p.cfg)
+	if err != nil {
+		return err
+	}
+	defer closeFn()
+
+	return writeXLSX(w, sheets)
+}
+
+// StreamPrint collects entries and writes the workbook.
+// Sheet columns depend on every entry of the type, so output waits for the stream to end.
+func (p *xlsxPrinter) StreamPrint(entriesChan <-chan *ldap.Entry) error {
+	var entries []*ldap.Entry
+	for entry := range entriesChan {
+		if entry != nil {
+			entries = append(entries, entry)
+		}
+	}
+	return p.Print(entries)
+}
+
+// buildSheets groups entries by object type and lays out each group in the
+// CSV wide format: a DN column followed by one column per attribute.
+func (p *xlsxPrinter) buildSheets(entries []*ldap.Entry) []xlsxSheet {
+	groups := make(map[string][]*ldap.Entry)
+	var order []string
+	for _, entry := range entries {
+		kind := bloodHoundCEKind(entry)
+		if kind == "" {
+			kind = "other"
+		}
+		if _, ok := groups[kind]; !ok {
+			order = append(order, kind)
+		}
+		groups[kind] = append(groups[kind], entry)
+	}
+	sort.Strings(order)
+
+	csv := &csvPrinter{cfg: p.cfg}
+	sheets := make([]xlsxSheet, 0, len(order))
+	for _, kind := range order {
+		header := append([]string{"DN"}, csv.collectAttrs(groups[kind])...)
+		rows := [][]string{header}
+		for _, entry := range groups[kind] {
+			rows = append(rows, csv.buildRow(entry, header))
+		}
+		sheets = append(sheets, xlsxSheet{Name: xlsxSheetName(kind), Rows: rows})
+	}
+
+	// A workbook needs at least one sheet
+	if len(sheets) == 0 {
+		sheets = append(sheets, xlsxSheet{Name: "results", Rows: [][]string{{"DN"}}})
+	}
+	return sheets
+}
+
+// xlsxSheetName truncates a name to the length Excel allows
+func xlsxSheetName(name string) string {
+	if len(name) > xlsxMaxSheetName {
+		return name[:xlsxMaxSheetName]
+	}
+	return name
+}
+
+// writeXLSX writes a minimal SpreadsheetML package containing the given sheets.
+// Cell values are stored as inline strings, so no shared string table is needed.
+func writeXLSX(w io.Writer, sheets []xlsxSheet) error {
+	zw := zip.NewWriter(w)
+
+	var contentTypes, workbook, rels bytes.Buffer
+	contentTypes.WriteString(xml.Header)
+	contentTypes.WriteString(`<Types xmlns="http://schemas.openxmlformats.org/package/2006/content-types">` +
+		`<Default Extension="rels" ContentType="application/vnd.openxmlformats-package.relationships+xml"/>` +
+		`<Default Extension="xml" ContentType="application/xml"/>` +
+		`<Override PartName="/xl/workbook.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.sheet.main+xml"/>`)
+
+	workbook.WriteString(xml.Header)
+	workbook.WriteString(`<workbook xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main" ` +
+		`xmlns:r="http://schemas.openxmlformats.org/officeDocument/2006/relationships"><sheets>`)
+
+	rels.WriteString(xml.Header)
+	rels.WriteString(`<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">`)
+
+	var definedNames bytes.Buffer
+	for i, sheet := range sheets {
+		n := i + 1
+		fmt.Fprintf(&contentTypes, `<Override PartName="/xl/worksheets/sheet%d.xml" ContentType="application/vnd.openxmlformats-officedocument.spreadsheetml.worksheet+xml"/>`, n)
+		fmt.Fprintf(&workbook, `<sheet name="%s" sheetId="%d" r:id="rId%d"/>`, xmlEscape(sheet.Name), n, n)
+		fmt.Fprintf(&rels, `<Relationship Id="rId%d" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/worksheet" Target="worksheets/sheet%d.xml"/>`, n, n)
+		// Excel expects the hidden _FilterDatabase name for each auto-filter
+		fmt.Fprintf(&definedNames, `<definedName name="_xlnm._FilterDatabase" localSheetId="%d" hidden="1">'%s'!%s</definedName>`,
+			i, xmlEscape(sheet.Name), xlsxAbsoluteRange(sheet))
+
+		if err := writeXLSXPart(zw, fmt.Sprintf("xl/worksheets/sheet%d.xml", n), xlsxWorksheet(sheet)); err != nil {
+			return err
+		}
+	}
+
+	contentTypes.WriteString(`</Types>`)
+	workbook.WriteString(`</sheets><definedNames>`)
+	workbook.Write(definedNames.Bytes())
+	workbook.WriteString(`</definedNames></workbook>`)
+	rels.WriteString(`</Relationships>`)
+
+	rootRels := xml.Header + `<Relationships xmlns="http://schemas.openxmlformats.org/package/2006/relationships">` +
+		`<Relationship Id="rId1" Type="http://schemas.openxmlformats.org/officeDocument/2006/relationships/officeDocument" Target="xl/workbook.xml"/>` +
+		`</Relationships>`
+
+	parts := []struct {
+		name string
+		data []byte
+	}{
+		{"[Content_Types].xml", contentTypes.Bytes()},
+		{"_rels/.rels", []byte(rootRels)},
+		{"xl/workbook.xml", workbook.Bytes()},
+		{"xl/_rels/workbook.xml.rels", rels.Bytes()},
+	}
+	for _, part := range parts {
+		if err := writeXLSXPart(zw, part.name, part.data); err != nil {
+			return err
+		}
+	}
+
+	if err := zw.Close(); err != nil {
+		return fmt.Errorf("failed to finalize XLSX file: %w", err)
+	}
+	return nil
+}
+
+// writeXLSXPart adds a single file to the workbook package
+func writeXLSXPart(zw *zip.Writer, name string, data []byte) error {
+	f, err := zw.Create(name)
+	if err != nil {
+		return fmt.Errorf("failed to add %s to XLSX file: %w", name, err)
+	}
+	if _, err := f.Write(data); err != nil {
+		return fmt.Errorf("failed to write %s to XLSX file: %w", name, err)
+	}
+	return nil
+}
+
+// xlsxWorksheet renders a sheet with a frozen header row and an auto-filter
+func xlsxWorksheet(sheet xlsxSheet) []byte {
+	var buf bytes.Buffer
+	buf.WriteString(xml.Header)
+	buf.WriteString(`<worksheet xmlns="http://schemas.openxmlformats.org/spreadsheetml/2006/main">`)
+	buf.WriteString(`<sheetViews><sheetView workbookViewId="0">` +
+		`<pane ySplit="1" topLeftCell="A2" activePane="bottomLeft" state="frozen"/>` +
+		`</sheetView></sheetViews>`)
+
+	buf.WriteString(`<sheetData>`)
+	for r, row := range sheet.Rows {
+		fmt.Fprintf(&buf, `<row r="%d">`, r+1)
+		for c, value := range row {
+			if value == "" {
+				continue
+			}
+			if len(value) > xlsxMaxCellLength {
+				value = strings.ToValidUTF8(value[:xlsxMaxCellLength], "")
+			}
+			fmt.Fprintf(&buf, `<c r="%s%d" t="inlineStr"><is><t xml:space="preserve">%s</t></is></c>`,
+				xlsxColumn(c), r+1, xmlEscape(value))
+		}
+		buf.WriteString(`</row>`)
+	}
+	buf.WriteString(`</sheetData>`)
+
+	fmt.Fprintf(&buf, `<autoFilter ref="%s"/>`, xlsxRange(sheet))
+	buf.WriteString(`</worksheet>`)
+	return buf.Bytes()
+}
+
+// xlsxRange returns the cell range covered by a sheet (e.g., A1:D42)
+func xlsxRange(sheet xlsxSheet) string {
+	return "A1:" + xlsxColumn(len(sheet.Rows[0])-1) + strconv.Itoa(len(sheet.Rows))
+}
+
+// xlsxAbsoluteRange returns the sheet range with absolute references (e.g., $A$1:$D$42)
+func xlsxAbsoluteRange(sheet xlsxSheet) string {
+	return "$A$1:$" + xlsxColumn(len(sheet.Rows[0])-1) + "$" + strconv.Itoa(len(sheet.Rows))
+}
+
+// xlsxColumn converts a zero-based column index to its letter name (0 -> A, 26 -> AA)
+func xlsxColumn(index int) string {
+	name := ""
+	for index >= 0 {
+		name = string(rune('A'+index%26)) + name
+		index = index/26 - 1
+	}
+	return name
+}
+
+// xmlEscape escapes text for use in XML content and attribute values
+func xmlEscape(s string) string {
+	var buf bytes.Buffer
+	_ = xml.EscapeText(&buf, []byte(s))
+	return buf.String()
+}
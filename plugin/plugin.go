@@ -0,0 +1,144 @@
+// Package plugin discovers external plugin executables and talks to them
+// over JSON on stdin and stdout. A plugin can add queries, format
+// attributes adgo leaves raw and provide post-processing steps for results.
+//
+// A plugin is any executable in the plugins directory. adgo runs
+//
+//	<plugin> describe
+//
+// once at startup and reads a Manifest as JSON from its stdout. Plugins
+// with formatters or steps are started as
+//
+//	<plugin> serve
+//
+// when first needed and receive one Request per line on stdin, answering
+// each with one Response line on stdout until stdin is closed.
+package plugin
+
+import (
+	"adgo/queries"
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"sort"
+	"strings"
+	"time"
+)
+
+// describeTimeout bounds how long a plugin may take to print its manifest
+const describeTimeout = 10 * time.Second
+
+// Manifest is what a plugin prints for "describe"
+type Manifest struct {
+	Name        string               `json:"name"`                  // Unique plugin name
+	Version     string               `json:"version,omitempty"`     // Plugin version, shown by adgo plugins list
+	Description string               `json:"description,omitempty"` // One-line summary
+	Queries     []queries.Definition `json:"queries,omitempty"`     // Queries registered as quick subcommands
+	Formatters  []string             `json:"formatters,omitempty"`  // Attributes the plugin formats
+	Steps       []string             `json:"steps,omitempty"`       // Post-processing steps the plugin provides
+}
+
+// Plugin is a discovered plugin executable
+type Plugin struct {
+	Path     string
+	Manifest Manifest
+
+	server server // The "serve" process, started on first use
+}
+
+// Discover runs "describe" for every executable in dir and returns the
+// plugins sorted by name. Plugins that fail to describe themselves are
+// skipped and reported in the returned error. A missing dir has no plugins.
+func Discover(dir string) ([]*Plugin, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("reading plugins directory: %w", err)
+	}
+
+	var plugins []*Plugin
+	var errs []error
+	names := make(map[string]string)
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() || !isExecutable(path) {
+			continue
+		}
+
+		m, err := describe(path)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("plugin %s: %w", e.Name(), err))
+			continue
+		}
+		if other, ok := names[m.Name]; ok {
+			errs = append(errs, fmt.Errorf("plugin %s: name %q is already used by %s", e.Name(), m.Name, other))
+			continue
+		}
+		names[m.Name] = e.Name()
+
+		p := &Plugin{Path: path, Manifest: m}
+		p.server.path = path
+		plugins = append(plugins, p)
+	}
+
+	sort.Slice(plugins, func(i, j int) bool { return plugins[i].Manifest.Name < plugins[j].Manifest.Name })
+	return plugins, errors.Join(errs...)
+}
+
+// describe runs "describe" and parses the manifest
+func describe(path string) (Manifest, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), describeTimeout)
+	defer cancel()
+
+	var stdout, stderr bytes.Buffer
+	cmd := exec.CommandContext(ctx, path, "describe")
+	cmd.Stdout = &stdout
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		if msg := strings.TrimSpace(stderr.String()); msg != "" {
+			return Manifest{}, fmt.Errorf("describe: %w: %s", err, msg)
+		}
+		return Manifest{}, fmt.Errorf("describe: %w", err)
+	}
+
+	var m Manifest
+	if err := json.Unmarshal(stdout.Bytes(), &m); err != nil {
+		return Manifest{}, fmt.Errorf("parsing manifest: %w", err)
+	}
+	if m.Name == "" {
+		return Manifest{}, fmt.Errorf("manifest has no name")
+	}
+	for _, step := range m.Steps {
+		if step == "" {
+			return Manifest{}, fmt.Errorf("manifest has an unnamed step")
+		}
+	}
+	return m, nil
+}
+
+// isExecutable reports whether path is a file the OS can run: an .exe or
+// .com file on Windows, a file with an execute bit elsewhere
+func isExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil || !info.Mode().IsRegular() {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".com"
+	}
+	return info.Mode().Perm()&0111 != 0
+}
+
+// Close stops the serve process of the plugin, if it was started
+func (p *Plugin) Close() error {
+	return p.server.close()
+}
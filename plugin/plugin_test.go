@@ -0,0 +1,201 @@
+package plugin
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// fakePluginEnv makes the test binary act as the plugin it was copied to
+const fakePluginEnv = "ADGO_TEST_PLUGIN"
+
+func TestMain(m *testing.M) {
+	if os.Getenv(fakePluginEnv) != "" && len(os.Args) > 1 {
+		os.Exit(runFakePlugin(os.Args[1]))
+	}
+	os.Exit(m.Run())
+}
+
+// runFakePlugin implements the plugin named like the executable:
+// "hr" works, "broken" fails to describe itself and "zdup" reuses the
+// name of hr
+func runFakePlugin(command string) int {
+	name := strings.TrimSuffix(filepath.Base(os.Args[0]), ".exe")
+	switch command {
+	case "describe":
+		switch name {
+		case "broken":
+			fmt.Fprintln(os.Stderr, "no manifest today")
+			return 1
+		case "zdup":
+			fmt.Println(`{"name":"hr"}`)
+		default:
+			fmt.Println(`{"name":"hr","version":"1.0",` +
+				`"queries":[{"name":"contractors","filter":"(employeeType=Contractor)"}],` +
+				`"formatters":["extensionAttribute7"],"steps":["enrich","drop","fail"]}`)
+		}
+		return 0
+	case "serve":
+		serveFake(os.Stdin, os.Stdout)
+		return 0
+	}
+	return 2
+}
+
+// serveFake answers format and process requests of the hr plugin
+func serveFake(in io.Reader, out io.Writer) {
+	enc := json.NewEncoder(out)
+	scanner := bufio.NewScanner(in)
+	for scanner.Scan() {
+		var req Request
+		if err := json.Unmarshal(scanner.Bytes(), &req); err != nil {
+			enc.Encode(Response{Error: err.Error()})
+			continue
+		}
+		switch {
+		case req.Method == MethodFormat:
+			// The entry only carries the attribute to format
+			var value string
+			for _, values := range req.Entry.Attributes {
+				value = strings.Join(values, ",")
+			}
+			enc.Encode(Response{Value: "Cost center " + value})
+		case req.Method == MethodProcess && req.Step == "enrich":
+			req.Entry.Attributes["department"] = []string{"Finance"}
+			enc.Encode(Response{Entry: req.Entry})
+		case req.Method == MethodProcess && req.Step == "drop":
+			enc.Encode(Response{})
+		default:
+			enc.Encode(Response{Error: "cannot " + req.Method + " " + req.Step})
+		}
+	}
+}
+
+// installFakePlugin copies the test binary into dir as the plugin name
+func installFakePlugin(t *testing.T, dir, name string) {
+	t.Helper()
+	self, err := os.Executable()
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := os.ReadFile(self)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if runtime.GOOS == "windows" {
+		name += ".exe"
+	}
+	if err := os.WriteFile(filepath.Join(dir, name), data, 0755); err != nil {
+		t.Fatal(err)
+	}
+}
+
+// discoverHR returns the hr plugin of a directory with the fake plugins
+func discoverHR(t *testing.T) *Plugin {
+	t.Helper()
+	t.Setenv(fakePluginEnv, "1")
+	dir := t.TempDir()
+	installFakePlugin(t, dir, "hr")
+
+	plugins, err := Discover(dir)
+	if err != nil || len(plugins) != 1 {
+		t.Fatalf("Discover = %d plugins, %v", len(plugins), err)
+	}
+	t.Cleanup(func() { plugins[0].Close() })
+	return plugins[0]
+}
+
+func TestDiscover(t *testing.T) {
+	t.Setenv(fakePluginEnv, "1")
+	dir := t.TempDir()
+	for _, name := range []string{"hr", "broken", "zdup"} {
+		installFakePlugin(t, dir, name)
+	}
+	if err := os.WriteFile(filepath.Join(dir, "README.txt"), []byte("not a plugin"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.Mkdir(filepath.Join(dir, "lib"), 0755); err != nil {
+		t.Fatal(err)
+	}
+
+	plugins, err := Discover(dir)
+	if len(plugins) != 1 {
+		t.Fatalf("Discover found %d plugins, want 1", len(plugins))
+	}
+	m := plugins[0].Manifest
+	if m.Name != "hr" || m.Version != "1.0" || len(m.Queries) != 1 || m.Queries[0].Name != "contractors" ||
+		len(m.Formatters) != 1 || len(m.Steps) != 3 {
+		t.Errorf("manifest = %+v", m)
+	}
+
+	if err == nil {
+		t.Fatal("Discover did not report the failing plugins")
+	}
+	for _, want := range []string{"no manifest today", `name "hr" is already used`} {
+		if !strings.Contains(err.Error(), want) {
+			t.Errorf("error %q does not contain %q", err, want)
+		}
+	}
+}
+
+func TestDiscoverMissingDir(t *testing.T) {
+	plugins, err := Discover(filepath.Join(t.TempDir(), "plugins"))
+	if plugins != nil || err != nil {
+		t.Errorf("Discover of a missing dir = %v, %v; want nil, nil", plugins, err)
+	}
+}
+
+func TestServe(t *testing.T) {
+	p := discoverHR(t)
+	e := ldap.NewEntry("CN=alice,DC=example,DC=com", map[string][]string{
+		"sAMAccountName":      {"alice"},
+		"extensionAttribute7": {"42"},
+	})
+
+	value, err := p.Format(e, "EXTENSIONATTRIBUTE7")
+	if err != nil || value != "Cost center 42" {
+		t.Errorf("Format = %q, %v; want Cost center 42", value, err)
+	}
+
+	processed, err := p.Process("enrich", e)
+	if err != nil {
+		t.Fatalf("Process(enrich): %v", err)
+	}
+	if processed.DN != e.DN || processed.GetAttributeValue("sAMAccountName") != "alice" ||
+		processed.GetAttributeValue("department") != "Finance" {
+		t.Errorf("Process(enrich) = %s %v", processed.DN, attributeValues(processed))
+	}
+
+	if dropped, err := p.Process("drop", e); dropped != nil || err != nil {
+		t.Errorf("Process(drop) = %v, %v; want nil, nil", dropped, err)
+	}
+
+	// A failed request does not stop the plugin
+	if _, err := p.Process("fail", e); err == nil || !strings.Contains(err.Error(), "cannot process fail") {
+		t.Errorf("Process(fail) error = %v", err)
+	}
+	if value, err := p.Format(e, "extensionAttribute7"); err != nil || value != "Cost center 42" {
+		t.Errorf("Format after a failed request = %q, %v", value, err)
+	}
+
+	if err := p.Close(); err != nil {
+		t.Errorf("Close: %v", err)
+	}
+}
+
+// attributeValues lists the attributes of e for error messages
+func attributeValues(e *ldap.Entry) map[string][]string {
+	attrs := make(map[string][]string, len(e.Attributes))
+	for _, a := range e.Attributes {
+		attrs[a.Name] = a.Values
+	}
+	return attrs
+}
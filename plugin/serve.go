@@ -0,0 +1,172 @@
+package plugin
+
+import (
+	"adgo/output"
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"os/exec"
+	"strings"
+	"sync"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// maxResponseLine bounds a single response line of a plugin
+const maxResponseLine = 64 << 20
+
+// Request methods
+const (
+	MethodFormat  = "format"  // Format Attribute of Entry
+	MethodProcess = "process" // Run Step on Entry
+)
+
+// Request is one line sent to a serving plugin. Entries use the line
+// format of -o raw output: text values under "attributes", base64 values
+// of binary attributes under "binary".
+type Request struct {
+	Method    string           `json:"method"`
+	Attribute string           `json:"attribute,omitempty"` // Attribute to format
+	Step      string           `json:"step,omitempty"`      // Step to run
+	Entry     *output.RawEntry `json:"entry"`               // For format, only the DN and Attribute
+}
+
+// Response is one line a serving plugin answers a Request with
+type Response struct {
+	Value string           `json:"value,omitempty"` // Formatted value
+	Entry *output.RawEntry `json:"entry,omitempty"` // Processed entry; null drops the entry
+	Error string           `json:"error,omitempty"` // Request failed
+}
+
+// server is the serve process of a plugin. Requests are serialized.
+type server struct {
+	path string
+
+	mu     sync.Mutex
+	cmd    *exec.Cmd
+	stdin  io.WriteCloser
+	enc    *json.Encoder
+	stdout *bufio.Scanner
+	err    error // Set once the process failed; later requests return it
+}
+
+// start runs the serve process; mu must be held
+func (s *server) start() error {
+	cmd := exec.Command(s.path, "serve")
+	cmd.Stderr = os.Stderr
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return fmt.Errorf("starting %s: %w", s.path, err)
+	}
+
+	s.cmd, s.stdin = cmd, stdin
+	s.enc = json.NewEncoder(stdin)
+	s.enc.SetEscapeHTML(false)
+	s.stdout = bufio.NewScanner(stdout)
+	s.stdout.Buffer(nil, maxResponseLine)
+	return nil
+}
+
+// call sends req and reads the response, starting the process on first use
+func (s *server) call(req Request) (Response, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.err != nil {
+		return Response{}, s.err
+	}
+	if s.cmd == nil {
+		if s.err = s.start(); s.err != nil {
+			return Response{}, s.err
+		}
+	}
+
+	if err := s.enc.Encode(req); err != nil {
+		s.err = fmt.Errorf("sending request: %w", err)
+		return Response{}, s.err
+	}
+	if !s.stdout.Scan() {
+		err := s.stdout.Err()
+		if err == nil {
+			err = io.ErrUnexpectedEOF
+		}
+		s.err = fmt.Errorf("reading response: %w", err)
+		return Response{}, s.err
+	}
+
+	var resp Response
+	if err := json.Unmarshal(s.stdout.Bytes(), &resp); err != nil {
+		return Response{}, fmt.Errorf("parsing response: %w", err)
+	}
+	if resp.Error != "" {
+		return Response{}, fmt.Errorf("%s", resp.Error)
+	}
+	return resp, nil
+}
+
+// close ends the serve process by closing its stdin
+func (s *server) close() error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if s.cmd == nil {
+		return nil
+	}
+	s.stdin.Close()
+	err := s.cmd.Wait()
+	s.cmd = nil
+	return err
+}
+
+// Format asks the plugin to format an attribute of e
+func (p *Plugin) Format(e *ldap.Entry, attribute string) (string, error) {
+	only := &ldap.Entry{DN: e.DN}
+	if attr := findAttribute(e, attribute); attr != nil {
+		only.Attributes = []*ldap.EntryAttribute{attr}
+	}
+	raw := output.NewRawEntry(only)
+
+	resp, err := p.server.call(Request{Method: MethodFormat, Attribute: attribute, Entry: &raw})
+	if err != nil {
+		return "", fmt.Errorf("plugin %s: formatting %s: %w", p.Manifest.Name, attribute, err)
+	}
+	return resp.Value, nil
+}
+
+// Process runs a post-processing step of the plugin on e. It returns the
+// processed entry, or nil if the plugin dropped it.
+func (p *Plugin) Process(step string, e *ldap.Entry) (*ldap.Entry, error) {
+	raw := output.NewRawEntry(e)
+
+	resp, err := p.server.call(Request{Method: MethodProcess, Step: step, Entry: &raw})
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: step %s: %w", p.Manifest.Name, step, err)
+	}
+	if resp.Entry == nil {
+		return nil, nil
+	}
+	processed, err := resp.Entry.Entry()
+	if err != nil {
+		return nil, fmt.Errorf("plugin %s: step %s: %w", p.Manifest.Name, step, err)
+	}
+	return processed, nil
+}
+
+// findAttribute returns the attribute of e named name, case-insensitively
+func findAttribute(e *ldap.Entry, name string) *ldap.EntryAttribute {
+	for _, attr := range e.Attributes {
+		if strings.EqualFold(attr.Name, name) {
+			return attr
+		}
+	}
+	return nil
+}
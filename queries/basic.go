@@ -8,7 +8,9 @@ import (
 // basicQueries contains standard LDAP object queries
 var basicQueries = map[string]Query{
 	"users": {
-		Filter: fmt.Sprintf("(%s=user)", analyze.AttrObjectClass),
+		Description: "All user accounts",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(%s=user)", analyze.AttrObjectClass),
 		Attributes: []string{
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
@@ -24,13 +26,15 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"computers": {
-		Filter: fmt.Sprintf("(%s=computer)", analyze.AttrObjectClass),
+		Description: "All computer accounts",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(%s=computer)", analyze.AttrObjectClass),
 		Attributes: []string{
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
 			analyze.AttrName,
 			analyze.AttrOperatingSystem,
-			"operatingSystemVersion",
+			analyze.AttrOperatingSystemVersion,
 			analyze.AttrDNSHostName,
 			analyze.AttrUserAccountControl,
 			analyze.AttrObjectSID,
@@ -40,6 +44,8 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"dc": {
+		Description: "All domain controllers",
+		Category:    CategoryBasic,
 		Filter: fmt.Sprintf("(&(%s=computer)(%s:%s:=%d))",
 			analyze.AttrObjectClass,
 			analyze.AttrUserAccountControl,
@@ -51,7 +57,7 @@ var basicQueries = map[string]Query{
 			analyze.AttrSAMAccountName,
 			analyze.AttrName,
 			analyze.AttrOperatingSystem,
-			"operatingSystemVersion",
+			analyze.AttrOperatingSystemVersion,
 			analyze.AttrDNSHostName,
 			analyze.AttrUserAccountControl,
 			analyze.AttrObjectSID,
@@ -61,21 +67,28 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"ou": {
-		Filter: fmt.Sprintf("(%s=organizationalUnit)", analyze.AttrObjectClass),
+		Description: "All organizational units",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(%s=organizationalUnit)", analyze.AttrObjectClass),
 		Attributes: []string{
 			analyze.AttrName,
 			analyze.AttrDistinguishedName,
 		},
 	},
 	"spn": {
-		Filter: fmt.Sprintf("(&(%s=*))", analyze.AttrServicePrincipalName),
+		Description: "All service principal names",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(&(%s=*))", analyze.AttrServicePrincipalName),
 		Attributes: []string{
 			"dn",
 			analyze.AttrCN,
 			analyze.AttrServicePrincipalName,
 		},
+		Minimal: []string{"dn", analyze.AttrServicePrincipalName},
 	},
 	"adminSDHolder": {
+		Description: "Accounts with AdminSDHolder protection",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(%s=person)(%s=*)(%s=1))",
 			analyze.AttrObjectCategory,
 			analyze.AttrSAMAccountName,
@@ -87,6 +100,8 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"group": {
+		Description: "Admin groups",
+		Category:    CategoryPermissions,
 		Filter: fmt.Sprintf("(&(%s=group)(%s=1))",
 			analyze.AttrObjectCategory,
 			analyze.AttrAdminCount,
@@ -104,6 +119,8 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"disabled": {
+		Description: "Disabled user accounts",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(%s:%s:=%d)",
 			analyze.AttrUserAccountControl,
 			analyze.OIDMatchRuleBitOr,
@@ -116,8 +133,88 @@ var basicQueries = map[string]Query{
 			analyze.AttrLastLogonTimestamp,
 		},
 	},
+	"expiredEnabled": {
+		Description: "Enabled user accounts past their accountExpires date",
+		Category:    CategoryAdmin,
+		Filter: fmt.Sprintf("(&(samAccountType=805306368)(!(%s:%s:=%d))(%s>=1)(%s<={now}))",
+			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_ACCOUNTDISABLE,
+			analyze.AttrAccountExpires,
+			analyze.AttrAccountExpires,
+		),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrUserPrincipalName,
+			analyze.AttrAccountExpires,
+			analyze.AttrLastLogonTimestamp,
+		},
+	},
+	"expiring": {
+		Description: "Enabled user accounts expiring within --days (default 30)",
+		Category:    CategoryAdmin,
+		Filter: fmt.Sprintf("(&(samAccountType=805306368)(!(%s:%s:=%d))(%s>={now})(%s<={days}))",
+			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_ACCOUNTDISABLE,
+			analyze.AttrAccountExpires,
+			analyze.AttrAccountExpires,
+		),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrUserPrincipalName,
+			analyze.AttrAccountExpires,
+			analyze.AttrLastLogonTimestamp,
+		},
+		Params: []Param{{Name: "days", Description: "Days ahead to look for expiring accounts", Default: "30", Type: ParamTypeDays}},
+	},
+	"smartcardRequired": {
+		Description: "User accounts that must log on with a smart card",
+		Category:    CategoryAdmin,
+		Filter: fmt.Sprintf("(&(samAccountType=805306368)(%s:%s:=%d))",
+			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_SMARTCARD_REQUIRED,
+		),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrUserPrincipalName,
+			analyze.AttrUserAccountControl,
+			analyze.AttrLastLogonTimestamp,
+		},
+	},
+	"logonRestricted": {
+		Description: "User accounts restricted to workstations (userWorkstations) or logon hours",
+		Category:    CategoryAdmin,
+		Filter: fmt.Sprintf("(&(samAccountType=805306368)(|(%s=*)(%s=*)))",
+			analyze.AttrUserWorkstations,
+			analyze.AttrLogonHours,
+		),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrUserWorkstations,
+			analyze.AttrLogonHours,
+			analyze.AttrUserAccountControl,
+		},
+	},
+	"nolaps": {
+		Description: "Enabled member computers without a legacy or Windows LAPS password",
+		Category:    CategoryBasic,
+		Filter: fmt.Sprintf("(&(%s=computer)(!(%s:%s:=%d))(!(%s=*))(!(%s=*)))",
+			analyze.AttrObjectCategory,
+			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_ACCOUNTDISABLE|analyze.UF_SERVER_TRUST_ACCOUNT,
+			analyze.AttrMSMcsAdmPwdExpirationTime,
+			analyze.AttrMSLAPSPasswordExpirationTime,
+		),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrDNSHostName,
+			analyze.AttrOperatingSystem,
+		},
+	},
 	"trustDomain": {
-		Filter: fmt.Sprintf("(%s=trustedDomain)", analyze.AttrObjectClass),
+		Description: "Trusted domains",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(%s=trustedDomain)", analyze.AttrObjectClass),
 		Attributes: []string{
 			analyze.AttrName,
 			analyze.AttrTrustDirection,
@@ -128,6 +225,8 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"trustattributes": {
+		Description: "Trusted domain attributes",
+		Category:    CategoryBasic,
 		Filter: fmt.Sprintf("(&(%s=trustedDomain)(%s=*))",
 			analyze.AttrObjectClass,
 			analyze.AttrTrustAttributes,
@@ -140,7 +239,9 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"sidhistory": {
-		Filter: fmt.Sprintf("(%s=*)", analyze.AttrSIDHistory),
+		Description: "Accounts with SID history",
+		Category:    CategoryPermissions,
+		Filter:      fmt.Sprintf("(%s=*)", analyze.AttrSIDHistory),
 		Attributes: []string{
 			"dn",
 			analyze.AttrCN,
@@ -149,7 +250,9 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"gpo": {
-		Filter: fmt.Sprintf("(%s=groupPolicyContainer)", analyze.AttrObjectClass),
+		Description: "All group policy objects",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(%s=groupPolicyContainer)", analyze.AttrObjectClass),
 		Attributes: []string{
 			analyze.AttrName,
 			analyze.AttrDisplayName,
@@ -159,6 +262,8 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"gpomachine": {
+		Description: "GPOs with machine settings",
+		Category:    CategoryBasic,
 		Filter: fmt.Sprintf("(&(%s=groupPolicyContainer)(%s=*))",
 			analyze.AttrObjectCategory,
 			analyze.AttrGPCMachineExtensionNames,
@@ -170,6 +275,8 @@ var basicQueries = map[string]Query{
 		},
 	},
 	"gpouser": {
+		Description: "GPOs with user settings",
+		Category:    CategoryBasic,
 		Filter: fmt.Sprintf("(&(%s=groupPolicyContainer)(%s=*))",
 			analyze.AttrObjectCategory,
 			analyze.AttrGPCUserExtensionNames,
@@ -180,8 +287,82 @@ var basicQueries = map[string]Query{
 			analyze.AttrGPCUserExtensionNames,
 		},
 	},
+	"gpolinks": {
+		Description: "Domains and OUs linking GPOs or blocking inheritance",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(|(%s=*)(%s=*))", analyze.AttrGPLink, analyze.AttrGPOptions),
+		Attributes: []string{
+			analyze.AttrName,
+			analyze.AttrObjectClass,
+			analyze.AttrGPLink,
+			analyze.AttrGPOptions,
+		},
+	},
+	"exchangeSchema": {
+		Description: "Exchange schema version (rangeUpper, decoded by the schema command)",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(%s=%s)", analyze.AttrCN, analyze.AttrMSExchSchemaVersionPt),
+		Attributes:  []string{analyze.AttrCN, analyze.AttrRangeUpper, analyze.AttrWhenChanged},
+		Partition:   PartitionSchema,
+	},
+	"exchangeServers": {
+		Description: "Exchange servers with their build (serialNumber) and roles",
+		Category:    CategoryBasic,
+		Filter:      "(objectClass=msExchExchangeServer)",
+		Attributes: []string{
+			analyze.AttrName,
+			analyze.AttrSerialNumber,
+			analyze.AttrMSExchCurrentServerRoles,
+			analyze.AttrNetworkAddress,
+			analyze.AttrWhenCreated,
+		},
+		Partition: PartitionConfiguration,
+	},
+	"kdsRootKeys": {
+		Description: "KDS root keys behind gMSA/dMSA passwords (configuration partition)",
+		Category:    CategoryBasic,
+		Filter:      "(objectClass=msKds-ProvRootKey)",
+		Attributes: []string{
+			analyze.AttrCN,
+			analyze.AttrMSKdsCreateTime,
+			analyze.AttrMSKdsUseStartTime,
+			analyze.AttrMSKdsDomainID,
+			analyze.AttrMSKdsVersion,
+			analyze.AttrMSKdsKDFAlgorithmID,
+			analyze.AttrMSKdsRootKeyData,
+			analyze.AttrWhenCreated,
+		},
+		Partition: PartitionConfiguration,
+	},
+	"fve": {
+		Description: "BitLocker recovery information escrowed in AD (recovery password when readable)",
+		Category:    CategoryBasic,
+		Filter:      "(objectClass=msFVE-RecoveryInformation)",
+		Attributes: []string{
+			analyze.AttrCN,
+			analyze.AttrMSFVERecoveryGuid,
+			analyze.AttrMSFVEVolumeGuid,
+			analyze.AttrWhenCreated,
+			analyze.AttrMSFVERecoveryPassword,
+		},
+	},
 	"machineAccountQuota": {
-		Filter:     "(objectClass=domain)",
-		Attributes: []string{"ms-DS-MachineAccountQuota"},
+		Description: "Machine account quota for the domain",
+		Category:    CategoryBasic,
+		Filter:      "(objectClass=domain)",
+		Attributes:  []string{analyze.AttrMSDSMachineAccountQuota},
+	},
+	"creatorsid": {
+		Description: "Computers created through the machine account quota, with their creator",
+		Category:    CategoryBasic,
+		Filter:      fmt.Sprintf("(&(%s=computer)(%s=*))", analyze.AttrObjectCategory, analyze.AttrMSDSCreatorSID),
+		Attributes: []string{
+			analyze.AttrSAMAccountName,
+			analyze.AttrDNSHostName,
+			analyze.AttrOperatingSystem,
+			analyze.AttrMSDSCreatorSID,
+			analyze.AttrWhenCreated,
+		},
+		ResolveSIDs: map[string]string{analyze.AttrMSDSCreatorSID: "creator"},
 	},
 }
@@ -0,0 +1,72 @@
+package queries
+
+import (
+	"adgo/analyze"
+	"fmt"
+)
+
+// Collection is a named query run by the collect command
+type Collection struct {
+	Name  string // Object type collected (users, computers, ...)
+	Query Query  // Query used to collect the objects
+}
+
+// collectionAttributes are requested for every collected object so the
+// BloodHound converters can build properties and resolve references
+var collectionAttributes = []string{
+	analyze.AttrObjectClass,
+	analyze.AttrObjectSID,
+	analyze.AttrObjectGUID,
+	analyze.AttrDistinguishedName,
+	analyze.AttrName,
+	analyze.AttrSAMAccountName,
+	analyze.AttrDisplayName,
+	analyze.AttrDescription,
+	"mail",
+	analyze.AttrWhenCreated,
+	analyze.AttrUserAccountControl,
+	analyze.AttrPrimaryGroupID,
+	analyze.AttrAdminCount,
+	analyze.AttrServicePrincipalName,
+	analyze.AttrMSDSAllowedToDelegateTo,
+	analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity,
+	analyze.AttrSIDHistory,
+	analyze.AttrLastLogon,
+	analyze.AttrLastLogonTimestamp,
+	analyze.AttrPwdLastSet,
+	analyze.AttrDNSHostName,
+	analyze.AttrOperatingSystem,
+	"ms-Mcs-AdmPwdExpirationTime",
+	"msLAPS-PasswordExpirationTime",
+	analyze.AttrMember,
+	analyze.AttrGPLink,
+	analyze.AttrGPOptions,
+	analyze.AttrGPCFileSysPath,
+	analyze.AttrMSDSBehaviorVersion,
+	analyze.AttrTrustPartner,
+	analyze.AttrTrustDirection,
+	analyze.AttrTrustType,
+	analyze.AttrTrustAttributes,
+	analyze.AttrSecurityIdentifier,
+	analyze.AttrNTSecurityDescriptor,
+}
+
+// Collections lists the object collections run by the collect command, in order
+var Collections = []Collection{
+	{Name: "users", Query: collectionQuery("(samAccountType=805306368)")},
+	{Name: "computers", Query: collectionQuery(fmt.Sprintf("(%s=computer)", analyze.AttrObjectClass))},
+	{Name: "groups", Query: collectionQuery(fmt.Sprintf("(%s=group)", analyze.AttrObjectClass))},
+	{Name: "domains", Query: collectionQuery(fmt.Sprintf("(%s=domain)", analyze.AttrObjectClass))},
+	{Name: "gpos", Query: collectionQuery(fmt.Sprintf("(%s=groupPolicyContainer)", analyze.AttrObjectClass))},
+	{Name: "ous", Query: collectionQuery(fmt.Sprintf("(%s=organizationalUnit)", analyze.AttrObjectClass))},
+	{Name: "containers", Query: collectionQuery(fmt.Sprintf("(%s=container)", analyze.AttrObjectClass))},
+	{Name: "trusts", Query: collectionQuery(fmt.Sprintf("(%s=trustedDomain)", analyze.AttrObjectClass))},
+}
+
+// collectionQuery builds a collection query using the shared attribute list
+func collectionQuery(filter string) Query {
+	return Query{
+		Filter:     filter,
+		Attributes: collectionAttributes,
+	}
+}
@@ -8,7 +8,9 @@ import (
 // delegationQueries contains Kerberos delegation-related queries
 var delegationQueries = map[string]Query{
 	"delegate": {
-		Filter: fmt.Sprintf("(%s=*)", analyze.AttrMSDSAllowedToDelegateTo),
+		Description: "Accounts with delegation rights",
+		Category:    CategoryDelegation,
+		Filter:      fmt.Sprintf("(%s=*)", analyze.AttrMSDSAllowedToDelegateTo),
 		Attributes: []string{
 			"dn",
 			analyze.AttrCN,
@@ -17,6 +19,8 @@ var delegationQueries = map[string]Query{
 		},
 	},
 	"unconstraineddelegate": {
+		Description: "Accounts with unconstrained delegation",
+		Category:    CategoryDelegation,
 		Filter: fmt.Sprintf("(%s:%s:=%d)",
 			analyze.AttrUserAccountControl,
 			analyze.OIDMatchRuleBitOr,
@@ -28,20 +32,47 @@ var delegationQueries = map[string]Query{
 			analyze.AttrSAMAccountName,
 			analyze.AttrUserAccountControl,
 			analyze.AttrObjectClass,
+			analyze.AttrDNSHostName,
+			analyze.AttrOperatingSystem,
+			analyze.AttrLastLogonTimestamp,
 		},
 	},
 	"constraineddelegate": {
-		Filter: fmt.Sprintf("(%s=*)", analyze.AttrMSDSAllowedToDelegateTo),
+		Description: "Accounts with constrained delegation",
+		Category:    CategoryDelegation,
+		Filter:      fmt.Sprintf("(%s=*)", analyze.AttrMSDSAllowedToDelegateTo),
 		Attributes: []string{
 			"dn",
 			analyze.AttrCN,
 			analyze.AttrSAMAccountName,
 			analyze.AttrMSDSAllowedToDelegateTo,
+			analyze.AttrUserAccountControl,
+			analyze.AttrObjectClass,
+		},
+	},
+	// Protocol transition (S4U2Self) lets the account impersonate any user
+	// to its constrained delegation services without their ticket
+	"protocoltransitiondelegate": {
+		Description: "Accounts with constrained delegation and protocol transition (T2A4D)",
+		Category:    CategoryDelegation,
+		Filter: fmt.Sprintf("(%s:%s:=%d)",
+			analyze.AttrUserAccountControl,
+			analyze.OIDMatchRuleBitOr,
+			analyze.UF_TRUSTED_TO_AUTH_FOR_DELEGATION,
+		),
+		Attributes: []string{
+			"dn",
+			analyze.AttrCN,
+			analyze.AttrSAMAccountName,
+			analyze.AttrMSDSAllowedToDelegateTo,
+			analyze.AttrUserAccountControl,
 			analyze.AttrObjectClass,
 		},
 	},
 	"resourceconstraineddelegate": {
-		Filter: fmt.Sprintf("(%s=*)", analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity),
+		Description: "Accounts with resource constrained delegation",
+		Category:    CategoryDelegation,
+		Filter:      fmt.Sprintf("(%s=*)", analyze.AttrMSDSAllowedToActOnBehalfOfOtherIdentity),
 		Attributes: []string{
 			"dn",
 			analyze.AttrCN,
@@ -50,4 +81,56 @@ var delegationQueries = map[string]Query{
 			analyze.AttrObjectClass,
 		},
 	},
+	// Coercion hints: an HTTP SPN suggests the WebClient service (WebDAV),
+	// a published printer a running Print Spooler on its server
+	"webdavHosts": {
+		Description: "Computers with HTTP SPNs, WebDAV coercion candidates",
+		Category:    CategoryDelegation,
+		Filter:      fmt.Sprintf("(&(objectCategory=computer)(%s=HTTP/*))", analyze.AttrServicePrincipalName),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrDNSHostName,
+			analyze.AttrServicePrincipalName,
+		},
+	},
+	"printQueues": {
+		Description: "Published printers, revealing Print Spooler servers",
+		Category:    CategoryDelegation,
+		Filter:      "(objectClass=printQueue)",
+		Attributes: []string{
+			"dn",
+			analyze.AttrPrinterName,
+			analyze.AttrServerName,
+			analyze.AttrShortServerName,
+			analyze.AttrUNCName,
+		},
+	},
+	"dmsa": {
+		Description: "Delegated managed service accounts with their predecessor and migration state",
+		Category:    CategoryDelegation,
+		Filter:      "(objectClass=msDS-DelegatedManagedServiceAccount)",
+		Attributes: []string{
+			"dn",
+			analyze.AttrCN,
+			analyze.AttrSAMAccountName,
+			analyze.AttrMSDSManagedAccountPrecededByLink,
+			analyze.AttrMSDSDelegatedMSAState,
+			analyze.AttrMSDSGroupMSAMembership,
+			analyze.AttrWhenCreated,
+		},
+	},
+	"dmsaSuperseded": {
+		Description: "Accounts superseded by a dMSA",
+		Category:    CategoryDelegation,
+		Filter:      fmt.Sprintf("(%s=*)", analyze.AttrMSDSSupersededManagedAccountLink),
+		Attributes: []string{
+			"dn",
+			analyze.AttrCN,
+			analyze.AttrSAMAccountName,
+			analyze.AttrMSDSSupersededManagedAccountLink,
+			analyze.AttrMSDSSupersededServiceAccountState,
+			analyze.AttrObjectClass,
+		},
+	},
 }
@@ -5,9 +5,18 @@ import (
 	"fmt"
 )
 
+// kerberoastingFilter matches enabled user accounts with an SPN, except krbtgt
+var kerberoastingFilter = fmt.Sprintf("(&(!(%s:%s:=%d))(samAccountType=805306368)(%s=*)(!%s=krbtgt))",
+	analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_ACCOUNTDISABLE,
+	analyze.AttrServicePrincipalName,
+	analyze.AttrSAMAccountName,
+)
+
 // kerberosQueries contains Kerberos-related attack queries
 var kerberosQueries = map[string]Query{
 	"asreproast": {
+		Description: "Accounts vulnerable to AS-REP roasting",
+		Category:    CategoryKerberos,
 		Filter: fmt.Sprintf("(&(%s:%s:=%d)(!(%s:%s:=%d))(!(%s=computer)))",
 			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_DONT_REQUIRE_PREAUTH,
 			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_ACCOUNTDISABLE,
@@ -16,11 +25,9 @@ var kerberosQueries = map[string]Query{
 		Attributes: []string{"dn", analyze.AttrSAMAccountName},
 	},
 	"kerberoasting": {
-		Filter: fmt.Sprintf("(&(!(%s:%s:=%d))(samAccountType=805306368)(%s=*)(!%s=krbtgt))",
-			analyze.AttrUserAccountControl, analyze.OIDMatchRuleBitOr, analyze.UF_ACCOUNTDISABLE,
-			analyze.AttrServicePrincipalName,
-			analyze.AttrSAMAccountName,
-		),
-		Attributes: []string{"dn", analyze.AttrSAMAccountName, analyze.AttrServicePrincipalName},
+		Description: "Accounts vulnerable to Kerberoasting",
+		Category:    CategoryKerberos,
+		Filter:      kerberoastingFilter,
+		Attributes:  []string{"dn", analyze.AttrSAMAccountName, analyze.AttrServicePrincipalName},
 	},
 }
@@ -0,0 +1,176 @@
+package queries
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/go-ldap/ldap/v3"
+	"go.yaml.in/yaml/v3"
+)
+
+// Definition describes a query loaded from a query pack file
+type Definition struct {
+	Name        string   `yaml:"name" json:"name"`                         // Query and quick subcommand name
+	Description string   `yaml:"description" json:"description"`           // One-line help text
+	Category    string   `yaml:"category" json:"category"`                 // Category shown in quick help
+	Filter      string   `yaml:"filter" json:"filter"`                     // LDAP filter
+	Attributes  []string `yaml:"attributes" json:"attributes"`             // Attributes to return
+	Params      []Param  `yaml:"params,omitempty" json:"params,omitempty"` // Parameters; undeclared {name} placeholders are required
+}
+
+// query returns the registry entry for the definition. Definitions without
+// a category are listed under CategoryCustom.
+func (d Definition) query() Query {
+	category := d.Category
+	if category == "" {
+		category = CategoryCustom
+	}
+	return withImplicitParams(Query{
+		Description: d.Description,
+		Category:    category,
+		Filter:      d.Filter,
+		Attributes:  d.Attributes,
+		Params:      d.Params,
+	})
+}
+
+// packFile is the layout of a query pack: definitions under a "queries" key.
+// A bare list of definitions is accepted as well.
+type packFile struct {
+	Queries []Definition `yaml:"queries"`
+}
+
+// packExtensions are the file extensions LoadFromDir reads. JSON is parsed
+// by the YAML decoder, as JSON is a subset of YAML.
+var packExtensions = map[string]bool{".yaml": true, ".yml": true, ".json": true}
+
+// LoadFromFile reads a YAML or JSON query pack and registers its queries.
+// Names that are already registered are rejected so packs cannot silently
+// replace built-in queries.
+func (r *Registry) LoadFromFile(path string) ([]Definition, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading query pack: %w", err)
+	}
+
+	var pack packFile
+	if err := yaml.Unmarshal(data, &pack); err != nil {
+		var list []Definition
+		if listErr := yaml.Unmarshal(data, &list); listErr != nil {
+			return nil, fmt.Errorf("parsing query pack %s: %w", path, err)
+		}
+		pack.Queries = list
+	}
+
+	seen := make(map[string]bool, len(pack.Queries))
+	for i, d := range pack.Queries {
+		if err := r.validateDefinition(d); err != nil {
+			return nil, fmt.Errorf("query pack %s, query %d: %w", path, i+1, err)
+		}
+		if seen[strings.ToLower(d.Name)] {
+			return nil, fmt.Errorf("query pack %s, query %d: query %q is defined twice", path, i+1, d.Name)
+		}
+		seen[strings.ToLower(d.Name)] = true
+	}
+	for _, d := range pack.Queries {
+		if err := r.register(d.Name, d.query()); err != nil {
+			return nil, fmt.Errorf("query pack %s: %w", path, err)
+		}
+	}
+	return pack.Queries, nil
+}
+
+// LoadFromDir loads every query pack (*.yaml, *.yml, *.json) in dir in name
+// order. A pack that fails to load is skipped; the errors are joined.
+func (r *Registry) LoadFromDir(dir string) ([]Definition, error) {
+	files, err := os.ReadDir(dir)
+	if err != nil {
+		return nil, fmt.Errorf("reading query directory: %w", err)
+	}
+	names := make([]string, 0, len(files))
+	for _, f := range files {
+		if !f.IsDir() && packExtensions[strings.ToLower(filepath.Ext(f.Name()))] {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+
+	var defs []Definition
+	var errs []error
+	for _, name := range names {
+		loaded, err := r.LoadFromFile(filepath.Join(dir, name))
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		defs = append(defs, loaded...)
+	}
+	return defs, errors.Join(errs...)
+}
+
+// Add validates and registers a single definition
+func (r *Registry) Add(d Definition) error {
+	if err := r.validateDefinition(d); err != nil {
+		return err
+	}
+	return r.register(d.Name, d.query())
+}
+
+// validateDefinition checks that a definition has a name, a valid filter and
+// does not clash with a registered query
+func (r *Registry) validateDefinition(d Definition) error {
+	if d.Name == "" {
+		return errors.New("name is required")
+	}
+	if strings.ContainsAny(d.Name, " \t/") {
+		return fmt.Errorf("invalid name %q: must not contain spaces or slashes", d.Name)
+	}
+	if err := r.checkName(d.Name); err != nil {
+		return err
+	}
+	if d.Filter == "" {
+		return fmt.Errorf("query %q: filter is required", d.Name)
+	}
+	if _, err := ldap.CompileFilter(d.Filter); err != nil {
+		return fmt.Errorf("query %q: invalid filter: %w", d.Name, err)
+	}
+	seen := make(map[string]bool)
+	for _, p := range d.Params {
+		if !paramNamePattern.MatchString(p.Name) {
+			return fmt.Errorf("query %q: invalid parameter name %q", d.Name, p.Name)
+		}
+		if seen[p.Name] {
+			return fmt.Errorf("query %q: parameter %q is declared twice", d.Name, p.Name)
+		}
+		if p.Type != "" && p.Type != ParamTypeDays {
+			return fmt.Errorf("query %q: parameter %q has unknown type %q", d.Name, p.Name, p.Type)
+		}
+		seen[p.Name] = true
+	}
+	return nil
+}
+
+// Add registers a user-defined query in the global registry
+func Add(d Definition) error {
+	return registry.Add(d)
+}
+
+// ValidateDefinition checks a definition against the global registry
+// without registering it
+func ValidateDefinition(d Definition) error {
+	return registry.validateDefinition(d)
+}
+
+// LoadFromFile loads a query pack into the global registry
+func LoadFromFile(path string) ([]Definition, error) {
+	return registry.LoadFromFile(path)
+}
+
+// LoadFromDir loads every query pack in dir into the global registry
+func LoadFromDir(dir string) ([]Definition, error) {
+	return registry.LoadFromDir(dir)
+}
@@ -8,6 +8,8 @@ import (
 // privilegeQueries contains privilege and group membership queries
 var privilegeQueries = map[string]Query{
 	"admin": {
+		Description: "All admin accounts and groups",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(|(&(%s=person)(%s=user))(%s=group))(%s=1))",
 			analyze.AttrObjectCategory,
 			analyze.AttrObjectClass,
@@ -21,7 +23,9 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"enterprise": {
-		Filter: fmt.Sprintf("(%s=Enterprise Admins)", analyze.AttrSAMAccountName),
+		Description: "Enterprise related information",
+		Category:    CategoryAdmin,
+		Filter:      fmt.Sprintf("(%s=Enterprise Admins)", analyze.AttrSAMAccountName),
 		Attributes: []string{
 			"dn",
 			analyze.AttrCN,
@@ -29,6 +33,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"domainadmins": {
+		Description: "Domain admin group members",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(%s=group)(%s=Domain Admins))",
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
@@ -40,6 +46,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"enterpriseadmins": {
+		Description: "Enterprise admin group members",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(%s=group)(%s=Enterprise Admins))",
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
@@ -51,6 +59,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"schemaadmins": {
+		Description: "Schema admin group members",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(%s=group)(%s=Schema Admins))",
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
@@ -62,6 +72,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"adminholders": {
+		Description: "Admin account holders",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(%s=person)(%s=*)(%s=1))",
 			analyze.AttrObjectCategory,
 			analyze.AttrSAMAccountName,
@@ -75,6 +87,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"highpriv": {
+		Description: "High privilege accounts",
+		Category:    CategoryPermissions,
 		Filter: fmt.Sprintf("(&(%s=user)(%s=1))",
 			analyze.AttrObjectClass,
 			analyze.AttrAdminCount,
@@ -88,6 +102,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"permissions": {
+		Description: "Account permissions",
+		Category:    CategoryPermissions,
 		Filter: fmt.Sprintf("(&(%s=user)(%s=*))",
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
@@ -101,6 +117,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"groupnested": {
+		Description: "Nested groups",
+		Category:    CategoryPermissions,
 		Filter: fmt.Sprintf("(&(%s=group)(%s=*))",
 			analyze.AttrObjectClass,
 			analyze.AttrMember,
@@ -113,6 +131,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"sensitivegroups": {
+		Description: "Sensitive AD groups",
+		Category:    CategoryAdmin,
 		Filter: fmt.Sprintf("(&(%s=group)(|(%s=Domain Admins)(%s=Enterprise Admins)(%s=Schema Admins)(%s=Administrators)(%s=Domain Controllers)(%s=Enterprise Key Admins)(%s=Domain Key Admins)))",
 			analyze.AttrObjectClass,
 			analyze.AttrSAMAccountName,
@@ -130,7 +150,9 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"managedby": {
-		Filter: fmt.Sprintf("(&(%s=*))", analyze.AttrManagedBy),
+		Description: "Objects with managedBy attribute",
+		Category:    CategoryPermissions,
+		Filter:      fmt.Sprintf("(&(%s=*))", analyze.AttrManagedBy),
 		Attributes: []string{
 			analyze.AttrCN,
 			analyze.AttrDistinguishedName,
@@ -138,6 +160,8 @@ var privilegeQueries = map[string]Query{
 		},
 	},
 	"acl": {
+		Description: "Objects with ACLs",
+		Category:    CategoryPermissions,
 		Filter: fmt.Sprintf("(&(%s=*)(%s=*))",
 			analyze.AttrObjectClass,
 			analyze.AttrNTSecurityDescriptor,
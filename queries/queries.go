@@ -3,14 +3,181 @@ package queries
 import (
 	"adgo/analyze"
 	"fmt"
+	"regexp"
+	"slices"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
 )
 
 // Query defines LDAP query filter and return attributes
 type Query struct {
-	Filter     string   // LDAP filter condition
-	Attributes []string // List of attributes to return
+	Description string            // One-line help text of the quick subcommand
+	Category    string            // Category the quick subcommand is listed under
+	Filter      string            // LDAP filter condition
+	Attributes  []string          // List of attributes to return
+	Minimal     []string          // Attributes of the minimal profile; default: the identifying ones among Attributes
+	Params      []Param           // Parameters substituted for {name} placeholders in Filter
+	ResolveSIDs map[string]string // SID attributes whose account names are added to results, keyed to the attribute that receives them
+	Partition   string            // Partition searched instead of the base DN, PartitionConfiguration or PartitionSchema
+}
+
+// Query categories, in the order quick help lists them
+const (
+	CategoryBasic       = "Basic Queries"
+	CategoryAdmin       = "Admin Queries"
+	CategoryKerberos    = "Kerberos Attacks"
+	CategoryDelegation  = "Delegation"
+	CategoryADCS        = "AD CS"
+	CategoryPermissions = "Permissions"
+	CategoryCustom      = "Custom Queries" // Default for user-defined queries
+	CategoryPlugin      = "Plugin Queries" // Default for plugin queries
+)
+
+// Attribute profiles, selecting which attributes of a query are requested
+const (
+	AttrProfileMinimal  = "minimal"  // Minimal, or the identifying attributes: fewer bytes, less noise
+	AttrProfileStandard = "standard" // Attributes
+	AttrProfileFull     = "full"     // Every user and operational attribute, plus Attributes
+)
+
+// AttrProfiles lists the attribute profiles
+var AttrProfiles = []string{AttrProfileMinimal, AttrProfileStandard, AttrProfileFull}
+
+// identifyingAttributes are kept from Attributes by the minimal profile of
+// queries that do not set Minimal
+var identifyingAttributes = []string{
+	"dn",
+	analyze.AttrSAMAccountName,
+	analyze.AttrName,
+	analyze.AttrCN,
+	analyze.AttrDNSHostName,
+}
+
+// ProfileAttributes returns the attributes q requests under an attribute
+// profile ("" is the standard profile)
+func (q Query) ProfileAttributes(profile string) ([]string, error) {
+	switch profile {
+	case "", AttrProfileStandard:
+		return q.Attributes, nil
+	case AttrProfileMinimal:
+		if len(q.Minimal) > 0 {
+			return q.Minimal, nil
+		}
+		var attrs []string
+		for _, attr := range q.Attributes {
+			if slices.ContainsFunc(identifyingAttributes, func(a string) bool { return strings.EqualFold(a, attr) }) {
+				attrs = append(attrs, attr)
+			}
+		}
+		if len(attrs) == 0 {
+			// An empty list would return every attribute
+			attrs = []string{analyze.AttrDistinguishedName}
+		}
+		return attrs, nil
+	case AttrProfileFull:
+		attrs := []string{"*", "+"}
+		for _, attr := range q.Attributes {
+			if attr != "*" && attr != "+" {
+				attrs = append(attrs, attr)
+			}
+		}
+		return attrs, nil
+	}
+	return nil, fmt.Errorf("unknown attribute profile %q (valid: %s)", profile, strings.Join(AttrProfiles, ", "))
+}
+
+// Partitions of the forest searched by queries that set Partition
+const (
+	PartitionConfiguration = "configuration"
+	PartitionSchema        = "schema"
+)
+
+// NamingContext returns the RootDSE attribute naming a partition, which
+// connect.ReadNamingContext reads, or "" for the domain partition
+func NamingContext(partition string) string {
+	switch partition {
+	case PartitionConfiguration:
+		return analyze.AttrConfigurationNamingContext
+	case PartitionSchema:
+		return analyze.AttrSchemaNamingContext
+	}
+	return ""
+}
+
+// PartitionDN returns the DN of a partition of the forest whose root
+// domain is forestDN, or forestDN for the domain partition ("")
+func PartitionDN(partition, forestDN string) string {
+	switch partition {
+	case PartitionConfiguration:
+		return "CN=Configuration," + forestDN
+	case PartitionSchema:
+		return "CN=Schema,CN=Configuration," + forestDN
+	}
+	return forestDN
+}
+
+// Param is a named query parameter
+type Param struct {
+	Name        string `yaml:"name" json:"name"`                         // Placeholder name, used as {name} in the filter
+	Description string `yaml:"description" json:"description,omitempty"` // Help text for the generated flag
+	Default     string `yaml:"default" json:"default,omitempty"`         // Value used when the parameter is not given
+	Required    bool   `yaml:"required" json:"required,omitempty"`       // Whether a value must be given
+	Type        string `yaml:"type" json:"type,omitempty"`               // How the value is substituted: literally, or ParamTypeDays
+}
+
+// ParamTypeDays marks a parameter whose value is a number of days from
+// now (negative for the past), substituted as the FILETIME of that moment
+const ParamTypeDays = "days"
+
+// Parameters filled in by the caller from the connection settings rather
+// than by the user
+const (
+	ParamDomain = "domain" // Domain DN, defaults to the configured base DN
+	ParamBaseDN = "baseDN" // Configured base DN
+	ParamNow    = "now"    // FILETIME of the build time, filled in by the builder
+)
+
+// placeholderPattern matches {name} placeholders in filters
+var placeholderPattern = regexp.MustCompile(`\{([A-Za-z][A-Za-z0-9_-]*)\}`)
+
+// paramNamePattern matches valid parameter names
+var paramNamePattern = regexp.MustCompile(`^[A-Za-z][A-Za-z0-9_-]*$`)
+
+// Placeholders returns the distinct placeholder names in filter in order of appearance
+func Placeholders(filter string) []string {
+	var names []string
+	for _, m := range placeholderPattern.FindAllStringSubmatch(filter, -1) {
+		if !slices.Contains(names, m[1]) {
+			names = append(names, m[1])
+		}
+	}
+	return names
+}
+
+// withImplicitParams returns q with a parameter declared for every
+// placeholder in its filter. Undeclared placeholders are required, except
+// the domain and base DN, which callers fill in from the connection settings,
+// and the current time, which the builder fills in.
+func withImplicitParams(q Query) Query {
+	for _, name := range Placeholders(q.Filter) {
+		if name == ParamNow {
+			continue
+		}
+		if slices.ContainsFunc(q.Params, func(p Param) bool { return p.Name == name }) {
+			continue
+		}
+		p := Param{Name: name, Description: fmt.Sprintf("Value for {%s}", name), Required: true}
+		if name == ParamDomain || name == ParamBaseDN {
+			p.Description = "Domain DN (default: configured base DN)"
+			p.Required = false
+		}
+		q.Params = append(q.Params, p)
+	}
+	return q
 }
 
 // Registry manages all available queries
@@ -23,42 +190,55 @@ var registry = &Registry{
 	queries: make(map[string]Query),
 }
 
+// builtinQueries are the query maps registered at startup. A name may be
+// defined in only one of them.
+var builtinQueries = []map[string]Query{
+	basicQueries,
+	privilegeQueries,
+	kerberosQueries,
+	delegationQueries,
+	certificateQueries,
+	DomainSpecificQueries,
+}
+
 // init initializes the registry with all default queries
 func init() {
-	// Register basic object queries
-	for name, q := range basicQueries {
-		Register(name, q)
-	}
-
-	// Register privilege and group queries
-	for name, q := range privilegeQueries {
-		Register(name, q)
-	}
-
-	// Register Kerberos attack queries
-	for name, q := range kerberosQueries {
-		Register(name, q)
-	}
-
-	// Register delegation queries
-	for name, q := range delegationQueries {
-		Register(name, q)
+	for _, set := range builtinQueries {
+		for name, q := range set {
+			if err := Register(name, q); err != nil {
+				panic(err)
+			}
+		}
 	}
+}
 
-	// Register certificate queries
-	for name, q := range certificateQueries {
-		Register(name, q)
-	}
+// Register adds a new query to the registry. It fails if the name clashes
+// with a registered query.
+func Register(name string, q Query) error {
+	return registry.register(name, q)
+}
 
-	// Register domain-specific queries
-	for name, q := range DomainSpecificQueries {
-		Register(name, q)
+// register adds a query unless its name clashes with a registered one
+func (r *Registry) register(name string, q Query) error {
+	if err := r.checkName(name); err != nil {
+		return err
 	}
+	r.queries[name] = withImplicitParams(q)
+	return nil
 }
 
-// Register adds a new query to the registry
-func Register(name string, q Query) {
-	registry.queries[name] = q
+// checkName fails if name is registered. Names differing only in case
+// clash too, as their quick subcommands would.
+func (r *Registry) checkName(name string) error {
+	for existing := range r.queries {
+		switch {
+		case existing == name:
+			return fmt.Errorf("query %q is already registered", name)
+		case strings.EqualFold(existing, name):
+			return fmt.Errorf("query %q clashes with registered query %q", name, existing)
+		}
+	}
+	return nil
 }
 
 // Get retrieves a query by name
@@ -81,6 +261,9 @@ func GetNames() []string {
 type QueryBuilder struct {
 	baseQuery Query
 	params    map[string]string
+	profile   string    // Attribute profile, see ProfileAttributes
+	custom    bool      // Attributes were set with WithAttributes and ignore the profile
+	now       time.Time // Time {now} and days parameters are relative to; zero for the build time
 }
 
 // NewQueryBuilder creates a new builder from a base query
@@ -101,10 +284,26 @@ func (b *QueryBuilder) WithParam(key, value string) *QueryBuilder {
 func (b *QueryBuilder) WithAttributes(attributes ...string) *QueryBuilder {
 	if len(attributes) > 0 {
 		b.baseQuery.Attributes = attributes
+		b.custom = true
 	}
 	return b
 }
 
+// WithAttrProfile selects the attributes of an attribute profile, see
+// ProfileAttributes. Attributes set with WithAttributes take precedence;
+// unknown profiles keep the standard attributes.
+func (b *QueryBuilder) WithAttrProfile(profile string) *QueryBuilder {
+	b.profile = profile
+	return b
+}
+
+// WithNow sets the time {now} and days parameters are relative to,
+// instead of the build time
+func (b *QueryBuilder) WithNow(now time.Time) *QueryBuilder {
+	b.now = now
+	return b
+}
+
 // WithBaseDN sets baseDN parameter
 func (b *QueryBuilder) WithBaseDN(baseDN string) *QueryBuilder {
 	b.params["baseDN"] = baseDN
@@ -113,25 +312,85 @@ func (b *QueryBuilder) WithBaseDN(baseDN string) *QueryBuilder {
 
 // Build constructs the final query object
 func (b *QueryBuilder) Build() Query {
+	attributes := b.baseQuery.Attributes
+	if !b.custom {
+		if profiled, err := b.baseQuery.ProfileAttributes(b.profile); err == nil {
+			attributes = profiled
+		}
+	}
 	result := Query{
-		Filter:     b.replaceParams(b.baseQuery.Filter),
-		Attributes: make([]string, len(b.baseQuery.Attributes)),
+		Description: b.baseQuery.Description,
+		Category:    b.baseQuery.Category,
+		Filter:      b.replaceParams(b.baseQuery.Filter),
+		Attributes:  make([]string, len(attributes)),
+		ResolveSIDs: b.baseQuery.ResolveSIDs,
+		Partition:   b.baseQuery.Partition,
 	}
 
-	copy(result.Attributes, b.baseQuery.Attributes)
+	copy(result.Attributes, attributes)
 	return result
 }
 
-// replaceParams replaces placeholders with parameter values
+// MissingParams returns the required parameters of the query that have
+// neither a value nor a default
+func (b *QueryBuilder) MissingParams() []string {
+	var missing []string
+	for _, p := range b.baseQuery.Params {
+		if _, ok := b.params[p.Name]; !ok && p.Required && p.Default == "" {
+			missing = append(missing, p.Name)
+		}
+	}
+	return missing
+}
+
+// replaceParams replaces placeholders with parameter values, falling back
+// to declared defaults. Values are escaped so they cannot alter the filter.
+// Days parameters become FILETIME values; {now} is the current FILETIME.
 func (b *QueryBuilder) replaceParams(filter string) string {
-	result := filter
+	now := b.now
+	if now.IsZero() {
+		now = time.Now()
+	}
+	values := make(map[string]string, len(b.params)+1)
+	for _, p := range b.baseQuery.Params {
+		if p.Default != "" {
+			values[p.Name] = p.Default
+		}
+	}
 	for key, value := range b.params {
-		placeholder := "{" + key + "}"
-		result = strings.ReplaceAll(result, placeholder, value)
+		values[key] = value
+	}
+	for _, p := range b.baseQuery.Params {
+		if v, ok := values[p.Name]; ok && p.Type == ParamTypeDays {
+			if days, err := strconv.Atoi(strings.TrimSpace(v)); err == nil {
+				values[p.Name] = strconv.FormatInt(analyze.ToFileTime(now.AddDate(0, 0, days)), 10)
+			}
+		}
+	}
+	if _, ok := values[ParamNow]; !ok {
+		values[ParamNow] = strconv.FormatInt(analyze.ToFileTime(now), 10)
+	}
+
+	result := filter
+	for key, value := range values {
+		result = strings.ReplaceAll(result, "{"+key+"}", ldap.EscapeFilter(value))
 	}
 	return result
 }
 
+// privilegedFilter matches accounts with adminCount=1 or nested in a
+// sensitive group of the {domain}
+var privilegedFilter = fmt.Sprintf("(|(%s=1)(%s:%s:=CN=Domain Admins,CN=Users,{domain})(%s:%s:=CN=Enterprise Admins,CN=Users,{domain})(%s:%s:=CN=Schema Admins,CN=Users,{domain})(%s:%s:=CN=Administrators,CN=Builtin,{domain})(%s:%s:=CN=Account Operators,CN=Builtin,{domain})(%s:%s:=CN=Backup Operators,CN=Builtin,{domain})(%s:%s:=CN=Server Operators,CN=Builtin,{domain}))",
+	analyze.AttrAdminCount,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+	analyze.AttrMemberOf, analyze.OIDMatchRuleInChain,
+)
+
 // DomainSpecificQueries requires domain name parameter
 var DomainSpecificQueries = map[string]Query{
 	"dcclonerights": {
@@ -151,4 +410,31 @@ var DomainSpecificQueries = map[string]Query{
 		),
 		Attributes: []string{"dn", analyze.AttrCN, analyze.AttrSAMAccountName, analyze.AttrMemberOf},
 	},
+	"kerberoastingPrivileged": {
+		Description: "Kerberoastable accounts with adminCount=1 or in a sensitive group",
+		Category:    CategoryKerberos,
+		Filter:      fmt.Sprintf("(&%s%s)", kerberoastingFilter, privilegedFilter),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrServicePrincipalName,
+			analyze.AttrAdminCount,
+			analyze.AttrMemberOf,
+		},
+	},
+	"privilegedUsers": {
+		Description: "Users with adminCount=1 or in a sensitive group, with logon times",
+		Category:    CategoryAdmin,
+		Filter:      fmt.Sprintf("(&(samAccountType=805306368)%s)", privilegedFilter),
+		Attributes: []string{
+			"dn",
+			analyze.AttrSAMAccountName,
+			analyze.AttrUserAccountControl,
+			analyze.AttrAdminCount,
+			analyze.AttrMemberOf,
+			analyze.AttrLastLogonTimestamp,
+			analyze.AttrPwdLastSet,
+			analyze.AttrWhenCreated,
+		},
+	},
 }
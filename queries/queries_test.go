@@ -1,7 +1,11 @@
 package queries
 
 import (
+	"os"
+	"path/filepath"
+	"slices"
 	"testing"
+	"time"
 )
 
 func TestQueryRegistry(t *testing.T) {
@@ -75,6 +79,34 @@ func TestQueryBuilderWithAttributes(t *testing.T) {
 	}
 }
 
+func TestPartitionQueries(t *testing.T) {
+	q, ok := Get("exchangeServers")
+	if !ok {
+		t.Fatal("Query exchangeServers should exist")
+	}
+	if got := NewQueryBuilder(q).Build().Partition; got != PartitionConfiguration {
+		t.Errorf("Build() Partition = %q, want %q", got, PartitionConfiguration)
+	}
+
+	// Certificate templates and CAs live in the configuration partition
+	for _, name := range []string{"caComputer", "esc1", "esc2"} {
+		if q, _ := Get(name); q.Partition != PartitionConfiguration {
+			t.Errorf("%s Partition = %q, want %q", name, q.Partition, PartitionConfiguration)
+		}
+	}
+
+	tests := map[string]string{
+		"":                     "DC=corp,DC=local",
+		PartitionConfiguration: "CN=Configuration,DC=corp,DC=local",
+		PartitionSchema:        "CN=Schema,CN=Configuration,DC=corp,DC=local",
+	}
+	for partition, want := range tests {
+		if got := PartitionDN(partition, "DC=corp,DC=local"); got != want {
+			t.Errorf("PartitionDN(%q) = %q, want %q", partition, got, want)
+		}
+	}
+}
+
 func TestDomainSpecificQueries(t *testing.T) {
 	// Test that domain-specific queries exist
 	testCases := []string{
@@ -98,3 +130,159 @@ func TestDomainSpecificQueries(t *testing.T) {
 		}
 	}
 }
+
+func TestCollections(t *testing.T) {
+	seen := make(map[string]bool)
+	for _, c := range Collections {
+		if seen[c.Name] {
+			t.Errorf("Collection %s is defined twice", c.Name)
+		}
+		seen[c.Name] = true
+
+		if c.Query.Filter == "" {
+			t.Errorf("Collection %s should have a filter", c.Name)
+		}
+
+		if len(c.Query.Attributes) == 0 {
+			t.Errorf("Collection %s should have attributes", c.Name)
+		}
+	}
+}
+
+func TestLoadFromDir(t *testing.T) {
+	dir := t.TempDir()
+	files := map[string]string{
+		"finance.yaml": `queries:
+  - name: financeusers
+    description: Finance department users
+    category: House
+    filter: (&(objectClass=user)(department=Finance))
+    attributes: [sAMAccountName, title]
+`,
+		"list.json": `[{"name": "servicedesk", "filter": "(department=Service Desk)"}]`,
+		"broken.yml": `- name: broken
+  filter: (objectClass=user`,
+		"clash.yaml": `- name: users
+  filter: (objectClass=user)`,
+		"notes.txt": `ignored`,
+	}
+	for name, content := range files {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	r := &Registry{queries: map[string]Query{"users": {Filter: "(objectClass=user)"}}}
+	defs, err := r.LoadFromDir(dir)
+	if err == nil {
+		t.Error("Expected errors for the invalid filter and the duplicate name")
+	}
+	if len(defs) != 2 {
+		t.Fatalf("Expected 2 loaded queries, got %d", len(defs))
+	}
+
+	q, ok := r.queries["financeusers"]
+	if !ok || q.Filter != "(&(objectClass=user)(department=Finance))" || len(q.Attributes) != 2 {
+		t.Errorf("financeusers not registered correctly: %+v", q)
+	}
+	if defs[0].Category != "House" || defs[0].Description != "Finance department users" {
+		t.Errorf("Unexpected metadata: %+v", defs[0])
+	}
+	if _, ok := r.queries["servicedesk"]; !ok {
+		t.Error("servicedesk from the JSON pack should be registered")
+	}
+	if _, ok := r.queries["broken"]; ok {
+		t.Error("broken should not be registered")
+	}
+}
+
+func TestQueryParams(t *testing.T) {
+	q := withImplicitParams(Query{
+		Filter: "(&(sAMAccountName={user})(memberOf=CN=Staff,{domain})(description={note}))",
+		Params: []Param{{Name: "note", Default: "n/a"}},
+	})
+	if len(q.Params) != 3 {
+		t.Fatalf("Expected 3 parameters, got %+v", q.Params)
+	}
+
+	builder := NewQueryBuilder(q).WithParam(ParamDomain, "DC=example,DC=com")
+	if missing := builder.MissingParams(); len(missing) != 1 || missing[0] != "user" {
+		t.Errorf("Expected user to be missing, got %v", missing)
+	}
+
+	result := builder.WithParam("user", "j*doe)").Build()
+	expected := `(&(sAMAccountName=j\2adoe\29)(memberOf=CN=Staff,DC=example,DC=com)(description=n/a))`
+	if result.Filter != expected {
+		t.Errorf("Expected filter %s, got %s", expected, result.Filter)
+	}
+}
+
+func TestQueryDaysParams(t *testing.T) {
+	q := withImplicitParams(Query{
+		Filter: "(&(accountExpires>={now})(accountExpires<={days}))",
+		Params: []Param{{Name: "days", Default: "30", Type: ParamTypeDays}},
+	})
+	if len(q.Params) != 1 {
+		t.Fatalf("{now} should not be a parameter, got %+v", q.Params)
+	}
+
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	result := NewQueryBuilder(q).WithNow(now).WithParam("days", "1").Build()
+	expected := "(&(accountExpires>=134116992000000000)(accountExpires<=134117856000000000))"
+	if result.Filter != expected {
+		t.Errorf("Expected filter %s, got %s", expected, result.Filter)
+	}
+}
+
+func TestRegisterConflicts(t *testing.T) {
+	r := &Registry{queries: map[string]Query{"users": {Filter: "(objectClass=user)"}}}
+	if err := r.register("users", Query{Filter: "(cn=x)"}); err == nil {
+		t.Error("Registering an existing name should fail")
+	}
+	if err := r.Add(Definition{Name: "Users", Filter: "(cn=x)"}); err == nil {
+		t.Error("Adding a name that differs only in case should fail")
+	}
+	if r.queries["users"].Filter != "(objectClass=user)" {
+		t.Errorf("The registered query was replaced: %+v", r.queries["users"])
+	}
+
+	path := filepath.Join(t.TempDir(), "twice.yaml")
+	pack := "- name: finance\n  filter: (department=Finance)\n- name: Finance\n  filter: (department=Sales)\n"
+	if err := os.WriteFile(path, []byte(pack), 0o600); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.LoadFromFile(path); err == nil {
+		t.Error("A pack defining a name twice should fail")
+	}
+	if _, ok := r.queries["finance"]; ok {
+		t.Error("No query of a failed pack should be registered")
+	}
+}
+
+func TestProfileAttributes(t *testing.T) {
+	q := Query{Attributes: []string{"dn", "sAMAccountName", "description", "memberOf"}}
+	tests := map[string][]string{
+		"":                  {"dn", "sAMAccountName", "description", "memberOf"},
+		AttrProfileStandard: {"dn", "sAMAccountName", "description", "memberOf"},
+		AttrProfileMinimal:  {"dn", "sAMAccountName"},
+		AttrProfileFull:     {"*", "+", "dn", "sAMAccountName", "description", "memberOf"},
+	}
+	for profile, want := range tests {
+		got, err := q.ProfileAttributes(profile)
+		if err != nil || !slices.Equal(got, want) {
+			t.Errorf("ProfileAttributes(%q) = %v, %v, want %v", profile, got, err, want)
+		}
+	}
+	if _, err := q.ProfileAttributes("huge"); err == nil {
+		t.Error("Expected an error for an unknown profile")
+	}
+
+	built := NewQueryBuilder(Query{Attributes: []string{"description"}}).WithAttrProfile(AttrProfileMinimal).Build()
+	if !slices.Equal(built.Attributes, []string{"distinguishedName"}) {
+		t.Errorf("Minimal without identifying attributes = %v", built.Attributes)
+	}
+	built = NewQueryBuilder(q).WithAttrProfile(AttrProfileFull).WithAttributes("cn").Build()
+	if !slices.Equal(built.Attributes, []string{"cn"}) {
+		t.Errorf("Explicit attributes should override the profile, got %v", built.Attributes)
+	}
+}
@@ -0,0 +1,164 @@
+// Package redact masks secrets in text and structured fields before they
+// are logged or shown in diagnostics: bind passwords, LAPS and other
+// password attributes, credentials in URLs and values after a password
+// keyword.
+package redact
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Mask replaces a secret value
+const Mask = "********"
+
+// minSecretLen is the shortest registered secret that is masked, so short
+// values do not mask unrelated text
+const minSecretLen = 4
+
+// secretAttributes hold secrets in full (lowercase)
+var secretAttributes = map[string]bool{
+	"ms-mcs-admpwd":                  true, // Legacy LAPS password
+	"mslaps-password":                true, // Windows LAPS password
+	"mslaps-encryptedpassword":       true,
+	"mslaps-encrypteddsrmpassword":   true,
+	"userpassword":                   true,
+	"unixuserpassword":               true,
+	"unicodepwd":                     true,
+	"msds-managedpassword":           true, // gMSA password blob
+	"mssfu30password":                true,
+	"os400-password":                 true,
+	"msds-hostservicaccountpassword": true,
+	"msfve-recoverypassword":         true, // BitLocker recovery password
+	"msfve-keypackage":               true,
+	"mskds-rootkeydata":              true, // KDS root key, derives every gMSA password
+}
+
+// secretKeyParts mark a field or map key containing them as holding a
+// secret (lowercase)
+var secretKeyParts = []string{"password", "passwd", "secret", "apikey", "api_key", "authorization", "credential"}
+
+// secretKeySuffixes mark a key ending in them as holding a secret, e.g.
+// "sinkToken" but not "tokenGroups" or "pwdLastSet" (lowercase)
+var secretKeySuffixes = []string{"pwd", "token"}
+
+var (
+	// passwordPattern matches a whole password keyword, an explicit ":", "="
+	// or "is" separator and the value after it, so "password report" or
+	// "pwdLastSet: ..." are left alone
+	passwordPattern = regexp.MustCompile(`(?i)\b(password|passwd|passwort|pwd|kennwort|contrase(?:n|ñ)a|mot de passe)\b(\s*[:=]\s*|\s+(?:is|ist|est|es)\s+)(\S+)`)
+
+	// attributePattern matches a secret attribute name followed by a value
+	attributePattern = regexp.MustCompile(`(?i)\b(` + attributeAlternatives() + `)(\s*[:=]\s*)(\S+)`)
+
+	// urlUserinfoPattern matches the password of user:password@ in a URL
+	urlUserinfoPattern = regexp.MustCompile(`(\b[a-zA-Z][a-zA-Z0-9+.-]*://[^/\s:@]*:)([^/\s@]+)(@)`)
+)
+
+// attributeAlternatives returns the secret attribute names as a regexp
+// alternation, longest first so prefixes do not win
+func attributeAlternatives() string {
+	names := make([]string, 0, len(secretAttributes))
+	for name := range secretAttributes {
+		names = append(names, regexp.QuoteMeta(name))
+	}
+	sort.Slice(names, func(i, j int) bool { return len(names[i]) > len(names[j]) })
+	return strings.Join(names, "|")
+}
+
+// secrets are exact values registered with AddSecret
+var secrets struct {
+	sync.RWMutex
+	values []string
+}
+
+// AddSecret makes Text mask every occurrence of value, e.g. the bind
+// password. Values shorter than four characters are ignored.
+func AddSecret(value string) {
+	if len(value) < minSecretLen {
+		return
+	}
+	secrets.Lock()
+	defer secrets.Unlock()
+	for _, v := range secrets.values {
+		if v == value {
+			return
+		}
+	}
+	secrets.values = append(secrets.values, value)
+	// Longest first, so a secret containing another is masked whole
+	sort.Slice(secrets.values, func(i, j int) bool { return len(secrets.values[i]) > len(secrets.values[j]) })
+}
+
+// IsSecretAttribute reports whether an LDAP attribute holds a secret in full
+func IsSecretAttribute(name string) bool {
+	return secretAttributes[strings.ToLower(name)]
+}
+
+// IsSecretKey reports whether a field or map key names a secret, e.g.
+// "password", "bindPassword", "sinkToken" or a secret LDAP attribute
+func IsSecretKey(key string) bool {
+	lower := strings.ToLower(key)
+	if secretAttributes[lower] {
+		return true
+	}
+	for _, part := range secretKeyParts {
+		if strings.Contains(lower, part) {
+			return true
+		}
+	}
+	for _, suffix := range secretKeySuffixes {
+		if strings.HasSuffix(lower, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+// Text masks registered secrets, URL passwords and values that follow a
+// password keyword or a secret attribute name in s
+func Text(s string) string {
+	secrets.RLock()
+	for _, v := range secrets.values {
+		s = strings.ReplaceAll(s, v, Mask)
+	}
+	secrets.RUnlock()
+
+	s = urlUserinfoPattern.ReplaceAllString(s, "${1}"+Mask+"${3}")
+	s = attributePattern.ReplaceAllString(s, "${1}${2}"+Mask)
+	return passwordPattern.ReplaceAllString(s, "${1}${2}"+Mask)
+}
+
+// Field returns the value to show for a field named key: Mask if the key
+// names a secret, otherwise the value with Text applied to its text
+func Field(key string, value any) any {
+	if IsSecretKey(key) {
+		return Mask
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case string:
+		return Text(v)
+	case error:
+		return Text(v.Error())
+	case fmt.Stringer:
+		return Text(v.String())
+	}
+	return value
+}
+
+// Map returns a copy of m with Field applied to every value
+func Map(m map[string]string) map[string]string {
+	if m == nil {
+		return nil
+	}
+	out := make(map[string]string, len(m))
+	for k, v := range m {
+		out[k] = fmt.Sprint(Field(k, v))
+	}
+	return out
+}
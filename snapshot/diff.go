@@ -0,0 +1,224 @@
+package snapshot
+
+import (
+	"adgo/analyze"
+	"encoding/base64"
+	"fmt"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ChangeKind classifies a change between two snapshots
+type ChangeKind string
+
+// Change kinds
+const (
+	ChangeAdded    ChangeKind = "added"    // Object only exists in the new snapshot
+	ChangeRemoved  ChangeKind = "removed"  // Object only exists in the old snapshot
+	ChangeModified ChangeKind = "modified" // Attribute values differ
+)
+
+// VolatileAttributes change during normal operation and are ignored by Diff
+var VolatileAttributes = map[string]bool{
+	"lastlogon":             true,
+	"lastlogontimestamp":    true,
+	"lastlogoff":            true,
+	"logoncount":            true,
+	"badpwdcount":           true,
+	"badpasswordtime":       true,
+	"whenchanged":           true,
+	"usnchanged":            true,
+	"dscorepropagationdata": true,
+	"replpropertymetadata":  true,
+}
+
+// Change is a single object-level difference
+type Change struct {
+	Kind      ChangeKind `json:"kind"`
+	ID        string     `json:"id"`
+	DN        string     `json:"dn"`
+	Attribute string     `json:"attribute,omitempty"` // Changed attribute (modified only)
+	Added     []string   `json:"added,omitempty"`     // Values (or flags, edges) gained
+	Removed   []string   `json:"removed,omitempty"`   // Values (or flags, edges) lost
+}
+
+// Diff compares two sets of objects and returns the changes from prev to cur.
+// Objects are matched by ID, so renamed or moved objects show up as a DN
+// change rather than a removal and an addition. userAccountControl changes
+// are reported as flags and nTSecurityDescriptor changes as ACL edges.
+func Diff(prev, cur []Object) []Change {
+	prevByID := make(map[string]Object, len(prev))
+	for _, o := range prev {
+		prevByID[o.ID] = o
+	}
+	curByID := make(map[string]Object, len(cur))
+	for _, o := range cur {
+		curByID[o.ID] = o
+	}
+
+	var changes []Change
+	for _, o := range cur {
+		p, ok := prevByID[o.ID]
+		if !ok {
+			changes = append(changes, Change{Kind: ChangeAdded, ID: o.ID, DN: o.DN})
+			continue
+		}
+		changes = append(changes, diffObject(p, o)...)
+	}
+	for _, o := range prev {
+		if _, ok := curByID[o.ID]; !ok {
+			changes = append(changes, Change{Kind: ChangeRemoved, ID: o.ID, DN: o.DN})
+		}
+	}
+
+	sort.SliceStable(changes, func(i, j int) bool {
+		return strings.ToLower(changes[i].DN) < strings.ToLower(changes[j].DN)
+	})
+	return changes
+}
+
+// diffObject compares the attributes of one object in two snapshots
+func diffObject(prev, cur Object) []Change {
+	var changes []Change
+	if prev.DN != cur.DN {
+		changes = append(changes, Change{
+			Kind: ChangeModified, ID: cur.ID, DN: cur.DN, Attribute: "dn",
+			Added: []string{cur.DN}, Removed: []string{prev.DN},
+		})
+	}
+
+	names := make(map[string]string)
+	for name := range prev.Attributes {
+		names[strings.ToLower(name)] = name
+	}
+	for name := range cur.Attributes {
+		names[strings.ToLower(name)] = name
+	}
+	keys := make([]string, 0, len(names))
+	for key := range names {
+		if !VolatileAttributes[key] {
+			keys = append(keys, key)
+		}
+	}
+	sort.Strings(keys)
+
+	for _, key := range keys {
+		name := names[key]
+		before, after := prev.Get(name), cur.Get(name)
+		if slices.Equal(before, after) {
+			continue
+		}
+
+		var added, removed []string
+		switch key {
+		case strings.ToLower(analyze.AttrUserAccountControl):
+			added, removed = diffValues(uacFlags(before), uacFlags(after))
+		case strings.ToLower(analyze.AttrNTSecurityDescriptor):
+			objectType := edgeObjectType(cur)
+			added, removed = diffValues(aclEdges(before, objectType), aclEdges(after, objectType))
+			if len(added) == 0 && len(removed) == 0 {
+				// Descriptor changed without affecting abusable rights
+				added = []string{"(descriptor changed)"}
+			}
+		default:
+			added, removed = diffValues(before, after)
+		}
+
+		changes = append(changes, Change{
+			Kind: ChangeModified, ID: cur.ID, DN: cur.DN, Attribute: name,
+			Added: added, Removed: removed,
+		})
+	}
+	return changes
+}
+
+// diffValues returns the values only in after (added) and only in before (removed)
+func diffValues(before, after []string) (added, removed []string) {
+	for _, v := range after {
+		if !slices.Contains(before, v) {
+			added = append(added, v)
+		}
+	}
+	for _, v := range before {
+		if !slices.Contains(after, v) {
+			removed = append(removed, v)
+		}
+	}
+	return added, removed
+}
+
+// uacFlags expands userAccountControl values into flag names
+func uacFlags(values []string) []string {
+	var flags []string
+	for _, v := range values {
+		uac, err := strconv.ParseUint(v, 10, 32)
+		if err != nil {
+			flags = append(flags, v)
+			continue
+		}
+		flags = append(flags, analyze.UACFlagNames(uint32(uac))...)
+	}
+	return flags
+}
+
+// aclEdges expands security descriptor values into "Right PrincipalSID" strings
+func aclEdges(values []string, objectType string) []string {
+	var edges []string
+	for _, v := range values {
+		raw, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(v, binaryPrefix))
+		if err != nil {
+			continue
+		}
+		acl, err := analyze.ExtractEdges(raw, objectType)
+		if err != nil {
+			continue
+		}
+		for _, e := range acl.Edges {
+			edges = append(edges, fmt.Sprintf("%s %s", e.RightName, e.PrincipalSID))
+		}
+	}
+	return edges
+}
+
+// edgeObjectType maps an object's classes to the type used by ExtractEdges
+func edgeObjectType(o Object) string {
+	classes := o.Get(analyze.AttrObjectClass)
+	switch {
+	case slices.Contains(classes, "computer"):
+		return "Computer"
+	case slices.Contains(classes, "user"):
+		return "User"
+	case slices.Contains(classes, "group"):
+		return "Group"
+	case slices.Contains(classes, "domainDNS"), slices.Contains(classes, "domain"):
+		return "Domain"
+	case slices.Contains(classes, "groupPolicyContainer"):
+		return "GPO"
+	case slices.Contains(classes, "organizationalUnit"):
+		return "OU"
+	default:
+		return "Container"
+	}
+}
+
+// String formats a change as a single line, e.g.
+// "[~] CN=svc,CN=Users,DC=example,DC=com userAccountControl: +DONT_REQUIRE_PREAUTH"
+func (c Change) String() string {
+	switch c.Kind {
+	case ChangeAdded:
+		return "[+] " + c.DN
+	case ChangeRemoved:
+		return "[-] " + c.DN
+	}
+
+	parts := make([]string, 0, len(c.Added)+len(c.Removed))
+	for _, v := range c.Added {
+		parts = append(parts, "+"+v)
+	}
+	for _, v := range c.Removed {
+		parts = append(parts, "-"+v)
+	}
+	return fmt.Sprintf("[~] %s %s: %s", c.DN, c.Attribute, strings.Join(parts, " "))
+}
@@ -0,0 +1,172 @@
+package snapshot
+
+import (
+	"bytes"
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// object normalizes an entry for Diff; guid fills the objectGUID with one
+// byte, and 0 leaves it out so the object is matched by DN
+func object(dn string, guid byte, attrs map[string][]string) Object {
+	e := ldap.NewEntry(dn, attrs)
+	if guid != 0 {
+		raw := bytes.Repeat([]byte{guid}, 16)
+		e.Attributes = append(e.Attributes, &ldap.EntryAttribute{
+			Name: "objectGUID", Values: []string{string(raw)}, ByteValues: [][]byte{raw},
+		})
+	}
+	return NewObject(e)
+}
+
+// guidID is the ID of an object created by object with the given guid
+func guidID(guid byte) string {
+	b := strings.Repeat(fmt.Sprintf("%02x", guid), 16)
+	return "{" + b[:8] + "-" + b[8:12] + "-" + b[12:16] + "-" + b[16:20] + "-" + b[20:] + "}"
+}
+
+func TestDiff(t *testing.T) {
+	const (
+		alice  = "CN=Alice,OU=Staff,DC=example,DC=com"
+		bob    = "CN=Bob,OU=Staff,DC=example,DC=com"
+		admins = "CN=Domain Admins,CN=Users,DC=example,DC=com"
+		vpn    = "CN=VPN,OU=Groups,DC=example,DC=com"
+		wifi   = "CN=WiFi,OU=Groups,DC=example,DC=com"
+	)
+
+	tests := []struct {
+		name string
+		prev []Object
+		cur  []Object
+		want []Change
+	}{
+		{
+			name: "unchanged",
+			prev: []Object{object(alice, 1, map[string][]string{"memberOf": {vpn, wifi}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"memberOf": {vpn, wifi}})},
+		},
+		{
+			name: "added and removed",
+			prev: []Object{object(alice, 1, nil)},
+			cur:  []Object{object(bob, 2, nil)},
+			want: []Change{
+				{Kind: ChangeRemoved, ID: guidID(1), DN: alice},
+				{Kind: ChangeAdded, ID: guidID(2), DN: bob},
+			},
+		},
+		{
+			name: "modified value",
+			prev: []Object{object(alice, 1, map[string][]string{"title": {"Engineer"}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"title": {"Manager"}})},
+			want: []Change{{Kind: ChangeModified, ID: guidID(1), DN: alice, Attribute: "title",
+				Added: []string{"Manager"}, Removed: []string{"Engineer"}}},
+		},
+		{
+			name: "attribute set and cleared",
+			prev: []Object{object(alice, 1, map[string][]string{"description": {"temp"}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"info": {"moved"}})},
+			want: []Change{
+				{Kind: ChangeModified, ID: guidID(1), DN: alice, Attribute: "description", Removed: []string{"temp"}},
+				{Kind: ChangeModified, ID: guidID(1), DN: alice, Attribute: "info", Added: []string{"moved"}},
+			},
+		},
+		{
+			name: "multi-valued attribute",
+			prev: []Object{object(alice, 1, map[string][]string{"memberOf": {vpn, wifi}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"memberOf": {admins, vpn}})},
+			want: []Change{{Kind: ChangeModified, ID: guidID(1), DN: alice, Attribute: "memberOf",
+				Added: []string{admins}, Removed: []string{wifi}}},
+		},
+		{
+			name: "multi-valued attribute reordered",
+			prev: []Object{object(alice, 1, map[string][]string{"memberOf": {vpn, wifi, admins}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"memberOf": {wifi, admins, vpn}})},
+		},
+		{
+			name: "attribute name case",
+			prev: []Object{object(alice, 1, map[string][]string{"memberOf": {vpn}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"memberof": {vpn}})},
+		},
+		{
+			name: "DN case matched by GUID",
+			prev: []Object{object(alice, 1, nil)},
+			cur:  []Object{object(strings.ToUpper(alice), 1, nil)},
+			want: []Change{{Kind: ChangeModified, ID: guidID(1), DN: strings.ToUpper(alice), Attribute: "dn",
+				Added: []string{strings.ToUpper(alice)}, Removed: []string{alice}}},
+		},
+		{
+			name: "DN case matched by DN",
+			prev: []Object{object(alice, 0, map[string][]string{"title": {"Engineer"}})},
+			cur:  []Object{object(strings.ToLower(alice), 0, map[string][]string{"title": {"Engineer"}})},
+			want: []Change{{Kind: ChangeModified, ID: strings.ToLower(alice), DN: strings.ToLower(alice), Attribute: "dn",
+				Added: []string{strings.ToLower(alice)}, Removed: []string{alice}}},
+		},
+		{
+			name: "moved object",
+			prev: []Object{object(alice, 1, nil)},
+			cur:  []Object{object("CN=Alice,OU=Leavers,DC=example,DC=com", 1, nil)},
+			want: []Change{{Kind: ChangeModified, ID: guidID(1), DN: "CN=Alice,OU=Leavers,DC=example,DC=com", Attribute: "dn",
+				Added: []string{"CN=Alice,OU=Leavers,DC=example,DC=com"}, Removed: []string{alice}}},
+		},
+		{
+			name: "volatile attributes",
+			prev: []Object{object(alice, 1, map[string][]string{"lastLogon": {"133000000000000000"}, "logonCount": {"4"}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"lastLogon": {"133100000000000000"}, "logonCount": {"5"}})},
+		},
+		{
+			name: "userAccountControl flags",
+			prev: []Object{object(alice, 1, map[string][]string{"userAccountControl": {"512"}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"userAccountControl": {"4194816"}})},
+			want: []Change{{Kind: ChangeModified, ID: guidID(1), DN: alice, Attribute: "userAccountControl",
+				Added: []string{"DONT_REQUIRE_PREAUTH"}}},
+		},
+		{
+			name: "descriptor without rights changes",
+			prev: []Object{object(alice, 1, map[string][]string{"nTSecurityDescriptor": {"\x01\x00\x04\x80"}})},
+			cur:  []Object{object(alice, 1, map[string][]string{"nTSecurityDescriptor": {"\x01\x00\x04\x90"}})},
+			want: []Change{{Kind: ChangeModified, ID: guidID(1), DN: alice, Attribute: "nTSecurityDescriptor",
+				Added: []string{"(descriptor changed)"}}},
+		},
+		{
+			name: "sorted by DN ignoring case",
+			prev: []Object{object("cn=Carol,DC=example,DC=com", 3, nil)},
+			cur:  []Object{object(bob, 2, nil), object(alice, 1, nil)},
+			want: []Change{
+				{Kind: ChangeAdded, ID: guidID(1), DN: alice},
+				{Kind: ChangeAdded, ID: guidID(2), DN: bob},
+				{Kind: ChangeRemoved, ID: guidID(3), DN: "cn=Carol,DC=example,DC=com"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := Diff(tt.prev, tt.cur)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Diff =\n%v\nwant\n%v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestChangeString(t *testing.T) {
+	tests := []struct {
+		change Change
+		want   string
+	}{
+		{Change{Kind: ChangeAdded, DN: "CN=Bob,DC=example,DC=com"}, "[+] CN=Bob,DC=example,DC=com"},
+		{Change{Kind: ChangeRemoved, DN: "CN=Bob,DC=example,DC=com"}, "[-] CN=Bob,DC=example,DC=com"},
+		{Change{Kind: ChangeModified, DN: "CN=Bob,DC=example,DC=com", Attribute: "memberOf",
+			Added: []string{"CN=VPN"}, Removed: []string{"CN=WiFi"}},
+			"[~] CN=Bob,DC=example,DC=com memberOf: +CN=VPN -CN=WiFi"},
+	}
+	for _, tt := range tests {
+		if got := tt.change.String(); got != tt.want {
+			t.Errorf("String() = %q, want %q", got, tt.want)
+		}
+	}
+}
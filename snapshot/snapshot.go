@@ -0,0 +1,110 @@
+// Package snapshot persists normalized query results and computes
+// object-level differences between two result sets.
+package snapshot
+
+import (
+	"adgo/analyze"
+	"encoding/base64"
+	"sort"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+// binaryPrefix marks attribute values stored as base64
+const binaryPrefix = "base64:"
+
+// Snapshot is a saved set of query results
+type Snapshot struct {
+	Name    string    `json:"name"`    // Snapshot name (file name without extension)
+	Query   string    `json:"query"`   // Query the results came from
+	Filter  string    `json:"filter"`  // LDAP filter that was executed
+	BaseDN  string    `json:"baseDN"`  // Search base
+	Created time.Time `json:"created"` // Time the results were collected
+	Objects []Object  `json:"objects"` // Normalized objects, sorted by DN
+}
+
+// Object is a normalized LDAP entry
+type Object struct {
+	ID         string              `json:"id"`         // objectGUID, or the lowercased DN when absent
+	DN         string              `json:"dn"`         // Distinguished name
+	Attributes map[string][]string `json:"attributes"` // Sorted values by attribute name
+}
+
+// New builds a snapshot from LDAP entries.
+// Values are sorted so that reordering by the server does not show up as a change;
+// binary values are stored as base64 with a "base64:" prefix.
+func New(name, query, filter, baseDN string, entries []*ldap.Entry) *Snapshot {
+	s := &Snapshot{
+		Name:    name,
+		Query:   query,
+		Filter:  filter,
+		BaseDN:  baseDN,
+		Created: time.Now().UTC(),
+		Objects: make([]Object, 0, len(entries)),
+	}
+
+	for _, e := range entries {
+		if e != nil {
+			s.Objects = append(s.Objects, NewObject(e))
+		}
+	}
+	sort.Slice(s.Objects, func(i, j int) bool {
+		return strings.ToLower(s.Objects[i].DN) < strings.ToLower(s.Objects[j].DN)
+	})
+	return s
+}
+
+// NewObject normalizes a single LDAP entry
+func NewObject(e *ldap.Entry) Object {
+	obj := Object{
+		ID:         objectID(e),
+		DN:         e.DN,
+		Attributes: make(map[string][]string, len(e.Attributes)),
+	}
+
+	for _, attr := range e.Attributes {
+		values := make([]string, 0, len(attr.ByteValues))
+		for _, raw := range attr.ByteValues {
+			values = append(values, normalizeValue(raw))
+		}
+		sort.Strings(values)
+		obj.Attributes[attr.Name] = values
+	}
+	return obj
+}
+
+// objectID identifies an object across snapshots, surviving renames and moves
+func objectID(e *ldap.Entry) string {
+	if raw := e.GetEqualFoldRawAttributeValue(analyze.AttrObjectGUID); len(raw) > 0 {
+		if guid, err := analyze.ParseObjectGUID(raw); err == nil {
+			return strings.ToLower(guid)
+		}
+	}
+	return strings.ToLower(e.DN)
+}
+
+// normalizeValue returns printable values as-is and base64-encodes the rest
+func normalizeValue(raw []byte) string {
+	if utf8.Valid(raw) && !strings.ContainsFunc(string(raw), func(r rune) bool {
+		return r < 0x20 && r != '\t' && r != '\n' && r != '\r'
+	}) {
+		return string(raw)
+	}
+	return binaryPrefix + base64.StdEncoding.EncodeToString(raw)
+}
+
+// Get returns the values of an attribute, matching its name case-insensitively
+func (o Object) Get(name string) []string {
+	if v, ok := o.Attributes[name]; ok {
+		return v
+	}
+	for k, v := range o.Attributes {
+		if strings.EqualFold(k, name) {
+			return v
+		}
+	}
+	return nil
+}
@@ -0,0 +1,125 @@
+package snapshot
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// snapshotExt is the file extension of stored snapshots
+const snapshotExt = ".json"
+
+// Store saves and loads snapshots as JSON files in a directory
+type Store struct {
+	Dir string
+}
+
+// Info summarizes a stored snapshot
+type Info struct {
+	Name    string
+	Query   string
+	Created time.Time
+	Objects int
+}
+
+// NewStore creates a store rooted at dir
+func NewStore(dir string) *Store {
+	return &Store{Dir: dir}
+}
+
+// DefaultDir returns the default snapshot directory ($HOME/.adgo/snapshots)
+func DefaultDir() string {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "snapshots"
+	}
+	return filepath.Join(home, ".adgo", "snapshots")
+}
+
+// Save writes a snapshot, replacing any snapshot with the same name
+func (st *Store) Save(s *Snapshot) (string, error) {
+	if err := validateName(s.Name); err != nil {
+		return "", err
+	}
+	if err := os.MkdirAll(st.Dir, 0700); err != nil {
+		return "", fmt.Errorf("creating snapshot directory: %w", err)
+	}
+
+	data, err := json.MarshalIndent(s, "", "  ")
+	if err != nil {
+		return "", fmt.Errorf("marshaling snapshot: %w", err)
+	}
+
+	path := st.path(s.Name)
+	// Snapshots may contain sensitive attributes; keep them private
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		return "", fmt.Errorf("writing snapshot: %w", err)
+	}
+	return path, nil
+}
+
+// Load reads a snapshot by name, or from a file path
+func (st *Store) Load(name string) (*Snapshot, error) {
+	path := name
+	if _, err := os.Stat(path); err != nil {
+		if err := validateName(name); err != nil {
+			return nil, err
+		}
+		path = st.path(name)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("reading snapshot %s: %w", name, err)
+	}
+
+	var s Snapshot
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("parsing snapshot %s: %w", name, err)
+	}
+	return &s, nil
+}
+
+// List returns the stored snapshots, oldest first
+func (st *Store) List() ([]Info, error) {
+	files, err := filepath.Glob(filepath.Join(st.Dir, "*"+snapshotExt))
+	if err != nil {
+		return nil, err
+	}
+
+	infos := make([]Info, 0, len(files))
+	for _, f := range files {
+		s, err := st.Load(f)
+		if err != nil {
+			return nil, err
+		}
+		infos = append(infos, Info{
+			Name:    strings.TrimSuffix(filepath.Base(f), snapshotExt),
+			Query:   s.Query,
+			Created: s.Created,
+			Objects: len(s.Objects),
+		})
+	}
+
+	sort.Slice(infos, func(i, j int) bool {
+		return infos[i].Created.Before(infos[j].Created)
+	})
+	return infos, nil
+}
+
+// path returns the file path for a snapshot name
+func (st *Store) path(name string) string {
+	return filepath.Join(st.Dir, name+snapshotExt)
+}
+
+// validateName rejects names that would escape the snapshot directory
+func validateName(name string) error {
+	if name == "" || strings.ContainsAny(name, `/\`) || name == "." || name == ".." {
+		return fmt.Errorf("invalid snapshot name: %q", name)
+	}
+	return nil
+}
@@ -0,0 +1,89 @@
+package snapshot
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"runtime"
+	"testing"
+	"time"
+
+	"github.com/go-ldap/ldap/v3"
+)
+
+func TestStoreRoundTrip(t *testing.T) {
+	sid := []byte{0x01, 0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x05, 0x12, 0x00, 0x00, 0x00}
+	entries := []*ldap.Entry{
+		ldap.NewEntry("CN=Bob,OU=Staff,DC=example,DC=com", map[string][]string{
+			"memberOf": {"CN=WiFi,OU=Groups,DC=example,DC=com", "CN=VPN,OU=Groups,DC=example,DC=com"},
+		}),
+		{DN: "CN=alice,OU=Staff,DC=example,DC=com", Attributes: []*ldap.EntryAttribute{
+			{Name: "objectSid", Values: []string{string(sid)}, ByteValues: [][]byte{sid}},
+		}},
+	}
+	saved := New("baseline", "users", "(objectClass=user)", "DC=example,DC=com", entries)
+	saved.Created = saved.Created.Truncate(time.Second)
+
+	st := NewStore(filepath.Join(t.TempDir(), "snapshots"))
+	path, err := st.Save(saved)
+	if err != nil {
+		t.Fatalf("Save: %v", err)
+	}
+	if path != filepath.Join(st.Dir, "baseline.json") {
+		t.Errorf("Save path = %s", path)
+	}
+	if info, err := os.Stat(path); err != nil {
+		t.Fatal(err)
+	} else if runtime.GOOS != "windows" && info.Mode().Perm() != 0600 {
+		t.Errorf("snapshot mode = %v, want 0600", info.Mode().Perm())
+	}
+
+	// Objects are sorted by DN ignoring case, values sorted and binary values prefixed
+	if saved.Objects[0].DN != "CN=alice,OU=Staff,DC=example,DC=com" ||
+		saved.Objects[0].Attributes["objectSid"][0] != "base64:AQEAAAAAAAUSAAAA" ||
+		saved.Objects[1].Attributes["memberOf"][0] != "CN=VPN,OU=Groups,DC=example,DC=com" {
+		t.Errorf("New objects = %+v", saved.Objects)
+	}
+
+	for _, name := range []string{"baseline", path} {
+		loaded, err := st.Load(name)
+		if err != nil {
+			t.Fatalf("Load(%s): %v", name, err)
+		}
+		if !reflect.DeepEqual(loaded, saved) {
+			t.Errorf("Load(%s) = %+v\nwant %+v", name, loaded, saved)
+		}
+		if changes := Diff(saved.Objects, loaded.Objects); len(changes) != 0 {
+			t.Errorf("Diff after a round trip = %v", changes)
+		}
+	}
+
+	later := New("later", "users", "(objectClass=user)", "DC=example,DC=com", entries[:1])
+	later.Created = saved.Created.Add(time.Hour)
+	if _, err := st.Save(later); err != nil {
+		t.Fatal(err)
+	}
+	infos, err := st.List()
+	if err != nil {
+		t.Fatalf("List: %v", err)
+	}
+	want := []Info{
+		{Name: "baseline", Query: "users", Created: saved.Created, Objects: 2},
+		{Name: "later", Query: "users", Created: later.Created, Objects: 1},
+	}
+	if !reflect.DeepEqual(infos, want) {
+		t.Errorf("List = %+v, want %+v", infos, want)
+	}
+}
+
+func TestStoreInvalidName(t *testing.T) {
+	st := NewStore(t.TempDir())
+	for _, name := range []string{"", ".", "..", "../escape", `sub\dir`} {
+		if _, err := st.Save(&Snapshot{Name: name}); err == nil {
+			t.Errorf("Save(%q) succeeded", name)
+		}
+	}
+	if _, err := st.Load("missing"); err == nil {
+		t.Error("Load of a missing snapshot succeeded")
+	}
+}
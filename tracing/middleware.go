@@ -0,0 +1,57 @@
+package tracing
+
+import (
+	"adgo/connect"
+	"context"
+	"strings"
+)
+
+// Middleware returns a connect.Middleware that records every search of a
+// client as an "ldap.search" span
+func Middleware() connect.Middleware {
+	return searchMiddleware{}
+}
+
+// searchMiddleware records searches as spans
+type searchMiddleware struct {
+	connect.BaseMiddleware
+}
+
+// search is the span and page count of a running search. Pages are only
+// counted by the search goroutine.
+type search struct {
+	span  *Span
+	pages int
+}
+
+// searchKey carries the running search
+type searchKey struct{}
+
+func (searchMiddleware) OnSearchStart(ctx context.Context, filter string, attributes []string) (context.Context, error) {
+	ctx, span := Start(ctx, "ldap.search",
+		String("ldap.filter", filter),
+		String("ldap.attributes", strings.Join(attributes, ",")))
+	if span == nil {
+		return ctx, nil
+	}
+	return context.WithValue(ctx, searchKey{}, &search{span: span}), nil
+}
+
+func (searchMiddleware) OnPage(ctx context.Context, entries int) {
+	if s, ok := ctx.Value(searchKey{}).(*search); ok {
+		s.pages++
+	}
+}
+
+func (searchMiddleware) OnSearchEnd(ctx context.Context, filter string, entries int, err error) {
+	s, ok := ctx.Value(searchKey{}).(*search)
+	if !ok {
+		return
+	}
+	if s.pages > 0 {
+		s.span.SetAttributes(Int("ldap.pages", s.pages))
+	}
+	s.span.SetAttributes(Int("ldap.entries", entries))
+	s.span.SetError(err)
+	s.span.End()
+}
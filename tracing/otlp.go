@@ -0,0 +1,282 @@
+package tracing
+
+import (
+	"bytes"
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// batchSize is the number of ended spans that triggers an export
+	batchSize = 256
+
+	// exportTimeout bounds one export request
+	exportTimeout = 10 * time.Second
+)
+
+// exp is the configured exporter, nil while tracing is off
+var exp atomic.Pointer[exporter]
+
+// current returns the configured exporter, or nil
+func current() *exporter {
+	return exp.Load()
+}
+
+// Config configures the OTLP exporter
+type Config struct {
+	Endpoint    string            // Traces URL, e.g. http://localhost:4318/v1/traces
+	Headers     map[string]string // Extra request headers, e.g. for authentication
+	ServiceName string            // service.name resource attribute
+	Version     string            // service.version resource attribute
+}
+
+// ConfigFromEnv returns the exporter settings of the standard OpenTelemetry
+// environment variables: OTEL_EXPORTER_OTLP_TRACES_ENDPOINT, or
+// OTEL_EXPORTER_OTLP_ENDPOINT with /v1/traces appended, and
+// OTEL_EXPORTER_OTLP_HEADERS as key=value pairs separated by commas.
+// The endpoint is empty if neither variable is set.
+func ConfigFromEnv() Config {
+	c := Config{Endpoint: os.Getenv("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")}
+	if c.Endpoint == "" {
+		if base := os.Getenv("OTEL_EXPORTER_OTLP_ENDPOINT"); base != "" {
+			c.Endpoint = TracesURL(base)
+		}
+	}
+	if headers := os.Getenv("OTEL_EXPORTER_OTLP_HEADERS"); headers != "" {
+		c.Headers = make(map[string]string)
+		for _, pair := range strings.Split(headers, ",") {
+			if k, v, ok := strings.Cut(pair, "="); ok {
+				c.Headers[strings.TrimSpace(k)] = strings.TrimSpace(v)
+			}
+		}
+	}
+	return c
+}
+
+// TracesURL returns the OTLP traces URL of a collector base URL
+func TracesURL(base string) string {
+	return strings.TrimSuffix(base, "/") + "/v1/traces"
+}
+
+// Enable starts exporting spans to c.Endpoint. Call the returned function
+// to export the remaining spans and stop.
+func Enable(c Config) (shutdown func(context.Context) error, err error) {
+	if c.Endpoint == "" {
+		return nil, fmt.Errorf("no OTLP endpoint configured")
+	}
+	if c.ServiceName == "" {
+		c.ServiceName = "adgo"
+	}
+
+	e := &exporter{config: c, client: &http.Client{Timeout: exportTimeout}}
+	exp.Store(e)
+	return func(ctx context.Context) error {
+		exp.CompareAndSwap(e, nil)
+		return e.flush(ctx)
+	}, nil
+}
+
+// exporter batches ended spans and posts them as OTLP JSON
+type exporter struct {
+	config Config
+	client *http.Client
+
+	mu      sync.Mutex
+	pending []*Span
+	sending sync.WaitGroup
+	errs    []error
+}
+
+// add queues an ended span, exporting a full batch in the background
+func (e *exporter) add(s *Span) {
+	e.mu.Lock()
+	e.pending = append(e.pending, s)
+	var batch []*Span
+	if len(e.pending) >= batchSize {
+		batch, e.pending = e.pending, nil
+	}
+	e.mu.Unlock()
+
+	if batch != nil {
+		e.sending.Add(1)
+		go func() {
+			defer e.sending.Done()
+			ctx, cancel := context.WithTimeout(context.Background(), exportTimeout)
+			defer cancel()
+			e.record(e.send(ctx, batch))
+		}()
+	}
+}
+
+// record keeps a failed export for flush to report
+func (e *exporter) record(err error) {
+	if err == nil {
+		return
+	}
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.errs = append(e.errs, err)
+}
+
+// flush waits for running exports and sends the remaining spans. It
+// returns the first export that failed.
+func (e *exporter) flush(ctx context.Context) error {
+	e.sending.Wait()
+
+	e.mu.Lock()
+	batch := e.pending
+	e.pending = nil
+	e.mu.Unlock()
+
+	if len(batch) > 0 {
+		e.record(e.send(ctx, batch))
+	}
+
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	if len(e.errs) > 0 {
+		return e.errs[0]
+	}
+	return nil
+}
+
+// send posts one batch of spans
+func (e *exporter) send(ctx context.Context, batch []*Span) error {
+	body, err := json.Marshal(e.request(batch))
+	if err != nil {
+		return err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.config.Endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("exporting spans: %w", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	for k, v := range e.config.Headers {
+		req.Header.Set(k, v)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("exporting spans: %w", err)
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return fmt.Errorf("exporting spans: %s: %s", resp.Status, strings.TrimSpace(string(msg)))
+	}
+	return nil
+}
+
+// OTLP JSON request types (opentelemetry-proto, JSON mapping)
+type (
+	otlpRequest struct {
+		ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+	}
+	otlpResourceSpans struct {
+		Resource   otlpResource     `json:"resource"`
+		ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+	}
+	otlpResource struct {
+		Attributes []otlpKeyValue `json:"attributes"`
+	}
+	otlpScopeSpans struct {
+		Scope otlpScope  `json:"scope"`
+		Spans []otlpSpan `json:"spans"`
+	}
+	otlpScope struct {
+		Name    string `json:"name"`
+		Version string `json:"version,omitempty"`
+	}
+	otlpSpan struct {
+		TraceID           string         `json:"traceId"`
+		SpanID            string         `json:"spanId"`
+		ParentSpanID      string         `json:"parentSpanId,omitempty"`
+		Name              string         `json:"name"`
+		Kind              int            `json:"kind"`
+		StartTimeUnixNano string         `json:"startTimeUnixNano"`
+		EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+		Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+		Status            otlpStatus     `json:"status"`
+	}
+	otlpStatus struct {
+		Code    int    `json:"code,omitempty"`
+		Message string `json:"message,omitempty"`
+	}
+	otlpKeyValue struct {
+		Key   string         `json:"key"`
+		Value map[string]any `json:"value"`
+	}
+)
+
+// OTLP enum values
+const (
+	spanKindInternal = 1
+	statusError      = 2
+)
+
+// request builds the OTLP request of a batch
+func (e *exporter) request(batch []*Span) otlpRequest {
+	resource := []otlpKeyValue{keyValue(String("service.name", e.config.ServiceName))}
+	if e.config.Version != "" {
+		resource = append(resource, keyValue(String("service.version", e.config.Version)))
+	}
+
+	spans := make([]otlpSpan, len(batch))
+	for i, s := range batch {
+		s.mu.Lock()
+		span := otlpSpan{
+			TraceID:           hex.EncodeToString(s.traceID[:]),
+			SpanID:            hex.EncodeToString(s.spanID[:]),
+			Name:              s.name,
+			Kind:              spanKindInternal,
+			StartTimeUnixNano: strconv.FormatInt(s.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(s.end.UnixNano(), 10),
+		}
+		if s.parentID != [8]byte{} {
+			span.ParentSpanID = hex.EncodeToString(s.parentID[:])
+		}
+		for _, a := range s.attrs {
+			span.Attributes = append(span.Attributes, keyValue(a))
+		}
+		if s.err != nil {
+			span.Status = otlpStatus{Code: statusError, Message: s.err.Error()}
+		}
+		s.mu.Unlock()
+		spans[i] = span
+	}
+
+	return otlpRequest{ResourceSpans: []otlpResourceSpans{{
+		Resource:   otlpResource{Attributes: resource},
+		ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: "adgo", Version: e.config.Version}, Spans: spans}},
+	}}}
+}
+
+// keyValue converts an attribute to its OTLP form; 64-bit integers are strings in OTLP JSON
+func keyValue(a Attr) otlpKeyValue {
+	var v map[string]any
+	switch x := a.Value.(type) {
+	case string:
+		v = map[string]any{"stringValue": x}
+	case bool:
+		v = map[string]any{"boolValue": x}
+	case int64:
+		v = map[string]any{"intValue": strconv.FormatInt(x, 10)}
+	case int:
+		v = map[string]any{"intValue": strconv.Itoa(x)}
+	case float64:
+		v = map[string]any{"doubleValue": x}
+	default:
+		v = map[string]any{"stringValue": fmt.Sprint(x)}
+	}
+	return otlpKeyValue{Key: a.Key, Value: v}
+}
@@ -0,0 +1,114 @@
+// Package tracing records spans of query execution and exports them to an
+// OpenTelemetry collector with OTLP over HTTP (JSON encoding). Without a
+// configured exporter, spans cost almost nothing and are dropped.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"sync"
+	"time"
+)
+
+// Attr is a span attribute
+type Attr struct {
+	Key   string
+	Value any // string, bool, int, int64 or float64
+}
+
+// String returns a string attribute
+func String(key, value string) Attr { return Attr{Key: key, Value: value} }
+
+// Int returns an integer attribute
+func Int(key string, value int) Attr { return Attr{Key: key, Value: int64(value)} }
+
+// Bool returns a boolean attribute
+func Bool(key string, value bool) Attr { return Attr{Key: key, Value: value} }
+
+// Span is a timed operation. A nil *Span is valid and does nothing, so
+// callers need not check whether tracing is enabled.
+type Span struct {
+	exporter *exporter
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	start    time.Time
+
+	mu    sync.Mutex
+	end   time.Time
+	attrs []Attr
+	err   error
+}
+
+// spanKey carries the current span in a context
+type spanKey struct{}
+
+// Start starts a span named name as a child of the span in ctx and returns
+// a context carrying it. It returns ctx and a nil span when tracing is off.
+func Start(ctx context.Context, name string, attrs ...Attr) (context.Context, *Span) {
+	exp := current()
+	if exp == nil {
+		return ctx, nil
+	}
+
+	s := &Span{exporter: exp, name: name, start: time.Now(), attrs: attrs}
+	if parent := FromContext(ctx); parent != nil {
+		s.traceID, s.parentID = parent.traceID, parent.spanID
+	} else {
+		rand.Read(s.traceID[:])
+	}
+	rand.Read(s.spanID[:])
+	return context.WithValue(ctx, spanKey{}, s), s
+}
+
+// FromContext returns the span in ctx, or nil
+func FromContext(ctx context.Context) *Span {
+	s, _ := ctx.Value(spanKey{}).(*Span)
+	return s
+}
+
+// TraceID returns the hex trace ID of the span in ctx, or "" without one
+func TraceID(ctx context.Context) string {
+	s := FromContext(ctx)
+	if s == nil {
+		return ""
+	}
+	return hex.EncodeToString(s.traceID[:])
+}
+
+// SetAttributes adds attributes to the span
+func (s *Span) SetAttributes(attrs ...Attr) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.attrs = append(s.attrs, attrs...)
+}
+
+// SetError marks the span as failed with err; nil leaves it unchanged
+func (s *Span) SetError(err error) {
+	if s == nil || err == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.err = err
+}
+
+// End ends the span and queues it for export. Later calls do nothing.
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	if !s.end.IsZero() {
+		s.mu.Unlock()
+		return
+	}
+	s.end = time.Now()
+	s.mu.Unlock()
+	s.exporter.add(s)
+}